@@ -0,0 +1,38 @@
+package action
+
+import (
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+// AbortOpts are the options used for aborting experiments
+type AbortOpts struct {
+	// KubeDriver enables access to Kubernetes cluster
+	*driver.KubeDriver
+}
+
+// NewAbortOpts initializes and returns abort opts
+func NewAbortOpts(kd *driver.KubeDriver) *AbortOpts {
+	return &AbortOpts{
+		KubeDriver: kd,
+	}
+}
+
+// KubeRun aborts a Kubernetes experiment.
+// The experiment stops after its current task; its partial results are preserved for reporting.
+func (aOpts *AbortOpts) KubeRun() error {
+	if err := aOpts.KubeDriver.Init(); err != nil {
+		return err
+	}
+
+	exp, err := base.BuildExperiment(aOpts.KubeDriver)
+	if err != nil {
+		return err
+	}
+	if exp.Completed() || exp.Aborted() || !exp.NoFailure() {
+		log.Logger.Infof("experiment group %v is not running; state: %v", aOpts.Group, experimentState(exp))
+		return nil
+	}
+	return aOpts.Abort()
+}
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestKubeAbort(t *testing.T) {
+	os.Chdir(t.TempDir())
+
+	// fix lOpts
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoDownload = true
+	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
+	assert.NoError(t, lOpts.KubeRun())
+
+	// a running experiment with one completed task
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", driver.ExperimentPath))
+	exp, err := driver.ExperimentFromBytes(byteArray)
+	assert.NoError(t, err)
+	exp.Result.NumCompletedTasks = 1
+	byteArray, _ = yaml.Marshal(exp)
+	lOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+
+	aOpts := NewAbortOpts(lOpts.KubeDriver)
+	assert.False(t, aOpts.AbortRequested())
+	assert.NoError(t, aOpts.KubeRun())
+	assert.True(t, aOpts.AbortRequested())
+
+	// the abort request is preserved when the running experiment writes its result
+	assert.NoError(t, aOpts.Write(exp))
+	assert.True(t, aOpts.AbortRequested())
+}
@@ -0,0 +1,459 @@
+package action
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ExperimentsPath is the path of the experiments API
+	ExperimentsPath = "/experiments"
+	// maxSpecBytes is the maximum size of submitted experiments
+	maxSpecBytes = 1 << 20
+	// maxRetainedExperiments is the maximum number of experiments kept in memory;
+	// the oldest experiments that are done are removed to make room for new ones
+	maxRetainedExperiments = 200
+	// maxLogBytes is the maximum size of the logs kept in memory for each experiment
+	maxLogBytes = 1 << 20
+
+	// QueuedState is the state of a submitted experiment that is waiting to run
+	QueuedState = "queued"
+	// RunningState is the state of a running experiment
+	RunningState = "running"
+	// DoneState is the state of an experiment that has ended
+	DoneState = "done"
+)
+
+// DefaultAPITasks are the tasks that experiments submitted to the experiments API may use by default.
+// Tasks that run scripts or executables on the server, such as run tasks, background tasks, and plugins,
+// are not among them.
+var DefaultAPITasks = []string{
+	base.AssessTaskName,
+	base.CollectGRPCTaskName,
+	base.CollectHTTPTaskName,
+	base.CompareTaskName,
+}
+
+// ExperimentStatus is the status of an experiment submitted to the experiments API
+type ExperimentStatus struct {
+	// ID of the experiment
+	ID string `json:"id"`
+	// State of the experiment; queued, running, or done
+	State string `json:"state"`
+	// SubmitTime is the time when the experiment was submitted
+	SubmitTime time.Time `json:"submitTime"`
+	// Error is the error with which the experiment ended, if any
+	Error string `json:"error,omitempty"`
+	// Verdict of the experiment so far
+	Verdict *Verdict `json:"verdict,omitempty"`
+	// Experiment is the experiment, including its result so far
+	Experiment json.RawMessage `json:"experiment,omitempty"`
+}
+
+// ExperimentAPI runs experiments submitted over HTTP, and serves their status, insights, and logs.
+// Experiments run one at a time, in the order in which they are submitted; this enables the logs of each experiment
+// to be captured. Experiments and their logs are kept in memory, up to a limit.
+// Requests must present the token of the API as a bearer token, and experiments may only use the allowed tasks,
+// without inputs that name local files.
+type ExperimentAPI struct {
+	mu sync.Mutex
+	// token that authenticates requests
+	token string
+	// tasks that submitted experiments may use
+	tasks map[string]bool
+	// experiments by ID
+	experiments map[string]*apiExperiment
+	// order is the list of experiment IDs in the order of submission
+	order []string
+	// queue of experiments waiting to run
+	queue chan *apiExperiment
+}
+
+// apiExperiment is an experiment submitted to the experiments API.
+// It is the driver of the experiment while it runs, and a log hook that captures its logs.
+type apiExperiment struct {
+	mu sync.Mutex
+	// id of the experiment
+	id string
+	// submitTime is the time when the experiment was submitted
+	submitTime time.Time
+	// exp is the submitted experiment
+	exp *base.Experiment
+	// state of the experiment
+	state string
+	// err is the error with which the experiment ended
+	err error
+	// snapshot is the JSON form of the experiment written by its latest update
+	snapshot []byte
+	// verdict of the experiment as of its latest update
+	verdict *Verdict
+	// logs of the experiment
+	logs bytes.Buffer
+	// logsTruncated is true if logs were dropped after the logs reached their maximum size
+	logsTruncated bool
+	// abort is true if the experiment has been asked to stop
+	abort bool
+	// done is closed when the experiment ends
+	done chan struct{}
+}
+
+// NewExperimentAPI returns an experiments API that authenticates requests using the given token,
+// and runs experiments that use the given tasks; it starts running submitted experiments
+func NewExperimentAPI(token string, tasks []string) (*ExperimentAPI, error) {
+	if token == "" {
+		return nil, errors.New("experiments API requires a token")
+	}
+	a := &ExperimentAPI{
+		token:       token,
+		tasks:       map[string]bool{},
+		experiments: map[string]*apiExperiment{},
+		queue:       make(chan *apiExperiment, 100),
+	}
+	for _, t := range tasks {
+		a.tasks[t] = true
+	}
+	go a.runQueue()
+	return a, nil
+}
+
+// Register adds the handlers of the experiments API to the mux
+func (a *ExperimentAPI) Register(mux *http.ServeMux) {
+	mux.HandleFunc(ExperimentsPath, a.authenticate(a.handleExperiments))
+	mux.HandleFunc(ExperimentsPath+"/", a.authenticate(a.handleExperiment))
+}
+
+// authenticate responds with status 401 to requests without the bearer token of the API
+func (a *ExperimentAPI) authenticate(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// Levels are the log levels captured in experiment logs
+func (e *apiExperiment) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire captures a log entry in the logs of the experiment
+func (e *apiExperiment) Fire(entry *logrus.Entry) error {
+	line, err := entry.Logger.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.logs.Len()+len(line) > maxLogBytes {
+		if !e.logsTruncated {
+			e.logs.WriteString("... logs truncated\n")
+			e.logsTruncated = true
+		}
+		return nil
+	}
+	e.logs.Write(line)
+	return nil
+}
+
+// runQueue runs submitted experiments one at a time
+func (a *ExperimentAPI) runQueue() {
+	for e := range a.queue {
+		e.mu.Lock()
+		if e.state != QueuedState {
+			e.mu.Unlock()
+			continue
+		}
+		e.state = RunningState
+		e.mu.Unlock()
+
+		// logs are captured only while the experiment runs
+		remove := log.Logger.AddHook(e)
+		err := base.RunExperiment(false, e)
+		remove()
+
+		e.end(err)
+	}
+}
+
+// end marks the experiment as done
+func (e *apiExperiment) end(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state = DoneState
+	e.err = err
+	close(e.done)
+}
+
+// Read the experiment
+func (e *apiExperiment) Read() (*base.Experiment, error) {
+	return e.exp, nil
+}
+
+// Write records a snapshot of the experiment, which is served while the experiment runs
+func (e *apiExperiment) Write(exp *base.Experiment) error {
+	b, err := json.Marshal(exp)
+	if err != nil {
+		return err
+	}
+	v := GetVerdict(exp)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.snapshot = b
+	e.verdict = v
+	return nil
+}
+
+// GetRevision returns the experiment revision; submitted experiments have a single revision
+func (e *apiExperiment) GetRevision() int {
+	return 0
+}
+
+// AbortRequested returns true if the experiment has been asked to stop
+func (e *apiExperiment) AbortRequested() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.abort
+}
+
+// status returns the status of the experiment
+func (e *apiExperiment) status(withExperiment bool) *ExperimentStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := &ExperimentStatus{
+		ID:         e.id,
+		State:      e.state,
+		SubmitTime: e.submitTime,
+		Verdict:    e.verdict,
+	}
+	if e.err != nil {
+		s.Error = e.err.Error()
+	}
+	if withExperiment {
+		s.Experiment = e.snapshot
+	}
+	return s
+}
+
+// handleExperiments lists experiments, or submits an experiment
+func (a *ExperimentAPI) handleExperiments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a.mu.Lock()
+		statuses := []*ExperimentStatus{}
+		for _, id := range a.order {
+			statuses = append(statuses, a.experiments[id].status(false))
+		}
+		a.mu.Unlock()
+		writeJSON(w, http.StatusOK, statuses)
+	case http.MethodPost:
+		e, err := a.submit(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, e.status(false))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// submit reads an experiment from the request in YAML or JSON form, validates it, and queues it to run
+func (a *ExperimentAPI) submit(r *http.Request) (*apiExperiment, error) {
+	b, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxSpecBytes))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read experiment: %v", err)
+	}
+	exp := &base.Experiment{}
+	if err := yaml.Unmarshal(b, exp); err != nil {
+		return nil, fmt.Errorf("invalid experiment: %v", err)
+	}
+	if len(exp.Spec) == 0 {
+		return nil, errors.New("experiment has no tasks")
+	}
+	if errs := base.ValidateExperiment(exp); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid experiment: %v", errs)
+	}
+	for _, name := range base.TaskNames(exp) {
+		if !a.tasks[name] {
+			return nil, fmt.Errorf("task %v is not allowed by the experiments API", name)
+		}
+	}
+	// local files would be read on the server
+	if inputs := base.LocalFileInputs(exp); len(inputs) > 0 {
+		return nil, fmt.Errorf("inputs %v name local files, which are not allowed by the experiments API", inputs)
+	}
+	id, err := newExperimentID()
+	if err != nil {
+		return nil, err
+	}
+	e := &apiExperiment{
+		id:         id,
+		submitTime: time.Now(),
+		exp:        exp,
+		state:      QueuedState,
+		done:       make(chan struct{}),
+	}
+	if err := e.Write(exp); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.evict() {
+		return nil, errors.New("too many experiments are kept")
+	}
+	select {
+	case a.queue <- e:
+	default:
+		return nil, errors.New("too many experiments are queued")
+	}
+	a.experiments[id] = e
+	a.order = append(a.order, id)
+	return e, nil
+}
+
+// evict removes the oldest experiments that are done, until there is room for another experiment.
+// It returns false if there is no room; the caller must hold the lock of the API.
+func (a *ExperimentAPI) evict() bool {
+	for i := 0; len(a.order) >= maxRetainedExperiments && i < len(a.order); {
+		id := a.order[i]
+		e := a.experiments[id]
+		e.mu.Lock()
+		done := e.state == DoneState
+		e.mu.Unlock()
+		if !done {
+			i++
+			continue
+		}
+		delete(a.experiments, id)
+		a.order = append(a.order[:i], a.order[i+1:]...)
+	}
+	return len(a.order) < maxRetainedExperiments
+}
+
+// handleExperiment serves the status, insights, or logs of an experiment, or stops it.
+// Paths are of the form /experiments/<id>, /experiments/<id>/insights, and /experiments/<id>/logs.
+func (a *ExperimentAPI) handleExperiment(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, ExperimentsPath+"/"), "/")
+	a.mu.Lock()
+	e, ok := a.experiments[parts[0]]
+	a.mu.Unlock()
+	if !ok || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	resource := ""
+	if len(parts) == 2 {
+		resource = parts[1]
+	}
+	switch {
+	case resource == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, e.status(true))
+	case resource == "" && r.Method == http.MethodDelete:
+		e.stop()
+		writeJSON(w, http.StatusAccepted, e.status(false))
+	case resource == "insights" && r.Method == http.MethodGet:
+		e.mu.Lock()
+		snapshot := e.snapshot
+		e.mu.Unlock()
+		exp := &base.Experiment{}
+		if err := json.Unmarshal(snapshot, exp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if exp.Result == nil || exp.Result.Insights == nil {
+			http.Error(w, "experiment has no insights yet", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, exp.Result.Insights)
+	case resource == "logs" && r.Method == http.MethodGet:
+		e.streamLogs(w, r.URL.Query().Get("follow") == "true")
+	case resource == "" || resource == "insights" || resource == "logs":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// stop asks a running experiment to stop, or removes a queued experiment from the queue
+func (e *apiExperiment) stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch e.state {
+	case QueuedState:
+		e.state = DoneState
+		e.err = errors.New("experiment deleted before it ran")
+		close(e.done)
+	case RunningState:
+		e.abort = true
+	}
+}
+
+// streamLogs writes the logs of the experiment. With follow, logs are streamed until the experiment ends.
+func (e *apiExperiment) streamLogs(w http.ResponseWriter, follow bool) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	flusher, _ := w.(http.Flusher)
+	written := 0
+	for {
+		e.mu.Lock()
+		logs := e.logs.Bytes()[written:]
+		chunk := make([]byte, len(logs))
+		copy(chunk, logs)
+		e.mu.Unlock()
+		if len(chunk) > 0 {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			written += len(chunk)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if !follow {
+			return
+		}
+		select {
+		case <-e.done:
+			// write logs captured after the last read
+			follow = false
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// newExperimentID returns a random experiment ID
+func newExperimentID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeJSON writes the value as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
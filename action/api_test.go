@@ -0,0 +1,218 @@
+package action
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAPIToken is the token of the experiments API in tests
+const testAPIToken = "test-token"
+
+// newTestAPIServer starts a server with an experiments API that allows the given tasks
+func newTestAPIServer(t *testing.T, tasks ...string) *httptest.Server {
+	a, err := NewExperimentAPI(testAPIToken, tasks)
+	assert.NoError(t, err)
+	mux := http.NewServeMux()
+	a.Register(mux)
+	return httptest.NewServer(mux)
+}
+
+// apiRequest sends a request with the token of the experiments API
+func apiRequest(t *testing.T, method string, url string, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testAPIToken)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+// submitExperiment submits the experiment to the API, and returns its status
+func submitExperiment(t *testing.T, url string, spec string) *ExperimentStatus {
+	resp := apiRequest(t, http.MethodPost, url+ExperimentsPath, spec)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	s := &ExperimentStatus{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(s))
+	return s
+}
+
+func TestExperimentAPI(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := newTestAPIServer(t, base.RunTaskName, base.AssessTaskName)
+	defer srv.Close()
+
+	s := submitExperiment(t, srv.URL, `
+spec:
+- run: |
+    echo '{"name": "throughput", "value": 100}'
+  with:
+    metrics: true
+- task: assess
+  with:
+    SLOs:
+      lower:
+      - metric: run/throughput
+        limit: 50
+`)
+	assert.NotEmpty(t, s.ID)
+
+	// logs are streamed until the experiment ends
+	resp := apiRequest(t, http.MethodGet, srv.URL+ExperimentsPath+"/"+s.ID+"/logs?follow=true", "")
+	logs, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Contains(t, string(logs), "task 1: run : completed")
+
+	resp = apiRequest(t, http.MethodGet, srv.URL+ExperimentsPath+"/"+s.ID, "")
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(s))
+	resp.Body.Close()
+	assert.Equal(t, DoneState, s.State)
+	assert.Empty(t, s.Error)
+	assert.True(t, s.Verdict.Pass)
+	exp := &base.Experiment{}
+	assert.NoError(t, json.Unmarshal(s.Experiment, exp))
+	assert.True(t, exp.Completed())
+
+	resp = apiRequest(t, http.MethodGet, srv.URL+ExperimentsPath+"/"+s.ID+"/insights", "")
+	in := &base.Insights{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(in))
+	resp.Body.Close()
+	assert.Equal(t, []float64{100}, in.NonHistMetricValues[0]["run/throughput"])
+
+	resp = apiRequest(t, http.MethodGet, srv.URL+ExperimentsPath, "")
+	statuses := []ExperimentStatus{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&statuses))
+	resp.Body.Close()
+	assert.Equal(t, 1, len(statuses))
+	assert.Nil(t, statuses[0].Experiment)
+
+	// invalid experiments are rejected
+	for _, spec := range []string{"spec: []", "spec:\n- task: unknown", "spec:\n- run: echo hi\n  timeout: soon"} {
+		resp = apiRequest(t, http.MethodPost, srv.URL+ExperimentsPath, spec)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, spec)
+	}
+
+	// unknown experiments are not found
+	for _, path := range []string{"/missing", "/" + s.ID + "/unknown"} {
+		resp = apiRequest(t, http.MethodGet, srv.URL+ExperimentsPath+path, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+}
+
+func TestExperimentAPIStop(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := newTestAPIServer(t, base.RunTaskName, base.AssessTaskName)
+	defer srv.Close()
+
+	running := submitExperiment(t, srv.URL, "spec:\n- run: sleep 1\n- run: echo second\n")
+	queued := submitExperiment(t, srv.URL, "spec:\n- run: echo queued\n")
+	for _, id := range []string{queued.ID, running.ID} {
+		resp := apiRequest(t, http.MethodDelete, srv.URL+ExperimentsPath+"/"+id, "")
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+
+	s := &ExperimentStatus{}
+	assert.Eventually(t, func() bool {
+		resp := apiRequest(t, http.MethodGet, srv.URL+ExperimentsPath+"/"+running.ID, "")
+		defer resp.Body.Close()
+		return json.NewDecoder(resp.Body).Decode(s) == nil && s.State == DoneState
+	}, 10*time.Second, 100*time.Millisecond)
+	exp := &base.Experiment{}
+	assert.NoError(t, json.Unmarshal(s.Experiment, exp))
+	assert.True(t, exp.Aborted())
+
+	resp := apiRequest(t, http.MethodGet, srv.URL+ExperimentsPath+"/"+queued.ID, "")
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(s))
+	assert.Equal(t, DoneState, s.State)
+	assert.NotEmpty(t, s.Error)
+}
+
+func TestExperimentAPIAuthentication(t *testing.T) {
+	_, err := NewExperimentAPI("", DefaultAPITasks)
+	assert.Error(t, err)
+
+	srv := newTestAPIServer(t, DefaultAPITasks...)
+	defer srv.Close()
+	for _, auth := range []string{"", "Bearer wrong-token", testAPIToken} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+ExperimentsPath, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, auth)
+	}
+}
+
+func TestExperimentAPITasks(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := newTestAPIServer(t, base.AssessTaskName, base.ParallelTaskName)
+	defer srv.Close()
+
+	// tasks that run scripts are not allowed by default, wherever they appear
+	for _, spec := range []string{
+		"spec:\n- run: echo hi",
+		"spec:\n- task: assess\nhooks:\n  onStart:\n  - run: echo hi",
+		"spec:\n- task: parallel\n  with:\n    branches:\n    - - run: echo hi",
+		"spec:\n- task: assess\nbackground:\n- name: b\n  run: echo hi",
+	} {
+		resp := apiRequest(t, http.MethodPost, srv.URL+ExperimentsPath, spec)
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, spec)
+		assert.Contains(t, string(b), "task run is not allowed", spec)
+	}
+
+	// inputs that name local files are not allowed, even for allowed tasks
+	srv = newTestAPIServer(t, DefaultAPITasks...)
+	defer srv.Close()
+	for spec, input := range map[string]string{
+		"spec:\n- task: http\n  with:\n    url: http://localhost\n    payloadFile: /etc/passwd":                                                "http/payloadFile",
+		"spec:\n- task: http\n  with:\n    url: http://localhost\n    mirror:\n      source: /etc/passwd\n      baselineURL: http://localhost": "http/mirror.source",
+		"spec:\n- task: grpc\n  with:\n    host: localhost:50051\n    call: a.b.C\n    proto: /etc/passwd":                                     "grpc/proto",
+	} {
+		resp := apiRequest(t, http.MethodPost, srv.URL+ExperimentsPath, spec)
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, spec)
+		assert.Contains(t, string(b), input, spec)
+	}
+}
+
+func TestExperimentAPIEvict(t *testing.T) {
+	a := &ExperimentAPI{experiments: map[string]*apiExperiment{}}
+	for i := 0; i < maxRetainedExperiments; i++ {
+		id := strconv.Itoa(i)
+		state := DoneState
+		if i == 0 {
+			state = RunningState
+		}
+		a.experiments[id] = &apiExperiment{id: id, state: state}
+		a.order = append(a.order, id)
+	}
+
+	// the oldest experiment that is done makes room for another one
+	assert.True(t, a.evict())
+	assert.Equal(t, maxRetainedExperiments-1, len(a.order))
+	assert.Equal(t, []string{"0", "2"}, a.order[:2])
+	assert.NotContains(t, a.experiments, "1")
+
+	// there is no room when no experiment is done
+	for _, e := range a.experiments {
+		e.state = QueuedState
+	}
+	a.order = append(a.order, "1")
+	a.experiments["1"] = &apiExperiment{id: "1", state: QueuedState}
+	assert.False(t, a.evict())
+}
@@ -1,11 +1,17 @@
 package action
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/antonmedv/expr"
 	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
 )
@@ -17,56 +23,218 @@ const (
 	NoFailure = "nofailure"
 	// SLOs states that all app versions participating in the experiment satisfy SLOs
 	SLOs = "slos"
+	// SLOsBy states that the given app version satisfies SLOs; used as slosby=<version>
+	SLOsBy = "slosby"
+	// Winner states that the given app version is the winner, which is the latest version that satisfies SLOs; used as winner=<version>
+	Winner = "winner"
+	// Loops is the number of experiment loops; used in comparisons such as loops>=3
+	Loops = "loops"
+
+	// JSONOutputFormatKey is the output format used to create JSON output
+	JSONOutputFormatKey = "json"
 )
 
 // AssertOpts are the options used for asserting experiment results
 type AssertOpts struct {
 	// Timeout is the duration to wait for conditions to be satisfied
 	Timeout time.Duration
+	// ConditionTimeouts are the durations to wait for specific conditions to be satisfied (e.g., slos: 10m).
+	// Conditions that are not listed here use Timeout.
+	ConditionTimeouts map[string]string
 	// Conditions are checked by assert
 	Conditions []string
+	// OutputFormat is the format of the assert result; text or json.
+	// Text results are logged; JSON results are written to the output.
+	OutputFormat string
 	// RunOpts provides options relating to experiment resources
 	RunOpts
+	// result is the result of the last assert
+	result *AssertResult
 }
 
 // NewAssertOpts initializes and returns assert opts
 func NewAssertOpts(kd *driver.KubeDriver) *AssertOpts {
 	return &AssertOpts{
-		RunOpts: *NewRunOpts(kd),
+		OutputFormat: TextOutputFormatKey,
+		RunOpts:      *NewRunOpts(kd),
 	}
 }
 
+// ConditionResult records whether an assert condition is satisfied
+type ConditionResult struct {
+	// Condition is the assert condition
+	Condition string `json:"condition"`
+	// Satisfied is true if the condition is satisfied
+	Satisfied bool `json:"satisfied"`
+}
+
+// SLOViolation records an SLO that is not satisfied by an app version
+type SLOViolation struct {
+	// Metric is the SLO metric
+	Metric string `json:"metric"`
+	// Type is upper or lower
+	Type string `json:"type"`
+	// Limit is the SLO limit
+	Limit float64 `json:"limit"`
+	// Version is the app version that violates the SLO
+	Version int `json:"version"`
+	// Value is the metric value for the version; nil if unavailable
+	Value *float64 `json:"value"`
+	// Excess is the amount by which the value exceeds the limit; nil if the value is unavailable
+	Excess *float64 `json:"excess"`
+}
+
+// AssertResult is the detailed result of assert
+type AssertResult struct {
+	// Satisfied is true if all conditions are satisfied
+	Satisfied bool `json:"satisfied"`
+	// Conditions records the result for each condition
+	Conditions []ConditionResult `json:"conditions"`
+	// SLOViolations lists the SLOs that are not satisfied, when the slos condition is asserted
+	SLOViolations []SLOViolation `json:"sloViolations,omitempty"`
+	// ExitCode classifies the reason why conditions are not satisfied; see ExitCodeSLOViolation and related exit codes
+	ExitCode int `json:"exitCode"`
+}
+
 // LocalRun asserts conditions for a local experiment
-func (aOpts *AssertOpts) LocalRun() (bool, error) {
-	return aOpts.Run(&driver.FileDriver{
-		RunDir: aOpts.RunDir,
-	})
+func (aOpts *AssertOpts) LocalRun(out io.Writer) (bool, error) {
+	fd, err := aOpts.localDriver()
+	if err != nil {
+		return false, err
+	}
+	return aOpts.Run(fd, out)
 }
 
 // LocalRun asserts conditions for a Kubernetes experiment
-func (aOpts *AssertOpts) KubeRun() (bool, error) {
+func (aOpts *AssertOpts) KubeRun(out io.Writer) (bool, error) {
 	if err := aOpts.KubeDriver.Init(); err != nil {
 		return false, err
 	}
 
-	return aOpts.Run(aOpts.KubeDriver)
+	return aOpts.Run(aOpts.KubeDriver, out)
 }
 
 // Run builds the experiment and verifies assert conditions
-func (assert *AssertOpts) Run(eio base.Driver) (bool, error) {
-	allGood, err := assert.verify(eio)
+func (assert *AssertOpts) Run(eio base.Driver, out io.Writer) (bool, error) {
+	result, err := assert.verify(eio)
 	if err != nil {
 		return false, err
 	}
-	if !allGood {
+	assert.result = result
+	switch strings.ToLower(assert.OutputFormat) {
+	case TextOutputFormatKey, "":
+	case JSONOutputFormatKey:
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			e := errors.New("unable to marshal assert result")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return false, e
+		}
+		fmt.Fprintln(out, string(b))
+	default:
+		e := fmt.Errorf("unsupported assert output format %v", assert.OutputFormat)
+		log.Logger.Error(e)
+		return false, e
+	}
+	if !result.Satisfied {
 		log.Logger.Error("assert conditions failed")
 		return false, nil
 	}
 	return true, nil
 }
 
+// Failure returns the error for the conditions that were not satisfied in the last assert,
+// along with the exit code that classifies the failure
+func (assert *AssertOpts) Failure() error {
+	code := ExitCodeError
+	if assert.result != nil && assert.result.ExitCode != ExitCodeSuccess {
+		code = assert.result.ExitCode
+	}
+	// the error is of the kind of failure, so that its remediation hint is shown
+	var err error = errors.New("assert conditions failed")
+	switch code {
+	case ExitCodeTaskFailure:
+		err = ierrors.New(ierrors.ErrTaskFailed, "assert conditions failed")
+	case ExitCodeSLOViolation:
+		err = ierrors.New(ierrors.ErrSLOViolated, "assert conditions failed")
+	case ExitCodeTimeout:
+		err = ierrors.New(ierrors.ErrTimeout, "assert conditions failed")
+	}
+	return &ExitError{
+		Code: code,
+		Err:  err,
+	}
+}
+
+// failureExitCode classifies the reason why assert conditions are not satisfied
+func failureExitCode(exp *base.Experiment) int {
+	switch {
+	case !exp.NoFailure():
+		return ExitCodeTaskFailure
+	case !exp.Completed():
+		return ExitCodeTimeout
+	case !exp.SLOs():
+		return ExitCodeSLOViolation
+	default:
+		return ExitCodeError
+	}
+}
+
+// timeouts returns the duration to wait for each condition to be satisfied
+func (assert *AssertOpts) timeouts() (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	for _, cond := range assert.Conditions {
+		timeouts[cond] = assert.Timeout
+	}
+	for cond, t := range assert.ConditionTimeouts {
+		found := false
+		for _, c := range assert.Conditions {
+			if strings.EqualFold(c, cond) {
+				found = true
+				d, err := time.ParseDuration(t)
+				if err != nil {
+					e := fmt.Errorf("invalid timeout %v for condition %v", t, cond)
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return nil, e
+				}
+				timeouts[c] = d
+			}
+		}
+		if !found {
+			e := fmt.Errorf("timeout specified for condition %v which is not asserted", cond)
+			log.Logger.Error(e)
+			return nil, e
+		}
+	}
+	return timeouts, nil
+}
+
+// logProgress logs the progress of the experiment while assert waits for conditions to be satisfied
+func logProgress(exp *base.Experiment) {
+	if exp.Result == nil {
+		log.Logger.Info("waiting for experiment to start")
+		return
+	}
+	msg := fmt.Sprintf("experiment progress: %v of %v tasks completed; loop %v", exp.Result.NumCompletedTasks, len(exp.Spec), exp.Result.NumLoops)
+	if in := exp.Result.Insights; in != nil && in.SLOs != nil {
+		versions := []int{}
+		for j := 0; j < in.NumVersions; j++ {
+			if exp.SLOsBy(j) {
+				versions = append(versions, j)
+			}
+		}
+		msg += fmt.Sprintf("; versions satisfying SLOs so far: %v of %v %v", len(versions), in.NumVersions, versions)
+	}
+	log.Logger.Info(msg)
+}
+
 // verify implements the core logic of assert
-func (assert *AssertOpts) verify(eio base.Driver) (bool, error) {
+func (assert *AssertOpts) verify(eio base.Driver) (*AssertResult, error) {
+	timeouts, err := assert.timeouts()
+	if err != nil {
+		return nil, err
+	}
+
 	// timeSpent tracks how much time has been spent so far in assert attempts
 	var timeSpent, _ = time.ParseDuration("0s")
 
@@ -77,55 +245,155 @@ func (assert *AssertOpts) verify(eio base.Driver) (bool, error) {
 	for {
 		exp, err := base.BuildExperiment(eio)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
 
-		allGood := true
+		result := &AssertResult{
+			Satisfied:  true,
+			Conditions: []ConditionResult{},
+		}
 
 		for _, cond := range assert.Conditions {
+			var c bool
 			if strings.ToLower(cond) == Completed {
-				c := exp.Completed()
-				allGood = allGood && c
+				c = exp.Completed()
 				if c {
 					log.Logger.Info("experiment completed")
 				} else {
 					log.Logger.Info("experiment did not complete")
 				}
 			} else if strings.ToLower(cond) == NoFailure {
-				nf := exp.NoFailure()
-				allGood = allGood && nf
-				if nf {
+				c = exp.NoFailure()
+				if c {
 					log.Logger.Info("experiment has no failure")
 				} else {
 					log.Logger.Info("experiment failed")
 				}
 			} else if strings.ToLower(cond) == SLOs {
-				slos := exp.SLOs()
-				allGood = allGood && slos
-				if slos {
+				c = exp.SLOs()
+				if c {
 					log.Logger.Info("SLOs are satisfied")
 				} else {
 					log.Logger.Info("SLOs are not satisfied")
+					result.SLOViolations = sloViolations(exp)
 				}
 			} else {
-				log.Logger.Error("unsupported assert condition detected; ", cond)
-				return false, fmt.Errorf("unsupported assert condition detected; %v", cond)
+				c, err = evalCondition(exp, cond)
+				if err != nil {
+					log.Logger.WithStackTrace(err.Error()).Error("unsupported assert condition detected; ", cond)
+					return nil, fmt.Errorf("unsupported assert condition detected; %v", cond)
+				}
+				if c {
+					log.Logger.Infof("condition %v is satisfied", cond)
+				} else {
+					log.Logger.Infof("condition %v is not satisfied", cond)
+				}
 			}
+			result.Satisfied = result.Satisfied && c
+			result.Conditions = append(result.Conditions, ConditionResult{
+				Condition: cond,
+				Satisfied: c,
+			})
 		}
 
-		if allGood {
+		if result.Satisfied {
 			log.Logger.Info("all conditions were satisfied")
-			return true, nil
-		} else {
-			if timeSpent >= assert.Timeout {
+			return result, nil
+		}
+
+		// stop waiting as soon as any unsatisfied condition runs out of time
+		for _, cr := range result.Conditions {
+			if !cr.Satisfied && timeSpent >= timeouts[cr.Condition] {
+				log.Logger.Infof("condition %v was not satisfied within %v", cr.Condition, timeouts[cr.Condition])
 				log.Logger.Info("not all conditions were satisfied")
-				return false, nil
-			} else {
-				log.Logger.Infof("sleeping %v ................................", sleepTime)
-				time.Sleep(sleepTime)
-				timeSpent += sleepTime
+				result.ExitCode = failureExitCode(exp)
+				return result, nil
 			}
 		}
+
+		logProgress(exp)
+		log.Logger.Infof("sleeping %v ................................", sleepTime)
+		time.Sleep(sleepTime)
+		timeSpent += sleepTime
+	}
+
+}
+
+// slosByPattern matches the slosby=<version> condition
+var slosByPattern = regexp.MustCompile(SLOsBy + `\s*=\s*(\d+)`)
+
+// winnerPattern matches the winner=<version> condition
+var winnerPattern = regexp.MustCompile(Winner + `\s*=\s*(\d+)`)
+
+// evalCondition evaluates a condition expression.
+// Expressions may use completed, nofailure, slos, loops, slosby=<version>, and winner=<version>,
+// combined using comparison and boolean operators; for example, slosby=1 && loops >= 3.
+func evalCondition(exp *base.Experiment, cond string) (bool, error) {
+	loops := 0
+	if exp.Result != nil {
+		loops = exp.Result.NumLoops
+	}
+	env := map[string]interface{}{
+		Completed: exp.Completed(),
+		NoFailure: exp.NoFailure(),
+		SLOs:      exp.SLOs(),
+		Loops:     loops,
+		SLOsBy:    exp.SLOsBy,
+		Winner:    exp.Winner(),
+	}
+	cond = slosByPattern.ReplaceAllString(cond, SLOsBy+"($1)")
+	cond = winnerPattern.ReplaceAllString(cond, Winner+" == $1")
+	program, err := expr.Compile(cond, expr.Env(env), expr.AsBool())
+	if err != nil {
+		return false, err
 	}
+	output, err := expr.Run(program, env)
+	if err != nil {
+		return false, err
+	}
+	return output.(bool), nil
+}
 
+// sloViolations returns the SLOs that are not satisfied by each app version
+func sloViolations(exp *base.Experiment) []SLOViolation {
+	if exp.Result == nil || exp.Result.Insights == nil || exp.Result.Insights.SLOs == nil {
+		return nil
+	}
+	in := exp.Result.Insights
+	satisfied := in.SLOsSatisfied
+	if satisfied == nil {
+		satisfied = &base.SLOResults{}
+	}
+	violations := []SLOViolation{}
+	check := func(slos []base.SLO, sat [][]bool, upper bool) {
+		for i, slo := range slos {
+			for j := 0; j < in.NumVersions; j++ {
+				if i < len(sat) && j < len(sat[i]) && sat[i][j] {
+					continue
+				}
+				v := SLOViolation{
+					Metric:  slo.Metric,
+					Type:    "lower",
+					Limit:   slo.Limit,
+					Version: j,
+					Value:   in.ScalarMetricValue(j, slo.Metric),
+				}
+				if upper {
+					v.Type = "upper"
+				}
+				if v.Value != nil {
+					excess := slo.Limit - *v.Value
+					if upper {
+						excess = *v.Value - slo.Limit
+					}
+					v.Excess = &excess
+				}
+				log.Logger.Infof("SLO %v %v limit %v is not satisfied by version %v", v.Metric, v.Type, v.Limit, j)
+				violations = append(violations, v)
+			}
+		}
+	}
+	check(in.SLOs.Upper, satisfied.Upper, true)
+	check(in.SLOs.Lower, satisfied.Lower, false)
+	return violations
 }
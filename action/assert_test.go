@@ -1,7 +1,9 @@
 package action
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -22,7 +24,7 @@ func TestLocalAssert(t *testing.T) {
 	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
 	aOpts.Conditions = []string{Completed, NoFailure, SLOs}
 
-	ok, err := aOpts.LocalRun()
+	ok, err := aOpts.LocalRun(os.Stdout)
 	assert.True(t, ok)
 	assert.NoError(t, err)
 }
@@ -35,7 +37,7 @@ func TestLocalAssertFailing(t *testing.T) {
 	aOpts.Conditions = []string{Completed, NoFailure, SLOs}
 	aOpts.Timeout = 5 * time.Second
 
-	ok, err := aOpts.LocalRun()
+	ok, err := aOpts.LocalRun(os.Stdout)
 	assert.False(t, ok)
 	assert.NoError(t, err)
 }
@@ -55,7 +57,112 @@ func TestKubeAssert(t *testing.T) {
 		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
 	}, metav1.CreateOptions{})
 
-	ok, err := aOpts.KubeRun()
+	ok, err := aOpts.KubeRun(os.Stdout)
 	assert.True(t, ok)
 	assert.NoError(t, err)
 }
+
+func TestLocalAssertJSON(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputsfail/experiment.yaml"))
+	// fix aOpts
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+	aOpts.Conditions = []string{Completed, NoFailure, SLOs}
+	aOpts.OutputFormat = JSONOutputFormatKey
+
+	var b bytes.Buffer
+	ok, err := aOpts.LocalRun(&b)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	result := AssertResult{}
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &result))
+	assert.False(t, result.Satisfied)
+	assert.Equal(t, 3, len(result.Conditions))
+	assert.Equal(t, ConditionResult{Condition: SLOs, Satisfied: false}, result.Conditions[2])
+	assert.Equal(t, 1, len(result.SLOViolations))
+	assert.Equal(t, "http/error-rate", result.SLOViolations[0].Metric)
+	assert.Equal(t, "upper", result.SLOViolations[0].Type)
+	assert.Equal(t, 0, result.SLOViolations[0].Version)
+	assert.NotNil(t, result.SLOViolations[0].Value)
+	assert.NotNil(t, result.SLOViolations[0].Excess)
+	assert.Equal(t, ExitCodeSLOViolation, result.ExitCode)
+	assert.Equal(t, ExitCodeSLOViolation, ExitCode(aOpts.Failure()))
+
+	// unsupported output format
+	aOpts.OutputFormat = "yaml"
+	_, err = aOpts.LocalRun(&b)
+	assert.Error(t, err)
+}
+
+func TestLocalAssertExpressions(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputs/experiment.yaml"))
+
+	for _, tc := range []struct {
+		cond string
+		ok   bool
+	}{
+		{"slosby=0", true},
+		{"slosby = 0 && nofailure", true},
+		{"completed && !slos", false},
+		{"loops >= 0 && loops < 1000", true},
+		{"loops > 1000 || slosby=0", true},
+		{"loops > 1000", false},
+		{"winner=0", true},
+		{"completed && winner = 0", true},
+		{"winner=1", false},
+		{"winner=1 || slosby=0", true},
+	} {
+		aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+		aOpts.Conditions = []string{tc.cond}
+		ok, err := aOpts.LocalRun(os.Stdout)
+		assert.NoError(t, err, tc.cond)
+		assert.Equal(t, tc.ok, ok, tc.cond)
+	}
+}
+
+func TestLocalAssertUnsupportedCondition(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputs/experiment.yaml"))
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+	for _, cond := range []string{"winner=v2", "winner", "loops + 1", "slosby=x"} {
+		aOpts.Conditions = []string{cond}
+		ok, err := aOpts.LocalRun(os.Stdout)
+		assert.False(t, ok, cond)
+		assert.Error(t, err, cond)
+	}
+}
+
+func TestLocalAssertConditionTimeouts(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputsfail/experiment.yaml"))
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+	aOpts.Conditions = []string{Completed, NoFailure, SLOs}
+	// the global timeout is never reached since slos fails as soon as its own timeout expires
+	aOpts.Timeout = time.Hour
+	aOpts.ConditionTimeouts = map[string]string{SLOs: "3s"}
+
+	start := time.Now()
+	ok, err := aOpts.LocalRun(os.Stdout)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+func TestLocalAssertInvalidConditionTimeouts(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputs/experiment.yaml"))
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+	aOpts.Conditions = []string{Completed, SLOs}
+
+	for _, ct := range []map[string]string{
+		{SLOs: "soon"},
+		{NoFailure: "5s"},
+	} {
+		aOpts.ConditionTimeouts = ct
+		ok, err := aOpts.LocalRun(os.Stdout)
+		assert.False(t, ok)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,76 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+// AuditOpts are the options used for displaying the audit log of Kubernetes experiments
+type AuditOpts struct {
+	// All displays the audit log of all experiment groups in the namespace
+	All bool
+	// OutputFormat is the format of the audit log; text or json
+	OutputFormat string
+	// KubeDriver enables access to the audit log
+	*driver.KubeDriver
+}
+
+// NewAuditOpts initializes and returns audit opts
+func NewAuditOpts(kd *driver.KubeDriver) *AuditOpts {
+	return &AuditOpts{
+		OutputFormat: TextOutputFormatKey,
+		KubeDriver:   kd,
+	}
+}
+
+// KubeRun logs the audit log of the experiment group, or of all experiment groups in the namespace, as a table;
+// in JSON format, the audit log is written into the given writer
+func (aOpts *AuditOpts) KubeRun(out io.Writer) error {
+	format := strings.ToLower(aOpts.OutputFormat)
+	if format != TextOutputFormatKey && format != JSONOutputFormatKey {
+		e := fmt.Errorf("unsupported audit output format %v", aOpts.OutputFormat)
+		log.Logger.Error(e)
+		return e
+	}
+	if err := aOpts.KubeDriver.Init(); err != nil {
+		return err
+	}
+	entries, err := aOpts.ReadAuditLog(aOpts.All)
+	if err != nil {
+		return err
+	}
+	if format == TextOutputFormatKey {
+		log.Logger.WithIndentedTrace(auditEntriesTable(entries)).Info("audit log")
+		return nil
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		e := errors.New("unable to marshal audit log")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	fmt.Fprintln(out, string(b))
+	return nil
+}
+
+// auditEntriesTable returns audit log entries as a table.
+// Values are only included in the JSON output.
+func auditEntriesTable(entries []driver.AuditEntry) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTION\tGROUP\tREVISION\tUSER\tKUBE USER\tOWNER")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", e.Time.UTC().Format(time.RFC3339), e.Action, e.Group, e.Revision, e.User, e.KubeUser, e.Owner)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestKubeAudit(t *testing.T) {
+	os.Chdir(t.TempDir())
+
+	// fix lOpts
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoDownload = true
+	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
+	assert.NoError(t, lOpts.KubeRun())
+
+	// abort the running experiment
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", driver.ExperimentPath))
+	exp, err := driver.ExperimentFromBytes(byteArray)
+	assert.NoError(t, err)
+	exp.Result.NumCompletedTasks = 1
+	byteArray, _ = yaml.Marshal(exp)
+	lOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, NewAbortOpts(lOpts.KubeDriver).KubeRun())
+
+	// delete the experiment
+	assert.NoError(t, NewDeleteOpts(lOpts.KubeDriver).KubeRun())
+
+	aOpts := NewAuditOpts(lOpts.KubeDriver)
+	var b bytes.Buffer
+	log.Logger.Out = &b
+	assert.NoError(t, aOpts.KubeRun(ioutil.Discard))
+	log.Logger.Out = os.Stderr
+	assert.Contains(t, b.String(), "audit log")
+	assert.Contains(t, b.String(), "ACTION")
+	assert.Regexp(t, `launch\s+default\s+1`, b.String())
+	assert.Regexp(t, `abort\s+default\s+1`, b.String())
+	assert.Regexp(t, `delete\s+default\s+1`, b.String())
+
+	// values are included in JSON output
+	aOpts.OutputFormat = JSONOutputFormatKey
+	b.Reset()
+	assert.NoError(t, aOpts.KubeRun(&b))
+	entries := []driver.AuditEntry{}
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &entries))
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, driver.LaunchAuditAction, entries[0].Action)
+	assert.NotNil(t, entries[0].Values["http"])
+
+	aOpts.OutputFormat = "yaml"
+	assert.Error(t, aOpts.KubeRun(&b))
+}
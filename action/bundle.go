@@ -0,0 +1,331 @@
+package action
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultBundleFile is the default path of experiment bundles
+	DefaultBundleFile = "iter8-bundle.tgz"
+	// BundleValuesFile is the name of the file with the default values of a bundle.
+	// Loading a bundle writes this file into the charts parent dir.
+	BundleValuesFile = "bundle-values.yaml"
+	// bundleMetadataFile is the name of the file that describes a bundle
+	bundleMetadataFile = "bundle.yaml"
+)
+
+// BundleOpts are the options used for creating and loading experiment bundles.
+// A bundle is a gzipped tar archive with the experiment charts and default values, which enables
+// experiments to be launched without network access.
+type BundleOpts struct {
+	// File is the path of the bundle
+	File string
+	// ChartsParentDir is the directory where `charts` is downloaded when the bundle is created,
+	// and where the bundle is loaded
+	ChartsParentDir string
+	// HubOpts are the options used for downloading charts when the bundle is created
+	HubOpts
+	// NoDownload creates the bundle using `charts` that are already present under ChartsParentDir
+	NoDownload bool
+	// Options provides the default values of the bundle
+	values.Options
+}
+
+// BundleMetadata describes a bundle
+type BundleMetadata struct {
+	// Version of Iter8 that created the bundle
+	Version string `json:"version"`
+	// RemoteFolderURL is the URL from which the charts in the bundle were downloaded
+	RemoteFolderURL string `json:"remoteFolderURL,omitempty"`
+	// Created is the time when the bundle was created
+	Created time.Time `json:"created"`
+}
+
+// NewBundleOpts initializes and returns bundle opts
+func NewBundleOpts() *BundleOpts {
+	return &BundleOpts{
+		File:            DefaultBundleFile,
+		ChartsParentDir: ".",
+		HubOpts:         *NewHubOpts(),
+	}
+}
+
+// CreateRun creates a bundle with the experiment charts and default values
+func (b *BundleOpts) CreateRun() error {
+	chartsDir := filepath.Join(b.ChartsParentDir, chartsFolderName)
+	meta := BundleMetadata{
+		Version: base.MajorMinor,
+		Created: time.Now(),
+	}
+	if !b.NoDownload {
+		b.ChartsDir = chartsDir
+		if err := b.HubOpts.LocalRun(); err != nil {
+			return err
+		}
+		meta.RemoteFolderURL = b.RemoteFolderURL
+	}
+
+	// default values of the bundle
+	vals, err := b.MergeValues(getter.All(cli.New()))
+	if err != nil {
+		e := errors.New("unable to obtain values for bundle")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	valuesBytes, err := yaml.Marshal(vals)
+	if err != nil {
+		return err
+	}
+	metaBytes, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	// the bundle is written into a temporary file, which replaces the bundle only when it is complete
+	f, err := ioutil.TempFile(filepath.Dir(b.File), "."+filepath.Base(b.File)+"-*")
+	if err != nil {
+		e := fmt.Errorf("unable to create bundle %v", b.File)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	defer os.Remove(f.Name())
+	err = writeBundle(f, metaBytes, valuesBytes, chartsDir)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), b.File); err != nil {
+		e := fmt.Errorf("unable to create bundle %v", b.File)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	log.Logger.Infof("created bundle %v", b.File)
+	return nil
+}
+
+// writeBundle writes a bundle with the given metadata, default values, and charts dir
+func writeBundle(w io.Writer, metaBytes []byte, valuesBytes []byte, chartsDir string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := addBundleFile(tw, bundleMetadataFile, metaBytes); err != nil {
+		return err
+	}
+	if err := addBundleFile(tw, BundleValuesFile, valuesBytes); err != nil {
+		return err
+	}
+	if err := addBundleDir(tw, chartsDir, chartsFolderName); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// addBundleFile adds a file with the given name and contents to the bundle
+func addBundleFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// addBundleDir adds the files in dir to the bundle, under the given name
+func addBundleDir(tw *tar.Writer, dir string, name string) error {
+	if _, err := os.Stat(dir); err != nil {
+		e := fmt.Errorf("unable to find charts dir %v", dir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		// bundles include directories and regular files; symlinks are followed
+		fi, err = os.Stat(p)
+		if err != nil {
+			return err
+		}
+		h, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		h.Name = path.Join(name, filepath.ToSlash(rel))
+		if fi.IsDir() {
+			h.Name += "/"
+			return tw.WriteHeader(h)
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+}
+
+// LoadRun loads the bundle into ChartsParentDir.
+// The charts in the bundle replace any existing `charts` under ChartsParentDir,
+// and the default values of the bundle are written into BundleValuesFile under ChartsParentDir.
+// The bundle is extracted into a temporary directory and validated before it replaces existing charts,
+// so that existing charts are left as is if the bundle is invalid.
+func (b *BundleOpts) LoadRun() (*BundleMetadata, error) {
+	f, err := os.Open(b.File)
+	if err != nil {
+		e := fmt.Errorf("unable to open bundle %v", b.File)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		e := fmt.Errorf("invalid bundle %v", b.File)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	defer gr.Close()
+
+	// the temporary directory is under ChartsParentDir, so that extracted charts can be renamed into place
+	if err := os.MkdirAll(b.ChartsParentDir, 0755); err != nil {
+		e := fmt.Errorf("unable to create charts parent dir %v", b.ChartsParentDir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	tmp, err := ioutil.TempDir(b.ChartsParentDir, ".bundle-")
+	if err != nil {
+		e := errors.New("unable to create temp dir for bundle")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	defer os.RemoveAll(tmp)
+
+	meta := &BundleMetadata{}
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			e := fmt.Errorf("invalid bundle %v", b.File)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return nil, e
+		}
+		name := path.Clean(h.Name)
+		switch {
+		case name == bundleMetadataFile:
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			if err := yaml.Unmarshal(data, meta); err != nil {
+				e := fmt.Errorf("invalid metadata in bundle %v", b.File)
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return nil, e
+			}
+		case name == BundleValuesFile || name == chartsFolderName || strings.HasPrefix(name, chartsFolderName+"/"):
+			if err := extractBundleEntry(tr, h, filepath.Join(tmp, filepath.FromSlash(name))); err != nil {
+				e := fmt.Errorf("unable to extract %v from bundle %v", h.Name, b.File)
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return nil, e
+			}
+		default:
+			e := fmt.Errorf("unexpected file %v in bundle %v", h.Name, b.File)
+			log.Logger.Error(e)
+			return nil, e
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, chartsFolderName)); err != nil {
+		e := fmt.Errorf("bundle %v has no charts", b.File)
+		log.Logger.Error(e)
+		return nil, e
+	}
+
+	if err := swapBundle(tmp, b.ChartsParentDir); err != nil {
+		e := fmt.Errorf("unable to load bundle %v into %v", b.File, b.ChartsParentDir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	if meta.Version != "" && meta.Version != base.MajorMinor {
+		log.Logger.Warnf("bundle %v was created by Iter8 %v; this is Iter8 %v", b.File, meta.Version, base.MajorMinor)
+	}
+	log.Logger.Infof("loaded bundle %v into %v", b.File, b.ChartsParentDir)
+	return meta, nil
+}
+
+// swapBundle moves the charts and values extracted into the temporary directory into the charts parent dir.
+// Existing charts are moved aside into the temporary directory, and restored if the extracted charts cannot be moved.
+func swapBundle(tmp string, parent string) error {
+	chartsDir := filepath.Join(parent, chartsFolderName)
+	old := filepath.Join(tmp, chartsFolderName+".old")
+	if err := os.Rename(chartsDir, old); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(filepath.Join(tmp, chartsFolderName), chartsDir); err != nil {
+		os.Rename(old, chartsDir)
+		return err
+	}
+	valuesFile := filepath.Join(tmp, BundleValuesFile)
+	if _, err := os.Stat(valuesFile); err != nil {
+		return nil
+	}
+	// rename does not replace existing files on all platforms
+	os.Remove(filepath.Join(parent, BundleValuesFile))
+	return os.Rename(valuesFile, filepath.Join(parent, BundleValuesFile))
+}
+
+// extractBundleEntry extracts a directory or regular file in the bundle into the given path
+func extractBundleEntry(tr *tar.Reader, h *tar.Header, p string) error {
+	switch h.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(p, 0755)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(h.Mode).Perm())
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, tr)
+		return err
+	default:
+		return fmt.Errorf("unsupported type of entry %v", h.Name)
+	}
+}
@@ -0,0 +1,128 @@
+package action
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, DefaultBundleFile)
+
+	// create bundle from local charts
+	bOpts := NewBundleOpts()
+	bOpts.File = bundle
+	bOpts.ChartsParentDir = base.CompletePath("../", "")
+	bOpts.NoDownload = true
+	bOpts.Values = []string{"tasks={http}", "http.duration=2s"}
+	assert.NoError(t, bOpts.CreateRun())
+
+	// load bundle
+	parent := filepath.Join(dir, "airgapped")
+	lbOpts := NewBundleOpts()
+	lbOpts.File = bundle
+	lbOpts.ChartsParentDir = parent
+	meta, err := lbOpts.LoadRun()
+	assert.NoError(t, err)
+	assert.Equal(t, base.MajorMinor, meta.Version)
+	assert.FileExists(t, filepath.Join(parent, chartsFolderName, DefaultChartName, "Chart.yaml"))
+	vals, err := ioutil.ReadFile(filepath.Join(parent, BundleValuesFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(vals), "duration: 2s")
+
+	// launch using the bundle
+	os.Chdir(t.TempDir())
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = filepath.Join(dir, "launch")
+	lOpts.ChartName = DefaultChartName
+	lOpts.Bundle = bundle
+	lOpts.DryRun = true
+	lOpts.Values = []string{"http.url=https://httpbin.org/get"}
+	assert.NoError(t, lOpts.LocalRun())
+	exp, err := ioutil.ReadFile(driver.ExperimentPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(exp), "https://httpbin.org/get")
+	assert.Contains(t, string(exp), "duration: 2s")
+}
+
+func TestLoadInvalidBundle(t *testing.T) {
+	dir := t.TempDir()
+
+	// missing bundle
+	bOpts := NewBundleOpts()
+	bOpts.File = filepath.Join(dir, "missing.tgz")
+	bOpts.ChartsParentDir = dir
+	_, err := bOpts.LoadRun()
+	assert.Error(t, err)
+
+	// bundle with files outside charts
+	bOpts.File = filepath.Join(dir, "invalid.tgz")
+	f, err := os.Create(bOpts.File)
+	assert.NoError(t, err)
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, addBundleFile(tw, "charts/../../escape.txt", []byte("hello")))
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	assert.NoError(t, f.Close())
+	_, err = bOpts.LoadRun()
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "escape.txt"))
+
+	// existing charts are left as is when the bundle is invalid
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, chartsFolderName, DefaultChartName), 0755))
+	chartFile := filepath.Join(dir, chartsFolderName, DefaultChartName, "Chart.yaml")
+	assert.NoError(t, ioutil.WriteFile(chartFile, []byte("name: iter8\n"), 0644))
+	for _, entries := range [][]string{
+		{"charts/iter8/Chart.yaml", "unexpected.txt"},
+		{BundleValuesFile},
+	} {
+		f, err := os.Create(bOpts.File)
+		assert.NoError(t, err)
+		gw := gzip.NewWriter(f)
+		tw := tar.NewWriter(gw)
+		for _, e := range entries {
+			assert.NoError(t, addBundleFile(tw, e, []byte("name: bundled\n")))
+		}
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, gw.Close())
+		assert.NoError(t, f.Close())
+		_, err = bOpts.LoadRun()
+		assert.Error(t, err)
+		b, err := ioutil.ReadFile(chartFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "name: iter8\n", string(b))
+		assert.NoFileExists(t, filepath.Join(dir, BundleValuesFile))
+	}
+
+	// and the temporary directories of bundles are removed
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, f := range files {
+		assert.False(t, strings.HasPrefix(f.Name(), ".bundle-"), f.Name())
+	}
+}
+
+func TestCreateBundleWithoutCharts(t *testing.T) {
+	dir := t.TempDir()
+	bOpts := NewBundleOpts()
+	bOpts.File = filepath.Join(dir, DefaultBundleFile)
+	bOpts.ChartsParentDir = dir
+	bOpts.NoDownload = true
+	assert.Error(t, bOpts.CreateRun())
+
+	// no partial bundle is left behind
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
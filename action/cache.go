@@ -0,0 +1,210 @@
+package action
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-getter"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// cacheDigestFile is the file of a cache entry with the digest of its charts
+	cacheDigestFile = "digest"
+	// cacheURLFile is the file of a cache entry with the URL from which its charts were downloaded
+	cacheURLFile = "url"
+)
+
+// DefaultCacheDir returns the default directory where downloaded charts are cached; ~/.iter8/cache
+func DefaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".iter8", "cache")
+	}
+	return filepath.Join(home, ".iter8", "cache")
+}
+
+// chartsCache caches charts folders downloaded from remote URLs, so that repeated downloads of the same URL,
+// such as launches in CI, reuse them. Each entry is keyed by the hash of the URL, which includes its ref,
+// and records the digest of its charts, which is verified whenever the entry is used.
+type chartsCache struct {
+	// dir is the directory of the cache
+	dir string
+}
+
+// cacheKey returns the key of the cache entry for the URL
+func cacheKey(url string) string {
+	h := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(h[:])
+}
+
+// entryDir returns the directory of the cache entry for the URL
+func (c *chartsCache) entryDir(url string) string {
+	return filepath.Join(c.dir, cacheKey(url))
+}
+
+// get returns the charts folder cached for the URL. False is returned if the URL is not cached,
+// or if the cached charts do not match their digest, in which case the entry is removed.
+func (c *chartsCache) get(url string) (string, bool) {
+	entry := c.entryDir(url)
+	want, err := ioutil.ReadFile(filepath.Join(entry, cacheDigestFile))
+	if err != nil {
+		return "", false
+	}
+	chartsDir := filepath.Join(entry, chartsFolderName)
+	got, err := digestDir(chartsDir)
+	if err != nil || got != strings.TrimSpace(string(want)) {
+		log.Logger.Warnf("cached charts of %v are corrupted; downloading them again", url)
+		os.RemoveAll(entry)
+		return "", false
+	}
+	return chartsDir, true
+}
+
+// download downloads the charts folder at the URL into the cache, replacing any cached charts of the URL,
+// and returns the cached charts folder
+func (c *chartsCache) download(url string) (string, error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		e := fmt.Errorf("unable to create cache dir %v", c.dir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	// charts are downloaded into a temporary entry, which replaces the entry of the URL when it is complete,
+	// so that an interrupted download is never used
+	tmp, err := ioutil.TempDir(c.dir, ".download-")
+	if err != nil {
+		e := errors.New("unable to create temporary directory")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	defer os.RemoveAll(tmp)
+
+	log.Logger.Infof("downloading %v into cache %v", url, c.dir)
+	if err := getter.Get(filepath.Join(tmp, chartsFolderName), url); err != nil {
+		e := ierrors.Wrap(ierrors.ErrChartDownloadFailed, err, "unable to download charts")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	digest, err := digestDir(filepath.Join(tmp, chartsFolderName))
+	if err != nil {
+		e := errors.New("unable to compute digest of charts")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, cacheDigestFile), []byte(digest+"\n"), 0644); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, cacheURLFile), []byte(url+"\n"), 0644); err != nil {
+		return "", err
+	}
+
+	entry := c.entryDir(url)
+	if err := os.RemoveAll(entry); err != nil {
+		e := fmt.Errorf("unable to remove cached charts of %v", url)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	if err := os.Rename(tmp, entry); err != nil {
+		// another download of the URL may have completed first
+		if chartsDir, ok := c.get(url); ok {
+			return chartsDir, nil
+		}
+		e := fmt.Errorf("unable to cache charts of %v", url)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	return filepath.Join(entry, chartsFolderName), nil
+}
+
+// digestDir returns the SHA-256 digest of the files in the directory, including their relative paths
+func digestDir(dir string) (string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+	h := sha256.New()
+	for _, p := range files {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%v\n", filepath.ToSlash(rel))
+		f, err := os.Open(p)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyDir copies the files in the source directory into the destination directory, replacing existing files
+func copyDir(src string, dst string) error {
+	return filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if fi.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// isLocalURL returns true if the go-getter URL refers to a local folder, which is not cached
+func isLocalURL(url string) bool {
+	// relative paths are local, even if the working directory is unavailable
+	pwd, err := os.Getwd()
+	if err != nil {
+		pwd = "."
+	}
+	src, err := getter.Detect(url, pwd, getter.Detectors)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(src, "file://")
+}
@@ -0,0 +1,100 @@
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chartsServer serves a charts folder archive, and counts its downloads
+func chartsServer(t *testing.T, downloads *int32) *httptest.Server {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"iter8/Chart.yaml":  "apiVersion: v2\nname: iter8\nversion: 0.11.0\n",
+		"iter8/values.yaml": "tasks: []\n",
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(downloads, 1)
+		}
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHubCache(t *testing.T) {
+	var downloads int32
+	srv := chartsServer(t, &downloads)
+	hOpts := NewHubOpts()
+	hOpts.CacheDir = t.TempDir()
+	hOpts.RemoteFolderURL = srv.URL + "/charts.tar.gz"
+
+	// the first download is cached
+	hOpts.ChartsDir = filepath.Join(t.TempDir(), chartsFolderName)
+	assert.NoError(t, hOpts.LocalRun())
+	assert.FileExists(t, filepath.Join(hOpts.ChartsDir, "iter8", "Chart.yaml"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+
+	// later downloads use the cache
+	hOpts.ChartsDir = filepath.Join(t.TempDir(), chartsFolderName)
+	assert.NoError(t, hOpts.LocalRun())
+	assert.FileExists(t, filepath.Join(hOpts.ChartsDir, "iter8", "values.yaml"))
+	charts, err := hOpts.List()
+	assert.NoError(t, err)
+	assert.Len(t, charts, 1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+
+	// corrupted charts are downloaded again
+	cached := filepath.Join(hOpts.CacheDir, cacheKey(hOpts.RemoteFolderURL), chartsFolderName, "iter8", "values.yaml")
+	assert.NoError(t, ioutil.WriteFile(cached, []byte("tasks: [tampered]\n"), 0644))
+	hOpts.ChartsDir = filepath.Join(t.TempDir(), chartsFolderName)
+	assert.NoError(t, hOpts.LocalRun())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&downloads))
+	b, err := ioutil.ReadFile(filepath.Join(hOpts.ChartsDir, "iter8", "values.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "tasks: []\n", string(b))
+
+	// refresh replaces the cached charts
+	hOpts.Refresh = true
+	assert.NoError(t, hOpts.LocalRun())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&downloads))
+
+	// without the cache, charts are downloaded every time
+	hOpts.Refresh = false
+	hOpts.NoCache = true
+	hOpts.ChartsDir = filepath.Join(t.TempDir(), chartsFolderName)
+	assert.NoError(t, hOpts.LocalRun())
+	assert.Equal(t, int32(4), atomic.LoadInt32(&downloads))
+}
+
+func TestIsLocalURL(t *testing.T) {
+	dir := t.TempDir()
+	assert.True(t, isLocalURL(dir))
+	assert.True(t, isLocalURL("./charts"))
+	assert.False(t, isLocalURL("github.com/iter8-tools/iter8.git//charts"))
+	assert.False(t, isLocalURL("github.com/iter8-tools/iter8.git?ref=v0.11.0//charts"))
+	assert.False(t, isLocalURL("https://example.com/charts.tar.gz"))
+
+	// local folders are not cached
+	hOpts := NewHubOpts()
+	hOpts.RemoteFolderURL = dir
+	assert.Nil(t, hOpts.cache())
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// MinCLIVersionAnnotation is the annotation of experiment charts with the minimum version of Iter8 that supports them;
+// for example, v0.11.1. Patch versions are compared, so that charts can require tasks added in a patch release;
+// a version without a patch, such as v0.11, is the first release of the minor version.
+// Charts without this annotation are assumed to be supported.
+const MinCLIVersionAnnotation = "iter8.tools/min-cli-version"
+
+// checkCLIVersion checks that this version of Iter8 supports the experiment chart.
+// Experiments generated by newer charts may contain tasks and inputs that this version cannot run,
+// so such charts are rejected before they are rendered.
+func checkCLIVersion(c *chart.Chart) error {
+	if c.Metadata == nil {
+		return nil
+	}
+	minVersion, ok := c.Metadata.Annotations[MinCLIVersionAnnotation]
+	if !ok || minVersion == "" {
+		return nil
+	}
+	min, err := parseVersion(minVersion)
+	if err != nil {
+		return fmt.Errorf("invalid %v annotation in chart %v: %v", MinCLIVersionAnnotation, c.Name(), err)
+	}
+	// pre-release and build suffixes, such as -rc1 or -3-g1a2b3c, are ignored
+	cur, err := parseVersion(strings.SplitN(base.Version, "-", 2)[0])
+	if err != nil {
+		// development builds are not checked
+		return nil
+	}
+	for i := range cur {
+		if cur[i] != min[i] {
+			if cur[i] < min[i] {
+				return fmt.Errorf("chart %v requires Iter8 %v or later, but this is Iter8 %v; upgrade Iter8, or use a version of the chart for Iter8 %v",
+					c.Name(), minVersion, base.Version, base.MajorMinor)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// parseVersion returns the major, minor, and patch versions in a version such as v0.11, 0.11, or v0.11.2;
+// the patch version is 0 if it is not specified
+func parseVersion(version string) ([3]int, error) {
+	v := [3]int{}
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, fmt.Errorf("version %v is not of the form vMAJOR.MINOR or vMAJOR.MINOR.PATCH", version)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("version %v is not of the form vMAJOR.MINOR or vMAJOR.MINOR.PATCH", version)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
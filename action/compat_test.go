@@ -0,0 +1,69 @@
+package action
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestCheckCLIVersion(t *testing.T) {
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "test"}}
+	assert.NoError(t, checkCLIVersion(c))
+
+	version := base.Version
+	defer func() { base.Version = version }()
+	base.Version = "v0.11.1"
+	for _, v := range []string{"v0.10", "0.11", "v0.11.0", "v0.11.1"} {
+		c.Metadata.Annotations = map[string]string{MinCLIVersionAnnotation: v}
+		assert.NoError(t, checkCLIVersion(c), v)
+	}
+
+	// patch versions are compared
+	for _, v := range []string{"v0.11.2", "v0.12", "v1.0.0"} {
+		c.Metadata.Annotations = map[string]string{MinCLIVersionAnnotation: v}
+		err := checkCLIVersion(c)
+		assert.Error(t, err, v)
+		assert.Contains(t, err.Error(), "chart test requires Iter8 "+v+" or later")
+	}
+
+	// pre-release and build suffixes are ignored
+	base.Version = "v0.11.2-rc1"
+	c.Metadata.Annotations = map[string]string{MinCLIVersionAnnotation: "v0.11.2"}
+	assert.NoError(t, checkCLIVersion(c))
+
+	c.Metadata.Annotations = map[string]string{MinCLIVersionAnnotation: "latest"}
+	assert.Error(t, checkCLIVersion(c))
+	base.Version = version
+
+	// the Iter8 chart is supported by this version
+	c, err := loader.Load(base.CompletePath("../", "charts/iter8"))
+	assert.NoError(t, err)
+	assert.NoError(t, checkCLIVersion(c))
+}
+
+func TestGenUnsupportedChart(t *testing.T) {
+	dir := t.TempDir()
+	cOpts := NewCreateChartOpts()
+	cOpts.Name = "newer"
+	cOpts.ChartsParentDir = dir
+	assert.NoError(t, cOpts.Run())
+
+	// the chart requires a newer version of Iter8
+	c, err := loader.Load(cOpts.chartDir())
+	assert.NoError(t, err)
+	c.Metadata.Annotations[MinCLIVersionAnnotation] = "v99.0"
+	assert.NoError(t, chartutil.SaveChartfile(filepath.Join(cOpts.chartDir(), "Chart.yaml"), c.Metadata))
+
+	gOpts := NewGenOpts()
+	gOpts.ChartsParentDir = dir
+	gOpts.ChartName = "newer"
+	gOpts.Output = StdoutOutput
+	err = gOpts.LocalRun(nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires Iter8 v99.0 or later")
+}
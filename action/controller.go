@@ -0,0 +1,31 @@
+package action
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/iter8-tools/iter8/driver"
+)
+
+// ControllerOpts are the options used for running the Iter8 controller
+type ControllerOpts struct {
+	// KubeDriver enables access to Kubernetes cluster
+	*driver.KubeDriver
+}
+
+// NewControllerOpts initializes and returns controller opts
+func NewControllerOpts(kd *driver.KubeDriver) *ControllerOpts {
+	return &ControllerOpts{
+		KubeDriver: kd,
+	}
+}
+
+// KubeRun runs the Iter8 controller until it is interrupted or terminated.
+// The controller runs experiments declared using Iter8 Experiment custom resources.
+func (cOpts *ControllerOpts) KubeRun() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return driver.NewController(cOpts.KubeDriver).Run(ctx)
+}
@@ -0,0 +1,105 @@
+package action
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+const (
+	// chartNamePlaceholder is replaced by the name of the chart in the scaffold
+	chartNamePlaceholder = "<CHARTNAME>"
+	// majorMinorPlaceholder is replaced by the minor version of Iter8 in the scaffold
+	majorMinorPlaceholder = "<MAJORMINOR>"
+	// versionPlaceholder is replaced by the version of Iter8 in the scaffold, without pre-release and build suffixes
+	versionPlaceholder = "<VERSION>"
+)
+
+// scaffold is the skeleton of experiment charts
+//go:embed scaffold/Chart.yaml scaffold/values.yaml scaffold/values.schema.json scaffold/README.md
+//go:embed scaffold/templates/_experiment.tpl scaffold/templates/_task-http.tpl scaffold/templates/_task-assess.tpl
+var scaffold embed.FS
+
+// chartNameRegexp matches valid names of experiment charts
+var chartNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// CreateChartOpts are the options used for creating experiment charts
+type CreateChartOpts struct {
+	// Name is the name of the chart
+	Name string
+	// ChartsParentDir is the directory under whose `charts` folder the chart is created
+	ChartsParentDir string
+}
+
+// NewCreateChartOpts initializes and returns create chart opts
+func NewCreateChartOpts() *CreateChartOpts {
+	return &CreateChartOpts{
+		ChartsParentDir: ".",
+	}
+}
+
+// chartDir returns the path to the chart directory
+func (cOpts *CreateChartOpts) chartDir() string {
+	return filepath.Join(cOpts.ChartsParentDir, chartsFolderName, cOpts.Name)
+}
+
+// Run creates the skeleton of an experiment chart, with Chart.yaml, values, values schema, task templates, and README.
+// The chart is created under the `charts` folder of ChartsParentDir, so that it can be used by gen and launch.
+func (cOpts *CreateChartOpts) Run() error {
+	if !chartNameRegexp.MatchString(cOpts.Name) {
+		e := fmt.Errorf("invalid chart name %v; chart names consist of lower case alphanumeric characters and -", cOpts.Name)
+		log.Logger.Error(e)
+		return e
+	}
+	dir := cOpts.chartDir()
+	if _, err := os.Stat(dir); err == nil {
+		e := fmt.Errorf("%v already exists", dir)
+		log.Logger.Error(e)
+		return e
+	}
+
+	replacer := strings.NewReplacer(chartNamePlaceholder, cOpts.Name, majorMinorPlaceholder, base.MajorMinor,
+		versionPlaceholder, strings.SplitN(base.Version, "-", 2)[0])
+	err := fs.WalkDir(scaffold, "scaffold", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := scaffold.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(p, "scaffold/")))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, []byte(replacer.Replace(string(data))), 0644)
+	})
+	if err != nil {
+		e := fmt.Errorf("unable to create chart %v", dir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	// the chart is usable as is
+	c, err := loader.Load(dir)
+	if err != nil {
+		e := fmt.Errorf("unable to load created chart %v", dir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	if err := validateValues(c, nil); err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	log.Logger.Infof("created chart %v", dir)
+	return nil
+}
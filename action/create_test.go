@@ -0,0 +1,46 @@
+package action
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCreateChart(t *testing.T) {
+	os.Chdir(t.TempDir())
+	cOpts := NewCreateChartOpts()
+	cOpts.Name = "my-experiment"
+	assert.NoError(t, cOpts.Run())
+	for _, f := range []string{"Chart.yaml", "values.yaml", "values.schema.json", "README.md", "templates/_experiment.tpl"} {
+		assert.FileExists(t, filepath.Join("charts", "my-experiment", f))
+	}
+
+	// the created chart renders an experiment
+	gOpts := NewGenOpts()
+	gOpts.ChartName = "my-experiment"
+	gOpts.Values = []string{"http.url=https://example.com"}
+	gOpts.Output = StdoutOutput
+	buf := &bytes.Buffer{}
+	assert.NoError(t, gOpts.LocalRun(buf))
+	assert.Contains(t, buf.String(), "task: http")
+	assert.Contains(t, buf.String(), "url: https://example.com")
+	assert.Contains(t, buf.String(), "task: assess")
+	exp := &base.Experiment{}
+	assert.NoError(t, yaml.Unmarshal(buf.Bytes(), exp))
+	assert.Equal(t, 2, len(exp.Spec))
+
+	// values are validated against the schema of the chart
+	gOpts.Values = []string{"tasks={grpc}"}
+	assert.Error(t, gOpts.LocalRun(buf))
+
+	// existing charts are not overwritten
+	assert.Error(t, cOpts.Run())
+
+	cOpts.Name = "My_Experiment"
+	assert.Error(t, cOpts.Run())
+}
@@ -1,11 +1,18 @@
 package action
 
 import (
+	"errors"
+
+	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
 )
 
 // DeleteOpts are the options used for deleting experiment groups
 type DeleteOpts struct {
+	// All deletes all experiment groups in the namespace
+	All bool
+	// DryRun lists the experiment groups and resources to be deleted without deleting them
+	DryRun bool
 	// KubeDriver enables access to Kubernetes cluster
 	*driver.KubeDriver
 }
@@ -24,5 +31,46 @@ func (dOpts *DeleteOpts) KubeRun() error {
 		return err
 	}
 
-	return dOpts.KubeDriver.Delete()
+	if !dOpts.All {
+		return dOpts.deleteGroup(dOpts.KubeDriver)
+	}
+
+	rels, err := dOpts.ListExperiments(false)
+	if err != nil {
+		return err
+	}
+	if len(rels) == 0 {
+		log.Logger.Info("no experiment groups found")
+	}
+	var errs []error
+	for _, rel := range rels {
+		kd := *dOpts.KubeDriver
+		kd.Group = rel.Name
+		if err := dOpts.deleteGroup(&kd); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		e := errors.New("unable to delete all experiment groups")
+		log.Logger.Error(e)
+		return e
+	}
+	return nil
+}
+
+// deleteGroup deletes the experiment group of the given driver, and
+// cleans up any experiment resources that remain after deletion
+func (dOpts *DeleteOpts) deleteGroup(kd *driver.KubeDriver) error {
+	var err error
+	if dOpts.DryRun {
+		log.Logger.Infof("would delete experiment group %v", kd.Group)
+	} else {
+		// continue with the cleanup even if deletion fails,
+		// so that resources of partially deleted experiments are removed
+		err = kd.Delete()
+	}
+	if _, e := kd.CleanupExperiment(dOpts.DryRun); e != nil {
+		return e
+	}
+	return err
 }
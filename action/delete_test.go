@@ -32,3 +32,28 @@ func TestKubeDelete(t *testing.T) {
 	err = dOpts.KubeRun()
 	assert.NoError(t, err)
 }
+
+func TestKubeDeleteAll(t *testing.T) {
+	// fix lOpts
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoDownload = true
+	lOpts.Values = []string{"tasks={http}", "http.url=https://iter8.tools", "http.duration=2s"}
+	assert.NoError(t, lOpts.KubeRun())
+
+	// dry run leaves the experiment group in place
+	dOpts := NewDeleteOpts(lOpts.KubeDriver)
+	dOpts.All = true
+	dOpts.DryRun = true
+	assert.NoError(t, dOpts.KubeRun())
+	rel, err := lOpts.Releases.Last(lOpts.Group)
+	assert.NoError(t, err)
+	assert.NotNil(t, rel)
+
+	dOpts.DryRun = false
+	assert.NoError(t, dOpts.KubeRun())
+	rels, err := lOpts.ListExperiments(false)
+	assert.NoError(t, err)
+	assert.Empty(t, rels)
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"errors"
+
+	ierrors "github.com/iter8-tools/iter8/base/errors"
+)
+
+// Exit codes of Iter8 commands, which enable CI/CD pipelines to act on the class of failure
+const (
+	// ExitCodeSuccess indicates that the command succeeded
+	ExitCodeSuccess = 0
+	// ExitCodeError indicates an error in the inputs or the environment of the command;
+	// for example, invalid flags, or an experiment that cannot be read
+	ExitCodeError = 1
+	// ExitCodeSLOViolation indicates that the experiment completed but its SLOs are not satisfied
+	ExitCodeSLOViolation = 2
+	// ExitCodeTaskFailure indicates that a task in the experiment failed
+	ExitCodeTaskFailure = 3
+	// ExitCodeTimeout indicates that the experiment did not complete before the timeout
+	ExitCodeTimeout = 4
+	// ExitCodeInfrastructure indicates that the infrastructure used by the experiment is unavailable;
+	// for example, an unreachable cluster, or a metrics backend that cannot be queried.
+	// Such failures may go away when the command is retried.
+	ExitCodeInfrastructure = 5
+)
+
+// ExitError is an error that is associated with an exit code
+type ExitError struct {
+	// Code is the exit code
+	Code int
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the underlying error
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for an error returned by an action
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		return ee.Code
+	}
+	switch {
+	// infrastructure failures take precedence, since they also fail the tasks that run into them
+	case errors.Is(err, ierrors.ErrClusterUnavailable), errors.Is(err, ierrors.ErrMetricsBackendUnavailable):
+		return ExitCodeInfrastructure
+	case errors.Is(err, ierrors.ErrTaskFailed):
+		return ExitCodeTaskFailure
+	case errors.Is(err, ierrors.ErrSLOViolated):
+		return ExitCodeSLOViolation
+	case errors.Is(err, ierrors.ErrTimeout):
+		return ExitCodeTimeout
+	}
+	return ExitCodeError
+}
@@ -0,0 +1,58 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, ExitCodeSuccess, ExitCode(nil))
+	assert.Equal(t, ExitCodeError, ExitCode(errors.New("unable to read experiment")))
+	assert.Equal(t, ExitCodeTimeout, ExitCode(&ExitError{Code: ExitCodeTimeout, Err: errors.New("timeout")}))
+	wrapped := fmt.Errorf("run failed: %w", &base.TaskError{Index: 1, Task: "http", Err: errors.New("fortio failed")})
+	assert.Equal(t, ExitCodeTaskFailure, ExitCode(wrapped))
+	assert.Equal(t, ExitCodeSLOViolation, ExitCode(ierrors.New(ierrors.ErrSLOViolated, "assert conditions failed")))
+	assert.Equal(t, ExitCodeError, ExitCode(ierrors.New(ierrors.ErrChartNotFound, "chart iter8 not found")))
+	assert.Equal(t, ExitCodeInfrastructure, ExitCode(ierrors.New(ierrors.ErrClusterUnavailable, "unable to get Kubernetes REST config")))
+	// a task that fails because metrics cannot be queried is an infrastructure failure
+	unavailable := &base.TaskError{Index: 1, Task: "custommetrics", Err: ierrors.New(ierrors.ErrMetricsBackendUnavailable, "unable to get metrics specs of any provider")}
+	assert.Equal(t, ExitCodeInfrastructure, ExitCode(fmt.Errorf("run failed: %w", unavailable)))
+}
+
+func TestRunTaskFailureExitCode(t *testing.T) {
+	os.Chdir(t.TempDir())
+	err := ioutil.WriteFile(driver.ExperimentPath, []byte(`
+spec:
+- run: exit 1
+result:
+  startTime: "2022-01-01T00:00:00Z"
+  numCompletedTasks: 0
+  failure: false
+  iter8Version: v0.11
+`), 0664)
+	assert.NoError(t, err)
+
+	rOpts := NewRunOpts(driver.NewFakeKubeDriver(cli.New()))
+	err = rOpts.LocalRun()
+	assert.Error(t, err)
+	assert.Equal(t, ExitCodeTaskFailure, ExitCode(err))
+
+	// assert classifies the failed experiment as a task failure
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+	aOpts.Conditions = []string{Completed, NoFailure}
+	ok, err := aOpts.LocalRun(ioutil.Discard)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, ExitCodeTaskFailure, ExitCode(aOpts.Failure()))
+	assert.True(t, errors.Is(aOpts.Failure(), ierrors.ErrTaskFailed))
+	assert.NotEmpty(t, ierrors.Hint(aOpts.Failure()))
+}
@@ -1,9 +1,21 @@
 package action
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
 
+	"github.com/Masterminds/sprig"
+	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
 	"helm.sh/helm/v3/pkg/chart"
@@ -13,23 +25,43 @@ import (
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/engine"
 	"helm.sh/helm/v3/pkg/getter"
+	"sigs.k8s.io/yaml"
 )
 
 const (
 	chartsFolderName = "charts"
 	DefaultChartName = "iter8"
+	// StdoutOutput is the output path that refers to the standard output
+	StdoutOutput = "-"
+	// defaultGenNamespace is the namespace used when rendering Kubernetes manifests
+	defaultGenNamespace = "default"
 )
 
 // GenOpts are the options used for generating experiment.yaml
 type GenOpts struct {
 	// Options provides the values to be combined with the experiment chart
 	values.Options
+	// Profiles are the names of profiles defined in values files, which are applied in order on top of the values files
+	Profiles []string
 	// ChartsParentDir is the directory where `charts` directory is located
 	ChartsParentDir string
 	// GenDir is the directory where the chart templates are rendered
 	GenDir string
 	// ChartName is the name of the chart
 	ChartName string
+	// Template is the path of a plain experiment spec template. If specified, the template is rendered
+	// with values using Go templates and sprig functions, instead of rendering the experiment chart.
+	Template string
+	// Output is the path of the generated experiment spec; StdoutOutput refers to the standard output.
+	// If empty, experiment.yaml is created in GenDir.
+	Output string
+	// ManifestsOutput is the path of the rendered Kubernetes manifests for the experiment;
+	// StdoutOutput refers to the standard output. If empty, manifests are not generated.
+	ManifestsOutput string
+	// Group is the experiment group used when rendering Kubernetes manifests
+	Group string
+	// Namespace is the namespace used when rendering Kubernetes manifests
+	Namespace string
 }
 
 // NewGenOpts initializes and returns gen opts
@@ -38,26 +70,49 @@ func NewGenOpts() *GenOpts {
 		ChartsParentDir: ".",
 		GenDir:          ".",
 		ChartName:       DefaultChartName,
+		Group:           driver.DefaultExperimentGroup,
+		Namespace:       defaultGenNamespace,
+	}
+}
+
+// releaseOptions returns the release used when rendering the experiment chart
+func (gen *GenOpts) releaseOptions() chartutil.ReleaseOptions {
+	return chartutil.ReleaseOptions{
+		Name:      gen.Group,
+		Namespace: gen.Namespace,
+		Revision:  1,
+		IsInstall: true,
 	}
 }
 
 // chartDir returns the path to chart directory
 func (gen *GenOpts) chartDir() string {
-	return path.Join(gen.ChartsParentDir, chartsFolderName, gen.ChartName)
+	return filepath.Join(gen.ChartsParentDir, chartsFolderName, gen.ChartName)
 }
 
-// LocalRun generates a local experiment.yaml file
-func (gen *GenOpts) LocalRun() error {
+// render renders the experiment chart templates with values, along with the experiment spec.
+// Rendered templates are keyed by their path, which begins with the chart name.
+func (gen *GenOpts) render(options chartutil.ReleaseOptions) (*chart.Chart, map[string]string, error) {
+	if _, err := os.Stat(gen.chartDir()); os.IsNotExist(err) {
+		e := ierrors.Wrap(ierrors.ErrChartNotFound, err, "chart %v not found under %v", gen.ChartName, filepath.Join(gen.ChartsParentDir, chartsFolderName))
+		log.Logger.Error(e)
+		return nil, nil, e
+	}
+
 	// update dependencies
 	if err := driver.UpdateChartDependencies(gen.chartDir(), nil); err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// read in the experiment chart
 	c, err := loader.Load(gen.chartDir())
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("unable to load experiment chart")
-		return err
+		return nil, nil, err
+	}
+	if err := checkCLIVersion(c); err != nil {
+		log.Logger.Error(err)
+		return nil, nil, err
 	}
 
 	// add in experiment.yaml template
@@ -68,35 +123,186 @@ func (gen *GenOpts) LocalRun() error {
 	})
 
 	// get values
+	opts, cleanup, err := withProfiles(gen.Options, gen.Profiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
 	p := getter.All(cli.New())
-	v, err := gen.MergeValues(p)
+	v, err := opts.MergeValues(p)
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("unable to obtain values for chart")
-		return err
+		return nil, nil, err
 	}
 
-	valuesToRender, err := chartutil.ToRenderValues(c, v, chartutil.ReleaseOptions{}, nil)
+	// validate values before rendering, so that typos are reported instead of producing unexpected experiments
+	if err := validateValues(c, v); err != nil {
+		log.Logger.Error(err)
+		return nil, nil, err
+	}
+
+	valuesToRender, err := chartutil.ToRenderValues(c, v, options, nil)
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("unable to compose chart information")
-		return err
+		return nil, nil, err
 	}
 
-	// render experiment.yaml
+	// render templates
 	m, err := engine.Render(c, valuesToRender)
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("unable to render chart templates")
 		log.Logger.Debug("values: ", valuesToRender)
+		return nil, nil, err
+	}
+	return c, m, nil
+}
+
+// experimentBytes returns the rendered experiment spec
+func experimentBytes(c *chart.Chart, m map[string]string) []byte {
+	return []byte(m[path.Join(c.Name(), "templates", driver.ExperimentPath)])
+}
+
+// manifestBytes returns the rendered Kubernetes manifests, excluding the experiment spec and partials
+func manifestBytes(c *chart.Chart, m map[string]string) []byte {
+	names := []string{}
+	for name, content := range m {
+		if name == path.Join(c.Name(), "templates", driver.ExperimentPath) ||
+			strings.HasPrefix(path.Base(name), "_") ||
+			strings.HasSuffix(name, "NOTES.txt") ||
+			strings.TrimSpace(content) == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&b, "---\n# Source: %v\n%v\n", name, strings.TrimSpace(m[name]))
+	}
+	return b.Bytes()
+}
+
+// write writes the given bytes to the output path, or into the given writer if the path is StdoutOutput
+func write(b []byte, output string, out io.Writer) error {
+	if output == StdoutOutput {
+		if !bytes.HasSuffix(b, []byte("\n")) {
+			b = append(b, '\n')
+		}
+		_, err := out.Write(b)
+		return err
+	}
+	if err := ioutil.WriteFile(output, b, 0664); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Errorf("unable to write %v", output)
+		return err
+	}
+	log.Logger.Infof("created %v file", output)
+	return nil
+}
+
+// LocalRun generates a local experiment.yaml file.
+// Optionally, the experiment spec and Kubernetes manifests are written to other paths, or into the given writer.
+func (gen *GenOpts) LocalRun(out io.Writer) error {
+	if gen.Template != "" {
+		return gen.templateRun(out)
+	}
+	options := chartutil.ReleaseOptions{}
+	if gen.ManifestsOutput != "" {
+		options = gen.releaseOptions()
+	}
+	c, m, err := gen.render(options)
+	if err != nil {
 		return err
 	}
 
 	// write experiment
-	expBytes := []byte(m[path.Join(c.Name(), "templates", driver.ExperimentPath)])
-	err = ioutil.WriteFile(path.Join(gen.GenDir, driver.ExperimentPath), expBytes, 0664)
+	output := gen.Output
+	if output == "" {
+		output = filepath.Join(gen.GenDir, driver.ExperimentPath)
+	}
+	if err = write(experimentBytes(c, m), output, out); err != nil {
+		return err
+	}
+
+	// write manifests
+	if gen.ManifestsOutput != "" {
+		return write(manifestBytes(c, m), gen.ManifestsOutput, out)
+	}
+	return nil
+}
+
+// templateRun generates experiment.yaml by rendering the plain experiment spec template with values.
+// Values are available in the template as .Values, as in experiment charts.
+func (gen *GenOpts) templateRun(out io.Writer) error {
+	if gen.ManifestsOutput != "" {
+		e := errors.New("manifests cannot be generated from a plain spec template; use an experiment chart instead")
+		log.Logger.Error(e)
+		return e
+	}
+	b, err := renderTemplate(gen.Template, gen.Options, gen.Profiles)
 	if err != nil {
-		log.Logger.WithStackTrace(err.Error()).Error("unable to write experiment")
 		return err
 	}
-	log.Logger.Infof("created %v file", driver.ExperimentPath)
+	output := gen.Output
+	if output == "" {
+		output = filepath.Join(gen.GenDir, driver.ExperimentPath)
+	}
+	return write(b, output, out)
+}
+
+// renderTemplate renders the plain experiment spec template in the given file with values,
+// and checks that the result is a valid experiment
+func renderTemplate(file string, opts values.Options, profiles []string) ([]byte, error) {
+	tplBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		e := fmt.Errorf("unable to read template %v", file)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
 
-	return err
+	// get values
+	opts, cleanup, err := withProfiles(opts, profiles)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	v, err := opts.MergeValues(getter.All(cli.New()))
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to obtain values for template")
+		return nil, err
+	}
+
+	// missing values are rendered as empty, as in experiment charts
+	tpl, err := template.New(path.Base(file)).Option("missingkey=zero").Funcs(sprig.TxtFuncMap()).Funcs(template.FuncMap{
+		"toYaml": toYaml,
+	}).Parse(string(tplBytes))
+	if err != nil {
+		e := fmt.Errorf("unable to parse template %v", file)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	var b bytes.Buffer
+	if err = tpl.Execute(&b, map[string]interface{}{"Values": v}); err != nil {
+		e := fmt.Errorf("unable to execute template %v", file)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	rendered := []byte(strings.ReplaceAll(b.String(), "<no value>", ""))
+
+	// the rendered spec must be a valid experiment
+	exp := &base.Experiment{}
+	if err = yaml.Unmarshal(rendered, exp); err != nil {
+		e := fmt.Errorf("template %v does not render a valid experiment", file)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	return rendered, nil
+}
+
+// toYaml returns the YAML form of the value, without a trailing newline, as in experiment charts
+func toYaml(v interface{}) string {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(b), "\n")
 }
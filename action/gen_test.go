@@ -1,16 +1,20 @@
 package action
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"testing"
 
 	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
 )
 
 func TestGen(t *testing.T) {
@@ -20,10 +24,71 @@ func TestGen(t *testing.T) {
 	gOpts.ChartsParentDir = base.CompletePath("../", "")
 	gOpts.ChartName = "iter8"
 	gOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get"}
-	err := gOpts.LocalRun()
+	err := gOpts.LocalRun(os.Stdout)
 	assert.NoError(t, err)
 }
 
+func TestGenChartNotFound(t *testing.T) {
+	os.Chdir(t.TempDir())
+	gOpts := NewGenOpts()
+	gOpts.ChartsParentDir = base.CompletePath("../", "")
+	gOpts.ChartName = "missing"
+	err := gOpts.LocalRun(ioutil.Discard)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ierrors.ErrChartNotFound))
+	assert.Contains(t, ierrors.Hint(err), "hub list")
+}
+
+func TestGenStdoutAndManifests(t *testing.T) {
+	os.Chdir(t.TempDir())
+	gOpts := NewGenOpts()
+	gOpts.ChartsParentDir = base.CompletePath("../", "")
+	gOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "runner=job"}
+	gOpts.Output = StdoutOutput
+	gOpts.ManifestsOutput = "manifests.yaml"
+	gOpts.Group = "hello"
+	gOpts.Namespace = "test"
+	buf := &bytes.Buffer{}
+	err := gOpts.LocalRun(buf)
+	assert.NoError(t, err)
+
+	// experiment spec is written to the writer instead of experiment.yaml
+	assert.Contains(t, buf.String(), "task: http")
+	_, err = os.Stat(driver.ExperimentPath)
+	assert.True(t, os.IsNotExist(err))
+
+	// manifests are rendered for the given group and namespace
+	b, err := ioutil.ReadFile("manifests.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "# Source: iter8/templates/k8s.yaml")
+	assert.Contains(t, string(b), "kind: Job")
+	assert.Contains(t, string(b), "name: hello-iter8-sa")
+	assert.Contains(t, string(b), "namespace: test")
+}
+
+func TestGenJobOptions(t *testing.T) {
+	os.Chdir(t.TempDir())
+	gOpts := NewGenOpts()
+	gOpts.ChartsParentDir = base.CompletePath("../", "")
+	gOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "runner=job",
+		"job.resources.requests.cpu=100m", "job.nodeSelector.disk=ssd", "job.tolerations[0].key=dedicated",
+		"job.serviceAccount=iter8", "job.imagePullSecrets={regcred}"}
+	gOpts.ManifestsOutput = "manifests.yaml"
+	gOpts.Group = "hello"
+	err := gOpts.LocalRun(os.Stdout)
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadFile("manifests.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "serviceAccountName: iter8")
+	assert.Contains(t, string(b), "cpu: 100m")
+	assert.Contains(t, string(b), "disk: ssd")
+	assert.Contains(t, string(b), "key: dedicated")
+	assert.Contains(t, string(b), "- name: regcred")
+	// the service account of the experiment is not created
+	assert.NotContains(t, string(b), "name: hello-iter8-sa")
+}
+
 func dumpExperiment(t *testing.T) {
 	file, err := os.Open("experiment.yaml")
 	assert.NoError(t, err)
@@ -43,7 +108,7 @@ func TestGenGRPC(t *testing.T) {
 	gOpts.ChartsParentDir = base.CompletePath("../", "")
 	gOpts.ChartName = "iter8"
 	gOpts.Values = []string{"tasks={grpc,assess}", "grpc.host=localhost:50051", "grpc.call=helloworld.Greeter.SayHello", "grpc.proto=helloworld.proto", "grpc.protoset=helloworld.protoset", "grpc.data.name=frodo", "assess.SLOs.upper.grpc/error-rate=0", "assess.SLOs.upper.grpc/latency/mean=150"}
-	err := gOpts.LocalRun()
+	err := gOpts.LocalRun(os.Stdout)
 	assert.NoError(t, err)
 
 	fd := &driver.FileDriver{
@@ -69,7 +134,7 @@ func TestGenDB(t *testing.T) {
 	gOpts.ChartName = "iter8"
 	gOpts.Values = []string{"tasks={custommetrics,assess}", "custommetrics.providerURLs[0]=https://raw.githubusercontent.com/iter8-tools/iter8/master/charts/iter8lib/templates/_metrics-istio.tpl", "custommetrics.common.providerURL=http://prometheus.istio-system:9090/api/v1/query", "custommetrics.versionInfo[0].destination_workload=httpbin-v2", "custommetrics.versionInfo[0].destination_workload_namespace=default", `custommetrics.versionInfo[0].startingTime="2020-02-01T09:44:40Z"`, "assess.SLOs.upper.istio/error-rate=0"}
 
-	err := gOpts.LocalRun()
+	err := gOpts.LocalRun(os.Stdout)
 	assert.NoError(t, err)
 
 	dumpExperiment(t)
@@ -88,3 +153,110 @@ func TestGenDB(t *testing.T) {
 	s := m["providerURLs"].([]interface{})
 	assert.Equal(t, []interface{}{"https://raw.githubusercontent.com/iter8-tools/iter8/master/charts/iter8lib/templates/_metrics-istio.tpl"}, s)
 }
+
+func TestGenTemplate(t *testing.T) {
+	os.Chdir(t.TempDir())
+	ioutil.WriteFile("experiment.tpl", []byte(`spec:
+- task: http
+  with:
+    url: {{ .Values.url }}
+    duration: {{ .Values.duration | default "5s" }}
+{{- with .Values.headers }}
+    headers:
+{{ toYaml . | indent 6 }}
+{{- end }}
+- task: assess
+  with:
+    SLOs:
+      upper:
+      - metric: http/latency-mean
+        limit: {{ .Values.limit }}
+`), 0644)
+	ioutil.WriteFile("values.yaml", []byte("url: https://httpbin.org/get\nlimit: 500\n"), 0644)
+
+	gOpts := NewGenOpts()
+	gOpts.Template = "experiment.tpl"
+	gOpts.ValueFiles = []string{"values.yaml"}
+	gOpts.Values = []string{"headers.x-user=alice"}
+	gOpts.Output = StdoutOutput
+	buf := &bytes.Buffer{}
+	assert.NoError(t, gOpts.LocalRun(buf))
+	assert.Contains(t, buf.String(), "url: https://httpbin.org/get")
+	assert.Contains(t, buf.String(), "duration: 5s")
+	assert.Contains(t, buf.String(), "x-user: alice")
+	assert.Contains(t, buf.String(), "limit: 500")
+
+	// manifests require an experiment chart
+	gOpts.ManifestsOutput = "manifests.yaml"
+	assert.Error(t, gOpts.LocalRun(buf))
+
+	// the template must render a valid experiment
+	ioutil.WriteFile("invalid.tpl", []byte("spec:\n- task: http\n  with:\n    qps: {{ .Values.qps }}\n"), 0644)
+	gOpts = NewGenOpts()
+	gOpts.Template = "invalid.tpl"
+	gOpts.Values = []string{"qps=fast"}
+	gOpts.Output = StdoutOutput
+	assert.Error(t, gOpts.LocalRun(buf))
+
+	// plugins are looked up when experiments are validated or run, not when they are generated
+	ioutil.WriteFile("plugin.tpl", []byte("spec:\n- task: {{ .Values.task }}\n"), 0644)
+	gOpts = NewGenOpts()
+	gOpts.Template = "plugin.tpl"
+	gOpts.Values = []string{"task=unknown"}
+	gOpts.Output = StdoutOutput
+	buf.Reset()
+	assert.NoError(t, gOpts.LocalRun(buf))
+	assert.Contains(t, buf.String(), "task: unknown")
+}
+
+func TestGenAnnotate(t *testing.T) {
+	os.Chdir(t.TempDir())
+	gOpts := NewGenOpts()
+	gOpts.ChartsParentDir = base.CompletePath("../", "")
+	gOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "runner=job",
+		"hooks.onStart={annotate}", "hooks.onFailure={annotate}",
+		"annotate.provider=datadog", "annotate.apiKeySecret=datadog", "annotate.tags={service:httpbin}",
+		"email.host=smtp.example.com", "email.from=a@example.com", "email.to={b@example.com}", "email.passwordSecret=smtp"}
+	gOpts.Output = StdoutOutput
+	gOpts.ManifestsOutput = "manifests.yaml"
+	buf := &bytes.Buffer{}
+	err := gOpts.LocalRun(buf)
+	assert.NoError(t, err)
+
+	// the API key is read from the environment of the job
+	assert.Contains(t, buf.String(), "task: annotate")
+	assert.Contains(t, buf.String(), "apiKeyEnv: ITER8_ANNOTATE_API_KEY")
+	assert.NotContains(t, buf.String(), "apiKeySecret")
+	b, err := ioutil.ReadFile("manifests.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "name: ITER8_SMTP_PASSWORD")
+	assert.Contains(t, string(b), "name: ITER8_ANNOTATE_API_KEY")
+}
+
+func TestGenDiscover(t *testing.T) {
+	os.Chdir(t.TempDir())
+	gOpts := NewGenOpts()
+	gOpts.ChartsParentDir = base.CompletePath("../", "")
+	gOpts.Values = []string{"tasks={discover,http,grpc}", "http.duration=10s", "grpc.call=helloworld.Greeter.SayHello", "runner=job",
+		"discover.path=/get", "discover.endpoints[0].name=candidate", "discover.endpoints[0].kind=Deployment",
+		"discover.endpoints[0].selector.app=httpbin", "discover.endpoints[0].port=8080"}
+	gOpts.Output = StdoutOutput
+	gOpts.ManifestsOutput = "manifests.yaml"
+	buf := &bytes.Buffer{}
+	err := gOpts.LocalRun(buf)
+	assert.NoError(t, err)
+
+	// targets of the http and grpc tasks default to the discovered endpoint
+	assert.Contains(t, buf.String(), "task: discover")
+	assert.Contains(t, buf.String(), "url: '{{ .Outputs.candidateURL }}'")
+	assert.Contains(t, buf.String(), "host: '{{ .Outputs.candidateHost }}'")
+	b, err := ioutil.ReadFile("manifests.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "-discover")
+	assert.Contains(t, string(b), "resources: [\"deployments\"]")
+
+	// the experiment is valid
+	exp := &base.Experiment{}
+	assert.NoError(t, yaml.Unmarshal(buf.Bytes(), exp))
+	assert.Equal(t, 3, len(exp.Spec))
+}
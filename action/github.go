@@ -0,0 +1,204 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/iter8/action/report"
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+const (
+	// GitHubTokenEnv is the environment variable containing the GitHub token
+	GitHubTokenEnv = "GITHUB_TOKEN"
+	// DefaultGitHubAPIURL is the default base URL of the GitHub API
+	DefaultGitHubAPIURL = "https://api.github.com"
+	// DefaultGitHubContext is the default context of commit statuses set by Iter8
+	DefaultGitHubContext = "iter8"
+
+	// GitHubSuccessState is the commit status state when the experiment completed without failures and satisfied SLOs
+	GitHubSuccessState = "success"
+	// GitHubFailureState is the commit status state when the experiment failed or did not satisfy SLOs
+	GitHubFailureState = "failure"
+	// GitHubPendingState is the commit status state when the experiment has not yet completed
+	GitHubPendingState = "pending"
+
+	// gitHubTimeout is the timeout for requests to the GitHub API
+	gitHubTimeout = 30 * time.Second
+)
+
+// GitHubOpts are the options used for publishing experiment results to GitHub
+type GitHubOpts struct {
+	// Repo is the GitHub repository in the owner/name format
+	Repo string
+	// SHA is the commit on which the status is set; if empty, no status is set
+	SHA string
+	// PR is the pull request on which the experiment report is posted as a comment; if zero, no comment is posted
+	PR int
+	// Context is the label that identifies the commit status
+	Context string
+	// TargetURL is an optional link included in the commit status
+	TargetURL string
+	// APIURL is the base URL of the GitHub API
+	APIURL string
+	// Token is used to authenticate with GitHub; if empty, it is read from the GITHUB_TOKEN environment variable
+	Token string
+	// RunOpts provides options relating to experiment resources
+	RunOpts
+}
+
+// NewGitHubOpts initializes and returns GitHub opts
+func NewGitHubOpts(kd *driver.KubeDriver) *GitHubOpts {
+	return &GitHubOpts{
+		Context: DefaultGitHubContext,
+		APIURL:  DefaultGitHubAPIURL,
+		RunOpts: *NewRunOpts(kd),
+	}
+}
+
+// LocalRun publishes the results of a local experiment to GitHub
+func (gOpts *GitHubOpts) LocalRun() error {
+	fd, err := gOpts.localDriver()
+	if err != nil {
+		return err
+	}
+	return gOpts.Run(fd)
+}
+
+// KubeRun publishes the results of a Kubernetes experiment to GitHub
+func (gOpts *GitHubOpts) KubeRun() error {
+	if err := gOpts.KubeDriver.Init(); err != nil {
+		return err
+	}
+	return gOpts.Run(gOpts.KubeDriver)
+}
+
+// Run builds the experiment, sets the commit status, and posts the pull request comment
+func (gOpts *GitHubOpts) Run(eio base.Driver) error {
+	if err := gOpts.validate(); err != nil {
+		return err
+	}
+
+	exp, err := base.BuildExperiment(eio)
+	if err != nil {
+		return err
+	}
+
+	state, description := gitHubState(exp)
+	if gOpts.SHA != "" {
+		err = gOpts.post(fmt.Sprintf("repos/%v/statuses/%v", gOpts.Repo, gOpts.SHA), map[string]string{
+			"state":       state,
+			"description": description,
+			"context":     gOpts.Context,
+			"target_url":  gOpts.TargetURL,
+		})
+		if err != nil {
+			return err
+		}
+		log.Logger.Infof("set %v status on commit %v", state, gOpts.SHA)
+	}
+
+	if gOpts.PR != 0 {
+		var b bytes.Buffer
+		tr := report.TextReporter{
+			Reporter: &report.Reporter{
+				Experiment: exp,
+			},
+		}
+		if err = tr.Gen(&b); err != nil {
+			return err
+		}
+		body := fmt.Sprintf("### Iter8 experiment: %v\n\n%v\n\n```\n%v\n```\n", state, description, strings.TrimSpace(b.String()))
+		err = gOpts.post(fmt.Sprintf("repos/%v/issues/%v/comments", gOpts.Repo, gOpts.PR), map[string]string{
+			"body": body,
+		})
+		if err != nil {
+			return err
+		}
+		log.Logger.Infof("posted experiment report on pull request %v", gOpts.PR)
+	}
+	return nil
+}
+
+// validate the GitHub opts
+func (gOpts *GitHubOpts) validate() error {
+	if len(strings.Split(gOpts.Repo, "/")) != 2 {
+		e := fmt.Errorf("repository must be in the owner/name format; got %v", gOpts.Repo)
+		log.Logger.Error(e)
+		return e
+	}
+	if gOpts.SHA == "" && gOpts.PR == 0 {
+		e := errors.New("a commit SHA or pull request number is required")
+		log.Logger.Error(e)
+		return e
+	}
+	if gOpts.Token == "" {
+		gOpts.Token = os.Getenv(GitHubTokenEnv)
+	}
+	if gOpts.Token == "" {
+		e := fmt.Errorf("GitHub token is required; set the %v environment variable", GitHubTokenEnv)
+		log.Logger.Error(e)
+		return e
+	}
+	log.AddSecret(gOpts.Token)
+	return nil
+}
+
+// gitHubState returns the commit status state and its description for the experiment
+func gitHubState(exp *base.Experiment) (string, string) {
+	switch {
+	case !exp.NoFailure():
+		return GitHubFailureState, "experiment has task failures"
+	case !exp.Completed():
+		return GitHubPendingState, "experiment has not completed"
+	case !exp.SLOs():
+		return GitHubFailureState, "SLOs are not satisfied"
+	default:
+		return GitHubSuccessState, "experiment completed and SLOs are satisfied"
+	}
+}
+
+// post sends the given payload to the given GitHub API path
+func (gOpts *GitHubOpts) post(path string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		e := errors.New("unable to marshal GitHub request")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	u := strings.TrimSuffix(gOpts.APIURL, "/") + "/" + path
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(b))
+	if err != nil {
+		e := errors.New("unable to create GitHub request")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+gOpts.Token)
+
+	client := &http.Client{Timeout: gitHubTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		e := errors.New("unable to send GitHub request")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e := fmt.Errorf("GitHub API returned status code %v for %v", resp.StatusCode, path)
+		log.Logger.Error(e)
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package action
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestLocalGitHub(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputs/experiment.yaml"))
+
+	requests := map[string]map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer my-token", r.Header.Get("Authorization"))
+		b, _ := ioutil.ReadAll(r.Body)
+		payload := map[string]string{}
+		assert.NoError(t, json.Unmarshal(b, &payload))
+		requests[r.URL.Path] = payload
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	// fix gOpts
+	gOpts := NewGitHubOpts(driver.NewFakeKubeDriver(cli.New()))
+	gOpts.APIURL = srv.URL
+	gOpts.Token = "my-token"
+	gOpts.Repo = "owner/repo"
+	gOpts.SHA = "abc123"
+	gOpts.PR = 7
+
+	err := gOpts.LocalRun()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(requests))
+	status := requests["/repos/owner/repo/statuses/abc123"]
+	assert.Equal(t, GitHubSuccessState, status["state"])
+	assert.Equal(t, DefaultGitHubContext, status["context"])
+	assert.Contains(t, requests["/repos/owner/repo/issues/7/comments"]["body"], "Experiment summary")
+}
+
+func TestLocalGitHubFailing(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputsfail/experiment.yaml"))
+
+	var state string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		payload := map[string]string{}
+		json.Unmarshal(b, &payload)
+		state = payload["state"]
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	// fix gOpts
+	gOpts := NewGitHubOpts(driver.NewFakeKubeDriver(cli.New()))
+	gOpts.APIURL = srv.URL
+	gOpts.Token = "my-token"
+	gOpts.Repo = "owner/repo"
+	gOpts.SHA = "abc123"
+
+	err := gOpts.LocalRun()
+	assert.NoError(t, err)
+	assert.NotEqual(t, GitHubSuccessState, state)
+
+	// GitHub API errors are surfaced
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	err = gOpts.LocalRun()
+	assert.Error(t, err)
+}
+
+func TestGitHubInvalidOpts(t *testing.T) {
+	gOpts := NewGitHubOpts(driver.NewFakeKubeDriver(cli.New()))
+	gOpts.Token = "my-token"
+	gOpts.SHA = "abc123"
+
+	// invalid repo
+	gOpts.Repo = "repo"
+	assert.Error(t, gOpts.LocalRun())
+
+	// no SHA or PR
+	gOpts.Repo = "owner/repo"
+	gOpts.SHA = ""
+	assert.Error(t, gOpts.LocalRun())
+
+	// no token
+	os.Unsetenv(GitHubTokenEnv)
+	gOpts.SHA = "abc123"
+	gOpts.Token = ""
+	assert.Error(t, gOpts.LocalRun())
+}
@@ -0,0 +1,62 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestHistory(t *testing.T) {
+	os.Chdir(t.TempDir())
+	assert.NoError(t, ioutil.WriteFile(driver.ExperimentPath, []byte("spec:\n- run: echo hello\n"), 0644))
+	historyDB := "sqlite://" + filepath.Join(t.TempDir(), "history.db")
+
+	// runs are recorded in the history database
+	rOpts := NewRunOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.HistoryDB = historyDB
+	rOpts.HistoryName = "my-experiment"
+	assert.NoError(t, rOpts.LocalRun())
+
+	hOpts := NewHistoryOpts()
+	hOpts.DB = historyDB
+	var out bytes.Buffer
+	log.Logger.Out = &out
+	assert.NoError(t, hOpts.LocalRun(ioutil.Discard))
+	log.Logger.Out = os.Stderr
+	assert.Contains(t, out.String(), "experiment runs")
+	assert.Contains(t, out.String(), "my-experiment")
+	assert.Contains(t, out.String(), "completed")
+
+	// metric values are queried across runs
+	db, err := driver.OpenHistoryDB(historyDB)
+	assert.NoError(t, err)
+	defer db.Close()
+	driver.CopyFileToPwd(t, base.CompletePath("../testdata/assertinputs", driver.ExperimentPath))
+	exp, err := (&driver.FileDriver{RunDir: "."}).Read()
+	assert.NoError(t, err)
+	assert.NoError(t, (&driver.HistoryDriver{Driver: &driver.FileDriver{RunDir: "."}, DB: db, Name: "my-experiment"}).Write(exp))
+
+	out.Reset()
+	hOpts.Name = "my-experiment"
+	hOpts.Metric = "http/latency-mean"
+	hOpts.OutputFormat = JSONOutputFormatKey
+	assert.NoError(t, hOpts.LocalRun(&out))
+	metrics := []driver.HistoryMetric{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &metrics))
+	assert.Equal(t, 1, len(metrics))
+	assert.Equal(t, "my-experiment", metrics[0].Name)
+
+	hOpts.OutputFormat = "yaml"
+	assert.Error(t, hOpts.LocalRun(&out))
+	hOpts.DB = ""
+	assert.Error(t, hOpts.LocalRun(&out))
+}
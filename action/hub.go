@@ -1,12 +1,25 @@
 package action
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/hashicorp/go-getter"
 	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
+	helmaction "helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
 )
 
 const defaultIter8Repo = "github.com/iter8-tools/iter8.git"
@@ -16,9 +29,37 @@ type HubOpts struct {
 	// RemoteFolderURL is the URL of the remote Iter8 experiment charts folder
 	// Remote URLs can be any go-getter URLs like GitHub or GitLab URLs
 	// https://github.com/hashicorp/go-getter
+	// Remote URLs can also be OCI references to an experiment chart, like oci://ghcr.io/org/charts/iter8:0.10.0
 	RemoteFolderURL string
 	// ChartsDir is the full path to the `charts` dir
 	ChartsDir string
+	// RegistryConfig is the path to the credentials file of OCI registries.
+	// Default is the file used by the helm registry login command.
+	RegistryConfig string
+	// Verify enables verification of the provenance of the chart.
+	// The chart must be pulled from an OCI registry along with its Helm provenance file,
+	// and must be signed by a key in the keyring.
+	Verify bool
+	// Keyring is the path to the keyring with the public keys trusted to sign charts.
+	// Default is ~/.gnupg/pubring.gpg
+	Keyring string
+	// NoCache disables the cache of charts downloaded from remote folders; charts are downloaded every time
+	NoCache bool
+	// Refresh downloads the charts again, replacing the cached charts of the remote folder
+	Refresh bool
+	// CacheDir is the directory where charts downloaded from remote folders are cached.
+	// Default is ~/.iter8/cache
+	CacheDir string
+}
+
+// ChartInfo describes an experiment chart available at the remote folder
+type ChartInfo struct {
+	// Name of the chart
+	Name string
+	// Versions of the chart that are available, latest first
+	Versions []string
+	// Description of the chart, from its Chart.yaml
+	Description string
 }
 
 func DefaultRemoteFolderURL() string {
@@ -34,16 +75,289 @@ func NewHubOpts() *HubOpts {
 	return &HubOpts{
 		RemoteFolderURL: DefaultRemoteFolderURL(),
 		ChartsDir:       chartsFolderName,
+		CacheDir:        DefaultCacheDir(),
+	}
+}
+
+// cache returns the cache of downloaded charts; nil if the charts of the remote folder are not cached,
+// because caching is disabled, or because the remote folder is a local folder
+func (hub *HubOpts) cache() *chartsCache {
+	if hub.NoCache || isLocalURL(hub.RemoteFolderURL) {
+		return nil
 	}
+	dir := hub.CacheDir
+	if dir == "" {
+		dir = DefaultCacheDir()
+	}
+	return &chartsCache{dir: dir}
+}
+
+// cachedCharts returns the cached charts folder of the remote folder, downloading it into the cache
+// if it is not cached, if the cached charts are corrupted, or if they are refreshed
+func (hub *HubOpts) cachedCharts(c *chartsCache) (string, error) {
+	if !hub.Refresh {
+		if chartsDir, ok := c.get(hub.RemoteFolderURL); ok {
+			log.Logger.Infof("using cached charts of %v", hub.RemoteFolderURL)
+			return chartsDir, nil
+		}
+	}
+	return c.download(hub.RemoteFolderURL)
 }
 
 // LocalRun downloads an experiment chart to DestDir
 func (hub *HubOpts) LocalRun() error {
+	if registry.IsOCI(hub.RemoteFolderURL) {
+		return hub.pullOCIChart()
+	}
+	if hub.Verify {
+		e := fmt.Errorf("unable to verify charts downloaded from %v; verification requires an OCI chart reference", hub.RemoteFolderURL)
+		log.Logger.Error(e)
+		return e
+	}
+	if c := hub.cache(); c != nil {
+		chartsDir, err := hub.cachedCharts(c)
+		if err != nil {
+			return err
+		}
+		if err := copyDir(chartsDir, hub.ChartsDir); err != nil {
+			e := fmt.Errorf("unable to copy cached charts into %v", hub.ChartsDir)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+		return nil
+	}
 	log.Logger.Infof("downloading %v into %v", hub.RemoteFolderURL, hub.ChartsDir)
 	if err := getter.Get(hub.ChartsDir, hub.RemoteFolderURL); err != nil {
-		e := errors.New("unable to download charts")
+		e := ierrors.Wrap(ierrors.ErrChartDownloadFailed, err, "unable to download charts")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return nil
+}
+
+// ListRun lists the experiment charts available at the remote folder, and logs them as a table
+func (hub *HubOpts) ListRun() error {
+	charts, err := hub.List()
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSIONS\tDESCRIPTION")
+	for _, c := range charts {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", c.Name, strings.Join(c.Versions, ", "), c.Description)
+	}
+	w.Flush()
+	log.Logger.WithIndentedTrace(b.String()).Info("experiment charts")
+	return nil
+}
+
+// List returns the experiment charts available at the remote folder.
+// For an OCI chart reference, the versions of the referenced chart are listed.
+// Otherwise, the charts in the remote folder are listed, after it is downloaded into the cache, or into a temporary directory
+// if it is not cached.
+func (hub *HubOpts) List() ([]ChartInfo, error) {
+	if registry.IsOCI(hub.RemoteFolderURL) {
+		return hub.listOCIChart()
+	}
+	if c := hub.cache(); c != nil {
+		chartsDir, err := hub.cachedCharts(c)
+		if err != nil {
+			return nil, err
+		}
+		return listCharts(chartsDir)
+	}
+
+	dir, err := ioutil.TempDir("", "iter8-hub-")
+	if err != nil {
+		e := errors.New("unable to create temporary directory")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	defer os.RemoveAll(dir)
+
+	chartsDir := filepath.Join(dir, chartsFolderName)
+	log.Logger.Infof("downloading %v", hub.RemoteFolderURL)
+	if err := getter.Get(chartsDir, hub.RemoteFolderURL); err != nil {
+		e := ierrors.Wrap(ierrors.ErrChartDownloadFailed, err, "unable to download charts")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	return listCharts(chartsDir)
+}
+
+// listCharts returns the charts in the given directory; each subdirectory with a Chart.yaml file is a chart
+func listCharts(dir string) ([]ChartInfo, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		e := fmt.Errorf("unable to read charts dir %v", dir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	charts := []ChartInfo{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		cf, err := chartutil.LoadChartfile(filepath.Join(dir, entry.Name(), chartutil.ChartfileName))
+		if err != nil {
+			// not a chart
+			log.Logger.Debugf("skipping %v: %v", entry.Name(), err)
+			continue
+		}
+		charts = append(charts, ChartInfo{
+			Name:        cf.Name,
+			Versions:    []string{cf.Version},
+			Description: cf.Description,
+		})
+	}
+	sort.Slice(charts, func(i, j int) bool {
+		return charts[i].Name < charts[j].Name
+	})
+	return charts, nil
+}
+
+// listOCIChart returns the versions of the chart referenced by the OCI URL, along with the description of its latest version
+func (hub *HubOpts) listOCIChart() ([]ChartInfo, error) {
+	ref, _, err := splitOCIRef(hub.RemoteFolderURL)
+	if err != nil {
+		log.Logger.Error(err)
+		return nil, err
+	}
+	rc, err := hub.registryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimPrefix(ref, registry.OCIScheme+"://")
+	tags, err := rc.Tags(name)
+	if err != nil {
+		e := ierrors.Wrap(ierrors.ErrChartDownloadFailed, err, "unable to list versions of chart %v", ref)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	info := ChartInfo{
+		Name:     path.Base(ref),
+		Versions: tags,
+	}
+	if len(tags) > 0 {
+		// tags are sorted with the latest version first
+		result, err := rc.Pull(name+":"+tags[0], registry.PullOptWithChart(true))
+		if err != nil {
+			e := ierrors.Wrap(ierrors.ErrChartDownloadFailed, err, "unable to pull chart %v:%v", ref, tags[0])
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return nil, e
+		}
+		if result.Chart != nil && result.Chart.Meta != nil {
+			info.Description = result.Chart.Meta.Description
+		}
+	}
+	return []ChartInfo{info}, nil
+}
+
+// registryClient returns a client of OCI registries that uses the credentials in the registry config
+func (hub *HubOpts) registryClient() (*registry.Client, error) {
+	registryConfig := hub.RegistryConfig
+	if registryConfig == "" {
+		registryConfig = cli.New().RegistryConfig
+	}
+	rc, err := registry.NewClient(registry.ClientOptCredentialsFile(registryConfig))
+	if err != nil {
+		e := errors.New("unable to create OCI registry client")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	return rc, nil
+}
+
+// pullOCIChart pulls the experiment chart referenced by the OCI URL, and unpacks it into the charts dir.
+// The chart replaces any chart with the same name in the charts dir.
+func (hub *HubOpts) pullOCIChart() error {
+	ref, version, err := splitOCIRef(hub.RemoteFolderURL)
+	if err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+
+	keyring := hub.Keyring
+	if hub.Verify {
+		if keyring == "" {
+			keyring = DefaultKeyring()
+		}
+		if _, err := os.Stat(keyring); err != nil {
+			e := fmt.Errorf("unable to read keyring %v with trusted keys", keyring)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+	}
+
+	rc, err := hub.registryClient()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(hub.ChartsDir, 0755); err != nil {
+		e := fmt.Errorf("unable to create charts dir %v", hub.ChartsDir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	// helm refuses to unpack a chart over an existing one
+	if err := os.RemoveAll(filepath.Join(hub.ChartsDir, path.Base(ref))); err != nil {
+		e := fmt.Errorf("unable to remove existing chart %v", path.Base(ref))
 		log.Logger.WithStackTrace(err.Error()).Error(e)
 		return e
 	}
+
+	log.Logger.Infof("pulling %v into %v", hub.RemoteFolderURL, hub.ChartsDir)
+	pull := helmaction.NewPullWithOpts(helmaction.WithConfig(&helmaction.Configuration{
+		RegistryClient: rc,
+	}))
+	pull.Settings = cli.New()
+	pull.Version = version
+	pull.Untar = true
+	pull.UntarDir = hub.ChartsDir
+	pull.DestDir = hub.ChartsDir
+	pull.Verify = hub.Verify
+	pull.Keyring = keyring
+	out, err := pull.Run(ref)
+	if err != nil {
+		e := ierrors.Wrap(ierrors.ErrChartDownloadFailed, err, "unable to pull chart %v", hub.RemoteFolderURL)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	if hub.Verify {
+		log.Logger.Debug(out)
+		log.Logger.Infof("verified provenance of chart %v", hub.RemoteFolderURL)
+	}
 	return nil
 }
+
+// DefaultKeyring returns the default keyring with the public keys trusted to sign charts; this is the keyring used by helm
+func DefaultKeyring() string {
+	if v, ok := os.LookupEnv("GNUPGHOME"); ok {
+		return filepath.Join(v, "pubring.gpg")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".gnupg", "pubring.gpg")
+	}
+	return filepath.Join(home, ".gnupg", "pubring.gpg")
+}
+
+// splitOCIRef splits an OCI reference of the form oci://host/path/name:tag into the reference without its tag,
+// and the tag. The tag is optional; without it, the latest version of the chart is pulled.
+func splitOCIRef(url string) (string, string, error) {
+	ref := strings.TrimSuffix(url, "/")
+	slash := strings.LastIndex(ref, "/")
+	if !registry.IsOCI(ref) || slash < len(registry.OCIScheme+"://") {
+		return "", "", fmt.Errorf("invalid OCI reference %v; must be of the form oci://host/path/name:tag", url)
+	}
+	version := ""
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		ref, version = ref[:colon], ref[colon+1:]
+	}
+	if name := ref[slash+1:]; name == "" || strings.Contains(name, "@") {
+		return "", "", fmt.Errorf("invalid OCI reference %v; must be of the form oci://host/path/name:tag", url)
+	}
+	return ref, version, nil
+}
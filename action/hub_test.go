@@ -1,9 +1,13 @@
 package action
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,7 +16,79 @@ func TestHub(t *testing.T) {
 	hOpts := NewHubOpts()
 	os.Chdir(t.TempDir())
 	hOpts.RemoteFolderURL = "github.com/iter8-tools/iter8.git//charts"
+	hOpts.CacheDir = t.TempDir()
 
 	err := hOpts.LocalRun()
 	assert.NoError(t, err)
+	assert.DirExists(t, filepath.Join(hOpts.CacheDir, cacheKey(hOpts.RemoteFolderURL), chartsFolderName))
+	assert.DirExists(t, chartsFolderName)
+}
+
+func TestSplitOCIRef(t *testing.T) {
+	for _, tc := range []struct {
+		url, ref, version string
+		err               bool
+	}{
+		{url: "oci://ghcr.io/org/charts/iter8:0.10.0", ref: "oci://ghcr.io/org/charts/iter8", version: "0.10.0"},
+		{url: "oci://localhost:5000/iter8", ref: "oci://localhost:5000/iter8"},
+		{url: "oci://localhost:5000/iter8:v1", ref: "oci://localhost:5000/iter8", version: "v1"},
+		{url: "oci://ghcr.io/org/charts/iter8/", ref: "oci://ghcr.io/org/charts/iter8"},
+		{url: "oci://ghcr.io", err: true},
+		{url: "oci://ghcr.io/:0.10.0", err: true},
+		{url: "oci://ghcr.io/org/iter8@sha256:abc", err: true},
+		{url: "github.com/iter8-tools/iter8.git//charts", err: true},
+	} {
+		ref, version, err := splitOCIRef(tc.url)
+		if tc.err {
+			assert.Error(t, err, tc.url)
+			continue
+		}
+		assert.NoError(t, err, tc.url)
+		assert.Equal(t, tc.ref, ref)
+		assert.Equal(t, tc.version, version)
+	}
+}
+
+func TestHubOCIInvalidRef(t *testing.T) {
+	hOpts := NewHubOpts()
+	hOpts.ChartsDir = t.TempDir()
+	hOpts.RemoteFolderURL = "oci://ghcr.io"
+	err := hOpts.LocalRun()
+	assert.Error(t, err)
+}
+
+func TestHubVerify(t *testing.T) {
+	// verification requires an OCI chart reference
+	hOpts := NewHubOpts()
+	hOpts.ChartsDir = t.TempDir()
+	hOpts.RemoteFolderURL = "github.com/iter8-tools/iter8.git//charts"
+	hOpts.CacheDir = t.TempDir()
+	hOpts.Verify = true
+	assert.Error(t, hOpts.LocalRun())
+
+	// verification requires a keyring
+	hOpts.RemoteFolderURL = "oci://localhost:5000/iter8:0.10.0"
+	hOpts.Keyring = filepath.Join(t.TempDir(), "missing.gpg")
+	assert.Error(t, hOpts.LocalRun())
+}
+
+func TestHubList(t *testing.T) {
+	hOpts := NewHubOpts()
+	hOpts.RemoteFolderURL = base.CompletePath("../", chartsFolderName)
+
+	charts, err := hOpts.List()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(charts))
+	assert.Equal(t, DefaultChartName, charts[0].Name)
+	assert.Equal(t, 1, len(charts[0].Versions))
+	assert.NotEmpty(t, charts[0].Description)
+
+	buf := bytes.Buffer{}
+	log.Logger.Out = &buf
+	defer func() { log.Logger.Out = os.Stderr }()
+	assert.NoError(t, hOpts.ListRun())
+	assert.Contains(t, buf.String(), "experiment charts")
+	assert.Contains(t, buf.String(), "NAME")
+	assert.Contains(t, buf.String(), DefaultChartName+"  ")
+	assert.Contains(t, buf.String(), charts[0].Versions[0])
 }
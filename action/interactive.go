@@ -0,0 +1,161 @@
+package action
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// defaultInteractiveTasks are the tasks suggested by the interactive launch
+	defaultInteractiveTasks = "http,assess"
+	// interactiveTasks are the tasks that may be chosen in the interactive launch
+	interactiveTasks = "assess, custommetrics, email, grpc, http, ready"
+)
+
+// prompter reads answers to prompts
+type prompter struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+// ask prompts for an answer, and returns the default if the answer is empty
+func (p *prompter) ask(question string, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%v [%v]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%v: ", question)
+	}
+	if !p.scanner.Scan() {
+		if err := p.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	answer := strings.TrimSpace(p.scanner.Text())
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// require prompts for an answer until a non-empty answer is provided
+func (p *prompter) require(question string) (string, error) {
+	for {
+		answer, err := p.ask(question, "")
+		if err != nil {
+			return "", err
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		fmt.Fprintln(p.out, "a value is required")
+	}
+}
+
+// sloValue converts an SLO such as http/latency-mean<=50 into a chart value
+func sloValue(slo string) (string, error) {
+	for op, kind := range map[string]string{"<=": "upper", ">=": "lower"} {
+		if i := strings.Index(slo, op); i > 0 {
+			metric := strings.TrimSpace(slo[:i])
+			limit := strings.TrimSpace(slo[i+len(op):])
+			if _, err := strconv.ParseFloat(limit, 64); err != nil {
+				return "", fmt.Errorf("invalid limit %v in SLO %v", limit, slo)
+			}
+			// dots in metric names are escaped so that they are not treated as nested keys
+			return fmt.Sprintf("assess.SLOs.%v.%v=%v", kind, strings.ReplaceAll(metric, ".", `\.`), limit), nil
+		}
+	}
+	return "", fmt.Errorf("SLO %v must be of the form <metric><=<limit> or <metric>>=<limit>", slo)
+}
+
+// Prompt interactively asks for the experiment chart, tasks, URLs, and SLOs,
+// and adds the answers to the values used for launching the experiment
+func (lOpts *LaunchOpts) Prompt(in io.Reader, out io.Writer) error {
+	p := &prompter{
+		scanner: bufio.NewScanner(in),
+		out:     out,
+	}
+	vals, err := lOpts.prompt(p)
+	if err != nil {
+		e := errors.New("unable to complete interactive launch")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	lOpts.Values = append(lOpts.Values, vals...)
+	return nil
+}
+
+// prompt implements the core logic of Prompt
+func (lOpts *LaunchOpts) prompt(p *prompter) ([]string, error) {
+	chartName, err := p.ask("experiment chart", lOpts.ChartName)
+	if err != nil {
+		return nil, err
+	}
+	lOpts.ChartName = chartName
+
+	answer, err := p.ask(fmt.Sprintf("tasks, separated by commas (%v)", interactiveTasks), defaultInteractiveTasks)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []string{}
+	for _, t := range strings.Split(answer, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tasks = append(tasks, t)
+		}
+	}
+	vals := []string{fmt.Sprintf("tasks={%v}", strings.Join(tasks, ","))}
+
+	for _, t := range tasks {
+		switch t {
+		case "http":
+			url, err := p.require("URL of the HTTP endpoint")
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, "http.url="+url)
+		case "grpc":
+			host, err := p.require("host of the gRPC service (e.g., hello.default:50051)")
+			if err != nil {
+				return nil, err
+			}
+			call, err := p.require("fully-qualified gRPC method (e.g., helloworld.Greeter.SayHello)")
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, "grpc.host="+host, "grpc.call="+call)
+		case "assess":
+			for {
+				answer, err := p.ask("SLOs, separated by commas (e.g., http/latency-mean<=50,http/error-rate<=0)", "")
+				if err != nil {
+					return nil, err
+				}
+				slos := []string{}
+				for _, slo := range strings.Split(answer, ",") {
+					if slo = strings.TrimSpace(slo); slo == "" {
+						continue
+					}
+					v, err := sloValue(slo)
+					if err != nil {
+						fmt.Fprintln(p.out, err)
+						slos = nil
+						break
+					}
+					slos = append(slos, v)
+				}
+				if slos != nil {
+					vals = append(vals, slos...)
+					break
+				}
+			}
+		default:
+			fmt.Fprintf(p.out, "configure the %v task using --set or --values\n", t)
+		}
+	}
+	return vals, nil
+}
@@ -0,0 +1,57 @@
+package action
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestPrompt(t *testing.T) {
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartName = "iter8"
+
+	// accept default chart and tasks, skip the empty URL, and retry the invalid SLOs
+	in := strings.NewReader("\n\n\nhttps://httpbin.org/get\nhttp/latency-mean<50\nhttp/latency-mean<=50, http/latency-p99.9<=200\n")
+	out := &bytes.Buffer{}
+	err := lOpts.Prompt(in, out)
+	assert.NoError(t, err)
+	assert.Equal(t, "iter8", lOpts.ChartName)
+	assert.Equal(t, []string{
+		"tasks={http,assess}",
+		"http.url=https://httpbin.org/get",
+		"assess.SLOs.upper.http/latency-mean=50",
+		`assess.SLOs.upper.http/latency-p99\.9=200`,
+	}, lOpts.Values)
+	assert.Contains(t, out.String(), "a value is required")
+	assert.Contains(t, out.String(), "must be of the form")
+}
+
+func TestPromptGRPC(t *testing.T) {
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+
+	in := strings.NewReader("mychart\ngrpc, ready\nhello.default:50051\nhelloworld.Greeter.SayHello\n")
+	out := &bytes.Buffer{}
+	err := lOpts.Prompt(in, out)
+	assert.NoError(t, err)
+	assert.Equal(t, "mychart", lOpts.ChartName)
+	assert.Equal(t, []string{
+		"tasks={grpc,ready}",
+		"grpc.host=hello.default:50051",
+		"grpc.call=helloworld.Greeter.SayHello",
+	}, lOpts.Values)
+	assert.Contains(t, out.String(), "configure the ready task")
+}
+
+func TestPromptIncomplete(t *testing.T) {
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+
+	// input ends before the URL is provided
+	in := strings.NewReader("iter8\nhttp\n")
+	err := lOpts.Prompt(in, &bytes.Buffer{})
+	assert.Error(t, err)
+	assert.Empty(t, lOpts.Values)
+}
@@ -1,7 +1,11 @@
 package action
 
 import (
-	"path"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
@@ -15,18 +19,42 @@ type LaunchOpts struct {
 	// RemoteFolderURL is the URL of the remote Iter8 experiment charts folder
 	// Remote URLs can be any go-getter URLs like GitHub or GitLab URLs
 	// https://github.com/hashicorp/go-getter
+	// Remote URLs can also be OCI references to an experiment chart, like oci://ghcr.io/org/charts/iter8:0.10.0
 	RemoteFolderURL string
+	// RegistryConfig is the path to the credentials file of OCI registries
+	RegistryConfig string
+	// Verify enables verification of the provenance of the downloaded chart
+	Verify bool
+	// Keyring is the path to the keyring with the public keys trusted to sign charts
+	Keyring string
+	// Force replaces the resources of the experiment instead of updating them, when an experiment group is upgraded
+	Force bool
+	// Bundle is the path of an experiment bundle. If specified, the bundle is loaded into ChartsParentDir
+	// and its default values are used, instead of downloading charts
+	Bundle string
 	// ChartsParentDir is the directory where `charts` is to be downloaded or is located
 	ChartsParentDir string
 	// NoDownload disables charts download.
 	// With this option turned on, `charts` that are already present locally are reused
 	NoDownload bool
+	// NoCache disables the cache of downloaded charts; charts are downloaded every time
+	NoCache bool
+	// Refresh downloads the charts again, replacing the cached charts of the remote folder
+	Refresh bool
 	// ChartName is the name of the chart
 	ChartName string
 	// Options provides the values to be combined with the experiment chart
 	values.Options
+	// Profiles are the names of profiles defined in values files, which are applied in order on top of the values files
+	Profiles []string
 	// Rundir is the directory where experiment.yaml file is located
 	RunDir string
+	// ObjectURL is the URL in object storage where the result of the local experiment is written
+	ObjectURL string
+	// HistoryDB is the URL of the database where the local experiment run is recorded
+	HistoryDB string
+	// HistoryName identifies the local experiment in the history database
+	HistoryName string
 	// KubeDriver enables Kubernetes experiment run
 	*driver.KubeDriver
 }
@@ -45,31 +73,67 @@ func NewLaunchOpts(kd *driver.KubeDriver) *LaunchOpts {
 	}
 }
 
-// LocalRun launches a local experiment
-func (lOpts *LaunchOpts) LocalRun() error {
-	log.Logger.Debug("launch local run started...")
-	if !lOpts.NoDownload {
-		// download chart from Iter8 hub
-		hOpts := &HubOpts{
-			RemoteFolderURL: lOpts.RemoteFolderURL,
-			ChartsDir:       path.Join(lOpts.ChartsParentDir, chartsFolderName),
+// downloadCharts downloads the experiment chart, unless local charts are reused, or a bundle is loaded
+func (lOpts *LaunchOpts) downloadCharts() error {
+	if lOpts.Bundle != "" {
+		if lOpts.Verify {
+			e := errors.New("bundles cannot be verified; verification requires the chart to be downloaded")
+			log.Logger.Error(e)
+			return e
 		}
-		if err := hOpts.LocalRun(); err != nil {
+		bOpts := &BundleOpts{
+			File:            lOpts.Bundle,
+			ChartsParentDir: lOpts.ChartsParentDir,
+		}
+		if _, err := bOpts.LoadRun(); err != nil {
 			return err
 		}
-		log.Logger.Debug("hub complete")
-	} else {
+		// values specified at launch take precedence over the default values of the bundle
+		lOpts.ValueFiles = append([]string{filepath.Join(lOpts.ChartsParentDir, BundleValuesFile)}, lOpts.ValueFiles...)
+		return nil
+	}
+	if lOpts.NoDownload {
+		if lOpts.Verify {
+			e := errors.New("local charts cannot be verified; verification requires the chart to be downloaded")
+			log.Logger.Error(e)
+			return e
+		}
 		log.Logger.Debug("using `charts` under ", lOpts.ChartsParentDir)
+		return nil
+	}
+	// download chart from Iter8 hub
+	hOpts := &HubOpts{
+		RemoteFolderURL: lOpts.RemoteFolderURL,
+		ChartsDir:       filepath.Join(lOpts.ChartsParentDir, chartsFolderName),
+		RegistryConfig:  lOpts.RegistryConfig,
+		Verify:          lOpts.Verify,
+		Keyring:         lOpts.Keyring,
+		NoCache:         lOpts.NoCache,
+		Refresh:         lOpts.Refresh,
+	}
+	if err := hOpts.LocalRun(); err != nil {
+		return err
+	}
+	log.Logger.Debug("hub complete")
+	return nil
+}
+
+// LocalRun launches a local experiment
+func (lOpts *LaunchOpts) LocalRun() error {
+	log.Logger.Debug("launch local run started...")
+	if err := lOpts.downloadCharts(); err != nil {
+		return err
 	}
 
 	// gen experiment spec
 	gOpts := GenOpts{
 		Options:         lOpts.Options,
+		Profiles:        lOpts.Profiles,
 		ChartsParentDir: lOpts.ChartsParentDir,
 		GenDir:          lOpts.RunDir,
 		ChartName:       lOpts.ChartName,
 	}
-	if err := gOpts.LocalRun(); err != nil {
+	if err := gOpts.LocalRun(os.Stdout); err != nil {
 		return err
 	}
 	log.Logger.Debug("gen complete")
@@ -83,31 +147,67 @@ func (lOpts *LaunchOpts) LocalRun() error {
 	// run experiment locally
 	log.Logger.Info("starting local experiment")
 	rOpts := &RunOpts{
-		RunDir:     lOpts.RunDir,
-		KubeDriver: lOpts.KubeDriver,
+		RunDir:      lOpts.RunDir,
+		ObjectURL:   lOpts.ObjectURL,
+		HistoryDB:   lOpts.HistoryDB,
+		HistoryName: lOpts.HistoryName,
+		KubeDriver:  lOpts.KubeDriver,
 	}
 	return rOpts.LocalRun()
 }
 
-// KubeRun launches a Kubernetes experiment
+// KubeRun launches a Kubernetes experiment.
+// If the experiment group exists, it is upgraded to a new revision.
 func (lOpts *LaunchOpts) KubeRun() error {
-	// initialize kube driver
-	if err := lOpts.KubeDriver.Init(); err != nil {
+	chartDir, valueOpts, cleanup, err := lOpts.prepareKube()
+	if err != nil {
 		return err
 	}
+	defer cleanup()
+	if rev := lOpts.KubeDriver.GetRevision(); rev > 0 {
+		log.Logger.Warnf("experiment group %v exists at revision %v, and will be upgraded; use the upgrade option to review changes to the experiment", lOpts.Group, rev)
+	}
+	return lOpts.KubeDriver.Launch(chartDir, valueOpts, lOpts.Group, lOpts.DryRun)
+}
 
-	if !lOpts.NoDownload {
-		// download chart from Iter8 hub
-		hOpts := &HubOpts{
-			RemoteFolderURL: lOpts.RemoteFolderURL,
-			ChartsDir:       path.Join(lOpts.ChartsParentDir, chartsFolderName),
-		}
-		if err := hOpts.LocalRun(); err != nil {
-			return err
-		}
-		log.Logger.Debug("hub complete")
+// KubeUpgradeRun upgrades an existing Kubernetes experiment group to a new revision.
+// The differences between the experiment of the current revision and the upgraded experiment are written into out
+// before the upgrade. With Force, resources of the experiment are replaced instead of being updated.
+func (lOpts *LaunchOpts) KubeUpgradeRun(out io.Writer) error {
+	chartDir, valueOpts, cleanup, err := lOpts.prepareKube()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if lOpts.KubeDriver.GetRevision() <= 0 {
+		e := fmt.Errorf("experiment group %v does not exist; launch it without the upgrade option", lOpts.Group)
+		log.Logger.Error(e)
+		return e
+	}
+
+	diff, err := lOpts.KubeDriver.Diff(chartDir, valueOpts, lOpts.Group)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		fmt.Fprintf(out, "no changes to the experiment of group %v at revision %v\n", lOpts.Group, lOpts.KubeDriver.GetRevision())
 	} else {
-		log.Logger.Debug("using `charts` under ", lOpts.ChartsParentDir)
+		fmt.Fprintf(out, "changes to the experiment of group %v at revision %v:\n%v", lOpts.Group, lOpts.KubeDriver.GetRevision(), diff)
+	}
+
+	return lOpts.KubeDriver.Upgrade(chartDir, valueOpts, lOpts.Group, lOpts.DryRun, lOpts.Force)
+}
+
+// prepareKube initializes the kube driver and the chart, and returns the chart dir and values of a Kubernetes launch.
+// The returned function removes temporary files used by the values, after the launch.
+func (lOpts *LaunchOpts) prepareKube() (string, values.Options, func(), error) {
+	// initialize kube driver
+	if err := lOpts.KubeDriver.Init(); err != nil {
+		return "", values.Options{}, nil, err
+	}
+
+	if err := lOpts.downloadCharts(); err != nil {
+		return "", values.Options{}, nil, err
 	}
 
 	// update dependencies
@@ -119,5 +219,19 @@ func (lOpts *LaunchOpts) KubeRun() error {
 	}
 	driver.UpdateChartDependencies(gOpts.chartDir(), lOpts.EnvSettings)
 
-	return lOpts.KubeDriver.Launch(gOpts.chartDir(), lOpts.Options, lOpts.Group, lOpts.DryRun)
+	// apply profiles
+	valueOpts, cleanup, err := withProfiles(lOpts.Options, lOpts.Profiles)
+	if err != nil {
+		return "", values.Options{}, nil, err
+	}
+
+	// store the experiment in the kind of object used by the driver; values may override this
+	if lOpts.Storage != "" && lOpts.Storage != driver.SecretStorage {
+		valueOpts.Values = append([]string{"storage=" + lOpts.Storage}, valueOpts.Values...)
+	}
+	if err := validateChart(gOpts.chartDir(), valueOpts); err != nil {
+		cleanup()
+		return "", values.Options{}, nil, err
+	}
+	return gOpts.chartDir(), valueOpts, cleanup, nil
 }
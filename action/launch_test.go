@@ -1,6 +1,7 @@
 package action
 
 import (
+	"bytes"
 	"os"
 	"testing"
 
@@ -20,6 +21,7 @@ func TestLocalLaunch(t *testing.T) {
 	// // TODO: fix git ref
 	// lOpts.RemoteFolderURL = defaultIter8Repo + "?ref=v0.11.0" + "//" + chartsFolderName
 	lOpts.ChartName = "iter8"
+	lOpts.NoCache = true
 	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
 
 	err := lOpts.LocalRun()
@@ -36,6 +38,7 @@ func TestKubeLaunch(t *testing.T) {
 	// // TODO: fix git ref
 	// lOpts.RemoteFolderURL = defaultIter8Repo + "?ref=v0.11.0" + "//" + chartsFolderName
 	lOpts.ChartName = "iter8"
+	lOpts.NoCache = true
 	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s", "runner=job"}
 
 	err = lOpts.KubeRun()
@@ -55,6 +58,7 @@ func TestLocalLaunchNoDownload(t *testing.T) {
 	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
 	lOpts.ChartsParentDir = base.CompletePath("../", "")
 	lOpts.ChartName = "iter8"
+	lOpts.NoCache = true
 	lOpts.NoDownload = true
 	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
 
@@ -70,6 +74,7 @@ func TestKubeLaunchNoDownload(t *testing.T) {
 	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
 	lOpts.ChartsParentDir = base.CompletePath("../", "")
 	lOpts.ChartName = "iter8"
+	lOpts.NoCache = true
 	lOpts.NoDownload = true
 	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s", "runner=job"}
 	// // fixing git ref forever
@@ -83,3 +88,49 @@ func TestKubeLaunchNoDownload(t *testing.T) {
 	assert.Equal(t, 1, rel.Version)
 	assert.NoError(t, err)
 }
+
+func TestLocalLaunchNoDownloadVerify(t *testing.T) {
+	os.Chdir(t.TempDir())
+
+	// local charts cannot be verified
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoCache = true
+	lOpts.NoDownload = true
+	lOpts.Verify = true
+	lOpts.DryRun = true
+
+	err := lOpts.LocalRun()
+	assert.Error(t, err)
+}
+
+func TestKubeUpgrade(t *testing.T) {
+	os.Chdir(t.TempDir())
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoCache = true
+	lOpts.NoDownload = true
+	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
+
+	// upgrade requires an existing group
+	buf := new(bytes.Buffer)
+	assert.Error(t, lOpts.KubeUpgradeRun(buf))
+
+	assert.NoError(t, lOpts.KubeRun())
+
+	// no changes
+	assert.NoError(t, lOpts.KubeUpgradeRun(buf))
+	assert.Contains(t, buf.String(), "no changes to the experiment of group default at revision 1")
+
+	buf.Reset()
+	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=5s"}
+	assert.NoError(t, lOpts.KubeUpgradeRun(buf))
+	assert.Contains(t, buf.String(), "changes to the experiment of group default at revision 2")
+	assert.Contains(t, buf.String(), "+ ")
+
+	rel, err := lOpts.Releases.Last(lOpts.Group)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, rel.Version)
+}
@@ -0,0 +1,68 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+// LintOpts are the options used for validating experiment charts and values
+type LintOpts struct {
+	// GenOpts provides the chart and the values to be validated
+	GenOpts
+}
+
+// NewLintOpts initializes and returns lint opts
+func NewLintOpts() *LintOpts {
+	return &LintOpts{
+		GenOpts: *NewGenOpts(),
+	}
+}
+
+// Lint renders the experiment chart with values, including its Kubernetes manifests,
+// and validates the experiment spec along with the inputs of every task.
+// Values that violate the values schema of the chart are reported individually,
+// since the experiment is not rendered from them.
+// All problems found are returned.
+func (lOpts *LintOpts) Lint() []error {
+	c, m, err := lOpts.render(lOpts.releaseOptions())
+	if err != nil {
+		var ve *valuesError
+		if errors.As(err, &ve) {
+			errs := []error{}
+			for _, v := range ve.violations {
+				errs = append(errs, fmt.Errorf("values: %v", v))
+			}
+			return errs
+		}
+		return []error{fmt.Errorf("unable to render chart: %v", err)}
+	}
+
+	exp, err := driver.ExperimentFromBytes(experimentBytes(c, m))
+	if err != nil {
+		return []error{fmt.Errorf("invalid experiment spec: %v", err)}
+	}
+	if len(exp.Spec) == 0 {
+		return []error{fmt.Errorf("experiment has no tasks")}
+	}
+	return base.ValidateExperiment(exp)
+}
+
+// LocalRun validates the experiment chart and values, and writes the problems found into the given writer
+func (lOpts *LintOpts) LocalRun(out io.Writer) error {
+	errs := lOpts.Lint()
+	for _, err := range errs {
+		fmt.Fprintf(out, "[ERROR] %v\n", err)
+	}
+	if len(errs) > 0 {
+		e := fmt.Errorf("found %v problem(s) in experiment", len(errs))
+		log.Logger.Error(e)
+		return e
+	}
+	fmt.Fprintln(out, "no problems found")
+	return nil
+}
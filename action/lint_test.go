@@ -0,0 +1,48 @@
+package action
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	os.Chdir(t.TempDir())
+	lOpts := NewLintOpts()
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.Values = []string{"tasks={http,assess}", "http.url=https://httpbin.org/get", "assess.SLOs.upper.http/error-rate=0"}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, lOpts.LocalRun(buf))
+	assert.Equal(t, "no problems found\n", buf.String())
+	// lint does not write the experiment
+	assert.NoFileExists(t, "experiment.yaml")
+}
+
+func TestLintInvalid(t *testing.T) {
+	os.Chdir(t.TempDir())
+	lOpts := NewLintOpts()
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+
+	// task inputs are invalid
+	lOpts.Values = []string{"tasks={http,grpc}", "http.versions[0].url=https://httpbin.org/get", "http.checkpointInterval=5s", "grpc.host=localhost:50051", "grpc.call=helloworld.Greeter.SayHello"}
+	errs := lOpts.Lint()
+	assert.Equal(t, 1, len(errs))
+	assert.Contains(t, errs[0].Error(), "task 1: http: http task cannot checkpoint load tests of versions")
+
+	// values violate the values schema; each violation is a problem
+	lOpts.Values = []string{"tasks={http,grpc}", "http.url=https://httpbin.org/get", "http.duration=5z", "http.qps=fast", "grpc.host=localhost:50051", "grpc.call=helloworld.Greeter.SayHello"}
+	errs = lOpts.Lint()
+	assert.Equal(t, 2, len(errs))
+	assert.Contains(t, errs[0].Error()+errs[1].Error(), "values: http.duration: Does not match pattern")
+	assert.Contains(t, errs[0].Error()+errs[1].Error(), "values: http.qps must be of type number, not string")
+
+	// chart cannot be rendered
+	lOpts.Values = []string{"tasks={http}", "runner=none"}
+	buf := new(bytes.Buffer)
+	assert.Error(t, lOpts.LocalRun(buf))
+	assert.Contains(t, buf.String(), "unable to render chart")
+}
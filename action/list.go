@@ -0,0 +1,105 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// ListOpts are the options used for listing Kubernetes experiments
+type ListOpts struct {
+	// AllNamespaces lists experiments in all namespaces
+	AllNamespaces bool
+	// KubeDriver enables access to experiment releases and secrets
+	*driver.KubeDriver
+}
+
+// ExperimentSummary summarizes a Kubernetes experiment
+type ExperimentSummary struct {
+	// Namespace of the experiment
+	Namespace string
+	// Group is the experiment group
+	Group string
+	// Chart is the chart used to launch the experiment
+	Chart string
+	// Owner of the experiment; empty if the experiment was launched without an owner
+	Owner string
+	// Revision is the latest revision of the experiment
+	Revision int
+	// Updated is the time when the latest revision was launched
+	Updated time.Time
+	// State is the state of the experiment; unknown if the experiment cannot be read
+	State string
+}
+
+// NewListOpts initializes and returns list opts
+func NewListOpts(kd *driver.KubeDriver) *ListOpts {
+	return &ListOpts{
+		KubeDriver: kd,
+	}
+}
+
+// KubeRun lists Kubernetes experiments
+func (lOpts *ListOpts) KubeRun() error {
+	if err := lOpts.KubeDriver.Init(); err != nil {
+		return err
+	}
+	summaries, err := lOpts.List()
+	if err != nil {
+		return err
+	}
+	log.Logger.WithIndentedTrace(experimentSummariesTable(summaries)).Info("experiments")
+	return nil
+}
+
+// List returns summaries of Kubernetes experiments
+func (lOpts *ListOpts) List() ([]ExperimentSummary, error) {
+	rels, err := lOpts.ListExperiments(lOpts.AllNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []ExperimentSummary{}
+	for _, rel := range rels {
+		s := ExperimentSummary{
+			Namespace: rel.Namespace,
+			Group:     rel.Name,
+			Revision:  rel.Version,
+			State:     "unknown",
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			s.Chart = fmt.Sprintf("%v-%v", rel.Chart.Metadata.Name, rel.Chart.Metadata.Version)
+		}
+		if owner, ok := rel.Config["owner"].(string); ok {
+			s.Owner = owner
+		}
+		if rel.Info != nil {
+			s.Updated = rel.Info.LastDeployed.Time
+		}
+		if exp, err := lOpts.ReadExperiment(rel.Namespace, rel.Name); err == nil {
+			s.State = experimentState(exp)
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+// experimentSummariesTable returns experiment summaries as a table
+func experimentSummariesTable(summaries []ExperimentSummary) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tGROUP\tOWNER\tCHART\tREVISION\tAGE\tSTATE")
+	for _, s := range summaries {
+		age := "unknown"
+		if !s.Updated.IsZero() {
+			age = duration.HumanDuration(time.Since(s.Updated))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", s.Namespace, s.Group, s.Owner, s.Chart, s.Revision, age, s.State)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKubeList(t *testing.T) {
+	os.Chdir(t.TempDir())
+
+	// launch two revisions of the default experiment group
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoDownload = true
+	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
+	assert.NoError(t, lOpts.KubeRun())
+	assert.NoError(t, lOpts.KubeRun())
+
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", driver.ExperimentPath))
+	lOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+
+	listOpts := NewListOpts(lOpts.KubeDriver)
+	summaries, err := listOpts.List()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(summaries))
+	assert.Equal(t, "default", summaries[0].Group)
+	assert.Equal(t, 2, summaries[0].Revision)
+	assert.Equal(t, "completed", summaries[0].State)
+
+	buf := new(bytes.Buffer)
+	log.Logger.Out = buf
+	defer func() { log.Logger.Out = os.Stderr }()
+	assert.NoError(t, listOpts.KubeRun())
+	assert.Contains(t, buf.String(), "NAMESPACE")
+	assert.Contains(t, buf.String(), "completed")
+}
+
+func TestKubeListOwner(t *testing.T) {
+	os.Chdir(t.TempDir())
+
+	// launch an experiment group owned by team-a
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoDownload = true
+	lOpts.Owner = "team-a"
+	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
+	assert.NoError(t, lOpts.KubeRun())
+
+	listOpts := NewListOpts(lOpts.KubeDriver)
+	summaries, err := listOpts.List()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(summaries))
+	assert.Equal(t, "team-a", summaries[0].Owner)
+
+	// experiments of other owners are not listed
+	listOpts.Owner = "team-b"
+	summaries, err = listOpts.List()
+	assert.NoError(t, err)
+	assert.Empty(t, summaries)
+}
@@ -1,11 +1,18 @@
 package action
 
 import (
+	"bytes"
+	"io"
+
 	"github.com/iter8-tools/iter8/driver"
 )
 
 // LogOpts enables fetching logs from Kubernetes
 type LogOpts struct {
+	// Revision is the experiment revision whose logs are fetched; logs of all revisions are fetched if zero
+	Revision int
+	// Follow streams logs until the experiment pods terminate
+	Follow bool
 	// KubeDriver enables interaction with Kubernetes cluster
 	*driver.KubeDriver
 }
@@ -22,5 +29,17 @@ func (lOpts *LogOpts) KubeRun() (string, error) {
 	if err := lOpts.KubeDriver.Init(); err != nil {
 		return "", err
 	}
-	return lOpts.GetExperimentLogs()
+	buf := new(bytes.Buffer)
+	if err := lOpts.StreamExperimentLogs(buf, lOpts.Revision, false); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// KubeStream streams logs from a Kubernetes experiment into the given writer
+func (lOpts *LogOpts) KubeStream(out io.Writer) error {
+	if err := lOpts.KubeDriver.Init(); err != nil {
+		return err
+	}
+	return lOpts.StreamExperimentLogs(out, lOpts.Revision, lOpts.Follow)
 }
@@ -1,6 +1,7 @@
 package action
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"testing"
@@ -48,3 +49,39 @@ func TestLog(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "fake logs", str)
 }
+
+func TestLogRevision(t *testing.T) {
+	os.Chdir(t.TempDir())
+
+	// fix lOpts
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoDownload = true
+	lOpts.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"}
+	assert.NoError(t, lOpts.KubeRun())
+
+	// fix logOpts
+	logOpts := NewLogOpts(lOpts.KubeDriver)
+	logOpts.Clientset.CoreV1().Pods("default").Create(context.TODO(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default-1-job-8218s",
+			Namespace: "default",
+			Labels: map[string]string{
+				"iter8.tools/group":    "default",
+				"iter8.tools/revision": "1",
+			},
+		},
+	}, metav1.CreateOptions{})
+
+	logOpts.Revision = 1
+	logOpts.Follow = true
+	buf := new(bytes.Buffer)
+	assert.NoError(t, logOpts.KubeStream(buf))
+	assert.Equal(t, "fake logs", buf.String())
+
+	// no pods for this revision
+	logOpts.Revision = 2
+	_, err := logOpts.KubeRun()
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+// PreflightOpts are the options used for checking the permissions needed by Kubernetes experiments
+type PreflightOpts struct {
+	// Runner of the experiment; job, cronjob, controller, or none
+	Runner string
+	// ServiceAccount used by the experiment pods; defaults to the service account created for the experiment
+	ServiceAccount string
+	// OutputFormat is the format of the check result; text or json
+	OutputFormat string
+	// KubeDriver enables access to Kubernetes cluster
+	*driver.KubeDriver
+}
+
+// NewPreflightOpts initializes and returns preflight opts
+func NewPreflightOpts(kd *driver.KubeDriver) *PreflightOpts {
+	return &PreflightOpts{
+		Runner:       driver.JobRunner,
+		OutputFormat: TextOutputFormatKey,
+		KubeDriver:   kd,
+	}
+}
+
+// KubeRun checks the permissions needed to launch and run the Kubernetes experiment,
+// and writes the result into the given writer.
+// An error is returned if any permission is missing.
+func (pOpts *PreflightOpts) KubeRun(out io.Writer) error {
+	format := strings.ToLower(pOpts.OutputFormat)
+	if format != TextOutputFormatKey && format != JSONOutputFormatKey {
+		e := fmt.Errorf("unsupported preflight output format %v", pOpts.OutputFormat)
+		log.Logger.Error(e)
+		return e
+	}
+	valid := false
+	for _, r := range driver.Runners {
+		valid = valid || pOpts.Runner == r
+	}
+	if !valid {
+		e := fmt.Errorf("unknown runner %v; must be one of %v", pOpts.Runner, driver.Runners)
+		log.Logger.Error(e)
+		return e
+	}
+	if err := pOpts.KubeDriver.InitKube(); err != nil {
+		return err
+	}
+
+	checks := pOpts.LaunchPermissions(pOpts.Runner)
+	if pOpts.Runner == driver.JobRunner || pOpts.Runner == driver.CronJobRunner {
+		sa := pOpts.ServiceAccount
+		if sa == "" {
+			sa = pOpts.ServiceAccountName()
+		}
+		checks = append(checks, pOpts.ExperimentPermissions(sa)...)
+	}
+	missing := pOpts.CheckAccess(checks)
+
+	if format == JSONOutputFormatKey {
+		b, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			e := errors.New("unable to marshal preflight checks")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+		fmt.Fprintln(out, string(b))
+	} else {
+		log.Logger.WithIndentedTrace(accessChecksTable(checks)).Info("permissions")
+	}
+
+	if missing > 0 {
+		e := fmt.Errorf("%v of %v permissions are missing", missing, len(checks))
+		log.Logger.Error(e)
+		return e
+	}
+	log.Logger.Infof("all %v permissions are available", len(checks))
+	return nil
+}
+
+// accessChecksTable returns the permission checks as a table
+func accessChecksTable(checks []driver.AccessCheck) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUBJECT\tVERB\tRESOURCE\tNAMESPACE\tNAME\tALLOWED\tREASON")
+	for _, c := range checks {
+		subject := c.Subject
+		if subject == "" {
+			subject = "(current user)"
+		}
+		resource := c.Resource
+		if c.Group != "" {
+			resource = resource + "." + c.Group
+		}
+		name := c.Name
+		if name == "" {
+			name = "*"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", subject, c.Verb, resource, c.Namespace, name, c.Allowed, c.Reason)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// allowAccessExcept allows all access reviews except those for the given verb and resource
+func allowAccessExcept(kd *driver.KubeDriver, verb string, resource string) {
+	cs := kd.Clientset.(*fake.Clientset)
+	cs.PrependReactor("create", "selfsubjectaccessreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		r := action.(ktesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview)
+		attrs := r.Spec.ResourceAttributes
+		r.Status.Allowed = attrs.Verb != verb || attrs.Resource != resource
+		return true, r, nil
+	})
+	cs.PrependReactor("create", "subjectaccessreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		r := action.(ktesting.CreateAction).GetObject().(*authv1.SubjectAccessReview)
+		attrs := r.Spec.ResourceAttributes
+		r.Status.Allowed = attrs.Verb != verb || attrs.Resource != resource
+		return true, r, nil
+	})
+}
+
+func TestPreflight(t *testing.T) {
+	os.Chdir(t.TempDir())
+	pOpts := NewPreflightOpts(driver.NewFakeKubeDriver(cli.New()))
+	allowAccessExcept(pOpts.KubeDriver, "", "")
+	buf := new(bytes.Buffer)
+	log.Logger.Out = buf
+	assert.NoError(t, pOpts.KubeRun(ioutil.Discard))
+	log.Logger.Out = os.Stderr
+	assert.Contains(t, buf.String(), "permissions")
+	assert.Contains(t, buf.String(), "jobs.batch")
+	assert.Contains(t, buf.String(), "system:serviceaccount:default:default-iter8-sa")
+
+	// missing permissions are reported
+	pOpts = NewPreflightOpts(driver.NewFakeKubeDriver(cli.New()))
+	pOpts.Runner = driver.CronJobRunner
+	pOpts.ServiceAccount = "iter8"
+	pOpts.OutputFormat = JSONOutputFormatKey
+	allowAccessExcept(pOpts.KubeDriver, "create", "cronjobs")
+	buf = new(bytes.Buffer)
+	assert.Error(t, pOpts.KubeRun(buf))
+	checks := []driver.AccessCheck{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &checks))
+	missing := []driver.AccessCheck{}
+	for _, c := range checks {
+		if !c.Allowed {
+			missing = append(missing, c)
+		}
+	}
+	assert.Equal(t, 1, len(missing))
+	assert.Equal(t, "batch", missing[0].Group)
+	assert.Equal(t, "system:serviceaccount:default:iter8", checks[len(checks)-1].Subject)
+
+	// permissions granted by the role of the experiment are checked for the service account
+	pOpts = NewPreflightOpts(driver.NewFakeKubeDriver(cli.New()))
+	pOpts.OutputFormat = JSONOutputFormatKey
+	allowAccessExcept(pOpts.KubeDriver, "", "")
+	buf = new(bytes.Buffer)
+	assert.NoError(t, pOpts.KubeRun(buf))
+	checks = []driver.AccessCheck{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &checks))
+	granted := map[string]bool{}
+	for _, c := range checks {
+		if c.Subject != "" {
+			granted[c.Verb+" "+c.Resource+" "+c.Name] = true
+		}
+	}
+	for _, p := range []string{
+		"get secrets default", "update secrets default", "create secrets ",
+		"get secrets default-part-1", "update secrets default-part-7", "delete secrets default-part-3",
+		"get leases default-lock", "update leases default-lock", "delete leases default-lock", "create leases ",
+		"create events ",
+	} {
+		assert.True(t, granted[p], p)
+	}
+
+	// events cannot be recorded
+	pOpts = NewPreflightOpts(driver.NewFakeKubeDriver(cli.New()))
+	allowAccessExcept(pOpts.KubeDriver, "create", "events")
+	assert.Error(t, pOpts.KubeRun(new(bytes.Buffer)))
+
+	// controller runner does not need permissions for jobs
+	pOpts = NewPreflightOpts(driver.NewFakeKubeDriver(cli.New()))
+	pOpts.Runner = driver.ControllerRunner
+	allowAccessExcept(pOpts.KubeDriver, "create", "jobs")
+	assert.NoError(t, pOpts.KubeRun(new(bytes.Buffer)))
+
+	// invalid runner
+	pOpts.Runner = "invalid"
+	assert.Error(t, pOpts.KubeRun(new(bytes.Buffer)))
+}
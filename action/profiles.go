@@ -0,0 +1,90 @@
+package action
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"sigs.k8s.io/yaml"
+)
+
+// profilesKey is the key under which named profiles are defined in values files
+const profilesKey = "profiles"
+
+// withProfiles returns values options in which the named profiles are applied in order, on top of the values files.
+// Profiles are defined under the profiles key of the values files; for example,
+//
+//	profiles:
+//	  soak:
+//	    http:
+//	      duration: 1h
+//
+// Values set on the command line take precedence over profiles.
+// The returned function removes the temporary files that hold the values of the profiles.
+func withProfiles(opts values.Options, profiles []string) (values.Options, func(), error) {
+	cleanup := func() {}
+	if len(profiles) == 0 {
+		return opts, cleanup, nil
+	}
+
+	// profiles defined in the values files; later files may override or add profiles
+	fileVals, err := (&values.Options{ValueFiles: opts.ValueFiles}).MergeValues(getter.All(cli.New()))
+	if err != nil {
+		e := fmt.Errorf("unable to read values files")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return values.Options{}, cleanup, e
+	}
+	defined, _ := fileVals[profilesKey].(map[string]interface{})
+
+	dir, err := ioutil.TempDir("", "iter8-profiles")
+	if err != nil {
+		e := fmt.Errorf("unable to create temp dir for profiles")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return values.Options{}, cleanup, e
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	result := opts
+	result.ValueFiles = append([]string{}, opts.ValueFiles...)
+	for i, name := range profiles {
+		profile, ok := defined[name].(map[string]interface{})
+		if !ok {
+			cleanup()
+			e := fmt.Errorf("profile %v is not defined in values files; defined profiles are [%v]", name, strings.Join(profileNames(defined), ", "))
+			log.Logger.Error(e)
+			return values.Options{}, func() {}, e
+		}
+		b, err := yaml.Marshal(profile)
+		if err != nil {
+			cleanup()
+			return values.Options{}, func() {}, err
+		}
+		f := filepath.Join(dir, fmt.Sprintf("profile-%d.yaml", i))
+		if err := ioutil.WriteFile(f, b, 0600); err != nil {
+			cleanup()
+			e := fmt.Errorf("unable to write values of profile %v", name)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return values.Options{}, func() {}, e
+		}
+		log.Logger.Debugf("applying profile %v", name)
+		result.ValueFiles = append(result.ValueFiles, f)
+	}
+	return result, cleanup, nil
+}
+
+// profileNames returns the sorted names of the defined profiles
+func profileNames(defined map[string]interface{}) []string {
+	names := []string{}
+	for name := range defined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
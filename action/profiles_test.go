@@ -0,0 +1,68 @@
+package action
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenProfiles(t *testing.T) {
+	os.Chdir(t.TempDir())
+	ioutil.WriteFile("base.yaml", []byte(`
+tasks: [http]
+http:
+  url: https://httpbin.org/get
+  duration: 10s
+profiles:
+  soak:
+    http:
+      duration: 1h
+  smoke:
+    http:
+      duration: 1s
+`), 0644)
+	ioutil.WriteFile("prod-overrides.yaml", []byte(`
+http:
+  url: https://prod.example.com/get
+profiles:
+  soak:
+    http:
+      qps: 20
+`), 0644)
+
+	gOpts := NewGenOpts()
+	gOpts.ChartsParentDir = base.CompletePath("../", "")
+	gOpts.ValueFiles = []string{"base.yaml", "prod-overrides.yaml"}
+	gOpts.Output = StdoutOutput
+
+	// without profiles
+	buf := &bytes.Buffer{}
+	assert.NoError(t, gOpts.LocalRun(buf))
+	assert.Contains(t, buf.String(), "url: https://prod.example.com/get")
+	assert.Contains(t, buf.String(), "duration: 10s")
+
+	// profiles from later files override those from earlier files
+	gOpts.Profiles = []string{"soak"}
+	buf.Reset()
+	assert.NoError(t, gOpts.LocalRun(buf))
+	assert.Contains(t, buf.String(), "duration: 1h")
+	assert.Contains(t, buf.String(), "qps: 20")
+
+	// profiles are applied in order, and values set on the command line take precedence
+	gOpts.Profiles = []string{"soak", "smoke"}
+	gOpts.Values = []string{"http.qps=5"}
+	buf.Reset()
+	assert.NoError(t, gOpts.LocalRun(buf))
+	assert.Contains(t, buf.String(), "duration: 1s")
+	assert.Contains(t, buf.String(), "qps: 5")
+
+	// undefined profile
+	gOpts.Profiles = []string{"load"}
+	err := gOpts.LocalRun(buf)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "defined profiles are [smoke, soak]")
+}
@@ -0,0 +1,192 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+)
+
+const (
+	// spinnerInterval is the interval at which the spinner of the progress display advances
+	spinnerInterval = 100 * time.Millisecond
+	// progressBarWidth is the number of characters in the progress bar of load tests
+	progressBarWidth = 24
+	// clearLine moves the cursor to the start of the line and clears it
+	clearLine = "\r\033[2K"
+)
+
+// spinnerFrames are the frames of the spinner of the running task
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// ProgressDisplay displays the progress of local experiments in a terminal.
+// The running task is shown on a status line with a spinner, along with a progress bar for load tests,
+// and a line is printed as each task ends. Logs written through the display are printed above the status line.
+type ProgressDisplay struct {
+	// mu protects the state of the display, and writes to out
+	mu sync.Mutex
+	// out is the terminal
+	out io.Writer
+	// loop and maxLoops are the current loop and the maximum number of loops
+	loop, maxLoops int
+	// task is the running task, such as "task 2: http"; empty if no task is running
+	task string
+	// taskStart is the time when the running task started
+	taskStart time.Time
+	// load is the progress of the running load test, if any
+	load *base.LoadProgress
+	// frame is the current frame of the spinner
+	frame int
+	// done stops the spinner
+	done chan struct{}
+}
+
+// NewProgressDisplay returns a progress display that writes to the given terminal
+func NewProgressDisplay(out io.Writer) *ProgressDisplay {
+	return &ProgressDisplay{
+		out:  out,
+		done: make(chan struct{}),
+	}
+}
+
+// IsTerminal returns true if the file is an interactive terminal, and the process does not run in CI.
+// Progress is displayed only in interactive terminals; elsewhere, plain logs are used.
+func IsTerminal(f *os.File) bool {
+	if os.Getenv("CI") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Start starts the spinner of the display
+func (d *ProgressDisplay) Start() {
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.done:
+				return
+			case <-ticker.C:
+				d.mu.Lock()
+				d.frame = (d.frame + 1) % len(spinnerFrames)
+				d.redraw()
+				d.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop stops the spinner, and clears the status line
+func (d *ProgressDisplay) Stop() {
+	close(d.done)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.task != "" {
+		fmt.Fprint(d.out, clearLine)
+	}
+	d.task = ""
+}
+
+// Write prints logs above the status line
+func (d *ProgressDisplay) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.task != "" {
+		fmt.Fprint(d.out, clearLine)
+	}
+	n, err := d.out.Write(p)
+	d.redraw()
+	return n, err
+}
+
+// LoopStarted prints the loop, if the experiment has multiple loops
+func (d *ProgressDisplay) LoopStarted(loop int, maxLoops int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.loop, d.maxLoops = loop, maxLoops
+	if maxLoops > 1 {
+		fmt.Fprintf(d.out, "%vloop %v/%v\n", clearLine, loop, maxLoops)
+	}
+}
+
+// TaskStarted shows the task on the status line
+func (d *ProgressDisplay) TaskStarted(index int, name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.task = fmt.Sprintf("task %v: %v", index, name)
+	d.taskStart = time.Now()
+	d.load = nil
+	d.redraw()
+}
+
+// TaskEnded prints a line with the status and duration of the task
+func (d *ProgressDisplay) TaskEnded(index int, name string, status base.TaskStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	symbol := "✓"
+	switch status {
+	case base.TaskFailed:
+		symbol = "✗"
+	case base.TaskFailureIgnored, base.TaskInterrupted:
+		symbol = "!"
+	case base.TaskSkipped:
+		symbol = "-"
+	}
+	line := fmt.Sprintf("%v task %v: %v %v (%v)", symbol, index, name, status, time.Since(d.taskStart).Round(100*time.Millisecond))
+	if d.load != nil {
+		line += " " + loadSummary(*d.load)
+	}
+	fmt.Fprintf(d.out, "%v%v\n", clearLine, line)
+	d.task = ""
+	d.load = nil
+}
+
+// LoadProgress shows the progress of the load test on the status line
+func (d *ProgressDisplay) LoadProgress(p base.LoadProgress) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.load = &p
+	d.redraw()
+}
+
+// redraw draws the status line; the caller must hold the lock
+func (d *ProgressDisplay) redraw() {
+	if d.task == "" {
+		return
+	}
+	line := spinnerFrames[d.frame] + " "
+	if d.maxLoops > 1 {
+		line += fmt.Sprintf("[loop %v/%v] ", d.loop, d.maxLoops)
+	}
+	line += d.task
+	if d.load != nil {
+		line += " " + progressBar(d.load.Fraction()) + " " + loadSummary(*d.load)
+	} else {
+		line += fmt.Sprintf(" (%v)", time.Since(d.taskStart).Round(time.Second))
+	}
+	fmt.Fprint(d.out, clearLine+line)
+}
+
+// progressBar returns a progress bar for the completed fraction
+func progressBar(fraction float64) string {
+	n := int(fraction * progressBarWidth)
+	return fmt.Sprintf("[%v%v] %3.0f%%", strings.Repeat("=", n), strings.Repeat(" ", progressBarWidth-n), 100*fraction)
+}
+
+// loadSummary describes the requests, rate, and latency of a load test
+func loadSummary(p base.LoadProgress) string {
+	requests := fmt.Sprintf("%v requests", p.Requests)
+	if p.TotalRequests > 0 {
+		requests = fmt.Sprintf("%v/%v requests", p.Requests, p.TotalRequests)
+	}
+	return fmt.Sprintf("%v, %.1f qps, %.1f ms mean latency", requests, p.QPS, p.MeanLatency)
+}
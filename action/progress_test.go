@@ -0,0 +1,55 @@
+package action
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressDisplay(t *testing.T) {
+	var buf bytes.Buffer
+	d := NewProgressDisplay(&buf)
+	d.LoopStarted(2, 3)
+	d.TaskStarted(1, "http")
+	d.LoadProgress(base.LoadProgress{
+		Task:          "http",
+		Requests:      50,
+		TotalRequests: 100,
+		QPS:           8,
+		MeanLatency:   12.25,
+	})
+	out := buf.String()
+	assert.Contains(t, out, "loop 2/3\n")
+	assert.Contains(t, out, "[loop 2/3] task 1: http")
+	assert.Contains(t, out, "[============            ]  50%")
+	assert.Contains(t, out, "50/100 requests, 8.0 qps, 12.2 ms mean latency")
+
+	// logs are printed above the status line
+	buf.Reset()
+	d.Write([]byte("a log line\n"))
+	assert.True(t, strings.HasPrefix(buf.String(), clearLine+"a log line\n"+clearLine))
+
+	buf.Reset()
+	d.TaskEnded(1, "http", base.TaskCompleted)
+	assert.Contains(t, buf.String(), "✓ task 1: http completed")
+	d.TaskStarted(2, "assess")
+	d.TaskEnded(2, "assess", base.TaskFailed)
+	assert.Contains(t, buf.String(), "✗ task 2: assess failed")
+
+	// no status line after tasks end
+	buf.Reset()
+	d.Write([]byte("done\n"))
+	d.Stop()
+	assert.Equal(t, "done\n", buf.String())
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.False(t, IsTerminal(f))
+}
@@ -0,0 +1,76 @@
+package action
+
+import (
+	"errors"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+// PruneOpts are the options used for pruning old revisions of experiment groups
+type PruneOpts struct {
+	// Keep is the number of latest revisions that are kept
+	Keep int
+	// OlderThan prunes only revisions that were deployed longer ago than this age; for example, 30d
+	OlderThan string
+	// All prunes all experiment groups in the namespace
+	All bool
+	// DryRun lists the revisions and resources to be pruned without deleting them
+	DryRun bool
+	// KubeDriver enables access to Kubernetes cluster
+	*driver.KubeDriver
+}
+
+// NewPruneOpts initializes and returns prune opts
+func NewPruneOpts(kd *driver.KubeDriver) *PruneOpts {
+	return &PruneOpts{
+		KubeDriver: kd,
+	}
+}
+
+// KubeRun prunes old revisions of Kubernetes experiment groups
+func (pOpts *PruneOpts) KubeRun() error {
+	policy := driver.RetentionPolicy{
+		Keep: pOpts.Keep,
+	}
+	if pOpts.OlderThan != "" {
+		d, err := driver.ParseAge(pOpts.OlderThan)
+		if err != nil {
+			log.Logger.Error(err)
+			return err
+		}
+		policy.OlderThan = d
+	}
+
+	// initialize kube driver
+	if err := pOpts.KubeDriver.Init(); err != nil {
+		return err
+	}
+
+	if !pOpts.All {
+		_, err := pOpts.Prune(policy, pOpts.DryRun)
+		return err
+	}
+
+	rels, err := pOpts.ListExperiments(false)
+	if err != nil {
+		return err
+	}
+	if len(rels) == 0 {
+		log.Logger.Info("no experiment groups found")
+	}
+	var errs []error
+	for _, rel := range rels {
+		kd := *pOpts.KubeDriver
+		kd.Group = rel.Name
+		if _, err := kd.Prune(policy, pOpts.DryRun); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		e := errors.New("unable to prune all experiment groups")
+		log.Logger.Error(e)
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestKubePrune(t *testing.T) {
+	// fix lOpts
+	lOpts := NewLaunchOpts(driver.NewFakeKubeDriver(cli.New()))
+	lOpts.ChartsParentDir = base.CompletePath("../", "")
+	lOpts.ChartName = "iter8"
+	lOpts.NoDownload = true
+	lOpts.Values = []string{"tasks={http}", "http.url=https://iter8.tools", "http.duration=2s"}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, lOpts.KubeRun())
+	}
+
+	// invalid age
+	pOpts := NewPruneOpts(lOpts.KubeDriver)
+	pOpts.Keep = 1
+	pOpts.OlderThan = "a month"
+	assert.Error(t, pOpts.KubeRun())
+
+	// dry run leaves all revisions in place
+	pOpts.OlderThan = ""
+	pOpts.All = true
+	pOpts.DryRun = true
+	assert.NoError(t, pOpts.KubeRun())
+	hist, err := lOpts.Releases.History(lOpts.Group)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(hist))
+
+	pOpts.DryRun = false
+	assert.NoError(t, pOpts.KubeRun())
+	hist, err = lOpts.Releases.History(lOpts.Group)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(hist))
+	assert.Equal(t, 3, hist[0].Version)
+}
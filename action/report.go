@@ -1,9 +1,16 @@
 package action
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/iter8-tools/iter8/action/report"
 	"github.com/iter8-tools/iter8/base"
@@ -17,12 +24,58 @@ const (
 
 	// HTMLOutputFormat is the output format used to create html output
 	HTMLOutputFormatKey = "html"
+
+	// SARIFOutputFormatKey is the output format used to create SARIF output
+	SARIFOutputFormatKey = "sarif"
+
+	// PrometheusOutputFormatKey is the output format used to create Prometheus text exposition output
+	PrometheusOutputFormatKey = "prometheus"
+
+	// CSVOutputFormatKey is the output format used to export metric observations as CSV
+	CSVOutputFormatKey = "csv"
+
+	// ParquetOutputFormatKey is the output format used to export metric observations as Apache Parquet
+	ParquetOutputFormatKey = "parquet"
+
+	// GrafanaOutputFormatKey is the output format used to create Grafana dashboards
+	GrafanaOutputFormatKey = "grafana"
+
+	// DefaultPushJob is the default Pushgateway job under which experiment metrics are pushed
+	DefaultPushJob = "iter8"
+
+	// clearScreen moves the cursor to the top left of the terminal and clears it
+	clearScreen = "\033[H\033[2J"
 )
 
 // ReportOpts are the options used for generating reports from experiment result
 type ReportOpts struct {
 	// OutputFormat specifies the output format to be used by report
 	OutputFormat string
+	// TemplateFile is the path to a user-supplied Go template used to render the report.
+	// If specified, OutputFormat is ignored.
+	TemplateFile string
+	// Compare lists other experiments to be compared side-by-side with this experiment.
+	// For local experiments, these are directories containing experiment.yaml files,
+	// or URLs of experiments in object storage.
+	// For Kubernetes experiments, these are experiment groups; the latest revision of each group is compared,
+	// since earlier revisions are not kept.
+	Compare []string
+	// Pushgateway is the URL of a Prometheus Pushgateway to which experiment metrics and
+	// SLO verdicts are pushed in addition to generating the report
+	Pushgateway string
+	// PushJob is the Pushgateway job under which experiment metrics are pushed
+	PushJob string
+	// Labels are added to every sample of Prometheus reports, and of experiment metrics pushed to the Pushgateway
+	Labels map[string]string
+	// Grafana is the URL of a Grafana instance in which the dashboard of the experiment is created or updated
+	// in addition to generating the report
+	Grafana string
+	// GrafanaToken is the Grafana API key or service account token used to push the dashboard
+	GrafanaToken string
+	// GrafanaDatasource is the uid of the Prometheus data source queried by dashboard panels
+	GrafanaDatasource string
+	// Follow re-renders the report whenever the result of the Kubernetes experiment changes, until interrupted
+	Follow bool
 	// RunOpts enables fetching local experiment spec and result
 	RunOpts
 	// KubeDriver enables fetching Kubernetes experiment spec and result
@@ -36,15 +89,40 @@ func NewReportOpts(kd *driver.KubeDriver) *ReportOpts {
 			RunDir: ".",
 		},
 		OutputFormat: TextOutputFormatKey,
+		PushJob:      DefaultPushJob,
 		KubeDriver:   kd,
 	}
 }
 
 // LocalRun generates report for a local experiment
 func (rOpts *ReportOpts) LocalRun(out io.Writer) error {
-	return rOpts.Run(&driver.FileDriver{
-		RunDir: rOpts.RunDir,
-	}, out)
+	fd, err := rOpts.localDriver()
+	if err != nil {
+		return err
+	}
+	if len(rOpts.Compare) > 0 {
+		names := []string{rOpts.RunDir}
+		if rOpts.ObjectURL != "" {
+			names[0] = rOpts.ObjectURL
+		}
+		drivers := []base.Driver{fd}
+		for _, name := range rOpts.Compare {
+			names = append(names, name)
+			if driver.IsObjectURL(name) {
+				od, err := driver.NewObjectDriver(name, "")
+				if err != nil {
+					return err
+				}
+				drivers = append(drivers, od)
+				continue
+			}
+			drivers = append(drivers, &driver.FileDriver{
+				RunDir: name,
+			})
+		}
+		return rOpts.RunCompare(names, drivers)
+	}
+	return rOpts.Run(fd, out)
 }
 
 // KubeRun generates report for a Kubernetes experiment
@@ -52,33 +130,173 @@ func (rOpts *ReportOpts) KubeRun(out io.Writer) error {
 	if err := rOpts.KubeDriver.Init(); err != nil {
 		return err
 	}
+	if rOpts.Follow {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return rOpts.follow(ctx, out)
+	}
+	if len(rOpts.Compare) > 0 {
+		names := append([]string{rOpts.Group}, rOpts.Compare...)
+		drivers := []base.Driver{rOpts.KubeDriver}
+		for _, group := range rOpts.Compare {
+			kd := *rOpts.KubeDriver
+			kd.Group = group
+			drivers = append(drivers, &kd)
+		}
+		return rOpts.RunCompare(names, drivers)
+	}
 	return rOpts.Run(rOpts, out)
 }
 
-// Run generates the text or HTML report
+// RunCompare logs a text report comparing the experiments read using the given drivers
+func (rOpts *ReportOpts) RunCompare(names []string, drivers []base.Driver) error {
+	if strings.ToLower(rOpts.OutputFormat) != TextOutputFormatKey || rOpts.TemplateFile != "" {
+		e := errors.New("comparison reports are only supported in text format")
+		log.Logger.Error(e)
+		return e
+	}
+	reporter := report.CompareReporter{
+		Names: names,
+	}
+	for i, d := range drivers {
+		e, err := base.BuildExperiment(d)
+		if err != nil {
+			log.Logger.Errorf("unable to read experiment %v", names[i])
+			return err
+		}
+		reporter.Experiments = append(reporter.Experiments, e)
+	}
+	var b bytes.Buffer
+	if err := reporter.Gen(&b); err != nil {
+		return err
+	}
+	log.Logger.WithIndentedTrace(b.String()).Info("comparison of experiments")
+	return nil
+}
+
+// follow watches the Kubernetes experiment, and generates the report whenever its result changes, until the context is done.
+// In terminals, the screen is cleared before each report, so that the report is updated in place.
+func (rOpts *ReportOpts) follow(ctx context.Context, out io.Writer) error {
+	if len(rOpts.Compare) > 0 {
+		e := errors.New("the follow option cannot be used with comparison reports")
+		log.Logger.Error(e)
+		return e
+	}
+	clear := false
+	if f, ok := out.(*os.File); ok {
+		clear = IsTerminal(f)
+	}
+	return rOpts.KubeDriver.WatchExperiment(ctx, func(e *base.Experiment) error {
+		if clear {
+			fmt.Fprint(out, clearScreen)
+		}
+		return rOpts.report(e, out)
+	})
+}
+
+// Run generates the text, HTML, SARIF, Prometheus, CSV or Parquet report, the Grafana dashboard, or the report defined by a user-supplied template
 func (rOpts *ReportOpts) Run(eio base.Driver, out io.Writer) error {
-	if e, err := base.BuildExperiment(eio); err != nil {
+	e, err := base.BuildExperiment(eio)
+	if err != nil {
 		return err
-	} else {
-		switch strings.ToLower(rOpts.OutputFormat) {
-		case TextOutputFormatKey:
-			reporter := report.TextReporter{
-				Reporter: &report.Reporter{
-					Experiment: e,
-				},
-			}
-			return reporter.Gen(out)
-		case HTMLOutputFormatKey:
-			reporter := report.HTMLReporter{
-				Reporter: &report.Reporter{
-					Experiment: e,
-				},
-			}
-			return reporter.Gen(out)
-		default:
-			e := fmt.Errorf("unsupported report format %v", rOpts.OutputFormat)
-			log.Logger.Error(e)
+	}
+	return rOpts.report(e, out)
+}
+
+// report generates the report of the experiment
+func (rOpts *ReportOpts) report(e *base.Experiment, out io.Writer) error {
+	if rOpts.Pushgateway != "" {
+		pr := report.PrometheusReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+			Labels: rOpts.Labels,
+		}
+		if err := pr.Push(rOpts.Pushgateway, rOpts.PushJob); err != nil {
+			return err
+		}
+	}
+	if rOpts.Grafana != "" {
+		if err := rOpts.grafanaReporter(e).Push(rOpts.Grafana, rOpts.GrafanaToken); err != nil {
+			return err
+		}
+	}
+	if rOpts.TemplateFile != "" {
+		tpl, err := ioutil.ReadFile(rOpts.TemplateFile)
+		if err != nil {
+			e := errors.New("unable to read report template")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
 			return e
 		}
+		reporter := report.TemplateReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+			Template: string(tpl),
+		}
+		return reporter.Gen(out)
+	}
+	switch strings.ToLower(rOpts.OutputFormat) {
+	case TextOutputFormatKey:
+		reporter := report.TextReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+		}
+		return reporter.Gen(out)
+	case HTMLOutputFormatKey:
+		reporter := report.HTMLReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+		}
+		return reporter.Gen(out)
+	case SARIFOutputFormatKey:
+		reporter := report.SARIFReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+		}
+		return reporter.Gen(out)
+	case PrometheusOutputFormatKey:
+		reporter := report.PrometheusReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+			Labels: rOpts.Labels,
+		}
+		return reporter.Gen(out)
+	case CSVOutputFormatKey:
+		reporter := report.CSVReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+		}
+		return reporter.Gen(out)
+	case ParquetOutputFormatKey:
+		reporter := report.ParquetReporter{
+			Reporter: &report.Reporter{
+				Experiment: e,
+			},
+		}
+		return reporter.Gen(out)
+	case GrafanaOutputFormatKey:
+		return rOpts.grafanaReporter(e).Gen(out)
+	default:
+		e := fmt.Errorf("unsupported report format %v", rOpts.OutputFormat)
+		log.Logger.Error(e)
+		return e
+	}
+}
+
+// grafanaReporter returns the reporter of the Grafana dashboard of the experiment, whose panels query
+// the metrics pushed under the Pushgateway job
+func (rOpts *ReportOpts) grafanaReporter(e *base.Experiment) *report.GrafanaReporter {
+	return &report.GrafanaReporter{
+		Reporter: &report.Reporter{
+			Experiment: e,
+		},
+		Job:        rOpts.PushJob,
+		Datasource: rOpts.GrafanaDatasource,
 	}
 }
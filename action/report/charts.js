@@ -0,0 +1,220 @@
+// iter8Charts is a minimal, dependency-free SVG charting library
+// used by the Iter8 HTML report. It is embedded in the Iter8 CLI
+// so that HTML reports can be viewed without network access.
+var iter8Charts = (function () {
+  var svgNS = "http://www.w3.org/2000/svg";
+  var palette = ["#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+    "#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf"];
+  var width = 760, height = 320;
+  var margin = { top: 40, right: 20, bottom: 60, left: 70 };
+
+  // el creates an SVG element with the given attributes
+  function el(name, attrs, parent) {
+    var e = document.createElementNS(svgNS, name);
+    for (var k in attrs) {
+      e.setAttribute(k, attrs[k]);
+    }
+    if (parent) {
+      parent.appendChild(e);
+    }
+    return e;
+  }
+
+  // text creates an SVG text element
+  function text(parent, x, y, str, attrs) {
+    var a = attrs || {};
+    a.x = x;
+    a.y = y;
+    var t = el("text", a, parent);
+    t.textContent = str;
+    return t;
+  }
+
+  // fmt formats a number for axis ticks and tooltips
+  function fmt(v) {
+    if (v === null || v === undefined || isNaN(v)) {
+      return "unavailable";
+    }
+    if (Math.abs(v) >= 1000 || (Math.abs(v) > 0 && Math.abs(v) < 0.01)) {
+      return v.toExponential(2);
+    }
+    return (Math.round(v * 100) / 100).toString();
+  }
+
+  // scale returns a linear scale function from domain to range
+  function scale(d0, d1, r0, r1) {
+    if (d1 === d0) {
+      d1 = d0 + 1;
+    }
+    return function (v) {
+      return r0 + (v - d0) * (r1 - r0) / (d1 - d0);
+    };
+  }
+
+  // frame creates the SVG canvas with title, axes and ticks
+  function frame(id, title, xLabel, yLabel, xMin, xMax, yMax, xTicks) {
+    var container = document.getElementById(id);
+    var svg = el("svg", {
+      viewBox: "0 0 " + width + " " + height,
+      width: "100%",
+      role: "img",
+      "font-family": "sans-serif",
+      "font-size": "12"
+    }, container);
+    text(svg, width / 2, 20, title, { "text-anchor": "middle", "font-size": "15" });
+    var x = scale(xMin, xMax, margin.left, width - margin.right);
+    var y = scale(0, yMax, height - margin.bottom, margin.top);
+    var axes = el("g", { stroke: "#333" }, svg);
+    el("line", { x1: margin.left, y1: height - margin.bottom, x2: width - margin.right, y2: height - margin.bottom }, axes);
+    el("line", { x1: margin.left, y1: margin.top, x2: margin.left, y2: height - margin.bottom }, axes);
+    // y ticks
+    for (var i = 0; i <= 4; i++) {
+      var v = yMax * i / 4;
+      el("line", { x1: margin.left - 4, y1: y(v), x2: width - margin.right, y2: y(v), stroke: "#eee" }, svg);
+      text(svg, margin.left - 8, y(v) + 4, fmt(v), { "text-anchor": "end" });
+    }
+    // x ticks
+    if (xTicks) {
+      xTicks.forEach(function (t) {
+        text(svg, x(t.at), height - margin.bottom + 18, t.label, { "text-anchor": "middle" });
+      });
+    } else {
+      for (var j = 0; j <= 4; j++) {
+        var xv = xMin + (xMax - xMin) * j / 4;
+        text(svg, x(xv), height - margin.bottom + 18, fmt(xv), { "text-anchor": "middle" });
+      }
+    }
+    text(svg, (margin.left + width - margin.right) / 2, height - 15, xLabel, { "text-anchor": "middle" });
+    text(svg, 15, (margin.top + height - margin.bottom) / 2, yLabel, {
+      "text-anchor": "middle",
+      transform: "rotate(-90 15 " + (margin.top + height - margin.bottom) / 2 + ")"
+    });
+    return { svg: svg, x: x, y: y };
+  }
+
+  // legend draws a legend for the given series names
+  function legend(svg, names) {
+    if (names.length < 2) {
+      return;
+    }
+    names.forEach(function (n, i) {
+      var lx = width - margin.right - 110;
+      var ly = margin.top + i * 16;
+      el("rect", { x: lx, y: ly - 9, width: 10, height: 10, fill: palette[i % palette.length] }, svg);
+      text(svg, lx + 14, ly, n);
+    });
+  }
+
+  // tooltip attaches a native SVG tooltip to an element
+  function tooltip(e, str) {
+    var t = el("title", {}, e);
+    t.textContent = str;
+  }
+
+  // histogram draws overlaid histograms, one per series
+  // each series is {name: string, buckets: [{lower, upper, count}]}
+  function histogram(id, title, xLabel, series) {
+    var xMin = Infinity, xMax = -Infinity, yMax = 0;
+    series.forEach(function (s) {
+      var total = 0;
+      (s.buckets || []).forEach(function (b) { total += b.count; });
+      s.total = total;
+      (s.buckets || []).forEach(function (b) {
+        xMin = Math.min(xMin, b.lower);
+        xMax = Math.max(xMax, b.upper);
+        if (total > 0) {
+          yMax = Math.max(yMax, 100 * b.count / total);
+        }
+      });
+    });
+    if (!isFinite(xMin)) {
+      return;
+    }
+    var f = frame(id, title, xLabel, "% of observations", xMin, xMax, yMax || 1);
+    series.forEach(function (s, i) {
+      var g = el("g", { fill: palette[i % palette.length], "fill-opacity": 0.5 }, f.svg);
+      (s.buckets || []).forEach(function (b) {
+        var pct = s.total > 0 ? 100 * b.count / s.total : 0;
+        var r = el("rect", {
+          x: f.x(b.lower),
+          y: f.y(pct),
+          width: Math.max(1, f.x(b.upper) - f.x(b.lower)),
+          height: f.y(0) - f.y(pct)
+        }, g);
+        tooltip(r, s.name + "\nrange: [" + fmt(b.lower) + ", " + fmt(b.upper) + "]\ncount: " + b.count + " (" + fmt(pct) + "%)");
+      });
+    });
+    legend(f.svg, series.map(function (s) { return s.name; }));
+  }
+
+  // line draws one line per series over the observation index
+  // each series is {name: string, values: [number]}
+  function line(id, title, xLabel, yLabel, series) {
+    var n = 0, yMax = 0;
+    series.forEach(function (s) {
+      n = Math.max(n, (s.values || []).length);
+      (s.values || []).forEach(function (v) { yMax = Math.max(yMax, v); });
+    });
+    if (n === 0) {
+      return;
+    }
+    var ticks = [];
+    for (var k = 1; k <= n; k++) {
+      if (n <= 10 || k === 1 || k === n || k % Math.ceil(n / 10) === 0) {
+        ticks.push({ at: k, label: k.toString() });
+      }
+    }
+    var f = frame(id, title, xLabel, yLabel, 1, Math.max(n, 2), yMax || 1, ticks);
+    series.forEach(function (s, i) {
+      var color = palette[i % palette.length];
+      var pts = (s.values || []).map(function (v, j) { return f.x(j + 1) + "," + f.y(v); });
+      el("polyline", { points: pts.join(" "), fill: "none", stroke: color, "stroke-width": 2 }, f.svg);
+      (s.values || []).forEach(function (v, j) {
+        var c = el("circle", { cx: f.x(j + 1), cy: f.y(v), r: 3, fill: color }, f.svg);
+        tooltip(c, s.name + "\n" + xLabel + ": " + (j + 1) + "\nvalue: " + fmt(v));
+      });
+    });
+    legend(f.svg, series.map(function (s) { return s.name; }));
+  }
+
+  // bar draws one bar per category
+  // values may contain nulls for unavailable values
+  // limit (optional) is drawn as a dashed horizontal line
+  function bar(id, title, yLabel, categories, values, limit) {
+    var yMax = 0;
+    values.forEach(function (v) {
+      if (v !== null) {
+        yMax = Math.max(yMax, v);
+      }
+    });
+    if (limit !== null && limit !== undefined) {
+      yMax = Math.max(yMax, limit);
+    }
+    var ticks = categories.map(function (c, i) { return { at: i + 0.5, label: c }; });
+    var f = frame(id, title, "", yLabel, 0, categories.length, yMax || 1, ticks);
+    var bw = (f.x(1) - f.x(0)) * 0.6;
+    values.forEach(function (v, i) {
+      if (v === null) {
+        text(f.svg, f.x(i + 0.5), f.y(0) - 6, "unavailable", { "text-anchor": "middle", fill: "#999" });
+        return;
+      }
+      var r = el("rect", {
+        x: f.x(i + 0.5) - bw / 2,
+        y: f.y(v),
+        width: bw,
+        height: f.y(0) - f.y(v),
+        fill: palette[i % palette.length]
+      }, f.svg);
+      tooltip(r, categories[i] + ": " + fmt(v));
+    });
+    if (limit !== null && limit !== undefined) {
+      el("line", {
+        x1: margin.left, y1: f.y(limit), x2: width - margin.right, y2: f.y(limit),
+        stroke: "#d62728", "stroke-dasharray": "6,4"
+      }, f.svg);
+      text(f.svg, width - margin.right, f.y(limit) - 4, "limit: " + fmt(limit), { "text-anchor": "end", fill: "#d62728" });
+    }
+  }
+
+  return { histogram: histogram, line: line, bar: bar };
+})();
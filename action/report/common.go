@@ -1,6 +1,7 @@
 package report
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
 
@@ -8,6 +9,22 @@ import (
 	"github.com/iter8-tools/iter8/base/log"
 )
 
+// init registers the text and HTML reports so that tasks can send them
+func init() {
+	base.RegisterReportRenderer("text", func(e *base.Experiment) (string, error) {
+		var b bytes.Buffer
+		tr := TextReporter{Reporter: &Reporter{Experiment: e}}
+		err := tr.Gen(&b)
+		return b.String(), err
+	})
+	base.RegisterReportRenderer("html", func(e *base.Experiment) (string, error) {
+		var b bytes.Buffer
+		hr := HTMLReporter{Reporter: &Reporter{Experiment: e}}
+		err := hr.Gen(&b)
+		return b.String(), err
+	})
+}
+
 // Reporter implements methods that are common to text and HTML reporting.
 type Reporter struct {
 	// Experiment enables access to all base.Experiment data and methods
@@ -89,3 +106,43 @@ func (r *Reporter) MetricWithUnits(metricName string) (string, error) {
 	}
 	return str, nil
 }
+
+// VersionSatisfiesSLOs returns true if the given app version (j) satisfies all SLOs
+func (r *Reporter) VersionSatisfiesSLOs(j int) bool {
+	if r.Result == nil || r.Result.Insights == nil || j < 0 || j >= r.Result.Insights.NumVersions {
+		return false
+	}
+	in := r.Result.Insights
+	if in.SLOs == nil {
+		return true
+	}
+	if in.SLOsSatisfied == nil {
+		return false
+	}
+	for _, rows := range [][][]bool{in.SLOsSatisfied.Upper, in.SLOsSatisfied.Lower} {
+		for _, row := range rows {
+			if j >= len(row) || !row[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// PairwiseComparisons compares each pair of versions using each sample and histogram metric, in sorted order of metrics
+func (r *Reporter) PairwiseComparisons() []base.PairwiseComparison {
+	in := r.Result.Insights
+	pcs := []base.PairwiseComparison{}
+	for _, mn := range in.DistributionMetrics() {
+		pcs = append(pcs, in.PairwiseComparisons(mn)...)
+	}
+	return pcs
+}
+
+// ComparisonValueStr converts a value of a pairwise comparison to string so that it can be printed in text and HTML reports
+func (r *Reporter) ComparisonValueStr(val *float64) string {
+	if val == nil {
+		return "unavailable"
+	}
+	return fmt.Sprintf("%0.3f", *val)
+}
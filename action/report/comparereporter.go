@@ -0,0 +1,147 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/iter8-tools/iter8/base"
+)
+
+// CompareReporter supports generation of text reports that compare multiple experiments.
+type CompareReporter struct {
+	// Names are the names of the experiments; used as column headers
+	Names []string
+	// Experiments are the experiments being compared
+	Experiments []*base.Experiment
+}
+
+// column is a single column in the comparison table
+type column struct {
+	// header of the column
+	header string
+	// reporter for the experiment in this column
+	reporter *Reporter
+	// version is the index of the app version in this column
+	version int
+}
+
+// columns returns one column per (experiment, version) pair
+func (cr *CompareReporter) columns() []column {
+	cols := []column{}
+	for k, e := range cr.Experiments {
+		r := &Reporter{Experiment: e}
+		if e.Result == nil || e.Result.Insights == nil || e.Result.Insights.NumVersions <= 1 {
+			cols = append(cols, column{header: cr.Names[k], reporter: r})
+			continue
+		}
+		for j := 0; j < e.Result.Insights.NumVersions; j++ {
+			cols = append(cols, column{
+				header:   fmt.Sprintf("%v (version %v)", cr.Names[k], j),
+				reporter: r,
+				version:  j,
+			})
+		}
+	}
+	return cols
+}
+
+// hasInsights returns true if the reporter's experiment has insights
+func hasInsights(r *Reporter) bool {
+	return r.Result != nil && r.Result.Insights != nil
+}
+
+// sortedMetrics returns the union of scalar and SLO metrics across all experiments in sorted order
+func (cr *CompareReporter) sortedMetrics() []string {
+	keys := []string{}
+	for _, e := range cr.Experiments {
+		r := &Reporter{Experiment: e}
+		if hasInsights(r) {
+			keys = append(keys, r.SortedScalarAndSLOMetrics()...)
+		}
+	}
+	tmp := base.Uniq(keys)
+	uniqKeys := []string{}
+	for _, val := range tmp {
+		uniqKeys = append(uniqKeys, val.(string))
+	}
+	sort.Strings(uniqKeys)
+	return uniqKeys
+}
+
+// metricWithUnits returns the metric name with units using the first experiment that knows about the metric
+func (cr *CompareReporter) metricWithUnits(mn string) string {
+	for _, e := range cr.Experiments {
+		r := &Reporter{Experiment: e}
+		if !hasInsights(r) {
+			continue
+		}
+		if nm, err := base.NormalizeMetricName(mn); err == nil {
+			if _, err := r.Result.Insights.GetMetricsInfo(nm); err == nil {
+				if mwu, err := r.MetricWithUnits(mn); err == nil {
+					return mwu
+				}
+			}
+		}
+	}
+	return mn
+}
+
+// Gen writes the comparison table into the given writer
+func (cr *CompareReporter) Gen(out io.Writer) error {
+	cols := cr.columns()
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', tabwriter.Debug)
+
+	// header
+	fmt.Fprint(w, "Metric")
+	for _, c := range cols {
+		fmt.Fprintf(w, "\t%v", c.header)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, "------")
+	for range cols {
+		fmt.Fprint(w, "\t-----")
+	}
+	fmt.Fprintln(w)
+
+	// experiment status
+	fmt.Fprint(w, "completed")
+	for _, c := range cols {
+		fmt.Fprintf(w, "\t%v", c.reporter.Completed())
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, "no task failures")
+	for _, c := range cols {
+		fmt.Fprintf(w, "\t%v", c.reporter.NoFailure())
+	}
+	fmt.Fprintln(w)
+
+	// SLOs
+	fmt.Fprint(w, "SLOs satisfied")
+	for _, c := range cols {
+		switch {
+		case !hasInsights(c.reporter):
+			fmt.Fprint(w, "\tunavailable")
+		case c.reporter.Result.Insights.SLOs == nil:
+			fmt.Fprint(w, "\tno SLOs")
+		default:
+			fmt.Fprintf(w, "\t%v", c.reporter.VersionSatisfiesSLOs(c.version))
+		}
+	}
+	fmt.Fprintln(w)
+
+	// metrics
+	for _, mn := range cr.sortedMetrics() {
+		fmt.Fprint(w, cr.metricWithUnits(mn))
+		for _, c := range cols {
+			if !hasInsights(c.reporter) {
+				fmt.Fprint(w, "\tunavailable")
+				continue
+			}
+			fmt.Fprintf(w, "\t%v", c.reporter.ScalarMetricValueStr(c.version, mn))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
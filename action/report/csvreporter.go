@@ -0,0 +1,63 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+// CSVReporter supports generation of CSV files from experiments.
+// Each row is an observation of a metric for a version, so that raw experiment data can be analyzed
+// with tools such as pandas.
+type CSVReporter struct {
+	// Reporter is embedded and enables access to all reporter data and methods
+	*Reporter
+}
+
+// Gen writes the metric observations of the experiment in CSV format, with a header row.
+// Columns that do not apply to an observation are empty.
+func (cr *CSVReporter) Gen(out io.Writer) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(observationColumns); err != nil {
+		e := errors.New("unable to write CSV report")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	for _, o := range cr.observations() {
+		record := []string{
+			strconv.Itoa(o.Version),
+			o.Metric,
+			string(o.Type),
+			"",
+			strconv.Itoa(o.Index),
+			csvFloat(o.Value),
+			csvFloat(o.Lower),
+			csvFloat(o.Upper),
+			"",
+		}
+		if o.Units != nil {
+			record[3] = *o.Units
+		}
+		if o.Count != nil {
+			record[8] = strconv.FormatUint(*o.Count, 10)
+		}
+		if err := w.Write(record); err != nil {
+			e := errors.New("unable to write CSV report")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvFloat formats the value with the minimum precision needed to represent it; nil values are empty
+func csvFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'g', -1, 64)
+}
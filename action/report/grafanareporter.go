@@ -0,0 +1,277 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// grafanaDashboardTitle is the title of generated Grafana dashboards
+	grafanaDashboardTitle = "Iter8 experiment"
+	// grafanaDashboardPath is the path of the Grafana HTTP API used to create and update dashboards
+	grafanaDashboardPath = "/api/dashboards/db"
+	// grafanaPanelWidth is the width of metric panels; the Grafana grid is 24 units wide
+	grafanaPanelWidth = 12
+	// grafanaPanelHeight is the height of panels
+	grafanaPanelHeight = 8
+)
+
+// GrafanaReporter supports generation of Grafana dashboards from experiments.
+// The dashboard has a panel for each experiment metric, with SLO limits drawn as thresholds.
+// Panels query the metrics pushed to a Prometheus Pushgateway by the Prometheus reporter,
+// so that successive runs of the experiment appear as a time series.
+// A text panel records the metric values and SLO verdicts of this run.
+type GrafanaReporter struct {
+	// Reporter is embedded and enables access to all reporter data and methods
+	*Reporter
+	// Job is the Pushgateway job under which experiment metrics are pushed
+	Job string
+	// Datasource is the uid of the Prometheus data source queried by panels; if empty, the default data source is used
+	Datasource string
+}
+
+// grafanaPanel is a Grafana dashboard panel
+type grafanaPanel map[string]interface{}
+
+// datasource returns the data source of panels and queries
+func (gr *GrafanaReporter) datasource() interface{} {
+	if gr.Datasource == "" {
+		return nil
+	}
+	return map[string]string{
+		"type": "prometheus",
+		"uid":  gr.Datasource,
+	}
+}
+
+// selector returns the Prometheus label selector for the given metric of the experiment
+func (gr *GrafanaReporter) selector(metric string) string {
+	ls := []string{}
+	if gr.Job != "" {
+		ls = append(ls, fmt.Sprintf(`job="%v"`, promEscape(gr.Job)))
+	}
+	ls = append(ls, fmt.Sprintf(`metric="%v"`, promEscape(metric)))
+	return "{" + strings.Join(ls, ",") + "}"
+}
+
+// thresholds returns the threshold steps for the SLOs of the given metric, and whether there are any.
+// Values violating an SLO are red; other values are green.
+func (gr *GrafanaReporter) thresholds(metric string) ([]map[string]interface{}, bool) {
+	in := gr.Result.Insights
+	if in.SLOs == nil {
+		return nil, false
+	}
+	var upper, lower *float64
+	for _, slo := range in.SLOs.Upper {
+		if nm, err := base.NormalizeMetricName(slo.Metric); err == nil && nm == metric {
+			l := slo.Limit
+			if upper == nil || l < *upper {
+				upper = &l
+			}
+		}
+	}
+	for _, slo := range in.SLOs.Lower {
+		if nm, err := base.NormalizeMetricName(slo.Metric); err == nil && nm == metric {
+			l := slo.Limit
+			if lower == nil || l > *lower {
+				lower = &l
+			}
+		}
+	}
+	if upper == nil && lower == nil {
+		return nil, false
+	}
+	steps := []map[string]interface{}{}
+	if lower != nil {
+		steps = append(steps,
+			map[string]interface{}{"color": "red", "value": nil},
+			map[string]interface{}{"color": "green", "value": *lower})
+	} else {
+		steps = append(steps, map[string]interface{}{"color": "green", "value": nil})
+	}
+	if upper != nil {
+		steps = append(steps, map[string]interface{}{"color": "red", "value": *upper})
+	}
+	return steps, true
+}
+
+// metricPanel returns the time series panel of the given metric
+func (gr *GrafanaReporter) metricPanel(id int, metric string) grafanaPanel {
+	title := metric
+	if mwu, err := gr.MetricWithUnits(metric); err == nil {
+		title = mwu
+	}
+	custom := map[string]interface{}{}
+	defaults := map[string]interface{}{
+		"custom": custom,
+	}
+	if steps, ok := gr.thresholds(metric); ok {
+		defaults["thresholds"] = map[string]interface{}{
+			"mode":  "absolute",
+			"steps": steps,
+		}
+		custom["thresholdsStyle"] = map[string]string{"mode": "line"}
+	}
+	description := ""
+	if m, err := gr.Result.Insights.GetMetricsInfo(metric); err == nil {
+		description = m.Description
+	}
+	return grafanaPanel{
+		"id":          id,
+		"type":        "timeseries",
+		"title":       title,
+		"description": description,
+		"datasource":  gr.datasource(),
+		"gridPos": map[string]int{
+			"x": ((id - 2) % 2) * grafanaPanelWidth,
+			"y": grafanaPanelHeight * (1 + (id-2)/2),
+			"w": grafanaPanelWidth,
+			"h": grafanaPanelHeight,
+		},
+		"fieldConfig": map[string]interface{}{
+			"defaults":  defaults,
+			"overrides": []interface{}{},
+		},
+		"targets": []map[string]interface{}{{
+			"refId":        "A",
+			"datasource":   gr.datasource(),
+			"expr":         "iter8_metric_value" + gr.selector(metric),
+			"legendFormat": "version {{version}}",
+		}},
+	}
+}
+
+// summary returns the markdown table with the metric values and SLO verdicts of this run
+func (gr *GrafanaReporter) summary() string {
+	in := gr.Result.Insights
+	var b strings.Builder
+	b.WriteString("| Metric |")
+	for j := 0; j < in.NumVersions; j++ {
+		fmt.Fprintf(&b, " version %v |", j)
+	}
+	b.WriteString("\n|---|")
+	b.WriteString(strings.Repeat("---|", in.NumVersions))
+	b.WriteString("\n")
+	for _, mn := range gr.SortedScalarAndSLOMetrics() {
+		title := mn
+		if mwu, err := gr.MetricWithUnits(mn); err == nil {
+			title = mwu
+		}
+		fmt.Fprintf(&b, "| %v |", title)
+		for j := 0; j < in.NumVersions; j++ {
+			fmt.Fprintf(&b, " %v |", gr.ScalarMetricValueStr(j, mn))
+		}
+		b.WriteString("\n")
+	}
+	if in.SLOs != nil {
+		b.WriteString("| **Satisfies SLOs** |")
+		for j := 0; j < in.NumVersions; j++ {
+			fmt.Fprintf(&b, " %v |", gr.VersionSatisfiesSLOs(j))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nExperiment completed: %v; no task failures: %v\n", gr.Completed(), gr.NoFailure())
+	return b.String()
+}
+
+// Dashboard returns the Grafana dashboard model of the experiment
+func (gr *GrafanaReporter) Dashboard() map[string]interface{} {
+	panels := []grafanaPanel{}
+	if gr.Result != nil && gr.Result.Insights != nil {
+		panels = append(panels, grafanaPanel{
+			"id":    1,
+			"type":  "text",
+			"title": "Latest run",
+			"gridPos": map[string]int{
+				"x": 0,
+				"y": 0,
+				"w": 2 * grafanaPanelWidth,
+				"h": grafanaPanelHeight,
+			},
+			"options": map[string]string{
+				"mode":    "markdown",
+				"content": gr.summary(),
+			},
+		})
+		for i, mn := range gr.SortedScalarAndSLOMetrics() {
+			panels = append(panels, gr.metricPanel(i+2, mn))
+		}
+	}
+	return map[string]interface{}{
+		"title":         grafanaDashboardTitle,
+		"tags":          []string{"iter8"},
+		"timezone":      "browser",
+		"schemaVersion": 36,
+		"time": map[string]string{
+			"from": "now-7d",
+			"to":   "now",
+		},
+		"panels": panels,
+	}
+}
+
+// Gen writes the Grafana dashboard JSON of the experiment into the given writer.
+// The dashboard can be imported into Grafana.
+func (gr *GrafanaReporter) Gen(out io.Writer) error {
+	b, err := json.MarshalIndent(gr.Dashboard(), "", "  ")
+	if err != nil {
+		e := errors.New("unable to marshal Grafana dashboard")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	_, err = fmt.Fprintln(out, string(b))
+	return err
+}
+
+// Push creates or updates the Grafana dashboard of the experiment using the Grafana HTTP API at the given URL.
+// The token is a Grafana API key or service account token; if empty, no authorization header is sent.
+func (gr *GrafanaReporter) Push(grafanaURL string, token string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"dashboard": gr.Dashboard(),
+		"overwrite": true,
+		"message":   "updated by Iter8",
+	})
+	if err != nil {
+		e := errors.New("unable to marshal Grafana dashboard")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	u := strings.TrimSuffix(grafanaURL, "/") + grafanaDashboardPath
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		e := errors.New("unable to create Grafana request")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		log.AddSecret(token)
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: pushTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		e := errors.New("unable to push dashboard to Grafana")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e := fmt.Errorf("Grafana returned status code %v", resp.StatusCode)
+		log.Logger.Error(e)
+		return e
+	}
+	log.Logger.Infof("pushed experiment dashboard to %v", strings.TrimSuffix(grafanaURL, "/"))
+	return nil
+}
@@ -4,59 +4,33 @@
     <!-- Required meta tags -->
     <meta charset="utf-8">
     <meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
-    <!-- Font Awesome -->
-    <script src="https://kit.fontawesome.com/db794f5235.js" crossorigin="anonymous"></script>
-    <!-- Bootstrap CSS -->
-    <link rel="stylesheet" href="https://stackpath.bootstrapcdn.com/bootstrap/4.3.1/css/bootstrap.min.css" integrity="sha384-ggOyR0iXCbMQv3Xipma34MD+dH/1fQ784/j6cY/iJTQUOhcWr7x9JvoRxT2MZw1T" crossorigin="anonymous">
+    <!-- Iter8 styles (embedded; no external dependencies) -->
     <style>
-      html {
-        font-size: 18px;
-      }		
+      {{ .ReportCSS }}
     </style>
     <title>Experiment Report</title>
   </head>
 
   <body>
-    <!-- jQuery first, then Popper.js, then Bootstrap JS -->
-    <script src="https://code.jquery.com/jquery-3.3.1.slim.min.js" integrity="sha384-q8i/X+965DzO0rT7abK41JStQIAqVgRVzpbzo5smXKp4YfRvH+8abtTE1Pi6jizo" crossorigin="anonymous"></script>
-    <script src="https://cdnjs.cloudflare.com/ajax/libs/popper.js/1.14.7/umd/popper.min.js" integrity="sha384-UO2eT0CpHqdSJQ6hJty5KVphtPhzWj9WO1clHTMGa3JDZwrnQq4sF86dIHNDz0W1" crossorigin="anonymous"></script>
-    <script src="https://stackpath.bootstrapcdn.com/bootstrap/4.3.1/js/bootstrap.min.js" integrity="sha384-JjSmVgyd0p3pXB1rRibZUAYoIIy6OrQ6VrjIEaFf/nJGzIxFDsf4x0xIM+B07jRM" crossorigin="anonymous"></script>
-  
-    <!-- Plotly.js -->
-    <script src="https://cdn.plot.ly/plotly-2.8.3.min.js"></script>
+    <!-- Iter8 charts (embedded; no external dependencies) -->
+    <script>
+      {{ .ChartsJS }}
+    </script>
 
     <div class="container">
       <h1 class="display-4"><a href="https://iter8.tools">Iter8</a> Experiment Report</h1>
       <hr>
       
-      <div class="toast fade {{ .RenderStr "showClassStatus" }} mw-100" role="alert" aria-live="assertive" aria-atomic="true">
-        <div class="toast-header {{ .RenderStr "textColorStatus" }}">
-          <strong class="mr-auto">
-            Experiment Status
-            &nbsp;&nbsp;
-            <i class="fas fa-thumbs-{{ .RenderStr "thumbsStatus" }}"></i>
-          </strong>
-          <button type="button" class="ml-2 mb-1 close" data-dismiss="toast" aria-label="Close">
-            <span aria-hidden="true">&times;</span>
-          </button>
+      <div class="status" role="status">
+        <div class="status-header {{ .RenderStr "textColorStatus" }}">
+          Experiment Status
+          &nbsp;&nbsp;
+          {{ .RenderStr "thumbsStatus" }}
         </div>
-        <div class="toast-body {{ .RenderStr "textColorStatus" }}">
+        <div class="status-body {{ .RenderStr "textColorStatus" }}">
           {{ .RenderStr "msgStatus" }}
         </div>
       </div>
-    
-      <script>
-      $(document).ready(function(){
-        $(function () {
-          $('[data-toggle="tooltip"]').tooltip()
-        });	
-
-        $(".toast").toast({
-          autohide: false,
-          delay: 10000
-        }).toast('show');
-      });	
-      </script>
 
       {{- if .Result.Insights }}
         {{- if not (empty .Result.Insights.SLOs) }}  
@@ -81,14 +55,14 @@
                 {{- range $ind, $slo := .Result.Insights.SLOs.Upper }}
                 <tr scope="row">
                   <td>
-                    <a href="javascript:void(0)" data-toggle="tooltip" data-placement="top" title="{{ $.MetricDescriptionHTML $slo.Metric }}">
+                    <a href="javascript:void(0)" title="{{ $.MetricDescriptionHTML $slo.Metric }}">
                       {{ $.MetricWithUnits $slo.Metric }}
                     </a>
                     &leq; {{ $slo.Limit -}}
                   </td>
                   {{- range (index $.Result.Insights.SLOsSatisfied.Upper $ind) }}
                   <td class="{{ renderSLOSatisfiedCellClass .  }} text-center">
-                    <i class="far {{ renderSLOSatisfiedHTML . }}"></i>                
+                    {{ renderSLOSatisfiedHTML . }}                
                   </td>
                   {{- end }}
                 </tr>
@@ -97,13 +71,13 @@
                 <tr scope="row">
                   <td>
                     {{- $slo.Limit }} &leq;
-                    <a href="javascript:void(0)" data-toggle="tooltip" data-placement="top" title="{{ $.MetricDescriptionHTML $slo.Metric }}">
+                    <a href="javascript:void(0)" title="{{ $.MetricDescriptionHTML $slo.Metric }}">
                       {{ $.MetricWithUnits $slo.Metric }}
                     </a>
                   </td>
                   {{- range (index $.Result.Insights.SLOsSatisfied.Lower $ind) }}
                   <td class="{{ renderSLOSatisfiedCellClass .  }} text-center">
-                    <i class="far {{ renderSLOSatisfiedHTML . }}"></i>                
+                    {{ renderSLOSatisfiedHTML . }}                
                   </td>
                   {{- end }}
                 </tr>
@@ -116,30 +90,43 @@
         {{ if (.SortedVectorMetrics) }}
         <section class="mt-5">
           <h3 class="display-6">Metric Histograms</h3>
+          <h4 class="display-7 text-muted">Distribution of observed values for each version</h4>
           <hr>
 
           {{- range $ind, $mn := .SortedVectorMetrics }}
           <div id="vm-{{ $mn }}"></div>
           <script>
-            var data = [];
-            {{- range until $.Result.Insights.NumVersions }}
-            data.push({
-              x: {{ $.VectorMetricValue . $mn }},
-              name: "Version {{ . }}",
-              histnorm: "percent", 
-              opacity: 0.5, 
-              type: "histogram"
-            })
-            {{- end }}
+            iter8Charts.histogram({{ printf "vm-%v" $mn }}, {{ printf "Histogram of %v" ($.MetricWithUnits $mn) }}, {{ $.MetricWithUnits $mn }}, {{ $.VectorMetricHistograms $mn }});
+          </script>
+          {{- end }}
+        </section>
+        {{- end }}
+
+        {{ if (.SortedMetricsWithHistory) }}
+        <section class="mt-5">
+          <h3 class="display-6">Metrics over time</h3>
+          <h4 class="display-7 text-muted">Observed values for each version across experiment loops</h4>
+          <hr>
+
+          {{- range $ind, $mn := .SortedMetricsWithHistory }}
+          <div id="hm-{{ $mn }}"></div>
+          <script>
+            iter8Charts.line({{ printf "hm-%v" $mn }}, {{ printf "%v over time" $mn }}, "Loop", {{ $.MetricWithUnits $mn }}, {{ $.ScalarMetricHistory $mn }});
+          </script>
+          {{- end }}
+        </section>
+        {{- end }}
+
+        {{ if ge .Result.Insights.NumVersions 2 }}
+        <section class="mt-5">
+          <h3 class="display-6">Version comparison</h3>
+          <h4 class="display-7 text-muted">Latest observed values for metrics across versions</h4>
+          <hr>
 
-            var layout = {
-              bargroupgap: 0.2, 
-              barmode: "overlay", 
-              title: "Histogram of {{ $.MetricWithUnits $mn }}", 
-              xaxis: {title: "{{ $.MetricWithUnits $mn }}"}, 
-              yaxis: {title: "% of observations"}
-            };
-            Plotly.newPlot("vm-{{ $mn }}", data, layout);
+          {{- range $ind, $mn := .SortedScalarAndSLOMetrics }}
+          <div id="cm-{{ $mn }}"></div>
+          <script>
+            iter8Charts.bar({{ printf "cm-%v" $mn }}, {{ $.MetricWithUnits $mn }}, {{ $.MetricWithUnits $mn }}, {{ $.VersionNames }}, {{ $.ScalarMetricValues $mn }}, {{ $.SLOLimit $mn }});
           </script>
           {{- end }}
         </section>
@@ -165,7 +152,7 @@
                 {{- range $ind, $mn := .SortedScalarAndSLOMetrics }}
                 <tr scope="row">
                   <td>
-                    <a href="javascript:void(0)" data-toggle="tooltip" data-placement="top" title="{{ $.MetricDescriptionHTML $mn }}">
+                    <a href="javascript:void(0)" title="{{ $.MetricDescriptionHTML $mn }}">
                       {{ $.MetricWithUnits $mn }}
                     </a>
                   </td>
@@ -179,6 +166,62 @@
             </tbody>
           </table>
         </section>
+
+        {{- if ge .Result.Insights.NumVersions 2 }}
+        <section class="mt-5">
+          <h3 class="display-6">Ranking of versions</h3>
+          <h4 class="display-7 text-muted">Versions that satisfy SLOs first, and then by rewards</h4>
+          <hr>
+          <table class="table">
+            <thead class="thead-light">
+              <tr>
+                <th scope="col">Rank</th>
+                <th scope="col" class="text-center">Version</th>
+              </tr>
+            </thead>
+            <tbody>
+                {{- range $rank, $v := .Ranking }}
+                <tr scope="row">
+                  <td>{{ add1 $rank }}</td>
+                  <td class="text-center">Version {{ $v }}</td>
+                </tr>
+                {{- end }}
+            </tbody>
+          </table>
+        </section>
+
+        {{- if not (empty .PairwiseComparisons) }}
+        <section class="mt-5">
+          <h3 class="display-6">Pairwise comparisons of versions</h3>
+          <h4 class="display-7 text-muted">Difference of means, and their significance using Welch's t-test</h4>
+          <hr>
+          <table class="table">
+            <thead class="thead-light">
+              <tr>
+                <th scope="col">Metric</th>
+                <th scope="col" class="text-center">Versions</th>
+                <th scope="col" class="text-center">Difference</th>
+                <th scope="col" class="text-center">Relative difference</th>
+                <th scope="col" class="text-center">p-value</th>
+                <th scope="col" class="text-center">Significant</th>
+              </tr>
+            </thead>
+            <tbody>
+                {{- range $pc := .PairwiseComparisons }}
+                <tr scope="row">
+                  <td>{{ $.MetricWithUnits $pc.Metric }}</td>
+                  <td class="text-center">{{ $pc.Version }} vs {{ $pc.Other }}</td>
+                  <td class="text-center">{{ printf "%0.3f" $pc.Difference }}</td>
+                  <td class="text-center">{{ $.ComparisonValueStr $pc.RelativeDifference }}</td>
+                  <td class="text-center">{{ $.ComparisonValueStr $pc.PValue }}</td>
+                  <td class="text-center">{{ $pc.Significant }}</td>
+                </tr>
+                {{- end }}
+            </tbody>
+          </table>
+        </section>
+        {{- end }}
+        {{- end }}
       {{- else }}
         <section class="mt-5">
           <h3 class="display-6">Metrics-based Insights</h3>
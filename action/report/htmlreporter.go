@@ -27,6 +27,27 @@ type HTMLReporter struct {
 //go:embed htmlreport.tpl
 var reportHTML string
 
+// chartsJS is the charting script embedded in the HTML report
+//go:embed charts.js
+var chartsJS string
+
+// reportCSS is the style sheet embedded in the HTML report
+//go:embed report.css
+var reportCSS string
+
+// numSampleBuckets is the number of buckets used when binning sample metrics into histograms
+const numSampleBuckets = 20
+
+// ChartSeries is the data for a single version in a chart within the HTML report
+type ChartSeries struct {
+	// Name of the series
+	Name string `json:"name"`
+	// Buckets are the histogram buckets for this series (used in histogram charts)
+	Buckets []base.HistBucket `json:"buckets,omitempty"`
+	// Values are the observed values for this series (used in line charts)
+	Values []float64 `json:"values,omitempty"`
+}
+
 // Gen creates an HTML report for a given experiment
 func (ht *HTMLReporter) Gen(out io.Writer) error {
 
@@ -53,77 +74,21 @@ func (ht *HTMLReporter) Gen(out io.Writer) error {
 	return nil
 }
 
-// HTMLHistCharts returns histogram charts section in HTML report
-func (r *HTMLReporter) HTMLHistCharts() string {
-	return `
-	<script>
-		var charts = [];
-		for (let i = 0; i < chartData.length; i++) {
-			nv.addGraph(function() {
-				charts.push(nv.models.multiBarChart());
-
-				charts[i]
-						.stacked(false)
-						.showControls(false)
-						.margin({left: 100, bottom: 100})
-						.useInteractiveGuideline(true)
-						.duration(250);
-
-				var contentGenerator = charts[i].interactiveLayer.tooltip.contentGenerator();
-				var tooltip = charts[i].interactiveLayer.tooltip;
-				tooltip.headerFormatter(function (d) {
-					var lower = d;
-					var upper = d + chartData[i].width;
-					lower = lower.toFixed(2);
-					upper = upper.toFixed(2);
-					return "<p>Range: [" + lower + ", " + upper + "]";
-				});
-				
-				// chart sub-models (ie. xAxis, yAxis, etc) when accessed directly, return themselves, not the parent chart, so need to chain separately
-				charts[i].xAxis
-						.axisLabel(chartData[i].xAxisLabel)
-						.tickFormat(function(d) { return d3.format(',.2f')(d);});
-
-				charts[i].yAxis
-						.axisLabel('Count')
-						.tickFormat(d3.format(',.1f'));
-
-				charts[i].showXAxis(true);
-
-				d3.select('#hist-chart-' + i)
-				.datum(chartData[i].datum)
-				.transition()
-				.call(charts[i]);
-						
-				nv.utils.windowResize(charts[i].update);
-				charts[i].dispatch.on('stateChange', function(e) { nv.log('New State:', JSON.stringify(e)); });
-				return charts[i];
-		});
-	}
-	</script>	
-	`
-}
-
 // RenderStr is a helper method for rendering strings
 // Used in HTML template
 func (r *HTMLReporter) RenderStr(what string) (string, error) {
 	var val string = ""
 	var err error = nil
 	switch what {
-	case "showClassStatus":
-		val = "show"
-		if r.NoFailure() {
-			val = ""
-		}
 	case "textColorStatus":
 		val = "text-danger"
 		if r.NoFailure() {
 			val = "text-success"
 		}
 	case "thumbsStatus":
-		val = "down"
+		val = "\u2718"
 		if r.NoFailure() {
-			val = "up"
+			val = "\u2714"
 		}
 	case "msgStatus":
 		val = ""
@@ -164,12 +129,12 @@ func (r *HTMLReporter) MetricDescriptionHTML(metricName string) (string, error)
 	return m.Description, nil
 }
 
-// renderSLOSatisfiedHTML provides the HTML icon indicating if the SLO is satisfied
+// renderSLOSatisfiedHTML provides the symbol indicating if the SLO is satisfied
 func renderSLOSatisfiedHTML(s bool) string {
 	if s {
-		return "fa-check-circle"
+		return "\u2714"
 	} else {
-		return "fa-times-circle"
+		return "\u2718"
 	}
 }
 
@@ -216,8 +181,153 @@ func (r *HTMLReporter) VectorMetricValue(i int, m string) []float64 {
 		return nil
 	}
 	if mm.Type == base.SampleMetricType {
+		if sk := in.SampleSketch(i, m); sk != nil {
+			return sampleHist(sk.Histogram())
+		}
 		return in.NonHistMetricValues[i][m]
 	}
 	// this is a hist metric
 	return sampleHist(in.HistMetricValues[i][m])
 }
+
+// ReportCSS returns the embedded style sheet
+func (r *HTMLReporter) ReportCSS() htmlT.CSS {
+	return htmlT.CSS(reportCSS)
+}
+
+// ChartsJS returns the embedded charting script
+// Used in HTML template
+func (r *HTMLReporter) ChartsJS() htmlT.JS {
+	return htmlT.JS(chartsJS)
+}
+
+// VersionNames returns the names of versions used in charts
+func (r *HTMLReporter) VersionNames() []string {
+	names := make([]string, r.Result.Insights.NumVersions)
+	for i := range names {
+		names[i] = fmt.Sprintf("Version %v", i)
+	}
+	return names
+}
+
+// sampleBuckets bins sample values into equal width histogram buckets
+func sampleBuckets(vals []float64) []base.HistBucket {
+	if len(vals) == 0 {
+		return nil
+	}
+	min, max := vals[0], vals[0]
+	for _, v := range vals {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	if min == max {
+		return []base.HistBucket{{Lower: min, Upper: max, Count: uint64(len(vals))}}
+	}
+	width := (max - min) / numSampleBuckets
+	buckets := make([]base.HistBucket, numSampleBuckets)
+	for k := range buckets {
+		buckets[k].Lower = min + float64(k)*width
+		buckets[k].Upper = buckets[k].Lower + width
+	}
+	for _, v := range vals {
+		k := int((v - min) / width)
+		if k >= numSampleBuckets {
+			k = numSampleBuckets - 1
+		}
+		buckets[k].Count++
+	}
+	return buckets
+}
+
+// VectorMetricHistograms returns the histogram of the given vector metric for each version
+// If it is a sample metric, then its values are binned into a histogram
+func (r *HTMLReporter) VectorMetricHistograms(m string) []ChartSeries {
+	in := r.Result.Insights
+	mm, ok := in.MetricsInfo[m]
+	if !ok {
+		log.Logger.Error("could not find vector metric: ", m)
+		return nil
+	}
+	series := []ChartSeries{}
+	for i, name := range r.VersionNames() {
+		s := ChartSeries{Name: name}
+		if sk := in.SampleSketch(i, m); sk != nil {
+			s.Buckets = sk.Histogram()
+		} else if mm.Type == base.SampleMetricType {
+			s.Buckets = sampleBuckets(in.NonHistMetricValues[i][m])
+		} else {
+			s.Buckets = in.HistMetricValues[i][m]
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+// SortedMetricsWithHistory returns the sorted names of counter and gauge metrics
+// that have been observed more than once for some version (for example, in looping experiments)
+func (r *HTMLReporter) SortedMetricsWithHistory() []string {
+	in := r.Result.Insights
+	keys := []string{}
+	for k, mm := range in.MetricsInfo {
+		if mm.Type != base.CounterMetricType && mm.Type != base.GaugeMetricType {
+			continue
+		}
+		for i := 0; i < len(in.NonHistMetricValues); i++ {
+			if len(in.NonHistMetricValues[i][k]) > 1 {
+				keys = append(keys, k)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ScalarMetricHistory returns all observed values of the given counter or gauge metric for each version
+func (r *HTMLReporter) ScalarMetricHistory(m string) []ChartSeries {
+	in := r.Result.Insights
+	series := []ChartSeries{}
+	for i, name := range r.VersionNames() {
+		series = append(series, ChartSeries{
+			Name:   name,
+			Values: in.NonHistMetricValues[i][m],
+		})
+	}
+	return series
+}
+
+// ScalarMetricValues returns the value of the given scalar metric for each version
+// Unavailable values are nil
+func (r *HTMLReporter) ScalarMetricValues(m string) []*float64 {
+	vals := make([]*float64, r.Result.Insights.NumVersions)
+	for i := range vals {
+		vals[i] = r.Result.Insights.ScalarMetricValue(i, m)
+	}
+	return vals
+}
+
+// SLOLimit returns the limit of the SLO (if any) which involves the given metric
+// Upper limits take precedence over lower limits
+func (r *HTMLReporter) SLOLimit(m string) *float64 {
+	slos := r.Result.Insights.SLOs
+	if slos == nil {
+		return nil
+	}
+	nm, err := base.NormalizeMetricName(m)
+	if err != nil {
+		return nil
+	}
+	for _, list := range [][]base.SLO{slos.Upper, slos.Lower} {
+		for _, slo := range list {
+			if n, err := base.NormalizeMetricName(slo.Metric); err == nil && n == nm {
+				limit := slo.Limit
+				return &limit
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// This file implements a minimal writer of Apache Parquet files: a single row group of flat columns,
+// each stored in a single uncompressed data page with PLAIN encoding.
+// Please see https://github.com/apache/parquet-format for the specification.
+
+const (
+	// parquetMagic starts and ends Parquet files
+	parquetMagic = "PAR1"
+
+	// physical types of Parquet columns
+	parquetInt32     int32 = 1
+	parquetInt64     int32 = 2
+	parquetDouble    int32 = 5
+	parquetByteArray int32 = 6
+
+	// repetition types of Parquet columns
+	parquetRequired int32 = 0
+	parquetOptional int32 = 1
+
+	// parquetUTF8 is the converted type of strings
+	parquetUTF8 int32 = 0
+	// parquetPlain is the PLAIN encoding
+	parquetPlain int32 = 0
+	// parquetRLE is the RLE / bit-packing hybrid encoding, used for definition levels
+	parquetRLE int32 = 3
+	// parquetDataPage is the type of version 1 data pages
+	parquetDataPage int32 = 0
+	// parquetUncompressed is the codec of uncompressed pages
+	parquetUncompressed int32 = 0
+)
+
+// thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// parquetColumn is a column of a Parquet file
+type parquetColumn struct {
+	// name of the column
+	name string
+	// physical type of the column; int32, int64, double, or byte array (string)
+	typ int32
+	// optional columns may have nil values
+	optional bool
+	// values of the column; int32, int64, float64, or string, or nil for null values of optional columns
+	values []interface{}
+}
+
+// writeParquet writes the columns, which have the same number of values, as a Parquet file
+func writeParquet(out io.Writer, columns []parquetColumn) error {
+	numRows := 0
+	if len(columns) > 0 {
+		numRows = len(columns[0].values)
+	}
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := []chunk{}
+	for _, c := range columns {
+		if len(c.values) != numRows {
+			return fmt.Errorf("column %v has %v values instead of %v", c.name, len(c.values), numRows)
+		}
+		page, err := c.page()
+		if err != nil {
+			return err
+		}
+		var header thriftWriter
+		header.structBegin()
+		header.i32Field(1, parquetDataPage)
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.fieldHeader(5, thriftStruct)
+		header.structBegin()
+		header.i32Field(1, int32(numRows))
+		header.i32Field(2, parquetPlain)
+		header.i32Field(3, parquetRLE)
+		header.i32Field(4, parquetRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunks = append(chunks, chunk{offset: int64(file.Len()), size: int64(header.buf.Len() + len(page))})
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+	}
+
+	// file metadata
+	var meta thriftWriter
+	meta.structBegin()
+	meta.i32Field(1, 1)
+	meta.listField(2, thriftStruct, len(columns)+1)
+	meta.structBegin()
+	meta.binaryField(4, "schema")
+	meta.i32Field(5, int32(len(columns)))
+	meta.structEnd()
+	for _, c := range columns {
+		meta.structBegin()
+		meta.i32Field(1, c.typ)
+		repetition := parquetRequired
+		if c.optional {
+			repetition = parquetOptional
+		}
+		meta.i32Field(3, repetition)
+		meta.binaryField(4, c.name)
+		if c.typ == parquetByteArray {
+			meta.i32Field(6, parquetUTF8)
+		}
+		meta.structEnd()
+	}
+	meta.i64Field(3, int64(numRows))
+	meta.listField(4, thriftStruct, 1)
+	meta.structBegin()
+	meta.listField(1, thriftStruct, len(columns))
+	total := int64(0)
+	for i, c := range columns {
+		total += chunks[i].size
+		meta.structBegin()
+		meta.i64Field(2, chunks[i].offset)
+		meta.fieldHeader(3, thriftStruct)
+		meta.structBegin()
+		meta.i32Field(1, c.typ)
+		meta.listField(2, thriftI32, 2)
+		meta.varint(zigzag(int64(parquetPlain)))
+		meta.varint(zigzag(int64(parquetRLE)))
+		meta.listField(3, thriftBinary, 1)
+		meta.binary(c.name)
+		meta.i32Field(4, parquetUncompressed)
+		meta.i64Field(5, int64(numRows))
+		meta.i64Field(6, chunks[i].size)
+		meta.i64Field(7, chunks[i].size)
+		meta.i64Field(9, chunks[i].offset)
+		meta.structEnd()
+		meta.structEnd()
+	}
+	meta.i64Field(2, total)
+	meta.i64Field(3, int64(numRows))
+	meta.structEnd()
+	meta.binaryField(6, "iter8")
+	meta.structEnd()
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.WriteString(parquetMagic)
+	_, err := out.Write(file.Bytes())
+	return err
+}
+
+// page returns the body of the data page of the column; definition levels of optional columns,
+// followed by the PLAIN encoded values that are not null
+func (c *parquetColumn) page() ([]byte, error) {
+	var b bytes.Buffer
+	if c.optional {
+		levels := encodeDefinitionLevels(c.values)
+		binary.Write(&b, binary.LittleEndian, uint32(len(levels)))
+		b.Write(levels)
+	}
+	for _, v := range c.values {
+		if v == nil {
+			if !c.optional {
+				return nil, fmt.Errorf("required column %v has a null value", c.name)
+			}
+			continue
+		}
+		switch c.typ {
+		case parquetInt32:
+			binary.Write(&b, binary.LittleEndian, v.(int32))
+		case parquetInt64:
+			binary.Write(&b, binary.LittleEndian, v.(int64))
+		case parquetDouble:
+			binary.Write(&b, binary.LittleEndian, math.Float64bits(v.(float64)))
+		case parquetByteArray:
+			s := v.(string)
+			binary.Write(&b, binary.LittleEndian, uint32(len(s)))
+			b.WriteString(s)
+		default:
+			return nil, fmt.Errorf("unsupported type %v of column %v", c.typ, c.name)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// encodeDefinitionLevels encodes whether each value is defined (1) or null (0) as RLE runs with a bit width of 1
+func encodeDefinitionLevels(values []interface{}) []byte {
+	var b thriftWriter
+	for i := 0; i < len(values); {
+		defined := values[i] != nil
+		run := 1
+		for i+run < len(values) && (values[i+run] != nil) == defined {
+			run++
+		}
+		b.varint(uint64(run) << 1)
+		if defined {
+			b.buf.WriteByte(1)
+		} else {
+			b.buf.WriteByte(0)
+		}
+		i += run
+	}
+	return b.buf.Bytes()
+}
+
+// thriftWriter encodes structs using the Thrift compact protocol, in which Parquet metadata is serialized
+type thriftWriter struct {
+	// buf holds the encoded bytes
+	buf bytes.Buffer
+	// lastIDs are the ids of the last fields written in the enclosing structs
+	lastIDs []int16
+}
+
+// structBegin starts a struct
+func (w *thriftWriter) structBegin() {
+	w.lastIDs = append(w.lastIDs, 0)
+}
+
+// structEnd ends a struct
+func (w *thriftWriter) structEnd() {
+	w.buf.WriteByte(0)
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+// fieldHeader writes the header of a field of the current struct
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &w.lastIDs[len(w.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+// i32Field writes an i32 field
+func (w *thriftWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(zigzag(int64(v)))
+}
+
+// i64Field writes an i64 field
+func (w *thriftWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(zigzag(v))
+}
+
+// binaryField writes a string field
+func (w *thriftWriter) binaryField(id int16, s string) {
+	w.fieldHeader(id, thriftBinary)
+	w.binary(s)
+}
+
+// listField writes the header of a list field, whose elements are written next
+func (w *thriftWriter) listField(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.varint(uint64(size))
+	}
+}
+
+// binary writes a string
+func (w *thriftWriter) binary(s string) {
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// varint writes an unsigned varint
+func (w *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	w.buf.Write(b[:n])
+}
+
+// zigzag encodes a signed integer as an unsigned integer
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
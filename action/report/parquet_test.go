@@ -0,0 +1,280 @@
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// thriftReader decodes structs encoded using the Thrift compact protocol, independently of thriftWriter.
+// Structs are decoded as maps from field ids to values; integers as int64, binaries as strings, and lists as slices.
+type thriftReader struct {
+	// data is the encoded bytes
+	data []byte
+	// pos is the position of the next byte to be read
+	pos int
+}
+
+// byte reads a byte
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errors.New("unexpected end of data")
+	}
+	r.pos++
+	return r.data[r.pos-1], nil
+}
+
+// varint reads an unsigned varint
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errors.New("invalid varint")
+	}
+	r.pos += n
+	return v, nil
+}
+
+// value reads a value of the given type
+func (r *thriftReader) value(typ byte) (interface{}, error) {
+	switch typ {
+	case thriftI32, thriftI64:
+		v, err := r.varint()
+		return int64(v>>1) ^ -int64(v&1), err
+	case thriftBinary:
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if r.pos+int(n) > len(r.data) {
+			return nil, errors.New("binary exceeds data")
+		}
+		r.pos += int(n)
+		return string(r.data[r.pos-int(n) : r.pos]), nil
+	case thriftList:
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(h >> 4)
+		if size == 15 {
+			if size, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		list := []interface{}{}
+		for i := uint64(0); i < size; i++ {
+			v, err := r.value(h & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case thriftStruct:
+		return r.structure()
+	}
+	return nil, fmt.Errorf("unsupported thrift type %v", typ)
+}
+
+// structure reads a struct
+func (r *thriftReader) structure() (map[int16]interface{}, error) {
+	s := map[int16]interface{}{}
+	id := int16(0)
+	for {
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if h == 0 {
+			return s, nil
+		}
+		if delta := int16(h >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := r.value(thriftI32)
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v.(int64))
+		}
+		if s[id], err = r.value(h & 0x0f); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parquetFile is a decoded Parquet file
+type parquetFile struct {
+	// meta is the file metadata
+	meta map[int16]interface{}
+	// columns are the decoded columns
+	columns []parquetColumn
+}
+
+// readParquet decodes the footer of a Parquet file written by writeParquet, and the data pages of its columns
+func readParquet(t *testing.T, data []byte) parquetFile {
+	assert.Equal(t, parquetMagic, string(data[:4]))
+	assert.Equal(t, parquetMagic, string(data[len(data)-4:]))
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := thriftReader{data: data[len(data)-8-metaLen : len(data)-8]}
+	meta, err := r.structure()
+	assert.NoError(t, err)
+	assert.Equal(t, metaLen, r.pos, "file metadata is fully decoded")
+
+	numRows := int(meta[3].(int64))
+	schema := meta[2].([]interface{})
+	rowGroups := meta[4].([]interface{})
+	assert.Len(t, rowGroups, 1)
+	rowGroup := rowGroups[0].(map[int16]interface{})
+	assert.Equal(t, int64(numRows), rowGroup[3])
+	chunks := rowGroup[1].([]interface{})
+	assert.Equal(t, int64(len(chunks)), schema[0].(map[int16]interface{})[5])
+	assert.Len(t, schema, len(chunks)+1)
+
+	f := parquetFile{meta: meta}
+	total := int64(0)
+	for i, c := range chunks {
+		el := schema[i+1].(map[int16]interface{})
+		col := parquetColumn{
+			name:     el[4].(string),
+			typ:      int32(el[1].(int64)),
+			optional: el[3] == int64(parquetOptional),
+		}
+		cm := c.(map[int16]interface{})[3].(map[int16]interface{})
+		assert.Equal(t, int64(col.typ), cm[1])
+		assert.Equal(t, []interface{}{col.name}, cm[3])
+		assert.Equal(t, int64(parquetUncompressed), cm[4])
+		assert.Equal(t, int64(numRows), cm[5])
+		total += cm[6].(int64)
+
+		// page header, followed by the page
+		offset := int(cm[9].(int64))
+		r := thriftReader{data: data[:offset+int(cm[6].(int64))], pos: offset}
+		header, err := r.structure()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(parquetDataPage), header[1])
+		dph := header[5].(map[int16]interface{})
+		assert.Equal(t, int64(numRows), dph[1])
+		assert.Equal(t, int64(parquetPlain), dph[2])
+		pageLen := int(header[3].(int64))
+		assert.Equal(t, offset+int(cm[6].(int64)), r.pos+pageLen, "column chunk is the page header and page")
+		col.values = decodePage(t, col, numRows, data[r.pos:r.pos+pageLen])
+		f.columns = append(f.columns, col)
+	}
+	assert.Equal(t, total, rowGroup[2])
+	return f
+}
+
+// decodePage decodes the definition levels and PLAIN encoded values of a data page
+func decodePage(t *testing.T, col parquetColumn, numRows int, page []byte) []interface{} {
+	defined := make([]bool, numRows)
+	for i := range defined {
+		defined[i] = true
+	}
+	if col.optional {
+		n := int(binary.LittleEndian.Uint32(page))
+		r := thriftReader{data: page[4 : 4+n]}
+		row := 0
+		for r.pos < len(r.data) {
+			h, err := r.varint()
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(0), h&1, "definition levels are RLE runs")
+			level, err := r.byte()
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, level, byte(1), "definition levels are 0 or 1")
+			for i := 0; i < int(h>>1); i++ {
+				defined[row] = level == 1
+				row++
+			}
+		}
+		assert.Equal(t, numRows, row)
+		page = page[4+n:]
+	}
+	values := make([]interface{}, numRows)
+	b := bytes.NewReader(page)
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+		switch col.typ {
+		case parquetInt32:
+			var v int32
+			assert.NoError(t, binary.Read(b, binary.LittleEndian, &v))
+			values[i] = v
+		case parquetInt64:
+			var v int64
+			assert.NoError(t, binary.Read(b, binary.LittleEndian, &v))
+			values[i] = v
+		case parquetDouble:
+			var v uint64
+			assert.NoError(t, binary.Read(b, binary.LittleEndian, &v))
+			values[i] = math.Float64frombits(v)
+		case parquetByteArray:
+			var n uint32
+			assert.NoError(t, binary.Read(b, binary.LittleEndian, &n))
+			s := make([]byte, n)
+			_, err := b.Read(s)
+			assert.NoError(t, err)
+			values[i] = string(s)
+		}
+	}
+	assert.Zero(t, b.Len(), "page is fully decoded")
+	return values
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	// columns of each type, with runs of null values
+	rows := 40
+	columns := []parquetColumn{
+		{name: "i32", typ: parquetInt32},
+		{name: "i64", typ: parquetInt64, optional: true},
+		{name: "double", typ: parquetDouble, optional: true},
+		{name: "string", typ: parquetByteArray, optional: true},
+	}
+	for i := 0; i < rows; i++ {
+		columns[0].values = append(columns[0].values, int32(i-rows/2))
+		columns[1].values = append(columns[1].values, int64(-i)<<40)
+		if i%7 == 3 {
+			columns[2].values = append(columns[2].values, nil)
+		} else {
+			columns[2].values = append(columns[2].values, float64(i)/3)
+		}
+		if i < 10 || i > 30 {
+			columns[3].values = append(columns[3].values, nil)
+		} else {
+			columns[3].values = append(columns[3].values, fmt.Sprintf("row %v ü", i))
+		}
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, writeParquet(&b, columns))
+	f := readParquet(t, b.Bytes())
+	assert.Equal(t, int64(rows), f.meta[3])
+	assert.Equal(t, "iter8", f.meta[6])
+	assert.Equal(t, columns, f.columns)
+
+	// enough columns for long list headers in the file metadata
+	many := make([]parquetColumn, 16)
+	for i := range many {
+		many[i] = parquetColumn{name: fmt.Sprintf("c%v", i), typ: parquetInt32, values: []interface{}{int32(i)}}
+	}
+	b.Reset()
+	assert.NoError(t, writeParquet(&b, many))
+	assert.Equal(t, many, readParquet(t, b.Bytes()).columns)
+
+	// an empty file
+	b.Reset()
+	assert.NoError(t, writeParquet(&b, []parquetColumn{{name: "empty", typ: parquetInt32}}))
+	f = readParquet(t, b.Bytes())
+	assert.Equal(t, int64(0), f.meta[3])
+	assert.Empty(t, f.columns[0].values)
+
+	// columns must have the same number of values, and required columns must not be null
+	assert.Error(t, writeParquet(&b, []parquetColumn{columns[0], {name: "short", typ: parquetInt32}}))
+	assert.Error(t, writeParquet(&b, []parquetColumn{{name: "null", typ: parquetInt32, values: []interface{}{nil}}}))
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"errors"
+	"io"
+
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+// ParquetReporter supports generation of Apache Parquet files from experiments.
+// The file has the same columns as CSV reports; columns that do not apply to an observation are null.
+type ParquetReporter struct {
+	// Reporter is embedded and enables access to all reporter data and methods
+	*Reporter
+}
+
+// Gen writes the metric observations of the experiment in Parquet format
+func (pr *ParquetReporter) Gen(out io.Writer) error {
+	rows := pr.observations()
+	columns := make([]parquetColumn, len(observationColumns))
+	for i, name := range observationColumns {
+		columns[i] = parquetColumn{
+			name:   name,
+			values: make([]interface{}, len(rows)),
+		}
+	}
+	columns[0].typ = parquetInt32
+	columns[1].typ = parquetByteArray
+	columns[2].typ = parquetByteArray
+	columns[3].typ, columns[3].optional = parquetByteArray, true
+	columns[4].typ = parquetInt32
+	columns[5].typ, columns[5].optional = parquetDouble, true
+	columns[6].typ, columns[6].optional = parquetDouble, true
+	columns[7].typ, columns[7].optional = parquetDouble, true
+	columns[8].typ, columns[8].optional = parquetInt64, true
+
+	for r, o := range rows {
+		columns[0].values[r] = int32(o.Version)
+		columns[1].values[r] = o.Metric
+		columns[2].values[r] = string(o.Type)
+		if o.Units != nil {
+			columns[3].values[r] = *o.Units
+		}
+		columns[4].values[r] = int32(o.Index)
+		if o.Value != nil {
+			columns[5].values[r] = *o.Value
+		}
+		if o.Lower != nil {
+			columns[6].values[r] = *o.Lower
+		}
+		if o.Upper != nil {
+			columns[7].values[r] = *o.Upper
+		}
+		if o.Count != nil {
+			columns[8].values[r] = int64(*o.Count)
+		}
+	}
+
+	if err := writeParquet(out, columns); err != nil {
+		e := errors.New("unable to write Parquet report")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return nil
+}
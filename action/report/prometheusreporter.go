@@ -0,0 +1,207 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// prometheusContentType is the content type of the Prometheus text exposition format
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+	// pushTimeout is the timeout used when pushing metrics to a Prometheus Pushgateway
+	pushTimeout = 10 * time.Second
+)
+
+// promLabelName matches valid Prometheus label names
+var promLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// promReservedLabels are the labels of samples generated by the reporter; they cannot be used as reporter labels
+var promReservedLabels = []string{"metric", "type", "limit", "version"}
+
+// PrometheusReporter supports generation of experiment metrics and SLO verdicts
+// in the Prometheus text exposition format.
+type PrometheusReporter struct {
+	// Reporter is embedded and enables access to all reporter data and methods
+	*Reporter
+	// Labels are added to every exposed sample
+	Labels map[string]string
+}
+
+// validateLabels checks that the labels of the reporter are valid Prometheus labels,
+// which do not clash with the labels of the generated samples
+func (pr *PrometheusReporter) validateLabels() error {
+	for k := range pr.Labels {
+		if !promLabelName.MatchString(k) || strings.HasPrefix(k, "__") {
+			return fmt.Errorf("invalid Prometheus label %v", k)
+		}
+		for _, r := range promReservedLabels {
+			if k == r {
+				return fmt.Errorf("Prometheus label %v is reserved for samples of experiment metrics", k)
+			}
+		}
+	}
+	return nil
+}
+
+// promEscape escapes a Prometheus label value
+func promEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// promBool converts a bool into a Prometheus sample value
+func promBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// promFamily writes the HELP and TYPE lines of a metric family
+func promFamily(out io.Writer, name string, help string) {
+	fmt.Fprintf(out, "# HELP %v %v\n", name, help)
+	fmt.Fprintf(out, "# TYPE %v gauge\n", name)
+}
+
+// sample writes a single sample with the given labels in addition to the reporter's labels
+func (pr *PrometheusReporter) sample(out io.Writer, name string, labels [][2]string, val float64) {
+	keys := []string{}
+	for k := range pr.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	all := [][2]string{}
+	for _, k := range keys {
+		all = append(all, [2]string{k, pr.Labels[k]})
+	}
+	all = append(all, labels...)
+	ls := []string{}
+	for _, l := range all {
+		ls = append(ls, fmt.Sprintf(`%v="%v"`, l[0], promEscape(l[1])))
+	}
+	if len(ls) > 0 {
+		fmt.Fprintf(out, "%v{%v} %v\n", name, strings.Join(ls, ","), strconv.FormatFloat(val, 'g', -1, 64))
+	} else {
+		fmt.Fprintf(out, "%v %v\n", name, strconv.FormatFloat(val, 'g', -1, 64))
+	}
+}
+
+// slos writes the verdicts for the given SLOs
+func (pr *PrometheusReporter) slos(out io.Writer, slos []base.SLO, satisfied [][]bool, upper bool) {
+	kind := "lower"
+	if upper {
+		kind = "upper"
+	}
+	for i, slo := range slos {
+		for j := 0; j < pr.Result.Insights.NumVersions; j++ {
+			ok := i < len(satisfied) && j < len(satisfied[i]) && satisfied[i][j]
+			pr.sample(out, "iter8_slo_satisfied", [][2]string{
+				{"metric", slo.Metric},
+				{"type", kind},
+				{"limit", strconv.FormatFloat(slo.Limit, 'g', -1, 64)},
+				{"version", strconv.Itoa(j)},
+			}, promBool(ok))
+		}
+	}
+}
+
+// Gen writes the experiment metrics and SLO verdicts in Prometheus text exposition format into the given writer
+func (pr *PrometheusReporter) Gen(out io.Writer) error {
+	if err := pr.validateLabels(); err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	promFamily(out, "iter8_experiment_completed", "Whether the experiment has completed (1) or not (0).")
+	pr.sample(out, "iter8_experiment_completed", nil, promBool(pr.Completed()))
+	promFamily(out, "iter8_experiment_failure", "Whether any experiment task has failed (1) or not (0).")
+	pr.sample(out, "iter8_experiment_failure", nil, promBool(!pr.NoFailure()))
+
+	if pr.Result == nil {
+		return nil
+	}
+	promFamily(out, "iter8_experiment_completed_tasks", "Number of completed experiment tasks.")
+	pr.sample(out, "iter8_experiment_completed_tasks", nil, float64(pr.Result.NumCompletedTasks))
+	promFamily(out, "iter8_experiment_loops", "Number of experiment loops.")
+	pr.sample(out, "iter8_experiment_loops", nil, float64(pr.Result.NumLoops))
+
+	in := pr.Result.Insights
+	if in == nil {
+		return nil
+	}
+
+	promFamily(out, "iter8_metric_value", "Value of an experiment metric for an app version.")
+	for _, mn := range pr.SortedScalarAndSLOMetrics() {
+		for j := 0; j < in.NumVersions; j++ {
+			if val := in.ScalarMetricValue(j, mn); val != nil {
+				pr.sample(out, "iter8_metric_value", [][2]string{
+					{"metric", mn},
+					{"version", strconv.Itoa(j)},
+				}, *val)
+			}
+		}
+	}
+
+	if in.SLOs != nil {
+		satisfied := in.SLOsSatisfied
+		if satisfied == nil {
+			satisfied = &base.SLOResults{}
+		}
+		promFamily(out, "iter8_slo_satisfied", "Whether an SLO is satisfied (1) or not (0) by an app version.")
+		pr.slos(out, in.SLOs.Upper, satisfied.Upper, true)
+		pr.slos(out, in.SLOs.Lower, satisfied.Lower, false)
+		promFamily(out, "iter8_versions_satisfying_slos", "Whether an app version satisfies all SLOs (1) or not (0).")
+		for j := 0; j < in.NumVersions; j++ {
+			pr.sample(out, "iter8_versions_satisfying_slos", [][2]string{
+				{"version", strconv.Itoa(j)},
+			}, promBool(pr.VersionSatisfiesSLOs(j)))
+		}
+	}
+
+	return nil
+}
+
+// Push sends the experiment metrics and SLO verdicts to the Prometheus Pushgateway at the given URL.
+// Metrics are grouped under the given job; any previously pushed metrics in this group are replaced.
+func (pr *PrometheusReporter) Push(gatewayURL string, job string) error {
+	var b bytes.Buffer
+	if err := pr.Gen(&b); err != nil {
+		return err
+	}
+
+	u := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequest(http.MethodPut, u, &b)
+	if err != nil {
+		e := errors.New("unable to create Pushgateway request")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	req.Header.Set("Content-Type", prometheusContentType)
+
+	client := &http.Client{Timeout: pushTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		e := errors.New("unable to push metrics to Pushgateway")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e := fmt.Errorf("Pushgateway returned status code %v", resp.StatusCode)
+		log.Logger.Error(e)
+		return e
+	}
+	log.Logger.Infof("pushed experiment metrics to %v", u)
+	return nil
+}
@@ -1,6 +1,12 @@
 package report
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -44,3 +50,438 @@ func TestReportHTML(t *testing.T) {
 	err = reporter.Gen(os.Stdout)
 	assert.NoError(t, err)
 }
+
+func TestSampleBuckets(t *testing.T) {
+	assert.Nil(t, sampleBuckets(nil))
+
+	b := sampleBuckets([]float64{3, 3, 3})
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, uint64(3), b[0].Count)
+
+	b = sampleBuckets([]float64{0, 1, 2, 10})
+	assert.Equal(t, numSampleBuckets, len(b))
+	total := uint64(0)
+	for _, bucket := range b {
+		total += bucket.Count
+	}
+	assert.Equal(t, uint64(4), total)
+	assert.Equal(t, uint64(1), b[numSampleBuckets-1].Count)
+}
+
+func TestReportHTMLCharts(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := HTMLReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+
+	assert.Equal(t, []string{"Version 0"}, reporter.VersionNames())
+	hists := reporter.VectorMetricHistograms("http/latency")
+	assert.Equal(t, 1, len(hists))
+	assert.Equal(t, 5, len(hists[0].Buckets))
+
+	// simulate a second loop
+	exp.Result.Insights.NonHistMetricValues[0]["http/latency-mean"] = append(exp.Result.Insights.NonHistMetricValues[0]["http/latency-mean"], 20.0)
+	assert.Contains(t, reporter.SortedMetricsWithHistory(), "http/latency-mean")
+	assert.Equal(t, 2, len(reporter.ScalarMetricHistory("http/latency-mean")[0].Values))
+
+	limit := reporter.SLOLimit("http/latency-p50")
+	assert.NotNil(t, limit)
+	assert.Equal(t, 1000.0, *limit)
+	assert.Nil(t, reporter.SLOLimit("http/latency-max"))
+
+	var b bytes.Buffer
+	err = reporter.Gen(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "iter8Charts.histogram")
+	assert.Contains(t, b.String(), "iter8Charts.line")
+	assert.NotContains(t, b.String(), "cdn.plot.ly")
+	// styles and scripts are embedded; the report loads no external resources
+	assert.Contains(t, b.String(), ".thead-light")
+	assert.NotRegexp(t, `<(script|link)[^>]+(src|href)=`, b.String())
+}
+
+func TestReportSampleSketch(t *testing.T) {
+	in := &base.Insights{
+		NumVersions: 1,
+		MetricsInfo: map[string]base.MetricMeta{
+			"grpc/latency": {Description: "gRPC Latency Sample", Type: base.SampleMetricType},
+		},
+		NonHistMetricValues: []map[string][]float64{{}},
+		HistMetricValues:    []map[string][]base.HistBucket{{}},
+		SampleSketches: []map[string]*base.SampleSketch{{
+			"grpc/latency": {Accuracy: 0.01, Count: 3, Sum: 5, Min: 1, Max: 2, Positive: map[int]int64{0: 1, 35: 2}},
+		}},
+	}
+	exp := &base.Experiment{Result: &base.ExperimentResult{Insights: in}}
+
+	// sketches are charted and tabulated as histograms
+	reporter := HTMLReporter{Reporter: &Reporter{Experiment: exp}}
+	hists := reporter.VectorMetricHistograms("grpc/latency")
+	assert.Equal(t, []base.HistBucket{{Lower: 1, Upper: 1, Count: 1}, {Lower: hists[0].Buckets[1].Lower, Upper: 2, Count: 2}}, hists[0].Buckets)
+	assert.Len(t, reporter.VectorMetricValue(0, "grpc/latency"), 3)
+
+	rows := reporter.observations()
+	assert.Len(t, rows, 2)
+	assert.Equal(t, uint64(2), *rows[1].Count)
+	assert.Nil(t, rows[1].Value)
+}
+
+func TestReportSARIF(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := SARIFReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+
+	// all SLOs are satisfied
+	var b bytes.Buffer
+	err = reporter.Gen(&b)
+	assert.NoError(t, err)
+	sl := sarifLog{}
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &sl))
+	assert.Equal(t, sarifVersion, sl.Version)
+	assert.Equal(t, 1, len(sl.Runs))
+	assert.Equal(t, 1+len(exp.Result.Insights.SLOs.Upper), len(sl.Runs[0].Tool.Driver.Rules))
+	assert.Empty(t, sl.Runs[0].Results)
+
+	// violate the second SLO and fail the experiment
+	exp.Result.Insights.SLOsSatisfied.Upper[1][0] = false
+	exp.Result.Failure = true
+	b.Reset()
+	err = reporter.Gen(&b)
+	assert.NoError(t, err)
+	sl = sarifLog{}
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &sl))
+	assert.Equal(t, 2, len(sl.Runs[0].Results))
+	assert.Equal(t, taskFailureRuleID, sl.Runs[0].Results[0].RuleID)
+	assert.Equal(t, "iter8/slo/upper/http/latency-mean", sl.Runs[0].Results[1].RuleID)
+	assert.Equal(t, "error", sl.Runs[0].Results[1].Level)
+	assert.Contains(t, sl.Runs[0].Results[1].Message.Text, "not satisfied by version 0")
+}
+
+func TestReportPrometheus(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := PrometheusReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+		Labels: map[string]string{"app": "my \"app\""},
+	}
+	var b bytes.Buffer
+	err = reporter.Gen(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "# TYPE iter8_experiment_completed gauge\n")
+	assert.Contains(t, b.String(), `iter8_experiment_completed{app="my \"app\""} 1`)
+	assert.Contains(t, b.String(), `iter8_experiment_failure{app="my \"app\""} 0`)
+	assert.Contains(t, b.String(), `iter8_slo_satisfied{app="my \"app\"",metric="http/latency-mean",type="upper",limit="500",version="0"} 1`)
+	assert.Contains(t, b.String(), `iter8_versions_satisfying_slos{app="my \"app\"",version="0"} 1`)
+	assert.Contains(t, b.String(), `iter8_metric_value{app="my \"app\"",metric="http/latency-mean",version="0"}`)
+}
+
+func TestReportPrometheusPush(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := PrometheusReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/metrics/job/my-job", r.URL.Path)
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err = reporter.Push(srv.URL+"/", "my-job")
+	assert.NoError(t, err)
+	assert.Contains(t, body, "iter8_experiment_completed 1")
+
+	// labels are pushed with every sample
+	reporter.Labels = map[string]string{"app": "httpbin"}
+	err = reporter.Push(srv.URL, "my-job")
+	assert.NoError(t, err)
+	assert.Contains(t, body, `iter8_experiment_completed{app="httpbin"} 1`)
+
+	// invalid labels are not pushed
+	body = ""
+	reporter.Labels = map[string]string{"metric": "httpbin"}
+	err = reporter.Push(srv.URL, "my-job")
+	assert.Error(t, err)
+	assert.Empty(t, body)
+	reporter.Labels = nil
+
+	// Pushgateway errors are surfaced
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	err = reporter.Push(srv.URL, "my-job")
+	assert.Error(t, err)
+}
+
+func TestReportCSV(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := CSVReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	var b bytes.Buffer
+	err = reporter.Gen(&b)
+	assert.NoError(t, err)
+
+	records, err := csv.NewReader(&b).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, observationColumns, records[0])
+	assert.Equal(t, []string{"0", "http/error-count", "Counter", "", "0", "0", "", "", ""}, records[1])
+	assert.Equal(t, []string{"0", "http/latency", "Histogram", "msec", "1", "", "12", "14", "7"}, records[4])
+	assert.Len(t, records, len(reporter.observations())+1)
+}
+
+func TestReportParquet(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := ParquetReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	var b bytes.Buffer
+	err = reporter.Gen(&b)
+	assert.NoError(t, err)
+
+	// the decoded columns hold the observations
+	f := readParquet(t, b.Bytes())
+	rows := reporter.observations()
+	assert.NotEmpty(t, rows)
+	assert.Equal(t, int64(len(rows)), f.meta[3])
+	assert.Len(t, f.columns, len(observationColumns))
+	for i, c := range f.columns {
+		assert.Equal(t, observationColumns[i], c.name)
+	}
+	for r, o := range rows {
+		assert.Equal(t, int32(o.Version), f.columns[0].values[r])
+		assert.Equal(t, o.Metric, f.columns[1].values[r])
+		assert.Equal(t, string(o.Type), f.columns[2].values[r])
+		assert.Equal(t, int32(o.Index), f.columns[4].values[r])
+		if o.Value != nil {
+			assert.Equal(t, *o.Value, f.columns[5].values[r])
+		} else {
+			assert.Nil(t, f.columns[5].values[r])
+		}
+		if o.Count != nil {
+			assert.Equal(t, int64(*o.Count), f.columns[8].values[r])
+			assert.Equal(t, *o.Lower, f.columns[6].values[r])
+			assert.Equal(t, *o.Upper, f.columns[7].values[r])
+		} else {
+			assert.Nil(t, f.columns[8].values[r])
+		}
+	}
+}
+
+func TestEncodeDefinitionLevels(t *testing.T) {
+	// runs of defined and null values, with the run length shifted left by one
+	assert.Equal(t, []byte{4, 1, 2, 0, 2, 1}, encodeDefinitionLevels([]interface{}{1.0, 2.0, nil, 3.0}))
+	assert.Empty(t, encodeDefinitionLevels(nil))
+	assert.Equal(t, uint64(1), zigzag(-1))
+	assert.Equal(t, uint64(4), zigzag(2))
+}
+
+func TestReportGrafana(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := GrafanaReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+		Job:        "my-job",
+		Datasource: "prom",
+	}
+	var b bytes.Buffer
+	err = reporter.Gen(&b)
+	assert.NoError(t, err)
+
+	dashboard := struct {
+		Title  string `json:"title"`
+		Panels []struct {
+			Type        string `json:"type"`
+			Title       string `json:"title"`
+			FieldConfig struct {
+				Defaults struct {
+					Thresholds struct {
+						Steps []struct {
+							Color string   `json:"color"`
+							Value *float64 `json:"value"`
+						} `json:"steps"`
+					} `json:"thresholds"`
+				} `json:"defaults"`
+			} `json:"fieldConfig"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+			Options struct {
+				Content string `json:"content"`
+			} `json:"options"`
+		} `json:"panels"`
+	}{}
+	err = json.Unmarshal(b.Bytes(), &dashboard)
+	assert.NoError(t, err)
+	assert.Equal(t, grafanaDashboardTitle, dashboard.Title)
+	assert.Equal(t, len(reporter.SortedScalarAndSLOMetrics())+1, len(dashboard.Panels))
+	assert.Equal(t, "text", dashboard.Panels[0].Type)
+	assert.Contains(t, dashboard.Panels[0].Options.Content, "| http/latency-mean (msec) | 29.62 |")
+
+	found := false
+	for _, p := range dashboard.Panels[1:] {
+		if p.Title != "http/latency-mean (msec)" {
+			continue
+		}
+		found = true
+		assert.Equal(t, `iter8_metric_value{job="my-job",metric="http/latency-mean"}`, p.Targets[0].Expr)
+		// the SLO limit is the threshold at which values turn red
+		steps := p.FieldConfig.Defaults.Thresholds.Steps
+		assert.Equal(t, 2, len(steps))
+		assert.Equal(t, "red", steps[1].Color)
+		assert.Equal(t, 500.0, *steps[1].Value)
+	}
+	assert.True(t, found)
+}
+
+func TestReportGrafanaPush(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	reporter := GrafanaReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, grafanaDashboardPath, r.URL.Path)
+		assert.Equal(t, "Bearer my-token", r.Header.Get("Authorization"))
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(b, &body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err = reporter.Push(srv.URL+"/", "my-token")
+	assert.NoError(t, err)
+	assert.Equal(t, true, body["overwrite"])
+	assert.Equal(t, grafanaDashboardTitle, body["dashboard"].(map[string]interface{})["title"])
+
+	// Grafana errors are surfaced
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	err = reporter.Push(srv.URL, "my-token")
+	assert.Error(t, err)
+}
+
+func TestReportVersions(t *testing.T) {
+	in := &base.Insights{
+		NumVersions: 3,
+		MetricsInfo: map[string]base.MetricMeta{
+			"grpc/latency":     {Type: base.SampleMetricType, Units: base.StringPointer("msec")},
+			"grpc/error-count": {Type: base.CounterMetricType},
+		},
+		NonHistMetricValues: []map[string][]float64{{
+			"grpc/latency":     {10, 11, 9, 10},
+			"grpc/error-count": {0},
+		}, {
+			"grpc/latency":     {20, 22, 19, 21},
+			"grpc/error-count": {0},
+		}, {
+			"grpc/latency":     {10, 12, 8, 10},
+			"grpc/error-count": {1},
+		}},
+		HistMetricValues: []map[string][]base.HistBucket{{}, {}, {}},
+		SLOs: &base.SLOLimits{
+			Upper: []base.SLO{{Metric: "grpc/error-count", Limit: 0}},
+		},
+		SLOsSatisfied: &base.SLOResults{
+			Upper: [][]bool{{true, true, false}},
+			Lower: [][]bool{},
+		},
+	}
+	exp := &base.Experiment{Result: &base.ExperimentResult{Insights: in}}
+
+	tr := TextReporter{Reporter: &Reporter{Experiment: exp}}
+	// each SLO is one row with a column per version
+	assert.Contains(t, tr.PrintSLOsText(), "grpc/error-count <= 0 |true |true |false\n")
+	// versions that satisfy SLOs are ranked first
+	assert.Contains(t, tr.PrintRankingText(), "1    |version 1\n2    |version 0\n3    |version 2\n")
+	assert.Len(t, tr.PairwiseComparisons(), 3)
+	assert.Contains(t, tr.PrintComparisonsText(), "grpc/latency (msec) |0 vs 1   |10.500     |1.050               |0.000   |true\n")
+
+	var b bytes.Buffer
+	assert.NoError(t, tr.Gen(&b))
+	assert.Contains(t, b.String(), "Pairwise comparisons of versions")
+
+	b.Reset()
+	hr := HTMLReporter{Reporter: &Reporter{Experiment: exp}}
+	assert.NoError(t, hr.Gen(&b))
+	assert.Contains(t, b.String(), "Ranking of versions")
+	assert.Contains(t, b.String(), "0 vs 2")
+}
@@ -0,0 +1,209 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// sarifVersion is the version of the SARIF spec used in SARIF reports
+	sarifVersion = "2.1.0"
+	// sarifSchema is the JSON schema for SARIF reports
+	sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifArtifact is the artifact against which results are reported
+	sarifArtifact = "experiment.yaml"
+	// taskFailureRuleID is the SARIF rule ID used to report task failures
+	taskFailureRuleID = "iter8/task-failure"
+)
+
+// SARIFReporter supports generation of SARIF reports from experiments.
+// SLO violations and task failures are reported as SARIF results.
+type SARIFReporter struct {
+	// Reporter is embedded and enables access to all reporter data and methods
+	*Reporter
+}
+
+// sarifLog is the top-level SARIF object
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun is a single run of a tool
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool describes the tool that produced the results
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver describes the tool component that produced the results
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule describes a rule (an SLO or task failure) that can be violated
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+// sarifMessage is a SARIF message
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult is a single violation of a rule
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// sarifLocation is the location of a result
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation is the physical location of a result
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+// sarifArtifactLocation is the location of an artifact
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sloRuleID returns the SARIF rule ID for an SLO
+func sloRuleID(slo base.SLO, upper bool) string {
+	if upper {
+		return fmt.Sprintf("iter8/slo/upper/%v", slo.Metric)
+	}
+	return fmt.Sprintf("iter8/slo/lower/%v", slo.Metric)
+}
+
+// sloText returns the textual description of an SLO
+func sloText(slo base.SLO, upper bool) string {
+	if upper {
+		return fmt.Sprintf("%v <= %v", slo.Metric, slo.Limit)
+	}
+	return fmt.Sprintf("%v <= %v", slo.Limit, slo.Metric)
+}
+
+// sarifLocations returns the locations used in all results
+func sarifLocations() []sarifLocation {
+	return []sarifLocation{{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{
+				URI: sarifArtifact,
+			},
+		},
+	}}
+}
+
+// sloResults returns the rules and results for the given SLOs
+func (sr *SARIFReporter) sloResults(slos []base.SLO, satisfied [][]bool, upper bool) ([]sarifRule, []sarifResult) {
+	rules := []sarifRule{}
+	results := []sarifResult{}
+	for i, slo := range slos {
+		rules = append(rules, sarifRule{
+			ID:               sloRuleID(slo, upper),
+			ShortDescription: sarifMessage{Text: fmt.Sprintf("SLO %v", sloText(slo, upper))},
+		})
+		for j := 0; j < sr.Result.Insights.NumVersions; j++ {
+			if i < len(satisfied) && j < len(satisfied[i]) && satisfied[i][j] {
+				continue
+			}
+			res := sarifResult{
+				RuleID:    sloRuleID(slo, upper),
+				Level:     "error",
+				Locations: sarifLocations(),
+				Properties: map[string]interface{}{
+					"version": j,
+					"metric":  slo.Metric,
+					"limit":   slo.Limit,
+				},
+			}
+			val := sr.Result.Insights.ScalarMetricValue(j, slo.Metric)
+			if val == nil {
+				res.Message.Text = fmt.Sprintf("SLO %v is not satisfied by version %v; metric value is unavailable", sloText(slo, upper), j)
+			} else {
+				res.Message.Text = fmt.Sprintf("SLO %v is not satisfied by version %v; observed value is %v", sloText(slo, upper), j, *val)
+				res.Properties["value"] = *val
+			}
+			results = append(results, res)
+		}
+	}
+	return rules, results
+}
+
+// Gen writes the SARIF report for a given experiment into the given writer
+func (sr *SARIFReporter) Gen(out io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "iter8",
+				Version:        base.Version,
+				InformationURI: "https://iter8.tools",
+				Rules: []sarifRule{{
+					ID:               taskFailureRuleID,
+					ShortDescription: sarifMessage{Text: "experiment tasks must not fail"},
+				}},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	if !sr.NoFailure() {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    taskFailureRuleID,
+			Level:     "error",
+			Message:   sarifMessage{Text: "experiment has task failures"},
+			Locations: sarifLocations(),
+		})
+	}
+
+	if sr.Result != nil && sr.Result.Insights != nil && sr.Result.Insights.SLOs != nil {
+		in := sr.Result.Insights
+		satisfied := in.SLOsSatisfied
+		if satisfied == nil {
+			satisfied = &base.SLOResults{}
+		}
+		rules, results := sr.sloResults(in.SLOs.Upper, satisfied.Upper, true)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rules...)
+		run.Results = append(run.Results, results...)
+		rules, results = sr.sloResults(in.SLOs.Lower, satisfied.Lower, false)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rules...)
+		run.Results = append(run.Results, results...)
+	}
+
+	b, err := json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		e := errors.New("unable to marshal SARIF report")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	// print output
+	fmt.Fprintln(out, string(b))
+	return nil
+}
@@ -0,0 +1,123 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/iter8-tools/iter8/base"
+)
+
+// observationColumns are the columns of the table of metric observations
+var observationColumns = []string{"version", "metric", "type", "units", "observation", "value", "lower", "upper", "count"}
+
+// observation is a row of the table of metric observations.
+// Observations of histogram metrics, and of sample metrics aggregated in sketches, are histogram buckets, with lower, upper, and count;
+// observations of other metrics have a value.
+type observation struct {
+	// Version is the index of the version
+	Version int
+	// Metric is the name of the metric
+	Metric string
+	// Type of the metric
+	Type base.MetricType
+	// Units of the metric, if any
+	Units *string
+	// Index is the index of the observation among the observations of the metric for the version
+	Index int
+	// Value of the observation of a metric other than a histogram
+	Value *float64
+	// Lower endpoint of the histogram bucket
+	Lower *float64
+	// Upper endpoint of the histogram bucket
+	Upper *float64
+	// Count of the histogram bucket
+	Count *uint64
+}
+
+// observations flattens the metric observations of the experiment into a table with a row per observation,
+// sorted by version and metric name
+func (r *Reporter) observations() []observation {
+	rows := []observation{}
+	in := r.Result.Insights
+	if in == nil {
+		return rows
+	}
+	for i := 0; i < in.NumVersions; i++ {
+		if i < len(in.NonHistMetricValues) {
+			for _, m := range sortedKeys(in.NonHistMetricValues[i]) {
+				for k, v := range in.NonHistMetricValues[i][m] {
+					v := v
+					rows = append(rows, observation{
+						Version: i,
+						Metric:  m,
+						Type:    in.MetricsInfo[m].Type,
+						Units:   in.MetricsInfo[m].Units,
+						Index:   k,
+						Value:   &v,
+					})
+				}
+			}
+		}
+		if i < len(in.HistMetricValues) {
+			for _, m := range sortedKeys(in.HistMetricValues[i]) {
+				for k, b := range in.HistMetricValues[i][m] {
+					b := b
+					rows = append(rows, observation{
+						Version: i,
+						Metric:  m,
+						Type:    in.MetricsInfo[m].Type,
+						Units:   in.MetricsInfo[m].Units,
+						Index:   k,
+						Lower:   &b.Lower,
+						Upper:   &b.Upper,
+						Count:   &b.Count,
+					})
+				}
+			}
+		}
+		if i < len(in.SampleSketches) {
+			for _, m := range sortedKeys(in.SampleSketches[i]) {
+				for k, b := range in.SampleSketches[i][m].Histogram() {
+					b := b
+					rows = append(rows, observation{
+						Version: i,
+						Metric:  m,
+						Type:    in.MetricsInfo[m].Type,
+						Units:   in.MetricsInfo[m].Units,
+						Index:   k,
+						Lower:   &b.Lower,
+						Upper:   &b.Upper,
+						Count:   &b.Count,
+					})
+				}
+			}
+		}
+	}
+	sort.SliceStable(rows, func(a, b int) bool {
+		if rows[a].Version != rows[b].Version {
+			return rows[a].Version < rows[b].Version
+		}
+		return rows[a].Metric < rows[b].Metric
+	})
+	return rows
+}
+
+// sortedKeys returns the sorted keys of the map
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch v := m.(type) {
+	case map[string][]float64:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string][]base.HistBucket:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]*base.SampleSketch:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
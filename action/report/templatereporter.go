@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	textT "text/template"
+
+	"github.com/Masterminds/sprig"
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+// TemplateReporter supports generation of reports from user-supplied Go templates.
+type TemplateReporter struct {
+	// Reporter is embedded and enables access to all reporter data and methods
+	*Reporter
+	// Template is the user-supplied Go template
+	Template string
+}
+
+// Gen writes the report rendered from the user-supplied template into the given writer
+func (tr *TemplateReporter) Gen(out io.Writer) error {
+	// create text template
+	ttpl, err := textT.New("report").Option("missingkey=error").Funcs(sprig.TxtFuncMap()).Funcs(tr.funcMap()).Parse(tr.Template)
+	if err != nil {
+		e := errors.New("unable to parse report template")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	var b bytes.Buffer
+	if err = ttpl.Execute(&b, tr); err != nil {
+		e := errors.New("unable to execute report template")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	// print output
+	fmt.Fprintln(out, b.String())
+	return nil
+}
+
+// funcMap returns the helper functions available within user-supplied templates
+func (tr *TemplateReporter) funcMap() textT.FuncMap {
+	return textT.FuncMap{
+		// versions returns the list of version indices
+		"versions": func() []int {
+			vs := []int{}
+			if tr.Result != nil && tr.Result.Insights != nil {
+				for i := 0; i < tr.Result.Insights.NumVersions; i++ {
+					vs = append(vs, i)
+				}
+			}
+			return vs
+		},
+		// metric returns the value of a scalar metric for a version, or nil if unavailable
+		"metric": func(version int, metric string) interface{} {
+			if tr.Result == nil || tr.Result.Insights == nil {
+				return nil
+			}
+			if val := tr.Result.Insights.ScalarMetricValue(version, metric); val != nil {
+				return *val
+			}
+			return nil
+		},
+		// sloSatisfied returns true if the version satisfies all SLOs
+		"sloSatisfied": tr.VersionSatisfiesSLOs,
+		// formatFloat formats a float using the given number of decimal places
+		"formatFloat": func(precision int, val float64) string {
+			return strconv.FormatFloat(val, 'f', precision, 64)
+		},
+	}
+}
@@ -6,6 +6,12 @@ Experiment summary:
   No task failures: {{ .NoFailure }}
   Total number of tasks: {{ len .Spec }}
   Number of completed tasks: {{ .Result.NumCompletedTasks }}
+{{- if .Result.Aborted }}
+  Experiment aborted: true
+{{- end }}
+{{- if .Result.Interrupted }}
+  Experiment interrupted: true
+{{- end }}
 
 {{- if .Result.Insights }}
 {{- if not (empty .Result.Insights.SLOs) }}
@@ -20,6 +26,20 @@ Latest observed values for metrics:
 ***********************************
 
 {{ .PrintMetricsText | indent 2 }}
+{{- if ge .Result.Insights.NumVersions 2 }}
+
+Ranking of versions:
+********************
+
+{{ .PrintRankingText | indent 2 }}
+{{- if not (empty .PairwiseComparisons) }}
+
+Pairwise comparisons of versions:
+*********************************
+
+{{ .PrintComparisonsText | indent 2 }}
+{{- end }}
+{{- end }}
 {{- else }}
 
 Metrics-based Insights:
@@ -101,8 +101,8 @@ func (r *TextReporter) printSLOsText(w *tabwriter.Writer) {
 				fmt.Fprint(w, str)
 				for j := 0; j < in.NumVersions; j++ {
 					fmt.Fprintf(w, "\t%v", in.SLOsSatisfied.Upper[i][j])
-					fmt.Fprintln(w)
 				}
+				fmt.Fprintln(w)
 			} else {
 				log.Logger.Error("unable to extract SLO text")
 			}
@@ -113,8 +113,8 @@ func (r *TextReporter) printSLOsText(w *tabwriter.Writer) {
 				fmt.Fprint(w, str)
 				for j := 0; j < in.NumVersions; j++ {
 					fmt.Fprintf(w, "\t%v", in.SLOsSatisfied.Lower[i][j])
-					fmt.Fprintln(w)
 				}
+				fmt.Fprintln(w)
 			} else {
 				log.Logger.Error("unable to extract SLO text")
 			}
@@ -165,3 +165,48 @@ func (r *TextReporter) printMetricsText(w *tabwriter.Writer) {
 	}
 	w.Flush()
 }
+
+// PrintRankingText returns the version ranking section of the text report as a string
+func (r *TextReporter) PrintRankingText() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', tabwriter.Debug)
+	r.printRankingText(w)
+	return b.String()
+}
+
+// printRankingText prints versions in order of preference into tab writer
+func (r *TextReporter) printRankingText(w *tabwriter.Writer) {
+	fmt.Fprintln(w, "Rank\tVersion")
+	fmt.Fprintln(w, "----\t-------")
+	for k, j := range r.Ranking() {
+		fmt.Fprintf(w, "%v\tversion %v", k+1, j)
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+// PrintComparisonsText returns the pairwise comparisons section of the text report as a string
+func (r *TextReporter) PrintComparisonsText() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', tabwriter.Debug)
+	r.printComparisonsText(w)
+	return b.String()
+}
+
+// printComparisonsText prints pairwise comparisons of versions for each sample and histogram metric into tab writer
+func (r *TextReporter) printComparisonsText(w *tabwriter.Writer) {
+	fmt.Fprintln(w, "Metric\tVersions\tDifference\tRelative difference\tp-value\tSignificant")
+	fmt.Fprintln(w, "------\t--------\t----------\t-------------------\t-------\t-----------")
+	for _, pc := range r.PairwiseComparisons() {
+		mwu, err := r.MetricWithUnits(pc.Metric)
+		if err != nil {
+			log.Logger.Error(err)
+			continue
+		}
+		fmt.Fprintf(w, "%v\t%v vs %v\t%v\t%v\t%v\t%v", mwu, pc.Version, pc.Other,
+			r.ComparisonValueStr(&pc.Difference), r.ComparisonValueStr(pc.RelativeDifference),
+			r.ComparisonValueStr(pc.PValue), pc.Significant)
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
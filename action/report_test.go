@@ -1,12 +1,15 @@
 package action
 
 import (
+	"bytes"
 	"context"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
 	"github.com/stretchr/testify/assert"
 	"helm.sh/helm/v3/pkg/cli"
@@ -45,6 +48,48 @@ func TestLocalReportHTML(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestLocalReportSARIF(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix rOpts
+	rOpts := NewReportOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.RunDir = base.CompletePath("../", "testdata/assertinputs")
+	rOpts.OutputFormat = SARIFOutputFormatKey
+
+	err := rOpts.LocalRun(os.Stdout)
+	assert.NoError(t, err)
+}
+
+func TestLocalReportPrometheus(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix rOpts
+	rOpts := NewReportOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.RunDir = base.CompletePath("../", "testdata/assertinputs")
+	rOpts.OutputFormat = PrometheusOutputFormatKey
+	rOpts.Labels = map[string]string{"app": "httpbin"}
+
+	var b bytes.Buffer
+	err := rOpts.LocalRun(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `iter8_experiment_completed{app="httpbin"} 1`)
+
+	// labels must be valid, and must not clash with the labels of samples
+	for _, label := range []string{"version", "__name__", "app-name"} {
+		rOpts.Labels = map[string]string{label: "httpbin"}
+		assert.Error(t, rOpts.LocalRun(&b), label)
+	}
+}
+
+func TestLocalReportGrafana(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix rOpts
+	rOpts := NewReportOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.RunDir = base.CompletePath("../", "testdata/assertinputs")
+	rOpts.OutputFormat = GrafanaOutputFormatKey
+
+	err := rOpts.LocalRun(os.Stdout)
+	assert.NoError(t, err)
+}
+
 func TestKubeReportText(t *testing.T) {
 	os.Chdir(t.TempDir())
 	base.SetupWithMock(t)
@@ -73,3 +118,73 @@ func TestLocalReportHTMLNoInsights(t *testing.T) {
 	err := rOpts.LocalRun(os.Stdout)
 	assert.NoError(t, err)
 }
+
+func TestLocalReportTemplate(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix rOpts
+	rOpts := NewReportOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.RunDir = base.CompletePath("../", "testdata/assertinputs")
+	rOpts.TemplateFile = base.CompletePath("../", "testdata/report.tpl")
+
+	var b bytes.Buffer
+	err := rOpts.LocalRun(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "SLOs satisfied: true")
+}
+
+func TestKubeReportCompare(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix rOpts
+	rOpts := NewReportOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.Compare = []string{"other"}
+
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", driver.ExperimentPath))
+	for _, name := range []string{"default", "other"} {
+		rOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
+		}, metav1.CreateOptions{})
+	}
+
+	// comparisons are logged
+	var b bytes.Buffer
+	log.Logger.Out = &b
+	defer func() { log.Logger.Out = os.Stderr }()
+	err := rOpts.KubeRun(ioutil.Discard)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "comparison of experiments")
+	assert.Contains(t, b.String(), "other")
+}
+
+func TestKubeReportFollow(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix rOpts
+	rOpts := NewReportOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.Follow = true
+
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", driver.ExperimentPath))
+	rOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, rOpts.KubeDriver.Init())
+
+	// the report is generated until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	var b bytes.Buffer
+	err := rOpts.follow(ctx, &b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "Experiment completed: true")
+	assert.NotContains(t, b.String(), clearScreen)
+
+	// comparison reports cannot be followed
+	rOpts.Compare = []string{"other"}
+	assert.Error(t, rOpts.follow(ctx, &b))
+}
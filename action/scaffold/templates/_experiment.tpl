@@ -0,0 +1,14 @@
+{{- define "experiment" -}}
+{{- if not .Values.tasks }}
+{{- fail ".Values.tasks is empty" }}
+{{- end }}
+spec:
+  {{- range .Values.tasks }}
+  {{- include (print "task." .) (index $.Values .) | trim | nindent 0 }}
+  {{- end }}
+result:
+  startTime:         {{ now | toJson }}
+  numCompletedTasks: 0
+  failure:           false
+  iter8Version:      {{ .Values.majorMinor }}
+{{- end }}
@@ -0,0 +1,10 @@
+{{- define "task.http" -}}
+{{- if not .url }}
+{{- fail "please specify the url parameter" }}
+{{- end }}
+# task: generate HTTP requests for app
+# collect Iter8's built-in HTTP latency and error-related metrics
+- task: http
+  with:
+{{ toYaml . | indent 4 }}
+{{- end }}
@@ -0,0 +1,182 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/xeipuuv/gojsonschema"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+// validateChart checks that this version of Iter8 supports the chart in the given dir,
+// and validates the values in the options against the values schema of the chart
+func validateChart(dir string, opts values.Options) error {
+	c, err := loader.Load(dir)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to load experiment chart")
+		return err
+	}
+	if err := checkCLIVersion(c); err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	v, err := opts.MergeValues(getter.All(cli.New()))
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to obtain values for chart")
+		return err
+	}
+	if err := validateValues(c, v); err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	return nil
+}
+
+// validateValues validates values against the values.schema.json file of the chart, if any.
+// Values are combined with the default values of the chart before they are validated.
+// The returned error lists each violation, along with a hint about how to fix it.
+func validateValues(c *chart.Chart, vals map[string]interface{}) error {
+	if c.Schema == nil {
+		return nil
+	}
+	coalesced, err := chartutil.CoalesceValues(c, vals)
+	if err != nil {
+		return err
+	}
+	valuesJSON, err := json.Marshal(coalesced)
+	if err != nil {
+		return err
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(c.Schema), gojsonschema.NewBytesLoader(valuesJSON))
+	if err != nil {
+		return fmt.Errorf("invalid values schema in chart %v: %v", c.Name(), err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(c.Schema, &schema); err != nil {
+		return err
+	}
+	ve := &valuesError{chart: c.Name()}
+	for _, re := range result.Errors() {
+		ve.violations = append(ve.violations, describeSchemaError(schema, re))
+	}
+	return ve
+}
+
+// valuesError lists the violations of the values schema of a chart
+type valuesError struct {
+	// chart is the name of the chart
+	chart string
+	// violations describe each violation of the values schema
+	violations []string
+}
+
+// Error lists each violation of the values schema on a separate line
+func (e *valuesError) Error() string {
+	return fmt.Sprintf("invalid values for chart %v:\n- %v", e.chart, strings.Join(e.violations, "\n- "))
+}
+
+// describeSchemaError describes a violation of the values schema in terms of the values set by users
+func describeSchemaError(schema map[string]interface{}, re gojsonschema.ResultError) string {
+	field := re.Field()
+	if field == gojsonschema.STRING_CONTEXT_ROOT {
+		field = ""
+	}
+	switch re.Type() {
+	case "additional_property_not_allowed":
+		property, _ := re.Details()["property"].(string)
+		key := joinKey(field, property)
+		known := schemaProperties(schema, field)
+		if s := base.ClosestMatch(property, known); s != "" {
+			return fmt.Sprintf("unknown key %v; did you mean %v?", key, joinKey(field, s))
+		}
+		if len(known) > 0 {
+			return fmt.Sprintf("unknown key %v; valid keys are %v", key, strings.Join(known, ", "))
+		}
+		return fmt.Sprintf("unknown key %v", key)
+	case "invalid_type":
+		expected, _ := re.Details()["expected"].(string)
+		given, _ := re.Details()["given"].(string)
+		msg := fmt.Sprintf("%v must be of type %v, not %v", field, expected, given)
+		if expected == "string" && (given == "integer" || given == "number" || given == "boolean") {
+			msg += fmt.Sprintf("; use --set-string %v=... to set it to a string", field)
+		}
+		if expected == "array" {
+			msg += fmt.Sprintf("; use --set \"%v={a,b}\" to set it to a list", field)
+		}
+		return msg
+	default:
+		if field == "" {
+			return re.Description()
+		}
+		return fmt.Sprintf("%v: %v", field, re.Description())
+	}
+}
+
+// joinKey joins the parent key and the child key, as in --set parent.child=...
+func joinKey(parent string, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+// schemaProperties returns the sorted names of the properties of the object at the given field of the schema.
+// Fields are dot separated keys, in which array items are identified by their index.
+func schemaProperties(schema map[string]interface{}, field string) []string {
+	node := schema
+	if field != "" {
+		for _, key := range strings.Split(field, ".") {
+			node = resolveRef(schema, node)
+			if _, err := strconv.Atoi(key); err == nil {
+				items, ok := node["items"].(map[string]interface{})
+				if !ok {
+					return nil
+				}
+				node = items
+				continue
+			}
+			props, _ := node["properties"].(map[string]interface{})
+			child, ok := props[key].(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			node = child
+		}
+	}
+	node = resolveRef(schema, node)
+	props, _ := node["properties"].(map[string]interface{})
+	names := []string{}
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveRef returns the definition referenced by the node, if it is a local reference;
+// otherwise, the node itself is returned
+func resolveRef(schema map[string]interface{}, node map[string]interface{}) map[string]interface{} {
+	ref, ok := node["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/definitions/") {
+		return node
+	}
+	defs, _ := schema["definitions"].(map[string]interface{})
+	if def, ok := defs[strings.TrimPrefix(ref, "#/definitions/")].(map[string]interface{}); ok {
+		return def
+	}
+	return node
+}
@@ -0,0 +1,74 @@
+package action
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateValues(t *testing.T) {
+	c, err := loader.Load(base.CompletePath("../", "charts/iter8"))
+	assert.NoError(t, err)
+	assert.NotNil(t, c.Schema)
+
+	// the examples in values.yaml are valid
+	b, err := ioutil.ReadFile(base.CompletePath("../", "charts/iter8/values.yaml"))
+	assert.NoError(t, err)
+	examples := []string{}
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			examples = append(examples, strings.TrimPrefix(line, "# "))
+		}
+	}
+	vals := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(strings.Join(examples, "\n")), &vals))
+	assert.Contains(t, vals, "istio")
+	assert.NoError(t, validateValues(c, vals))
+
+	for _, tc := range []struct {
+		values []string
+		msg    string
+	}{
+		{[]string{"runer=job"}, "unknown key runer; did you mean runner?"},
+		{[]string{"http.numReqests=5"}, "unknown key http.numReqests; did you mean http.numRequests?"},
+		{[]string{"xyzzy=1"}, "unknown key xyzzy; valid keys are annotate, assess, background"},
+		{[]string{"http.numRequests=many"}, "http.numRequests must be of type integer, not string"},
+		{[]string{"owner=123"}, "use --set-string owner=..."},
+		{[]string{"tasks=http"}, "tasks must be of type array, not string"},
+		{[]string{"runner=jobs"}, "runner: runner must be one of the following"},
+		{[]string{"ready.resources[0].resource=pods", "ready.resources[0].name=httpbin", "ready.resources[0].namespace=other"},
+			"unknown key ready.resources.0.namespace"},
+	} {
+		opts := values.Options{Values: tc.values}
+		v, err := opts.MergeValues(nil)
+		assert.NoError(t, err)
+		err = validateValues(c, v)
+		if assert.Error(t, err, tc.values) {
+			assert.Contains(t, err.Error(), tc.msg)
+		}
+	}
+}
+
+func TestValidateValuesWithoutSchema(t *testing.T) {
+	c, err := loader.Load(base.CompletePath("../", "charts/iter8"))
+	assert.NoError(t, err)
+	c.Schema = nil
+	assert.NoError(t, validateValues(c, chartutil.Values{"unknown": true}))
+}
+
+func TestGenInvalidValues(t *testing.T) {
+	gen := NewGenOpts()
+	gen.ChartsParentDir = base.CompletePath("../", "")
+	gen.GenDir = t.TempDir()
+	gen.Values = []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.qps=fast"}
+	err := gen.LocalRun(ioutil.Discard)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "http.qps must be of type number, not string")
+}
@@ -0,0 +1,248 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/iter8/action/report"
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+const (
+	// DefaultServePort is the default port of the Iter8 server
+	DefaultServePort = 8080
+	// DefaultServeAddress is the default address on which the Iter8 server listens
+	DefaultServeAddress = "localhost"
+	// VerdictPath is the path of the endpoint that serves experiment verdicts
+	VerdictPath = "/verdict"
+	// FlaggerPath is the path of the Flagger-compatible webhook endpoint
+	FlaggerPath = "/flagger"
+	// HealthPath is the path of the health check endpoint
+	HealthPath = "/healthz"
+	// groupParam is the query parameter used to select the experiment group
+	groupParam = "group"
+)
+
+// ServeOpts are the options used for serving experiment verdicts over HTTP
+type ServeOpts struct {
+	// Address is the host or IP address on which the server listens; empty for all interfaces
+	Address string
+	// Port is the port on which the server listens
+	Port int
+	// API enables the experiments API, through which experiments are submitted and inspected
+	API bool
+	// APIToken is the bearer token required by the experiments API
+	APIToken string
+	// APITasks are the tasks that experiments submitted to the experiments API may use
+	APITasks []string
+	// RunOpts provides options relating to experiment resources
+	RunOpts
+}
+
+// Verdict is the experiment verdict served by the Iter8 server.
+// It is designed to be consumed by tools like the Argo Rollouts web metric provider;
+// for example, using the success condition result.pass == true.
+type Verdict struct {
+	// Group is the experiment group; empty for local experiments
+	Group string `json:"group,omitempty"`
+	// Completed is true if the experiment has completed
+	Completed bool `json:"completed"`
+	// NoFailure is true if no task in the experiment has failed
+	NoFailure bool `json:"noFailure"`
+	// SLOs is true if all app versions satisfy SLOs
+	SLOs bool `json:"slos"`
+	// Pass is true if the experiment completed without failures and all app versions satisfy SLOs
+	Pass bool `json:"pass"`
+	// NumVersions is the number of app versions in the experiment
+	NumVersions int `json:"numVersions"`
+	// Metrics are the scalar metric values for each app version; nil values are unavailable
+	Metrics map[string][]*float64 `json:"metrics,omitempty"`
+}
+
+// NewServeOpts initializes and returns serve opts
+func NewServeOpts(kd *driver.KubeDriver) *ServeOpts {
+	return &ServeOpts{
+		Address:  DefaultServeAddress,
+		Port:     DefaultServePort,
+		APITasks: DefaultAPITasks,
+		RunOpts:  *NewRunOpts(kd),
+	}
+}
+
+// LocalRun serves the verdict of a local experiment
+func (sOpts *ServeOpts) LocalRun() error {
+	return sOpts.Run(func(_ string) (base.Driver, error) {
+		return sOpts.localDriver()
+	})
+}
+
+// KubeRun serves the verdicts of Kubernetes experiments.
+// The experiment group may be selected using the group query parameter.
+func (sOpts *ServeOpts) KubeRun() error {
+	if err := sOpts.KubeDriver.InitKube(); err != nil {
+		return err
+	}
+	return sOpts.Run(sOpts.kubeDriverFor)
+}
+
+// kubeDriverFor returns a Kubernetes driver for the given experiment group
+func (sOpts *ServeOpts) kubeDriverFor(group string) (base.Driver, error) {
+	kd := *sOpts.KubeDriver
+	if group != "" {
+		kd.Group = group
+	}
+	return &kd, nil
+}
+
+// Run starts the server
+func (sOpts *ServeOpts) Run(driverFor func(group string) (base.Driver, error)) error {
+	addr := net.JoinHostPort(sOpts.Address, strconv.Itoa(sOpts.Port))
+	mux := http.NewServeMux()
+	mux.Handle("/", NewServeHandler(driverFor))
+	if sOpts.API {
+		a, err := NewExperimentAPI(sOpts.APIToken, sOpts.APITasks)
+		if err != nil {
+			log.Logger.Error(err)
+			return err
+		}
+		log.Logger.Infof("serving experiments API on %v%v", addr, ExperimentsPath)
+		a.Register(mux)
+	}
+	log.Logger.Infof("serving experiment verdicts on %v", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// NewServeHandler returns the HTTP handler of the Iter8 server
+func NewServeHandler(driverFor func(group string) (base.Driver, error)) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc(VerdictPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		group := r.URL.Query().Get(groupParam)
+		d, err := driverFor(group)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		exp, err := base.BuildExperiment(d)
+		if err != nil {
+			http.Error(w, "unable to read experiment", http.StatusNotFound)
+			return
+		}
+		v := GetVerdict(exp)
+		v.Group = group
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	})
+	mux.HandleFunc(FlaggerPath, func(w http.ResponseWriter, r *http.Request) {
+		flaggerWebhook(w, r, driverFor)
+	})
+	return mux
+}
+
+// FlaggerPayload is the payload sent by Flagger to webhooks
+type FlaggerPayload struct {
+	// Name is the name of the canary
+	Name string `json:"name"`
+	// Namespace is the namespace of the canary
+	Namespace string `json:"namespace"`
+	// Phase is the canary analysis phase
+	Phase string `json:"phase"`
+	// Metadata is the webhook metadata specified in the canary.
+	// Iter8 uses the following keys:
+	// group is the experiment group (defaults to the group query parameter);
+	// conditions is a comma-separated list of conditions that must be satisfied (defaults to completed,nofailure,slos).
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// flaggerWebhook implements a Flagger-compatible webhook.
+// It responds with status 200 if the experiment satisfies the conditions, which lets the canary advance,
+// and with status 412 otherwise, which makes Flagger count a failed check.
+func flaggerWebhook(w http.ResponseWriter, r *http.Request, driverFor func(group string) (base.Driver, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload := FlaggerPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid Flagger payload", http.StatusBadRequest)
+		return
+	}
+
+	group := r.URL.Query().Get(groupParam)
+	if g, ok := payload.Metadata[groupParam]; ok {
+		group = g
+	}
+	conditions := []string{Completed, NoFailure, SLOs}
+	if c, ok := payload.Metadata["conditions"]; ok {
+		conditions = strings.Split(c, ",")
+	}
+
+	d, err := driverFor(group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	exp, err := base.BuildExperiment(d)
+	if err != nil {
+		http.Error(w, "unable to read experiment", http.StatusNotFound)
+		return
+	}
+
+	v := GetVerdict(exp)
+	v.Group = group
+	ok := true
+	for _, cond := range conditions {
+		switch strings.ToLower(strings.TrimSpace(cond)) {
+		case Completed:
+			ok = ok && v.Completed
+		case NoFailure:
+			ok = ok && v.NoFailure
+		case SLOs:
+			ok = ok && v.SLOs
+		default:
+			http.Error(w, fmt.Sprintf("unsupported condition %v", cond), http.StatusBadRequest)
+			return
+		}
+	}
+	log.Logger.Infof("Flagger webhook for canary %v/%v in phase %v: conditions satisfied: %v", payload.Namespace, payload.Name, payload.Phase, ok)
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusPreconditionFailed)
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+// GetVerdict returns the verdict for the given experiment
+func GetVerdict(exp *base.Experiment) *Verdict {
+	v := &Verdict{
+		Completed: exp.Completed(),
+		NoFailure: exp.NoFailure(),
+	}
+	if exp.Result != nil && exp.Result.Insights != nil {
+		in := exp.Result.Insights
+		v.SLOs = exp.SLOs()
+		v.NumVersions = in.NumVersions
+		r := &report.Reporter{Experiment: exp}
+		v.Metrics = map[string][]*float64{}
+		for _, mn := range r.SortedScalarAndSLOMetrics() {
+			for j := 0; j < in.NumVersions; j++ {
+				v.Metrics[mn] = append(v.Metrics[mn], in.ScalarMetricValue(j, mn))
+			}
+		}
+	}
+	v.Pass = v.Completed && v.NoFailure && v.SLOs
+	return v
+}
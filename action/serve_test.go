@@ -0,0 +1,116 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLocalServe(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix sOpts
+	sOpts := NewServeOpts(driver.NewFakeKubeDriver(cli.New()))
+	sOpts.RunDir = base.CompletePath("../", "testdata/assertinputs")
+	srv := httptest.NewServer(NewServeHandler(func(_ string) (base.Driver, error) {
+		return &driver.FileDriver{RunDir: sOpts.RunDir}, nil
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + HealthPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + VerdictPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	b, _ := ioutil.ReadAll(resp.Body)
+	v := Verdict{}
+	assert.NoError(t, json.Unmarshal(b, &v))
+	assert.True(t, v.Completed)
+	assert.True(t, v.NoFailure)
+	assert.True(t, v.SLOs)
+	assert.True(t, v.Pass)
+	assert.Equal(t, 1, v.NumVersions)
+	assert.NotNil(t, v.Metrics["http/latency-mean"][0])
+
+	resp, err = http.Post(srv.URL+VerdictPath, "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestLocalServeFailing(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputsfail/experiment.yaml"))
+	fd := &driver.FileDriver{RunDir: "."}
+	exp, err := base.BuildExperiment(fd)
+	assert.NoError(t, err)
+	assert.False(t, GetVerdict(exp).Pass)
+}
+
+func TestKubeServe(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// fix sOpts
+	sOpts := NewServeOpts(driver.NewFakeKubeDriver(cli.New()))
+
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", driver.ExperimentPath))
+	sOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-group",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+
+	srv := httptest.NewServer(NewServeHandler(sOpts.kubeDriverFor))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + VerdictPath + "?group=my-group")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	b, _ := ioutil.ReadAll(resp.Body)
+	v := Verdict{}
+	assert.NoError(t, json.Unmarshal(b, &v))
+	assert.Equal(t, "my-group", v.Group)
+	assert.True(t, v.Pass)
+}
+
+func TestServeFlagger(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputsfail/experiment.yaml"))
+	srv := httptest.NewServer(NewServeHandler(func(group string) (base.Driver, error) {
+		if group == "passing" {
+			return &driver.FileDriver{RunDir: base.CompletePath("../", "testdata/assertinputs")}, nil
+		}
+		return &driver.FileDriver{RunDir: "."}, nil
+	}))
+	defer srv.Close()
+
+	post := func(payload string) int {
+		resp, err := http.Post(srv.URL+FlaggerPath, "application/json", strings.NewReader(payload))
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// passing experiment
+	assert.Equal(t, http.StatusOK, post(`{"name": "podinfo", "namespace": "test", "phase": "Progressing", "metadata": {"group": "passing"}}`))
+	// failing experiment
+	assert.Equal(t, http.StatusPreconditionFailed, post(`{"name": "podinfo", "namespace": "test", "phase": "Progressing"}`))
+	// failing experiment satisfies the completed condition
+	assert.Equal(t, http.StatusOK, post(`{"name": "podinfo", "namespace": "test", "phase": "Progressing", "metadata": {"conditions": "completed"}}`))
+	// invalid condition
+	assert.Equal(t, http.StatusBadRequest, post(`{"name": "podinfo", "metadata": {"conditions": "winner"}}`))
+	// invalid payload
+	assert.Equal(t, http.StatusBadRequest, post(`not json`))
+}
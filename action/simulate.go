@@ -0,0 +1,58 @@
+package action
+
+import (
+	"errors"
+	"io/ioutil"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+	"sigs.k8s.io/yaml"
+)
+
+// SimulateOpts are the options used for simulating an experiment
+type SimulateOpts struct {
+	// Rundir is the directory of the local experiment.yaml file
+	RunDir string
+	// SimulationFile is the YAML file that describes the synthetic metrics.
+	// If empty, default synthetic metrics are used.
+	SimulationFile string
+}
+
+// NewSimulateOpts initializes and returns simulate opts
+func NewSimulateOpts() *SimulateOpts {
+	return &SimulateOpts{
+		RunDir: ".",
+	}
+}
+
+// simulation reads the simulation file
+func (sOpts *SimulateOpts) simulation() (*base.Simulation, error) {
+	sim := &base.Simulation{}
+	if sOpts.SimulationFile == "" {
+		return sim, nil
+	}
+	b, err := ioutil.ReadFile(sOpts.SimulationFile)
+	if err != nil {
+		e := errors.New("unable to read simulation file")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	if err = yaml.UnmarshalStrict(b, sim); err != nil {
+		e := errors.New("unable to parse simulation file")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	return sim, nil
+}
+
+// LocalRun simulates a local experiment
+func (sOpts *SimulateOpts) LocalRun() error {
+	sim, err := sOpts.simulation()
+	if err != nil {
+		return err
+	}
+	return base.SimulateExperiment(sim, &driver.FileDriver{
+		RunDir: sOpts.RunDir,
+	})
+}
@@ -0,0 +1,111 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+const (
+	// statusWatchInterval is the duration between refreshes of the experiment status in watch mode
+	statusWatchInterval = 3 * time.Second
+)
+
+// StatusOpts are the options used for summarizing the status of an experiment
+type StatusOpts struct {
+	// Watch refreshes the status until the experiment completes, fails, or is aborted
+	Watch bool
+	// KubeDriver enables fetching Kubernetes experiment spec and result
+	*driver.KubeDriver
+}
+
+// NewStatusOpts initializes and returns status opts
+func NewStatusOpts(kd *driver.KubeDriver) *StatusOpts {
+	return &StatusOpts{
+		KubeDriver: kd,
+	}
+}
+
+// KubeRun summarizes the status of a Kubernetes experiment
+func (sOpts *StatusOpts) KubeRun() error {
+	if err := sOpts.KubeDriver.Init(); err != nil {
+		return err
+	}
+	return sOpts.Run(sOpts.KubeDriver)
+}
+
+// Run builds the experiment and logs its status.
+// In watch mode, the status is logged repeatedly until the experiment completes or fails.
+func (sOpts *StatusOpts) Run(eio base.Driver) error {
+	for {
+		exp, err := base.BuildExperiment(eio)
+		if err != nil {
+			return err
+		}
+		log.Logger.WithIndentedTrace(statusTable(exp)).Info("experiment status")
+		if !sOpts.Watch || exp.Completed() || exp.Aborted() || exp.Interrupted() || !exp.NoFailure() {
+			return nil
+		}
+		log.Logger.Debugf("refreshing experiment status in %v", statusWatchInterval)
+		time.Sleep(statusWatchInterval)
+	}
+}
+
+// experimentState returns the state of the experiment; not started, running, completed, aborted, or failed
+func experimentState(exp *base.Experiment) string {
+	switch {
+	case exp.Result == nil:
+		return "not started"
+	case !exp.NoFailure():
+		return "failed"
+	case exp.Completed():
+		return "completed"
+	case exp.Aborted():
+		return "aborted"
+	case exp.Interrupted():
+		return "interrupted"
+	default:
+		return "running"
+	}
+}
+
+// statusTable returns the status of the experiment as a table
+func statusTable(exp *base.Experiment) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tVALUE")
+	if exp.Result == nil {
+		fmt.Fprintf(w, "State\t%v\n", experimentState(exp))
+		w.Flush()
+		return b.String()
+	}
+	fmt.Fprintf(w, "State\t%v\n", experimentState(exp))
+	fmt.Fprintf(w, "Revision\t%v\n", exp.Result.Revision)
+	fmt.Fprintf(w, "Start time\t%v\n", exp.Result.StartTime.Format(time.RFC3339))
+	fmt.Fprintf(w, "Loops\t%v\n", exp.Result.NumLoops)
+	fmt.Fprintf(w, "Completed tasks\t%v of %v\n", exp.Result.NumCompletedTasks, len(exp.Spec))
+	fmt.Fprintf(w, "Failure\t%v\n", exp.Result.Failure)
+	if exp.Result.Aborted {
+		fmt.Fprintf(w, "Aborted\t%v\n", exp.Result.Aborted)
+	}
+	if exp.Result.Interrupted {
+		fmt.Fprintf(w, "Interrupted\t%v\n", exp.Result.Interrupted)
+	}
+	if in := exp.Result.Insights; in != nil && in.SLOs != nil {
+		satisfying := 0
+		for j := 0; j < in.NumVersions; j++ {
+			if exp.SLOsBy(j) {
+				satisfying++
+			}
+		}
+		fmt.Fprintf(w, "SLOs\t%v\n", len(in.SLOs.Upper)+len(in.SLOs.Lower))
+		fmt.Fprintf(w, "Versions satisfying SLOs\t%v of %v\n", satisfying, in.NumVersions)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package action
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestStatus(t *testing.T) {
+	os.Chdir(t.TempDir())
+	driver.CopyFileToPwd(t, base.CompletePath("../", "testdata/assertinputsfail/experiment.yaml"))
+
+	sOpts := NewStatusOpts(driver.NewFakeKubeDriver(cli.New()))
+	sOpts.Watch = true
+	buf := new(bytes.Buffer)
+	log.Logger.Out = buf
+	defer func() { log.Logger.Out = os.Stderr }()
+	err := sOpts.Run(&driver.FileDriver{RunDir: "."})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "experiment status")
+	assert.Contains(t, buf.String(), "completed")
+	assert.Contains(t, buf.String(), "Completed tasks           4 of 4")
+	assert.Contains(t, buf.String(), "Versions satisfying SLOs  0 of 1")
+}
@@ -0,0 +1,283 @@
+package base
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"fortio.org/fortio/stats"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// defaultAffinityUsers is the default number of synthetic users
+	defaultAffinityUsers = 100
+	// defaultAffinityUserID is the default template of the IDs of synthetic users
+	defaultAffinityUserID = "user-[[ .User ]]"
+	// defaultAffinityHeader is the default HTTP header with the ID of the user
+	defaultAffinityHeader = "X-User-Id"
+
+	// builtInHTTPUserCountId is the number of synthetic users routed to the version
+	builtInHTTPUserCountId = "user-count"
+	// builtInHTTPUserErrorRateId is the fraction of synthetic users routed to the version who received an error
+	builtInHTTPUserErrorRateId = "user-error-rate"
+)
+
+// affinityInputs configure session affinity; requests are sent on behalf of synthetic users,
+// and each user is consistently routed to the same version, by hashing the ID of the user
+type affinityInputs struct {
+	// Users is the number of synthetic users; requests are sent on behalf of each user in turn. Optional. Default is 100.
+	Users *int `json:"users,omitempty" yaml:"users,omitempty"`
+	// UserID is the template of the ID of each user, which is executed with the index of the user (.User);
+	// for example, session-[[ .User ]]. The template uses [[ and ]] as delimiters, since the outputs of earlier tasks
+	// are used in inputs with {{ and }}. Optional. Default is user-[[ .User ]].
+	UserID string `json:"userID,omitempty" yaml:"userID,omitempty"`
+	// Header is the HTTP header with the ID of the user in each request. Optional. Default is X-User-Id.
+	Header string `json:"header,omitempty" yaml:"header,omitempty"`
+}
+
+// validate the affinity inputs
+func (a *affinityInputs) validate() error {
+	if a.Users != nil && *a.Users <= 0 {
+		return fmt.Errorf("number of users %v must be positive", *a.Users)
+	}
+	if _, err := a.userIDs(1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// initializeDefaults sets default values for the affinity inputs
+func (a *affinityInputs) initializeDefaults() {
+	if a.Users == nil {
+		a.Users = intPointer(defaultAffinityUsers)
+	}
+	if a.UserID == "" {
+		a.UserID = defaultAffinityUserID
+	}
+	if a.Header == "" {
+		a.Header = defaultAffinityHeader
+	}
+}
+
+// userIDs returns the IDs of n users
+func (a *affinityInputs) userIDs(n int) ([]string, error) {
+	userID := a.UserID
+	if userID == "" {
+		userID = defaultAffinityUserID
+	}
+	tpl, err := template.New("userID").Delims("[[", "]]").Option("missingkey=error").Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template of user IDs %q: %v", userID, err)
+	}
+	ids := make([]string, n)
+	for i := range ids {
+		var b bytes.Buffer
+		if err := tpl.Execute(&b, map[string]interface{}{"User": i}); err != nil {
+			return nil, fmt.Errorf("invalid template of user IDs %q: %v", userID, err)
+		}
+		ids[i] = b.String()
+	}
+	return ids, nil
+}
+
+// affinityVersion returns the version to which the user is routed, by hashing the ID of the user
+func affinityVersion(userID string, numVersions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return int(h.Sum32() % uint32(numVersions))
+}
+
+// affinityUser is a synthetic user, who is routed to the same version in each request
+type affinityUser struct {
+	// id of the user
+	id string
+	// version to which the user is routed
+	version int
+	// errored is true if the user received an error
+	errored bool
+}
+
+// affinityWorker sends the requests of synthetic users, and accumulates the metrics of each version without synchronization
+type affinityWorker struct {
+	// hists are the histograms of latencies of each version, in seconds
+	hists []*stats.Histogram
+	// errs are the numbers of responses of each version that were errors
+	errs []float64
+}
+
+// newAffinityWorker returns a worker with empty metrics for the given number of versions
+func newAffinityWorker(numVersions int) *affinityWorker {
+	w := &affinityWorker{
+		hists: make([]*stats.Histogram, numVersions),
+		errs:  make([]float64, numVersions),
+	}
+	for i := range w.hists {
+		w.hists[i] = stats.NewHistogram(0, 0.001)
+	}
+	return w
+}
+
+// send sends a request of the user to its version, and records the metrics of the response.
+// Each user is handled by one worker at a time, so the user is updated without synchronization.
+func (w *affinityWorker) send(t *collectHTTPTask, client *http.Client, payload []byte, u *affinityUser) {
+	v := t.With.Versions[u.version]
+	method := http.MethodGet
+	if payload != nil {
+		method = http.MethodPost
+	}
+	status := 0
+	start := time.Now()
+	req, err := http.NewRequest(method, v.URL, bytes.NewReader(payload))
+	if err == nil {
+		for k, val := range t.With.Headers {
+			req.Header.Set(k, val)
+		}
+		for k, val := range v.Headers {
+			req.Header.Set(k, val)
+		}
+		if t.With.ContentType != nil {
+			req.Header.Set("Content-Type", *t.With.ContentType)
+		}
+		req.Header.Set(t.With.Affinity.Header, u.id)
+		var resp *http.Response
+		if resp, err = client.Do(req); err == nil {
+			_, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil {
+				status = resp.StatusCode
+			}
+		}
+	}
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Debugf("request of user %v to %v failed", u.id, v.URL)
+	}
+	w.hists[u.version].Record(time.Since(start).Seconds())
+	if status == 0 || t.errorCode(status) {
+		w.errs[u.version]++
+		u.errored = true
+	}
+}
+
+// payload returns the payload of requests, or nil if requests have no payload
+func (t *collectHTTPTask) payload() ([]byte, error) {
+	if t.With.PayloadFile != nil {
+		return ioutil.ReadFile(*t.With.PayloadFile)
+	}
+	if t.With.PayloadStr != nil {
+		return []byte(*t.With.PayloadStr), nil
+	}
+	return nil, nil
+}
+
+// runAffinity sends requests on behalf of synthetic users, routing each user consistently to the same version,
+// and updates the built-in metrics of each version along with the number of users and the fraction of them who received errors
+func (t *collectHTTPTask) runAffinity(exp *Experiment) error {
+	a := t.With.Affinity
+	a.initializeDefaults()
+	ids, err := a.userIDs(*a.Users)
+	if err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	payload, err := t.payload()
+	if err != nil {
+		e := errors.New("unable to read payload")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	numVersions := len(t.With.Versions)
+	users := make([]*affinityUser, len(ids))
+	for i, id := range ids {
+		users[i] = &affinityUser{id: id, version: affinityVersion(id, numVersions)}
+	}
+	var deadline time.Time
+	if t.With.Duration != nil {
+		d, _ := time.ParseDuration(*t.With.Duration)
+		deadline = time.Now().Add(d)
+	}
+
+	// requests are sent at the given rate by a fixed pool of workers, one for each connection;
+	// each worker accumulates its own metrics, which are merged when the load test ends.
+	// Users take turns, and a user is sent to a worker only after its previous request completes.
+	workers := make([]*affinityWorker, *t.With.Connections)
+	client := newMirrorClient(*t.With.Connections)
+	work := make(chan *affinityUser, len(workers))
+	idle := make(chan *affinityUser, len(users))
+	for _, u := range users {
+		idle <- u
+	}
+	var wg sync.WaitGroup
+	for i := range workers {
+		w := newAffinityWorker(numVersions)
+		workers[i] = w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range work {
+				w.send(t, client, payload, u)
+				idle <- u
+			}
+		}()
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / float64(*t.With.QPS)))
+	for n := int64(0); t.With.NumRequests == nil || n < *t.With.NumRequests; n++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		if exp.stopped() {
+			log.Logger.Warn("stopped sending requests of users")
+			break
+		}
+		work <- <-idle
+		<-ticker.C
+	}
+	ticker.Stop()
+	close(work)
+	wg.Wait()
+	client.CloseIdleConnections()
+
+	err = exp.Result.initInsightsWithNumVersions(numVersions)
+	if err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+	userCounts := make([]float64, numVersions)
+	userErrors := make([]float64, numVersions)
+	for _, u := range users {
+		userCounts[u.version]++
+		if u.errored {
+			userErrors[u.version]++
+		}
+	}
+	for i := 0; i < numVersions; i++ {
+		h := stats.NewHistogram(0, 0.001)
+		errs := float64(0)
+		for _, w := range workers {
+			h.Transfer(w.hists[i])
+			errs += w.errs[i]
+		}
+		t.updateMetrics(in, i, errs, h.Export().CalcPercentiles(t.With.Percentiles))
+
+		in.updateMetric(httpMetricPrefix+"/"+builtInHTTPUserCountId, MetricMeta{
+			Description: "number of synthetic users routed to the version",
+			Type:        GaugeMetricType,
+		}, i, userCounts[i])
+		userErrorRate := float64(0)
+		if userCounts[i] > 0 {
+			userErrorRate = userErrors[i] / userCounts[i]
+		}
+		in.updateMetric(httpMetricPrefix+"/"+builtInHTTPUserErrorRateId, MetricMeta{
+			Description: "fraction of synthetic users routed to the version who received an error",
+			Type:        GaugeMetricType,
+		}, i, userErrorRate)
+	}
+	log.Logger.Infof("routed %v users to versions; number of users of each version: %v", len(users), userCounts)
+	return nil
+}
@@ -0,0 +1,285 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// AnnotateTaskName is the name of the task this file implements
+	AnnotateTaskName = "annotate"
+
+	// DatadogProvider posts experiment milestones as Datadog events
+	DatadogProvider = "datadog"
+	// GrafanaProvider posts experiment milestones as Grafana annotations
+	GrafanaProvider = "grafana"
+
+	// defaultDatadogURL is the default URL of the Datadog API
+	defaultDatadogURL = "https://api.datadoghq.com"
+	// datadogEventsPath is the path of the Datadog events API
+	datadogEventsPath = "/api/v1/events"
+	// grafanaAnnotationsPath is the path of the Grafana annotations API
+	grafanaAnnotationsPath = "/api/annotations"
+	// defaultAnnotateTimeout is the default timeout of requests to the events or annotations API
+	defaultAnnotateTimeout = "10s"
+	// iter8Tag is added to all events and annotations, so that they can be filtered in dashboards
+	iter8Tag = "iter8"
+)
+
+// milestone is a point in the timeline of an experiment that is posted as an event or annotation
+type milestone string
+
+const (
+	// startedMilestone is posted before the first task of a loop has completed
+	startedMilestone milestone = "started"
+	// failedMilestone is posted after a task has failed
+	failedMilestone milestone = "failed"
+	// abortedMilestone is posted after the experiment is aborted
+	abortedMilestone milestone = "aborted"
+	// sloViolatedMilestone is posted when a version does not satisfy SLOs
+	sloViolatedMilestone milestone = "slo-violated"
+	// winnerMilestone is posted when all versions satisfy SLOs, and a winner is selected
+	winnerMilestone milestone = "winner-selected"
+	// completedMilestone is posted when the experiment completes without SLOs
+	completedMilestone milestone = "completed"
+)
+
+// annotateInputs are the inputs to the annotate task
+type annotateInputs struct {
+	// Provider is the service to which the event is posted; datadog or grafana
+	Provider string `json:"provider" yaml:"provider"`
+	// URL is the base URL of the Datadog API, or of the Grafana instance.
+	// Defaults to https://api.datadoghq.com for Datadog; required for Grafana.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// APIKeyEnv is the name of the environment variable containing the Datadog API key or the Grafana token
+	APIKeyEnv string `json:"apiKeyEnv,omitempty" yaml:"apiKeyEnv,omitempty"`
+	// APIKeyFile is the path to a file containing the Datadog API key or the Grafana token, such as a mounted Kubernetes secret
+	APIKeyFile string `json:"apiKeyFile,omitempty" yaml:"apiKeyFile,omitempty"`
+	// Tags are added to the event, in addition to the iter8 and milestone tags; for example, service:httpbin
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Title of the event; defaults to a title with the milestone
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	// Text of the event; defaults to a summary of the experiment
+	Text string `json:"text,omitempty" yaml:"text,omitempty"`
+	// Timeout is the timeout of the request to the events or annotations API
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// annotateTask posts experiment milestones as events or annotations, so that experiments can be
+// overlaid on existing service dashboards.
+// The milestone is determined by the state of the experiment when the task runs; for example,
+// the task posts a started event in the onStart hook, and a winner or SLO violation at the end of the experiment.
+// Like the email task, the annotate task runs even if an earlier task has failed.
+type annotateTask struct {
+	// TaskMeta has fields common to all tasks
+	TaskMeta
+	// With contains the inputs to this task
+	With annotateInputs `json:"with" yaml:"with"`
+}
+
+// notifies marks the annotate task as a notification task
+func (t *annotateTask) notifies() {}
+
+// initializeDefaults sets default values for task inputs
+func (t *annotateTask) initializeDefaults() {
+	if t.With.URL == "" && t.With.Provider == DatadogProvider {
+		t.With.URL = defaultDatadogURL
+	}
+	if t.With.Timeout == nil {
+		t.With.Timeout = StringPointer(defaultAnnotateTimeout)
+	}
+}
+
+// validateInputs for this task
+func (t *annotateTask) validateInputs() error {
+	if t.With.Provider != DatadogProvider && t.With.Provider != GrafanaProvider {
+		return fmt.Errorf("invalid provider %v; must be one of %v or %v", t.With.Provider, DatadogProvider, GrafanaProvider)
+	}
+	if t.With.Provider == GrafanaProvider && t.With.URL == "" {
+		return errors.New("annotate task requires the URL of the Grafana instance")
+	}
+	if t.With.Timeout != nil {
+		if _, err := time.ParseDuration(*t.With.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %v: %v", *t.With.Timeout, err)
+		}
+	}
+	return nil
+}
+
+// apiKey returns the API key from the environment or from a file
+func (t *annotateTask) apiKey() (string, error) {
+	if t.With.APIKeyEnv != "" {
+		return os.Getenv(t.With.APIKeyEnv), nil
+	}
+	if t.With.APIKeyFile != "" {
+		b, err := ioutil.ReadFile(t.With.APIKeyFile)
+		if err != nil {
+			e := errors.New("unable to read API key file")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return "", e
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return "", nil
+}
+
+// experimentMilestone returns the milestone that the experiment has reached
+func experimentMilestone(exp *Experiment) milestone {
+	switch {
+	case exp.Result.Failure:
+		return failedMilestone
+	case exp.Aborted():
+		return abortedMilestone
+	case exp.Result.NumCompletedTasks == 0:
+		return startedMilestone
+	case exp.Result.Insights == nil || exp.Result.Insights.SLOs == nil:
+		return completedMilestone
+	case !exp.SLOs():
+		return sloViolatedMilestone
+	default:
+		return winnerMilestone
+	}
+}
+
+// title returns the title of the event
+func (t *annotateTask) title(m milestone) string {
+	if t.With.Title != "" {
+		return t.With.Title
+	}
+	switch m {
+	case sloViolatedMilestone:
+		return "Iter8 experiment SLOs violated"
+	case winnerMilestone:
+		return "Iter8 experiment winner selected"
+	default:
+		return fmt.Sprintf("Iter8 experiment %v", m)
+	}
+}
+
+// text returns the text of the event
+func (t *annotateTask) text(exp *Experiment, m milestone) string {
+	if t.With.Text != "" {
+		return t.With.Text
+	}
+	s := fmt.Sprintf("Loop %v; number of completed tasks: %v", exp.NumLoops(), exp.Result.NumCompletedTasks)
+	switch m {
+	case sloViolatedMilestone:
+		violating := []string{}
+		for j := 0; j < exp.Result.Insights.NumVersions; j++ {
+			if !exp.SLOsBy(j) {
+				violating = append(violating, fmt.Sprint(j))
+			}
+		}
+		s += fmt.Sprintf("; versions not satisfying SLOs: %v", strings.Join(violating, ", "))
+		if w := exp.Winner(); w >= 0 {
+			s += fmt.Sprintf("; winner: version %v", w)
+		}
+	case winnerMilestone:
+		s += fmt.Sprintf("; winner: version %v", exp.Winner())
+	}
+	return s
+}
+
+// tags returns the tags of the event
+func (t *annotateTask) tags(m milestone) []string {
+	return append([]string{iter8Tag, "milestone:" + string(m)}, t.With.Tags...)
+}
+
+// payload returns the body of the request to the events or annotations API
+func (t *annotateTask) payload(exp *Experiment, m milestone) map[string]interface{} {
+	if t.With.Provider == GrafanaProvider {
+		return map[string]interface{}{
+			"time": time.Now().UnixNano() / int64(time.Millisecond),
+			"tags": t.tags(m),
+			"text": t.title(m) + "\n" + t.text(exp, m),
+		}
+	}
+	alertType := "info"
+	switch m {
+	case failedMilestone:
+		alertType = "error"
+	case abortedMilestone, sloViolatedMilestone:
+		alertType = "warning"
+	case winnerMilestone:
+		alertType = "success"
+	}
+	return map[string]interface{}{
+		"title":            t.title(m),
+		"text":             t.text(exp, m),
+		"tags":             t.tags(m),
+		"alert_type":       alertType,
+		"source_type_name": iter8Tag,
+	}
+}
+
+// post sends the event to the events or annotations API
+func (t *annotateTask) post(body []byte) error {
+	timeout, err := time.ParseDuration(*t.With.Timeout)
+	if err != nil {
+		return err
+	}
+	path := datadogEventsPath
+	if t.With.Provider == GrafanaProvider {
+		path = grafanaAnnotationsPath
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(t.With.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	key, err := t.apiKey()
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		if t.With.Provider == GrafanaProvider {
+			req.Header.Set("Authorization", "Bearer "+key)
+		} else {
+			req.Header.Set("DD-API-KEY", key)
+		}
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%v returned status code %v", t.With.Provider, resp.StatusCode)
+	}
+	return nil
+}
+
+// run executes this task
+func (t *annotateTask) run(exp *Experiment) error {
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	t.initializeDefaults()
+
+	m := experimentMilestone(exp)
+	body, err := json.Marshal(t.payload(exp, m))
+	if err != nil {
+		e := errors.New("unable to marshal event")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	if err = t.post(body); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Errorf("unable to post event to %v", t.With.Provider)
+		return err
+	}
+	log.Logger.Infof("posted experiment %v event to %v", m, t.With.Provider)
+	return nil
+}
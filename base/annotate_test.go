@@ -0,0 +1,157 @@
+package base
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startFakeEventServer starts a server that records the paths, headers, and bodies of the events it receives
+func startFakeEventServer(t *testing.T) (*httptest.Server, *[]*http.Request, *[]map[string]interface{}) {
+	reqs := []*http.Request{}
+	bodies := []map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(b, &body))
+		reqs = append(reqs, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs, &bodies
+}
+
+func TestAnnotateDatadog(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv, reqs, bodies := startFakeEventServer(t)
+	os.Setenv("DD_API_KEY", "secret")
+	defer os.Unsetenv("DD_API_KEY")
+
+	annotate := func() Task {
+		return &annotateTask{
+			TaskMeta: TaskMeta{Task: StringPointer(AnnotateTaskName)},
+			With: annotateInputs{
+				Provider:  DatadogProvider,
+				URL:       srv.URL,
+				APIKeyEnv: "DD_API_KEY",
+				Tags:      []string{"service:httpbin"},
+			},
+		}
+	}
+	exp := &Experiment{
+		Spec: []Task{&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello")}}},
+		Hooks: &Hooks{
+			OnStart:   ExperimentSpec{annotate()},
+			OnSuccess: ExperimentSpec{annotate()},
+		},
+	}
+	err := RunExperiment(false, &mockDriver{exp})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(*reqs))
+	for _, r := range *reqs {
+		assert.Equal(t, datadogEventsPath, r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("DD-API-KEY"))
+	}
+	assert.Equal(t, "Iter8 experiment started", (*bodies)[0]["title"])
+	assert.Equal(t, "info", (*bodies)[0]["alert_type"])
+	assert.Equal(t, []interface{}{"iter8", "milestone:started", "service:httpbin"}, (*bodies)[0]["tags"])
+	assert.Equal(t, "Iter8 experiment completed", (*bodies)[1]["title"])
+	assert.Contains(t, (*bodies)[1]["text"], "number of completed tasks: 1")
+}
+
+func TestAnnotateGrafanaAfterFailure(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv, reqs, bodies := startFakeEventServer(t)
+	assert.NoError(t, ioutil.WriteFile("token", []byte("secret\n"), 0600))
+
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}},
+			&annotateTask{
+				TaskMeta: TaskMeta{Task: StringPointer(AnnotateTaskName)},
+				With: annotateInputs{
+					Provider:   GrafanaProvider,
+					URL:        srv.URL + "/",
+					APIKeyFile: "token",
+				},
+			},
+		},
+	}
+	err := RunExperiment(false, &mockDriver{exp})
+	assert.Error(t, err)
+
+	// the annotation is posted even though an earlier task failed
+	assert.Equal(t, 1, len(*reqs))
+	assert.Equal(t, grafanaAnnotationsPath, (*reqs)[0].URL.Path)
+	assert.Equal(t, "Bearer secret", (*reqs)[0].Header.Get("Authorization"))
+	assert.Contains(t, (*bodies)[0]["text"], "Iter8 experiment failed")
+	assert.Contains(t, (*bodies)[0]["tags"], "milestone:failed")
+	assert.NotNil(t, (*bodies)[0]["time"])
+}
+
+func TestExperimentMilestone(t *testing.T) {
+	exp := &Experiment{Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.Equal(t, startedMilestone, experimentMilestone(exp))
+
+	exp.Result.NumCompletedTasks = 1
+	assert.Equal(t, completedMilestone, experimentMilestone(exp))
+
+	exp.Result.Insights = &Insights{
+		NumVersions: 2,
+		SLOs: &SLOLimits{
+			Upper: []SLO{{Metric: "http/latency-mean", Limit: 100}},
+		},
+		SLOsSatisfied: &SLOResults{
+			Upper: [][]bool{{false, true}},
+		},
+	}
+	assert.Equal(t, sloViolatedMilestone, experimentMilestone(exp))
+	at := &annotateTask{}
+	assert.Contains(t, at.text(exp, sloViolatedMilestone), "versions not satisfying SLOs: 0; winner: version 1")
+
+	exp.Result.Insights.SLOsSatisfied.Upper = [][]bool{{true, true}}
+	assert.Equal(t, winnerMilestone, experimentMilestone(exp))
+	assert.Contains(t, at.text(exp, winnerMilestone), "winner: version 1")
+
+	exp.Result.Aborted = true
+	assert.Equal(t, abortedMilestone, experimentMilestone(exp))
+	exp.Result.Failure = true
+	assert.Equal(t, failedMilestone, experimentMilestone(exp))
+}
+
+func TestAnnotateInvalidInputs(t *testing.T) {
+	at := &annotateTask{}
+	assert.Error(t, at.validateInputs())
+
+	at.With.Provider = GrafanaProvider
+	assert.Error(t, at.validateInputs())
+
+	at.With.URL = "http://grafana:3000"
+	assert.NoError(t, at.validateInputs())
+
+	at.With.Timeout = StringPointer("soon")
+	assert.Error(t, at.validateInputs())
+
+	at = &annotateTask{With: annotateInputs{Provider: DatadogProvider}}
+	assert.NoError(t, at.validateInputs())
+	at.initializeDefaults()
+	assert.Equal(t, defaultDatadogURL, at.With.URL)
+}
+
+func TestAnnotateTaskUnmarshal(t *testing.T) {
+	s := ExperimentSpec{}
+	err := s.UnmarshalJSON([]byte(`[{"task": "annotate", "with": {"provider": "datadog", "tags": ["env:staging"]}}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(s))
+	at, ok := s[0].(*annotateTask)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"env:staging"}, at.With.Tags)
+}
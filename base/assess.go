@@ -2,6 +2,9 @@ package base
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/iter8-tools/iter8/base/log"
 )
@@ -10,6 +13,13 @@ import (
 type assessInputs struct {
 	// SLOs are the SLO limits
 	SLOs *SLOLimits `json:"SLOs,omitempty" yaml:"SLOs,omitempty"`
+	// OnMissingMetric is the treatment of SLOs whose metrics have no value for a version, unless specified by the SLO;
+	// unsatisfied, fail, or skip. Default value is unsatisfied.
+	OnMissingMetric MissingMetricPolicy `json:"onMissingMetric,omitempty" yaml:"onMissingMetric,omitempty"`
+	// Rewards are metrics that are optimized; versions that satisfy SLOs are compared by them. Optional.
+	Rewards *Rewards `json:"rewards,omitempty" yaml:"rewards,omitempty"`
+	// Weights configure the recommended traffic weights of versions, which are recorded in insights. Optional.
+	Weights *weightsInputs `json:"weights,omitempty" yaml:"weights,omitempty"`
 }
 
 // assessTask enables assessment of versions
@@ -26,11 +36,35 @@ const (
 )
 
 // initializeDefaults sets default values for task inputs
-func (t *assessTask) initializeDefaults() {}
+func (t *assessTask) initializeDefaults() {
+	if t.With.OnMissingMetric == "" {
+		t.With.OnMissingMetric = MissingMetricUnsatisfied
+	}
+}
 
 //validateInputs for this task
 func (t *assessTask) validateInputs() error {
-	return nil
+	if err := validateMissingMetricPolicy(t.With.OnMissingMetric); err != nil {
+		return err
+	}
+	if t.With.SLOs != nil {
+		for _, slo := range append(append([]SLO{}, t.With.SLOs.Upper...), t.With.SLOs.Lower...) {
+			if err := validateMissingMetricPolicy(slo.OnMissing); err != nil {
+				return fmt.Errorf("SLO for metric %v: %v", slo.Metric, err)
+			}
+		}
+	}
+	return validateWeightsInputs(t.With.Weights)
+}
+
+// validateMissingMetricPolicy returns an error if the treatment of missing metrics is not supported
+func validateMissingMetricPolicy(p MissingMetricPolicy) error {
+	switch p {
+	case "", MissingMetricUnsatisfied, MissingMetricFail, MissingMetricSkip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported treatment of missing metrics %v; must be %v, %v, or %v", p, MissingMetricUnsatisfied, MissingMetricFail, MissingMetricSkip)
+	}
 }
 
 // Run executes the assess-app-versions task
@@ -46,14 +80,22 @@ func (t *assessTask) run(exp *Experiment) error {
 		log.Logger.Error("uninitialized insights within experiment")
 		return errors.New("uninitialized insights within experiment")
 	}
-	if t.With.SLOs == nil ||
-		exp.Result.Insights.NumVersions == 0 {
-		// do nothing for now
-		// todo: fix when rewards are introduced
-
+	if exp.Result.Insights.NumVersions == 0 ||
+		(t.With.SLOs == nil && t.With.Rewards == nil && t.With.Weights == nil) {
 		log.Logger.Warn("nothing to do; returning")
 		return nil
 	}
+	if t.With.Rewards != nil {
+		exp.Result.Insights.Rewards = t.With.Rewards
+	}
+	if t.With.SLOs == nil {
+		return t.recommend(exp)
+	}
+
+	// explain SLOs whose metrics are not collected, which would otherwise be unsatisfied without explanation
+	for _, msg := range checkSLOMetrics(exp.Result.Insights, t.With.SLOs) {
+		log.Logger.Warn(msg)
+	}
 
 	// set SLOs (if needed)
 	err = exp.Result.Insights.setSLOs(t.With.SLOs)
@@ -67,47 +109,123 @@ func (t *assessTask) run(exp *Experiment) error {
 		return err
 	}
 
-	// set SLOsSatisfied
+	// set SLOsSatisfied, and record SLOs whose metrics are missing
+	missing := []MissingMetric{}
 	exp.Result.Insights.SLOsSatisfied = &SLOResults{
-		Upper: evaluateSLOs(exp, t.With.SLOs.Upper, true),
-		Lower: evaluateSLOs(exp, t.With.SLOs.Lower, false),
+		Upper: t.evaluateSLOs(exp, t.With.SLOs.Upper, true, &missing),
+		Lower: t.evaluateSLOs(exp, t.With.SLOs.Lower, false, &missing),
+	}
+	exp.Result.Insights.MissingMetrics = nil
+	if len(missing) > 0 {
+		exp.Result.Insights.MissingMetrics = missing
 	}
+	for _, m := range missing {
+		if m.Treatment == MissingMetricFail {
+			e := fmt.Errorf("unable to find value for version %v and metric %s", m.Version, m.Metric)
+			log.Logger.Error(e)
+			return e
+		}
+	}
+
+	return t.recommend(exp)
+}
+
+// recommend records the recommended traffic weights of versions, if they are configured
+func (t *assessTask) recommend(exp *Experiment) error {
+	if t.With.Weights == nil {
+		return nil
+	}
+	return t.recordWeights(exp)
+}
 
-	return err
+// checkSLOMetrics returns a message for each SLO whose metric is not collected by the tasks of the experiment,
+// suggesting the collected metric with the closest name, if any
+func checkSLOMetrics(in *Insights, slos *SLOLimits) []string {
+	known := []string{}
+	for m := range in.MetricsInfo {
+		known = append(known, m)
+	}
+	sort.Strings(known)
+
+	msgs := []string{}
+	for _, slo := range append(append([]SLO{}, slos.Upper...), slos.Lower...) {
+		s := strings.Split(slo.Metric, "/")
+		if len(s) != 2 && len(s) != 3 {
+			msgs = append(msgs, fmt.Sprintf("invalid SLO metric %v; metric names must be of the form a/b or a/b/c", slo.Metric))
+			continue
+		}
+		// aggregated metrics are checked using the name of the vector metric
+		m, suffix := slo.Metric, ""
+		if len(s) == 3 {
+			m, suffix = s[0]+"/"+s[1], "/"+s[2]
+		}
+		nm, err := NormalizeMetricName(m)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("invalid SLO metric %v", slo.Metric))
+			continue
+		}
+		if _, ok := in.MetricsInfo[nm]; ok {
+			continue
+		}
+		msg := fmt.Sprintf("SLO metric %v is not collected by the tasks of the experiment", slo.Metric)
+		if c := ClosestMatch(nm, known); c != "" {
+			msg += fmt.Sprintf("; did you mean %v?", c+suffix)
+		} else if len(known) > 0 {
+			msg += fmt.Sprintf("; collected metrics are %v", strings.Join(known, ", "))
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
 }
 
-// evaluate SLOs and output the boolean SLO X version matrix
-func evaluateSLOs(exp *Experiment, slos []SLO, upper bool) [][]bool {
+// evaluate SLOs and output the boolean SLO X version matrix;
+// SLOs whose metrics are missing are treated as configured, and appended to missing
+func (t *assessTask) evaluateSLOs(exp *Experiment, slos []SLO, upper bool, missing *[]MissingMetric) [][]bool {
 	slosSatisfied := make([][]bool, len(slos))
 	for i := 0; i < len(slos); i++ {
 		slosSatisfied[i] = make([]bool, exp.Result.Insights.NumVersions)
 		for j := 0; j < exp.Result.Insights.NumVersions; j++ {
-			slosSatisfied[i][j] = sloSatisfied(exp, slos, i, j, upper)
+			satisfied, ok := sloSatisfied(exp, slos, i, j, upper)
+			if !ok {
+				treatment := slos[i].OnMissing
+				if treatment == "" {
+					treatment = t.With.OnMissingMetric
+				}
+				log.Logger.Warnf("unable to find value for version %v and metric %s; treating SLO as %v", j, slos[i].Metric, treatment)
+				*missing = append(*missing, MissingMetric{
+					Metric:    slos[i].Metric,
+					Version:   j,
+					Treatment: treatment,
+				})
+				// skipped SLOs do not prevent the version from satisfying SLOs
+				satisfied = treatment == MissingMetricSkip
+			}
+			slosSatisfied[i][j] = satisfied
 		}
 	}
 	return slosSatisfied
 }
 
-// sloSatisfied returns true if SLO i satisfied by version j
-func sloSatisfied(e *Experiment, slos []SLO, i int, j int, upper bool) bool {
+// sloSatisfied returns true if SLO i satisfied by version j;
+// the second return value is false if the metric has no value for the version
+func sloSatisfied(e *Experiment, slos []SLO, i int, j int, upper bool) (bool, bool) {
 	val := e.Result.Insights.ScalarMetricValue(j, slos[i].Metric)
 	// check if metric is available
 	if val == nil {
-		log.Logger.Warnf("unable to find value for version %v and metric %s", j, slos[i].Metric)
-		return false
+		return false, false
 	}
 
 	if upper {
 		// check upper limit
 		if *val > slos[i].Limit {
-			return false
+			return false, true
 		}
 	} else {
 		// check lower limit
 		if *val < slos[i].Limit {
-			return false
+			return false, true
 		}
 	}
 
-	return true
+	return true, true
 }
@@ -39,3 +39,80 @@ func TestRunAssess(t *testing.T) {
 	err = task.run(exp)
 	assert.NoError(t, err)
 }
+
+func TestCheckSLOMetrics(t *testing.T) {
+	in := &Insights{
+		MetricsInfo: map[string]MetricMeta{
+			"http/latency-p99":   {Type: GaugeMetricType},
+			"http/error-rate":    {Type: GaugeMetricType},
+			"custom/latency":     {Type: HistogramMetricType},
+			"http/request-count": {Type: CounterMetricType},
+		},
+	}
+	msgs := checkSLOMetrics(in, &SLOLimits{
+		Upper: []SLO{
+			{Metric: "http/latency-p99.0", Limit: 100},
+			{Metric: "http/latency-p9", Limit: 100},
+			{Metric: "http/error_rate", Limit: 0},
+			{Metric: "custom/latncy/mean", Limit: 50},
+			{Metric: "custom/latency/mean", Limit: 50},
+		},
+		Lower: []SLO{
+			{Metric: "other/throughput", Limit: 10},
+			{Metric: "throughput", Limit: 10},
+		},
+	})
+	assert.Equal(t, []string{
+		"SLO metric http/latency-p9 is not collected by the tasks of the experiment; did you mean http/latency-p99?",
+		"SLO metric http/error_rate is not collected by the tasks of the experiment; did you mean http/error-rate?",
+		"SLO metric custom/latncy/mean is not collected by the tasks of the experiment; did you mean custom/latency/mean?",
+		"SLO metric other/throughput is not collected by the tasks of the experiment; collected metrics are custom/latency, http/error-rate, http/latency-p99, http/request-count",
+		"invalid SLO metric throughput; metric names must be of the form a/b or a/b/c",
+	}, msgs)
+}
+
+func TestAssessMissingMetrics(t *testing.T) {
+	os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			SLOs: &SLOLimits{
+				Upper: []SLO{
+					{Metric: "a/b", Limit: 20.0},
+					{Metric: "a/c", Limit: 20.0, OnMissing: MissingMetricSkip},
+				},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	exp.Result.initInsightsWithNumVersions(1)
+
+	// missing metrics make SLOs unsatisfied by default, unless they are skipped
+	assert.NoError(t, task.run(exp))
+	assert.Equal(t, [][]bool{{false}, {true}}, exp.Result.Insights.SLOsSatisfied.Upper)
+	assert.Equal(t, []MissingMetric{
+		{Metric: "a/b", Version: 0, Treatment: MissingMetricUnsatisfied},
+		{Metric: "a/c", Version: 0, Treatment: MissingMetricSkip},
+	}, exp.Result.Insights.MissingMetrics)
+
+	// missing metrics can fail the task
+	task.With.OnMissingMetric = MissingMetricFail
+	assert.Error(t, task.run(exp))
+	assert.Equal(t, MissingMetricFail, exp.Result.Insights.MissingMetrics[0].Treatment)
+
+	// missing metrics are no longer recorded once they have values
+	exp.Result.Insights.MetricsInfo = map[string]MetricMeta{"a/b": {Type: GaugeMetricType}}
+	exp.Result.Insights.NonHistMetricValues[0]["a/b"] = []float64{10}
+	assert.NoError(t, task.run(exp))
+	assert.Equal(t, [][]bool{{true}, {true}}, exp.Result.Insights.SLOsSatisfied.Upper)
+	assert.Len(t, exp.Result.Insights.MissingMetrics, 1)
+
+	// unsupported treatments are rejected
+	task.With.OnMissingMetric = "ignore"
+	assert.Error(t, task.run(exp))
+}
@@ -0,0 +1,245 @@
+// Package aws finds AWS credentials and signs requests to AWS services with Signature Version 4.
+// It is used by the S3 object store of experiments, and by tasks that test AWS Lambda functions.
+package aws
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRegion is the AWS region used when none is configured
+	DefaultRegion = "us-east-1"
+	// TimeFormat is the format of timestamps in AWS signatures
+	TimeFormat = "20060102T150405Z"
+	// dateFormat is the format of dates in AWS credential scopes
+	dateFormat = "20060102"
+)
+
+// Credentials are the credentials used to sign AWS requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Expiration is the time when temporary credentials expire; zero for long-term credentials
+	Expiration time.Time
+}
+
+// Expired returns true if temporary credentials expire within a minute
+func (c *Credentials) Expired() bool {
+	return !c.Expiration.IsZero() && time.Now().Add(time.Minute).After(c.Expiration)
+}
+
+// Region returns the AWS region configured in the environment
+func Region() string {
+	for _, v := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if r := os.Getenv(v); r != "" {
+			return r
+		}
+	}
+	return DefaultRegion
+}
+
+// Endpoint returns the endpoint of the AWS service configured in the environment, if any
+func Endpoint(service string) string {
+	if e := os.Getenv("AWS_ENDPOINT_URL_" + strings.ToUpper(service)); e != "" {
+		return strings.TrimSuffix(e, "/")
+	}
+	return strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/")
+}
+
+// GetCredentials returns credentials from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables,
+// or, with IAM roles for service accounts (IRSA), by exchanging the web identity token for temporary credentials
+func GetCredentials(client *http.Client, region string) (*Credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return &Credentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+	tokenFile, role := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN")
+	if tokenFile == "" || role == "" {
+		return nil, errors.New("no AWS credentials; set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN")
+	}
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read web identity token: %v", err)
+	}
+	return assumeRoleWithWebIdentity(client, region, role, strings.TrimSpace(string(token)))
+}
+
+// assumeRoleWithWebIdentityResponse is the response of the STS AssumeRoleWithWebIdentity action
+type assumeRoleWithWebIdentityResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// assumeRoleWithWebIdentity exchanges a web identity token for temporary credentials of the role
+func assumeRoleWithWebIdentity(client *http.Client, region string, role string, token string) (*Credentials, error) {
+	endpoint := Endpoint("sts")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%v.amazonaws.com", region)
+	}
+	session := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "iter8"
+	}
+	q := url.Values{}
+	q.Set("Action", "AssumeRoleWithWebIdentity")
+	q.Set("Version", "2011-06-15")
+	q.Set("RoleArn", role)
+	q.Set("RoleSessionName", session)
+	q.Set("WebIdentityToken", token)
+	req, err := http.NewRequest(http.MethodPost, endpoint+"/", strings.NewReader(q.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to assume role %v: %v", role, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("unable to assume role %v: %v", role, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unable to assume role %v: STS returned status %v", role, resp.StatusCode)
+	}
+	r := assumeRoleWithWebIdentityResponse{}
+	if err := xml.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("unable to parse credentials of role %v: %v", role, err)
+	}
+	return &Credentials{
+		AccessKeyID:     r.Credentials.AccessKeyID,
+		SecretAccessKey: r.Credentials.SecretAccessKey,
+		SessionToken:    r.Credentials.SessionToken,
+		Expiration:      r.Credentials.Expiration,
+	}, nil
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data using the key
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sha256Hex returns the hex encoded SHA256 hash of the data
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// URIEncode encodes the string as required by AWS signatures; slashes are preserved if encodeSlash is false
+func URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// SignV4 signs the request to the AWS service using AWS Signature Version 4
+func SignV4(req *http.Request, payload []byte, creds *Credentials, region string, service string, t time.Time) {
+	amzDate := t.UTC().Format(TimeFormat)
+	date := t.UTC().Format(dateFormat)
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	// canonical headers include the host, the content type, and all x-amz-* headers
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "content-type" {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := []string{}
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// canonical query string sorts parameters by name
+	query := req.URL.Query()
+	keys := []string{}
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := []string{}
+	for _, k := range keys {
+		vs := query[k]
+		sort.Strings(vs)
+		for _, v := range vs {
+			params = append(params, URIEncode(k, true)+"="+URIEncode(v, true))
+		}
+	}
+
+	uri := req.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	if service == "s3" {
+		// S3 object keys are encoded once
+		uri = URIEncode(req.URL.Path, false)
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uri,
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
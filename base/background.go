@@ -0,0 +1,191 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// backgroundStopTimeout is the time that background tasks are given to exit after they are asked to stop
+	backgroundStopTimeout = 5 * time.Second
+	// backgroundOutputLimit is the number of bytes of the latest output of a background task that are retained for logging
+	backgroundOutputLimit = 64 * 1024
+)
+
+// BackgroundTask is a shell script that runs in the background while the experiment runs;
+// for example, a port-forward, a metrics poller, or a resource watcher.
+// Background tasks start before the first task of the experiment, and are stopped after the experiment ends,
+// whether it completes, fails, or is aborted.
+type BackgroundTask struct {
+	// Name of the background task
+	Name string `json:"name" yaml:"name"`
+	// Run is the script run by the background task
+	Run string `json:"run" yaml:"run"`
+	// Shell that runs the script; bash, sh, powershell, pwsh, or cmd. Optional.
+	// If unspecified it will be defaulted to bash, or powershell on Windows.
+	Shell string `json:"shell,omitempty" yaml:"shell,omitempty"`
+	// Delay is the time to wait after starting the background task before the experiment continues; for example, 5s. Optional.
+	Delay *string `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+// validate validates the background task
+func (b *BackgroundTask) validate() error {
+	if b.Name == "" {
+		return errors.New("background task requires a name")
+	}
+	if b.Run == "" {
+		return fmt.Errorf("background task %v requires a run script", b.Name)
+	}
+	if err := validateShell(b.Shell); err != nil {
+		return fmt.Errorf("background task %v: %v", b.Name, err)
+	}
+	if b.Delay != nil {
+		if _, err := time.ParseDuration(*b.Delay); err != nil {
+			return fmt.Errorf("background task %v has invalid delay %v", b.Name, *b.Delay)
+		}
+	}
+	return nil
+}
+
+// backgroundProcess is a started background task
+type backgroundProcess struct {
+	// name of the background task
+	name string
+	// cmd is the command running the script
+	cmd *exec.Cmd
+	// out is the latest combined output of the command
+	out *tailBuffer
+	// done is closed when the command exits
+	done chan struct{}
+	// stopping is closed when the command is asked to stop
+	stopping chan struct{}
+}
+
+// backgroundProcesses are the started background tasks of an experiment
+type backgroundProcesses []*backgroundProcess
+
+// startBackground starts the background tasks of the experiment.
+// If a background task cannot be started, those already started are stopped.
+func (exp *Experiment) startBackground() (backgroundProcesses, error) {
+	var bps backgroundProcesses
+	for i := range exp.Background {
+		b := &exp.Background[i]
+		if err := b.validate(); err != nil {
+			log.Logger.Error(err)
+			bps.stop()
+			return nil, err
+		}
+		bp, err := b.start()
+		if err != nil {
+			bps.stop()
+			return nil, err
+		}
+		bps = append(bps, bp)
+		if b.Delay != nil {
+			d, _ := time.ParseDuration(*b.Delay)
+			time.Sleep(d)
+		}
+	}
+	return bps, nil
+}
+
+// start starts the background task
+func (b *BackgroundTask) start() (*backgroundProcess, error) {
+	cmd := shellCommand(b.Shell, b.Run)
+	// append the environment variable for temp dir
+	cmd.Env = append(os.Environ(), tempDirEnv)
+	// background tasks run in their own process group, so that processes they start are also stopped
+	setProcessGroup(cmd)
+	out := &tailBuffer{limit: backgroundOutputLimit}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Start(); err != nil {
+		e := fmt.Errorf("unable to start background task %v", b.Name)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	log.Logger.Infof("background task %v: started", b.Name)
+
+	bp := &backgroundProcess{
+		name:     b.Name,
+		cmd:      cmd,
+		out:      out,
+		done:     make(chan struct{}),
+		stopping: make(chan struct{}),
+	}
+	go func() {
+		err := cmd.Wait()
+		close(bp.done)
+		select {
+		case <-bp.stopping:
+		default:
+			log.Logger.WithStackTrace(out.String()).Warnf("background task %v: exited before the experiment ended: %v", bp.name, err)
+		}
+	}()
+	return bp, nil
+}
+
+// stop stops the background tasks in the reverse order in which they were started
+func (bps backgroundProcesses) stop() {
+	for i := len(bps) - 1; i >= 0; i-- {
+		bps[i].stop()
+	}
+}
+
+// stop asks the background task to exit, and kills it if it does not exit in time
+func (bp *backgroundProcess) stop() {
+	close(bp.stopping)
+	select {
+	case <-bp.done:
+	default:
+		terminateProcessGroup(bp.cmd)
+		select {
+		case <-bp.done:
+		case <-time.After(backgroundStopTimeout):
+			killProcessGroup(bp.cmd)
+			<-bp.done
+		}
+	}
+	log.Logger.WithStackTrace(bp.out.String()).Infof("background task %v: stopped", bp.name)
+}
+
+// tailBuffer is a writer that retains only the latest bytes written to it,
+// so that long running background tasks do not accumulate their output in memory
+type tailBuffer struct {
+	// limit is the maximum number of bytes retained
+	limit int
+	// buf holds the retained bytes
+	buf []byte
+	// dropped is the number of earlier bytes that are no longer retained
+	dropped int
+	// mu protects the buffer
+	mu sync.Mutex
+}
+
+// Write retains the latest bytes of p, dropping earlier bytes beyond the limit
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.limit; over > 0 {
+		t.dropped += over
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the retained bytes, noting the number of bytes that were dropped
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dropped > 0 {
+		return fmt.Sprintf("(%v bytes of earlier output omitted)\n%s", t.dropped, t.buf)
+	}
+	return string(t.buf)
+}
@@ -0,0 +1,81 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunExperimentBackground(t *testing.T) {
+	os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Background: []BackgroundTask{{
+			Name:  "ticker",
+			Run:   "trap 'echo stopped >> ticks.txt; exit 0' TERM; while true; do echo tick >> ticks.txt; sleep 0.1; done",
+			Delay: StringPointer("200ms"),
+		}},
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("grep tick ticks.txt")}},
+		},
+	}
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.True(t, exp.Completed())
+
+	// the background task is stopped when the experiment ends
+	b, err := ioutil.ReadFile("ticks.txt")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(b), "stopped\n"))
+	time.Sleep(300 * time.Millisecond)
+	c, _ := ioutil.ReadFile("ticks.txt")
+	assert.Equal(t, string(b), string(c))
+}
+
+func TestRunExperimentBackgroundFailure(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// background tasks are stopped when a task fails
+	exp := &Experiment{
+		Background: []BackgroundTask{{Name: "sleeper", Run: "sleep 60"}},
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("false")}},
+		},
+	}
+	start := time.Now()
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+	assert.Less(t, time.Since(start).Seconds(), 10.0)
+
+	// invalid background tasks fail the experiment before tasks run
+	exp = &Experiment{
+		Background: []BackgroundTask{{Name: "invalid"}},
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("touch ran")}},
+		},
+	}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+	assert.False(t, exp.NoFailure())
+	assert.NoFileExists(t, "ran")
+}
+
+func TestBackgroundTaskValidate(t *testing.T) {
+	assert.NoError(t, (&BackgroundTask{Name: "pf", Run: "kubectl port-forward svc/httpbin 8080:80"}).validate())
+	assert.Error(t, (&BackgroundTask{Run: "sleep 1"}).validate())
+	assert.Error(t, (&BackgroundTask{Name: "pf"}).validate())
+	assert.Error(t, (&BackgroundTask{Name: "pf", Run: "sleep 1", Delay: StringPointer("soon")}).validate())
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{limit: 8}
+	n, err := b.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "abc", b.String())
+
+	// only the latest bytes are retained
+	b.Write([]byte("defghij"))
+	assert.Equal(t, "(2 bytes of earlier output omitted)\ncdefghij", b.String())
+	b.Write([]byte(strings.Repeat("x", 20) + "yz"))
+	assert.Equal(t, "(24 bytes of earlier output omitted)\nxxxxxxyz", b.String())
+}
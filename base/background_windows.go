@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package base
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing on Windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills the command, since Windows processes cannot be asked to exit
+func terminateProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
+
+// killProcessGroup kills the command
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
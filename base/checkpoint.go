@@ -0,0 +1,166 @@
+package base
+
+import (
+	"time"
+
+	"fortio.org/fortio/stats"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// TaskCheckpoint records the progress of a load test that has not completed, such as one interrupted when its pod was preempted.
+// A resumed run of the experiment continues the load test from the checkpoint, instead of repeating the requests it has sent,
+// and combines the metrics of both runs.
+type TaskCheckpoint struct {
+	// Task is the index of the task in the experiment spec
+	Task int `json:"task" yaml:"task"`
+	// Elapsed is the duration for which the load test has run; for example, 1m30s
+	Elapsed string `json:"elapsed" yaml:"elapsed"`
+	// Requests is the number of requests sent so far
+	Requests int64 `json:"requests" yaml:"requests"`
+	// Errors is the number of responses so far that were errors
+	Errors int64 `json:"errors" yaml:"errors"`
+	// Latency is the histogram of latencies so far, in msec
+	Latency []HistBucket `json:"latency,omitempty" yaml:"latency,omitempty"`
+	// RecordedBuckets is the number of buckets of the latency histogram metric that were recorded in insights by an interrupted run;
+	// a resumed run replaces them with the buckets of the combined runs
+	RecordedBuckets int `json:"recordedBuckets,omitempty" yaml:"recordedBuckets,omitempty"`
+	// Time when the checkpoint was recorded
+	Time time.Time `json:"time" yaml:"time"`
+}
+
+// elapsed returns the duration for which the load test has run; zero for a nil checkpoint
+func (c *TaskCheckpoint) elapsed() time.Duration {
+	if c == nil {
+		return 0
+	}
+	d, _ := time.ParseDuration(c.Elapsed)
+	return d
+}
+
+// histogram returns the histogram of latencies so far, in seconds; empty for a nil checkpoint.
+// Latencies are approximated by the midpoints of their buckets.
+func (c *TaskCheckpoint) histogram() *stats.Histogram {
+	h := stats.NewHistogram(0, 0.001)
+	if c == nil || len(c.Latency) == 0 {
+		return h
+	}
+	for _, b := range c.Latency {
+		if b.Count > 0 {
+			h.RecordN((b.Lower+b.Upper)/2000.0, int(b.Count))
+		}
+	}
+	// the first and last buckets start and end at the extreme latencies
+	h.Min = c.Latency[0].Lower / 1000.0
+	h.Max = c.Latency[len(c.Latency)-1].Upper / 1000.0
+	return h
+}
+
+// merge returns the checkpoint of the task after further progress, given by the number of errors, the histogram of latencies
+// in seconds, and the duration of the latest run of the load test; a nil checkpoint merges with no earlier progress
+func (c *TaskCheckpoint) merge(task int, errors int64, hist *stats.Histogram, elapsed time.Duration) *TaskCheckpoint {
+	h := stats.Merge(c.histogram(), hist)
+	next := &TaskCheckpoint{
+		Task:     task,
+		Elapsed:  (c.elapsed() + elapsed).Round(time.Millisecond).String(),
+		Requests: h.Count,
+		Errors:   errors,
+		Time:     time.Now().UTC(),
+	}
+	if c != nil {
+		next.Errors += c.Errors
+		next.RecordedBuckets = c.RecordedBuckets
+	}
+	if h.Count > 0 {
+		next.Latency = latencyHist(h.Export())
+	}
+	return next
+}
+
+// trimHistMetric removes the last n buckets of the histogram metric of the version
+func (in *Insights) trimHistMetric(m string, i int, n int) {
+	if i >= len(in.HistMetricValues) {
+		return
+	}
+	if b := in.HistMetricValues[i][m]; len(b) >= n {
+		in.HistMetricValues[i][m] = b[:len(b)-n]
+	}
+}
+
+// taskIndex returns the index of the task in the experiment spec, or -1 if it is not one of the tasks of the experiment, such as a hook.
+// Tasks in parallel branches are not tasks of the experiment; the spec of a branch is not the spec of the stored experiment.
+func (exp *Experiment) taskIndex(t Task) int {
+	if exp.branch > 0 {
+		return -1
+	}
+	for i := range exp.Spec {
+		if exp.Spec[i] == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkpointOf returns the checkpoint of the task, or nil if the task has no checkpoint
+func (exp *Experiment) checkpointOf(t Task) *TaskCheckpoint {
+	if exp.Result == nil || exp.Result.Checkpoint == nil {
+		return nil
+	}
+	if i := exp.taskIndex(t); i < 0 || i != exp.Result.Checkpoint.Task {
+		return nil
+	}
+	return exp.Result.Checkpoint
+}
+
+// checkpointOpts configure the periodic checkpointing of a load test
+type checkpointOpts struct {
+	// interval at which checkpoints are written
+	interval time.Duration
+	// isError returns true if a status code is an error
+	isError func(code int) bool
+	// exp is the running experiment
+	exp *Experiment
+	// task is the index of the task
+	task int
+	// prior is the checkpoint of an earlier run of the task; nil if the load test started from the beginning
+	prior *TaskCheckpoint
+}
+
+// newCheckpointOpts returns options for checkpointing the task at the given interval;
+// nil is returned if the interval is not specified, or if the task is not one of the tasks of the experiment,
+// such as a task in a parallel branch
+func newCheckpointOpts(interval *string, isError func(code int) bool, exp *Experiment, t Task) (*checkpointOpts, error) {
+	if interval == nil {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(*interval)
+	if err != nil {
+		return nil, err
+	}
+	i := exp.taskIndex(t)
+	if i < 0 {
+		if exp.branch > 0 {
+			log.Logger.Warnf("load tests in parallel branches are not checkpointed; ignoring checkpoint interval %v", *interval)
+		}
+		return nil, nil
+	}
+	return &checkpointOpts{
+		interval: d,
+		isError:  isError,
+		exp:      exp,
+		task:     i,
+		prior:    exp.checkpointOf(t),
+	}, nil
+}
+
+// save records the progress of the load test in the result of the experiment, and writes it using the driver of the experiment
+func (o *checkpointOpts) save(errors int64, hist *stats.Histogram, elapsed time.Duration) {
+	o.exp.Result.Checkpoint = o.prior.merge(o.task, errors, hist, elapsed)
+	if o.exp.driver == nil {
+		return
+	}
+	if err := o.exp.driver.Write(o.exp); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warn("unable to write checkpoint")
+		return
+	}
+	log.Logger.Debugf("checkpointed task %v after %v requests", o.task+1, o.exp.Result.Checkpoint.Requests)
+}
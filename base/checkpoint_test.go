@@ -0,0 +1,181 @@
+package base
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fortio.org/fortio/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+// checkpointDriver is a mock driver that counts the checkpoints it writes, and records the number of tasks in the experiments it writes
+type checkpointDriver struct {
+	mockDriver
+	checkpoints int
+	numTasks    []int
+}
+
+// Write an experiment
+func (d *checkpointDriver) Write(e *Experiment) error {
+	if e.Result != nil && e.Result.Checkpoint != nil {
+		d.checkpoints++
+	}
+	d.numTasks = append(d.numTasks, len(e.Spec))
+	return d.mockDriver.Write(e)
+}
+
+func TestCheckpointMerge(t *testing.T) {
+	h := stats.NewHistogram(0, 0.001)
+	for i := 1; i <= 10; i++ {
+		h.Record(0.01 * float64(i))
+	}
+	var c *TaskCheckpoint
+	c = c.merge(2, 3, h.Clone(), 5*time.Second)
+	assert.Equal(t, 2, c.Task)
+	assert.Equal(t, int64(10), c.Requests)
+	assert.Equal(t, int64(3), c.Errors)
+	assert.Equal(t, 5*time.Second, c.elapsed())
+	assert.InDelta(t, 0.01, c.histogram().Min, 0.0001)
+	assert.InDelta(t, 0.1, c.histogram().Max, 0.0001)
+
+	c.RecordedBuckets = len(c.Latency)
+	c = c.merge(2, 1, h.Clone(), 2*time.Second)
+	assert.Equal(t, int64(20), c.Requests)
+	assert.Equal(t, int64(4), c.Errors)
+	assert.Equal(t, 7*time.Second, c.elapsed())
+	assert.NotZero(t, c.RecordedBuckets)
+	p50 := c.histogram().Export().CalcPercentile(50)
+	assert.InDelta(t, 0.05, p50, 0.01)
+}
+
+func TestResumeHTTPFromCheckpoint(t *testing.T) {
+	os.Chdir(t.TempDir())
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			URL:         srv.URL,
+			NumRequests: int64Pointer(40),
+			QPS:         float32Pointer(20),
+			Connections: intPointer(1),
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{ct},
+	}
+	exp.initResults(1)
+	d := &mockDriver{exp}
+
+	// the load test is checkpointed when it is interrupted
+	interrupt := make(chan struct{})
+	exp.interrupt = interrupt
+	time.AfterFunc(500*time.Millisecond, func() { close(interrupt) })
+	assert.ErrorIs(t, exp.run(d, 0), errInterrupted)
+	cp := exp.Result.Checkpoint
+	assert.NotNil(t, cp)
+	assert.Equal(t, 0, cp.Task)
+	assert.Greater(t, cp.Requests, int64(0))
+	assert.Less(t, cp.Requests, int64(40))
+	assert.Equal(t, len(cp.Latency), cp.RecordedBuckets)
+
+	// the resumed load test sends the remaining requests
+	assert.NoError(t, ResumeExperiment(d))
+	assert.True(t, exp.Completed())
+	assert.Nil(t, exp.Result.Checkpoint)
+	assert.Equal(t, int64(40), atomic.LoadInt64(&requests))
+	count := exp.Result.Insights.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountId)
+	assert.Equal(t, 40.0, *count)
+	total := uint64(0)
+	for _, b := range exp.Result.Insights.HistMetricValues[0][httpMetricPrefix+"/"+builtInHTTPLatencyHistId] {
+		total += b.Count
+	}
+	assert.Equal(t, uint64(40), total)
+}
+
+func TestPeriodicCheckpoint(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			URL:                srv.URL,
+			Duration:           StringPointer("1s"),
+			CheckpointInterval: StringPointer("200ms"),
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{ct},
+	}
+	exp.initResults(1)
+	d := &checkpointDriver{mockDriver: mockDriver{exp}}
+	assert.NoError(t, RunExperiment(true, d))
+	assert.GreaterOrEqual(t, d.checkpoints, 3)
+	// the checkpoint is cleared when the task completes
+	assert.Nil(t, exp.Result.Checkpoint)
+
+	ct.With.CheckpointInterval = StringPointer("0s")
+	assert.Error(t, ct.validateInputs())
+}
+
+func TestNoCheckpointInParallelBranch(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			URL:                srv.URL,
+			Duration:           StringPointer("1s"),
+			CheckpointInterval: StringPointer("200ms"),
+		},
+	}
+	pt := &parallelTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(ParallelTaskName),
+		},
+		With: parallelInputs{
+			Branches: []ExperimentSpec{{ct}},
+		},
+	}
+	rt := &runTask{
+		TaskMeta: TaskMeta{
+			Run: StringPointer("echo hello"),
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{pt, rt},
+	}
+	exp.initResults(1)
+	d := &checkpointDriver{mockDriver: mockDriver{exp}}
+	assert.NoError(t, RunExperiment(true, d))
+	assert.True(t, exp.Completed())
+
+	// only the experiment is written, and the load test in the branch is not checkpointed
+	assert.Zero(t, d.checkpoints)
+	for _, n := range d.numTasks {
+		assert.Equal(t, 2, n)
+	}
+}
@@ -2,6 +2,8 @@ package base
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/bojand/ghz/runner"
@@ -28,18 +30,36 @@ const (
 	insecureDefault = true
 )
 
+// collectGRPCInputs are the inputs of the grpc task
+type collectGRPCInputs struct {
+	// Config is the ghz configuration of the load test
+	runner.Config
+	// Versions are the versions of the app that are load tested one after another, for A/B/n experiments; the first is the baseline.
+	// Each version is load tested with the inputs of this task, using the host and metadata of the version. Optional.
+	// If unspecified, the app at host is the only version.
+	Versions []grpcVersion `json:"versions,omitempty" yaml:"versions,omitempty"`
+}
+
+// grpcVersion is a version of the app that is load tested by the grpc task
+type grpcVersion struct {
+	// Host of the version; for example, hello-v2.default:50051
+	Host string `json:"host" yaml:"host"`
+	// Metadata is added to the metadata of the task in calls to the version. Optional.
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
 // collectGRPCTask enables load testing of gRPC services.
 type collectGRPCTask struct {
 	// TaskMeta has fields common to all tasks
 	TaskMeta
 	// With contains the inputs to this task
-	With runner.Config `json:"with" yaml:"with"`
+	With collectGRPCInputs `json:"with" yaml:"with"`
 }
 
 // initializeDefaults sets default values for the collect task
 func (t *collectGRPCTask) initializeDefaults() {
 	// set defaults
-	gd.SetDefaults(&t.With)
+	gd.SetDefaults(&t.With.Config)
 	// if dial timeout is zero, then set a default...
 	if t.With.DialTimeout == 0 {
 		td, _ := time.ParseDuration("10s")
@@ -54,17 +74,72 @@ func (t *collectGRPCTask) initializeDefaults() {
 
 // validate task inputs
 func (t *collectGRPCTask) validateInputs() error {
+	if t.With.Host == "" && len(t.With.Versions) == 0 {
+		return errors.New("grpc task requires a host")
+	}
+	for i, v := range t.With.Versions {
+		if v.Host == "" {
+			return fmt.Errorf("grpc task requires a host for version %v", i)
+		}
+	}
+	if t.With.Call == "" {
+		return errors.New("grpc task requires a call")
+	}
 	return nil
 }
 
+// localFileInputs returns the names of the inputs of the task that name local files
+func (t *collectGRPCTask) localFileInputs() []string {
+	inputs := []string{}
+	for name, v := range map[string]string{
+		"proto":         t.With.Proto,
+		"protoset":      t.With.Protoset,
+		"cacert":        t.With.RootCert,
+		"cert":          t.With.Cert,
+		"key":           t.With.Key,
+		"data-file":     t.With.DataPath,
+		"binary-file":   t.With.BinDataPath,
+		"metadata-file": t.With.MetadataPath,
+	} {
+		if v != "" {
+			inputs = append(inputs, name)
+		}
+	}
+	if len(t.With.ImportPaths) > 0 {
+		inputs = append(inputs, "import-paths")
+	}
+	sort.Strings(inputs)
+	return inputs
+}
+
 // resultForVersion collects gRPC test result for a given version
-func (t *collectGRPCTask) resultForVersion() (*runner.Report, error) {
+func (t *collectGRPCTask) resultForVersion(exp *Experiment) (*runner.Report, error) {
 	// the main idea is to run ghz with proper options
 
-	opts := runner.WithConfig(&t.With)
+	opts := runner.WithConfig(&t.With.Config)
 
 	// todo: supply all the allowed options
-	igr, err := runner.Run(t.With.Call, t.With.Host, opts)
+	c, err := runner.NewConfig(t.With.Call, t.With.Host, opts)
+	if err != nil {
+		e := errors.New("invalid ghz config")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	reqr, err := runner.NewRequester(c)
+	if err != nil {
+		e := errors.New("unable to create ghz requester")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	// stop the test after its duration, or when the experiment is interrupted;
+	// the report includes results of requests sent so far
+	if z := time.Duration(t.With.Z); z > 0 {
+		timer := time.AfterFunc(z, func() { reqr.Stop(runner.ReasonTimeout) })
+		defer timer.Stop()
+	}
+	defer exp.onInterrupt(func() { reqr.Stop(runner.ReasonCancel) })()
+
+	igr, err := reqr.Run()
 	if err != nil {
 		e := errors.New("ghz run failed")
 		log.Logger.WithStackTrace(err.Error()).Error(e)
@@ -100,70 +175,105 @@ func (t *collectGRPCTask) run(exp *Experiment) error {
 
 	t.initializeDefaults()
 
-	// 2. collect raw results from ghz
-
-	// run ghz test
-	// collect ghz report
-	// ghz reports will be further processed to populate metrics
-	data, err := t.resultForVersion()
-	if err != nil {
-		return err
+	// 2. Init insights with num versions: one, unless versions are load tested
+	numVersions := len(t.With.Versions)
+	if numVersions == 0 {
+		numVersions = 1
 	}
-
-	// 3. Init insights with num versions: always 1 in this task
-	err = exp.Result.initInsightsWithNumVersions(1)
+	err = exp.Result.initInsightsWithNumVersions(numVersions)
 	if err != nil {
 		return err
 	}
 	in := exp.Result.Insights
 
-	// 4. Populate all metrics collected by this task
-	if data != nil { // assuming there is some raw ghz result to process
-		// populate grpc request count
-		// todo: this logic breaks for looped experiments. Fix when we get to loops.
-		m := gRPCMetricPrefix + "/" + gRPCRequestCountMetricName
-		mm := MetricMeta{
-			Description: "number of gRPC requests sent",
-			Type:        CounterMetricType,
-		}
-		in.updateMetric(m, mm, 0, float64(data.Count))
-
-		// populate error count & rate
-		ec := float64(0)
-		for _, count := range data.ErrorDist {
-			ec += float64(count)
+	// 3. collect raw results from ghz for each version
+	// ghz reports will be further processed to populate metrics
+	if len(t.With.Versions) == 0 {
+		data, err := t.resultForVersion(exp)
+		if err != nil {
+			return err
 		}
-
-		// populate count
-		// todo: This logic breaks for looped experiments. Fix when we get to loops.
-		m = gRPCMetricPrefix + "/" + gRPCErrorCountMetricName
-		mm = MetricMeta{
-			Description: "number of responses that were errors",
-			Type:        CounterMetricType,
+		updateGRPCMetrics(in, 0, data)
+		return nil
+	}
+	for i, v := range t.With.Versions {
+		if exp.stopped() {
+			break
 		}
-		in.updateMetric(m, mm, 0, ec)
-
-		// populate rate
-		// todo: This logic breaks for looped experiments. Fix when we get to loops.
-		m = gRPCMetricPrefix + "/" + gRPCErrorRateMetricName
-		rc := float64(data.Count)
-		if rc != 0 {
-			mm = MetricMeta{
-				Description: "fraction of responses that were errors",
-				Type:        GaugeMetricType,
-			}
-			in.updateMetric(m, mm, 0, ec/rc)
+		log.Logger.Infof("load testing version %v: %v", i, v.Host)
+		data, err := t.forVersion(v).resultForVersion(exp)
+		if err != nil {
+			return err
 		}
+		updateGRPCMetrics(in, i, data)
+	}
+	return nil
+}
 
-		// populate latency sample
-		m = gRPCMetricPrefix + "/" + gRPCLatencySampleMetricName
+// forVersion returns a copy of the task that load tests the given version
+func (t *collectGRPCTask) forVersion(v grpcVersion) *collectGRPCTask {
+	vt := *t
+	vt.With.Host = v.Host
+	vt.With.Versions = nil
+	vt.With.Metadata = map[string]string{}
+	for k, val := range t.With.Metadata {
+		vt.With.Metadata[k] = val
+	}
+	for k, val := range v.Metadata {
+		vt.With.Metadata[k] = val
+	}
+	return &vt
+}
+
+// updateGRPCMetrics populates the metrics of the version (i) from the ghz report
+func updateGRPCMetrics(in *Insights, i int, data *runner.Report) {
+	// there is nothing to populate without a raw ghz result
+	if data == nil {
+		return
+	}
+	// populate grpc request count
+	// todo: this logic breaks for looped experiments. Fix when we get to loops.
+	m := gRPCMetricPrefix + "/" + gRPCRequestCountMetricName
+	mm := MetricMeta{
+		Description: "number of gRPC requests sent",
+		Type:        CounterMetricType,
+	}
+	in.updateMetric(m, mm, i, float64(data.Count))
+
+	// populate error count & rate
+	ec := float64(0)
+	for _, count := range data.ErrorDist {
+		ec += float64(count)
+	}
+
+	// populate count
+	// todo: This logic breaks for looped experiments. Fix when we get to loops.
+	m = gRPCMetricPrefix + "/" + gRPCErrorCountMetricName
+	mm = MetricMeta{
+		Description: "number of responses that were errors",
+		Type:        CounterMetricType,
+	}
+	in.updateMetric(m, mm, i, ec)
+
+	// populate rate
+	// todo: This logic breaks for looped experiments. Fix when we get to loops.
+	m = gRPCMetricPrefix + "/" + gRPCErrorRateMetricName
+	rc := float64(data.Count)
+	if rc != 0 {
 		mm = MetricMeta{
-			Description: "gRPC Latency Sample",
-			Type:        SampleMetricType,
-			Units:       StringPointer("msec"),
+			Description: "fraction of responses that were errors",
+			Type:        GaugeMetricType,
 		}
-		lh := latencySample(data.Details)
-		in.updateMetric(m, mm, 0, lh)
+		in.updateMetric(m, mm, i, ec/rc)
 	}
-	return nil
+
+	// populate latency sample
+	m = gRPCMetricPrefix + "/" + gRPCLatencySampleMetricName
+	mm = MetricMeta{
+		Description: "gRPC Latency Sample",
+		Type:        SampleMetricType,
+		Units:       StringPointer("msec"),
+	}
+	lh := latencySample(data.Details)
+	in.updateMetric(m, mm, i, lh)
 }
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
@@ -29,10 +30,12 @@ func TestRunCollectGRPCUnary(t *testing.T) {
 		TaskMeta: TaskMeta{
 			Task: StringPointer(CollectGRPCTaskName),
 		},
-		With: runner.Config{
-			Data: map[string]interface{}{"name": "bob"},
-			Call: "helloworld.Greeter.SayHello",
-			Host: internal.TestLocalhost,
+		With: collectGRPCInputs{
+			Config: runner.Config{
+				Data: map[string]interface{}{"name": "bob"},
+				Call: "helloworld.Greeter.SayHello",
+				Host: internal.TestLocalhost,
+			},
 		},
 	}
 
@@ -84,15 +87,17 @@ func TestMockGRPCWithSLOsAndPercentiles(t *testing.T) {
 		TaskMeta: TaskMeta{
 			Task: StringPointer(CollectGRPCTaskName),
 		},
-		With: runner.Config{
-			N:           100,
-			RPS:         20,
-			C:           1,
-			Timeout:     runner.Duration(20 * time.Second),
-			Data:        map[string]interface{}{"name": "bob"},
-			DialTimeout: runner.Duration(20 * time.Second),
-			Call:        "helloworld.Greeter.SayHello",
-			Host:        internal.TestLocalhost,
+		With: collectGRPCInputs{
+			Config: runner.Config{
+				N:           100,
+				RPS:         20,
+				C:           1,
+				Timeout:     runner.Duration(20 * time.Second),
+				Data:        map[string]interface{}{"name": "bob"},
+				DialTimeout: runner.Duration(20 * time.Second),
+				Call:        "helloworld.Greeter.SayHello",
+				Host:        internal.TestLocalhost,
+			},
 		},
 	}
 
@@ -157,3 +162,42 @@ func TestMockGRPCWithSLOsAndPercentiles(t *testing.T) {
 	count := gs.GetCount(callType)
 	assert.Equal(t, int(ct.With.N), count)
 }
+
+func TestMockGRPCVersions(t *testing.T) {
+	os.Chdir(t.TempDir())
+	callType := helloworld.Unary
+	gs, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	t.Cleanup(s.Stop)
+
+	// the inputs of versions are inlined with those of ghz
+	ct := &collectGRPCTask{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"task": "grpc",
+		"with": {
+			"total": 20,
+			"call": "helloworld.Greeter.SayHello",
+			"data": {"name": "bob"},
+			"metadata": {"user": "iter8"},
+			"versions": [{"host": "`+internal.TestLocalhost+`"}, {"host": "`+internal.TestLocalhost+`", "metadata": {"version": "v2"}}]
+		}
+	}`), ct))
+	assert.Equal(t, "helloworld.Greeter.SayHello", ct.With.Call)
+	assert.Len(t, ct.With.Versions, 2)
+
+	exp := &Experiment{
+		Spec: []Task{ct},
+	}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+	assert.Equal(t, 2, exp.Result.Insights.NumVersions)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, 20.0, *exp.Result.Insights.ScalarMetricValue(i, gRPCMetricPrefix+"/"+gRPCRequestCountMetricName))
+	}
+	assert.Equal(t, 40, gs.GetCount(callType))
+	// metadata of versions is added to that of the task
+	assert.Equal(t, map[string]string{"user": "iter8", "version": "v2"}, ct.forVersion(ct.With.Versions[1]).With.Metadata)
+	assert.Equal(t, map[string]string{"user": "iter8"}, ct.With.Metadata)
+}
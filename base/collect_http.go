@@ -1,6 +1,7 @@
 package base
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -30,6 +31,7 @@ type collectHTTPInputs struct {
 	// QPS is the number of requests per second sent to the app. Default value is 8.0.
 	QPS *float32 `json:"qps,omitempty" yaml:"qps,omitempty"`
 	// Connections is the number of number of parallel connections used to send load. Default value is 4.
+	// Each connection is kept alive by its own thread; connections are reopened after error responses, which lowers the throughput of load tests of failing apps.
 	Connections *int `json:"connections,omitempty" yaml:"connections,omitempty"`
 	// PayloadStr is the string data to be sent as payload. If this field is specified, Iter8 will send HTTP POST requests to the app using this string as the payload.
 	PayloadStr *string `json:"payloadStr,omitempty" yaml:"payloadStr,omitempty"`
@@ -45,6 +47,32 @@ type collectHTTPInputs struct {
 	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 	// URL to use for querying the app
 	URL string `json:"url" yaml:"url"`
+	// LiveMetricsInterval is the interval at which interim values of built-in metrics are logged while the load test runs. Specified in the Go duration string format (example, 30s). In Kubernetes experiments, they are also published in an annotation of the experiment secret. Optional; by default, metrics are available only when the task completes.
+	LiveMetricsInterval *string `json:"liveMetricsInterval,omitempty" yaml:"liveMetricsInterval,omitempty"`
+	// CheckpointInterval is the interval at which the progress of the load test is checkpointed in the experiment result. Specified in the Go duration string format (example, 1m).
+	// A resumed run of an interrupted experiment continues the load test from its checkpoint. Optional; by default, the load test is checkpointed only when it is interrupted by a signal. Load tests in the branches of a parallel task are not checkpointed.
+	CheckpointInterval *string `json:"checkpointInterval,omitempty" yaml:"checkpointInterval,omitempty"`
+	// Mirror replays mirrored production requests against the app at URL (the candidate) and a baseline, instead of generating load.
+	// Metrics are collected for both versions; the baseline is version 0, and the candidate is version 1. Optional.
+	Mirror *mirrorInputs `json:"mirror,omitempty" yaml:"mirror,omitempty"`
+	// Versions are the versions of the app that are load tested one after another, for A/B/n experiments; the first is the baseline.
+	// Each version is load tested with the inputs of this task, using the URL and headers of the version. Optional.
+	// If unspecified, the app at URL is the only version.
+	Versions []httpVersion `json:"versions,omitempty" yaml:"versions,omitempty"`
+	// Affinity splits the load between versions by synthetic users, instead of load testing versions one after another;
+	// each user is consistently routed to the same version, so that user-level metrics are meaningful. Optional.
+	Affinity *affinityInputs `json:"affinity,omitempty" yaml:"affinity,omitempty"`
+	// Serverless invokes serverless functions, such as AWS Lambda function URLs, with signed requests,
+	// and separates the metrics of cold starts from the built-in metrics of warm invocations. Optional.
+	Serverless *serverlessInputs `json:"serverless,omitempty" yaml:"serverless,omitempty"`
+}
+
+// httpVersion is a version of the app that is load tested by the http task
+type httpVersion struct {
+	// URL to use for querying the version
+	URL string `json:"url" yaml:"url"`
+	// Headers are added to the HTTP headers of the task in queries of the version. Optional.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 }
 
 const (
@@ -134,9 +162,72 @@ func (t *collectHTTPTask) initializeDefaults() {
 
 // validateInputs for this task
 func (t *collectHTTPTask) validateInputs() error {
+	if t.With.URL == "" && len(t.With.Versions) == 0 {
+		return errors.New("http task requires a URL")
+	}
+	for i, v := range t.With.Versions {
+		if v.URL == "" {
+			return fmt.Errorf("http task requires a URL for version %v", i)
+		}
+	}
+	if len(t.With.Versions) > 0 && t.With.Mirror != nil {
+		return errors.New("http task cannot replay mirrored requests to versions; use the baselineURL of the mirror instead")
+	}
+	if len(t.With.Versions) > 0 && t.With.CheckpointInterval != nil {
+		return errors.New("http task cannot checkpoint load tests of versions")
+	}
+	if t.With.Affinity != nil {
+		if len(t.With.Versions) == 0 {
+			return errors.New("http task requires versions for session affinity")
+		}
+		if err := t.With.Affinity.validate(); err != nil {
+			return err
+		}
+	}
+	if t.With.Serverless != nil {
+		if t.With.Mirror != nil || t.With.Affinity != nil {
+			return errors.New("http task cannot invoke serverless functions with mirrored requests or session affinity")
+		}
+		if t.With.CheckpointInterval != nil {
+			return errors.New("http task cannot checkpoint invocations of serverless functions")
+		}
+		if err := t.With.Serverless.validate(); err != nil {
+			return err
+		}
+	}
+	if t.With.Duration != nil {
+		if _, err := time.ParseDuration(*t.With.Duration); err != nil {
+			return fmt.Errorf("invalid duration %v", *t.With.Duration)
+		}
+	}
+	if t.With.LiveMetricsInterval != nil {
+		if d, err := time.ParseDuration(*t.With.LiveMetricsInterval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid live metrics interval %v", *t.With.LiveMetricsInterval)
+		}
+	}
+	if t.With.CheckpointInterval != nil {
+		if d, err := time.ParseDuration(*t.With.CheckpointInterval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid checkpoint interval %v", *t.With.CheckpointInterval)
+		}
+	}
+	if t.With.Mirror != nil {
+		return t.With.Mirror.validate()
+	}
 	return nil
 }
 
+// localFileInputs returns the names of the inputs of the task that name local files
+func (t *collectHTTPTask) localFileInputs() []string {
+	inputs := []string{}
+	if t.With.PayloadFile != nil {
+		inputs = append(inputs, "payloadFile")
+	}
+	if t.With.Mirror != nil && !isURL(t.With.Mirror.Source) {
+		inputs = append(inputs, "mirror.source")
+	}
+	return inputs
+}
+
 // getFortioOptions constructs Fortio's HTTP runner options based on collect task inputs
 func (t *collectHTTPTask) getFortioOptions() (*fhttp.HTTPRunnerOptions, error) {
 	fortioLog.SetOutput(io.Discard)
@@ -196,8 +287,9 @@ func (t *collectHTTPTask) getFortioOptions() (*fhttp.HTTPRunnerOptions, error) {
 	return fo, nil
 }
 
-// getFortioResults collects Fortio run results
-func (t *collectHTTPTask) getFortioResults() (*fhttp.HTTPRunnerResults, error) {
+// getFortioResults collects Fortio run results.
+// If the task has a checkpoint, the load test continues from the checkpoint, and nil results are returned if it has already ended.
+func (t *collectHTTPTask) getFortioResults(exp *Experiment) (*fhttp.HTTPRunnerResults, error) {
 	// the main idea is to run Fortio with proper options
 
 	fo, err := t.getFortioOptions()
@@ -206,6 +298,43 @@ func (t *collectHTTPTask) getFortioResults() (*fhttp.HTTPRunnerResults, error) {
 	}
 	log.Logger.Trace("got fortio options")
 	log.Logger.Trace("URL: ", fo.URL)
+
+	// send the remaining requests, or run for the remaining duration, of an interrupted load test
+	if cp := exp.checkpointOf(t); cp != nil {
+		if fo.Exactly > 0 {
+			fo.Exactly -= cp.Requests
+		} else {
+			fo.Duration -= cp.elapsed()
+		}
+		if fo.Exactly < 0 || (fo.Exactly == 0 && fo.Duration <= 0) {
+			log.Logger.Info("load test ended before the checkpoint")
+			return nil, nil
+		}
+		log.Logger.Infof("continuing load test from checkpoint after %v requests", cp.Requests)
+	}
+
+	// report the progress of the load test, stream live metrics, and checkpoint the load test
+	live, err := newLiveMetricsOpts(t.With.LiveMetricsInterval, t.errorCode, exp)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, err := newCheckpointOpts(t.With.CheckpointInterval, t.errorCode, exp, t)
+	if err != nil {
+		return nil, err
+	}
+	if lt := startLoadTracker(CollectHTTPTaskName, fo.Exactly, fo.Duration, fo.NumThreads, live, checkpoint); lt != nil {
+		fo.AccessLogger = lt
+		defer lt.stop()
+		if live != nil || checkpoint != nil {
+			// errors are counted from the Fortio logs of responses that are not OK
+			fo.LogErrors = true
+			fortioLog.SetOutput(lt)
+			defer fortioLog.SetOutput(io.Discard)
+		}
+	}
+	// stop the load test if the experiment is interrupted; results of requests sent so far are returned
+	fo.Stop = periodic.NewAborter()
+	defer exp.onInterrupt(fo.Stop.Abort)()
 	ifr, err := fhttp.RunHTTPTest(fo)
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("fortio failed")
@@ -226,8 +355,28 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 
 	t.initializeDefaults()
 
+	// replay mirrored requests instead of generating load
+	if t.With.Mirror != nil {
+		return t.runMirror(exp)
+	}
+
+	// split the load between versions by synthetic users
+	if t.With.Affinity != nil {
+		return t.runAffinity(exp)
+	}
+
+	// invoke serverless functions, separating cold starts
+	if t.With.Serverless != nil {
+		return t.runServerless(exp)
+	}
+
+	// load test each version
+	if len(t.With.Versions) > 0 {
+		return t.runVersions(exp)
+	}
+
 	// run fortio
-	data, err := t.getFortioResults()
+	data, err := t.getFortioResults(exp)
 	if err != nil {
 		return err
 	}
@@ -240,99 +389,178 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 	}
 	in := exp.Result.Insights
 
+	// error count
+	val := float64(0)
 	if data != nil {
-		// request count
-		m := httpMetricPrefix + "/" + builtInHTTPRequestCountId
-		mm := MetricMeta{
-			Description: "number of requests sent",
-			Type:        CounterMetricType,
-		}
-		in.updateMetric(m, mm, 0, float64(data.DurationHistogram.Count))
-
-		// error count & rate
-		val := float64(0)
 		for code, count := range data.RetCodes {
 			if t.errorCode(code) {
 				val += float64(count)
 			}
 		}
-		// error count
-		m = httpMetricPrefix + "/" + builtInHTTPErrorCountId
-		mm = MetricMeta{
-			Description: "number of responses that were errors",
-			Type:        CounterMetricType,
+	}
+
+	prior := exp.checkpointOf(t)
+	if i := exp.taskIndex(t); i >= 0 && (prior != nil || exp.interrupted()) {
+		// combine the results with those of earlier runs of the load test
+		hist, elapsed := stats.NewHistogram(0, 0.001), time.Duration(0)
+		if data != nil {
+			hist = (&TaskCheckpoint{Latency: latencyHist(data.DurationHistogram)}).histogram()
+			elapsed = data.ActualDuration
 		}
-		in.updateMetric(m, mm, 0, val)
-
-		// error-rate
-		m = httpMetricPrefix + "/" + builtInHTTPErrorRateId
-		rc := float64(data.DurationHistogram.Count)
-		if rc != 0 {
-			mm = MetricMeta{
-				Description: "fraction of responses that were errors",
-				Type:        GaugeMetricType,
-			}
-			in.updateMetric(m, mm, 0, val/rc)
+		cp := prior.merge(i, int64(val), hist, elapsed)
+		hd := cp.histogram().Export().CalcPercentiles(t.With.Percentiles)
+		cp.Latency = latencyHist(hd)
+		// the latency histogram recorded by an interrupted run is replaced by that of the combined runs
+		in.trimHistMetric(httpMetricPrefix+"/"+builtInHTTPLatencyHistId, 0, cp.RecordedBuckets)
+		t.updateMetrics(in, 0, float64(cp.Errors), hd)
+		// checkpoint the load test if it was interrupted
+		if exp.interrupted() {
+			cp.RecordedBuckets = len(cp.Latency)
+			exp.Result.Checkpoint = cp
 		}
+		return nil
+	}
 
-		// mean-latency
-		m = httpMetricPrefix + "/" + builtInHTTPLatencyMeanId
-		mm = MetricMeta{
-			Description: "mean of observed latency values",
-			Type:        GaugeMetricType,
-			Units:       StringPointer("msec"),
-		}
-		in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.Avg)
+	if data != nil {
+		t.updateMetrics(in, 0, val, data.DurationHistogram)
+	}
+	return nil
+}
 
-		// stddev-latency
-		m = httpMetricPrefix + "/" + builtInHTTPLatencyStdDevId
-		mm = MetricMeta{
-			Description: "standard deviation of observed latency values",
-			Type:        GaugeMetricType,
-			Units:       StringPointer("msec"),
+// forVersion returns a copy of the task that load tests the given version
+func (t *collectHTTPTask) forVersion(v httpVersion) *collectHTTPTask {
+	vt := *t
+	vt.With.URL = v.URL
+	vt.With.Versions = nil
+	vt.With.Headers = map[string]string{}
+	for k, val := range t.With.Headers {
+		vt.With.Headers[k] = val
+	}
+	for k, val := range v.Headers {
+		vt.With.Headers[k] = val
+	}
+	return &vt
+}
+
+// runVersions load tests each version one after another, and populates the metrics of each version.
+// If the experiment is interrupted, the metrics of versions that were load tested are retained;
+// the load tests of versions are not checkpointed.
+func (t *collectHTTPTask) runVersions(exp *Experiment) error {
+	err := exp.Result.initInsightsWithNumVersions(len(t.With.Versions))
+	if err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+
+	for i, v := range t.With.Versions {
+		if exp.stopped() {
+			break
+		}
+		log.Logger.Infof("load testing version %v: %v", i, v.URL)
+		vt := t.forVersion(v)
+		data, err := vt.getFortioResults(exp)
+		if err != nil {
+			return err
 		}
-		in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.StdDev)
+		if data == nil {
+			continue
+		}
+		val := float64(0)
+		for code, count := range data.RetCodes {
+			if vt.errorCode(code) {
+				val += float64(count)
+			}
+		}
+		vt.updateMetrics(in, i, val, data.DurationHistogram)
+	}
+	return nil
+}
+
+// updateMetrics updates the built-in metrics of the version from the number of errors and the latency histogram
+func (t *collectHTTPTask) updateMetrics(in *Insights, i int, numErrors float64, hd *stats.HistogramData) {
+	// request count
+	m := httpMetricPrefix + "/" + builtInHTTPRequestCountId
+	mm := MetricMeta{
+		Description: "number of requests sent",
+		Type:        CounterMetricType,
+	}
+	in.updateMetric(m, mm, i, float64(hd.Count))
 
-		// min-latency
-		m = httpMetricPrefix + "/" + builtInHTTPLatencyMinId
+	// error count
+	m = httpMetricPrefix + "/" + builtInHTTPErrorCountId
+	mm = MetricMeta{
+		Description: "number of responses that were errors",
+		Type:        CounterMetricType,
+	}
+	in.updateMetric(m, mm, i, numErrors)
+
+	// error-rate
+	m = httpMetricPrefix + "/" + builtInHTTPErrorRateId
+	rc := float64(hd.Count)
+	if rc != 0 {
 		mm = MetricMeta{
-			Description: "minimum of observed latency values",
+			Description: "fraction of responses that were errors",
 			Type:        GaugeMetricType,
-			Units:       StringPointer("msec"),
 		}
-		in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.Min)
+		in.updateMetric(m, mm, i, numErrors/rc)
+	}
 
-		// max-latency
-		m = httpMetricPrefix + "/" + builtInHTTPLatencyMaxId
+	// mean-latency
+	m = httpMetricPrefix + "/" + builtInHTTPLatencyMeanId
+	mm = MetricMeta{
+		Description: "mean of observed latency values",
+		Type:        GaugeMetricType,
+		Units:       StringPointer("msec"),
+	}
+	in.updateMetric(m, mm, i, 1000.0*hd.Avg)
+
+	// stddev-latency
+	m = httpMetricPrefix + "/" + builtInHTTPLatencyStdDevId
+	mm = MetricMeta{
+		Description: "standard deviation of observed latency values",
+		Type:        GaugeMetricType,
+		Units:       StringPointer("msec"),
+	}
+	in.updateMetric(m, mm, i, 1000.0*hd.StdDev)
+
+	// min-latency
+	m = httpMetricPrefix + "/" + builtInHTTPLatencyMinId
+	mm = MetricMeta{
+		Description: "minimum of observed latency values",
+		Type:        GaugeMetricType,
+		Units:       StringPointer("msec"),
+	}
+	in.updateMetric(m, mm, i, 1000.0*hd.Min)
+
+	// max-latency
+	m = httpMetricPrefix + "/" + builtInHTTPLatencyMaxId
+	mm = MetricMeta{
+		Description: "maximum of observed latency values",
+		Type:        GaugeMetricType,
+		Units:       StringPointer("msec"),
+	}
+	in.updateMetric(m, mm, i, 1000.0*hd.Max)
+
+	// percentiles
+	for _, p := range hd.Percentiles {
+		m = fmt.Sprintf("%v/%v%v", httpMetricPrefix, builtInHTTPLatencyPercentilePrefix, p.Percentile)
 		mm = MetricMeta{
-			Description: "maximum of observed latency values",
+			Description: fmt.Sprintf("%v-th percentile of observed latency values", p.Percentile),
 			Type:        GaugeMetricType,
 			Units:       StringPointer("msec"),
 		}
-		in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.Max)
-
-		// percentiles
-		for _, p := range data.DurationHistogram.Percentiles {
-			m = fmt.Sprintf("%v/%v%v", httpMetricPrefix, builtInHTTPLatencyPercentilePrefix, p.Percentile)
-			mm = MetricMeta{
-				Description: fmt.Sprintf("%v-th percentile of observed latency values", p.Percentile),
-				Type:        GaugeMetricType,
-				Units:       StringPointer("msec"),
-			}
-			in.updateMetric(m, mm, 0, 1000.0*p.Value)
-		}
+		in.updateMetric(m, mm, i, 1000.0*p.Value)
+	}
 
-		// latency histogram
-		m = httpMetricPrefix + "/" + builtInHTTPLatencyHistId
-		mm = MetricMeta{
-			Description: "Latency Histogram",
-			Type:        HistogramMetricType,
-			Units:       StringPointer("msec"),
-		}
-		lh := latencyHist(data.DurationHistogram)
-		in.updateMetric(m, mm, 0, lh)
+	// latency histogram
+	m = httpMetricPrefix + "/" + builtInHTTPLatencyHistId
+	mm = MetricMeta{
+		Description: "Latency Histogram",
+		Type:        HistogramMetricType,
+		Units:       StringPointer("msec"),
 	}
-	return nil
+	lh := latencyHist(hd)
+	in.updateMetric(m, mm, i, lh)
 }
 
 // compute latency histogram by resampling
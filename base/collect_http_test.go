@@ -1,8 +1,15 @@
 package base
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -47,3 +54,257 @@ func TestRunCollectHTTP(t *testing.T) {
 	assert.NotNil(t, mm)
 	assert.NoError(t, err)
 }
+
+func TestCollectHTTPVersions(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// each version counts its requests; the candidate fails requests without the version header
+	counts := make([]int32, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1":
+			atomic.AddInt32(&counts[0], 1)
+		case "/v2":
+			atomic.AddInt32(&counts[1], 1)
+		case "/v3":
+			atomic.AddInt32(&counts[2], 1)
+			if r.Header.Get("X-Version") != "v3" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			NumRequests: int64Pointer(10),
+			QPS:         float32Pointer(100),
+			Headers:     map[string]string{"X-Version": "none"},
+			Versions: []httpVersion{
+				{URL: srv.URL + "/v1"},
+				{URL: srv.URL + "/v2"},
+				{URL: srv.URL + "/v3", Headers: map[string]string{"X-Version": "v3"}},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+	assert.Equal(t, 3, exp.Result.Insights.NumVersions)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, int32(10), atomic.LoadInt32(&counts[i]))
+		assert.Equal(t, 10.0, *exp.Result.Insights.ScalarMetricValue(i, httpMetricPrefix+"/"+builtInHTTPRequestCountId))
+		// headers of versions take precedence over headers of the task
+		assert.Equal(t, 0.0, *exp.Result.Insights.ScalarMetricValue(i, httpMetricPrefix+"/"+builtInHTTPErrorCountId))
+	}
+
+	// versions are not combined with mirrored requests
+	ct.With.Mirror = &mirrorInputs{Source: "requests.json", BaselineURL: srv.URL}
+	assert.Error(t, ct.validateInputs())
+	ct.With.Mirror = nil
+	ct.With.Versions[1].URL = ""
+	assert.Error(t, ct.validateInputs())
+}
+
+func TestCollectHTTPAffinity(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// each version records the users whose requests it receives; version 2 fails all requests
+	var mu sync.Mutex
+	users := map[string]map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		id := r.Header.Get("X-Session")
+		if users[id] == nil {
+			users[id] = map[string]int{}
+		}
+		users[id][r.URL.Path]++
+		if r.URL.Path == "/v3" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			NumRequests: int64Pointer(60),
+			QPS:         float32Pointer(200),
+			Versions: []httpVersion{
+				{URL: srv.URL + "/v1"},
+				{URL: srv.URL + "/v2"},
+				{URL: srv.URL + "/v3"},
+			},
+			Affinity: &affinityInputs{
+				Users:  intPointer(20),
+				UserID: "session-[[ .User ]]",
+				Header: "X-Session",
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+
+	// each user sends the same number of requests, always to the same version
+	assert.Len(t, users, 20)
+	for id, paths := range users {
+		assert.Len(t, paths, 1, id)
+		assert.Equal(t, 3, paths[fmt.Sprintf("/v%v", affinityVersion(id, 3)+1)], id)
+	}
+	in := exp.Result.Insights
+	assert.Equal(t, 3, in.NumVersions)
+	total := 0.0
+	for i := 0; i < 3; i++ {
+		userCount := *in.ScalarMetricValue(i, httpMetricPrefix+"/"+builtInHTTPUserCountId)
+		total += userCount
+		assert.Equal(t, 3*userCount, *in.ScalarMetricValue(i, httpMetricPrefix+"/"+builtInHTTPRequestCountId))
+	}
+	assert.Equal(t, 20.0, total)
+	assert.Equal(t, 0.0, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPUserErrorRateId))
+	assert.Equal(t, 1.0, *in.ScalarMetricValue(2, httpMetricPrefix+"/"+builtInHTTPUserErrorRateId))
+
+	// session affinity requires versions, and a valid template of user IDs
+	ct.With.Affinity.UserID = "[[ .Missing ]]"
+	assert.Error(t, ct.validateInputs())
+	ct.With.Affinity.UserID = ""
+	ct.With.Versions = nil
+	ct.With.URL = srv.URL
+	assert.Error(t, ct.validateInputs())
+}
+
+func TestCollectHTTPServerless(t *testing.T) {
+	os.Chdir(t.TempDir())
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	// the function rejects unsigned requests, and reports a cold start in its tenth invocation
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 10 {
+			w.Header().Set("X-Cold-Start", "true")
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/us-west-2/lambda/aws4_request") ||
+			r.Header.Get("X-Amz-Date") == "" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			NumRequests: int64Pointer(20),
+			QPS:         float32Pointer(100),
+			Connections: intPointer(1),
+			URL:         srv.URL,
+			Serverless: &serverlessInputs{
+				Region:          "us-west-2",
+				ColdStartHeader: "X-Cold-Start",
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+
+	// the first invocation and the reported cold start are separated from warm invocations
+	in := exp.Result.Insights
+	assert.Equal(t, int32(20), atomic.LoadInt32(&count))
+	assert.Equal(t, 18.0, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountId))
+	assert.Equal(t, 0.0, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountId))
+	assert.Equal(t, 2.0, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPColdStartCountId))
+	assert.Equal(t, 0.0, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPColdStartErrorCountId))
+	assert.NotNil(t, in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPColdStartLatencyMeanId))
+
+	// unsigned requests are rejected; only the first invocation is a cold start
+	ct.With.Serverless.AuthType = noneAuthType
+	exp = &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+	assert.Equal(t, 19.0, *exp.Result.Insights.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountId))
+	assert.Equal(t, 1.0, *exp.Result.Insights.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPColdStartErrorCountId))
+
+	// requests are not signed without credentials
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+	ct.With.Serverless.AuthType = awsIAMAuthType
+	assert.Error(t, ct.run(exp))
+
+	// serverless functions are not invoked with session affinity, or with an invalid auth type
+	ct.With.Serverless.AuthType = "IAM"
+	assert.Error(t, ct.validateInputs())
+	ct.With.Serverless.AuthType = ""
+	ct.With.Affinity = &affinityInputs{}
+	assert.Error(t, ct.validateInputs())
+}
+
+// BenchmarkCollectHTTP measures the throughput of the http task against a local app, in requests per second;
+// load is generated by a fixed pool of threads, each with a keep-alive connection that is established before the load test starts
+func BenchmarkCollectHTTP(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		// status of the responses of the app
+		status int
+		// live enables live metrics, which record latency histograms and count errors
+		live bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "live metrics", status: http.StatusOK, live: true},
+		// connections are closed after error responses, so each request reconnects
+		{name: "errors", status: http.StatusInternalServerError},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			os.Chdir(b.TempDir())
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(bc.status)
+			}))
+			b.Cleanup(srv.Close)
+
+			ct := &collectHTTPTask{
+				TaskMeta: TaskMeta{
+					Task: StringPointer(CollectHTTPTaskName),
+				},
+				With: collectHTTPInputs{
+					NumRequests: int64Pointer(int64(b.N)),
+					QPS:         float32Pointer(1e6),
+					Connections: intPointer(8),
+					URL:         srv.URL,
+				},
+			}
+			if bc.live {
+				ct.With.LiveMetricsInterval = StringPointer("1h")
+			}
+			exp := &Experiment{
+				Spec:   []Task{ct},
+				Result: &ExperimentResult{},
+			}
+			exp.initResults(1)
+			b.ResetTimer()
+			start := time.Now()
+			err := ct.run(exp)
+			elapsed := time.Since(start)
+			assert.NoError(b, err)
+			b.ReportMetric(float64(b.N)/elapsed.Seconds(), "req/s")
+		})
+	}
+}
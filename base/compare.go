@@ -0,0 +1,288 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// CompareTaskName is the name of the task this file implements
+	CompareTaskName = "compare"
+
+	// compareMetricPrefix is the prefix for all metrics collected by this task
+	compareMetricPrefix = "compare"
+	// builtInCompareRequestCountId is the number of requests sent to each version
+	builtInCompareRequestCountId = "request-count"
+	// builtInMismatchCountId is the number of requests whose response differs from that of the baseline
+	builtInMismatchCountId = "mismatch-count"
+	// builtInMismatchRateId is the fraction of requests whose response differs from that of the baseline
+	builtInMismatchRateId = "mismatch-rate"
+
+	// defaultCompareTimeout is the default timeout of each request
+	defaultCompareTimeout = "10s"
+	// maxLoggedDiffs is the maximum number of differences that are logged by the compare task
+	maxLoggedDiffs = 10
+)
+
+// sampleRequest is a request that is sent to versions of the app, such as a mirrored production request
+type sampleRequest struct {
+	// Method of the request; defaults to GET
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	// Path of the request, including the query; it is appended to the base URLs of the versions
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Headers of the request
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Body of the request
+	Body string `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// String describes the request
+func (r sampleRequest) String() string {
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	return method + " " + r.path()
+}
+
+// path returns the path of the request, starting with /
+func (r sampleRequest) path() string {
+	if strings.HasPrefix(r.Path, "/") {
+		return r.Path
+	}
+	return "/" + r.Path
+}
+
+// capturedResponse is the response of a version to a sample request
+type capturedResponse struct {
+	// status code of the response; zero if the request failed
+	status int
+	// header of the response
+	header http.Header
+	// body of the response
+	body []byte
+}
+
+// sendRequest sends the request to the version with the base URL, along with the headers,
+// and returns its response and latency
+func sendRequest(client *http.Client, baseURL string, r sampleRequest, headers map[string]string) (*capturedResponse, time.Duration) {
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(baseURL, "/")+r.path(), strings.NewReader(r.Body))
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warnf("unable to create request %v", r)
+		return &capturedResponse{}, 0
+	}
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "Host") || strings.EqualFold(k, "Content-Length") {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Debugf("request %v to %v failed", r, baseURL)
+		return &capturedResponse{}, time.Since(start)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Debugf("unable to read response of request %v to %v", r, baseURL)
+		return &capturedResponse{}, latency
+	}
+	return &capturedResponse{status: resp.StatusCode, header: resp.Header, body: body}, latency
+}
+
+// responseRules determine whether responses of versions are equivalent
+type responseRules struct {
+	// CompareHeaders are response headers that must be equal, in addition to status codes and bodies
+	CompareHeaders []string `json:"compareHeaders,omitempty" yaml:"compareHeaders,omitempty"`
+	// IgnoreFields are fields of JSON response bodies that are not compared; for example, timestamp.
+	// Nested fields are separated by dots, such as metadata.requestId; fields of objects in arrays are ignored in each object.
+	IgnoreFields []string `json:"ignoreFields,omitempty" yaml:"ignoreFields,omitempty"`
+}
+
+// deleteField deletes the field with the path from the JSON value
+func deleteField(v interface{}, path []string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(val, path[0])
+			return
+		}
+		if f, ok := val[path[0]]; ok {
+			deleteField(f, path[1:])
+		}
+	case []interface{}:
+		for _, e := range val {
+			deleteField(e, path)
+		}
+	}
+}
+
+// diff describes how the response differs from the baseline response; it is empty if the responses are equivalent;
+// that is, if their status codes, compared headers, and bodies are equal.
+// JSON bodies are compared as values, without the ignored fields.
+func (rr *responseRules) diff(baseline *capturedResponse, other *capturedResponse) string {
+	if baseline.status != other.status {
+		return fmt.Sprintf("status code %v differs from %v", other.status, baseline.status)
+	}
+	for _, h := range rr.CompareHeaders {
+		if baseline.header.Get(h) != other.header.Get(h) {
+			return fmt.Sprintf("header %v %q differs from %q", h, other.header.Get(h), baseline.header.Get(h))
+		}
+	}
+	if bytes.Equal(baseline.body, other.body) {
+		return ""
+	}
+	var bv, ov interface{}
+	if json.Unmarshal(baseline.body, &bv) != nil || json.Unmarshal(other.body, &ov) != nil {
+		return "body differs"
+	}
+	for _, f := range rr.IgnoreFields {
+		deleteField(bv, strings.Split(f, "."))
+		deleteField(ov, strings.Split(f, "."))
+	}
+	if reflect.DeepEqual(bv, ov) {
+		return ""
+	}
+	bm, bok := bv.(map[string]interface{})
+	om, ook := ov.(map[string]interface{})
+	if !bok || !ook {
+		return "body differs"
+	}
+	fields := []string{}
+	for k := range bm {
+		if !reflect.DeepEqual(bm[k], om[k]) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range om {
+		if _, ok := bm[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("body fields %v differ", strings.Join(fields, ", "))
+}
+
+// compareInputs are the inputs to the compare task
+type compareInputs struct {
+	// URLs are the base URLs of the versions; the first is the baseline
+	URLs []string `json:"urls" yaml:"urls"`
+	// Requests are sent to each version; defaults to a GET request of the base URLs
+	Requests []sampleRequest `json:"requests,omitempty" yaml:"requests,omitempty"`
+	// Headers are added to all requests
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Timeout of each request
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// responseRules determine whether responses are equivalent
+	responseRules `json:",inline" yaml:",inline"`
+}
+
+// compareTask sends identical requests to all versions of the app, and compares the responses of each version
+// with those of the baseline, so that functional parity of versions can be an SLO.
+// The number and fraction of requests whose response differs from that of the baseline are metrics of each version.
+type compareTask struct {
+	// TaskMeta has fields common to all tasks
+	TaskMeta
+	// With contains the inputs to this task
+	With compareInputs `json:"with" yaml:"with"`
+}
+
+// initializeDefaults sets default values for task inputs
+func (t *compareTask) initializeDefaults() {
+	if len(t.With.Requests) == 0 {
+		t.With.Requests = []sampleRequest{{}}
+	}
+	if t.With.Timeout == nil {
+		t.With.Timeout = StringPointer(defaultCompareTimeout)
+	}
+}
+
+// validateInputs for this task
+func (t *compareTask) validateInputs() error {
+	if len(t.With.URLs) < 2 {
+		return errors.New("compare task requires the URLs of a baseline and at least one other version")
+	}
+	if t.With.Timeout != nil {
+		if _, err := time.ParseDuration(*t.With.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %v", *t.With.Timeout)
+		}
+	}
+	return nil
+}
+
+// run executes this task
+func (t *compareTask) run(exp *Experiment) error {
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	t.initializeDefaults()
+
+	timeout, _ := time.ParseDuration(*t.With.Timeout)
+	client := &http.Client{Timeout: timeout}
+	mismatches := make([]float64, len(t.With.URLs))
+	logged := 0
+	for _, r := range t.With.Requests {
+		if exp.stopped() {
+			return errors.New("compare task interrupted")
+		}
+		baseline, _ := sendRequest(client, t.With.URLs[0], r, t.With.Headers)
+		for j := 1; j < len(t.With.URLs); j++ {
+			resp, _ := sendRequest(client, t.With.URLs[j], r, t.With.Headers)
+			d := t.With.diff(baseline, resp)
+			if d == "" {
+				continue
+			}
+			mismatches[j]++
+			if logged < maxLoggedDiffs {
+				log.Logger.Infof("response of version %v to %v: %v", j, r, d)
+				logged++
+			}
+		}
+	}
+
+	// this task populates insights in the experiment
+	err = exp.Result.initInsightsWithNumVersions(len(t.With.URLs))
+	if err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+	n := float64(len(t.With.Requests))
+	for j, v := range mismatches {
+		in.updateMetric(compareMetricPrefix+"/"+builtInCompareRequestCountId, MetricMeta{
+			Description: "number of requests sent",
+			Type:        CounterMetricType,
+		}, j, n)
+		in.updateMetric(compareMetricPrefix+"/"+builtInMismatchCountId, MetricMeta{
+			Description: "number of requests whose response differs from that of the baseline",
+			Type:        CounterMetricType,
+		}, j, v)
+		in.updateMetric(compareMetricPrefix+"/"+builtInMismatchRateId, MetricMeta{
+			Description: "fraction of requests whose response differs from that of the baseline",
+			Type:        GaugeMetricType,
+		}, j, v/n)
+	}
+	return nil
+}
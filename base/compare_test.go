@@ -0,0 +1,84 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCompare(t *testing.T) {
+	os.Chdir(t.TempDir())
+	version := func(v int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/teapot" && v == 2:
+				w.WriteHeader(http.StatusTeapot)
+			case r.URL.Path == "/items" && v == 1:
+				fmt.Fprintf(w, `{"items": [{"id": %v, "name": "a"}], "count": 2}`, v)
+			default:
+				fmt.Fprintf(w, `{"items": [{"id": %v, "name": "a"}], "count": 1, "meta": {"version": %v}}`, v, v)
+			}
+		}))
+	}
+	urls := []string{}
+	for v := 0; v < 3; v++ {
+		s := version(v)
+		defer s.Close()
+		urls = append(urls, s.URL)
+	}
+
+	ct := &compareTask{
+		TaskMeta: TaskMeta{Task: StringPointer(CompareTaskName)},
+		With: compareInputs{
+			URLs:          urls,
+			Requests:      []sampleRequest{{Path: "/get"}, {Path: "teapot"}, {Method: "POST", Path: "/items", Body: "{}"}, {Path: "/items?page=2"}},
+			responseRules: responseRules{IgnoreFields: []string{"items.id", "meta.version"}},
+		},
+	}
+	exp := &Experiment{Spec: []Task{ct}, Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+	in := exp.Result.Insights
+	assert.Equal(t, 3, in.NumVersions)
+	rate := compareMetricPrefix + "/" + builtInMismatchRateId
+	assert.Equal(t, []float64{0}, in.NonHistMetricValues[0][rate])
+	assert.Equal(t, []float64{0.5}, in.NonHistMetricValues[1][rate])
+	assert.Equal(t, []float64{0.25}, in.NonHistMetricValues[2][rate])
+	assert.Equal(t, []float64{4}, in.NonHistMetricValues[2][compareMetricPrefix+"/"+builtInCompareRequestCountId])
+
+	// the task is unmarshaled with its response rules
+	b, err := json.Marshal(ct)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"ignoreFields":["items.id","meta.version"]`)
+	exp = &Experiment{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"spec": [{"task": "compare", "with": {"urls": ["a", "b"], "ignoreFields": ["time"]}}]}`), exp))
+	assert.Equal(t, []string{"time"}, exp.Spec[0].(*compareTask).With.IgnoreFields)
+
+	// a baseline and another version are required
+	ct.With.URLs = urls[:1]
+	assert.Error(t, ct.run(&Experiment{Spec: []Task{ct}, Result: &ExperimentResult{}}))
+}
+
+func TestResponseDiff(t *testing.T) {
+	rr := &responseRules{CompareHeaders: []string{"Content-Type"}, IgnoreFields: []string{"time", "items.id"}}
+	json := http.Header{"Content-Type": []string{"application/json"}}
+	text := http.Header{"Content-Type": []string{"text/plain"}}
+	assert.Empty(t, rr.diff(&capturedResponse{status: 200, header: json, body: []byte(`{"a": 1, "b": [1, 2], "time": 1}`)},
+		&capturedResponse{status: 200, header: json, body: []byte(`{"b":[1,2],"a":1,"time":2}`)}))
+	assert.Empty(t, rr.diff(&capturedResponse{status: 200, header: json, body: []byte(`{"items": [{"id": 1}, {"id": 2}]}`)},
+		&capturedResponse{status: 200, header: json, body: []byte(`{"items": [{"id": 3}, {"id": 4}]}`)}))
+	assert.Equal(t, "body fields a, c differ", rr.diff(&capturedResponse{status: 200, header: json, body: []byte(`{"a": 1, "b": 2}`)},
+		&capturedResponse{status: 200, header: json, body: []byte(`{"a": 2, "b": 2, "c": 3}`)}))
+	assert.Contains(t, rr.diff(&capturedResponse{status: 200, header: json, body: []byte(`{}`)},
+		&capturedResponse{status: 200, header: text, body: []byte(`{}`)}), "header Content-Type")
+	assert.Equal(t, "body differs", rr.diff(&capturedResponse{status: 200, header: text, body: []byte("a")},
+		&capturedResponse{status: 200, header: text, body: []byte("b")}))
+	assert.Equal(t, "status code 500 differs from 200", rr.diff(&capturedResponse{status: 200}, &capturedResponse{status: 500}))
+	// failed requests of both versions are equivalent
+	assert.Empty(t, rr.diff(&capturedResponse{}, &capturedResponse{}))
+}
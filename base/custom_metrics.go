@@ -10,11 +10,13 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 
 	"time"
 
 	"github.com/itchyny/gojq"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	log "github.com/iter8-tools/iter8/base/log"
 
 	"sigs.k8s.io/yaml"
@@ -82,6 +84,21 @@ type customMetricsInputs struct {
 
 	// VersionInfo values that are specific to each version
 	VersionInfo []map[string]interface{} `json:"versionInfo" yaml:"versionInfo"`
+
+	// Query configures the rate limiting and retries of queries of providers; optional
+	Query *metricsQueryPolicy `json:"query,omitempty" yaml:"query,omitempty"`
+
+	// Concurrency is the maximum number of provider templates fetched, and of metrics queried, at the same time,
+	// across providers and versions. Default value is 4; a value of 1 queries metrics one at a time.
+	Concurrency *int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+}
+
+// concurrency returns the maximum number of concurrent requests to providers
+func (in *customMetricsInputs) concurrency() int {
+	if in.Concurrency == nil {
+		return defaultMetricsConcurrency
+	}
+	return *in.Concurrency
 }
 
 const (
@@ -94,6 +111,9 @@ const (
 
 	// how much time has elapsed between startingTime and now
 	elapsedTimeSecondsStr = "elapsedTimeSeconds"
+
+	// defaultMetricsConcurrency is the default maximum number of concurrent requests to providers
+	defaultMetricsConcurrency = 4
 )
 
 // customMetricsTask enables collection of custom metrics from databases
@@ -108,7 +128,15 @@ func (t *customMetricsTask) initializeDefaults() {
 
 // validate task inputs
 func (t *customMetricsTask) validateInputs() error {
-	return nil
+	return t.With.validate()
+}
+
+// validate the custom metrics inputs
+func (in *customMetricsInputs) validate() error {
+	if in.Concurrency != nil && *in.Concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %v", *in.Concurrency)
+	}
+	return in.Query.validate()
 }
 
 // for a given version info and Experiment, calculate the elapsed time that
@@ -142,7 +170,7 @@ func getElapsedTimeSeconds(versionInfo map[string]interface{}, exp *Experiment)
 //
 // bool return value represents whether the pipeline was able to run to
 // completion (prevents double error statement)
-func queryDatabaseAndGetValue(template MetricsSpec, metric Metric) (interface{}, bool) {
+func queryDatabaseAndGetValue(client *http.Client, template MetricsSpec, metric Metric) (interface{}, bool) {
 	var requestBody io.Reader
 	if metric.Body != nil {
 		requestBody = strings.NewReader(*metric.Body)
@@ -160,6 +188,9 @@ func queryDatabaseAndGetValue(template MetricsSpec, metric Metric) (interface{},
 	// iterate through headers
 	for headerName, headerValue := range template.Headers {
 		req.Header.Add(headerName, headerValue)
+		if log.IsSecretKey(headerName) {
+			log.AddSecret(headerValue, strings.TrimSpace(strings.TrimPrefix(headerValue, "Bearer")))
+		}
 		log.Logger.Debug("add header: ", headerName, ", value: ", headerValue)
 	}
 	req.Header.Add("Content-Type", "application/json;charset=utf-8")
@@ -174,7 +205,6 @@ func queryDatabaseAndGetValue(template MetricsSpec, metric Metric) (interface{},
 	req.URL.RawQuery = q.Encode()
 
 	// send request
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Logger.Error("could not request metric ", metric.Name, ": ", err)
@@ -217,12 +247,13 @@ func queryDatabaseAndGetValue(template MetricsSpec, metric Metric) (interface{},
 }
 
 // get provider template from URL
-func getProviderTemplate(url string, commonValues map[string]interface{}) (*template.Template, error) {
+func getProviderTemplate(client *http.Client, url string, commonValues map[string]interface{}) (*template.Template, error) {
 	// fetch b from url
-	resp, err := http.Get(url)
+	resp, err := client.Get(url)
 	if err != nil {
-		log.Logger.Error(err)
-		return nil, err
+		e := ierrors.Wrap(ierrors.ErrMetricsBackendUnavailable, err, "unable to get metrics template of provider %v", url)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
 	}
 
 	// read responseBody
@@ -255,6 +286,124 @@ func getProviderTemplate(url string, commonValues map[string]interface{}) (*temp
 	return st, nil
 }
 
+// providerMetricsSpecs returns the metrics spec of the provider for each version.
+// The metrics specs are obtained by executing the provider template with the common and version values.
+func providerMetricsSpecs(client *http.Client, providerURL string, in customMetricsInputs, exp *Experiment) ([]MetricsSpec, error) {
+	// finalize metrics spec
+	template, err := getProviderTemplate(client, providerURL, in.Common)
+	if err != nil {
+		return nil, err
+	}
+	providerSpecs := []MetricsSpec{}
+	for _, versionInfo := range in.VersionInfo {
+		// add elapsedTimeSeconds
+		elapsedTimeSeconds, err := getElapsedTimeSeconds(versionInfo, exp)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]interface{})
+		for k, v := range versionInfo {
+			values[k] = v
+		}
+		values[elapsedTimeSecondsStr] = elapsedTimeSeconds
+
+		// get the metrics spec
+		var buf bytes.Buffer
+		err = template.Execute(&buf, values)
+		if err != nil {
+			return nil, err
+		}
+		var metrics MetricsSpec
+		err = yaml.Unmarshal(buf.Bytes(), &metrics)
+		if err != nil {
+			return nil, err
+		}
+		providerSpecs = append(providerSpecs, metrics)
+	}
+	return providerSpecs, nil
+}
+
+// versionMetricsSpecs returns the metrics spec of each provider for each version, fetching the templates of providers concurrently.
+// Providers are isolated from each other; if the metrics specs of a provider cannot be obtained, its specs are nil,
+// and the error is returned at the index of the provider.
+func versionMetricsSpecs(client *http.Client, in customMetricsInputs, exp *Experiment) ([][]MetricsSpec, []error) {
+	specs := make([][]MetricsSpec, len(in.ProviderURLs))
+	errs := make([]error, len(in.ProviderURLs))
+	runBounded(len(in.ProviderURLs), in.concurrency(), func(p int) {
+		specs[p], errs[p] = providerMetricsSpecs(client, in.ProviderURLs[p], in, exp)
+		if errs[p] != nil {
+			log.Logger.WithStackTrace(errs[p].Error()).Errorf("unable to get metrics specs of provider %v", in.ProviderURLs[p])
+		}
+	})
+	return specs, errs
+}
+
+// metricQuery is a query of a metric of a provider for a version
+type metricQuery struct {
+	// spec is the metrics spec of the provider for the version
+	spec MetricsSpec
+	// metric to be queried
+	metric Metric
+	// version is the index of the version
+	version int
+	// value of the metric
+	value interface{}
+	// ok is true if the query ran to completion
+	ok bool
+}
+
+// metricQueries returns the queries of metrics of all providers for all versions, in order of providers, versions, and metrics;
+// metrics that are not selected are skipped
+func metricQueries(specs [][]MetricsSpec, selected func(Metric) bool) []*metricQuery {
+	queries := []*metricQuery{}
+	for _, providerSpecs := range specs {
+		for i, metrics := range providerSpecs {
+			for _, metric := range metrics.Metrics {
+				if selected != nil && !selected(metric) {
+					continue
+				}
+				queries = append(queries, &metricQuery{
+					spec:    metrics,
+					metric:  metric,
+					version: i,
+				})
+			}
+		}
+	}
+	return queries
+}
+
+// runMetricQueries runs the queries, with at most the given number of queries in flight;
+// the value of each query is recorded in the query
+func runMetricQueries(client *http.Client, queries []*metricQuery, concurrency int) {
+	runBounded(len(queries), concurrency, func(k int) {
+		q := queries[k]
+		log.Logger.Debug("query for metric ", q.metric.Name)
+		q.value, q.ok = queryDatabaseAndGetValue(client, q.spec, q.metric)
+	})
+}
+
+// runBounded calls f for each index in [0, n), with at most the given number of calls running at the same time
+func runBounded(n int, concurrency int, f func(int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for k := 0; k < n; k++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(k int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(k)
+		}(k)
+	}
+	wg.Wait()
+}
+
 // run executes this task
 func (t *customMetricsTask) run(exp *Experiment) error {
 	// validate inputs
@@ -280,84 +429,66 @@ func (t *customMetricsTask) run(exp *Experiment) error {
 	}
 
 	// collect metrics from all providers and for all versions
-	for _, providerURL := range t.With.ProviderURLs {
-		// finalize metrics spec
-		template, err := getProviderTemplate(providerURL, t.With.Common)
+	client := t.With.Query.client()
+	specs, errs := versionMetricsSpecs(client, t.With, exp)
+	failed := 0
+	for _, err := range errs {
 		if err != nil {
-			return err
+			failed++
 		}
-		for i, versionInfo := range t.With.VersionInfo {
-			// add elapsedTimeSeconds
-			elapsedTimeSeconds, err := getElapsedTimeSeconds(versionInfo, exp)
-			if err != nil {
-				return err
-			}
-			if versionInfo == nil {
-				versionInfo = make(map[string]interface{})
-			}
-			versionInfo[elapsedTimeSecondsStr] = elapsedTimeSeconds
-
-			// get the metrics spec
-			var buf bytes.Buffer
-			err = template.Execute(&buf, versionInfo)
-			if err != nil {
-				return err
-			}
-			var metrics MetricsSpec
-			err = yaml.Unmarshal(buf.Bytes(), &metrics)
-			if err != nil {
-				return err
-			}
-
-			// get each metric
-			for _, metric := range metrics.Metrics {
-				log.Logger.Debug("query for metric ", metric.Name)
+	}
+	if failed > 0 && failed == len(errs) {
+		e := ierrors.Wrap(ierrors.ErrMetricsBackendUnavailable, errs[0], "unable to get metrics specs of any provider")
+		log.Logger.WithStackTrace(errs[0].Error()).Error(e)
+		return e
+	}
+	queries := metricQueries(specs, nil)
+	runMetricQueries(client, queries, t.With.concurrency())
 
-				// perform database query and extract metric value
-				value, ok := queryDatabaseAndGetValue(metrics, metric)
+	// metric values are recorded in order, regardless of the order in which queries complete
+	for _, q := range queries {
+		metrics, metric, value := q.spec, q.metric, q.value
 
-				// check if there were any issues querying database and extracting value
-				if !ok {
-					log.Logger.Error("could not query for metric ", metric.Name, ": ", err)
-					continue
-				}
+		// check if there were any issues querying database and extracting value
+		if !q.ok {
+			log.Logger.Error("could not query for metric ", metric.Name)
+			continue
+		}
 
-				// do not save value if it has no value
-				if value == nil {
-					log.Logger.Error("could not extract non-nil value for metric ", metric.Name, ": ", err)
-					continue
-				}
+		// do not save value if it has no value
+		if value == nil {
+			log.Logger.Error("could not extract non-nil value for metric ", metric.Name)
+			continue
+		}
 
-				// determine metric type
-				var metricType MetricType
-				if metric.Type == "gauge" {
-					metricType = GaugeMetricType
-				} else if metric.Type == "counter" {
-					metricType = CounterMetricType
-				}
+		// determine metric type
+		var metricType MetricType
+		if metric.Type == "gauge" {
+			metricType = GaugeMetricType
+		} else if metric.Type == "counter" {
+			metricType = CounterMetricType
+		}
 
-				// finalize metric data
-				mm := MetricMeta{
-					Description: *metric.Description,
-					Type:        metricType,
-					Units:       metric.Units,
-				}
+		// finalize metric data
+		mm := MetricMeta{
+			Description: *metric.Description,
+			Type:        metricType,
+			Units:       metric.Units,
+		}
 
-				// convert value to float
-				valueString := fmt.Sprint(value)
-				floatValue, err := strconv.ParseFloat(valueString, 64)
-				if err != nil {
-					log.Logger.Error("could not parse string \""+valueString+"\" to float:", err)
-					continue
-				}
+		// convert value to float
+		valueString := fmt.Sprint(value)
+		floatValue, err := strconv.ParseFloat(valueString, 64)
+		if err != nil {
+			log.Logger.Error("could not parse string \""+valueString+"\" to float:", err)
+			continue
+		}
 
-				err = exp.Result.Insights.updateMetric(metrics.Provider+"/"+metric.Name, mm, i, floatValue)
+		err = exp.Result.Insights.updateMetric(metrics.Provider+"/"+metric.Name, mm, q.version, floatValue)
 
-				if err != nil {
-					log.Logger.Error("could not add update metric", err)
-					continue
-				}
-			}
+		if err != nil {
+			log.Logger.Error("could not add update metric", err)
+			continue
 		}
 	}
 
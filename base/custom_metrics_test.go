@@ -2,11 +2,15 @@ package base
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -615,3 +619,80 @@ func TestRequestBody(t *testing.T) {
 
 	assert.Equal(t, exp.Result.Insights.NonHistMetricValues[0][testRequestBody+"/request-count"][0], float64(43))
 }
+
+// providers and versions are queried concurrently, and a failing provider does not prevent metrics of other providers from being collected
+func TestCustomMetricsConcurrency(t *testing.T) {
+	os.Chdir(t.TempDir())
+	var inFlight, maxInFlight int64
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			m := atomic.LoadInt64(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt64(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintf(w, `{"value": %v}`, r.URL.Query().Get("version"))
+	}))
+	defer backend.Close()
+	providers := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backend.tpl" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+			return
+		}
+		fmt.Fprintf(w, `provider: backend
+method: GET
+url: %v
+metrics:
+- name: m1
+  type: gauge
+  description: m1
+  params:
+  - name: version
+    value: "{{ .version }}"
+  jqExpression: .value
+- name: m2
+  type: counter
+  description: m2
+  params:
+  - name: version
+    value: "{{ .version }}"
+  jqExpression: .value
+`, backend.URL)
+	}))
+	defer providers.Close()
+
+	ct := &customMetricsTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CustomMetricsTaskName),
+		},
+		With: customMetricsInputs{
+			ProviderURLs: []string{providers.URL + "/missing.tpl", providers.URL + "/backend.tpl"},
+			VersionInfo:  []map[string]interface{}{{"version": 0}, {"version": 1}, {"version": 2}},
+			Concurrency:  intPointer(2),
+		},
+	}
+	assert.NoError(t, ct.validateInputs())
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+		driver: &mockDriver{},
+	}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, []float64{float64(i)}, exp.Result.Insights.NonHistMetricValues[i]["backend/m1"])
+		assert.Equal(t, []float64{float64(i)}, exp.Result.Insights.NonHistMetricValues[i]["backend/m2"])
+	}
+	assert.Equal(t, int64(2), atomic.LoadInt64(&maxInFlight))
+
+	// the task fails if no provider has metrics specs
+	ct.With.ProviderURLs = []string{providers.URL + "/missing.tpl"}
+	assert.Error(t, ct.run(exp))
+
+	ct.With.Concurrency = intPointer(0)
+	assert.Error(t, ct.validateInputs())
+}
@@ -0,0 +1,367 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// DiscoveryTaskName is the name of the task this file implements
+	DiscoveryTaskName = "discover"
+
+	// ServiceKind endpoints are discovered from Services
+	ServiceKind = "Service"
+	// DeploymentKind endpoints are discovered from the Service selecting the pods of a Deployment
+	DeploymentKind = "Deployment"
+	// IngressKind endpoints are discovered from the host of an Ingress
+	IngressKind = "Ingress"
+	// RevisionKind endpoints are discovered from the URL of a Knative Revision that is tagged in the traffic of its Knative Service
+	RevisionKind = "Revision"
+
+	// defaultScheme is the default scheme of discovered URLs
+	defaultScheme = "http"
+	// urlOutputSuffix is the suffix of the name of the output with the URL of an endpoint
+	urlOutputSuffix = "URL"
+	// hostOutputSuffix is the suffix of the name of the output with the host and port of an endpoint
+	hostOutputSuffix = "Host"
+)
+
+var (
+	servicesGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	ingressesGVR   = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+
+	// endpointNameRegex matches endpoint names that can be used in templates of outputs, such as {{ .Outputs.candidateURL }}
+	endpointNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// discoveryEndpoint identifies a version of the app by the labels of a Kubernetes object
+type discoveryEndpoint struct {
+	// Name of the endpoint, such as baseline or candidate; the outputs <name>URL and <name>Host are published
+	Name string `json:"name" yaml:"name"`
+	// Kind of the object; Service (default), Deployment, Ingress, or Revision (Knative)
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Selector is the set of labels of the object; exactly one object must match
+	Selector map[string]string `json:"selector" yaml:"selector"`
+	// Port is the name or number of the port of the Service. Optional; defaults to the first port of the Service
+	Port *intstr.IntOrString `json:"port,omitempty" yaml:"port,omitempty"`
+}
+
+// discoveryInputs are the inputs to the discover task
+type discoveryInputs struct {
+	// Namespace in which objects are discovered. Optional; defaults to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Scheme of the URLs; defaults to http, or https for Ingresses with TLS
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	// Path appended to the URLs; for example, /get
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Endpoints to discover
+	Endpoints []discoveryEndpoint `json:"endpoints" yaml:"endpoints"`
+	// Timeout is the maximum time spent waiting for the objects to be found
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the objects. Optional.
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the objects. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// discoveryTask finds the endpoints of versions of the app by the labels of Services, Deployments, Ingresses, or Knative Revisions,
+// and publishes their URLs and hosts as outputs, so that targets of later http and grpc tasks
+// need not be known when the experiment is launched; for example, url: "{{ .Outputs.candidateURL }}"
+type discoveryTask struct {
+	// TaskMeta has fields common to all tasks
+	TaskMeta
+	// With contains the inputs to this task
+	With discoveryInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the objects
+func (t *discoveryTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for task inputs
+func (t *discoveryTask) initializeDefaults() {
+	if t.With.Timeout == nil {
+		t.With.Timeout = StringPointer(defaultTimeout)
+	}
+	for i := range t.With.Endpoints {
+		if t.With.Endpoints[i].Kind == "" {
+			t.With.Endpoints[i].Kind = ServiceKind
+		}
+	}
+	t.driver().initKube()
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs for this task
+func (t *discoveryTask) validateInputs() error {
+	if len(t.With.Endpoints) == 0 {
+		return errors.New("discover task requires endpoints")
+	}
+	names := map[string]bool{}
+	for _, ep := range t.With.Endpoints {
+		if !endpointNameRegex.MatchString(ep.Name) {
+			return fmt.Errorf("invalid endpoint name %q; names must be letters, digits, and underscores", ep.Name)
+		}
+		if names[ep.Name] {
+			return fmt.Errorf("duplicate endpoint name %v", ep.Name)
+		}
+		names[ep.Name] = true
+		if len(ep.Selector) == 0 {
+			return fmt.Errorf("endpoint %v requires a selector", ep.Name)
+		}
+		switch {
+		case ep.Kind == "", strings.EqualFold(ep.Kind, ServiceKind), strings.EqualFold(ep.Kind, DeploymentKind):
+		case strings.EqualFold(ep.Kind, IngressKind), strings.EqualFold(ep.Kind, RevisionKind):
+			if ep.Port != nil {
+				return fmt.Errorf("endpoint %v of kind %v does not have a port", ep.Name, ep.Kind)
+			}
+		default:
+			return fmt.Errorf("invalid kind %v of endpoint %v; must be one of %v, %v, %v, or %v", ep.Kind, ep.Name, ServiceKind, DeploymentKind, IngressKind, RevisionKind)
+		}
+	}
+	if t.With.Timeout != nil {
+		if _, err := time.ParseDuration(*t.With.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %v", *t.With.Timeout)
+		}
+	}
+	return nil
+}
+
+// findOne returns the only object of the resource in the namespace of the task that matches the selector
+func (t *discoveryTask) findOne(gvr schema.GroupVersionResource, selector map[string]string) (*unstructured.Unstructured, error) {
+	list, err := t.driver().dynamicClient.Resource(gvr).Namespace(*t.With.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch len(list.Items) {
+	case 0:
+		return nil, fmt.Errorf("no %v match labels %v", gvr.Resource, labels.Set(selector))
+	case 1:
+		return &list.Items[0], nil
+	default:
+		names := []string{}
+		for _, o := range list.Items {
+			names = append(names, o.GetName())
+		}
+		return nil, fmt.Errorf("%v %v match labels %v; the selector must match one", gvr.Resource, strings.Join(names, ", "), labels.Set(selector))
+	}
+}
+
+// servicePort returns the port of the service with the given name or number, or the first port
+func servicePort(svc *unstructured.Unstructured, p *intstr.IntOrString) (int64, error) {
+	port := ""
+	if p != nil {
+		port = p.String()
+	}
+	ports, _, _ := unstructured.NestedSlice(svc.Object, "spec", "ports")
+	for _, p := range ports {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(pm, "port")
+		name, _, _ := unstructured.NestedString(pm, "name")
+		if port == "" || port == name || port == strconv.FormatInt(number, 10) {
+			return number, nil
+		}
+	}
+	if port == "" {
+		return 0, fmt.Errorf("service %v has no ports", svc.GetName())
+	}
+	return 0, fmt.Errorf("service %v has no port %v", svc.GetName(), port)
+}
+
+// deploymentService returns the only service in the namespace of the task that selects the pods of the deployment
+func (t *discoveryTask) deploymentService(deploy *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	podLabels, _, _ := unstructured.NestedStringMap(deploy.Object, "spec", "template", "metadata", "labels")
+	list, err := t.driver().dynamicClient.Resource(servicesGVR).Namespace(*t.With.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var svc *unstructured.Unstructured
+	for i, s := range list.Items {
+		sel, _, _ := unstructured.NestedStringMap(s.Object, "spec", "selector")
+		if len(sel) == 0 || !labels.SelectorFromSet(sel).Matches(labels.Set(podLabels)) {
+			continue
+		}
+		if svc != nil {
+			return nil, fmt.Errorf("services %v and %v select the pods of deployment %v", svc.GetName(), s.GetName(), deploy.GetName())
+		}
+		svc = &list.Items[i]
+	}
+	if svc == nil {
+		return nil, fmt.Errorf("no service selects the pods of deployment %v", deploy.GetName())
+	}
+	return svc, nil
+}
+
+// ingressHost returns the host of the first rule of the ingress, or else its load balancer address,
+// and whether the ingress terminates TLS
+func ingressHost(ing *unstructured.Unstructured) (string, bool, error) {
+	tls, _, _ := unstructured.NestedSlice(ing.Object, "spec", "tls")
+	rules, _, _ := unstructured.NestedSlice(ing.Object, "spec", "rules")
+	for _, r := range rules {
+		if rm, ok := r.(map[string]interface{}); ok {
+			if host, _, _ := unstructured.NestedString(rm, "host"); host != "" {
+				return host, len(tls) > 0, nil
+			}
+		}
+	}
+	lbs, _, _ := unstructured.NestedSlice(ing.Object, "status", "loadBalancer", "ingress")
+	for _, lb := range lbs {
+		if lbm, ok := lb.(map[string]interface{}); ok {
+			if host, _, _ := unstructured.NestedString(lbm, "hostname"); host != "" {
+				return host, len(tls) > 0, nil
+			}
+			if ip, _, _ := unstructured.NestedString(lbm, "ip"); ip != "" {
+				return ip, len(tls) > 0, nil
+			}
+		}
+	}
+	return "", false, fmt.Errorf("ingress %v has no host and no load balancer address", ing.GetName())
+}
+
+// discover returns the URL and the host and port of the endpoint
+func (t *discoveryTask) discover(ep discoveryEndpoint) (string, string, error) {
+	scheme := t.With.Scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+	path := t.With.Path
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if strings.EqualFold(ep.Kind, IngressKind) {
+		ing, err := t.findOne(ingressesGVR, ep.Selector)
+		if err != nil {
+			return "", "", err
+		}
+		host, tls, err := ingressHost(ing)
+		if err != nil {
+			return "", "", err
+		}
+		port := 80
+		if tls {
+			port = 443
+			if t.With.Scheme == "" {
+				scheme = "https"
+			}
+		}
+		return fmt.Sprintf("%v://%v%v", scheme, host, path), fmt.Sprintf("%v:%v", host, port), nil
+	}
+
+	if strings.EqualFold(ep.Kind, RevisionKind) {
+		rev, err := t.findOne(knativeRevisionsGVR, ep.Selector)
+		if err != nil {
+			return "", "", err
+		}
+		u, err := t.revisionURL(rev)
+		if err != nil {
+			return "", "", err
+		}
+		if t.With.Scheme != "" {
+			u.Scheme = t.With.Scheme
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		return fmt.Sprintf("%v://%v%v", u.Scheme, u.Host, path), fmt.Sprintf("%v:%v", u.Hostname(), port), nil
+	}
+
+	var svc *unstructured.Unstructured
+	var err error
+	if strings.EqualFold(ep.Kind, DeploymentKind) {
+		var deploy *unstructured.Unstructured
+		if deploy, err = t.findOne(deploymentsGVR, ep.Selector); err != nil {
+			return "", "", err
+		}
+		svc, err = t.deploymentService(deploy)
+	} else {
+		svc, err = t.findOne(servicesGVR, ep.Selector)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	port, err := servicePort(svc, ep.Port)
+	if err != nil {
+		return "", "", err
+	}
+	host := fmt.Sprintf("%v.%v:%v", svc.GetName(), svc.GetNamespace(), port)
+	return fmt.Sprintf("%v://%v%v", scheme, host, path), host, nil
+}
+
+// run executes this task
+func (t *discoveryTask) run(exp *Experiment) error {
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	t.initializeDefaults()
+
+	timeout, err := time.ParseDuration(*t.With.Timeout)
+	if err != nil {
+		e := errors.New("invalid format for timeout")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	// objects may not exist yet when the experiment starts; retry until time out
+	interval := 1 * time.Second
+	for _, ep := range t.With.Endpoints {
+		var url, host string
+		err = retry.OnError(
+			wait.Backoff{
+				Steps:    int(timeout / interval),
+				Cap:      timeout,
+				Duration: interval,
+				Factor:   1.0,
+				Jitter:   0.1,
+			},
+			func(err error) bool {
+				log.Logger.Infof("discovering endpoint %v: %v", ep.Name, err)
+				return true
+			},
+			func() error {
+				var err error
+				url, host, err = t.discover(ep)
+				return err
+			},
+		)
+		if err != nil {
+			e := fmt.Errorf("unable to discover endpoint %v: %v", ep.Name, err)
+			log.Logger.Error(e)
+			return e
+		}
+		log.Logger.Infof("discovered endpoint %v: %v", ep.Name, url)
+		exp.setOutput(ep.Name+urlOutputSuffix, url)
+		exp.setOutput(ep.Name+hostOutputSuffix, host)
+	}
+	return nil
+}
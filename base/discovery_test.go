@@ -0,0 +1,130 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// createObject creates the object in the fake cluster
+func createObject(t *testing.T, gvr schema.GroupVersionResource, obj map[string]interface{}) {
+	_, err := kd.dynamicClient.Resource(gvr).Namespace("default").Create(context.Background(), &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+}
+
+// intOrStringPointer returns a pointer to the port
+func intOrStringPointer(p intstr.IntOrString) *intstr.IntOrString {
+	return &p
+}
+
+func TestDiscover(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	for _, v := range []string{"v1", "v2"} {
+		createObject(t, servicesGVR, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      "httpbin-" + v,
+				"namespace": "default",
+				"labels":    map[string]interface{}{"app": "httpbin", "version": v},
+			},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"app": "httpbin", "version": v},
+				"ports": []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(8000)},
+					map[string]interface{}{"name": "grpc", "port": int64(50051)},
+				},
+			},
+		})
+	}
+	createObject(t, deploymentsGVR, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "httpbin-v2",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"track": "canary"},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "httpbin", "version": "v2", "pod-template-hash": "abc"}},
+			},
+		},
+	})
+	createObject(t, ingressesGVR, map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "Ingress",
+		"metadata": map[string]interface{}{
+			"name":      "httpbin",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"app": "httpbin"},
+		},
+		"spec": map[string]interface{}{
+			"tls":   []interface{}{map[string]interface{}{"hosts": []interface{}{"httpbin.example.com"}}},
+			"rules": []interface{}{map[string]interface{}{"host": "httpbin.example.com"}},
+		},
+	})
+
+	dt := &discoveryTask{
+		TaskMeta: TaskMeta{Task: StringPointer(DiscoveryTaskName)},
+		With: discoveryInputs{
+			Path: "get",
+			Endpoints: []discoveryEndpoint{
+				{Name: "baseline", Selector: map[string]string{"app": "httpbin", "version": "v1"}},
+				{Name: "candidate", Kind: DeploymentKind, Selector: map[string]string{"track": "canary"}, Port: intOrStringPointer(intstr.FromString("grpc"))},
+				{Name: "public", Kind: IngressKind, Selector: map[string]string{"app": "httpbin"}},
+			},
+		},
+	}
+	exp := &Experiment{Spec: []Task{dt}, Result: &ExperimentResult{}}
+	assert.NoError(t, dt.run(exp))
+	assert.Equal(t, map[string]string{
+		"baselineURL":   "http://httpbin-v1.default:8000/get",
+		"baselineHost":  "httpbin-v1.default:8000",
+		"candidateURL":  "http://httpbin-v2.default:50051/get",
+		"candidateHost": "httpbin-v2.default:50051",
+		"publicURL":     "https://httpbin.example.com/get",
+		"publicHost":    "httpbin.example.com:443",
+	}, exp.Result.Outputs)
+
+	// later tasks use the outputs in their inputs
+	ht := &collectHTTPTask{TaskMeta: TaskMeta{Task: StringPointer(CollectHTTPTaskName)}, With: collectHTTPInputs{URL: "{{ .Outputs.candidateURL }}"}}
+	rt, err := exp.withOutputs(ht)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://httpbin-v2.default:50051/get", rt.(*collectHTTPTask).With.URL)
+
+	// the selector must match exactly one object
+	dt.With.Timeout = StringPointer("1s")
+	dt.With.Endpoints = []discoveryEndpoint{{Name: "any", Selector: map[string]string{"app": "httpbin"}}}
+	assert.Error(t, dt.run(exp))
+	dt.With.Endpoints = []discoveryEndpoint{{Name: "none", Selector: map[string]string{"app": "other"}}}
+	assert.Error(t, dt.run(exp))
+	dt.With.Endpoints = []discoveryEndpoint{{Name: "baseline", Selector: map[string]string{"version": "v1"}, Port: intOrStringPointer(intstr.FromInt(9090))}}
+	assert.Error(t, dt.run(exp))
+}
+
+func TestDiscoverInvalidInputs(t *testing.T) {
+	for _, in := range []discoveryInputs{
+		{},
+		{Endpoints: []discoveryEndpoint{{Name: "candidate-1", Selector: map[string]string{"app": "httpbin"}}}},
+		{Endpoints: []discoveryEndpoint{{Name: "candidate"}}},
+		{Endpoints: []discoveryEndpoint{{Name: "candidate", Kind: "Pod", Selector: map[string]string{"app": "httpbin"}}}},
+		{Endpoints: []discoveryEndpoint{{Name: "candidate", Kind: IngressKind, Port: intOrStringPointer(intstr.FromInt(80)), Selector: map[string]string{"app": "httpbin"}}}},
+		{Endpoints: []discoveryEndpoint{
+			{Name: "candidate", Selector: map[string]string{"app": "httpbin"}},
+			{Name: "candidate", Selector: map[string]string{"app": "httpbin"}},
+		}},
+		{Endpoints: []discoveryEndpoint{{Name: "candidate", Selector: map[string]string{"app": "httpbin"}}}, Timeout: StringPointer("soon")},
+	} {
+		dt := &discoveryTask{With: in}
+		assert.Error(t, dt.validateInputs())
+	}
+}
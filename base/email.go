@@ -0,0 +1,280 @@
+package base
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// EmailTaskName is the name of the task this file implements
+	EmailTaskName = "email"
+
+	// StartTLSMode upgrades the SMTP connection to TLS using STARTTLS
+	StartTLSMode = "starttls"
+	// TLSMode uses implicit TLS for the SMTP connection
+	TLSMode = "tls"
+	// NoTLSMode does not use TLS for the SMTP connection
+	NoTLSMode = "none"
+
+	// defaultSMTPPort is the default SMTP submission port
+	defaultSMTPPort = 587
+	// defaultSMTPTimeout is the default timeout for connecting to the SMTP server
+	defaultSMTPTimeout = "30s"
+	// defaultEmailFormat is the default format of the emailed report
+	defaultEmailFormat = "text"
+)
+
+// emailInputs are the inputs to the email task
+type emailInputs struct {
+	// Host is the SMTP server host
+	Host string `json:"host" yaml:"host"`
+	// Port is the SMTP server port
+	Port int `json:"port,omitempty" yaml:"port,omitempty"`
+	// TLS is the TLS mode; starttls, tls, or none
+	TLS string `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// Timeout is the timeout for connecting to the SMTP server
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// From is the sender address
+	From string `json:"from" yaml:"from"`
+	// To is the list of recipient addresses
+	To []string `json:"to" yaml:"to"`
+	// Subject is the email subject; defaults to a subject with the experiment status
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty"`
+	// Format is the format of the emailed report; text or html
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Username is used to authenticate with the SMTP server
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	// PasswordEnv is the name of the environment variable containing the SMTP password
+	PasswordEnv string `json:"passwordEnv,omitempty" yaml:"passwordEnv,omitempty"`
+	// PasswordFile is the path to a file containing the SMTP password, such as a mounted Kubernetes secret
+	PasswordFile string `json:"passwordFile,omitempty" yaml:"passwordFile,omitempty"`
+}
+
+// emailTask enables emailing the experiment report.
+// Unlike other tasks, the email task runs even if an earlier task has failed,
+// so that the failure can be reported.
+type emailTask struct {
+	// TaskMeta has fields common to all tasks
+	TaskMeta
+	// With contains the inputs to this task
+	With emailInputs `json:"with" yaml:"with"`
+}
+
+// notifyTask is implemented by tasks that notify users about the experiment.
+// Notification tasks run even if an earlier task in the experiment has failed.
+type notifyTask interface {
+	Task
+	// notifies is a marker method
+	notifies()
+}
+
+// notifies marks the email task as a notification task
+func (t *emailTask) notifies() {}
+
+// reportRenderers render experiment reports in different formats.
+// They are registered by packages that implement reporting.
+var reportRenderers = map[string]func(*Experiment) (string, error){}
+
+// RegisterReportRenderer registers a function that renders experiment reports in the given format.
+// Registered renderers are used by tasks that send reports.
+func RegisterReportRenderer(format string, render func(*Experiment) (string, error)) {
+	reportRenderers[format] = render
+}
+
+// initializeDefaults sets default values for task inputs
+func (t *emailTask) initializeDefaults() {
+	if t.With.Port == 0 {
+		t.With.Port = defaultSMTPPort
+	}
+	if t.With.TLS == "" {
+		t.With.TLS = StartTLSMode
+	}
+	if t.With.Timeout == nil {
+		t.With.Timeout = StringPointer(defaultSMTPTimeout)
+	}
+	if t.With.Format == "" {
+		t.With.Format = defaultEmailFormat
+	}
+}
+
+// validateInputs for this task
+func (t *emailTask) validateInputs() error {
+	if t.With.Host == "" {
+		return errors.New("email task requires an SMTP host")
+	}
+	if t.With.From == "" || len(t.With.To) == 0 {
+		return errors.New("email task requires sender and recipient addresses")
+	}
+	if t.With.TLS != "" && t.With.TLS != StartTLSMode && t.With.TLS != TLSMode && t.With.TLS != NoTLSMode {
+		return fmt.Errorf("invalid TLS mode %v; must be one of %v, %v, or %v", t.With.TLS, StartTLSMode, TLSMode, NoTLSMode)
+	}
+	return nil
+}
+
+// password returns the SMTP password from the environment or from a file, and registers it to be redacted from logs
+func (t *emailTask) password() (string, error) {
+	password := ""
+	if t.With.PasswordEnv != "" {
+		password = os.Getenv(t.With.PasswordEnv)
+	} else if t.With.PasswordFile != "" {
+		b, err := ioutil.ReadFile(t.With.PasswordFile)
+		if err != nil {
+			e := errors.New("unable to read SMTP password file")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return "", e
+		}
+		password = strings.TrimSpace(string(b))
+	}
+	log.AddSecret(password)
+	return password, nil
+}
+
+// experimentStatus returns a short description of the experiment status
+func experimentStatus(exp *Experiment) string {
+	switch {
+	case !exp.NoFailure():
+		return "failed"
+	case exp.Aborted():
+		return "aborted"
+	case exp.Result.Insights != nil && !exp.SLOs():
+		return "did not satisfy SLOs"
+	default:
+		return "succeeded"
+	}
+}
+
+// body renders the email body
+func (t *emailTask) body(exp *Experiment) (string, error) {
+	if render, ok := reportRenderers[t.With.Format]; ok {
+		return render(exp)
+	}
+	if t.With.Format != defaultEmailFormat {
+		return "", fmt.Errorf("unsupported report format %v", t.With.Format)
+	}
+	// fall back to a summary if no text renderer is registered
+	return fmt.Sprintf("Experiment %v\nNumber of completed tasks: %v\n",
+		experimentStatus(exp), exp.Result.NumCompletedTasks), nil
+}
+
+// message composes the email message
+func (t *emailTask) message(exp *Experiment) ([]byte, error) {
+	body, err := t.body(exp)
+	if err != nil {
+		return nil, err
+	}
+	subject := t.With.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("Iter8 experiment %v", experimentStatus(exp))
+	}
+	contentType := "text/plain"
+	if t.With.Format == "html" {
+		contentType = "text/html"
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", t.With.From)
+	fmt.Fprintf(&b, "To: %v\r\n", strings.Join(t.With.To, ", "))
+	fmt.Fprintf(&b, "Subject: %v\r\n", subject)
+	fmt.Fprintf(&b, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprint(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: %v; charset=UTF-8\r\n", contentType)
+	fmt.Fprint(&b, "\r\n")
+	fmt.Fprint(&b, strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+// send delivers the message using the SMTP server
+func (t *emailTask) send(msg []byte) error {
+	timeout, err := time.ParseDuration(*t.With.Timeout)
+	if err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(t.With.Host, strconv.Itoa(t.With.Port))
+	tlsConfig := &tls.Config{ServerName: t.With.Host}
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	if t.With.TLS == TLSMode {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, t.With.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if t.With.TLS == StartTLSMode {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if t.With.Username != "" {
+		password, err := t.password()
+		if err != nil {
+			return err
+		}
+		if err = c.Auth(smtp.PlainAuth("", t.With.Username, password, t.With.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(t.With.From); err != nil {
+		return err
+	}
+	for _, to := range t.With.To {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// run executes this task
+func (t *emailTask) run(exp *Experiment) error {
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	t.initializeDefaults()
+
+	msg, err := t.message(exp)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to compose email")
+		return err
+	}
+	if err = t.send(msg); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to send email")
+		return err
+	}
+	log.Logger.Infof("emailed experiment report to %v", strings.Join(t.With.To, ", "))
+	return nil
+}
@@ -0,0 +1,187 @@
+package base
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer is a minimal SMTP server that records the messages it receives
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	auth     []string
+	messages []string
+}
+
+// startFakeSMTPServer starts a fake SMTP server on a random local port
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &fakeSMTPServer{listener: l}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+// port returns the port of the fake SMTP server
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// handle an SMTP session
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	w("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			w("250-localhost")
+			w("250 AUTH PLAIN")
+		case strings.HasPrefix(cmd, "AUTH"):
+			s.mu.Lock()
+			s.auth = append(s.auth, strings.TrimSpace(line))
+			s.mu.Unlock()
+			w("235 authenticated")
+		case cmd == "DATA":
+			w("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg.String())
+			s.mu.Unlock()
+			w("250 ok")
+		case cmd == "QUIT":
+			w("221 bye")
+			return
+		default:
+			w("250 ok")
+		}
+	}
+}
+
+func TestEmailTask(t *testing.T) {
+	s := startFakeSMTPServer(t)
+	os.Setenv("SMTP_PASSWORD", "secret")
+	defer os.Unsetenv("SMTP_PASSWORD")
+
+	et := &emailTask{
+		TaskMeta: TaskMeta{Task: StringPointer(EmailTaskName)},
+		With: emailInputs{
+			Host:        "127.0.0.1",
+			Port:        s.port(),
+			TLS:         NoTLSMode,
+			From:        "iter8@example.com",
+			To:          []string{"a@example.com", "b@example.com"},
+			Username:    "iter8",
+			PasswordEnv: "SMTP_PASSWORD",
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{et},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	err := et.run(exp)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(s.messages))
+	assert.Equal(t, 1, len(s.auth))
+	assert.Contains(t, s.messages[0], "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, s.messages[0], "Subject: Iter8 experiment succeeded\r\n")
+	assert.Contains(t, s.messages[0], "Content-Type: text/plain; charset=UTF-8\r\n")
+	// the password is redacted from logs
+	assert.Equal(t, "password "+log.Redacted, log.Redact("password secret"))
+}
+
+func TestEmailAfterFailure(t *testing.T) {
+	os.Chdir(t.TempDir())
+	s := startFakeSMTPServer(t)
+
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo skipped")}},
+			&emailTask{
+				TaskMeta: TaskMeta{Task: StringPointer(EmailTaskName)},
+				With: emailInputs{
+					Host:    "127.0.0.1",
+					Port:    s.port(),
+					TLS:     NoTLSMode,
+					From:    "iter8@example.com",
+					To:      []string{"a@example.com"},
+					Subject: "experiment alert",
+				},
+			},
+		},
+	}
+	err := RunExperiment(false, &mockDriver{exp})
+	assert.Error(t, err)
+	assert.False(t, exp.NoFailure())
+	assert.Equal(t, 0, exp.Result.NumCompletedTasks)
+
+	// email is sent even though an earlier task failed
+	assert.Equal(t, 1, len(s.messages))
+	assert.Contains(t, s.messages[0], "Subject: experiment alert\r\n")
+	assert.Contains(t, s.messages[0], "Experiment failed")
+}
+
+func TestEmailInvalidInputs(t *testing.T) {
+	et := &emailTask{}
+	assert.Error(t, et.validateInputs())
+
+	et.With = emailInputs{Host: "smtp.example.com", From: "iter8@example.com"}
+	assert.Error(t, et.validateInputs())
+
+	et.With.To = []string{"a@example.com"}
+	assert.NoError(t, et.validateInputs())
+
+	et.With.TLS = "ssl"
+	assert.Error(t, et.validateInputs())
+
+	et.With.TLS = ""
+	et.With.Format = "pdf"
+	et.initializeDefaults()
+	_, err := et.message(&Experiment{Result: &ExperimentResult{}})
+	assert.Error(t, err)
+}
+
+func TestEmailTaskUnmarshal(t *testing.T) {
+	s := ExperimentSpec{}
+	err := s.UnmarshalJSON([]byte(`[{"task": "email", "with": {"host": "smtp.example.com", "from": "a@b.c", "to": ["x@y.z"]}}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(s))
+	et, ok := s[0].(*emailTask)
+	assert.True(t, ok)
+	assert.Equal(t, "smtp.example.com", et.With.Host)
+}
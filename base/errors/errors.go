@@ -0,0 +1,123 @@
+// Package errors defines the kinds of errors returned by Iter8, so that callers can branch on them using errors.Is,
+// along with hints that tell users how to remediate them.
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors returned by Iter8
+var (
+	// ErrChartNotFound indicates that an experiment chart is not present locally
+	ErrChartNotFound = errors.New("chart not found")
+	// ErrChartDownloadFailed indicates that experiment charts could not be downloaded
+	ErrChartDownloadFailed = errors.New("chart download failed")
+	// ErrExperimentNotFound indicates that the experiment could not be found
+	ErrExperimentNotFound = errors.New("experiment not found")
+	// ErrInvalidExperiment indicates that the experiment could not be parsed or is invalid
+	ErrInvalidExperiment = errors.New("invalid experiment")
+	// ErrClusterUnavailable indicates that the Kubernetes cluster could not be reached
+	ErrClusterUnavailable = errors.New("cluster unavailable")
+	// ErrMetricsBackendUnavailable indicates that metrics could not be queried from any metrics provider
+	ErrMetricsBackendUnavailable = errors.New("metrics backend unavailable")
+	// ErrTaskFailed indicates that a task in the experiment failed
+	ErrTaskFailed = errors.New("task failed")
+	// ErrSLOViolated indicates that the experiment completed but its SLOs are not satisfied
+	ErrSLOViolated = errors.New("SLO violated")
+	// ErrTimeout indicates that the experiment did not complete before the timeout
+	ErrTimeout = errors.New("timeout")
+)
+
+// kinds are the kinds of errors, along with their default remediation hints
+var kinds = []struct {
+	kind error
+	hint string
+}{
+	{ErrChartNotFound, "check the chart name; use 'iter8 hub list' to list the charts in the remote folder, and ensure that charts are downloaded, or present under the charts parent dir when the noDownload option is used"},
+	{ErrChartDownloadFailed, "check the remoteFolderURL and network access to it; use the refresh option if the cached charts are outdated"},
+	{ErrExperimentNotFound, "check that the experiment was launched; for Kubernetes experiments, use 'iter8 k list' to list experiment groups and their namespaces"},
+	{ErrInvalidExperiment, "use 'iter8 lint' to validate the experiment"},
+	{ErrClusterUnavailable, "check the kubeconfig and its current context; for example, using 'kubectl cluster-info'"},
+	{ErrMetricsBackendUnavailable, "check the provider URLs and the credentials of the metrics backend; throttled queries can be retried using the query options of the task"},
+	{ErrTaskFailed, "see the logs of the experiment, or use 'iter8 k log' for Kubernetes experiments, to find why the task failed; tasks can be retried using their retries option"},
+	{ErrSLOViolated, "use 'iter8 report' or 'iter8 k report' to see the metrics and SLOs of each version"},
+	{ErrTimeout, "increase the timeout of the assert conditions, or check why the experiment is not progressing using 'iter8 k log'"},
+}
+
+// Error is an error of a given kind, with a message for users, and an optional remediation hint
+type Error struct {
+	// Kind is the kind of the error, such as ErrChartNotFound
+	Kind error
+	// Msg is the message of the error
+	Msg string
+	// Hint tells users how to remediate the error; the hint of the kind is used if it is empty
+	Hint string
+	// Err is the underlying error, if any
+	Err error
+}
+
+// New returns an error of the given kind, with the formatted message
+func New(kind error, format string, a ...interface{}) *Error {
+	return &Error{
+		Kind: kind,
+		Msg:  fmt.Sprintf(format, a...),
+	}
+}
+
+// Wrap returns an error of the given kind that wraps the underlying error, with the formatted message
+func Wrap(kind error, err error, format string, a ...interface{}) *Error {
+	e := New(kind, format, a...)
+	e.Err = err
+	return e
+}
+
+// WithHint sets the remediation hint of the error, which replaces the hint of its kind
+func (e *Error) WithHint(format string, a ...interface{}) *Error {
+	e.Hint = fmt.Sprintf(format, a...)
+	return e
+}
+
+// Error returns the message of the error. The underlying error is not part of the message;
+// it is logged along with the stack trace where the error occurs.
+func (e *Error) Error() string {
+	if e.Msg == "" {
+		return e.Kind.Error()
+	}
+	return e.Msg
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is the kind of the error
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Hint returns the remediation hint for the error; empty if there is none.
+// The hint of the outermost error in the chain that has a hint, or is of a known kind, is used.
+func Hint(err error) string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if ie, ok := e.(*Error); ok && ie.Hint != "" {
+			return ie.Hint
+		}
+		for _, k := range kinds {
+			if isKind(e, k.kind) {
+				return k.hint
+			}
+		}
+	}
+	return ""
+}
+
+// isKind returns true if the error, excluding the errors it wraps, is of the given kind
+func isKind(err error, kind error) bool {
+	if err == kind {
+		return true
+	}
+	x, ok := err.(interface{ Is(error) bool })
+	return ok && x.Is(kind)
+}
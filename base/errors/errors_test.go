@@ -0,0 +1,54 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	cause := &os.PathError{Op: "open", Path: "charts/iter8", Err: os.ErrNotExist}
+	e := Wrap(ErrChartNotFound, cause, "chart %v not found", "iter8")
+	assert.Equal(t, "chart iter8 not found", e.Error())
+	assert.True(t, errors.Is(e, ErrChartNotFound))
+	assert.False(t, errors.Is(e, ErrChartDownloadFailed))
+	// the underlying error is wrapped
+	assert.True(t, errors.Is(e, os.ErrNotExist))
+
+	// kinds are preserved when errors are wrapped further
+	wrapped := fmt.Errorf("launch failed: %w", e)
+	assert.True(t, errors.Is(wrapped, ErrChartNotFound))
+	var ie *Error
+	assert.True(t, errors.As(wrapped, &ie))
+	assert.Equal(t, ErrChartNotFound, ie.Kind)
+
+	// an error without a message uses the message of its kind
+	assert.Equal(t, "SLO violated", (&Error{Kind: ErrSLOViolated}).Error())
+}
+
+func TestHint(t *testing.T) {
+	assert.Empty(t, Hint(nil))
+	assert.Empty(t, Hint(errors.New("unable to read experiment")))
+
+	// the hint of the kind is used by default
+	e := New(ErrMetricsBackendUnavailable, "unable to get metrics specs of any provider")
+	assert.Contains(t, Hint(e), "provider URLs")
+	assert.Contains(t, Hint(fmt.Errorf("task 2: %w", e)), "provider URLs")
+	assert.Contains(t, Hint(ErrSLOViolated), "report")
+
+	// a specific hint takes precedence over the hint of the kind
+	e = New(ErrExperimentNotFound, "unable to read experiment").WithHint("check the run directory %v", "/tmp/run")
+	assert.Equal(t, "check the run directory /tmp/run", Hint(e))
+
+	// the hint of the outermost kind is used
+	e = Wrap(ErrChartDownloadFailed, New(ErrClusterUnavailable, "unreachable"), "unable to download charts")
+	assert.Contains(t, Hint(e), "remoteFolderURL")
+
+	// every kind has a hint
+	for _, k := range kinds {
+		assert.NotEmpty(t, Hint(k.kind), k.kind.Error())
+	}
+}
@@ -0,0 +1,75 @@
+package base
+
+import (
+	"fmt"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// EventReasonStarted is the reason of the event recorded when an experiment starts
+	EventReasonStarted = "ExperimentStarted"
+	// EventReasonTaskCompleted is the reason of the event recorded when a task completes
+	EventReasonTaskCompleted = "TaskCompleted"
+	// EventReasonTaskFailed is the reason of the event recorded when a task fails
+	EventReasonTaskFailed = "TaskFailed"
+	// EventReasonSLOViolation is the reason of the event recorded when an assessment finds versions that do not satisfy SLOs
+	EventReasonSLOViolation = "SLOViolation"
+	// EventReasonLoopCompleted is the reason of the event recorded when a loop of an experiment with more loops completes
+	EventReasonLoopCompleted = "LoopCompleted"
+	// EventReasonCompleted is the reason of the event recorded when an experiment completes
+	EventReasonCompleted = "ExperimentCompleted"
+)
+
+// ExperimentEvent is a milestone in the progress of an experiment
+type ExperimentEvent struct {
+	// Warning is true for failures and SLO violations
+	Warning bool
+	// Reason is a short, machine readable description of the milestone; for example, TaskFailed
+	Reason string
+	// Message is a human readable description of the milestone
+	Message string
+}
+
+// EventRecorder is implemented by drivers that record the milestones of experiments;
+// for example, as Kubernetes events of the object that stores the experiment
+type EventRecorder interface {
+	// RecordEvent records the milestone of the running experiment
+	RecordEvent(e ExperimentEvent) error
+}
+
+// recordEvent records the milestone using the driver of the experiment, if it is an event recorder.
+// Failure to record the event does not fail the experiment.
+func (exp *Experiment) recordEvent(warning bool, reason string, format string, a ...interface{}) {
+	er, ok := exp.driver.(EventRecorder)
+	if !ok {
+		return
+	}
+	if err := er.RecordEvent(ExperimentEvent{
+		Warning: warning,
+		Reason:  reason,
+		Message: fmt.Sprintf(format, a...),
+	}); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warnf("unable to record event %v", reason)
+	}
+}
+
+// recordSLOViolation records an SLO violation if the task assessed SLOs, and some versions do not satisfy them
+func (exp *Experiment) recordSLOViolation(t Task) {
+	if _, ok := t.(*assessTask); !ok || exp.Result.Insights == nil || exp.Result.Insights.SLOs == nil {
+		return
+	}
+	satisfied := map[int]bool{}
+	for _, j := range exp.getSLOsSatisfiedBy() {
+		satisfied[j] = true
+	}
+	violated := []int{}
+	for j := 0; j < exp.Result.Insights.NumVersions; j++ {
+		if !satisfied[j] {
+			violated = append(violated, j)
+		}
+	}
+	if len(violated) > 0 {
+		exp.recordEvent(true, EventReasonSLOViolation, "versions %v do not satisfy SLOs", violated)
+	}
+}
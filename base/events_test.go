@@ -0,0 +1,66 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eventsDriver is a mock driver that records the events of experiments
+type eventsDriver struct {
+	mockDriver
+	events []ExperimentEvent
+}
+
+// RecordEvent records the event
+func (d *eventsDriver) RecordEvent(e ExperimentEvent) error {
+	d.events = append(d.events, e)
+	return nil
+}
+
+// reasons returns the reasons of the recorded events
+func (d *eventsDriver) reasons() []string {
+	reasons := []string{}
+	for _, e := range d.events {
+		reasons = append(reasons, e.Reason)
+	}
+	return reasons
+}
+
+func TestRecordEvents(t *testing.T) {
+	os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo first")}},
+			&assessTask{
+				TaskMeta: TaskMeta{Task: StringPointer(AssessTaskName)},
+				With: assessInputs{
+					SLOs: &SLOLimits{Upper: []SLO{{Metric: "a/latency", Limit: 100}}},
+				},
+			},
+		},
+	}
+	exp.initResults(1)
+	exp.Result.initInsightsWithNumVersions(2)
+	exp.Result.Insights.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 0, 50.0)
+	exp.Result.Insights.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 1, 150.0)
+	d := &eventsDriver{mockDriver: mockDriver{exp}}
+	assert.NoError(t, RunExperiment(true, d))
+	assert.Equal(t, []string{EventReasonStarted, EventReasonTaskCompleted, EventReasonTaskCompleted, EventReasonSLOViolation, EventReasonCompleted}, d.reasons())
+	assert.Equal(t, "versions [1] do not satisfy SLOs", d.events[3].Message)
+	assert.True(t, d.events[3].Warning)
+	assert.False(t, d.events[4].Warning)
+
+	// failures are warnings
+	exp = &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}},
+		},
+	}
+	d = &eventsDriver{mockDriver: mockDriver{exp}}
+	assert.Error(t, RunExperiment(false, d))
+	assert.Equal(t, []string{EventReasonStarted, EventReasonTaskFailed}, d.reasons())
+	assert.True(t, d.events[1].Warning)
+	assert.Contains(t, d.events[1].Message, "task 1: run failed")
+}
@@ -1,15 +1,19 @@
 package base
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/antonmedv/expr"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	log "github.com/iter8-tools/iter8/base/log"
 	"github.com/montanaflynn/stats"
 	"helm.sh/helm/v3/pkg/time"
@@ -36,12 +40,35 @@ type Experiment struct {
 	// Spec is the sequence of tasks that constitute this experiment
 	Spec ExperimentSpec `json:"spec" yaml:"spec"`
 
+	// Background tasks run while the experiment runs. Optional.
+	Background []BackgroundTask `json:"background,omitempty" yaml:"background,omitempty"`
+
+	// Hooks are tasks that run when a loop of this experiment starts, succeeds, or fails. Optional.
+	Hooks *Hooks `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+
+	// Loop configures the loops of this experiment. Optional.
+	// If unspecified, the experiment runs its tasks once; it may be looped by its runner, such as a cronjob
+	Loop *LoopSpec `json:"loop,omitempty" yaml:"loop,omitempty"`
+
+	// Metrics configures how this experiment records metrics. Optional.
+	Metrics *MetricsRecording `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+
 	// Result is the current results from this experiment.
 	// The experiment may not have completed in which case results may be partial.
 	Result *ExperimentResult `json:"result" yaml:"result"`
 
 	// driver enables interacting with experiment result stored externally
 	driver Driver
+
+	// interrupt is closed when the experiment run is interrupted by a signal
+	interrupt chan struct{}
+
+	// ctx is the context of task runs; it is done when the experiment run is interrupted,
+	// or when the attempt of the task being run times out. Nil if task runs are not cancelled.
+	ctx context.Context
+
+	// branch is the number of the parallel branch run by this experiment; zero if it is not a branch
+	branch int
 }
 
 // ExperimentResult defines the current results from the experiment
@@ -61,11 +88,43 @@ type ExperimentResult struct {
 	// Failure is true if any of its tasks failed
 	Failure bool `json:"failure" yaml:"failure"`
 
+	// Aborted is true if the experiment was stopped before completing its tasks
+	Aborted bool `json:"aborted,omitempty" yaml:"aborted,omitempty"`
+
+	// Interrupted is true if the experiment run was stopped by a signal, such as SIGTERM sent to a preempted pod.
+	// Metrics collected by the interrupted task before the signal are retained.
+	Interrupted bool `json:"interrupted,omitempty" yaml:"interrupted,omitempty"`
+
+	// Checkpoint records the progress of a load test that has not completed, so that a resumed run continues it
+	Checkpoint *TaskCheckpoint `json:"checkpoint,omitempty" yaml:"checkpoint,omitempty"`
+
+	// Loop is the loop schedule of experiments with a loop spec
+	Loop *LoopStatus `json:"loop,omitempty" yaml:"loop,omitempty"`
+
 	// Insights produced in this experiment
 	Insights *Insights `json:"insights,omitempty" yaml:"insights,omitempty"`
 
+	// Outputs published by tasks; later tasks may use them in templates of their inputs, such as {{ .Outputs.token }}
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+
 	// Iter8Version is the version of Iter8 CLI that created this result object
 	Iter8Version string `json:"iter8Version" yaml:"iter8Version"`
+
+	// sketchAccuracy is the relative accuracy of sketches of sample metrics in new insights; zero if observations are retained
+	sketchAccuracy float64
+
+	// pendingMetrics are gauges recorded before insights were initialized; they are recorded for every version once they are
+	pendingMetrics []pendingMetric
+}
+
+// pendingMetric is a gauge that applies to every version, recorded before the number of versions is known
+type pendingMetric struct {
+	// name of the metric
+	name string
+	// meta is the metadata of the metric
+	meta MetricMeta
+	// value of the metric
+	value float64
 }
 
 // Insights records the number of versions in this experiment,
@@ -92,11 +151,31 @@ type Insights struct {
 	// the inner slice contains the list of all observed histogram buckets for a given version and given metric; value [i]["foo/bar"][k] is the [k]th observed bucket for version [i] for the hist metric `bar` under backend `foo`.
 	HistMetricValues []map[string][]HistBucket `json:"histMetricValues,omitempty" yaml:"histMetricValues,omitempty"`
 
+	// SampleSketches:
+	// the outer slice must be the same length as the number of app versions
+	// the map key must match name of a sample metric in MetricsInfo
+	// the sketch aggregates all observed values for a given version and given sample metric, when sample metrics are streamed;
+	// sample metrics are then not in NonHistMetricValues
+	SampleSketches []map[string]*SampleSketch `json:"sampleSketches,omitempty" yaml:"sampleSketches,omitempty"`
+
 	// SLOs involved in this experiment
 	SLOs *SLOLimits `json:"SLOs,omitempty" yaml:"SLOs,omitempty"`
 
 	// SLOsSatisfied indicator matrices that show if upper and lower SLO limits are satisfied
 	SLOsSatisfied *SLOResults `json:"SLOsSatisfied,omitempty" yaml:"SLOsSatisfied,omitempty"`
+
+	// MissingMetrics lists the SLOs whose metrics had no value for a version in the latest assessment, and how they were treated
+	MissingMetrics []MissingMetric `json:"missingMetrics,omitempty" yaml:"missingMetrics,omitempty"`
+
+	// Rewards are the metrics that are optimized by versions that satisfy SLOs
+	Rewards *Rewards `json:"rewards,omitempty" yaml:"rewards,omitempty"`
+
+	// Weights are the recommended traffic weights of versions, as percentages, from the latest assessment;
+	// the best version that satisfies SLOs is recommended more traffic, for progressive rollouts
+	Weights []int32 `json:"weights,omitempty" yaml:"weights,omitempty"`
+
+	// sketchAccuracy is the relative accuracy of new sketches of sample metrics
+	sketchAccuracy float64
 }
 
 // MetricMeta describes a metric
@@ -116,6 +195,32 @@ type SLO struct {
 
 	// Limit is the acceptable limit for this metric
 	Limit float64 `json:"limit" yaml:"limit"`
+
+	// OnMissing is the treatment of this SLO when its metric has no value for a version;
+	// unsatisfied, fail, or skip. Optional; defaults to the treatment of the assess task.
+	OnMissing MissingMetricPolicy `json:"onMissing,omitempty" yaml:"onMissing,omitempty"`
+}
+
+// MissingMetricPolicy is the treatment of SLOs whose metrics have no value for a version
+type MissingMetricPolicy string
+
+const (
+	// MissingMetricUnsatisfied marks the SLO as unsatisfied by the version
+	MissingMetricUnsatisfied MissingMetricPolicy = "unsatisfied"
+	// MissingMetricFail fails the assess task, and hence the experiment
+	MissingMetricFail MissingMetricPolicy = "fail"
+	// MissingMetricSkip does not evaluate the SLO for the version, which does not prevent the version from satisfying SLOs
+	MissingMetricSkip MissingMetricPolicy = "skip"
+)
+
+// MissingMetric records an SLO whose metric had no value for a version
+type MissingMetric struct {
+	// Metric of the SLO
+	Metric string `json:"metric" yaml:"metric"`
+	// Version for which the metric had no value
+	Version int `json:"version" yaml:"version"`
+	// Treatment of the SLO
+	Treatment MissingMetricPolicy `json:"treatment" yaml:"treatment"`
 }
 
 // SLOLimits specify upper or lower limits for metrics
@@ -147,8 +252,42 @@ type TaskMeta struct {
 	Run *string `json:"run,omitempty" yaml:"run,omitempty"`
 	// If is the condition used to determine if this task needs to run
 	// If the condition is not satisfied, then it is skipped in an experiment
+	// Conditions may use SLOs(), SLOsBy(version), Completed(), NoFailure(), Metric(name, version), Winner(), and NumLoops()
 	// Example: SLOs()
 	If *string `json:"if,omitempty" yaml:"if,omitempty"`
+	// Timeout is the time limit for each attempt to run this task; for example, 5m. Optional.
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Retries is the number of times this task is retried after it fails. Optional.
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// Backoff is the time to wait before the first retry; it doubles after each retry. Optional. Default is 1s
+	Backoff *string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	// ContinueOnError continues the experiment even if this task fails. Optional.
+	ContinueOnError bool `json:"continueOnError,omitempty" yaml:"continueOnError,omitempty"`
+}
+
+// TaskError is returned when a task fails during an experiment run
+type TaskError struct {
+	// Index of the task in the experiment spec, starting at 1
+	Index int
+	// Task is the name of the task
+	Task string
+	// Err is the error returned by the task
+	Err error
+}
+
+// Error returns the error returned by the task
+func (e *TaskError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error returned by the task
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is ErrTaskFailed
+func (e *TaskError) Is(target error) bool {
+	return target == ierrors.ErrTaskFailed
 }
 
 // taskMetaWith enables unmarshaling of tasks
@@ -192,6 +331,24 @@ func (s *ExperimentSpec) UnmarshalJSON(data []byte) error {
 				rt := &readinessTask{}
 				json.Unmarshal(tBytes, rt)
 				tsk = rt
+			case DiscoveryTaskName:
+				dt := &discoveryTask{}
+				err := json.Unmarshal(tBytes, dt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = dt
+			case CompareTaskName:
+				ct := &compareTask{}
+				err := json.Unmarshal(tBytes, ct)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = ct
 			case CustomMetricsTaskName:
 				cdt := &customMetricsTask{}
 				err := json.Unmarshal(tBytes, cdt)
@@ -219,6 +376,132 @@ func (s *ExperimentSpec) UnmarshalJSON(data []byte) error {
 					return e
 				}
 				tsk = cgt
+			case EmailTaskName:
+				et := &emailTask{}
+				err := json.Unmarshal(tBytes, et)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = et
+			case AnnotateTaskName:
+				at := &annotateTask{}
+				err := json.Unmarshal(tBytes, at)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = at
+			case GatewayTaskName:
+				gt := &gatewayTask{}
+				err := json.Unmarshal(tBytes, gt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = gt
+			case HelmTaskName:
+				ht := &helmTask{}
+				err := json.Unmarshal(tBytes, ht)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = ht
+			case IstioTaskName:
+				it := &istioTask{}
+				err := json.Unmarshal(tBytes, it)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = it
+			case KServeTaskName:
+				kt := &kserveTask{}
+				err := json.Unmarshal(tBytes, kt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = kt
+			case SeldonTaskName:
+				st := &seldonTask{}
+				err := json.Unmarshal(tBytes, st)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = st
+			case KnativeTaskName:
+				kt := &knativeTask{}
+				err := json.Unmarshal(tBytes, kt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = kt
+			case LinkerdTaskName:
+				lt := &linkerdTask{}
+				err := json.Unmarshal(tBytes, lt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = lt
+			case ManifestsTaskName:
+				mt := &manifestsTask{}
+				err := json.Unmarshal(tBytes, mt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = mt
+			case ParallelTaskName:
+				pt := &parallelTask{}
+				err := json.Unmarshal(tBytes, pt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = pt
+			case IngestTaskName:
+				it := &ingestTask{}
+				err := json.Unmarshal(tBytes, it)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = it
+			case LabelTaskName:
+				lt := &labelTask{}
+				err := json.Unmarshal(tBytes, lt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = lt
+			case SoakTaskName:
+				st := &soakTask{}
+				err := json.Unmarshal(tBytes, st)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = st
 			case AssessTaskName:
 				at := &assessTask{}
 				err := json.Unmarshal(tBytes, at)
@@ -229,8 +512,16 @@ func (s *ExperimentSpec) UnmarshalJSON(data []byte) error {
 				}
 				tsk = at
 			default:
-				log.Logger.Error("unknown task: " + *t.Task)
-				return errors.New("unknown task: " + *t.Task)
+				// tasks that are not built in may be implemented by plugins;
+				// plugins are looked up when tasks are validated or run, so that experiments can be read without them
+				pt := &pluginTask{}
+				err := json.Unmarshal(tBytes, pt)
+				if err != nil {
+					e := errors.New("json unmarshal error")
+					log.Logger.WithStackTrace(err.Error()).Error(e)
+					return e
+				}
+				tsk = pt
 			}
 		}
 		n := append(*s, tsk)
@@ -273,11 +564,33 @@ func (in *Insights) updateMetricValueScalar(m string, i int, val float64) {
 	in.NonHistMetricValues[i][m] = append(in.NonHistMetricValues[i][m], val)
 }
 
-// updateMetricValueVector updates a vector metric value for a given version
+// updateMetricValueVector updates a vector metric value for a given version;
+// if sample metrics are streamed, the values are added to the sketch of the metric
 func (in *Insights) updateMetricValueVector(m string, i int, val []float64) {
+	if in.SampleSketches != nil {
+		s, ok := in.SampleSketches[i][m]
+		if !ok {
+			accuracy := in.sketchAccuracy
+			if accuracy <= 0 {
+				accuracy = defaultSketchAccuracy
+			}
+			s = newSampleSketch(accuracy)
+			in.SampleSketches[i][m] = s
+		}
+		s.add(val...)
+		return
+	}
 	in.NonHistMetricValues[i][m] = append(in.NonHistMetricValues[i][m], val...)
 }
 
+// SampleSketch returns the sketch of the given sample metric for the given version; nil if the metric is not streamed
+func (in *Insights) SampleSketch(i int, m string) *SampleSketch {
+	if i >= len(in.SampleSketches) {
+		return nil
+	}
+	return in.SampleSketches[i][m]
+}
+
 // updateMetricValueHist updates a histogram metric value for a given version
 func (in *Insights) updateMetricValueHist(m string, i int, val []HistBucket) {
 	in.HistMetricValues[i][m] = append(in.HistMetricValues[i][m], val...)
@@ -402,7 +715,32 @@ func (r *ExperimentResult) initInsightsWithNumVersions(n int) error {
 			NumVersions: n,
 		}
 	}
+	r.Insights.sketchAccuracy = r.sketchAccuracy
 	r.Insights.initMetrics()
+	return r.flushPendingMetrics()
+}
+
+// recordGauge records a gauge that applies to every version, such as the time spent waiting for readiness.
+// If insights are not yet initialized, the gauge is recorded once they are, since the number of versions is not known until then.
+func (r *ExperimentResult) recordGauge(m string, mm MetricMeta, val float64) error {
+	r.pendingMetrics = append(r.pendingMetrics, pendingMetric{name: m, meta: mm, value: val})
+	if r.Insights == nil {
+		return nil
+	}
+	return r.flushPendingMetrics()
+}
+
+// flushPendingMetrics records pending gauges for every version
+func (r *ExperimentResult) flushPendingMetrics() error {
+	pending := r.pendingMetrics
+	r.pendingMetrics = nil
+	for _, pm := range pending {
+		for i := 0; i < r.Insights.NumVersions; i++ {
+			if err := r.Insights.updateMetric(pm.name, pm.meta, i, pm.value); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -414,8 +752,12 @@ func (in *Insights) initMetrics() error {
 			log.Logger.Error(err)
 			return err
 		}
-		if len(in.NonHistMetricValues[0])+len(in.HistMetricValues[0]) != len(in.MetricsInfo) {
-			err := fmt.Errorf("inconsistent number for metrics in non hist metric values (%v), hist metric values (%v), metrics info (%v)", len(in.NonHistMetricValues[0]), len(in.HistMetricValues[0]), len(in.MetricsInfo))
+		numSketches := 0
+		if len(in.SampleSketches) > 0 {
+			numSketches = len(in.SampleSketches[0])
+		}
+		if len(in.NonHistMetricValues[0])+len(in.HistMetricValues[0])+numSketches != len(in.MetricsInfo) {
+			err := fmt.Errorf("inconsistent number for metrics in non hist metric values (%v), hist metric values (%v), sample sketches (%v), metrics info (%v)", len(in.NonHistMetricValues[0]), len(in.HistMetricValues[0]), numSketches, len(in.MetricsInfo))
 			log.Logger.Error(err)
 			return err
 		}
@@ -432,9 +774,21 @@ func (in *Insights) initMetrics() error {
 		in.NonHistMetricValues[i] = make(map[string][]float64)
 		in.HistMetricValues[i] = make(map[string][]HistBucket)
 	}
+	// initialize sample sketches for each version, if sample metrics are streamed
+	if in.sketchAccuracy > 0 {
+		in.initSampleSketches()
+	}
 	return nil
 }
 
+// initSampleSketches initializes the sketches of sample metrics for each version
+func (in *Insights) initSampleSketches() {
+	in.SampleSketches = make([]map[string]*SampleSketch, in.NumVersions)
+	for i := 0; i < in.NumVersions; i++ {
+		in.SampleSketches[i] = make(map[string]*SampleSketch)
+	}
+}
+
 // getCounterOrGaugeMetricFromValuesMap gets the value of the given counter or gauge metric, for the given version, from metric values map
 func (in *Insights) getCounterOrGaugeMetricFromValuesMap(i int, m string) *float64 {
 	if mm, ok := in.MetricsInfo[m]; ok {
@@ -464,6 +818,9 @@ func (in *Insights) getCounterOrGaugeMetricFromValuesMap(i int, m string) *float
 
 // getSampleAggregation aggregates the given base metric for the given version (i) with the given aggregation (a)
 func (in *Insights) getSampleAggregation(i int, baseMetric string, a string) *float64 {
+	if s := in.SampleSketch(i, baseMetric); s != nil {
+		return s.aggregate(i, baseMetric, a)
+	}
 	at := AggregationType(a)
 	vals := in.NonHistMetricValues[i][baseMetric]
 	if len(vals) == 0 {
@@ -663,6 +1020,12 @@ type Driver interface {
 	GetRevision() int
 }
 
+// AbortChecker is implemented by drivers through which a running experiment can be asked to stop
+type AbortChecker interface {
+	// AbortRequested returns true if the experiment has been asked to stop
+	AbortRequested() bool
+}
+
 // Completed returns true if the experiment is complete
 func (exp *Experiment) Completed() bool {
 	if exp != nil {
@@ -680,6 +1043,16 @@ func (exp *Experiment) NoFailure() bool {
 	return exp != nil && exp.Result != nil && !exp.Result.Failure
 }
 
+// Aborted returns true if the experiment was stopped before completing its tasks
+func (exp *Experiment) Aborted() bool {
+	return exp != nil && exp.Result != nil && exp.Result.Aborted
+}
+
+// Interrupted returns true if the experiment run was stopped by a signal
+func (exp *Experiment) Interrupted() bool {
+	return exp != nil && exp.Result != nil && exp.Result.Interrupted
+}
+
 // getSLOsSatisfiedBy returns the set of versions which satisfy SLOs
 func (exp *Experiment) getSLOsSatisfiedBy() []int {
 	if exp == nil {
@@ -742,8 +1115,73 @@ func (exp *Experiment) SLOs() bool {
 	return exp.Result.Insights.NumVersions == len(sby)
 }
 
+// SLOsBy returns true if the given app version satisfies all SLOs
+func (exp *Experiment) SLOsBy(version int) bool {
+	for _, j := range exp.getSLOsSatisfiedBy() {
+		if j == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Metric returns the value of the given scalar metric for the given app version.
+// The value is NaN if the metric is unavailable, so that comparisons with it are false.
+func (exp *Experiment) Metric(name string, version int) float64 {
+	if exp == nil || exp.Result == nil || exp.Result.Insights == nil {
+		return math.NaN()
+	}
+	if version < 0 || version >= exp.Result.Insights.NumVersions {
+		return math.NaN()
+	}
+	v := exp.Result.Insights.ScalarMetricValue(version, name)
+	if v == nil {
+		return math.NaN()
+	}
+	return *v
+}
+
+// Winner returns the latest app version that satisfies SLOs, or -1 if no version satisfies SLOs.
+// Versions are ordered with the baseline first, so the winner is the newest candidate that satisfies SLOs.
+func (exp *Experiment) Winner() int {
+	sby := exp.getSLOsSatisfiedBy()
+	if len(sby) == 0 {
+		return -1
+	}
+	return sby[len(sby)-1]
+}
+
+// NumLoops returns the number of loops that the experiment has started
+func (exp *Experiment) NumLoops() int {
+	if exp == nil || exp.Result == nil {
+		return 0
+	}
+	return exp.Result.NumLoops
+}
+
 // run the experiment
-func (exp *Experiment) run(driver Driver) error {
+// If an OTLP endpoint is configured, the experiment run and its tasks are also exported as trace spans.
+// Tasks are run starting at the given index; earlier tasks are assumed to have completed.
+func (exp *Experiment) run(driver Driver, start int) error {
+	tr := newTracer()
+	root := tr.startSpan("experiment", nil)
+	root.setAttribute("iter8.experiment.start_task", start+1)
+	err := exp.runTasks(driver, tr, root, start)
+	if exp.Result != nil {
+		root.setAttribute("iter8.experiment.loops", exp.Result.NumLoops)
+		root.setAttribute("iter8.experiment.completed_tasks", exp.Result.NumCompletedTasks)
+		root.setAttribute("iter8.experiment.failure", exp.Result.Failure)
+	}
+	root.setAttribute("iter8.experiment.tasks", len(exp.Spec))
+	root.endSpan(err)
+	// failure to export traces does not fail the experiment
+	_ = tr.export()
+	return err
+}
+
+// runTasks runs the experiment tasks in sequence, starting at the given index, and records a span for each task.
+// A new loop is started only when running from the first task.
+func (exp *Experiment) runTasks(driver Driver, tr *tracer, root *span, start int) error {
 	var err error
 	exp.driver = driver
 	if exp.Result == nil {
@@ -754,59 +1192,175 @@ func (exp *Experiment) run(driver Driver) error {
 
 	log.Logger.Debug("exp result exists now ... ")
 
-	exp.incrementNumLoops()
-	log.Logger.Debugf("experiment loop %d started ...", exp.Result.NumLoops)
+	if start == 0 {
+		exp.incrementNumLoops()
+		exp.Result.NumCompletedTasks = 0
+		log.Logger.Debugf("experiment loop %d started ...", exp.Result.NumLoops)
+	} else {
+		log.Logger.Debugf("experiment loop %d resumed ...", exp.Result.NumLoops)
+	}
+	reportLoopStarted(exp)
 	err = driver.Write(exp)
 	if err != nil {
 		return err
 	}
+	if start == 0 && exp.Result.NumLoops == 1 {
+		exp.recordEvent(false, EventReasonStarted, "experiment started with %v tasks", len(exp.Spec))
+	}
 
-	log.Logger.Debugf("attempting to execute %v tasks", len(exp.Spec))
-	for i, t := range exp.Spec {
-		log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : started")
-		shouldRun := true
-		// if task has a condition
-		if cond := getIf(t); cond != nil {
-			// condition evaluates to false ... then shouldRun is false
-			program, err := expr.Compile(*cond, expr.Env(exp), expr.AsBool())
-			if err != nil {
-				log.Logger.WithStackTrace(err.Error()).Error("unable to compile if clause")
-				return err
+	if start == 0 {
+		if err = exp.runStartHook(); err != nil {
+			root.setAttribute("iter8.experiment.hook_failure", onStartHook)
+			exp.failExperiment()
+			if e := driver.Write(exp); e != nil {
+				return e
 			}
+			exp.runNotifyTasks(0)
+			exp.runEndHook(driver)
+			return err
+		}
+	}
 
-			output, err := expr.Run(program, exp)
-			if err != nil {
-				log.Logger.WithStackTrace(err.Error()).Error("unable to run if clause")
+	log.Logger.Debugf("attempting to execute %v tasks", len(exp.Spec)-start)
+	for i := start; i < len(exp.Spec); i++ {
+		t := exp.Spec[i]
+		if ac, ok := driver.(AbortChecker); ok && ac.AbortRequested() {
+			log.Logger.Infof("experiment aborted before task %v: %v", i+1, *getName(t))
+			exp.Result.Aborted = true
+			root.setAttribute("iter8.experiment.aborted", true)
+			if err = driver.Write(exp); err != nil {
 				return err
 			}
-
-			shouldRun = output.(bool)
+			exp.runNotifyTasks(i)
+			exp.runEndHook(driver)
+			return nil
+		}
+		log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : started")
+		reportTaskStarted(i+1, *getName(t))
+		shouldRun, err := exp.shouldRun(t)
+		if err != nil {
+			return err
 		}
+		ts := tr.startSpan("task "+*getName(t), root)
+		ts.setAttribute("iter8.task.name", *getName(t))
+		ts.setAttribute("iter8.task.index", i+1)
+		ts.setAttribute("iter8.task.skipped", !shouldRun)
 		if shouldRun {
-			err = t.run(exp)
-			if err != nil {
+			err = exp.runWithPolicy(t)
+			ignored := err != nil && getTaskMeta(t).ContinueOnError
+			if ignored {
+				ts.setAttribute("iter8.task.failure_ignored", true)
+			}
+			ts.endSpan(err)
+			if err != nil && exp.interrupted() {
+				// the task failed because it was interrupted
+				reportTaskEnded(i+1, *getName(t), TaskInterrupted)
+				return exp.stopInterrupted(driver, root, i)
+			}
+			if ignored {
+				log.Logger.WithStackTrace(err.Error()).Warn("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : " + "failure ignored")
+				reportTaskEnded(i+1, *getName(t), TaskFailureIgnored)
+			} else if err != nil {
 				log.Logger.Error("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : " + "failure")
+				reportTaskEnded(i+1, *getName(t), TaskFailed)
+				exp.recordEvent(true, EventReasonTaskFailed, "task %v: %v failed: %v", i+1, *getName(t), err)
 				exp.failExperiment()
 				e := driver.Write(exp)
 				if e != nil {
 					return e
 				}
-				return err
+				exp.runNotifyTasks(i + 1)
+				exp.runEndHook(driver)
+				return &TaskError{
+					Index: i + 1,
+					Task:  *getName(t),
+					Err:   err,
+				}
+			}
+			if exp.interrupted() {
+				reportTaskEnded(i+1, *getName(t), TaskInterrupted)
+				return exp.stopInterrupted(driver, root, i)
+			}
+			if !ignored {
+				log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : " + "completed")
+				reportTaskEnded(i+1, *getName(t), TaskCompleted)
+				exp.recordEvent(false, EventReasonTaskCompleted, "task %v: %v completed", i+1, *getName(t))
+				exp.recordSLOViolation(t)
 			}
-			log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : " + "completed")
 		} else {
+			ts.endSpan(nil)
 			log.Logger.WithStackTrace(fmt.Sprint("false condition: ", *getIf(t))).Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : " + "skipped")
+			reportTaskEnded(i+1, *getName(t), TaskSkipped)
 		}
 
 		exp.incrementNumCompletedTasks()
+		exp.Result.Checkpoint = nil
 		err = driver.Write(exp)
 		if err != nil {
 			return err
 		}
 	}
+	// gauges recorded before any task initialized insights, apply to the single version under test
+	if len(exp.Result.pendingMetrics) > 0 && exp.Result.Insights == nil {
+		if err = exp.Result.initInsightsWithNumVersions(1); err != nil {
+			return err
+		}
+		if err = driver.Write(exp); err != nil {
+			return err
+		}
+	}
+	if maxLoops := exp.Loop.maxLoops(); maxLoops > 1 && exp.Result.NumLoops < maxLoops {
+		exp.recordEvent(false, EventReasonLoopCompleted, "loop %v of %v completed", exp.Result.NumLoops, maxLoops)
+	} else {
+		exp.recordEvent(false, EventReasonCompleted, "experiment completed %v tasks", len(exp.Spec))
+	}
+	exp.runEndHook(driver)
 	return nil
 }
 
+// shouldRun returns true if the task has no condition, or if its condition evaluates to true
+func (exp *Experiment) shouldRun(t Task) (bool, error) {
+	cond := getIf(t)
+	if cond == nil {
+		return true, nil
+	}
+	// condition evaluates to false ... then shouldRun is false
+	program, err := expr.Compile(*cond, expr.Env(exp), expr.AsBool())
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to compile if clause")
+		return false, err
+	}
+
+	output, err := expr.Run(program, exp)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to run if clause")
+		return false, err
+	}
+
+	return output.(bool), nil
+}
+
+// runNotifyTasks runs the notification tasks starting at the given index, after an earlier task has failed
+// or the experiment has been aborted.
+// Errors are logged but do not change the outcome of the experiment.
+func (exp *Experiment) runNotifyTasks(start int) {
+	for i := start; i < len(exp.Spec); i++ {
+		t, ok := exp.Spec[i].(notifyTask)
+		if !ok {
+			continue
+		}
+		if shouldRun, err := exp.shouldRun(t); err != nil || !shouldRun {
+			continue
+		}
+		log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : started")
+		if err := t.run(exp); err != nil {
+			log.Logger.Error("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : " + "failure")
+			continue
+		}
+		log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + " : " + "completed")
+	}
+}
+
 // failExperiment sets the experiment failure status to true
 func (e *Experiment) failExperiment() {
 	e.Result.Failure = true
@@ -822,6 +1376,16 @@ func (e *Experiment) incrementNumLoops() {
 	e.Result.NumLoops++
 }
 
+// getTaskMeta returns the fields common to all tasks
+func getTaskMeta(t Task) TaskMeta {
+	var tm TaskMeta
+	// convert t to jsonBytes
+	jsonBytes, _ := json.Marshal(t)
+	// convert jsonBytes to TaskMeta
+	_ = json.Unmarshal(jsonBytes, &tm)
+	return tm
+}
+
 // getIf returns the condition (if any) which determine
 // whether of not if this task needs to run
 func getIf(t Task) *string {
@@ -870,7 +1434,171 @@ func RunExperiment(reuseResult bool, driver Driver) error {
 	} else {
 		if !reuseResult {
 			exp.initResults(driver.GetRevision())
+		} else if exp.Result != nil {
+			// the checkpoint of an earlier run is used only when the experiment is resumed
+			exp.Result.Checkpoint = nil
+		}
+		return exp.runWithBackground(driver, 0)
+	}
+}
+
+// runWithBackground starts the background tasks of the experiment, runs it starting at the given task,
+// in loops if the experiment has a loop spec, and stops the background tasks when the experiment ends
+func (exp *Experiment) runWithBackground(driver Driver, start int) error {
+	if exp.Metrics != nil {
+		if err := exp.Metrics.validate(); err != nil {
+			e := ierrors.Wrap(ierrors.ErrInvalidExperiment, err, "%v", err)
+			log.Logger.Error(e)
+			return e
+		}
+	}
+	bps, err := exp.startBackground()
+	if err != nil {
+		if exp.Result != nil {
+			exp.failExperiment()
+			if e := driver.Write(exp); e != nil {
+				return e
+			}
+		}
+		return err
+	}
+	defer bps.stop()
+	defer exp.notifyInterrupt()()
+	if exp.Result != nil {
+		exp.Result.Interrupted = false
+		exp.Result.sketchAccuracy = exp.Metrics.sketchAccuracy()
+	}
+
+	if exp.Loop != nil {
+		return exp.runLoops(driver, start)
+	}
+	return exp.run(driver, start)
+}
+
+// ValidateExperiment checks the inputs and conditions of all tasks in the experiment.
+// Unlike a run, which stops at the first invalid task, all problems are returned.
+func ValidateExperiment(exp *Experiment) []error {
+	var errs []error
+	for i := range exp.Background {
+		if err := exp.Background[i].validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if exp.Loop != nil {
+		if err := exp.Loop.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if exp.Metrics != nil {
+		if err := exp.Metrics.validate(); err != nil {
+			errs = append(errs, err)
 		}
-		return exp.run(driver)
 	}
+	for i, t := range exp.Spec {
+		name := *getName(t)
+		if err := t.validateInputs(); err != nil {
+			errs = append(errs, fmt.Errorf("task %v: %v: %v", i+1, name, err))
+		}
+		if err := validatePolicy(getTaskMeta(t)); err != nil {
+			errs = append(errs, fmt.Errorf("task %v: %v: %v", i+1, name, err))
+		}
+		if cond := getIf(t); cond != nil {
+			if _, err := expr.Compile(*cond, expr.Env(exp), expr.AsBool()); err != nil {
+				errs = append(errs, fmt.Errorf("task %v: %v: invalid if condition %v: %v", i+1, name, *cond, err))
+			}
+		}
+	}
+	if exp.Hooks != nil {
+		errs = append(errs, exp.Hooks.validate()...)
+	}
+	return errs
+}
+
+// forEachTask calls f with each task of the experiment, including those in hooks and in the branches of parallel tasks
+func forEachTask(exp *Experiment, f func(t Task)) {
+	var each func(spec ExperimentSpec)
+	each = func(spec ExperimentSpec) {
+		for _, t := range spec {
+			f(t)
+			if pt, ok := t.(*parallelTask); ok {
+				for _, b := range pt.With.Branches {
+					each(b)
+				}
+			}
+		}
+	}
+	each(exp.Spec)
+	if exp.Hooks != nil {
+		each(exp.Hooks.OnStart)
+		each(exp.Hooks.OnSuccess)
+		each(exp.Hooks.OnFailure)
+	}
+}
+
+// TaskNames returns the sorted names of the tasks used by the experiment, including those in hooks and
+// in the branches of parallel tasks. Background tasks run scripts; they are named after the run task.
+func TaskNames(exp *Experiment) []string {
+	names := map[string]bool{}
+	forEachTask(exp, func(t Task) {
+		if name := getName(t); name != nil {
+			names[*name] = true
+		}
+	})
+	if len(exp.Background) > 0 {
+		names[RunTaskName] = true
+	}
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// localFileReader is implemented by tasks with inputs that name local files read by the task
+type localFileReader interface {
+	// localFileInputs returns the names of the inputs of the task that name local files
+	localFileInputs() []string
+}
+
+// LocalFileInputs returns the inputs of the tasks of the experiment that name local files read by the tasks,
+// as task/input; for example, http/payloadFile. Experiments submitted by remote clients must not use them,
+// since the files are read on the server.
+func LocalFileInputs(exp *Experiment) []string {
+	inputs := []string{}
+	forEachTask(exp, func(t Task) {
+		if r, ok := t.(localFileReader); ok {
+			for _, in := range r.localFileInputs() {
+				inputs = append(inputs, *getName(t)+"/"+in)
+			}
+		}
+	})
+	return inputs
+}
+
+// ResumeExperiment resumes an experiment from its first incomplete task.
+// The stored result, including insights from completed tasks, is reused;
+// the failure and abort status are cleared so that the remaining tasks can run.
+func ResumeExperiment(driver Driver) error {
+	exp, err := BuildExperiment(driver)
+	if err != nil {
+		return err
+	}
+	if exp.Result == nil {
+		e := errors.New("experiment has no result to resume from")
+		log.Logger.Error(e)
+		return e
+	}
+	if exp.Completed() {
+		log.Logger.Info("experiment has already completed; nothing to resume")
+		return nil
+	}
+	start := exp.Result.NumCompletedTasks
+	exp.Result.Failure = false
+	exp.Result.Aborted = false
+	if exp.Result.Loop != nil {
+		exp.Result.Loop.StopReason = ""
+	}
+	log.Logger.Infof("resuming experiment from task %v", start+1)
+	return exp.runWithBackground(driver, start)
 }
@@ -2,6 +2,7 @@ package base
 
 import (
 	"io/ioutil"
+	"math"
 	"os"
 	"testing"
 
@@ -115,3 +116,102 @@ func TestFailExperiment(t *testing.T) {
 	exp.failExperiment()
 	assert.False(t, exp.NoFailure())
 }
+
+func TestIfConditionHelpers(t *testing.T) {
+	os.Chdir(t.TempDir())
+	script := `echo '{"name": "throughput", "value": 230}'
+echo '{"name": "throughput", "value": 180, "version": 1}'`
+	cond := func(name string, c string) *runTask {
+		return &runTask{TaskMeta: TaskMeta{
+			If:  StringPointer(c),
+			Run: StringPointer(`echo "$NAME=true" >> $ITER8_OUTPUT`),
+		}, With: &runInputs{Env: []envVar{{Name: "NAME", Value: StringPointer(name)}}}}
+	}
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer(script)}, With: &runInputs{Metrics: true}},
+			&assessTask{
+				TaskMeta: TaskMeta{Task: StringPointer(AssessTaskName)},
+				With: assessInputs{SLOs: &SLOLimits{
+					Lower: []SLO{{Metric: "run/throughput", Limit: 200}},
+				}},
+			},
+			cond("metric", `Metric("run/throughput", 0) > 200 && Metric("run/throughput", 1) < 200`),
+			cond("winner", `Winner() == 0 && SLOsBy(0) && NumLoops() == 1`),
+			cond("missing", `Metric("run/missing", 0) > 0 || Metric("run/missing", 0) <= 0`),
+			cond("noversion", `Metric("run/throughput", 2) >= 0`),
+		},
+	}
+	assert.Empty(t, ValidateExperiment(exp))
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.Equal(t, map[string]string{"metric": "true", "winner": "true"}, exp.Result.Outputs)
+
+	exp.Result.Insights.SLOsSatisfied.Lower[0][0] = false
+	assert.Equal(t, -1, exp.Winner())
+	assert.True(t, math.IsNaN((*Experiment)(nil).Metric("run/throughput", 0)))
+}
+
+// abortingDriver is a mock driver that requests an abort once the given number of tasks have completed
+type abortingDriver struct {
+	mockDriver
+	// after is the number of completed tasks after which abort is requested
+	after int
+}
+
+// AbortRequested returns true once the given number of tasks have completed
+func (d *abortingDriver) AbortRequested() bool {
+	return d.Experiment.Result.NumCompletedTasks >= d.after
+}
+
+func TestRunExperimentAborted(t *testing.T) {
+	os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo first")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo second")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo third")}},
+		},
+	}
+	err := RunExperiment(false, &abortingDriver{mockDriver: mockDriver{exp}, after: 1})
+	assert.NoError(t, err)
+	assert.True(t, exp.Aborted())
+	assert.False(t, exp.Completed())
+	assert.True(t, exp.NoFailure())
+	assert.Equal(t, 1, exp.Result.NumCompletedTasks)
+}
+
+func TestResumeExperiment(t *testing.T) {
+	os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo first >> first.txt")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("test -f ready")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo third")}},
+		},
+	}
+	d := &mockDriver{exp}
+
+	// nothing to resume without a result
+	assert.Error(t, ResumeExperiment(d))
+
+	err := RunExperiment(false, d)
+	assert.Error(t, err)
+	assert.False(t, exp.NoFailure())
+	assert.Equal(t, 1, exp.Result.NumCompletedTasks)
+
+	_, err = os.Create("ready")
+	assert.NoError(t, err)
+	err = ResumeExperiment(d)
+	assert.NoError(t, err)
+	assert.True(t, d.Experiment.Completed())
+	assert.True(t, d.Experiment.NoFailure())
+	assert.Equal(t, 1, d.Experiment.Result.NumLoops)
+
+	// the first task is not run again
+	b, err := ioutil.ReadFile("first.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", string(b))
+
+	// resuming a completed experiment does nothing
+	assert.NoError(t, ResumeExperiment(d))
+}
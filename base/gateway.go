@@ -0,0 +1,161 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// GatewayTaskName is the name of the task which shifts or mirrors traffic using a Gateway API HTTPRoute
+	GatewayTaskName = "gateway"
+	// requestMirrorFilter is the type of HTTPRoute filters that mirror requests
+	requestMirrorFilter = "RequestMirror"
+)
+
+// httpRouteGVR identifies Gateway API HTTPRoutes
+var httpRouteGVR = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1beta1",
+	Resource: "httproutes",
+}
+
+// gatewayInputs identifies the HTTPRoute and the traffic split that it should have
+type gatewayInputs struct {
+	// Namespace of the HTTPRoute. Optional. If left unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// HTTPRoute is the name of the HTTPRoute
+	HTTPRoute string `json:"httpRoute" yaml:"httpRoute"`
+	// Rule is the index of the rule of the HTTPRoute whose traffic is shifted, starting at 0. Optional.
+	Rule int `json:"rule,omitempty" yaml:"rule,omitempty"`
+	// Backends replace the backends of the rule. Their weights must add up to 100. Optional.
+	Backends []trafficBackend `json:"backends,omitempty" yaml:"backends,omitempty"`
+	// Mirror is the version to which traffic of the rule is mirrored. Optional.
+	Mirror *trafficBackend `json:"mirror,omitempty" yaml:"mirror,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the HTTPRoute. Optional.
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the HTTPRoute. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// gatewayTask shifts traffic between versions, or mirrors traffic to a version,
+// by updating a rule of a Gateway API HTTPRoute
+type gatewayTask struct {
+	TaskMeta
+	With gatewayInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the HTTPRoute
+func (t *gatewayTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the gateway task
+func (t *gatewayTask) initializeDefaults() {
+	t.driver().initKube()
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *gatewayTask) validateInputs() error {
+	if t.With.HTTPRoute == "" {
+		return errors.New("gateway task requires an httpRoute")
+	}
+	if len(t.With.Backends) == 0 && t.With.Mirror == nil {
+		return errors.New("gateway task requires backends or a mirror")
+	}
+	if t.With.Rule < 0 {
+		return fmt.Errorf("invalid rule %v", t.With.Rule)
+	}
+	if err := validateBackends(t.With.Backends); err != nil {
+		return err
+	}
+	if t.With.Mirror != nil && t.With.Mirror.Service == "" {
+		return errors.New("gateway task requires a service for the mirror")
+	}
+	return nil
+}
+
+// run executes the task
+func (t *gatewayTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+
+	// update the rule
+	err = updateTrafficObject(t.driver(), httpRouteGVR, *t.With.Namespace, t.With.HTTPRoute, t.updateRule)
+	if err != nil {
+		return err
+	}
+	traffic := describeBackends(t.With.Backends)
+	if t.With.Mirror != nil {
+		traffic += fmt.Sprintf(" mirror %v", t.With.Mirror)
+	}
+	log.Logger.Infof("updated httproute %v/%v: %v", *t.With.Namespace, t.With.HTTPRoute, traffic)
+	return nil
+}
+
+// updateRule sets the backends and mirror of the rule of the HTTPRoute
+func (t *gatewayTask) updateRule(obj *unstructured.Unstructured) error {
+	rules, _, err := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	if err != nil {
+		return err
+	}
+	if t.With.Rule >= len(rules) {
+		return fmt.Errorf("httproute has %v rules; no rule %v", len(rules), t.With.Rule)
+	}
+	rule, ok := rules[t.With.Rule].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("rule %v is not an object", t.With.Rule)
+	}
+
+	if len(t.With.Backends) > 0 {
+		refs := []interface{}{}
+		for _, b := range t.With.Backends {
+			ref := backendRef(b)
+			ref["weight"] = int64(b.Weight)
+			refs = append(refs, ref)
+		}
+		rule["backendRefs"] = refs
+	}
+	if t.With.Mirror != nil {
+		// replace any existing mirror of the rule
+		filters := []interface{}{}
+		if fs, ok := rule["filters"].([]interface{}); ok {
+			for _, f := range fs {
+				if fm, ok := f.(map[string]interface{}); ok && fm["type"] == requestMirrorFilter {
+					continue
+				}
+				filters = append(filters, f)
+			}
+		}
+		filters = append(filters, map[string]interface{}{
+			"type":          requestMirrorFilter,
+			"requestMirror": map[string]interface{}{"backendRef": backendRef(*t.With.Mirror)},
+		})
+		rule["filters"] = filters
+	}
+	rules[t.With.Rule] = rule
+	return unstructured.SetNestedSlice(obj.Object, rules, "spec", "rules")
+}
+
+// backendRef returns the Gateway API reference to the service of the backend
+func backendRef(b trafficBackend) map[string]interface{} {
+	ref := map[string]interface{}{"name": b.Service}
+	if b.Port != nil {
+		ref["port"] = int64(*b.Port)
+	}
+	return ref
+}
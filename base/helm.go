@@ -0,0 +1,196 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"github.com/mitchellh/copystructure"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+const (
+	// HelmTaskName is the name of the task which upgrades or rolls back a Helm release
+	HelmTaskName = "helm"
+	// upgradeAction upgrades the release
+	upgradeAction = "upgrade"
+	// rollbackAction rolls back the release
+	rollbackAction = "rollback"
+)
+
+// helmInputs identifies the Helm release, and the chart and values it is upgraded to, or the revision it is rolled back to
+type helmInputs struct {
+	// Release is the name of the Helm release
+	Release string `json:"release" yaml:"release"`
+	// Namespace of the release. Optional. If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Action is upgrade or rollback. Optional. If unspecified it will be defaulted to upgrade
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Chart is the chart that the release is upgraded to; a chart in the repo, a chart URL, or an OCI reference. Optional.
+	// If unspecified, the release is upgraded using its current chart.
+	Chart string `json:"chart,omitempty" yaml:"chart,omitempty"`
+	// RepoURL is the URL of the chart repo. Optional.
+	RepoURL string `json:"repoURL,omitempty" yaml:"repoURL,omitempty"`
+	// Version of the chart. Optional. If unspecified, the latest version is used.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Values of the release; for example, the image tag of the candidate version. Optional.
+	Values map[string]interface{} `json:"values,omitempty" yaml:"values,omitempty"`
+	// ResetValues uses only the given values, instead of merging them with the values of the current release. Optional.
+	ResetValues bool `json:"resetValues,omitempty" yaml:"resetValues,omitempty"`
+	// Revision that the release is rolled back to. Optional. If unspecified, the release is rolled back to its previous revision.
+	Revision int `json:"revision,omitempty" yaml:"revision,omitempty"`
+	// Wait until the resources of the release are ready. Optional.
+	Wait bool `json:"wait,omitempty" yaml:"wait,omitempty"`
+	// Timeout for waiting, and for Kubernetes operations. Optional. If unspecified it will be defaulted to 5m
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the release. Optional.
+	// If unspecified, the release is in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the release. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// helmTask upgrades a Helm release to a candidate chart and values, or rolls it back.
+// Used with the if condition SLOs(), this enables the experiment to promote the candidate version of a Helm-based app.
+type helmTask struct {
+	TaskMeta
+	With helmInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the release
+func (t *helmTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the helm task
+func (t *helmTask) initializeDefaults() {
+	if t.With.Action == "" {
+		t.With.Action = upgradeAction
+	}
+	if t.With.Timeout == nil {
+		t.With.Timeout = StringPointer("5m")
+	}
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *helmTask) validateInputs() error {
+	if t.With.Release == "" {
+		return errors.New("helm task requires a release")
+	}
+	if t.With.Action != "" && t.With.Action != upgradeAction && t.With.Action != rollbackAction {
+		return fmt.Errorf("helm task action must be %v or %v", upgradeAction, rollbackAction)
+	}
+	if t.With.Action == rollbackAction && (t.With.Chart != "" || len(t.With.Values) > 0) {
+		return errors.New("helm task chart and values may only be used with the upgrade action")
+	}
+	if t.With.Revision < 0 {
+		return fmt.Errorf("invalid revision %v", t.With.Revision)
+	}
+	if t.With.Timeout != nil {
+		if _, err := time.ParseDuration(*t.With.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %v", *t.With.Timeout)
+		}
+	}
+	return nil
+}
+
+// run executes the task
+func (t *helmTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+	timeout, _ := time.ParseDuration(*t.With.Timeout)
+	cfg, err := t.driver().helmConfig(*t.With.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if t.With.Action == rollbackAction {
+		return t.rollback(cfg, timeout)
+	}
+	return t.upgrade(cfg, timeout)
+}
+
+// upgrade upgrades the release
+func (t *helmTask) upgrade(cfg *action.Configuration, timeout time.Duration) error {
+	client := action.NewUpgrade(cfg)
+	client.Namespace = *t.With.Namespace
+	client.ReuseValues = !t.With.ResetValues
+	client.ResetValues = t.With.ResetValues
+	client.Wait = t.With.Wait
+	client.Timeout = timeout
+	client.RepoURL = t.With.RepoURL
+	client.Version = t.With.Version
+
+	ch, err := t.chart(cfg, &client.ChartPathOptions)
+	if err != nil {
+		e := fmt.Errorf("unable to get chart for release %v/%v", *t.With.Namespace, t.With.Release)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	// values are copied, since Helm merges the values of the current release into them
+	vals := map[string]interface{}{}
+	if t.With.Values != nil {
+		v, err := copystructure.Copy(t.With.Values)
+		if err != nil {
+			return err
+		}
+		vals = v.(map[string]interface{})
+	}
+	rel, err := client.Run(t.With.Release, ch, vals)
+	if err != nil {
+		e := fmt.Errorf("unable to upgrade release %v/%v", *t.With.Namespace, t.With.Release)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	log.Logger.Infof("upgraded release %v/%v to revision %v with chart %v-%v", *t.With.Namespace, t.With.Release, rel.Version, ch.Name(), ch.Metadata.Version)
+	return nil
+}
+
+// chart returns the chart that the release is upgraded to; this is the current chart of the release if no chart is specified
+func (t *helmTask) chart(cfg *action.Configuration, opts *action.ChartPathOptions) (*chart.Chart, error) {
+	if t.With.Chart == "" {
+		rel, err := action.NewGet(cfg).Run(t.With.Release)
+		if err != nil {
+			return nil, err
+		}
+		if rel.Chart == nil {
+			return nil, errors.New("release has no chart")
+		}
+		return rel.Chart, nil
+	}
+	path, err := opts.LocateChart(t.With.Chart, t.driver().EnvSettings)
+	if err != nil {
+		return nil, err
+	}
+	return loader.Load(path)
+}
+
+// rollback rolls back the release
+func (t *helmTask) rollback(cfg *action.Configuration, timeout time.Duration) error {
+	client := action.NewRollback(cfg)
+	client.Version = t.With.Revision
+	client.Wait = t.With.Wait
+	client.Timeout = timeout
+	if err := client.Run(t.With.Release); err != nil {
+		e := fmt.Errorf("unable to roll back release %v/%v", *t.With.Namespace, t.With.Release)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	log.Logger.Infof("rolled back release %v/%v", *t.With.Namespace, t.With.Release)
+	return nil
+}
@@ -0,0 +1,97 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// installRelease stores a deployed release of a chart in the fake Helm configuration of the default namespace
+func installRelease(t *testing.T) {
+	*kd = *NewFakeKubeDriver(cli.New())
+	rel := &release.Release{
+		Name:      "httpbin",
+		Namespace: "default",
+		Version:   1,
+		Info:      &release.Info{Status: release.StatusDeployed, LastDeployed: helmtime.Now()},
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "httpbin", Version: "0.1.0"},
+			Values:   map[string]interface{}{"image": map[string]interface{}{"tag": "v1"}, "replicas": 1.0},
+		},
+		Config: map[string]interface{}{"replicas": 2.0},
+	}
+	assert.NoError(t, kd.helmConfigs["default"].Releases.Create(rel))
+}
+
+func TestHelmUpgradeAndRollback(t *testing.T) {
+	os.Chdir(t.TempDir())
+	installRelease(t)
+
+	// upgrade the current chart of the release with new values
+	ht := &helmTask{
+		TaskMeta: TaskMeta{Task: StringPointer(HelmTaskName)},
+		With: helmInputs{
+			Release: "httpbin",
+			Values:  map[string]interface{}{"image": map[string]interface{}{"tag": "v2"}},
+		},
+	}
+	assert.NoError(t, ht.run(&Experiment{Spec: []Task{ht}, Result: &ExperimentResult{}}))
+	rel, err := kd.helmConfigs["default"].Releases.Last("httpbin")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rel.Version)
+	// values of the current release are reused
+	assert.Equal(t, map[string]interface{}{"image": map[string]interface{}{"tag": "v2"}, "replicas": 2.0}, rel.Config)
+
+	// upgrade to a local chart with only the new values
+	dir, err := chartutil.Create("httpbin", t.TempDir())
+	assert.NoError(t, err)
+	ht.With.Chart = dir
+	ht.With.ResetValues = true
+	assert.NoError(t, ht.run(&Experiment{Spec: []Task{ht}, Result: &ExperimentResult{}}))
+	rel, _ = kd.helmConfigs["default"].Releases.Last("httpbin")
+	assert.Equal(t, 3, rel.Version)
+	assert.Equal(t, "0.1.0", rel.Chart.Metadata.Version)
+	assert.Equal(t, map[string]interface{}{"image": map[string]interface{}{"tag": "v2"}}, rel.Config)
+
+	// roll back to the first revision
+	ht = &helmTask{
+		TaskMeta: TaskMeta{Task: StringPointer(HelmTaskName)},
+		With:     helmInputs{Release: "httpbin", Action: rollbackAction, Revision: 1},
+	}
+	assert.NoError(t, ht.run(&Experiment{Spec: []Task{ht}, Result: &ExperimentResult{}}))
+	rel, _ = kd.helmConfigs["default"].Releases.Last("httpbin")
+	assert.Equal(t, 4, rel.Version)
+	assert.Equal(t, map[string]interface{}{"replicas": 2.0}, rel.Config)
+
+	// missing releases are errors
+	ht.With.Release = "missing"
+	assert.Error(t, ht.run(&Experiment{Spec: []Task{ht}, Result: &ExperimentResult{}}))
+}
+
+func TestHelmValidateInputs(t *testing.T) {
+	ht := &helmTask{TaskMeta: TaskMeta{Task: StringPointer(HelmTaskName)}}
+	// a release is required
+	assert.Error(t, ht.validateInputs())
+
+	ht.With.Release = "httpbin"
+	assert.NoError(t, ht.validateInputs())
+
+	ht.With.Action = "install"
+	assert.Error(t, ht.validateInputs())
+
+	// charts and values are not used with rollbacks
+	ht.With.Action = rollbackAction
+	ht.With.Chart = "httpbin"
+	assert.Error(t, ht.validateInputs())
+
+	ht.With.Chart = ""
+	ht.With.Timeout = StringPointer("soon")
+	assert.Error(t, ht.validateInputs())
+}
@@ -0,0 +1,115 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/antonmedv/expr"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// onStartHook is the name of the hook that runs when an experiment loop starts
+	onStartHook = "onStart"
+	// onSuccessHook is the name of the hook that runs when an experiment loop completes its tasks without failure
+	onSuccessHook = "onSuccess"
+	// onFailureHook is the name of the hook that runs when an experiment loop fails or is aborted
+	onFailureHook = "onFailure"
+)
+
+// Hooks are lists of tasks that run when an experiment loop starts, succeeds, or fails.
+// Hooks enable cleanup and notifications without encoding them as trailing tasks with if conditions.
+type Hooks struct {
+	// OnStart tasks run before the first task of each loop. Optional.
+	// If an onStart task fails, the experiment fails, and the onFailure tasks run.
+	OnStart ExperimentSpec `json:"onStart,omitempty" yaml:"onStart,omitempty"`
+	// OnSuccess tasks run after all tasks of a loop complete without failure. Optional.
+	OnSuccess ExperimentSpec `json:"onSuccess,omitempty" yaml:"onSuccess,omitempty"`
+	// OnFailure tasks run after a task fails, or after the experiment is aborted. Optional.
+	OnFailure ExperimentSpec `json:"onFailure,omitempty" yaml:"onFailure,omitempty"`
+}
+
+// validate validates the inputs, policies, and conditions of the tasks of the hooks
+func (h *Hooks) validate() []error {
+	var errs []error
+	for _, hook := range []struct {
+		name  string
+		tasks ExperimentSpec
+	}{{onStartHook, h.OnStart}, {onSuccessHook, h.OnSuccess}, {onFailureHook, h.OnFailure}} {
+		for i, t := range hook.tasks {
+			name := *getName(t)
+			if err := t.validateInputs(); err != nil {
+				errs = append(errs, fmt.Errorf("%v hook: task %v: %v: %v", hook.name, i+1, name, err))
+			}
+			if err := validatePolicy(getTaskMeta(t)); err != nil {
+				errs = append(errs, fmt.Errorf("%v hook: task %v: %v: %v", hook.name, i+1, name, err))
+			}
+			if cond := getIf(t); cond != nil {
+				if _, err := expr.Compile(*cond, expr.Env(&Experiment{}), expr.AsBool()); err != nil {
+					errs = append(errs, fmt.Errorf("%v hook: task %v: %v: invalid if condition %v: %v", hook.name, i+1, name, *cond, err))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// runHook runs the tasks of a hook in sequence; the hook stops at the first task failure.
+// Hook tasks are not counted as completed tasks of the experiment.
+func (exp *Experiment) runHook(hook string, tasks ExperimentSpec) error {
+	for i, t := range tasks {
+		name := fmt.Sprintf("%v hook task %v: %v", hook, i+1, *getName(t))
+		log.Logger.Info(name + " : started")
+		shouldRun, err := exp.shouldRun(t)
+		if err != nil {
+			return err
+		}
+		if !shouldRun {
+			log.Logger.WithStackTrace(fmt.Sprint("false condition: ", *getIf(t))).Info(name + " : skipped")
+			continue
+		}
+		if err := exp.runWithPolicy(t); err != nil {
+			if getTaskMeta(t).ContinueOnError {
+				log.Logger.WithStackTrace(err.Error()).Warn(name + " : failure ignored")
+				continue
+			}
+			log.Logger.Error(name + " : failure")
+			return &TaskError{
+				Index: i + 1,
+				Task:  *getName(t),
+				Err:   fmt.Errorf("%v hook: %w", hook, err),
+			}
+		}
+		log.Logger.Info(name + " : completed")
+	}
+	return nil
+}
+
+// runStartHook runs the onStart tasks of the experiment
+func (exp *Experiment) runStartHook() error {
+	if exp.Hooks == nil {
+		return nil
+	}
+	return exp.runHook(onStartHook, exp.Hooks.OnStart)
+}
+
+// runEndHook runs the onSuccess or onFailure tasks of the experiment, depending on the outcome of the loop.
+// Failures of these tasks are logged, and do not change the outcome of the experiment.
+func (exp *Experiment) runEndHook(driver Driver) {
+	if exp.Hooks == nil {
+		return
+	}
+	hook, tasks := onSuccessHook, exp.Hooks.OnSuccess
+	if exp.Result.Failure || exp.Result.Aborted {
+		hook, tasks = onFailureHook, exp.Hooks.OnFailure
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	if err := exp.runHook(hook, tasks); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warnf("%v hook failed", hook)
+	}
+	// hook tasks may publish outputs and metrics
+	if err := driver.Write(exp); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warnf("unable to write experiment after %v hook", hook)
+	}
+}
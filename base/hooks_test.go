@@ -0,0 +1,85 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+// hookTask returns a run task that publishes the given output
+func hookTask(name string) *runTask {
+	return &runTask{TaskMeta: TaskMeta{Run: StringPointer("echo " + name + "=true >> $ITER8_OUTPUT")}}
+}
+
+func TestRunExperimentHooks(t *testing.T) {
+	os.Chdir(t.TempDir())
+	hooks := &Hooks{
+		OnStart:   []Task{hookTask("start")},
+		OnSuccess: []Task{hookTask("success")},
+		OnFailure: []Task{hookTask("failure")},
+	}
+
+	// successful experiment
+	exp := &Experiment{
+		Spec:  []Task{hookTask("task")},
+		Hooks: hooks,
+	}
+	assert.Empty(t, ValidateExperiment(exp))
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.True(t, exp.Completed())
+	assert.Equal(t, 1, exp.Result.NumCompletedTasks)
+	assert.Equal(t, map[string]string{"start": "true", "task": "true", "success": "true"}, exp.Result.Outputs)
+
+	// failed task
+	exp = &Experiment{
+		Spec:  []Task{&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}}, hookTask("task")},
+		Hooks: hooks,
+	}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+	assert.False(t, exp.NoFailure())
+	assert.Equal(t, map[string]string{"start": "true", "failure": "true"}, exp.Result.Outputs)
+
+	// failed onStart hook; tasks do not run
+	exp = &Experiment{
+		Spec: []Task{hookTask("task")},
+		Hooks: &Hooks{
+			OnStart:   []Task{&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}}},
+			OnFailure: []Task{hookTask("failure")},
+		},
+	}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+	assert.False(t, exp.NoFailure())
+	assert.Equal(t, 0, exp.Result.NumCompletedTasks)
+	assert.Equal(t, map[string]string{"failure": "true"}, exp.Result.Outputs)
+
+	// failed onSuccess hook does not fail the experiment
+	exp = &Experiment{
+		Spec:  []Task{hookTask("task")},
+		Hooks: &Hooks{OnSuccess: []Task{&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}}}},
+	}
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.True(t, exp.NoFailure())
+}
+
+func TestReadExperimentHooks(t *testing.T) {
+	b := []byte(`
+spec:
+- run: echo hello
+hooks:
+  onStart:
+  - run: echo start
+  onFailure:
+  - run: echo failure
+    if: "NumLoops() > 1"
+`)
+	exp := &Experiment{}
+	assert.NoError(t, yaml.Unmarshal(b, exp))
+	assert.Equal(t, 1, len(exp.Hooks.OnStart))
+	assert.Empty(t, exp.Hooks.OnSuccess)
+	assert.Equal(t, 1, len(exp.Hooks.OnFailure))
+
+	exp.Hooks.OnFailure[0].(*runTask).If = StringPointer("Unknown()")
+	assert.Equal(t, 1, len(ValidateExperiment(exp)))
+}
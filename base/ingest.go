@@ -0,0 +1,413 @@
+package base
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// IngestTaskName is the name of the task which ingests business events pushed by the app
+	IngestTaskName = "ingest"
+	// IngestPath is the path of the endpoint to which business events are posted
+	IngestPath = "/events"
+	// ingestMetricPrefix is the prefix of the metrics aggregated from business events
+	ingestMetricPrefix = "ingest"
+	// defaultIngestPort is the default port of the ingest endpoint
+	defaultIngestPort = 8090
+	// ingestShutdownTimeout is the time given to in-flight requests to complete when the window of the ingest task ends
+	ingestShutdownTimeout = 5 * time.Second
+	// maxIngestRequestBytes is the maximum size of the body of a request with business events
+	maxIngestRequestBytes = 1 << 20
+
+	// sumAggregation is the sum of the values of events
+	sumAggregation = "sum"
+	// countAggregation is the number of events
+	countAggregation = "count"
+	// meanAggregation is the mean of the values of events
+	meanAggregation = "mean"
+)
+
+// ingestMetric is a metric aggregated from business events, such as conversions or revenue
+type ingestMetric struct {
+	// Name of the metric; events of the metric are aggregated into ingest/<name>
+	Name string `json:"name" yaml:"name"`
+	// Aggregation of the values of events for each version; sum, count, or mean. Optional. Default is sum.
+	Aggregation string `json:"aggregation,omitempty" yaml:"aggregation,omitempty"`
+	// Description of the metric. Optional.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Units of the metric. Optional.
+	Units *string `json:"units,omitempty" yaml:"units,omitempty"`
+}
+
+// ingestInputs are the inputs of the ingest task
+type ingestInputs struct {
+	// Duration is the window in which business events are accepted; for example, 10m.
+	// The ingest task is usually run in a parallel branch with tasks that generate load or shift traffic.
+	Duration string `json:"duration" yaml:"duration"`
+	// Port of the ingest endpoint. Optional. Default is 8090.
+	Port *int `json:"port,omitempty" yaml:"port,omitempty"`
+	// Versions are the names of the versions of the app, in order; events identify their version by name or by index.
+	Versions []string `json:"versions" yaml:"versions"`
+	// Metrics are the metrics aggregated from business events; events of other metrics are rejected.
+	Metrics []ingestMetric `json:"metrics" yaml:"metrics"`
+	// TokenEnv is the name of the environment variable containing a token shared with the app. Optional.
+	// If a token is specified, events must be posted with the header Authorization: Bearer <token>.
+	TokenEnv string `json:"tokenEnv,omitempty" yaml:"tokenEnv,omitempty"`
+	// TokenFile is the path to a file containing the token shared with the app, such as a mounted Kubernetes secret. Optional.
+	TokenFile string `json:"tokenFile,omitempty" yaml:"tokenFile,omitempty"`
+}
+
+// ingestTask accepts business events pushed by the app during a window, aggregates them for each version,
+// and records them as metrics; for example, for rewards of the assess task.
+type ingestTask struct {
+	TaskMeta
+	With ingestInputs `json:"with" yaml:"with"`
+}
+
+// businessEvent is a business event pushed by the app; for example,
+// {"version": "v2", "metric": "revenue", "value": 12.5}
+type businessEvent struct {
+	// Version of the app that produced the event; its name or its index
+	Version interface{} `json:"version"`
+	// Metric of the event
+	Metric string `json:"metric"`
+	// Value of the event. Optional. Default is 1; for example, for a conversion.
+	Value *float64 `json:"value,omitempty"`
+}
+
+// initializeDefaults sets default values for the ingest task
+func (t *ingestTask) initializeDefaults() {
+	if t.With.Port == nil {
+		t.With.Port = intPointer(defaultIngestPort)
+	}
+	for i := range t.With.Metrics {
+		if t.With.Metrics[i].Aggregation == "" {
+			t.With.Metrics[i].Aggregation = sumAggregation
+		}
+	}
+}
+
+// validateInputs validates task inputs
+func (t *ingestTask) validateInputs() error {
+	if _, err := time.ParseDuration(t.With.Duration); err != nil {
+		return fmt.Errorf("ingest task requires a valid duration: %v", err)
+	}
+	if t.With.Port != nil && (*t.With.Port < 0 || *t.With.Port > 65535) {
+		return fmt.Errorf("invalid port %v", *t.With.Port)
+	}
+	if len(t.With.Versions) == 0 {
+		return errors.New("ingest task requires the names of versions")
+	}
+	if len(t.With.Metrics) == 0 {
+		return errors.New("ingest task requires at least one metric")
+	}
+	if t.With.TokenEnv != "" && t.With.TokenFile != "" {
+		return errors.New("ingest task accepts either tokenEnv or tokenFile, but not both")
+	}
+	names := map[string]bool{}
+	for _, m := range t.With.Metrics {
+		if m.Name == "" || strings.Contains(m.Name, "/") {
+			return fmt.Errorf("invalid metric name %q", m.Name)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("duplicate metric %v", m.Name)
+		}
+		names[m.Name] = true
+		switch m.Aggregation {
+		case "", sumAggregation, countAggregation, meanAggregation:
+		default:
+			return fmt.Errorf("invalid aggregation %v of metric %v; must be one of sum, count, or mean", m.Aggregation, m.Name)
+		}
+	}
+	return nil
+}
+
+// token returns the token shared with the app, from the environment or from a file;
+// it is empty if no token is specified
+func (t *ingestTask) token() (string, error) {
+	token := ""
+	if t.With.TokenEnv != "" {
+		token = os.Getenv(t.With.TokenEnv)
+	} else if t.With.TokenFile != "" {
+		b, err := ioutil.ReadFile(t.With.TokenFile)
+		if err != nil {
+			e := errors.New("unable to read ingest token file")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return "", e
+		}
+		token = strings.TrimSpace(string(b))
+	} else {
+		return "", nil
+	}
+	// an empty token would leave the endpoint open, although a token is expected
+	if token == "" {
+		e := errors.New("ingest token is empty")
+		log.Logger.Error(e)
+		return "", e
+	}
+	log.AddSecret(token)
+	return token, nil
+}
+
+// localFileInputs returns the inputs of the task that name local files
+func (t *ingestTask) localFileInputs() []string {
+	if t.With.TokenFile != "" {
+		return []string{"tokenFile"}
+	}
+	return nil
+}
+
+// eventAggregate accumulates the values of the events of a metric for a version
+type eventAggregate struct {
+	// sum of values
+	sum float64
+	// count of events
+	count float64
+}
+
+// eventAggregator aggregates business events for each version and metric
+type eventAggregator struct {
+	// token that requests must carry, if any
+	token string
+	// mu protects aggregates
+	mu sync.Mutex
+	// versions are the indexes of versions by name
+	versions map[string]int
+	// numVersions is the number of versions
+	numVersions int
+	// aggregates of each version, by metric
+	aggregates []map[string]*eventAggregate
+}
+
+// newEventAggregator returns an aggregator for the versions and metrics of the task
+func newEventAggregator(t *ingestTask) *eventAggregator {
+	a := &eventAggregator{
+		versions:    map[string]int{},
+		numVersions: len(t.With.Versions),
+		aggregates:  make([]map[string]*eventAggregate, len(t.With.Versions)),
+	}
+	for i, v := range t.With.Versions {
+		a.versions[v] = i
+		a.aggregates[i] = map[string]*eventAggregate{}
+		for _, m := range t.With.Metrics {
+			a.aggregates[i][m.Name] = &eventAggregate{}
+		}
+	}
+	return a
+}
+
+// version returns the index of the version of the event
+func (a *eventAggregator) version(e businessEvent) (int, error) {
+	switch v := e.Version.(type) {
+	case string:
+		if i, ok := a.versions[v]; ok {
+			return i, nil
+		}
+		// versions may also be identified by their index in a string
+		if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < a.numVersions {
+			return i, nil
+		}
+	case float64:
+		if i := int(v); float64(i) == v && i >= 0 && i < a.numVersions {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown version %v", e.Version)
+}
+
+// add aggregates the events; if any event is invalid, none of them are aggregated
+func (a *eventAggregator) add(events []businessEvent) error {
+	idx := make([]int, len(events))
+	for j, e := range events {
+		i, err := a.version(e)
+		if err != nil {
+			return err
+		}
+		if _, ok := a.aggregates[i][e.Metric]; !ok {
+			return fmt.Errorf("unknown metric %v", e.Metric)
+		}
+		idx[j] = i
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for j, e := range events {
+		val := float64(1)
+		if e.Value != nil {
+			val = *e.Value
+		}
+		agg := a.aggregates[idx[j]][e.Metric]
+		agg.sum += val
+		agg.count++
+	}
+	return nil
+}
+
+// decodeEvents decodes the business events in the body of a request; the body is a JSON object or list of objects,
+// or a sequence of them, such as newline-delimited JSON
+func decodeEvents(r io.Reader) ([]businessEvent, error) {
+	events := []businessEvent{}
+	d := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return nil, err
+		}
+		var batch []businessEvent
+		if err := json.Unmarshal(raw, &batch); err != nil {
+			e := businessEvent{}
+			if err := json.Unmarshal(raw, &e); err != nil {
+				return nil, err
+			}
+			batch = []businessEvent{e}
+		}
+		events = append(events, batch...)
+	}
+}
+
+// ServeHTTP accepts business events posted to the ingest endpoint
+func (a *eventAggregator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != IngestPath {
+		http.NotFound(w, r)
+		return
+	}
+	if a.token != "" {
+		auth := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "business events must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	events, err := decodeEvents(http.MaxBytesReader(w, r.Body, maxIngestRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid business events: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := a.add(events); err != nil {
+		http.Error(w, fmt.Sprintf("invalid business event: %v", err), http.StatusBadRequest)
+		return
+	}
+	log.Logger.Debugf("ingested %v business events", len(events))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, `{"accepted":%v}`, len(events))
+}
+
+// ingest accepts business events using the listener until the window ends or the experiment is interrupted,
+// and records the aggregated metrics of each version
+func (t *ingestTask) ingest(exp *Experiment, l net.Listener) error {
+	err := exp.Result.initInsightsWithNumVersions(len(t.With.Versions))
+	if err != nil {
+		return err
+	}
+	token, err := t.token()
+	if err != nil {
+		return err
+	}
+	window, _ := time.ParseDuration(t.With.Duration)
+	a := newEventAggregator(t)
+	a.token = token
+	srv := &http.Server{Handler: a}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+	log.Logger.Infof("ingesting business events at http://%v%v for %v", l.Addr(), IngestPath, window)
+
+	timer := time.NewTimer(window)
+	select {
+	case <-timer.C:
+	case <-exp.interrupt:
+		timer.Stop()
+		log.Logger.Warn("stopped ingesting business events")
+	case <-exp.context().Done():
+		timer.Stop()
+		log.Logger.Warn("stopped ingesting business events")
+	case err := <-served:
+		e := errors.New("ingest endpoint failed")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ingestShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warn("unable to shut down ingest endpoint gracefully")
+	}
+
+	in := exp.Result.Insights
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, m := range t.With.Metrics {
+		mm := MetricMeta{
+			Description: m.Description,
+			Units:       m.Units,
+			Type:        GaugeMetricType,
+		}
+		if mm.Description == "" {
+			mm.Description = fmt.Sprintf("%v of business events of %v", m.Aggregation, m.Name)
+		}
+		// sums are not counters, since values of events may be negative; for example, refunds
+		if m.Aggregation == countAggregation {
+			mm.Type = CounterMetricType
+		}
+		for i := 0; i < len(t.With.Versions); i++ {
+			agg := a.aggregates[i][m.Name]
+			var val float64
+			switch m.Aggregation {
+			case countAggregation:
+				val = agg.count
+			case meanAggregation:
+				// the mean is undefined without events
+				if agg.count == 0 {
+					continue
+				}
+				val = agg.sum / agg.count
+			default:
+				val = agg.sum
+			}
+			if err := in.updateMetric(ingestMetricPrefix+"/"+m.Name, mm, i, val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run executes the task
+func (t *ingestTask) run(exp *Experiment) error {
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+	t.initializeDefaults()
+
+	l, err := net.Listen("tcp", fmt.Sprintf(":%v", *t.With.Port))
+	if err != nil {
+		e := fmt.Errorf("unable to listen for business events on port %v", *t.With.Port)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return t.ingest(exp, l)
+}
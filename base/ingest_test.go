@@ -0,0 +1,163 @@
+package base
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngest(t *testing.T) {
+	os.Chdir(t.TempDir())
+	task := &ingestTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(IngestTaskName),
+		},
+		With: ingestInputs{
+			Duration: "1s",
+			Versions: []string{"v1", "v2"},
+			Metrics: []ingestMetric{
+				{Name: "conversions"},
+				{Name: "revenue", Aggregation: meanAggregation, Units: StringPointer("usd")},
+				{Name: "visits", Aggregation: countAggregation},
+			},
+		},
+	}
+	assert.NoError(t, task.validateInputs())
+	task.initializeDefaults()
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	done := make(chan error)
+	go func() {
+		done <- task.ingest(exp, l)
+	}()
+
+	url := fmt.Sprintf("http://%v%v", l.Addr(), IngestPath)
+	post := func(body string) int {
+		resp, err := http.Post(url, "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	// events are identified by the name or the index of their version, and posted as objects, lists, or newline-delimited JSON
+	assert.Equal(t, http.StatusAccepted, post(`{"version": "v1", "metric": "conversions"}`))
+	assert.Equal(t, http.StatusAccepted, post(`[{"version": 1, "metric": "conversions"}, {"version": "1", "metric": "conversions", "value": 2}]`))
+	assert.Equal(t, http.StatusAccepted, post("{\"version\": \"v2\", \"metric\": \"revenue\", \"value\": 10}\n{\"version\": \"v2\", \"metric\": \"revenue\", \"value\": 20}"))
+	assert.Equal(t, http.StatusAccepted, post(`[{"version": "v1", "metric": "visits", "value": 5}, {"version": "v1", "metric": "visits"}]`))
+	// batches with unknown versions or metrics are rejected
+	assert.Equal(t, http.StatusBadRequest, post(`[{"version": "v1", "metric": "conversions"}, {"version": "v3", "metric": "conversions"}]`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"version": 0, "metric": "clicks"}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"version": 0.5, "metric": "conversions"}`))
+	assert.Equal(t, http.StatusBadRequest, post(`not json`))
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	assert.NoError(t, <-done)
+	in := exp.Result.Insights
+	assert.Equal(t, 2, in.NumVersions)
+	assert.Equal(t, 1.0, *in.ScalarMetricValue(0, "ingest/conversions"))
+	assert.Equal(t, 3.0, *in.ScalarMetricValue(1, "ingest/conversions"))
+	// the mean is undefined for versions without events
+	assert.Nil(t, in.ScalarMetricValue(0, "ingest/revenue"))
+	assert.Equal(t, 15.0, *in.ScalarMetricValue(1, "ingest/revenue"))
+	assert.Equal(t, GaugeMetricType, in.MetricsInfo["ingest/revenue"].Type)
+	assert.Equal(t, 2.0, *in.ScalarMetricValue(0, "ingest/visits"))
+	assert.Equal(t, 0.0, *in.ScalarMetricValue(1, "ingest/visits"))
+	// counts are counters, while sums may decrease
+	assert.Equal(t, CounterMetricType, in.MetricsInfo["ingest/visits"].Type)
+	assert.Equal(t, GaugeMetricType, in.MetricsInfo["ingest/conversions"].Type)
+}
+
+func TestIngestToken(t *testing.T) {
+	os.Chdir(t.TempDir())
+	os.Setenv("INGEST_TOKEN", "shared-token")
+	defer os.Unsetenv("INGEST_TOKEN")
+	task := &ingestTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(IngestTaskName),
+		},
+		With: ingestInputs{
+			Duration: "1s",
+			Versions: []string{"v1"},
+			Metrics:  []ingestMetric{{Name: "conversions"}},
+			TokenEnv: "INGEST_TOKEN",
+		},
+	}
+	assert.NoError(t, task.validateInputs())
+	task.initializeDefaults()
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	done := make(chan error)
+	go func() {
+		done <- task.ingest(exp, l)
+	}()
+
+	// events are accepted only with the shared token
+	url := fmt.Sprintf("http://%v%v", l.Addr(), IngestPath)
+	for auth, code := range map[string]int{
+		"":                    http.StatusUnauthorized,
+		"Bearer wrong-token":  http.StatusUnauthorized,
+		"shared-token":        http.StatusUnauthorized,
+		"Bearer shared-token": http.StatusAccepted,
+	} {
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"version": "v1", "metric": "conversions"}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, code, resp.StatusCode, auth)
+	}
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, 1.0, *exp.Result.Insights.ScalarMetricValue(0, "ingest/conversions"))
+
+	// an empty token is an error, rather than an open endpoint
+	os.Setenv("INGEST_TOKEN", "")
+	_, err = task.token()
+	assert.Error(t, err)
+	task.With.TokenFile = "token.txt"
+	assert.Error(t, task.validateInputs())
+	assert.Equal(t, []string{"tokenFile"}, task.localFileInputs())
+}
+
+func TestIngestInputs(t *testing.T) {
+	task := &ingestTask{
+		With: ingestInputs{
+			Duration: "1m",
+			Versions: []string{"v1"},
+			Metrics:  []ingestMetric{{Name: "conversions"}},
+		},
+	}
+	assert.NoError(t, task.validateInputs())
+
+	task.With.Metrics = append(task.With.Metrics, ingestMetric{Name: "conversions"})
+	assert.Error(t, task.validateInputs())
+	task.With.Metrics = []ingestMetric{{Name: "revenue", Aggregation: "max"}}
+	assert.Error(t, task.validateInputs())
+	task.With.Metrics = []ingestMetric{{Name: "a/revenue"}}
+	assert.Error(t, task.validateInputs())
+	task.With.Metrics = nil
+	assert.Error(t, task.validateInputs())
+	task.With.Versions = nil
+	assert.Error(t, task.validateInputs())
+	task.With.Duration = "forever"
+	assert.Error(t, task.validateInputs())
+}
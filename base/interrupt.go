@@ -0,0 +1,100 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// interruptSignals interrupt an experiment run; for example, Kubernetes sends SIGTERM to pods of jobs that are preempted or evicted
+var interruptSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// errInterrupted is returned by a run of an experiment that is interrupted by a signal
+var errInterrupted = errors.New("experiment interrupted")
+
+// notifyInterrupt interrupts the experiment when the process receives an interrupt signal, until the returned function is called.
+// A second signal terminates the process without waiting for the experiment to stop.
+func (exp *Experiment) notifyInterrupt() func() {
+	sig := make(chan os.Signal, 1)
+	interrupt := make(chan struct{})
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	signal.Notify(sig, interruptSignals...)
+	go func() {
+		select {
+		case s := <-sig:
+			signal.Stop(sig)
+			log.Logger.Warnf("received %v; stopping the experiment", s)
+			close(interrupt)
+			cancel()
+		case <-done:
+		}
+	}()
+	exp.interrupt = interrupt
+	exp.ctx = ctx
+	return func() {
+		signal.Stop(sig)
+		close(done)
+		cancel()
+	}
+}
+
+// context returns the context of task runs; it is done when the experiment is interrupted,
+// or when the attempt of the task being run times out
+func (exp *Experiment) context() context.Context {
+	if exp.ctx == nil {
+		return context.Background()
+	}
+	return exp.ctx
+}
+
+// interrupted returns true if the experiment has been interrupted by a signal
+func (exp *Experiment) interrupted() bool {
+	select {
+	case <-exp.interrupt:
+		return true
+	default:
+		return false
+	}
+}
+
+// stopped returns true if the experiment has been interrupted, or the attempt of the task being run has timed out;
+// tasks stop sending requests when they are stopped
+func (exp *Experiment) stopped() bool {
+	return exp.interrupted() || exp.context().Err() != nil
+}
+
+// onInterrupt calls f if the experiment is interrupted, or the attempt of the task being run times out,
+// before the returned function is called; for example, to stop load generation by a task
+func (exp *Experiment) onInterrupt(f func()) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-exp.interrupt:
+			f()
+		case <-exp.context().Done():
+			f()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// stopInterrupted records that the experiment was interrupted while running the task with the given index,
+// and writes the result, so that metrics collected before the interrupt are not lost.
+// The task is not counted as completed; a resumed run of the experiment runs it again.
+func (exp *Experiment) stopInterrupted(driver Driver, root *span, i int) error {
+	log.Logger.Warnf("experiment interrupted at task %v: %v", i+1, *getName(exp.Spec[i]))
+	exp.Result.Interrupted = true
+	root.setAttribute("iter8.experiment.interrupted", true)
+	if err := driver.Write(exp); err != nil {
+		return err
+	}
+	return errInterrupted
+}
@@ -0,0 +1,69 @@
+package base
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterruptHTTP(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			URL:      srv.URL,
+			Duration: StringPointer("30s"),
+			QPS:      float32Pointer(20),
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{ct, &runTask{TaskMeta: TaskMeta{Run: StringPointer("touch second")}}},
+	}
+	exp.initResults(1)
+	d := &mockDriver{exp}
+
+	// the load test stops soon after the experiment is interrupted
+	interrupt := make(chan struct{})
+	exp.interrupt = interrupt
+	time.AfterFunc(time.Second, func() { close(interrupt) })
+	start := time.Now()
+	err := exp.run(d, 0)
+	assert.ErrorIs(t, err, errInterrupted)
+	assert.Less(t, time.Since(start).Seconds(), 10.0)
+
+	// metrics collected before the interrupt are retained, and the task is not completed
+	assert.True(t, d.Experiment.Interrupted())
+	assert.Equal(t, 0, d.Experiment.Result.NumCompletedTasks)
+	count := d.Experiment.Result.Insights.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountId)
+	assert.NotNil(t, count)
+	assert.Greater(t, *count, 0.0)
+	assert.NoFileExists(t, "second")
+}
+
+func TestNotifyInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals cannot be sent to the process on Windows")
+	}
+	exp := &Experiment{}
+	stop := exp.notifyInterrupt()
+	defer stop()
+	assert.False(t, exp.interrupted())
+
+	p, err := os.FindProcess(os.Getpid())
+	assert.NoError(t, err)
+	assert.NoError(t, p.Signal(syscall.SIGTERM))
+	assert.Eventually(t, exp.interrupted, 5*time.Second, 10*time.Millisecond)
+}
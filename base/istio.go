@@ -0,0 +1,211 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// IstioTaskName is the name of the task which shifts or mirrors traffic using an Istio VirtualService
+	IstioTaskName = "istio"
+)
+
+// virtualServiceGVR identifies Istio VirtualServices
+var virtualServiceGVR = schema.GroupVersionResource{
+	Group:    "networking.istio.io",
+	Version:  "v1beta1",
+	Resource: "virtualservices",
+}
+
+// istioDestination is a version of the app to which traffic is routed or mirrored
+type istioDestination struct {
+	// Host is the service of the version; for example, httpbin
+	Host string `json:"host" yaml:"host"`
+	// Subset of the service, defined by a DestinationRule; for example, v2. Optional.
+	Subset string `json:"subset,omitempty" yaml:"subset,omitempty"`
+	// Port of the service. Optional.
+	Port *uint32 `json:"port,omitempty" yaml:"port,omitempty"`
+	// Weight is the percentage of traffic routed to this version. Ignored for mirrors.
+	Weight int32 `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
+
+// istioInputs identifies the VirtualService and the traffic split that it should have
+type istioInputs struct {
+	// Namespace of the VirtualService. Optional. If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// VirtualService is the name of the VirtualService
+	VirtualService string `json:"virtualService" yaml:"virtualService"`
+	// Route is the name of the HTTP route whose traffic is shifted. Optional.
+	// If unspecified, the first HTTP route of the VirtualService is used.
+	Route string `json:"route,omitempty" yaml:"route,omitempty"`
+	// Destinations replace the destinations of the route. Their weights must add up to 100. Optional.
+	Destinations []istioDestination `json:"destinations,omitempty" yaml:"destinations,omitempty"`
+	// Mirror is the version to which traffic of the route is mirrored. Optional.
+	Mirror *istioDestination `json:"mirror,omitempty" yaml:"mirror,omitempty"`
+	// MirrorPercent is the percentage of traffic that is mirrored. Optional. If unspecified, all traffic is mirrored.
+	MirrorPercent *float64 `json:"mirrorPercent,omitempty" yaml:"mirrorPercent,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the VirtualService. Optional.
+	// If unspecified, the VirtualService is looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the VirtualService. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// istioTask shifts traffic between versions, or mirrors traffic to a version,
+// by updating an HTTP route of an Istio VirtualService.
+// Used with the if condition SLOs(), this enables progressive rollouts of versions that satisfy SLOs.
+type istioTask struct {
+	TaskMeta
+	With istioInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the VirtualService
+func (t *istioTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the istio task
+func (t *istioTask) initializeDefaults() {
+	t.driver().initKube()
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *istioTask) validateInputs() error {
+	if t.With.VirtualService == "" {
+		return errors.New("istio task requires a virtualService")
+	}
+	if len(t.With.Destinations) == 0 && t.With.Mirror == nil {
+		return errors.New("istio task requires destinations or a mirror")
+	}
+	weights := []int32{}
+	for _, d := range t.With.Destinations {
+		if d.Host == "" {
+			return errors.New("istio task requires a host for each destination")
+		}
+		weights = append(weights, d.Weight)
+	}
+	if err := validateWeights(weights); err != nil {
+		return err
+	}
+	if t.With.Mirror != nil && t.With.Mirror.Host == "" {
+		return errors.New("istio task requires a host for the mirror")
+	}
+	if t.With.MirrorPercent != nil && (*t.With.MirrorPercent < 0 || *t.With.MirrorPercent > 100) {
+		return fmt.Errorf("mirrorPercent %v must be between 0 and 100", *t.With.MirrorPercent)
+	}
+	return nil
+}
+
+// run executes the task
+func (t *istioTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+
+	// update the route
+	err = updateTrafficObject(t.driver(), virtualServiceGVR, *t.With.Namespace, t.With.VirtualService, t.updateRoute)
+	if err != nil {
+		return err
+	}
+	log.Logger.Infof("updated virtualservice %v/%v: %v", *t.With.Namespace, t.With.VirtualService, t.traffic())
+	return nil
+}
+
+// updateRoute sets the destinations and mirror of the HTTP route of the VirtualService
+func (t *istioTask) updateRoute(obj *unstructured.Unstructured) error {
+	routes, found, err := unstructured.NestedSlice(obj.Object, "spec", "http")
+	if err != nil {
+		return err
+	}
+	if !found || len(routes) == 0 {
+		return errors.New("virtualservice has no http routes")
+	}
+
+	index := -1
+	for i, r := range routes {
+		route, ok := r.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("http route %v is not an object", i)
+		}
+		if t.With.Route == "" || route["name"] == t.With.Route {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("virtualservice has no http route named %v", t.With.Route)
+	}
+
+	route := routes[index].(map[string]interface{})
+	if len(t.With.Destinations) > 0 {
+		dests := []interface{}{}
+		for _, d := range t.With.Destinations {
+			dests = append(dests, map[string]interface{}{
+				"destination": d.destination(),
+				"weight":      int64(d.Weight),
+			})
+		}
+		route["route"] = dests
+	}
+	if t.With.Mirror != nil {
+		route["mirror"] = t.With.Mirror.destination()
+		if t.With.MirrorPercent != nil {
+			route["mirrorPercentage"] = map[string]interface{}{"value": *t.With.MirrorPercent}
+		} else {
+			delete(route, "mirrorPercentage")
+		}
+	}
+	routes[index] = route
+	return unstructured.SetNestedSlice(obj.Object, routes, "spec", "http")
+}
+
+// destination returns the Istio destination of the version
+func (d istioDestination) destination() map[string]interface{} {
+	dest := map[string]interface{}{"host": d.Host}
+	if d.Subset != "" {
+		dest["subset"] = d.Subset
+	}
+	if d.Port != nil {
+		dest["port"] = map[string]interface{}{"number": int64(*d.Port)}
+	}
+	return dest
+}
+
+// String describes the version
+func (d istioDestination) String() string {
+	if d.Subset != "" {
+		return d.Host + "/" + d.Subset
+	}
+	return d.Host
+}
+
+// traffic describes the traffic split and mirror set by the task
+func (t *istioTask) traffic() string {
+	parts := []string{}
+	for _, d := range t.With.Destinations {
+		parts = append(parts, fmt.Sprintf("%v=%v%%", d, d.Weight))
+	}
+	if t.With.Mirror != nil {
+		percent := 100.0
+		if t.With.MirrorPercent != nil {
+			percent = *t.With.MirrorPercent
+		}
+		parts = append(parts, fmt.Sprintf("mirror %v=%v%%", t.With.Mirror, percent))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,137 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/cli"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// newVirtualService creates a VirtualService with a primary route to v1, in a fake cluster
+func newVirtualService(t *testing.T) {
+	*kd = *NewFakeKubeDriver(cli.New())
+	vs := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"name": "httpbin", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"hosts": []interface{}{"httpbin"},
+			"http": []interface{}{
+				map[string]interface{}{
+					"name":  "headers",
+					"match": []interface{}{map[string]interface{}{"headers": map[string]interface{}{"x-canary": map[string]interface{}{"exact": "true"}}}},
+					"route": []interface{}{map[string]interface{}{"destination": map[string]interface{}{"host": "httpbin", "subset": "v2"}}},
+				},
+				map[string]interface{}{
+					"name":  "primary",
+					"route": []interface{}{map[string]interface{}{"destination": map[string]interface{}{"host": "httpbin", "subset": "v1"}}},
+				},
+			},
+		},
+	}}
+	_, err := kd.dynamicClient.Resource(virtualServiceGVR).Namespace("default").Create(context.Background(), vs, metav1.CreateOptions{})
+	assert.NoError(t, err)
+}
+
+// getRoute returns the named HTTP route of the VirtualService
+func getRoute(t *testing.T, name string) map[string]interface{} {
+	obj, err := kd.dynamicClient.Resource(virtualServiceGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.NoError(t, err)
+	routes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "http")
+	for _, r := range routes {
+		if r.(map[string]interface{})["name"] == name {
+			return r.(map[string]interface{})
+		}
+	}
+	return nil
+}
+
+func TestIstioShiftTraffic(t *testing.T) {
+	os.Chdir(t.TempDir())
+	newVirtualService(t)
+	it := &istioTask{
+		TaskMeta: TaskMeta{Task: StringPointer(IstioTaskName)},
+		With: istioInputs{
+			VirtualService: "httpbin",
+			Route:          "primary",
+			Destinations: []istioDestination{
+				{Host: "httpbin", Subset: "v1", Weight: 80},
+				{Host: "httpbin", Subset: "v2", Weight: 20},
+			},
+		},
+	}
+	assert.NoError(t, it.run(&Experiment{Spec: []Task{it}, Result: &ExperimentResult{}}))
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"destination": map[string]interface{}{"host": "httpbin", "subset": "v1"}, "weight": int64(80)},
+		map[string]interface{}{"destination": map[string]interface{}{"host": "httpbin", "subset": "v2"}, "weight": int64(20)},
+	}, getRoute(t, "primary")["route"])
+	// other routes are unchanged
+	assert.Equal(t, 1, len(getRoute(t, "headers")["route"].([]interface{})))
+
+	// missing routes are errors
+	it.With.Route = "missing"
+	assert.Error(t, it.run(&Experiment{Spec: []Task{it}, Result: &ExperimentResult{}}))
+}
+
+func TestIstioMirror(t *testing.T) {
+	os.Chdir(t.TempDir())
+	newVirtualService(t)
+	port := uint32(8000)
+	percent := 10.0
+	it := &istioTask{
+		TaskMeta: TaskMeta{Task: StringPointer(IstioTaskName)},
+		With: istioInputs{
+			Namespace:      StringPointer("default"),
+			VirtualService: "httpbin",
+			Mirror:         &istioDestination{Host: "httpbin", Subset: "v2", Port: &port},
+			MirrorPercent:  &percent,
+		},
+	}
+	assert.NoError(t, it.run(&Experiment{Spec: []Task{it}, Result: &ExperimentResult{}}))
+
+	// the first route is used if no route is specified
+	route := getRoute(t, "headers")
+	assert.Equal(t, map[string]interface{}{"host": "httpbin", "subset": "v2", "port": map[string]interface{}{"number": int64(8000)}}, route["mirror"])
+	assert.Equal(t, map[string]interface{}{"value": 10.0}, route["mirrorPercentage"])
+	assert.Nil(t, getRoute(t, "primary")["mirror"])
+}
+
+func TestIstioValidateInputs(t *testing.T) {
+	it := &istioTask{
+		TaskMeta: TaskMeta{Task: StringPointer(IstioTaskName)},
+		With:     istioInputs{VirtualService: "httpbin"},
+	}
+	// destinations or a mirror are required
+	assert.Error(t, it.validateInputs())
+
+	// weights must add up to 100
+	it.With.Destinations = []istioDestination{{Host: "httpbin", Subset: "v1", Weight: 50}, {Host: "httpbin", Subset: "v2", Weight: 20}}
+	assert.Error(t, it.validateInputs())
+	it.With.Destinations[1].Weight = 50
+	assert.NoError(t, it.validateInputs())
+
+	percent := 120.0
+	it.With.Mirror = &istioDestination{Host: "httpbin", Subset: "v2"}
+	it.With.MirrorPercent = &percent
+	assert.Error(t, it.validateInputs())
+
+	it.With.VirtualService = ""
+	assert.Error(t, it.validateInputs())
+}
+
+func TestUnmarshalIstioTask(t *testing.T) {
+	spec := ExperimentSpec{}
+	assert.NoError(t, spec.UnmarshalJSON([]byte(`[{"task": "istio", "if": "SLOs()", "with": {"virtualService": "httpbin", "destinations": [{"host": "httpbin", "weight": 100}]}}]`)))
+	assert.Equal(t, 1, len(spec))
+	it, ok := spec[0].(*istioTask)
+	assert.True(t, ok)
+	assert.Equal(t, "SLOs()", *it.If)
+	assert.Equal(t, "httpbin", it.With.VirtualService)
+}
@@ -0,0 +1,175 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// KnativeTaskName is the name of the task which shifts traffic between revisions of a Knative Service
+	KnativeTaskName = "knative"
+
+	// knativeServiceLabel is the label of Knative Revisions with the name of their Knative Service
+	knativeServiceLabel = "serving.knative.dev/service"
+)
+
+var (
+	// knativeServicesGVR identifies Knative Services
+	knativeServicesGVR = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+	// knativeRevisionsGVR identifies Knative Revisions
+	knativeRevisionsGVR = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "revisions"}
+)
+
+// knativeTarget is a revision of a Knative Service to which traffic is routed
+type knativeTarget struct {
+	// Revision is the name of the revision; for example, httpbin-00002. Optional, if LatestRevision is true.
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	// LatestRevision routes traffic to the latest ready revision of the Knative Service. Optional.
+	LatestRevision bool `json:"latestRevision,omitempty" yaml:"latestRevision,omitempty"`
+	// Tag of the revision; Knative gives tagged revisions their own URL, such as http://candidate-httpbin.default.example.com. Optional.
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	// Percent is the percentage of traffic routed to the revision
+	Percent int32 `json:"percent,omitempty" yaml:"percent,omitempty"`
+}
+
+// String describes the target
+func (t knativeTarget) String() string {
+	if t.LatestRevision {
+		return "latest"
+	}
+	return t.Revision
+}
+
+// knativeInputs identifies the Knative Service and the traffic split that it should have
+type knativeInputs struct {
+	// Namespace of the Knative Service. Optional. If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Service is the name of the Knative Service
+	Service string `json:"service" yaml:"service"`
+	// Traffic replaces the traffic of the Knative Service. Percentages must add up to 100.
+	Traffic []knativeTarget `json:"traffic" yaml:"traffic"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the Knative Service. Optional.
+	// If unspecified, the Knative Service is looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the Knative Service. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// knativeTask shifts traffic between revisions by updating the traffic of a Knative Service.
+// Used with the if condition SLOs(), this enables progressive rollouts of revisions that satisfy SLOs.
+type knativeTask struct {
+	TaskMeta
+	With knativeInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the Knative Service
+func (t *knativeTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the knative task
+func (t *knativeTask) initializeDefaults() {
+	t.driver().initKube()
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *knativeTask) validateInputs() error {
+	if t.With.Service == "" {
+		return errors.New("knative task requires a service")
+	}
+	if len(t.With.Traffic) == 0 {
+		return errors.New("knative task requires traffic")
+	}
+	weights := []int32{}
+	for _, tt := range t.With.Traffic {
+		if (tt.Revision == "") == !tt.LatestRevision {
+			return errors.New("each traffic target requires either a revision or latestRevision")
+		}
+		weights = append(weights, tt.Percent)
+	}
+	return validateWeights(weights)
+}
+
+// run executes the task
+func (t *knativeTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+
+	// update the traffic
+	err = updateTrafficObject(t.driver(), knativeServicesGVR, *t.With.Namespace, t.With.Service, t.updateTraffic)
+	if err != nil {
+		return err
+	}
+	parts := []string{}
+	for _, tt := range t.With.Traffic {
+		parts = append(parts, fmt.Sprintf("%v=%v%%", tt, tt.Percent))
+	}
+	log.Logger.Infof("updated knative service %v/%v: %v", *t.With.Namespace, t.With.Service, strings.Join(parts, ", "))
+	return nil
+}
+
+// updateTraffic sets the traffic of the Knative Service
+func (t *knativeTask) updateTraffic(obj *unstructured.Unstructured) error {
+	traffic := []interface{}{}
+	for _, tt := range t.With.Traffic {
+		target := map[string]interface{}{
+			"percent": int64(tt.Percent),
+		}
+		if tt.LatestRevision {
+			target["latestRevision"] = true
+		} else {
+			target["revisionName"] = tt.Revision
+			target["latestRevision"] = false
+		}
+		if tt.Tag != "" {
+			target["tag"] = tt.Tag
+		}
+		traffic = append(traffic, target)
+	}
+	return unstructured.SetNestedSlice(obj.Object, traffic, "spec", "traffic")
+}
+
+// revisionURL returns the URL of the Knative Revision, from the traffic status of its Knative Service;
+// only revisions that are tagged in the traffic of the Knative Service have their own URL
+func (t *discoveryTask) revisionURL(rev *unstructured.Unstructured) (*url.URL, error) {
+	svcName := rev.GetLabels()[knativeServiceLabel]
+	if svcName == "" {
+		return nil, fmt.Errorf("revision %v does not belong to a knative service", rev.GetName())
+	}
+	svc, err := t.driver().dynamicClient.Resource(knativeServicesGVR).Namespace(rev.GetNamespace()).Get(context.Background(), svcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	traffic, _, _ := unstructured.NestedSlice(svc.Object, "status", "traffic")
+	for _, tt := range traffic {
+		ttm, ok := tt.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(ttm, "revisionName")
+		u, _, _ := unstructured.NestedString(ttm, "url")
+		if name == rev.GetName() && u != "" {
+			return url.Parse(u)
+		}
+	}
+	return nil, fmt.Errorf("revision %v has no URL; tag it in the traffic of knative service %v", rev.GetName(), svcName)
+}
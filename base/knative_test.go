@@ -0,0 +1,117 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newKnativeService creates a Knative Service with a tagged revision, and the revisions of the service, in a fake cluster
+func newKnativeService(t *testing.T) {
+	createTrafficObject(t, knativeServicesGVR, map[string]interface{}{
+		"apiVersion": "serving.knative.dev/v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "httpbin", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"traffic": []interface{}{
+				map[string]interface{}{"revisionName": "httpbin-00001", "percent": int64(100)},
+				map[string]interface{}{"revisionName": "httpbin-00002", "percent": int64(0), "tag": "candidate"},
+			},
+		},
+		"status": map[string]interface{}{
+			"url": "http://httpbin.default.example.com",
+			"traffic": []interface{}{
+				map[string]interface{}{"revisionName": "httpbin-00001", "percent": int64(100)},
+				map[string]interface{}{"revisionName": "httpbin-00002", "percent": int64(0), "tag": "candidate", "url": "http://candidate-httpbin.default.example.com"},
+			},
+		},
+	})
+	for _, rev := range []string{"httpbin-00001", "httpbin-00002"} {
+		createObject(t, knativeRevisionsGVR, map[string]interface{}{
+			"apiVersion": "serving.knative.dev/v1",
+			"kind":       "Revision",
+			"metadata": map[string]interface{}{
+				"name":      rev,
+				"namespace": "default",
+				"labels":    map[string]interface{}{knativeServiceLabel: "httpbin", "serving.knative.dev/revision": rev},
+			},
+		})
+	}
+}
+
+func TestKnativeShiftTraffic(t *testing.T) {
+	os.Chdir(t.TempDir())
+	newKnativeService(t)
+
+	kt := &knativeTask{
+		TaskMeta: TaskMeta{Task: StringPointer(KnativeTaskName)},
+		With: knativeInputs{
+			Service: "httpbin",
+			Traffic: []knativeTarget{
+				{Revision: "httpbin-00001", Percent: 80},
+				{Revision: "httpbin-00002", Tag: "candidate", Percent: 20},
+			},
+		},
+	}
+	assert.NoError(t, kt.run(&Experiment{Spec: []Task{kt}, Result: &ExperimentResult{}}))
+	spec := getTrafficObject(t, knativeServicesGVR, "httpbin")["spec"].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"revisionName": "httpbin-00001", "latestRevision": false, "percent": int64(80)},
+		map[string]interface{}{"revisionName": "httpbin-00002", "latestRevision": false, "percent": int64(20), "tag": "candidate"},
+	}, spec["traffic"])
+
+	// all traffic may be routed to the latest revision
+	kt.With.Traffic = []knativeTarget{{LatestRevision: true, Percent: 100}}
+	assert.NoError(t, kt.run(&Experiment{Spec: []Task{kt}, Result: &ExperimentResult{}}))
+	spec = getTrafficObject(t, knativeServicesGVR, "httpbin")["spec"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"latestRevision": true, "percent": int64(100)}}, spec["traffic"])
+
+	// missing services are errors
+	kt.With.Service = "missing"
+	assert.Error(t, kt.run(&Experiment{Spec: []Task{kt}, Result: &ExperimentResult{}}))
+}
+
+func TestKnativeInvalidInputs(t *testing.T) {
+	for _, in := range []knativeInputs{
+		{Traffic: []knativeTarget{{Revision: "a", Percent: 100}}},
+		{Service: "httpbin"},
+		{Service: "httpbin", Traffic: []knativeTarget{{Revision: "a", Percent: 50}, {Revision: "b", Percent: 40}}},
+		{Service: "httpbin", Traffic: []knativeTarget{{Percent: 100}}},
+		{Service: "httpbin", Traffic: []knativeTarget{{Revision: "a", LatestRevision: true, Percent: 100}}},
+	} {
+		kt := &knativeTask{With: in}
+		assert.Error(t, kt.validateInputs())
+	}
+}
+
+func TestDiscoverRevision(t *testing.T) {
+	os.Chdir(t.TempDir())
+	newKnativeService(t)
+
+	dt := &discoveryTask{
+		TaskMeta: TaskMeta{Task: StringPointer(DiscoveryTaskName)},
+		With: discoveryInputs{
+			Path: "/get",
+			Endpoints: []discoveryEndpoint{
+				{Name: "candidate", Kind: RevisionKind, Selector: map[string]string{"serving.knative.dev/revision": "httpbin-00002"}},
+			},
+		},
+	}
+	exp := &Experiment{Spec: []Task{dt}, Result: &ExperimentResult{}}
+	assert.NoError(t, dt.run(exp))
+	assert.Equal(t, map[string]string{
+		"candidateURL":  "http://candidate-httpbin.default.example.com/get",
+		"candidateHost": "candidate-httpbin.default.example.com:80",
+	}, exp.Result.Outputs)
+
+	// revisions without a tag do not have their own URL
+	dt.With.Timeout = StringPointer("1s")
+	dt.With.Endpoints[0].Selector = map[string]string{"serving.knative.dev/revision": "httpbin-00001"}
+	assert.Error(t, dt.run(exp))
+
+	// revisions do not have ports
+	dt.With.Endpoints[0].Port = intOrStringPointer(intstr.FromInt(80))
+	assert.Error(t, dt.validateInputs())
+}
@@ -0,0 +1,105 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// KServeTaskName is the name of the task which shifts traffic to the canary model of a KServe InferenceService
+	KServeTaskName = "kserve"
+)
+
+// inferenceServiceGVR identifies KServe InferenceServices
+var inferenceServiceGVR = schema.GroupVersionResource{
+	Group:    "serving.kserve.io",
+	Version:  "v1beta1",
+	Resource: "inferenceservices",
+}
+
+// kserveInputs identifies the InferenceService and the percentage of traffic routed to its canary model
+type kserveInputs struct {
+	// Namespace of the InferenceService. Optional. If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// InferenceService is the name of the InferenceService
+	InferenceService string `json:"inferenceService" yaml:"inferenceService"`
+	// CanaryTrafficPercent is the percentage of traffic routed to the canary model; the rest is routed to the
+	// previously rolled out model. Optional. If unspecified, this is the weight of the canary (version 1)
+	// recommended by the latest assessment of the previous model (version 0) and the canary.
+	CanaryTrafficPercent *int32 `json:"canaryTrafficPercent,omitempty" yaml:"canaryTrafficPercent,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the InferenceService. Optional.
+	// If unspecified, the InferenceService is looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the InferenceService. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// kserveTask shifts traffic to the canary model of a KServe InferenceService by updating the canaryTrafficPercent
+// of its predictor. Along with the weights recommended by the assess task, or the if condition SLOs(),
+// this enables progressive rollouts of models within an experiment.
+type kserveTask struct {
+	TaskMeta
+	With kserveInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the InferenceService
+func (t *kserveTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the kserve task
+func (t *kserveTask) initializeDefaults() {
+	t.driver().initKube()
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *kserveTask) validateInputs() error {
+	if t.With.InferenceService == "" {
+		return errors.New("kserve task requires an inferenceService")
+	}
+	if p := t.With.CanaryTrafficPercent; p != nil && (*p < 0 || *p > 100) {
+		return fmt.Errorf("canaryTrafficPercent %v must be between 0 and 100", *p)
+	}
+	return nil
+}
+
+// run executes the task
+func (t *kserveTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+
+	percent := t.With.CanaryTrafficPercent
+	if percent == nil {
+		weights, err := recommendedWeights(exp, 2)
+		if err != nil {
+			log.Logger.Error(err)
+			return err
+		}
+		percent = int32Pointer(weights[1])
+	}
+
+	// update the canary traffic percent
+	err = updateTrafficObject(t.driver(), inferenceServiceGVR, *t.With.Namespace, t.With.InferenceService, func(obj *unstructured.Unstructured) error {
+		return unstructured.SetNestedField(obj.Object, int64(*percent), "spec", "predictor", "canaryTrafficPercent")
+	})
+	if err != nil {
+		return err
+	}
+	log.Logger.Infof("updated inferenceservice %v/%v: canary=%v%%", *t.With.Namespace, t.With.InferenceService, *percent)
+	return nil
+}
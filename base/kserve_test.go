@@ -0,0 +1,59 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKServeShiftTraffic(t *testing.T) {
+	os.Chdir(t.TempDir())
+	createTrafficObject(t, inferenceServiceGVR, map[string]interface{}{
+		"apiVersion": "serving.kserve.io/v1beta1",
+		"kind":       "InferenceService",
+		"metadata":   map[string]interface{}{"name": "sklearn-iris", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"predictor": map[string]interface{}{
+				"canaryTrafficPercent": int64(10),
+				"model":                map[string]interface{}{"modelFormat": map[string]interface{}{"name": "sklearn"}},
+			},
+		},
+	})
+	canaryTrafficPercent := func() interface{} {
+		return getTrafficObject(t, inferenceServiceGVR, "sklearn-iris")["spec"].(map[string]interface{})["predictor"].(map[string]interface{})["canaryTrafficPercent"]
+	}
+
+	kt := &kserveTask{
+		TaskMeta: TaskMeta{Task: StringPointer(KServeTaskName)},
+		With: kserveInputs{
+			InferenceService:     "sklearn-iris",
+			CanaryTrafficPercent: int32Pointer(30),
+		},
+	}
+	exp := &Experiment{Spec: []Task{kt}, Result: &ExperimentResult{}}
+	assert.NoError(t, kt.run(exp))
+	assert.Equal(t, int64(30), canaryTrafficPercent())
+
+	// without a percentage, the weights recommended by the assess task are used
+	kt.With.CanaryTrafficPercent = nil
+	assert.Error(t, kt.run(exp))
+	exp.initResults(1)
+	exp.Result.initInsightsWithNumVersions(2)
+	exp.Result.Insights.Weights = []int32{40, 60}
+	assert.NoError(t, kt.run(exp))
+	assert.Equal(t, int64(60), canaryTrafficPercent())
+
+	// weights must be recommended for the previous model and the canary
+	exp.Result.Insights.Weights = []int32{40, 30, 30}
+	assert.Error(t, kt.run(exp))
+
+	// missing inference services are errors
+	kt.With.CanaryTrafficPercent = int32Pointer(0)
+	kt.With.InferenceService = "missing"
+	assert.Error(t, kt.run(exp))
+
+	kt.With.CanaryTrafficPercent = int32Pointer(120)
+	assert.Error(t, kt.validateInputs())
+	assert.Error(t, (&kserveTask{}).validateInputs())
+}
@@ -2,19 +2,25 @@ package base
 
 import (
 	"errors"
+	"os"
 
 	// Import to initialize client auth plugins.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/iter8-tools/iter8/base/log"
 
+	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
 
 	"k8s.io/client-go/dynamic"
 )
 
 var (
 	kd = NewKubeDriver(cli.New())
+	// targetDrivers enable interaction with clusters other than the one in which the experiment runs;
+	// they are keyed by kubeconfig file and context
+	targetDrivers = map[string]*KubeDriver{}
 )
 
 // KubeDriver embeds Kube configuration, and
@@ -24,6 +30,8 @@ type KubeDriver struct {
 	*cli.EnvSettings
 	// dynamicClient enables unstructured interaction with a Kubernetes cluster
 	dynamicClient dynamic.Interface
+	// helmConfigs enable Helm-based interaction with releases; they are keyed by the namespace of the releases
+	helmConfigs map[string]*action.Configuration
 }
 
 // NewKubeDriver creates and returns a new KubeDriver
@@ -31,6 +39,7 @@ func NewKubeDriver(s *cli.EnvSettings) *KubeDriver {
 	kd := &KubeDriver{
 		EnvSettings:   s,
 		dynamicClient: nil,
+		helmConfigs:   map[string]*action.Configuration{},
 	}
 	return kd
 }
@@ -55,3 +64,48 @@ func (kd *KubeDriver) initKube() (err error) {
 
 	return nil
 }
+
+// helmConfig returns the Helm configuration for releases in the namespace
+func (kd *KubeDriver) helmConfig(namespace string) (*action.Configuration, error) {
+	if cfg, ok := kd.helmConfigs[namespace]; ok {
+		return cfg, nil
+	}
+	cfg := new(action.Configuration)
+	helmDriver := os.Getenv("HELM_DRIVER")
+	if err := cfg.Init(kd.EnvSettings.RESTClientGetter(), namespace, helmDriver, log.Logger.Debugf); err != nil {
+		e := errors.New("unable to get Helm client config")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	// registry client enables charts in OCI registries
+	registryClient, err := registry.NewClient()
+	if err != nil {
+		e := errors.New("unable to get Helm registry client")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	cfg.RegistryClient = registryClient
+	if kd.helmConfigs == nil {
+		kd.helmConfigs = map[string]*action.Configuration{}
+	}
+	kd.helmConfigs[namespace] = cfg
+	return cfg, nil
+}
+
+// targetDriver returns the KubeDriver for the cluster identified by the kubeconfig file and context.
+// If neither is specified, this is the cluster in which the experiment runs.
+func targetDriver(kubeconfig string, kubecontext string) *KubeDriver {
+	if kubeconfig == "" && kubecontext == "" {
+		return kd
+	}
+	key := kubeconfig + "|" + kubecontext
+	if td, ok := targetDrivers[key]; ok {
+		return td
+	}
+	s := cli.New()
+	s.KubeConfig = kubeconfig
+	s.KubeContext = kubecontext
+	td := NewKubeDriver(s)
+	targetDrivers[key] = td
+	return td
+}
@@ -0,0 +1,150 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// LabelTaskName is the name of the task which labels and annotates workloads with the experiment that tested them
+	LabelTaskName = "label"
+
+	// GroupLabel is the label with the experiment group that last tested the workload;
+	// for example, kubectl get deployments -l iter8.tools/group=hello
+	GroupLabel = "iter8.tools/group"
+	// RevisionAnnotation is the annotation with the revision of the experiment that last tested the workload
+	RevisionAnnotation = "iter8.tools/revision"
+	// OutcomeAnnotation is the annotation with the outcome of the experiment that last tested the workload;
+	// this is the milestone reached by the experiment, such as winner-selected, slo-violated, or failed
+	OutcomeAnnotation = "iter8.tools/outcome"
+	// TimeAnnotation is the annotation with the time at which the workload was labeled, in RFC 3339 format
+	TimeAnnotation = "iter8.tools/time"
+)
+
+// labelObject identifies a workload that is labeled with the experiment; for example, a Deployment or Service
+type labelObject struct {
+	// Group of the object. Optional. If unspecified it will be defaulted to ""
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+	// Version of the object. Optional. If unspecified it will be defaulted to ""
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Resource type of the object. Required.
+	Resource string `json:"resource" yaml:"resource"`
+	// Namespace of the object. Optional. If left unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Name of the object. Required.
+	Name string `json:"name" yaml:"name"`
+}
+
+// labelInputs are the inputs of the label task
+type labelInputs struct {
+	// Group is the name of the experiment group; the chart sets it to the name of the release
+	Group string `json:"group" yaml:"group"`
+	// Objects are the workloads that are labeled
+	Objects []labelObject `json:"objects" yaml:"objects"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the objects. Optional.
+	// If unspecified, the objects are looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the objects. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// labelTask labels the tested workloads with the experiment group, and annotates them with the revision and outcome
+// of the experiment, so that users can find the experiment that last tested a workload.
+// The task is usually the last task of the experiment; like the email task, it runs even if an earlier task has failed.
+type labelTask struct {
+	// TaskMeta has fields common to all tasks
+	TaskMeta
+	// With contains the inputs to this task
+	With labelInputs `json:"with" yaml:"with"`
+}
+
+// notifies marks the label task as a notification task
+func (t *labelTask) notifies() {}
+
+// driver returns the KubeDriver for the cluster containing the objects
+func (t *labelTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets the namespace of objects to the namespace of the experiment, if not already set
+func (t *labelTask) initializeDefaults() {
+	for i := range t.With.Objects {
+		if t.With.Objects[i].Namespace == nil {
+			t.With.Objects[i].Namespace = StringPointer(t.driver().Namespace())
+		}
+	}
+}
+
+// validateInputs for this task
+func (t *labelTask) validateInputs() error {
+	if t.With.Group == "" {
+		return errors.New("label task requires the experiment group")
+	}
+	if errs := validation.IsValidLabelValue(t.With.Group); len(errs) > 0 {
+		return fmt.Errorf("invalid experiment group %v: %v", t.With.Group, strings.Join(errs, "; "))
+	}
+	if len(t.With.Objects) == 0 {
+		return errors.New("label task requires at least one object")
+	}
+	for i, o := range t.With.Objects {
+		if o.Resource == "" || o.Name == "" {
+			return fmt.Errorf("label task requires a resource and a name for object %v", i+1)
+		}
+	}
+	return nil
+}
+
+// label labels and annotates the object with the experiment
+func (t *labelTask) label(o labelObject, m milestone, revision int, now time.Time) error {
+	gvr := schema.GroupVersionResource{Group: o.Group, Version: o.Version, Resource: o.Resource}
+	return updateTrafficObject(t.driver(), gvr, *o.Namespace, o.Name, func(obj *unstructured.Unstructured) error {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[GroupLabel] = t.With.Group
+		obj.SetLabels(labels)
+
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[RevisionAnnotation] = strconv.Itoa(revision)
+		annotations[OutcomeAnnotation] = string(m)
+		annotations[TimeAnnotation] = now.UTC().Format(time.RFC3339)
+		obj.SetAnnotations(annotations)
+		return nil
+	})
+}
+
+// run executes this task
+func (t *labelTask) run(exp *Experiment) error {
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	t.initializeDefaults()
+	if err = t.driver().initKube(); err != nil {
+		return err
+	}
+
+	m := experimentMilestone(exp)
+	now := time.Now()
+	for _, o := range t.With.Objects {
+		if err = t.label(o, m, exp.Result.Revision, now); err != nil {
+			return err
+		}
+	}
+	log.Logger.Infof("labeled %v objects with experiment group %v, revision %v, and outcome %v", len(t.With.Objects), t.With.Group, exp.Result.Revision, m)
+	return nil
+}
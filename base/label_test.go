@@ -0,0 +1,93 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLabel(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	createObject(t, deploymentsGVR, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "httpbin",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"app": "httpbin"},
+		},
+	})
+	createObject(t, servicesGVR, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "httpbin", "namespace": "default"},
+	})
+
+	label := &labelTask{
+		TaskMeta: TaskMeta{Task: StringPointer(LabelTaskName)},
+		With: labelInputs{
+			Group: "hello",
+			Objects: []labelObject{
+				{Group: "apps", Version: "v1", Resource: "deployments", Name: "httpbin"},
+				{Version: "v1", Resource: "services", Name: "httpbin"},
+			},
+		},
+	}
+	// the label task runs even though an earlier task failed
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}},
+			label,
+		},
+	}
+	err := RunExperiment(false, &mockDriver{exp})
+	assert.Error(t, err)
+
+	obj, err := kd.dynamicClient.Resource(deploymentsGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "httpbin", GroupLabel: "hello"}, obj.GetLabels())
+	assert.Equal(t, "0", obj.GetAnnotations()[RevisionAnnotation])
+	assert.Equal(t, string(failedMilestone), obj.GetAnnotations()[OutcomeAnnotation])
+	assert.NotEmpty(t, obj.GetAnnotations()[TimeAnnotation])
+
+	// the outcome is updated by later runs
+	exp.Result.Failure = false
+	exp.Result.NumCompletedTasks = 2
+	assert.NoError(t, label.run(exp))
+	obj, err = kd.dynamicClient.Resource(servicesGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", obj.GetLabels()[GroupLabel])
+	assert.Equal(t, string(completedMilestone), obj.GetAnnotations()[OutcomeAnnotation])
+
+	// the labeled objects must exist
+	label.With.Objects[0].Name = "missing"
+	assert.Error(t, label.run(exp))
+}
+
+func TestLabelInputs(t *testing.T) {
+	lt := &labelTask{}
+	assert.Error(t, lt.validateInputs())
+
+	lt.With.Group = "not a label value"
+	assert.Error(t, lt.validateInputs())
+
+	lt.With.Group = "hello"
+	assert.Error(t, lt.validateInputs())
+
+	lt.With.Objects = []labelObject{{Resource: "deployments"}}
+	assert.Error(t, lt.validateInputs())
+
+	lt.With.Objects[0].Name = "httpbin"
+	assert.NoError(t, lt.validateInputs())
+
+	s := ExperimentSpec{}
+	err := s.UnmarshalJSON([]byte(`[{"task": "label", "with": {"group": "hello", "objects": [{"resource": "services", "name": "httpbin"}]}}]`))
+	assert.NoError(t, err)
+	_, ok := s[0].(*labelTask)
+	assert.True(t, ok)
+}
@@ -0,0 +1,99 @@
+package base
+
+import (
+	"errors"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// LinkerdTaskName is the name of the task which shifts traffic using a Linkerd (SMI) TrafficSplit
+	LinkerdTaskName = "linkerd"
+)
+
+// trafficSplitGVR identifies SMI TrafficSplits, which are used by Linkerd for traffic shifting
+var trafficSplitGVR = schema.GroupVersionResource{
+	Group:    "split.smi-spec.io",
+	Version:  "v1alpha2",
+	Resource: "trafficsplits",
+}
+
+// linkerdInputs identifies the TrafficSplit and the traffic split that it should have
+type linkerdInputs struct {
+	// Namespace of the TrafficSplit. Optional. If left unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// TrafficSplit is the name of the TrafficSplit
+	TrafficSplit string `json:"trafficSplit" yaml:"trafficSplit"`
+	// Backends replace the backends of the TrafficSplit. Their weights must add up to 100; their ports are ignored.
+	Backends []trafficBackend `json:"backends" yaml:"backends"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the TrafficSplit. Optional.
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the TrafficSplit. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// linkerdTask shifts traffic between versions by updating the backends of a TrafficSplit
+type linkerdTask struct {
+	TaskMeta
+	With linkerdInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the TrafficSplit
+func (t *linkerdTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the linkerd task
+func (t *linkerdTask) initializeDefaults() {
+	t.driver().initKube()
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *linkerdTask) validateInputs() error {
+	if t.With.TrafficSplit == "" {
+		return errors.New("linkerd task requires a trafficSplit")
+	}
+	if len(t.With.Backends) == 0 {
+		return errors.New("linkerd task requires backends")
+	}
+	return validateBackends(t.With.Backends)
+}
+
+// run executes the task
+func (t *linkerdTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+
+	// update the backends
+	err = updateTrafficObject(t.driver(), trafficSplitGVR, *t.With.Namespace, t.With.TrafficSplit, t.updateBackends)
+	if err != nil {
+		return err
+	}
+	log.Logger.Infof("updated trafficsplit %v/%v: %v", *t.With.Namespace, t.With.TrafficSplit, describeBackends(t.With.Backends))
+	return nil
+}
+
+// updateBackends sets the backends of the TrafficSplit
+func (t *linkerdTask) updateBackends(obj *unstructured.Unstructured) error {
+	backends := []interface{}{}
+	for _, b := range t.With.Backends {
+		backends = append(backends, map[string]interface{}{
+			"service": b.Service,
+			"weight":  int64(b.Weight),
+		})
+	}
+	return unstructured.SetNestedSlice(obj.Object, backends, "spec", "backends")
+}
@@ -0,0 +1,87 @@
+package base
+
+import (
+	"regexp"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// nonOKCodeRegexp matches the Fortio logs of responses whose status codes are not OK
+var nonOKCodeRegexp = regexp.MustCompile(`Non ok http code (-?\d+)`)
+
+// LiveMetrics are the interim values of built-in metrics while a load test runs.
+// They enable long experiments to be monitored before they complete.
+type LiveMetrics struct {
+	// Task is the name of the task that generates the load
+	Task string `json:"task" yaml:"task"`
+	// Time when the metrics were computed, in RFC 3339 format
+	Time string `json:"time" yaml:"time"`
+	// Elapsed is the time since the load test started
+	Elapsed string `json:"elapsed" yaml:"elapsed"`
+	// Requests is the number of requests sent so far
+	Requests int64 `json:"requests" yaml:"requests"`
+	// Errors is the number of responses so far that were errors
+	Errors int64 `json:"errors" yaml:"errors"`
+	// ErrorRate is the fraction of responses so far that were errors
+	ErrorRate float64 `json:"errorRate" yaml:"errorRate"`
+	// QPS is the rate at which requests were sent so far, in requests per second
+	QPS float64 `json:"qps" yaml:"qps"`
+	// MeanLatency is the mean latency of requests so far, in milliseconds
+	MeanLatency float64 `json:"latencyMean" yaml:"latencyMean"`
+	// LatencyP50 is the running 50th percentile latency, in milliseconds
+	LatencyP50 float64 `json:"latencyP50" yaml:"latencyP50"`
+	// LatencyP99 is the running 99th percentile latency, in milliseconds
+	LatencyP99 float64 `json:"latencyP99" yaml:"latencyP99"`
+}
+
+// LiveMetricsWriter is implemented by drivers that publish the live metrics of running experiments;
+// for example, as an annotation of the object that stores the experiment in Kubernetes
+type LiveMetricsWriter interface {
+	// WriteLiveMetrics publishes the live metrics of the running experiment
+	WriteLiveMetrics(m *LiveMetrics) error
+}
+
+// liveMetricsOpts configure the streaming of live metrics during a load test
+type liveMetricsOpts struct {
+	// interval at which live metrics are streamed
+	interval time.Duration
+	// isError returns true if a status code is an error
+	isError func(code int) bool
+	// exp is the running experiment
+	exp *Experiment
+}
+
+// newLiveMetricsOpts returns options for streaming live metrics at the given interval;
+// nil is returned if the interval is not specified
+func newLiveMetricsOpts(interval *string, isError func(code int) bool, exp *Experiment) (*liveMetricsOpts, error) {
+	if interval == nil {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(*interval)
+	if err != nil {
+		return nil, err
+	}
+	return &liveMetricsOpts{
+		interval: d,
+		isError:  isError,
+		exp:      exp,
+	}, nil
+}
+
+// stream logs the live metrics, and publishes them using the driver of the experiment, if it is a live metrics writer
+func (o *liveMetricsOpts) stream(m *LiveMetrics) {
+	log.Logger.WithField("requests", m.Requests).
+		WithField("errorRate", m.ErrorRate).
+		WithField("latencyP50", m.LatencyP50).
+		WithField("latencyP99", m.LatencyP99).
+		Infof("%v live metrics after %v", m.Task, m.Elapsed)
+	if o.exp == nil {
+		return
+	}
+	if lw, ok := o.exp.driver.(LiveMetricsWriter); ok {
+		if err := lw.WriteLiveMetrics(m); err != nil {
+			log.Logger.WithStackTrace(err.Error()).Warn("unable to write live metrics")
+		}
+	}
+}
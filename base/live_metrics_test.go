@@ -0,0 +1,95 @@
+package base
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// liveMetricsDriver is a mock driver that records live metrics
+type liveMetricsDriver struct {
+	mockDriver
+	mu   sync.Mutex
+	live []*LiveMetrics
+}
+
+// WriteLiveMetrics records the live metrics
+func (d *liveMetricsDriver) WriteLiveMetrics(m *LiveMetrics) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.live = append(d.live, m)
+	return nil
+}
+
+func TestLiveMetricsSnapshot(t *testing.T) {
+	ct := &collectHTTPTask{}
+	ct.initializeDefaults()
+	live, err := newLiveMetricsOpts(StringPointer("1h"), ct.errorCode, nil)
+	assert.NoError(t, err)
+	lt := startLoadTracker(CollectHTTPTaskName, 0, 0, 1, live, nil)
+	defer lt.stop()
+	for i := 0; i < 4; i++ {
+		lt.Report(0, 0, 0.01)
+	}
+	lt.Write([]byte("W [0] Non ok http code 503\nW [1] Non ok http code 302 (HTTP/1.1 302)\n"))
+
+	m := lt.liveSnapshot()
+	assert.Equal(t, CollectHTTPTaskName, m.Task)
+	assert.Equal(t, int64(4), m.Requests)
+	assert.Equal(t, int64(1), m.Errors)
+	assert.Equal(t, 0.25, m.ErrorRate)
+	assert.InDelta(t, 10.0, m.LatencyP50, 1.0)
+	assert.InDelta(t, 10.0, m.LatencyP99, 1.0)
+
+	// live metrics are optional
+	live, err = newLiveMetricsOpts(nil, ct.errorCode, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, live)
+	_, err = newLiveMetricsOpts(StringPointer("soon"), ct.errorCode, nil)
+	assert.Error(t, err)
+}
+
+func TestStreamLiveMetrics(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1)%2 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{Task: StringPointer(CollectHTTPTaskName)},
+		With: collectHTTPInputs{
+			URL:                 srv.URL,
+			Duration:            StringPointer("1s"),
+			QPS:                 float32Pointer(20),
+			Connections:         intPointer(1),
+			LiveMetricsInterval: StringPointer("200ms"),
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	d := &liveMetricsDriver{mockDriver: mockDriver{exp}}
+	exp.driver = d
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assert.NotEmpty(t, d.live)
+	last := d.live[len(d.live)-1]
+	assert.Greater(t, last.Requests, int64(0))
+	assert.Greater(t, last.Errors, int64(0))
+	assert.Greater(t, last.ErrorRate, 0.0)
+
+	// invalid intervals are rejected
+	ct.With.LiveMetricsInterval = StringPointer("-1s")
+	assert.Error(t, ct.validateInputs())
+}
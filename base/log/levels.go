@@ -0,0 +1,125 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Subsystems of Iter8, whose log levels can be set individually
+const (
+	// BaseSubsystem logs experiments and their tasks
+	BaseSubsystem = "base"
+	// DriverSubsystem logs the storage of experiments; for example, in Kubernetes or in object stores
+	DriverSubsystem = "driver"
+	// ActionSubsystem logs the actions of Iter8 commands, and reports
+	ActionSubsystem = "action"
+	// CmdSubsystem logs the Iter8 CLI
+	CmdSubsystem = "cmd"
+)
+
+// Subsystems are the subsystems of Iter8, whose log levels can be set individually
+var Subsystems = []string{BaseSubsystem, DriverSubsystem, ActionSubsystem, CmdSubsystem}
+
+// modulePrefix is the prefix of the functions of Iter8 packages
+const modulePrefix = "github.com/iter8-tools/iter8/"
+
+// subsystemLevels are the log levels of subsystems
+type subsystemLevels struct {
+	// mu protects the levels
+	mu sync.RWMutex
+	// level is the log level of subsystems without their own level
+	level logrus.Level
+	// levels are the log levels of subsystems, by subsystem
+	levels map[string]logrus.Level
+}
+
+// ParseLevels parses log levels of the form info,base=trace,driver=warning, which consist of the log level,
+// optionally followed by the log levels of subsystems
+func ParseLevels(s string) (logrus.Level, map[string]logrus.Level, error) {
+	parts := strings.Split(s, ",")
+	level, err := logrus.ParseLevel(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return level, nil, err
+	}
+	levels := map[string]logrus.Level{}
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return level, nil, fmt.Errorf("invalid log level %v for subsystem; must be of the form subsystem=level", p)
+		}
+		subsystem := strings.TrimSpace(kv[0])
+		if !isSubsystem(subsystem) {
+			return level, nil, fmt.Errorf("unknown subsystem %v; must be one of %v", subsystem, strings.Join(Subsystems, ", "))
+		}
+		if levels[subsystem], err = logrus.ParseLevel(strings.TrimSpace(kv[1])); err != nil {
+			return level, nil, err
+		}
+	}
+	return level, levels, nil
+}
+
+// isSubsystem returns true if s is a subsystem of Iter8
+func isSubsystem(s string) bool {
+	for _, x := range Subsystems {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLevels sets the log level, along with the log levels of subsystems, which take precedence for entries logged by them
+func (l *Iter8Logger) SetLevels(level logrus.Level, levels map[string]logrus.Level) {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+	l.levels.level = level
+	l.levels.levels = map[string]logrus.Level{}
+	// the logger logs entries at the most verbose level of any subsystem; entries above the level of their subsystem are dropped
+	max := level
+	for s, lvl := range levels {
+		l.levels.levels[s] = lvl
+		if lvl > max {
+			max = lvl
+		}
+	}
+	l.SetLevel(max)
+}
+
+// enabled returns true if the entry is at or below the log level of the subsystem that logged it
+func (ls *subsystemLevels) enabled(entry *logrus.Entry) bool {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	if len(ls.levels) == 0 {
+		return true
+	}
+	level := ls.level
+	if lvl, ok := ls.levels[callerSubsystem()]; ok {
+		level = lvl
+	}
+	return entry.Level <= level
+}
+
+// callerSubsystem returns the subsystem of the function that logged the entry being formatted; empty if it is not known
+func callerSubsystem() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if strings.HasPrefix(f.Function, modulePrefix) && !strings.HasPrefix(f.Function, modulePrefix+"base/log.") {
+			// the subsystem is the top level package of the function; for example, action for action/report
+			pkg := strings.TrimPrefix(f.Function, modulePrefix)
+			if i := strings.IndexAny(pkg, "/."); i >= 0 {
+				pkg = pkg[:i]
+			}
+			return pkg
+		}
+		if !more {
+			return ""
+		}
+	}
+}
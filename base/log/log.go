@@ -3,7 +3,9 @@ package log
 
 import (
 	"bufio"
+	"encoding/json"
 	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
@@ -13,8 +15,13 @@ var Level = logrus.InfoLevel
 
 // Iter8Logger inherits all methods from logrus logger.
 // Provides additional methods for standardized Iter8 logging.
+// Entries are dropped if they are above the log level of the subsystem that logged them, and secrets in entries are redacted.
 type Iter8Logger struct {
 	*logrus.Logger
+	// levels are the log levels of subsystems
+	levels *subsystemLevels
+	// hooksMu serializes changes to the hooks of the logger
+	hooksMu sync.Mutex
 }
 
 // StackTrace is the trace from external components like a shell scripts run by an Iter8 task.
@@ -25,20 +32,70 @@ type StackTrace struct {
 	Trace string
 }
 
+const (
+	// TextFormat is the log format intended for humans
+	TextFormat = "text"
+	// JSONFormat is the log format intended for log pipelines; each log entry is a JSON object
+	JSONFormat = "json"
+	// timestampFormat is the format of log timestamps
+	timestampFormat = "2006-01-02 15:04:05"
+)
+
 // Logger to be used in all of Iter8.
 var Logger *Iter8Logger
 
 // init initializes the logger.
 func init() {
-	Logger = &Iter8Logger{logrus.New()}
-	Logger.SetFormatter(&logrus.TextFormatter{
-		TimestampFormat: "2006-01-02 15:04:05",
-		FullTimestamp:   true,
-		DisableQuote:    true,
-		DisableSorting:  true,
-	})
+	Logger = &Iter8Logger{
+		Logger: logrus.New(),
+		levels: &subsystemLevels{},
+	}
+	Logger.SetFormat(TextFormat)
+	Logger.SetLevels(Level, nil)
+}
+
+// AddHook adds a hook that is fired for log entries, and returns a function that removes it;
+// for example, to capture the logs of an experiment while it runs
+func (l *Iter8Logger) AddHook(hook logrus.Hook) func() {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+	l.Logger.AddHook(hook)
+	return func() {
+		l.hooksMu.Lock()
+		defer l.hooksMu.Unlock()
+		hooks := logrus.LevelHooks{}
+		for level, hs := range l.Hooks {
+			for _, h := range hs {
+				if h != hook {
+					hooks[level] = append(hooks[level], h)
+				}
+			}
+		}
+		l.ReplaceHooks(hooks)
+	}
+}
 
-	Logger.SetLevel(Level)
+// SetFormat sets the format of log entries; either text or json.
+// Unknown formats are treated as text.
+func (l *Iter8Logger) SetFormat(format string) {
+	if format == JSONFormat {
+		l.SetFormatter(&iter8Formatter{
+			Formatter: &logrus.JSONFormatter{
+				TimestampFormat: timestampFormat,
+			},
+			levels: l.levels,
+		})
+		return
+	}
+	l.SetFormatter(&iter8Formatter{
+		Formatter: &logrus.TextFormatter{
+			TimestampFormat: timestampFormat,
+			FullTimestamp:   true,
+			DisableQuote:    true,
+			DisableSorting:  true,
+		},
+		levels: l.levels,
+	})
 }
 
 // WithStackTrace yields a log entry with a formatted stack trace field embedded in it.
@@ -57,6 +114,11 @@ func (l *Iter8Logger) WithIndentedTrace(t string) *logrus.Entry {
 	})
 }
 
+// MarshalJSON marshals the raw trace, so that traces are readable in JSON logs
+func (st *StackTrace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(st.Trace)
+}
+
 // String processes stack traces by prefixing each line of the trace with prefix.
 // This enables other tools like grep to easily filter out these traces if needed.
 func (st *StackTrace) String() string {
@@ -1,9 +1,12 @@
 package log
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,3 +22,116 @@ func TestStackTrace(t *testing.T) {
 	assert.Contains(t, st.String(), "::Trace:: b")
 
 }
+
+func TestJSONFormat(t *testing.T) {
+	var b bytes.Buffer
+	out := Logger.Out
+	Logger.Out = &b
+	Logger.SetFormat(JSONFormat)
+	defer func() {
+		Logger.Out = out
+		Logger.SetFormat(TextFormat)
+	}()
+
+	Logger.WithStackTrace("a\nb").Error("hello there")
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &entry))
+	assert.Equal(t, "hello there", entry["msg"])
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "a\nb", entry["stack-trace"])
+}
+
+func TestParseLevels(t *testing.T) {
+	level, levels, err := ParseLevels("info")
+	assert.NoError(t, err)
+	assert.Equal(t, logrus.InfoLevel, level)
+	assert.Empty(t, levels)
+
+	level, levels, err = ParseLevels("warning, base=trace,driver=error")
+	assert.NoError(t, err)
+	assert.Equal(t, logrus.WarnLevel, level)
+	assert.Equal(t, map[string]logrus.Level{BaseSubsystem: logrus.TraceLevel, DriverSubsystem: logrus.ErrorLevel}, levels)
+
+	for _, s := range []string{"loud", "info,base", "info,kube=trace", "info,base=loud"} {
+		_, _, err = ParseLevels(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	var b bytes.Buffer
+	out := Logger.Out
+	Logger.Out = &b
+	defer func() {
+		Logger.Out = out
+		Logger.SetLevels(Level, nil)
+	}()
+
+	// the logger logs at the most verbose level of any subsystem
+	Logger.SetLevels(logrus.WarnLevel, map[string]logrus.Level{BaseSubsystem: logrus.TraceLevel})
+	assert.Equal(t, logrus.TraceLevel, Logger.GetLevel())
+
+	// entries of other subsystems are logged at the log level
+	Logger.Info("dropped")
+	Logger.Warn("logged")
+	assert.NotContains(t, b.String(), "dropped")
+	assert.Contains(t, b.String(), "logged")
+}
+
+func TestRedact(t *testing.T) {
+	for in, want := range map[string]string{
+		"add header: Authorization, value: Bearer abc.def-123": "add header: Authorization, value: Bearer [REDACTED]",
+		`{"Authorization": "Basic dXNlcjpwYXNz"}`:              `{"Authorization": "Basic [REDACTED]"}`,
+		"curl -H 'authorization: bearer xyz' http://prom":      "curl -H 'authorization: bearer [REDACTED]' http://prom",
+		"add header: DD-API-KEY, value: 0123456789abcdef":      "add header: DD-API-KEY, value: [REDACTED]",
+		"url: http://host/query?access_token=abc&q=up":         "url: http://host/query?access_token=[REDACTED]&q=up",
+		`password: "hunter22"`:                                 `password: "[REDACTED]"`,
+		"request-count for version 0":                          "request-count for version 0",
+	} {
+		assert.Equal(t, want, Redact(in))
+	}
+
+	AddSecret("s3cr3t-value", "ab")
+	assert.Equal(t, "value is [REDACTED]", Redact("value is s3cr3t-value"))
+	// short values are not redacted
+	assert.Equal(t, "ab", Redact("ab"))
+
+	assert.True(t, IsSecretKey("X-Auth-Token"))
+	assert.True(t, IsSecretKey("Authorization"))
+	assert.False(t, IsSecretKey("Content-Type"))
+
+	// secrets in messages and traces are redacted
+	var b bytes.Buffer
+	out := Logger.Out
+	Logger.Out = &b
+	defer func() { Logger.Out = out }()
+	Logger.WithStackTrace("Authorization: Bearer xyz").Error("token=abc")
+	assert.NotContains(t, b.String(), "xyz")
+	assert.NotContains(t, b.String(), "abc")
+	assert.Contains(t, b.String(), Redacted)
+}
+
+// countingHook counts the entries that it fires on
+type countingHook struct {
+	count int
+}
+
+func (h *countingHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *countingHook) Fire(*logrus.Entry) error {
+	h.count++
+	return nil
+}
+
+func TestAddHook(t *testing.T) {
+	out := Logger.Out
+	Logger.Out = &bytes.Buffer{}
+	defer func() { Logger.Out = out }()
+
+	h := &countingHook{}
+	remove := Logger.AddHook(h)
+	Logger.Info("hello there")
+	remove()
+	Logger.Info("hello again")
+	assert.Equal(t, 1, h.count)
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces secrets in log entries
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the minimum length of secret values that are redacted; shorter values would redact unrelated text
+const minSecretLength = 4
+
+var (
+	// authorizationRegex matches the credentials in Authorization headers; for example, Authorization: Bearer xyz
+	authorizationRegex = regexp.MustCompile(`(?i)((?:proxy-)?authorization["']?(?:\s*[:=]\s*|,\s*value:\s*)["']?(?:bearer|basic|token|sharedkey|aws4-hmac-sha256)?\s*)[^\s"',}\]]+`)
+	// bearerRegex matches bearer tokens
+	bearerRegex = regexp.MustCompile(`(?i)(\bbearer\s+)[a-z0-9\-._~+/]+=*`)
+	// secretKeyRegex matches the values of keys that name secrets; for example, token=xyz, "password": "xyz", or DD-API-KEY, value: xyz
+	secretKeyRegex = regexp.MustCompile(`(?i)([\w-]*` + secretNames + `["']?(?:\s*[:=]\s*|,\s*value:\s*)["']?)[^\s"',&}\]]+`)
+	// secretNameRegex matches names of keys and headers that name secrets
+	secretNameRegex = regexp.MustCompile(`(?i)authorization|` + secretNames)
+)
+
+// secretNames matches the parts of names of keys that name secrets
+const secretNames = `(?:token|password|passwd|secret|api[_-]?key|app(?:lication)?[_-]?key|access[_-]?key)`
+
+// IsSecretKey returns true if the name of the key or header names a secret; for example, Authorization, X-Auth-Token, or DD-API-KEY
+func IsSecretKey(name string) bool {
+	return secretNameRegex.MatchString(name)
+}
+
+// secrets are the secret values that are redacted in log entries
+var secrets = struct {
+	// mu protects values
+	mu sync.RWMutex
+	// values are the secret values, longest first
+	values []string
+}{}
+
+// AddSecret registers secret values, such as tokens read from Kubernetes secrets, which are redacted wherever they appear in log entries.
+// Values that are already registered are ignored, so that secrets resolved by every loop of an experiment are registered once.
+func AddSecret(values ...string) {
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	for _, v := range values {
+		if len(v) >= minSecretLength && !containsString(secrets.values, v) {
+			secrets.values = append(secrets.values, v)
+		}
+	}
+	// longer secrets are redacted first, so that secrets that contain others are redacted fully
+	sort.Slice(secrets.values, func(i, j int) bool {
+		return len(secrets.values[i]) > len(secrets.values[j])
+	})
+}
+
+// containsString returns true if the value is in the list
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces Authorization headers, bearer tokens, values of keys that name secrets, and registered secret values in s
+func Redact(s string) string {
+	secrets.mu.RLock()
+	for _, v := range secrets.values {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	secrets.mu.RUnlock()
+	s = authorizationRegex.ReplaceAllString(s, "${1}"+Redacted)
+	s = bearerRegex.ReplaceAllString(s, "${1}"+Redacted)
+	return secretKeyRegex.ReplaceAllString(s, "${1}"+Redacted)
+}
+
+// redactEntry returns a copy of the entry, in which secrets in the message and fields are redacted
+func redactEntry(entry *logrus.Entry) *logrus.Entry {
+	c := *entry
+	c.Message = Redact(entry.Message)
+	c.Data = make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		switch x := v.(type) {
+		case string:
+			c.Data[k] = Redact(x)
+		case *StackTrace:
+			c.Data[k] = &StackTrace{prefix: x.prefix, Trace: Redact(x.Trace)}
+		case error:
+			c.Data[k] = Redact(x.Error())
+		default:
+			c.Data[k] = v
+		}
+	}
+	return &c
+}
+
+// iter8Formatter formats the log entries of Iter8. Entries above the log level of the subsystem that logged them are dropped,
+// and secrets are redacted.
+type iter8Formatter struct {
+	// Formatter formats entries
+	logrus.Formatter
+	// levels are the log levels of subsystems
+	levels *subsystemLevels
+}
+
+// Format formats the entry; nothing is written for dropped entries
+func (f *iter8Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.levels.enabled(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(redactEntry(entry))
+}
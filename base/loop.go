@@ -0,0 +1,174 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// loopStoppedMaxLoops indicates that the experiment completed its maximum number of loops
+	loopStoppedMaxLoops = "completed maxLoops"
+	// loopStoppedSLOViolation indicates that a version did not satisfy SLOs
+	loopStoppedSLOViolation = "SLOs not satisfied"
+	// loopStoppedFailure indicates that a task failed
+	loopStoppedFailure = "task failure"
+	// loopStoppedAborted indicates that the experiment was aborted
+	loopStoppedAborted = "aborted"
+)
+
+// LoopSpec configures the loops of an experiment. Each loop runs all tasks of the experiment.
+type LoopSpec struct {
+	// Interval between the start of consecutive loops; for example, 1m. Optional.
+	// If unspecified, a loop starts as soon as the previous loop completes.
+	Interval *string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// MaxLoops is the maximum number of loops. Optional. If unspecified it will be defaulted to 1
+	MaxLoops int `json:"maxLoops,omitempty" yaml:"maxLoops,omitempty"`
+	// StopOnSLOViolation stops looping after a loop in which a version does not satisfy SLOs. Optional.
+	StopOnSLOViolation bool `json:"stopOnSLOViolation,omitempty" yaml:"stopOnSLOViolation,omitempty"`
+}
+
+// LoopStatus records the loop schedule of an experiment
+type LoopStatus struct {
+	// LastLoopTime is the time when the latest loop started
+	LastLoopTime time.Time `json:"lastLoopTime" yaml:"lastLoopTime"`
+	// NextLoopTime is the time when the next loop is scheduled to start; nil if looping has stopped
+	NextLoopTime *time.Time `json:"nextLoopTime,omitempty" yaml:"nextLoopTime,omitempty"`
+	// StopReason describes why looping has stopped; empty while looping
+	StopReason string `json:"stopReason,omitempty" yaml:"stopReason,omitempty"`
+}
+
+// validate validates the loop spec
+func (l *LoopSpec) validate() error {
+	if l.MaxLoops < 0 {
+		return fmt.Errorf("invalid maxLoops %v", l.MaxLoops)
+	}
+	if l.Interval != nil {
+		if _, err := time.ParseDuration(*l.Interval); err != nil {
+			return fmt.Errorf("invalid loop interval %v", *l.Interval)
+		}
+	}
+	return nil
+}
+
+// maxLoops returns the maximum number of loops
+func (l *LoopSpec) maxLoops() int {
+	if l == nil || l.MaxLoops == 0 {
+		return 1
+	}
+	return l.MaxLoops
+}
+
+// interval returns the interval between the start of consecutive loops
+func (l *LoopSpec) interval() time.Duration {
+	if l == nil || l.Interval == nil {
+		return 0
+	}
+	d, _ := time.ParseDuration(*l.Interval)
+	return d
+}
+
+// stopReason returns the reason to stop looping after the latest loop, or an empty string if looping continues
+func (exp *Experiment) stopReason() string {
+	switch {
+	case exp.Result.Aborted:
+		return loopStoppedAborted
+	case exp.Result.Failure:
+		return loopStoppedFailure
+	case exp.Result.NumLoops >= exp.Loop.maxLoops():
+		return loopStoppedMaxLoops
+	case exp.Loop != nil && exp.Loop.StopOnSLOViolation && !exp.SLOs():
+		return loopStoppedSLOViolation
+	}
+	return ""
+}
+
+// runLoops runs loops of the experiment until it completes its maximum number of loops, or is stopped.
+// The first loop runs tasks starting at the given index; this enables an interrupted loop to be resumed.
+// The loop schedule is recorded in the result, so that loops can be continued by later runs that reuse the result.
+func (exp *Experiment) runLoops(driver Driver, start int) error {
+	if exp.Loop != nil {
+		if err := exp.Loop.validate(); err != nil {
+			log.Logger.Error(err)
+			return err
+		}
+	}
+	if exp.Result.Loop != nil && exp.Result.Loop.StopReason != "" {
+		log.Logger.Infof("experiment has stopped looping: %v", exp.Result.Loop.StopReason)
+		return nil
+	}
+	for {
+		if exp.Result.Loop == nil {
+			exp.Result.Loop = &LoopStatus{}
+		}
+		if start == 0 {
+			// wait for the scheduled start of the loop
+			if exp.Result.Loop.NextLoopTime != nil {
+				if err := exp.waitUntil(*exp.Result.Loop.NextLoopTime, driver); err != nil {
+					log.Logger.Info(err.Error())
+					if exp.interrupted() {
+						// looping continues in a later run that reuses the result
+						exp.Result.Interrupted = true
+						if e := driver.Write(exp); e != nil {
+							return e
+						}
+						return errInterrupted
+					}
+					exp.Result.Aborted = true
+					exp.Result.Loop.NextLoopTime = nil
+					exp.Result.Loop.StopReason = loopStoppedAborted
+					return driver.Write(exp)
+				}
+			}
+			exp.Result.Loop.LastLoopTime = time.Now()
+			exp.Result.Loop.NextLoopTime = nil
+		}
+
+		err := exp.run(driver, start)
+		start = 0
+		if exp.Result.Interrupted {
+			// looping continues in a later run that resumes the experiment
+			return err
+		}
+
+		if reason := exp.stopReason(); reason != "" {
+			exp.Result.Loop.StopReason = reason
+			log.Logger.Infof("experiment stopped looping after loop %v: %v", exp.Result.NumLoops, reason)
+			if e := driver.Write(exp); e != nil {
+				return e
+			}
+			return err
+		}
+		next := exp.Result.Loop.LastLoopTime.Add(exp.Loop.interval())
+		exp.Result.Loop.NextLoopTime = &next
+		if e := driver.Write(exp); e != nil {
+			return e
+		}
+		if err != nil {
+			return err
+		}
+		log.Logger.Infof("experiment loop %v completed; next loop at %v", exp.Result.NumLoops, next.Format(time.RFC3339))
+	}
+}
+
+// waitUntil waits until the given time. Waiting stops with an error if the experiment is asked to stop, or is interrupted.
+func (exp *Experiment) waitUntil(t time.Time, driver Driver) error {
+	for {
+		if ac, ok := driver.(AbortChecker); ok && ac.AbortRequested() {
+			return errors.New("experiment aborted while waiting for the next loop")
+		}
+		if exp.interrupted() {
+			return errors.New("experiment interrupted while waiting for the next loop")
+		}
+		d := time.Until(t)
+		if d <= 0 {
+			return nil
+		}
+		if d > time.Second {
+			d = time.Second
+		}
+		time.Sleep(d)
+	}
+}
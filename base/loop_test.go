@@ -0,0 +1,66 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunExperimentLoops(t *testing.T) {
+	os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo loop >> loops.txt")}},
+		},
+		Loop: &LoopSpec{Interval: StringPointer("10ms"), MaxLoops: 3},
+	}
+	d := &mockDriver{exp}
+	assert.NoError(t, RunExperiment(false, d))
+	assert.True(t, exp.Completed())
+	assert.Equal(t, 3, exp.Result.NumLoops)
+	assert.Equal(t, loopStoppedMaxLoops, exp.Result.Loop.StopReason)
+	assert.Nil(t, exp.Result.Loop.NextLoopTime)
+
+	b, err := ioutil.ReadFile("loops.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(b), "loop"))
+
+	// runs that reuse the result do not loop again once looping has stopped
+	assert.NoError(t, RunExperiment(true, d))
+	assert.Equal(t, 3, exp.Result.NumLoops)
+}
+
+func TestRunExperimentLoopsStopped(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// without insights, SLOs are not satisfied
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo loop")}},
+		},
+		Loop: &LoopSpec{MaxLoops: 3, StopOnSLOViolation: true},
+	}
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.Equal(t, 1, exp.Result.NumLoops)
+	assert.Equal(t, loopStoppedSLOViolation, exp.Result.Loop.StopReason)
+
+	// task failures stop looping
+	exp = &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("false")}},
+		},
+		Loop: &LoopSpec{MaxLoops: 3},
+	}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+	assert.Equal(t, 1, exp.Result.NumLoops)
+	assert.Equal(t, loopStoppedFailure, exp.Result.Loop.StopReason)
+}
+
+func TestLoopSpecValidate(t *testing.T) {
+	assert.NoError(t, (&LoopSpec{}).validate())
+	assert.NoError(t, (&LoopSpec{Interval: StringPointer("1m"), MaxLoops: 10}).validate())
+	assert.Error(t, (&LoopSpec{MaxLoops: -1}).validate())
+	assert.Error(t, (&LoopSpec{Interval: StringPointer("soon")}).validate())
+}
@@ -0,0 +1,250 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// ManifestsTaskName is the name of the task which applies or deletes Kubernetes manifests, and patches Kubernetes objects
+	ManifestsTaskName = "manifests"
+	// applyAction creates or updates the objects of the manifest
+	applyAction = "apply"
+	// deleteAction deletes the objects of the manifest
+	deleteAction = "delete"
+)
+
+// patchTypes are the types of patches supported by the manifests task
+var patchTypes = map[string]types.PatchType{
+	"merge":     types.MergePatchType,
+	"json":      types.JSONPatchType,
+	"strategic": types.StrategicMergePatchType,
+}
+
+// manifestPatch is a patch of a Kubernetes object; for example, a patch of the image of a Deployment
+type manifestPatch struct {
+	// Group of the object. Optional. If unspecified it will be defaulted to ""
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+	// Version of the object
+	Version string `json:"version" yaml:"version"`
+	// Resource type of the object; for example, deployments
+	Resource string `json:"resource" yaml:"resource"`
+	// Name of the object
+	Name string `json:"name" yaml:"name"`
+	// Type of the patch; merge, json, or strategic. Optional. If unspecified it will be defaulted to merge
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Patch is the YAML or JSON patch
+	Patch string `json:"patch" yaml:"patch"`
+}
+
+// gvr returns the group, version, and resource of the patched object
+func (p *manifestPatch) gvr() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: p.Group, Version: p.Version, Resource: p.Resource}
+}
+
+// manifestsInputs identifies the manifest and patches, and whether the objects of the manifest are applied or deleted
+type manifestsInputs struct {
+	// Action is apply or delete. Optional. If unspecified it will be defaulted to apply
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Namespace of the objects whose manifests do not specify a namespace, and of patched objects. Optional.
+	// If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Manifest contains one or more Kubernetes objects in YAML, separated by ---. Optional.
+	// The resource type of each object is derived from its kind; for example, the resource type of Deployments is deployments.
+	Manifest string `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+	// Patches of Kubernetes objects, applied after the manifest. Optional. Only used with the apply action.
+	Patches []manifestPatch `json:"patches,omitempty" yaml:"patches,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the objects. Optional.
+	// If unspecified, the objects are in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the objects. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// manifestsTask applies or deletes Kubernetes manifests and patches Kubernetes objects.
+// Used with the if conditions SLOs() and !SLOs(), this enables the experiment to promote or roll back versions;
+// for example, by patching the image of a Deployment, or the selector of a Service.
+type manifestsTask struct {
+	TaskMeta
+	With manifestsInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the objects
+func (t *manifestsTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the manifests task
+func (t *manifestsTask) initializeDefaults() {
+	if t.With.Action == "" {
+		t.With.Action = applyAction
+	}
+	t.driver().initKube()
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *manifestsTask) validateInputs() error {
+	if t.With.Action != "" && t.With.Action != applyAction && t.With.Action != deleteAction {
+		return fmt.Errorf("manifests task action must be %v or %v", applyAction, deleteAction)
+	}
+	if t.With.Manifest == "" && len(t.With.Patches) == 0 {
+		return errors.New("manifests task requires a manifest or patches")
+	}
+	if t.With.Action == deleteAction && len(t.With.Patches) > 0 {
+		return fmt.Errorf("manifests task patches may only be used with the %v action", applyAction)
+	}
+	if _, err := decodeManifest(t.With.Manifest); err != nil {
+		return err
+	}
+	for i, p := range t.With.Patches {
+		if p.Version == "" || p.Resource == "" || p.Name == "" || p.Patch == "" {
+			return fmt.Errorf("patch %v requires a version, resource, name, and patch", i)
+		}
+		if _, ok := patchTypes[p.Type]; p.Type != "" && !ok {
+			return fmt.Errorf("patch %v has invalid type %v; type must be merge, json, or strategic", i, p.Type)
+		}
+	}
+	return nil
+}
+
+// decodeManifest decodes the Kubernetes objects in the manifest
+func decodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	for {
+		obj := map[string]interface{}{}
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode manifest: %v", err)
+		}
+		// skip empty documents
+		if len(obj) == 0 {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		if u.GetAPIVersion() == "" || u.GetKind() == "" || u.GetName() == "" {
+			return nil, fmt.Errorf("object %v of manifest requires an apiVersion, kind, and name", len(objs))
+		}
+		objs = append(objs, u)
+	}
+}
+
+// run executes the task
+func (t *manifestsTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+	if t.driver().dynamicClient == nil {
+		e := errors.New("unable to get Kubernetes dynamic client")
+		log.Logger.Error(e)
+		return e
+	}
+
+	objs, _ := decodeManifest(t.With.Manifest)
+	for _, obj := range objs {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(*t.With.Namespace)
+		}
+		if t.With.Action == deleteAction {
+			err = t.delete(obj)
+		} else {
+			err = t.apply(obj)
+		}
+		if err != nil {
+			e := fmt.Errorf("unable to %v %v %v/%v", t.With.Action, strings.ToLower(obj.GetKind()), obj.GetNamespace(), obj.GetName())
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+	}
+
+	for _, p := range t.With.Patches {
+		if err := t.patch(p); err != nil {
+			e := fmt.Errorf("unable to patch %v %v/%v", p.Resource, *t.With.Namespace, p.Name)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+		log.Logger.Infof("patched %v %v/%v", p.Resource, *t.With.Namespace, p.Name)
+	}
+	return nil
+}
+
+// apply creates the object, or updates it if it exists
+func (t *manifestsTask) apply(obj *unstructured.Unstructured) error {
+	gvr, _ := meta.UnsafeGuessKindToResource(obj.GroupVersionKind())
+	client := t.driver().dynamicClient.Resource(gvr).Namespace(obj.GetNamespace())
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cur, err := client.Get(context.Background(), obj.GetName(), metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			if _, err := client.Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+			log.Logger.Infof("created %v %v/%v", gvr.Resource, obj.GetNamespace(), obj.GetName())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		obj.SetResourceVersion(cur.GetResourceVersion())
+		if _, err := client.Update(context.Background(), obj, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		log.Logger.Infof("updated %v %v/%v", gvr.Resource, obj.GetNamespace(), obj.GetName())
+		return nil
+	})
+}
+
+// delete deletes the object; objects that do not exist are ignored
+func (t *manifestsTask) delete(obj *unstructured.Unstructured) error {
+	gvr, _ := meta.UnsafeGuessKindToResource(obj.GroupVersionKind())
+	err := t.driver().dynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Delete(context.Background(), obj.GetName(), metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		log.Logger.Infof("%v %v/%v does not exist", gvr.Resource, obj.GetNamespace(), obj.GetName())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Logger.Infof("deleted %v %v/%v", gvr.Resource, obj.GetNamespace(), obj.GetName())
+	return nil
+}
+
+// patch patches the object
+func (t *manifestsTask) patch(p manifestPatch) error {
+	pt := types.MergePatchType
+	if p.Type != "" {
+		pt = patchTypes[p.Type]
+	}
+	// patches may be written in YAML
+	data, err := yaml.ToJSON([]byte(p.Patch))
+	if err != nil {
+		return err
+	}
+	_, err = t.driver().dynamicClient.Resource(p.gvr()).Namespace(*t.With.Namespace).Patch(context.Background(), p.Name, pt, data, metav1.PatchOptions{})
+	return err
+}
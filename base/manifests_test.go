@@ -0,0 +1,150 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/cli"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const promoteManifest = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: httpbin
+spec:
+  selector:
+    app: httpbin
+    version: v2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: httpbin-config
+  namespace: other
+data:
+  version: v2
+`
+
+var configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func TestManifestsApplyAndDelete(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	svc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "httpbin", "namespace": "default"},
+		"spec":       map[string]interface{}{"selector": map[string]interface{}{"app": "httpbin", "version": "v1"}},
+	}}
+	_, err := kd.dynamicClient.Resource(servicesGVR).Namespace("default").Create(context.Background(), svc, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	mt := &manifestsTask{
+		TaskMeta: TaskMeta{Task: StringPointer(ManifestsTaskName)},
+		With:     manifestsInputs{Manifest: promoteManifest},
+	}
+	assert.NoError(t, mt.run(&Experiment{Spec: []Task{mt}, Result: &ExperimentResult{}}))
+
+	// existing objects are updated
+	obj, err := kd.dynamicClient.Resource(servicesGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.NoError(t, err)
+	version, _, _ := unstructured.NestedString(obj.Object, "spec", "selector", "version")
+	assert.Equal(t, "v2", version)
+	// new objects are created in their own namespace
+	_, err = kd.dynamicClient.Resource(configMapsGVR).Namespace("other").Get(context.Background(), "httpbin-config", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// delete objects; deleting them again is not an error
+	mt.With.Action = deleteAction
+	assert.NoError(t, mt.run(&Experiment{Spec: []Task{mt}, Result: &ExperimentResult{}}))
+	assert.NoError(t, mt.run(&Experiment{Spec: []Task{mt}, Result: &ExperimentResult{}}))
+	_, err = kd.dynamicClient.Resource(servicesGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestManifestsPatch(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	deploy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "httpbin", "namespace": "default"},
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "httpbin", "image": "kennethreitz/httpbin:v1"}},
+		}}},
+	}}
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	_, err := kd.dynamicClient.Resource(deploymentsGVR).Namespace("default").Create(context.Background(), deploy, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	mt := &manifestsTask{
+		TaskMeta: TaskMeta{Task: StringPointer(ManifestsTaskName)},
+		With: manifestsInputs{
+			Patches: []manifestPatch{{
+				Group:    "apps",
+				Version:  "v1",
+				Resource: "deployments",
+				Name:     "httpbin",
+				Type:     "json",
+				Patch:    `[{"op": "replace", "path": "/spec/template/spec/containers/0/image", "value": "kennethreitz/httpbin:v2"}]`,
+			}},
+		},
+	}
+	assert.NoError(t, mt.run(&Experiment{Spec: []Task{mt}, Result: &ExperimentResult{}}))
+	obj, err := kd.dynamicClient.Resource(deploymentsGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.NoError(t, err)
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, "kennethreitz/httpbin:v2", containers[0].(map[string]interface{})["image"])
+
+	// merge patches may be written in YAML
+	mt.With.Patches[0].Type = ""
+	mt.With.Patches[0].Patch = "metadata:\n  labels:\n    version: v2\n"
+	assert.NoError(t, mt.run(&Experiment{Spec: []Task{mt}, Result: &ExperimentResult{}}))
+	obj, _ = kd.dynamicClient.Resource(deploymentsGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.Equal(t, "v2", obj.GetLabels()["version"])
+
+	// patching missing objects is an error
+	mt.With.Patches[0].Name = "missing"
+	assert.Error(t, mt.run(&Experiment{Spec: []Task{mt}, Result: &ExperimentResult{}}))
+}
+
+func TestManifestsValidateInputs(t *testing.T) {
+	mt := &manifestsTask{TaskMeta: TaskMeta{Task: StringPointer(ManifestsTaskName)}}
+	// a manifest or patches are required
+	assert.Error(t, mt.validateInputs())
+
+	mt.With.Manifest = "apiVersion: v1\nkind: Service\n"
+	assert.Error(t, mt.validateInputs())
+	mt.With.Manifest = promoteManifest
+	assert.NoError(t, mt.validateInputs())
+
+	mt.With.Action = "replace"
+	assert.Error(t, mt.validateInputs())
+
+	mt.With.Action = deleteAction
+	mt.With.Patches = []manifestPatch{{Version: "v1", Resource: "services", Name: "httpbin", Patch: "{}"}}
+	assert.Error(t, mt.validateInputs())
+
+	mt.With.Action = applyAction
+	assert.NoError(t, mt.validateInputs())
+	mt.With.Patches[0].Type = "apply"
+	assert.Error(t, mt.validateInputs())
+}
+
+func TestUnmarshalManifestsTask(t *testing.T) {
+	spec := ExperimentSpec{}
+	assert.NoError(t, spec.UnmarshalJSON([]byte(`[{"task": "manifests", "if": "!SLOs()", "with": {"action": "delete", "manifest": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: httpbin-v2\n"}}]`)))
+	assert.Equal(t, 1, len(spec))
+	_, ok := spec[0].(*manifestsTask)
+	assert.True(t, ok)
+	// rollback conditions are valid
+	assert.Empty(t, ValidateExperiment(&Experiment{Spec: spec, Result: &ExperimentResult{}}))
+}
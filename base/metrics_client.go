@@ -0,0 +1,223 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// defaultQueryRetries is the default number of times a query of a metrics provider is retried
+	defaultQueryRetries = 3
+	// defaultQueryBackoff is the default time to wait before the first retry of a query
+	defaultQueryBackoff = time.Second
+	// maxQueryBackoff is the maximum time to wait before a retry of a query
+	maxQueryBackoff = 30 * time.Second
+)
+
+// metricsQueryPolicy configures how metrics providers are queried. Providers such as Datadog and New Relic throttle queries,
+// especially when experiments loop; throttled queries are retried, and the rate of queries can be limited.
+type metricsQueryPolicy struct {
+	// QPS is the maximum number of queries per second sent to each provider backend (host). Optional; by default, the rate is not limited.
+	// The limit is shared by all tasks of the experiment that query the backend.
+	QPS *float32 `json:"qps,omitempty" yaml:"qps,omitempty"`
+	// Retries is the number of times a query is retried when it is throttled (status code 429), or fails with a server error or a network error.
+	// Default value is 3.
+	Retries *int `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// Backoff is the time to wait before the first retry; it is doubled for each retry, up to 30s, with random jitter.
+	// The Retry-After or X-RateLimit-Reset header of a throttled response takes precedence. Default value is 1s.
+	Backoff *string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+}
+
+// validate the query policy
+func (p *metricsQueryPolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.QPS != nil && *p.QPS <= 0 {
+		return fmt.Errorf("invalid query qps %v", *p.QPS)
+	}
+	if p.Retries != nil && *p.Retries < 0 {
+		return fmt.Errorf("invalid query retries %v", *p.Retries)
+	}
+	if p.Backoff != nil {
+		if d, err := time.ParseDuration(*p.Backoff); err != nil || d < 0 {
+			return fmt.Errorf("invalid query backoff %v", *p.Backoff)
+		}
+	}
+	return nil
+}
+
+// client returns an HTTP client for queries of metrics providers that follows the policy; a nil policy uses the defaults
+func (p *metricsQueryPolicy) client() *http.Client {
+	t := &metricsTransport{
+		retries: defaultQueryRetries,
+		backoff: defaultQueryBackoff,
+	}
+	if p != nil {
+		if p.QPS != nil {
+			t.qps = float64(*p.QPS)
+		}
+		if p.Retries != nil {
+			t.retries = *p.Retries
+		}
+		if p.Backoff != nil {
+			t.backoff, _ = time.ParseDuration(*p.Backoff)
+		}
+	}
+	return &http.Client{Transport: t}
+}
+
+// backendLimiter limits the rate of requests to a backend
+type backendLimiter struct {
+	// mu protects next
+	mu sync.Mutex
+	// next is the earliest time at which the next request may be sent
+	next time.Time
+}
+
+// wait blocks until a request may be sent to the backend at the given rate
+func (l *backendLimiter) wait(qps float64) {
+	l.mu.Lock()
+	t := time.Now()
+	if l.next.After(t) {
+		t = l.next
+	}
+	l.next = t.Add(time.Duration(float64(time.Second) / qps))
+	l.mu.Unlock()
+	time.Sleep(time.Until(t))
+}
+
+var (
+	// backendLimitersMu protects backendLimiters
+	backendLimitersMu sync.Mutex
+	// backendLimiters are the rate limiters of backends, by host; they are shared, so that tasks and loops of an experiment
+	// do not together exceed the rate
+	backendLimiters = map[string]*backendLimiter{}
+)
+
+// limiterOf returns the rate limiter of the backend
+func limiterOf(host string) *backendLimiter {
+	backendLimitersMu.Lock()
+	defer backendLimitersMu.Unlock()
+	l, ok := backendLimiters[host]
+	if !ok {
+		l = &backendLimiter{}
+		backendLimiters[host] = l
+	}
+	return l
+}
+
+// metricsTransport is an HTTP round tripper for queries of metrics providers. It limits the rate of requests to each backend,
+// and retries requests that are throttled or fail, with exponential backoff and jitter.
+type metricsTransport struct {
+	// base sends requests; defaults to http.DefaultTransport
+	base http.RoundTripper
+	// qps is the maximum number of requests per second sent to each backend; zero if the rate is not limited
+	qps float64
+	// retries is the number of times a request is retried
+	retries int
+	// backoff is the time to wait before the first retry
+	backoff time.Duration
+}
+
+// retryable returns true if the request that resulted in the response or error should be retried
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfter returns the time to wait before retrying a throttled request, as requested by the provider, if any;
+// the Retry-After header is in seconds or an HTTP date, and the X-RateLimit-Reset header used by Datadog is in seconds
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+			return time.Duration(s) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t), true
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if s, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Reset")); err == nil && s >= 0 {
+			return time.Duration(s) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// jitter returns a random duration between half of the backoff and the backoff,
+// so that retries of concurrent queries are spread out
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 1 {
+		return backoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+}
+
+// RoundTrip sends the request, retrying it if it is throttled or fails
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// the body is sent again when the request is retried
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	backoff := t.backoff
+	for attempt := 0; ; attempt++ {
+		r := req.Clone(req.Context())
+		if body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		if t.qps > 0 {
+			limiterOf(req.URL.Host).wait(t.qps)
+		}
+		resp, err := base.RoundTrip(r)
+		if attempt >= t.retries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		wait := jitter(backoff)
+		if d, ok := retryAfter(resp); ok {
+			wait = d
+		}
+		if wait > maxQueryBackoff {
+			wait = maxQueryBackoff
+		}
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		log.Logger.Warnf("query of %v failed: %v; retrying in %v", req.URL.Host, reason, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+		if backoff *= 2; backoff > maxQueryBackoff {
+			backoff = maxQueryBackoff
+		}
+	}
+}
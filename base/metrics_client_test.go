@@ -0,0 +1,111 @@
+package base
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsQueryRetries(t *testing.T) {
+	var attempts int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "query", string(b))
+		switch r.URL.Path {
+		case "/throttled":
+			// throttled twice
+			if atomic.AddInt64(&attempts, 1) <= 2 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"value": 1}`))
+		case "/unavailable":
+			atomic.AddInt64(&attempts, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/unauthorized":
+			atomic.AddInt64(&attempts, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	p := &metricsQueryPolicy{
+		Retries: intPointer(2),
+		Backoff: StringPointer("1ms"),
+	}
+	assert.NoError(t, p.validate())
+	client := p.client()
+
+	// throttled queries are retried, along with their bodies
+	resp, err := client.Post(srv.URL+"/throttled", "text/plain", strings.NewReader("query"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&attempts))
+
+	// the response of the last attempt is returned
+	atomic.StoreInt64(&attempts, 0)
+	resp, err = client.Post(srv.URL+"/unavailable", "text/plain", strings.NewReader("query"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&attempts))
+
+	// client errors are not retried
+	atomic.StoreInt64(&attempts, 0)
+	resp, err = client.Post(srv.URL+"/unauthorized", "text/plain", strings.NewReader("query"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&attempts))
+}
+
+func TestMetricsQueryRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// queries of two tasks share the rate limit of the backend
+	p := &metricsQueryPolicy{QPS: float32Pointer(20)}
+	c1, c2 := p.client(), p.client()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c1.Get(srv.URL)
+		assert.NoError(t, err)
+		_, err = c2.Get(srv.URL)
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start).Seconds(), 0.25)
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	_, ok := retryAfter(resp)
+	assert.False(t, ok)
+
+	resp.Header.Set("X-RateLimit-Reset", "7")
+	d, ok := retryAfter(resp)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+
+	resp.Header.Set("Retry-After", "3")
+	d, _ = retryAfter(resp)
+	assert.Equal(t, 3*time.Second, d)
+
+	resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	d, _ = retryAfter(resp)
+	assert.InDelta(t, 60, d.Seconds(), 2)
+
+	for i := 0; i < 10; i++ {
+		j := jitter(time.Second)
+		assert.GreaterOrEqual(t, j, 500*time.Millisecond)
+		assert.Less(t, j, time.Second)
+	}
+
+	assert.Error(t, (&metricsQueryPolicy{QPS: float32Pointer(0)}).validate())
+	assert.Error(t, (&metricsQueryPolicy{Retries: intPointer(-1)}).validate())
+	assert.Error(t, (&metricsQueryPolicy{Backoff: StringPointer("soon")}).validate())
+}
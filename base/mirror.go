@@ -0,0 +1,248 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/stats"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// baselineVersion is the index of the baseline version when mirrored requests are replayed
+	baselineVersion = 0
+	// candidateVersion is the index of the candidate version when mirrored requests are replayed
+	candidateVersion = 1
+
+	// mirrorSourceTimeout is the timeout of the request to a tap endpoint for mirrored requests
+	mirrorSourceTimeout = 30 * time.Second
+	// mirrorRequestTimeout is the timeout of each replayed request
+	mirrorRequestTimeout = 30 * time.Second
+)
+
+// mirrorInputs configure the replay of mirrored production requests against a candidate and a baseline
+type mirrorInputs struct {
+	// Source of mirrored requests; the URL of a tap endpoint, such as one draining a mirror queue, or the path of a file.
+	// Each line of the source is a mirrored request in JSON.
+	Source string `json:"source" yaml:"source"`
+	// BaselineURL is the base URL of the baseline; the URL of the http task is the base URL of the candidate
+	BaselineURL string `json:"baselineURL" yaml:"baselineURL"`
+	// responseRules determine whether responses of the candidate and the baseline are equivalent
+	responseRules `json:",inline" yaml:",inline"`
+}
+
+// validate the mirror inputs
+func (m *mirrorInputs) validate() error {
+	if m.Source == "" {
+		return errors.New("http task requires a source of mirrored requests")
+	}
+	if m.BaselineURL == "" {
+		return errors.New("http task requires the URL of the baseline to replay mirrored requests")
+	}
+	return nil
+}
+
+// isURL returns true if the source of mirrored requests is an HTTP or HTTPS URL, rather than the path of a file
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// readMirroredRequests reads at most limit mirrored requests from the source; if limit is not positive, all requests are read
+func readMirroredRequests(source string, limit int64) ([]sampleRequest, error) {
+	var r io.Reader
+	if isURL(source) {
+		client := &http.Client{Timeout: mirrorSourceTimeout}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("source of mirrored requests returned status code %v", resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	reqs := []sampleRequest{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() && (limit <= 0 || int64(len(reqs)) < limit) {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		req := sampleRequest{}
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid mirrored request %q: %v", line, err)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, scanner.Err()
+}
+
+// newMirrorClient returns an HTTP client for replaying mirrored requests with the given number of connections.
+// Connections to the candidate and the baseline are kept alive and reused by the workers, instead of the two idle connections
+// for each host kept by default, so that high rates of requests are not limited by the cost of establishing connections.
+func newMirrorClient(connections int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 2 * connections
+	transport.MaxIdleConnsPerHost = connections
+	transport.MaxConnsPerHost = connections
+	return &http.Client{
+		Transport: transport,
+		Timeout:   mirrorRequestTimeout,
+	}
+}
+
+// mirrorWorker replays mirrored requests, and accumulates the metrics of its requests without synchronization
+type mirrorWorker struct {
+	// hists are the histograms of latencies of the baseline and the candidate, in seconds
+	hists [2]*stats.Histogram
+	// errs are the numbers of responses of the baseline and the candidate that were errors
+	errs [2]float64
+	// mismatches is the number of responses of the candidate that differ from those of the baseline
+	mismatches float64
+}
+
+// newMirrorWorker returns a worker with empty metrics
+func newMirrorWorker() *mirrorWorker {
+	return &mirrorWorker{
+		hists: [2]*stats.Histogram{stats.NewHistogram(0, 0.001), stats.NewHistogram(0, 0.001)},
+	}
+}
+
+// replay sends the mirrored request to the baseline and the candidate, and records the metrics of their responses
+func (w *mirrorWorker) replay(t *collectHTTPTask, client *http.Client, mr sampleRequest) {
+	baseline, bl := sendRequest(client, t.With.Mirror.BaselineURL, mr, t.With.Headers)
+	candidate, cl := sendRequest(client, t.With.URL, mr, t.With.Headers)
+	for i, r := range []struct {
+		resp    *capturedResponse
+		latency time.Duration
+	}{{baseline, bl}, {candidate, cl}} {
+		w.hists[i].Record(r.latency.Seconds())
+		if r.resp.status == 0 || t.errorCode(r.resp.status) {
+			w.errs[i]++
+		}
+	}
+	if d := t.With.Mirror.diff(baseline, candidate); d != "" {
+		log.Logger.Debugf("response of candidate to %v: %v", mr, d)
+		w.mismatches++
+	}
+}
+
+// mergeMirrorWorkers returns the histograms of latencies and the numbers of errors of the baseline and the candidate,
+// and the number of mismatched responses, of all the workers
+func mergeMirrorWorkers(workers []*mirrorWorker) ([]*stats.Histogram, []float64, float64) {
+	hists := []*stats.Histogram{stats.NewHistogram(0, 0.001), stats.NewHistogram(0, 0.001)}
+	errs := []float64{0, 0}
+	mismatches := float64(0)
+	for _, w := range workers {
+		for i := range hists {
+			hists[i].Transfer(w.hists[i])
+			errs[i] += w.errs[i]
+		}
+		mismatches += w.mismatches
+	}
+	return hists, errs, mismatches
+}
+
+// runMirror replays mirrored requests against the candidate and the baseline, and updates the built-in metrics
+// of both versions along with the number and rate of mismatched responses
+func (t *collectHTTPTask) runMirror(exp *Experiment) error {
+	limit := int64(0)
+	if t.With.NumRequests != nil {
+		limit = *t.With.NumRequests
+	}
+	reqs, err := readMirroredRequests(t.With.Mirror.Source, limit)
+	if err != nil {
+		e := errors.New("unable to read mirrored requests")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	if len(reqs) == 0 {
+		e := errors.New("no mirrored requests to replay")
+		log.Logger.Error(e)
+		return e
+	}
+	var deadline time.Time
+	if t.With.Duration != nil {
+		d, _ := time.ParseDuration(*t.With.Duration)
+		deadline = time.Now().Add(d)
+	}
+
+	// requests are sent at the given rate by a fixed pool of workers, one for each connection;
+	// each worker accumulates its own metrics, which are merged when the replay ends
+	workers := make([]*mirrorWorker, *t.With.Connections)
+	client := newMirrorClient(*t.With.Connections)
+	work := make(chan sampleRequest, len(workers))
+	var wg sync.WaitGroup
+	for i := range workers {
+		w := newMirrorWorker()
+		workers[i] = w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mr := range work {
+				w.replay(t, client, mr)
+			}
+		}()
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / float64(*t.With.QPS)))
+	for _, mr := range reqs {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		if exp.stopped() {
+			log.Logger.Warn("stopped replaying mirrored requests")
+			break
+		}
+		work <- mr
+		<-ticker.C
+	}
+	ticker.Stop()
+	close(work)
+	wg.Wait()
+	client.CloseIdleConnections()
+	hists, errs, mismatches := mergeMirrorWorkers(workers)
+
+	// this task populates insights for the baseline and the candidate
+	err = exp.Result.initInsightsWithNumVersions(2)
+	if err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+	for i, h := range hists {
+		t.updateMetrics(in, i, errs[i], h.Export().CalcPercentiles(t.With.Percentiles))
+	}
+
+	// mismatches; the baseline is equivalent to itself
+	n := float64(hists[candidateVersion].Count)
+	for i, v := range []float64{baselineVersion: 0, candidateVersion: mismatches} {
+		in.updateMetric(httpMetricPrefix+"/"+builtInMismatchCountId, MetricMeta{
+			Description: "number of mirrored requests whose response differs from that of the baseline",
+			Type:        CounterMetricType,
+		}, i, v)
+		in.updateMetric(httpMetricPrefix+"/"+builtInMismatchRateId, MetricMeta{
+			Description: "fraction of mirrored requests whose response differs from that of the baseline",
+			Type:        GaugeMetricType,
+		}, i, v/n)
+	}
+	log.Logger.Infof("replayed %v mirrored requests; %v responses of the candidate differ from the baseline", n, mismatches)
+	return nil
+}
@@ -0,0 +1,105 @@
+package base
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunMirror(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// the candidate returns a different response for /status/418 and a different timestamp
+	baseline := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"path": %q, "method": %q, "time": 1}`, r.URL.Path, r.Method)
+	}))
+	defer baseline.Close()
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status/418" {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+		fmt.Fprintf(w, `{"time": 2, "method": %q, "path": %q}`, r.Method, r.URL.Path)
+	}))
+	defer candidate.Close()
+	// the tap endpoint returns mirrored requests
+	tap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"path": "/get"}`)
+		fmt.Fprintln(w, `{"method": "POST", "path": "post", "headers": {"Content-Type": "application/json"}, "body": "{}"}`)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, `{"path": "/status/418"}`)
+		fmt.Fprintln(w, `{"path": "/anything"}`)
+	}))
+	defer tap.Close()
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			URL: candidate.URL,
+			QPS: float32Pointer(100),
+			Mirror: &mirrorInputs{
+				Source:        tap.URL,
+				BaselineURL:   baseline.URL,
+				responseRules: responseRules{IgnoreFields: []string{"time"}},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+	in := exp.Result.Insights
+	assert.Equal(t, 2, in.NumVersions)
+	assert.Equal(t, []float64{4}, in.NonHistMetricValues[candidateVersion][httpMetricPrefix+"/"+builtInHTTPRequestCountId])
+	assert.Equal(t, []float64{1}, in.NonHistMetricValues[candidateVersion][httpMetricPrefix+"/"+builtInHTTPErrorCountId])
+	assert.Equal(t, []float64{0}, in.NonHistMetricValues[baselineVersion][httpMetricPrefix+"/"+builtInHTTPErrorCountId])
+	assert.Equal(t, []float64{1}, in.NonHistMetricValues[candidateVersion][httpMetricPrefix+"/"+builtInMismatchCountId])
+	assert.Equal(t, []float64{0.25}, in.NonHistMetricValues[candidateVersion][httpMetricPrefix+"/"+builtInMismatchRateId])
+	assert.Equal(t, []float64{0}, in.NonHistMetricValues[baselineVersion][httpMetricPrefix+"/"+builtInMismatchRateId])
+	mm, err := in.GetMetricsInfo(httpMetricPrefix + "/" + builtInHTTPLatencyPercentilePrefix + "50")
+	assert.NotNil(t, mm)
+	assert.NoError(t, err)
+
+	// at most numRequests requests are replayed from a file
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"path": "/status/418"}`+"\n"+`{"path": "/get"}`+"\n"), 0644))
+	ct.With.Mirror.Source = path
+	ct.With.NumRequests = int64Pointer(1)
+	exp = &Experiment{Spec: []Task{ct}, Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.NoError(t, ct.run(exp))
+	assert.Equal(t, []float64{1}, exp.Result.Insights.NonHistMetricValues[candidateVersion][httpMetricPrefix+"/"+builtInMismatchRateId])
+
+	// invalid sources
+	assert.NoError(t, ioutil.WriteFile(path, []byte("GET /get\n"), 0644))
+	assert.Error(t, ct.run(&Experiment{Spec: []Task{ct}, Result: &ExperimentResult{}}))
+	ct.With.Mirror.Source = filepath.Join(t.TempDir(), "missing.jsonl")
+	assert.Error(t, ct.run(&Experiment{Spec: []Task{ct}, Result: &ExperimentResult{}}))
+	ct.With.Mirror.BaselineURL = ""
+	assert.Error(t, ct.validateInputs())
+}
+
+func TestMergeMirrorWorkers(t *testing.T) {
+	w1, w2 := newMirrorWorker(), newMirrorWorker()
+	w1.hists[baselineVersion].Record(0.01)
+	w1.hists[candidateVersion].Record(0.02)
+	w1.errs[candidateVersion] = 1
+	w2.hists[baselineVersion].Record(0.03)
+	w2.hists[candidateVersion].Record(0.04)
+	w2.mismatches = 1
+	hists, errs, mismatches := mergeMirrorWorkers([]*mirrorWorker{w1, w2, newMirrorWorker()})
+	assert.Equal(t, int64(2), hists[baselineVersion].Count)
+	assert.Equal(t, int64(2), hists[candidateVersion].Count)
+	assert.InDelta(t, 0.04, hists[candidateVersion].Max, 0.0001)
+	assert.Equal(t, []float64{0, 1}, errs)
+	assert.Equal(t, float64(1), mismatches)
+}
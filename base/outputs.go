@@ -0,0 +1,138 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// outputEnv is the environment variable with the path of the file to which run tasks write outputs
+	outputEnv = "ITER8_OUTPUT"
+)
+
+// setOutput publishes an output for later tasks
+func (exp *Experiment) setOutput(name string, value string) {
+	if exp.Result.Outputs == nil {
+		exp.Result.Outputs = map[string]string{}
+	}
+	exp.Result.Outputs[name] = value
+	log.Logger.Debugf("output %v published", name)
+}
+
+// copyOutputs returns a copy of outputs
+func copyOutputs(outputs map[string]string) map[string]string {
+	if outputs == nil {
+		return nil
+	}
+	c := make(map[string]string, len(outputs))
+	for k, v := range outputs {
+		c[k] = v
+	}
+	return c
+}
+
+// withOutputs returns a copy of the task in which templates in its inputs and run script are executed
+// using the outputs of earlier tasks. The task itself is unchanged, so that it can be run again in later loops.
+// Tasks of parallel branches are executed when they run.
+func (exp *Experiment) withOutputs(t Task) (Task, error) {
+	if _, ok := t.(*parallelTask); ok {
+		return t, nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil || !bytes.Contains(b, []byte("{{")) {
+		return t, nil
+	}
+	v := map[string]interface{}{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	for _, field := range []string{"with", "run"} {
+		if f, ok := v[field]; ok {
+			if v[field], err = exp.executeOutputs(f); err != nil {
+				e := fmt.Errorf("unable to use outputs in task %v", *getName(t))
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return nil, e
+			}
+		}
+	}
+	if b, err = json.Marshal(v); err != nil {
+		return nil, err
+	}
+	rt := reflect.New(reflect.TypeOf(t).Elem()).Interface().(Task)
+	if err := json.Unmarshal(b, rt); err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// executeOutputs executes templates in the strings of the value, using the outputs of earlier tasks
+func (exp *Experiment) executeOutputs(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+		tpl, err := template.New("outputs").Option("missingkey=error").Parse(val)
+		if err != nil {
+			return nil, err
+		}
+		outputs := exp.Result.Outputs
+		if outputs == nil {
+			outputs = map[string]string{}
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, struct{ Outputs map[string]string }{Outputs: outputs}); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	case map[string]interface{}:
+		for k, e := range val {
+			r, err := exp.executeOutputs(e)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = r
+		}
+		return val, nil
+	case []interface{}:
+		for i, e := range val {
+			r, err := exp.executeOutputs(e)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = r
+		}
+		return val, nil
+	}
+	return v, nil
+}
+
+// readOutputs publishes the outputs written by a run task to the file as name=value lines
+func (exp *Experiment) readOutputs(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("invalid output %q; outputs must be name=value", line)
+		}
+		exp.setOutput(kv[0], kv[1])
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,56 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTaskOutputs(t *testing.T) {
+	os.Chdir(t.TempDir())
+	consumer := &runTask{TaskMeta: TaskMeta{Run: StringPointer(`test "{{ .Outputs.token }}" = abc`)}}
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo token=abc >> $ITER8_OUTPUT; echo url=http://a?b=c >> $ITER8_OUTPUT")}},
+			consumer,
+		},
+	}
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.True(t, exp.Completed())
+	assert.Equal(t, map[string]string{"token": "abc", "url": "http://a?b=c"}, exp.Result.Outputs)
+	// the template is kept for later loops
+	assert.Equal(t, `test "{{ .Outputs.token }}" = abc`, *consumer.Run)
+
+	// missing outputs fail the task
+	exp = &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer(`echo {{ .Outputs.missing }}`)}},
+		},
+	}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+
+	// outputs must be name=value
+	exp = &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer(`echo token >> $ITER8_OUTPUT`)}},
+		},
+	}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+}
+
+func TestExecuteOutputs(t *testing.T) {
+	exp := &Experiment{Result: &ExperimentResult{Outputs: map[string]string{"pod": "httpbin-1", "ns": "test"}}}
+	rt := newReadinessTask("{{ .Outputs.pod }}").withVersion("v1").withResource("pods").withNamespace("{{ .Outputs.ns }}").build()
+	tsk, err := exp.withOutputs(rt)
+	assert.NoError(t, err)
+	assert.Equal(t, "httpbin-1", tsk.(*readinessTask).With.Name)
+	assert.Equal(t, "test", *tsk.(*readinessTask).With.Namespace)
+	assert.Equal(t, "{{ .Outputs.pod }}", rt.With.Name)
+
+	// tasks without templates are unchanged
+	rt = newReadinessTask("httpbin").build()
+	tsk, err = exp.withOutputs(rt)
+	assert.NoError(t, err)
+	assert.Same(t, rt, tsk)
+}
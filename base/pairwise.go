@@ -0,0 +1,237 @@
+package base
+
+import (
+	"math"
+	"sort"
+
+	"github.com/montanaflynn/stats"
+)
+
+// defaultSignificanceLevel is the significance level of pairwise comparisons of versions
+const defaultSignificanceLevel = 0.05
+
+// PairwiseComparison compares the distribution of a sample or histogram metric of two versions, using Welch's t-test
+type PairwiseComparison struct {
+	// Metric is the name of the sample or histogram metric
+	Metric string `json:"metric" yaml:"metric"`
+	// Version is the index of the first version; it is compared with Other
+	Version int `json:"version" yaml:"version"`
+	// Other is the index of the second version
+	Other int `json:"other" yaml:"other"`
+	// Difference is the mean of Other minus the mean of Version
+	Difference float64 `json:"difference" yaml:"difference"`
+	// RelativeDifference is the difference as a fraction of the mean of Version; nil if the mean of Version is zero
+	RelativeDifference *float64 `json:"relativeDifference,omitempty" yaml:"relativeDifference,omitempty"`
+	// PValue is the two-sided p-value of Welch's t-test of the difference of means;
+	// nil if either version has fewer than two observations, or their variances are zero
+	PValue *float64 `json:"pValue,omitempty" yaml:"pValue,omitempty"`
+	// Significant is true if the p-value is below the significance level of 0.05
+	Significant bool `json:"significant" yaml:"significant"`
+}
+
+// summary describes the distribution of observations of a metric for a version
+type summary struct {
+	// mean of observations
+	mean float64
+	// variance is the (sample) variance of observations
+	variance float64
+	// count of observations
+	count float64
+}
+
+// summarize returns the summary of the observations of the sample or histogram metric (m) of version (i),
+// or nil if there are none
+func (in *Insights) summarize(i int, m string) *summary {
+	mm, ok := in.MetricsInfo[m]
+	if !ok || i < 0 || i >= in.NumVersions {
+		return nil
+	}
+	switch mm.Type {
+	case SampleMetricType:
+		if s := in.SampleSketch(i, m); s != nil {
+			if s.Count == 0 {
+				return nil
+			}
+			n := float64(s.Count)
+			sd := s.stdDev()
+			return &summary{mean: s.mean(), variance: sd * sd * n / math.Max(1, n-1), count: n}
+		}
+		vals := in.NonHistMetricValues[i][m]
+		if len(vals) == 0 {
+			return nil
+		}
+		mean, _ := stats.Mean(vals)
+		variance := 0.0
+		if len(vals) > 1 {
+			variance, _ = stats.SampleVariance(vals)
+		}
+		return &summary{mean: mean, variance: variance, count: float64(len(vals))}
+	case HistogramMetricType:
+		// observations in each bucket are assumed to be at its midpoint
+		n, sum, sumSquares := 0.0, 0.0, 0.0
+		for _, b := range in.HistMetricValues[i][m] {
+			c, mid := float64(b.Count), (b.Lower+b.Upper)/2
+			n += c
+			sum += c * mid
+			sumSquares += c * mid * mid
+		}
+		if n == 0 {
+			return nil
+		}
+		mean := sum / n
+		variance := 0.0
+		if n > 1 {
+			variance = math.Max(0, (sumSquares-n*mean*mean)/(n-1))
+		}
+		return &summary{mean: mean, variance: variance, count: n}
+	default:
+		return nil
+	}
+}
+
+// DistributionMetrics returns the names of sample and histogram metrics in sorted order; their versions can be compared pairwise
+func (in *Insights) DistributionMetrics() []string {
+	ms := []string{}
+	for m, mm := range in.MetricsInfo {
+		if mm.Type == SampleMetricType || mm.Type == HistogramMetricType {
+			ms = append(ms, m)
+		}
+	}
+	sort.Strings(ms)
+	return ms
+}
+
+// PairwiseComparisons compares each pair of versions using the given sample or histogram metric (m).
+// Pairs in which either version has no observations are omitted.
+func (in *Insights) PairwiseComparisons(m string) []PairwiseComparison {
+	pcs := []PairwiseComparison{}
+	for i := 0; i < in.NumVersions; i++ {
+		a := in.summarize(i, m)
+		if a == nil {
+			continue
+		}
+		for j := i + 1; j < in.NumVersions; j++ {
+			b := in.summarize(j, m)
+			if b == nil {
+				continue
+			}
+			pc := PairwiseComparison{
+				Metric:     m,
+				Version:    i,
+				Other:      j,
+				Difference: b.mean - a.mean,
+			}
+			if a.mean != 0 {
+				pc.RelativeDifference = float64Pointer(pc.Difference / a.mean)
+			}
+			if p, ok := welchTTest(a, b); ok {
+				pc.PValue = float64Pointer(p)
+				pc.Significant = p < defaultSignificanceLevel
+			}
+			pcs = append(pcs, pc)
+		}
+	}
+	return pcs
+}
+
+// welchTTest returns the two-sided p-value of Welch's t-test of the difference of the means of a and b;
+// false if the test is undefined
+func welchTTest(a *summary, b *summary) (float64, bool) {
+	if a.count < 2 || b.count < 2 {
+		return 0, false
+	}
+	va, vb := a.variance/a.count, b.variance/b.count
+	se := va + vb
+	if se == 0 {
+		return 0, false
+	}
+	t := (b.mean - a.mean) / math.Sqrt(se)
+	// Welch–Satterthwaite degrees of freedom
+	df := se * se / (va*va/(a.count-1) + vb*vb/(b.count-1))
+	// the two-sided p-value of the t-distribution is the regularized incomplete beta function at df/(df+t^2)
+	return regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t)), true
+}
+
+// regularizedIncompleteBeta returns the regularized incomplete beta function I_x(a, b)
+func regularizedIncompleteBeta(a float64, b float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// the continued fraction converges rapidly for x < (a+1)/(a+b+2); otherwise, use the symmetry of the function
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete beta function using Lentz's method
+func betaContinuedFraction(a float64, b float64, x float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		for _, num := range []float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1 + num*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + num/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// Ranking returns the versions in order of preference: versions that satisfy SLOs before others,
+// then versions with better rewards, and then later versions, as in Winner().
+func (exp *Experiment) Ranking() []int {
+	if exp == nil || exp.Result == nil || exp.Result.Insights == nil {
+		return nil
+	}
+	in := exp.Result.Insights
+	satisfied := map[int]bool{}
+	for _, j := range exp.getSLOsSatisfiedBy() {
+		satisfied[j] = true
+	}
+	ranking := make([]int, in.NumVersions)
+	for j := range ranking {
+		ranking[j] = j
+	}
+	sort.SliceStable(ranking, func(x, y int) bool {
+		i, j := ranking[x], ranking[y]
+		if satisfied[i] != satisfied[j] {
+			return satisfied[i]
+		}
+		if c := compareRewards(exp, in.Rewards, i, j); c != 0 {
+			return c > 0
+		}
+		return i > j
+	})
+	return ranking
+}
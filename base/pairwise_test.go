@@ -0,0 +1,100 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegularizedIncompleteBeta(t *testing.T) {
+	// two-sided p-values of the t-distribution; for example, t = 2.228 with 10 degrees of freedom has a p-value of 0.05
+	for _, c := range []struct {
+		t, df, p float64
+	}{
+		{2.228, 10, 0.05},
+		{1.96, 1e6, 0.05},
+		{2.576, 1e6, 0.01},
+		{0, 5, 1},
+		{12.706, 1, 0.05},
+	} {
+		p := regularizedIncompleteBeta(c.df/2, 0.5, c.df/(c.df+c.t*c.t))
+		assert.InDelta(t, c.p, p, 1e-3, "t = %v, df = %v", c.t, c.df)
+	}
+	assert.Equal(t, 0.0, regularizedIncompleteBeta(2, 3, 0))
+	assert.Equal(t, 1.0, regularizedIncompleteBeta(2, 3, 1))
+}
+
+func TestPairwiseComparisons(t *testing.T) {
+	exp := &Experiment{}
+	exp.initResults(1)
+	exp.Result.initInsightsWithNumVersions(3)
+	in := exp.Result.Insights
+	mm := MetricMeta{Type: SampleMetricType, Units: StringPointer("msec")}
+	in.updateMetric("grpc/latency", mm, 0, []float64{10, 11, 9, 10, 12, 8, 10, 11, 9, 10})
+	in.updateMetric("grpc/latency", mm, 1, []float64{10, 12, 8, 11, 9, 10, 10, 11, 9, 10})
+	in.updateMetric("grpc/latency", mm, 2, []float64{20, 22, 19, 21, 20, 18, 20, 22, 19, 21})
+	hm := MetricMeta{Type: HistogramMetricType, Units: StringPointer("msec")}
+	in.updateMetric("http/latency", hm, 0, []HistBucket{{Lower: 0, Upper: 10, Count: 50}, {Lower: 10, Upper: 20, Count: 50}})
+	in.updateMetric("http/latency", hm, 2, []HistBucket{{Lower: 10, Upper: 20, Count: 50}, {Lower: 20, Upper: 30, Count: 50}})
+	in.updateMetric("http/error-count", MetricMeta{Type: CounterMetricType}, 0, 1.0)
+
+	assert.Equal(t, []string{"grpc/latency", "http/latency"}, in.DistributionMetrics())
+
+	pcs := in.PairwiseComparisons("grpc/latency")
+	assert.Len(t, pcs, 3)
+	// versions 0 and 1 have the same mean
+	assert.Equal(t, 0, pcs[0].Version)
+	assert.Equal(t, 1, pcs[0].Other)
+	assert.InDelta(t, 0, pcs[0].Difference, 1e-9)
+	assert.InDelta(t, 1, *pcs[0].PValue, 1e-6)
+	assert.False(t, pcs[0].Significant)
+	// version 2 has twice the latency of the others
+	assert.Equal(t, 2, pcs[1].Other)
+	assert.InDelta(t, 10.2, pcs[1].Difference, 1e-9)
+	assert.InDelta(t, 1.02, *pcs[1].RelativeDifference, 1e-9)
+	assert.True(t, pcs[1].Significant)
+	assert.Less(t, *pcs[1].PValue, 1e-6)
+	assert.True(t, pcs[2].Significant)
+
+	// versions without observations are omitted
+	pcs = in.PairwiseComparisons("http/latency")
+	assert.Len(t, pcs, 1)
+	assert.Equal(t, 0, pcs[0].Version)
+	assert.Equal(t, 2, pcs[0].Other)
+	assert.InDelta(t, 10, pcs[0].Difference, 1e-9)
+	assert.True(t, pcs[0].Significant)
+
+	// scalar metrics have no distribution
+	assert.Empty(t, in.PairwiseComparisons("http/error-count"))
+	assert.Empty(t, in.PairwiseComparisons("http/missing"))
+
+	// sketches of streamed samples are compared using their moments
+	sketched := &Insights{NumVersions: 2, sketchAccuracy: 0.01}
+	sketched.initMetrics()
+	sketched.updateMetric("grpc/latency", mm, 0, []float64{10, 11, 9, 10, 12, 8, 10, 11, 9, 10})
+	sketched.updateMetric("grpc/latency", mm, 1, []float64{20, 22, 19, 21, 20, 18, 20, 22, 19, 21})
+	pcs = sketched.PairwiseComparisons("grpc/latency")
+	assert.Len(t, pcs, 1)
+	assert.InDelta(t, 10.2, pcs[0].Difference, 1e-9)
+	assert.True(t, pcs[0].Significant)
+
+	// the test is undefined without variance
+	_, ok := welchTTest(&summary{mean: 1, count: 5}, &summary{mean: 2, count: 5})
+	assert.False(t, ok)
+	_, ok = welchTTest(&summary{mean: 1, variance: 1, count: 1}, &summary{mean: 2, variance: 1, count: 5})
+	assert.False(t, ok)
+}
+
+func TestRanking(t *testing.T) {
+	exp := newWeightsExperiment(&assessTask{})
+	assert.Equal(t, []int{2, 1, 0}, exp.Ranking())
+
+	// versions that satisfy SLOs are ranked first, and then by rewards
+	exp.Result.Insights.Rewards = &Rewards{Max: []string{"a/conversions"}}
+	assert.Equal(t, []int{1, 2, 0}, exp.Ranking())
+	exp.Result.Insights.SLOs = &SLOLimits{Upper: []SLO{{Metric: "a/latency", Limit: 200}}}
+	exp.Result.Insights.SLOsSatisfied = &SLOResults{Upper: [][]bool{{true, true, false}}, Lower: [][]bool{}}
+	assert.Equal(t, []int{1, 0, 2}, exp.Ranking())
+
+	assert.Nil(t, (&Experiment{}).Ranking())
+}
@@ -0,0 +1,249 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"github.com/mitchellh/copystructure"
+)
+
+const (
+	// ParallelTaskName is the name of the task which runs groups of tasks in parallel
+	ParallelTaskName = "parallel"
+)
+
+// parallelInputs are the branches of the parallel task
+type parallelInputs struct {
+	// Branches run concurrently; the tasks in each branch run in sequence.
+	// For example, a branch may generate load while another collects custom metrics after a delay.
+	Branches []ExperimentSpec `json:"branches" yaml:"branches"`
+}
+
+// parallelTask runs branches of tasks concurrently.
+// The task completes when all its branches complete; this is the synchronization point for later tasks.
+type parallelTask struct {
+	TaskMeta
+	With parallelInputs `json:"with" yaml:"with"`
+}
+
+// initializeDefaults sets default values for the parallel task
+func (t *parallelTask) initializeDefaults() {}
+
+// validateInputs validates task inputs
+func (t *parallelTask) validateInputs() error {
+	if len(t.With.Branches) == 0 {
+		return errors.New("parallel task requires at least one branch")
+	}
+	for i, b := range t.With.Branches {
+		if len(b) == 0 {
+			return fmt.Errorf("branch %v has no tasks", i+1)
+		}
+		for j, bt := range b {
+			if err := bt.validateInputs(); err != nil {
+				return fmt.Errorf("branch %v: task %v: %v: %v", i+1, j+1, *getName(bt), err)
+			}
+			if err := validatePolicy(getTaskMeta(bt)); err != nil {
+				return fmt.Errorf("branch %v: task %v: %v: %v", i+1, j+1, *getName(bt), err)
+			}
+		}
+	}
+	return nil
+}
+
+// run executes the task
+func (t *parallelTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// each branch updates its own copy of insights and outputs; they are merged after all branches complete
+	branches := make([]*Experiment, len(t.With.Branches))
+	errs := make([]error, len(t.With.Branches))
+	var wg sync.WaitGroup
+	for i, b := range t.With.Branches {
+		// branches are interrupted along with the run
+		r := *exp.Result
+		in, err := copyInsights(exp.Result.Insights)
+		if err != nil {
+			return err
+		}
+		r.Insights = in
+		r.Outputs = copyOutputs(exp.Result.Outputs)
+		branches[i] = &Experiment{
+			Spec:      b,
+			Result:    &r,
+			driver:    exp.driver,
+			interrupt: exp.interrupt,
+			ctx:       exp.ctx,
+			branch:    i + 1,
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = branches[i].runBranch()
+		}(i)
+	}
+	wg.Wait()
+
+	// observations and outputs are merged in the order of branches
+	merged, err := copyInsights(exp.Result.Insights)
+	if err != nil {
+		return err
+	}
+	for i, b := range branches {
+		merged, err = mergeInsights(merged, exp.Result.Insights, b.Result.Insights)
+		if err != nil {
+			e := fmt.Errorf("unable to merge insights of branch %v", i+1)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+	}
+	exp.Result.Insights = merged
+	original := copyOutputs(exp.Result.Outputs)
+	for _, b := range branches {
+		for k, v := range b.Result.Outputs {
+			if o, ok := original[k]; !ok || o != v {
+				exp.setOutput(k, v)
+			}
+		}
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("branch %v: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// runBranch runs the tasks of a branch in sequence; the branch stops at the first task failure,
+// or when the experiment is interrupted
+func (exp *Experiment) runBranch() error {
+	for i, t := range exp.Spec {
+		name := fmt.Sprintf("branch %v task %v: %v", exp.branch, i+1, *getName(t))
+		if exp.stopped() {
+			log.Logger.Warn(name + " : not started since the experiment was interrupted")
+			return nil
+		}
+		log.Logger.Info(name + " : started")
+		shouldRun, err := exp.shouldRun(t)
+		if err != nil {
+			return err
+		}
+		if !shouldRun {
+			log.Logger.WithStackTrace(fmt.Sprint("false condition: ", *getIf(t))).Info(name + " : skipped")
+			continue
+		}
+		if err := exp.runWithPolicy(t); err != nil {
+			if getTaskMeta(t).ContinueOnError {
+				log.Logger.WithStackTrace(err.Error()).Warn(name + " : failure ignored")
+				continue
+			}
+			log.Logger.Error(name + " : failure")
+			return &TaskError{
+				Index: i + 1,
+				Task:  *getName(t),
+				Err:   err,
+			}
+		}
+		log.Logger.Info(name + " : completed")
+	}
+	return nil
+}
+
+// copyInsights returns a deep copy of insights
+func copyInsights(in *Insights) (*Insights, error) {
+	if in == nil {
+		return nil, nil
+	}
+	c, err := copystructure.Copy(in)
+	if err != nil {
+		return nil, err
+	}
+	return c.(*Insights), nil
+}
+
+// mergeInsights adds the metrics observed by a branch to the merged insights.
+// The branch started with a copy of the original insights; only observations beyond these are added.
+// SLOs and their results are taken from the branch if it assessed them.
+func mergeInsights(merged *Insights, original *Insights, branch *Insights) (*Insights, error) {
+	if branch == nil {
+		return merged, nil
+	}
+	if merged == nil {
+		merged = &Insights{NumVersions: branch.NumVersions}
+	}
+	if merged.NumVersions != branch.NumVersions {
+		return nil, fmt.Errorf("inconsistent number for app versions; merged (%v); branch (%v)", merged.NumVersions, branch.NumVersions)
+	}
+	if merged.MetricsInfo == nil {
+		if err := merged.initMetrics(); err != nil {
+			return nil, err
+		}
+	}
+
+	for m, mm := range branch.MetricsInfo {
+		if err := merged.registerMetric(m, mm); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < len(branch.NonHistMetricValues); i++ {
+		for m, vals := range branch.NonHistMetricValues[i] {
+			merged.NonHistMetricValues[i][m] = append(merged.NonHistMetricValues[i][m], vals[numObservations(original, i, m):]...)
+		}
+	}
+	for i := 0; i < len(branch.HistMetricValues); i++ {
+		for m, vals := range branch.HistMetricValues[i] {
+			merged.HistMetricValues[i][m] = append(merged.HistMetricValues[i][m], vals[numObservations(original, i, m):]...)
+		}
+	}
+	if branch.SampleSketches != nil && merged.SampleSketches == nil {
+		merged.initSampleSketches()
+	}
+	for i := 0; i < len(branch.SampleSketches); i++ {
+		for m, sk := range branch.SampleSketches[i] {
+			var earlier *SampleSketch
+			if original != nil {
+				earlier = original.SampleSketch(i, m)
+			}
+			if merged.SampleSketches[i][m] == nil {
+				merged.SampleSketches[i][m] = newSampleSketch(sk.Accuracy)
+			}
+			merged.SampleSketches[i][m].merge(sk.delta(earlier))
+		}
+	}
+
+	if branch.SLOs != nil {
+		if err := merged.setSLOs(branch.SLOs); err != nil {
+			return nil, err
+		}
+	}
+	if branch.SLOsSatisfied != nil && (original == nil || !reflect.DeepEqual(branch.SLOsSatisfied, original.SLOsSatisfied)) {
+		merged.SLOsSatisfied = branch.SLOsSatisfied
+		merged.MissingMetrics = branch.MissingMetrics
+	}
+	return merged, nil
+}
+
+// numObservations returns the number of observations of a metric for the given version
+func numObservations(in *Insights, i int, m string) int {
+	if in == nil {
+		return 0
+	}
+	if i < len(in.NonHistMetricValues) {
+		if vals, ok := in.NonHistMetricValues[i][m]; ok {
+			return len(vals)
+		}
+	}
+	if i < len(in.HistMetricValues) {
+		if vals, ok := in.HistMetricValues[i][m]; ok {
+			return len(vals)
+		}
+	}
+	return 0
+}
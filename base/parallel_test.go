@@ -0,0 +1,138 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunParallelTask(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// each branch succeeds only if the other branch runs concurrently
+	wait := "for i in $(seq 50); do test -f %v && exit 0; sleep 0.1; done; exit 1"
+	pt := &parallelTask{
+		TaskMeta: TaskMeta{Task: StringPointer(ParallelTaskName)},
+		With: parallelInputs{
+			Branches: []ExperimentSpec{
+				{&runTask{TaskMeta: TaskMeta{Run: StringPointer("touch first; " + fmt.Sprintf(wait, "second"))}}},
+				{
+					&runTask{TaskMeta: TaskMeta{Run: StringPointer("touch second; " + fmt.Sprintf(wait, "first"))}},
+					&runTask{TaskMeta: TaskMeta{Run: StringPointer("touch third")}},
+				},
+			},
+		},
+	}
+	exp := &Experiment{Spec: []Task{pt}}
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.True(t, exp.Completed())
+	assert.True(t, exp.NoFailure())
+	assert.FileExists(t, "third")
+
+	// failures of branches fail the task
+	pt.With.Branches[1] = ExperimentSpec{&runTask{TaskMeta: TaskMeta{Run: StringPointer("false")}}}
+	exp = &Experiment{Spec: []Task{pt}}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+	assert.False(t, exp.NoFailure())
+}
+
+func TestMergeInsights(t *testing.T) {
+	mm := MetricMeta{Description: "m", Type: CounterMetricType}
+	original := &Insights{NumVersions: 1}
+	assert.NoError(t, original.initMetrics())
+	assert.NoError(t, original.updateMetric("a/m", mm, 0, 1.0))
+
+	// both branches start with a copy of the original insights
+	b1, _ := copyInsights(original)
+	assert.NoError(t, b1.updateMetric("a/m", mm, 0, 2.0))
+	assert.NoError(t, b1.updateMetric("b/m", mm, 0, 3.0))
+	b2, _ := copyInsights(original)
+	assert.NoError(t, b2.updateMetric("a/m", mm, 0, 4.0))
+	assert.NoError(t, b2.setSLOs(&SLOLimits{Upper: []SLO{{Metric: "a/m", Limit: 5}}}))
+
+	merged, _ := copyInsights(original)
+	merged, err := mergeInsights(merged, original, b1)
+	assert.NoError(t, err)
+	merged, err = mergeInsights(merged, original, b2)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 4}, merged.NonHistMetricValues[0]["a/m"])
+	assert.Equal(t, []float64{3}, merged.NonHistMetricValues[0]["b/m"])
+	assert.Equal(t, b2.SLOs, merged.SLOs)
+	// the original insights are unchanged
+	assert.Equal(t, []float64{1}, original.NonHistMetricValues[0]["a/m"])
+
+	// branches without insights contribute nothing; branches with other versions are inconsistent
+	merged, err = mergeInsights(nil, nil, b1)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, merged.NonHistMetricValues[0]["a/m"])
+	_, err = mergeInsights(merged, nil, &Insights{NumVersions: 2})
+	assert.Error(t, err)
+}
+
+func TestUnmarshalParallelTask(t *testing.T) {
+	spec := []byte(`[{"task": "parallel", "with": {"branches": [[{"run": "echo hello"}], [{"run": "echo world"}, {"task": "assess"}]]}}]`)
+	var s ExperimentSpec
+	assert.NoError(t, json.Unmarshal(spec, &s))
+	assert.Equal(t, 1, len(s))
+	pt, ok := s[0].(*parallelTask)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(pt.With.Branches))
+	assert.Equal(t, 2, len(pt.With.Branches[1]))
+	assert.NoError(t, pt.validateInputs())
+
+	// branches must have tasks
+	pt.With.Branches = append(pt.With.Branches, ExperimentSpec{})
+	assert.Error(t, pt.validateInputs())
+	pt.With.Branches = nil
+	assert.Error(t, pt.validateInputs())
+}
+
+func TestInterruptParallelTask(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	pt := &parallelTask{
+		TaskMeta: TaskMeta{Task: StringPointer(ParallelTaskName)},
+		With: parallelInputs{
+			Branches: []ExperimentSpec{
+				{
+					&collectHTTPTask{
+						TaskMeta: TaskMeta{Task: StringPointer(CollectHTTPTaskName)},
+						With: collectHTTPInputs{
+							URL:      srv.URL,
+							Duration: StringPointer("20s"),
+							QPS:      float32Pointer(20),
+						},
+					},
+					&runTask{TaskMeta: TaskMeta{Run: StringPointer("touch second")}},
+				},
+			},
+		},
+	}
+	exp := &Experiment{Spec: []Task{pt}}
+	exp.initResults(1)
+
+	// the load test in the branch stops when the experiment is interrupted, and its metrics are retained
+	interrupt := make(chan struct{})
+	exp.interrupt = interrupt
+	time.AfterFunc(500*time.Millisecond, func() { close(interrupt) })
+	start := time.Now()
+	assert.ErrorIs(t, exp.run(&mockDriver{exp}, 0), errInterrupted)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.True(t, exp.Result.Interrupted)
+	assert.Equal(t, 0, exp.Result.NumCompletedTasks)
+	count := exp.Result.Insights.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountId)
+	if assert.NotNil(t, count) {
+		assert.Greater(t, *count, 0.0)
+	}
+	// later tasks of the branch do not run
+	assert.NoFileExists(t, "second")
+}
@@ -0,0 +1,96 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/base/plugin"
+)
+
+// pluginTask runs a task implemented by an external plugin.
+// A task whose name is not built into Iter8 is a plugin task if its plugin executable, iter8-task-<name>, is found.
+// Metrics observed by the plugin are recorded under the task name; for example, <name>/<metric>.
+type pluginTask struct {
+	// TaskMeta has fields common to all tasks
+	TaskMeta
+	// With are the inputs of the task; they are sent to the plugin as is. Optional.
+	With map[string]interface{} `json:"with,omitempty" yaml:"with,omitempty"`
+}
+
+// initializeDefaults sets default values for task inputs
+func (t *pluginTask) initializeDefaults() {}
+
+// validateInputs for this task; the plugin must be found, and inputs are validated by the plugin when it runs
+func (t *pluginTask) validateInputs() error {
+	if _, err := plugin.Find(*t.Task); err != nil {
+		return fmt.Errorf("unknown task: %v", *t.Task)
+	}
+	return nil
+}
+
+// run the task in its plugin
+func (t *pluginTask) run(exp *Experiment) error {
+	path, err := plugin.Find(*t.Task)
+	if err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	c, err := plugin.Start(path, nil)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	req := &plugin.Request{
+		Task:   *t.Task,
+		Inputs: t.With,
+		Experiment: plugin.Context{
+			NumLoops: exp.NumLoops(),
+			Outputs:  exp.Result.Outputs,
+		},
+	}
+	if exp.Result.Insights != nil {
+		b, err := json.Marshal(exp.Result.Insights)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &req.Experiment.Insights); err != nil {
+			return err
+		}
+	}
+
+	var metrics []runMetric
+	err = c.Run(exp.context(), req, func(r *plugin.Response) error {
+		switch {
+		case r.Metric != nil:
+			m := runMetric{
+				Name:        r.Metric.Name,
+				Type:        MetricType(r.Metric.Type),
+				Value:       r.Metric.Value,
+				Version:     r.Metric.Version,
+				Description: r.Metric.Description,
+				Units:       r.Metric.Units,
+			}
+			if m.Name == "" || strings.Contains(m.Name, "/") {
+				return fmt.Errorf("plugin sent invalid metric name %v", m.Name)
+			}
+			if m.Version < 0 {
+				return fmt.Errorf("plugin sent invalid version %v for metric %v", m.Version, m.Name)
+			}
+			metrics = append(metrics, m)
+		case r.Output != nil:
+			exp.setOutput(r.Output.Name, r.Output.Value)
+		case r.Log != "":
+			log.Logger.Info(*t.Task + ": " + r.Log)
+		}
+		return nil
+	})
+	if err != nil {
+		e := fmt.Errorf("plugin task %v failed: %v", *t.Task, err)
+		log.Logger.Error(e)
+		return e
+	}
+	return exp.updateRunMetrics(*t.Task, metrics)
+}
@@ -0,0 +1,392 @@
+// Package plugin implements the protocol between Iter8 and external task plugins.
+//
+// A task plugin is an executable named iter8-task-<name>, which implements the task <name>.
+// Plugins are found in the directories listed in the ITER8_PLUGINS environment variable, followed by those in PATH.
+// Iter8 starts the plugin, which serves gRPC on a local address and prints a handshake line to stdout;
+// Iter8 then sends the task inputs and experiment context to the plugin, which streams back metrics, outputs, and logs.
+// Plugins written in Go can use Serve to implement this protocol.
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// MagicCookieKey is the environment variable set by Iter8 when it starts a plugin
+	MagicCookieKey = "ITER8_PLUGIN_MAGIC_COOKIE"
+	// MagicCookieValue is the value of the magic cookie; plugins refuse to run without it
+	MagicCookieValue = "c8a4e7f1-5b2d-4d6e-9a3f-iter8-task-plugin"
+	// ProtocolVersion is the version of the plugin protocol
+	ProtocolVersion = 1
+	// PluginsPathEnv is the environment variable with the list of directories in which plugins are found
+	PluginsPathEnv = "ITER8_PLUGINS"
+	// ExecutablePrefix is the prefix of the names of plugin executables
+	ExecutablePrefix = "iter8-task-"
+
+	// coreProtocolVersion is the version of the handshake
+	coreProtocolVersion = 1
+	// serviceName is the name of the gRPC service implemented by plugins
+	serviceName = "iter8.plugin.v1.TaskPlugin"
+	// runMethod is the full name of the streaming method that runs the task
+	runMethod = "/" + serviceName + "/Run"
+)
+
+var (
+	// HandshakeTimeout is the time that Iter8 waits for a plugin to print its handshake
+	HandshakeTimeout = 30 * time.Second
+
+	// validName matches valid plugin task names
+	validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+)
+
+// Request is sent to the plugin when the task runs
+type Request struct {
+	// Task is the name of the task
+	Task string `json:"task"`
+	// Inputs are the inputs of the task; this is the with section of the task
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+	// Experiment is the context of the experiment in which the task runs
+	Experiment Context `json:"experiment"`
+}
+
+// Context is the experiment context of a plugin task
+type Context struct {
+	// NumLoops is the number of loops that the experiment has started
+	NumLoops int `json:"numLoops"`
+	// Outputs are the outputs published by earlier tasks
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// Insights are the insights of the experiment so far, in the form recorded in the experiment result
+	Insights map[string]interface{} `json:"insights,omitempty"`
+}
+
+// Response is streamed from the plugin to Iter8 while the task runs; each response has one of its fields set
+type Response struct {
+	// Metric is an observed metric value
+	Metric *Metric `json:"metric,omitempty"`
+	// Output is an output published for later tasks
+	Output *Output `json:"output,omitempty"`
+	// Log is a message to be logged by Iter8
+	Log string `json:"log,omitempty"`
+}
+
+// Metric is a metric value observed by the plugin; it is recorded under the task name, for example, <task>/<name>
+type Metric struct {
+	// Name of the metric
+	Name string `json:"name"`
+	// Type of the metric; Counter, Gauge, or Sample. Optional. If unspecified it will be defaulted to Gauge
+	Type string `json:"type,omitempty"`
+	// Value of the metric; a number, or for Sample metrics, a list of numbers
+	Value interface{} `json:"value"`
+	// Version is the index of the app version that the metric is observed for. Optional. Default is 0
+	Version int `json:"version,omitempty"`
+	// Description of the metric. Optional.
+	Description string `json:"description,omitempty"`
+	// Units of the metric. Optional.
+	Units *string `json:"units,omitempty"`
+}
+
+// Output is an output published by the plugin for later tasks
+type Output struct {
+	// Name of the output
+	Name string `json:"name"`
+	// Value of the output
+	Value string `json:"value"`
+}
+
+// Func implements a task plugin. It runs the task described by the request, and sends responses while it runs.
+type Func func(ctx context.Context, req *Request, send func(*Response) error) error
+
+// server is implemented by the gRPC server of plugins
+type server interface {
+	run(ctx context.Context, req *Request, send func(*Response) error) error
+}
+
+// funcServer is a server that runs a plugin function
+type funcServer struct {
+	f Func
+}
+
+// run runs the plugin function
+func (s *funcServer) run(ctx context.Context, req *Request, send func(*Response) error) error {
+	return s.f(ctx, req, send)
+}
+
+// serviceDesc describes the gRPC service implemented by plugins.
+// Requests and responses are JSON documents carried as google.protobuf.Struct messages.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*server)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Run",
+		Handler:       runHandler,
+		ServerStreams: true,
+	}},
+	Metadata: "iter8/plugin",
+}
+
+// runHandler handles the Run method of the plugin service
+func runHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := &structpb.Struct{}
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	req := &Request{}
+	if err := fromStruct(in, req); err != nil {
+		return err
+	}
+	return srv.(server).run(stream.Context(), req, func(r *Response) error {
+		out, err := toStruct(r)
+		if err != nil {
+			return err
+		}
+		return stream.SendMsg(out)
+	})
+}
+
+// toStruct converts a value into a google.protobuf.Struct message, using its JSON representation
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+// fromStruct converts a google.protobuf.Struct message into a value, using its JSON representation
+func fromStruct(s *structpb.Struct, v interface{}) error {
+	b, err := json.Marshal(s.AsMap())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Serve serves the plugin function. It is called by the main function of plugins written in Go,
+// and returns when the plugin is stopped by Iter8, or with an error if the plugin is not started by Iter8.
+func Serve(f Func) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this executable is an Iter8 task plugin; it is run by Iter8 experiments")
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer()
+	s.RegisterService(&serviceDesc, &funcServer{f: f})
+	// the handshake tells Iter8 where to connect
+	fmt.Printf("%v|%v|%v|%v|grpc\n", coreProtocolVersion, ProtocolVersion, lis.Addr().Network(), lis.Addr().String())
+	return s.Serve(lis)
+}
+
+// Find returns the path of the plugin executable for the given task.
+// Plugins are found in the directories listed in ITER8_PLUGINS, followed by those in PATH.
+func Find(task string) (string, error) {
+	if !validName.MatchString(task) {
+		return "", fmt.Errorf("invalid plugin task name %v", task)
+	}
+	name := ExecutablePrefix + task
+	for _, dir := range filepath.SplitList(os.Getenv(PluginsPathEnv)) {
+		if dir == "" {
+			continue
+		}
+		candidates := []string{filepath.Join(dir, name)}
+		if runtime.GOOS == "windows" {
+			candidates = append(candidates, filepath.Join(dir, name+".exe"))
+		}
+		for _, c := range candidates {
+			if fi, err := os.Stat(c); err == nil && fi.Mode().IsRegular() {
+				return c, nil
+			}
+		}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("plugin %v not found in %v or PATH", name, PluginsPathEnv)
+	}
+	return path, nil
+}
+
+// Client is a running plugin
+type Client struct {
+	// cmd is the plugin process
+	cmd *exec.Cmd
+	// conn is the gRPC connection to the plugin
+	conn *grpc.ClientConn
+	// stderr is the standard error of the plugin
+	stderr *syncBuffer
+}
+
+// Start starts the plugin executable with the given additional environment variables, and connects to it
+func Start(path string, env []string) (*Client, error) {
+	c := &Client{
+		cmd:    exec.Command(path),
+		stderr: &syncBuffer{},
+	}
+	c.cmd.Env = append(os.Environ(), env...)
+	c.cmd.Env = append(c.cmd.Env, MagicCookieKey+"="+MagicCookieValue)
+	c.cmd.Stderr = c.stderr
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		e := fmt.Errorf("unable to start plugin %v", path)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+
+	// read the handshake; later output of the plugin is logged
+	lines := make(chan string, 1)
+	go func() {
+		r := bufio.NewReader(stdout)
+		line, _ := r.ReadString('\n')
+		lines <- line
+		_, _ = io.Copy(ioutil.Discard, r)
+	}()
+	var line string
+	select {
+	case line = <-lines:
+	case <-time.After(HandshakeTimeout):
+		c.kill()
+		return nil, fmt.Errorf("plugin %v did not complete handshake within %v", path, HandshakeTimeout)
+	}
+	addr, err := parseHandshake(line)
+	if err != nil {
+		c.kill()
+		e := fmt.Errorf("invalid handshake from plugin %v", path)
+		log.Logger.WithStackTrace(err.Error() + "\n" + c.stderr.String()).Error(e)
+		return nil, e
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), HandshakeTimeout)
+	defer cancel()
+	c.conn, err = grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		c.kill()
+		e := fmt.Errorf("unable to connect to plugin %v at %v", path, addr)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	return c, nil
+}
+
+// parseHandshake returns the address of the plugin in the handshake line
+func parseHandshake(line string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 5 {
+		return "", fmt.Errorf("handshake %q must be of the form core-version|version|network|address|grpc", line)
+	}
+	if parts[0] != strconv.Itoa(coreProtocolVersion) {
+		return "", fmt.Errorf("unsupported core protocol version %v", parts[0])
+	}
+	if parts[1] != strconv.Itoa(ProtocolVersion) {
+		return "", fmt.Errorf("unsupported plugin protocol version %v; Iter8 supports version %v", parts[1], ProtocolVersion)
+	}
+	if parts[2] != "tcp" {
+		return "", fmt.Errorf("unsupported network %v", parts[2])
+	}
+	if parts[4] != "grpc" {
+		return "", fmt.Errorf("unsupported protocol %v", parts[4])
+	}
+	return parts[3], nil
+}
+
+// Run runs the task in the plugin, and calls recv with each response of the plugin
+func (c *Client) Run(ctx context.Context, req *Request, recv func(*Response) error) error {
+	in, err := toStruct(req)
+	if err != nil {
+		return err
+	}
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], runMethod)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		out := &structpb.Struct{}
+		err := stream.RecvMsg(out)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if s := c.stderr.String(); s != "" {
+				log.Logger.WithStackTrace(s).Error("standard error of plugin")
+			}
+			return err
+		}
+		r := &Response{}
+		if err := fromStruct(out, r); err != nil {
+			return err
+		}
+		if err := recv(r); err != nil {
+			return err
+		}
+	}
+}
+
+// Close disconnects from the plugin and stops it
+func (c *Client) Close() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.kill()
+}
+
+// kill stops the plugin process
+func (c *Client) kill() {
+	if c.cmd.Process != nil {
+		_ = c.cmd.Process.Kill()
+		_ = c.cmd.Wait()
+	}
+}
+
+// syncBuffer is a buffer that is safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write appends to the buffer
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the contents of the buffer
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHandshake(t *testing.T) {
+	addr, err := parseHandshake("1|1|tcp|127.0.0.1:4321|grpc\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:4321", addr)
+
+	for _, line := range []string{
+		"",
+		"hello world",
+		"2|1|tcp|127.0.0.1:4321|grpc",
+		"1|2|tcp|127.0.0.1:4321|grpc",
+		"1|1|unix|/tmp/plugin.sock|grpc",
+		"1|1|tcp|127.0.0.1:4321|netrpc",
+	} {
+		_, err := parseHandshake(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ExecutablePrefix+"hello")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	os.Setenv(PluginsPathEnv, dir)
+	defer os.Unsetenv(PluginsPathEnv)
+
+	p, err := Find("hello")
+	assert.NoError(t, err)
+	assert.Equal(t, path, p)
+
+	_, err = Find("missing")
+	assert.Error(t, err)
+	_, err = Find("../hello")
+	assert.Error(t, err)
+}
+
+func TestServeWithoutIter8(t *testing.T) {
+	assert.Error(t, Serve(nil))
+}
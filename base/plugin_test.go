@@ -0,0 +1,111 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/iter8/base/plugin"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+// TestMain serves the test plugin when the test binary is started as a plugin
+func TestMain(m *testing.M) {
+	if os.Getenv(plugin.MagicCookieKey) == plugin.MagicCookieValue {
+		if err := plugin.Serve(testPlugin); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testPlugin publishes a greeting and observes throughput for two versions, or fails or hangs if asked to
+func testPlugin(ctx context.Context, req *plugin.Request, send func(*plugin.Response) error) error {
+	if req.Inputs["fail"] == true {
+		return errors.New("asked to fail")
+	}
+	if req.Inputs["hang"] == true {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if err := send(&plugin.Response{Log: "running"}); err != nil {
+		return err
+	}
+	greeting := fmt.Sprintf("%v from loop %v", req.Inputs["greeting"], req.Experiment.NumLoops)
+	if err := send(&plugin.Response{Output: &plugin.Output{Name: "greeting", Value: greeting}}); err != nil {
+		return err
+	}
+	for i, v := range []float64{100, 200} {
+		if err := send(&plugin.Response{Metric: &plugin.Metric{Name: "throughput", Value: v, Version: i}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installTestPlugin installs the test binary as the plugin of the given task
+func installTestPlugin(t *testing.T, task string) {
+	exe, err := os.Executable()
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	assert.NoError(t, os.Symlink(exe, filepath.Join(dir, plugin.ExecutablePrefix+task)))
+	os.Setenv(plugin.PluginsPathEnv, dir)
+	t.Cleanup(func() { os.Unsetenv(plugin.PluginsPathEnv) })
+}
+
+func TestRunPluginTask(t *testing.T) {
+	installTestPlugin(t, "echo")
+
+	b := []byte(`
+spec:
+- task: echo
+  with:
+    greeting: hello
+- task: assess
+  with:
+    SLOs:
+      lower:
+      - metric: echo/throughput
+        limit: 150
+`)
+	exp := &Experiment{}
+	assert.NoError(t, yaml.Unmarshal(b, exp))
+	assert.IsType(t, &pluginTask{}, exp.Spec[0])
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.Equal(t, "hello from loop 1", exp.Result.Outputs["greeting"])
+	assert.Equal(t, []float64{200}, exp.Result.Insights.NonHistMetricValues[1]["echo/throughput"])
+	assert.Equal(t, 1, exp.Winner())
+
+	// plugin failures are task failures
+	exp.Spec[0].(*pluginTask).With["fail"] = true
+	err := RunExperiment(false, &mockDriver{exp})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "asked to fail")
+
+	// plugin tasks are stopped when they time out
+	exp.Spec[0].(*pluginTask).With["fail"] = false
+	exp.Spec[0].(*pluginTask).With["hang"] = true
+	exp.Spec[0].(*pluginTask).Timeout = StringPointer("100ms")
+	start := time.Now()
+	err = RunExperiment(false, &mockDriver{exp})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Less(t, time.Since(start).Seconds(), 2.0)
+
+	// experiments with tasks without plugins can be read, but are invalid and fail to run
+	exp = &Experiment{}
+	assert.NoError(t, yaml.Unmarshal([]byte("spec:\n- task: missing\n"), exp))
+	assert.IsType(t, &pluginTask{}, exp.Spec[0])
+	errs := ValidateExperiment(exp)
+	if assert.Equal(t, 1, len(errs)) {
+		assert.Contains(t, errs[0].Error(), "unknown task: missing")
+	}
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+}
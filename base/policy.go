@@ -0,0 +1,112 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// defaultBackoff is the time to wait before the first retry of a task
+	defaultBackoff = time.Second
+)
+
+// validatePolicy validates the timeout, retries, and backoff of a task
+func validatePolicy(tm TaskMeta) error {
+	if tm.Timeout != nil {
+		if d, err := time.ParseDuration(*tm.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %v", *tm.Timeout)
+		}
+	}
+	if tm.Retries < 0 {
+		return fmt.Errorf("invalid retries %v", tm.Retries)
+	}
+	if tm.Backoff != nil {
+		if _, err := time.ParseDuration(*tm.Backoff); err != nil {
+			return fmt.Errorf("invalid backoff %v", *tm.Backoff)
+		}
+	}
+	return nil
+}
+
+// runWithPolicy runs the task, retrying it with exponential backoff if it fails, and limiting the duration of each attempt.
+// Tasks without a timeout or retries are run as is.
+func (exp *Experiment) runWithPolicy(t Task) error {
+	t, err := exp.withOutputs(t)
+	if err != nil {
+		return err
+	}
+	tm := getTaskMeta(t)
+	if tm.Timeout == nil && tm.Retries == 0 {
+		return t.run(exp)
+	}
+	if err := validatePolicy(tm); err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	var timeout time.Duration
+	if tm.Timeout != nil {
+		timeout, _ = time.ParseDuration(*tm.Timeout)
+	}
+	backoff := defaultBackoff
+	if tm.Backoff != nil {
+		backoff, _ = time.ParseDuration(*tm.Backoff)
+	}
+
+	for attempt := 0; attempt <= tm.Retries; attempt++ {
+		if attempt > 0 {
+			log.Logger.WithStackTrace(err.Error()).Warnf("task %v: attempt %v failed; retrying in %v", *getName(t), attempt, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err = exp.attempt(t, timeout); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// attempt runs the task on a copy of the insights and outputs of the experiment; the copy is kept only if the task succeeds.
+// This ensures that failed or timed out attempts do not change the experiment.
+// A timeout of zero means that the attempt is not time limited.
+// When the attempt times out, its context is cancelled, and attempt returns after the task has stopped.
+func (exp *Experiment) attempt(t Task, timeout time.Duration) error {
+	in, err := copyInsights(exp.Result.Insights)
+	if err != nil {
+		return err
+	}
+	r := *exp.Result
+	r.Insights = in
+	r.Outputs = copyOutputs(exp.Result.Outputs)
+	ctx, cancel := context.WithCancel(exp.context())
+	defer cancel()
+	a := *exp
+	a.Result = &r
+	a.ctx = ctx
+
+	done := make(chan error, 1)
+	go func() {
+		done <- t.run(&a)
+	}()
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		exp.Result.Insights = a.Result.Insights
+		exp.Result.Outputs = a.Result.Outputs
+		return nil
+	case <-expired:
+		e := fmt.Errorf("task %v timed out after %v", *getName(t), timeout)
+		log.Logger.Error(e)
+		cancel()
+		<-done
+		return e
+	}
+}
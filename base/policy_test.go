@@ -0,0 +1,107 @@
+package base
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyTask is a task that records a metric value and then fails, until it has been run the given number of times
+type flakyTask struct {
+	TaskMeta
+	// failures is the number of runs that fail
+	failures int
+	// runs is the number of times the task has run
+	runs int
+}
+
+func (t *flakyTask) initializeDefaults() {}
+
+func (t *flakyTask) validateInputs() error { return nil }
+
+func (t *flakyTask) run(exp *Experiment) error {
+	t.runs++
+	if err := exp.Result.initInsightsWithNumVersions(1); err != nil {
+		return err
+	}
+	exp.Result.Insights.updateMetric("flaky/runs", MetricMeta{Description: "runs", Type: CounterMetricType}, 0, float64(t.runs))
+	if t.runs <= t.failures {
+		return errors.New("flaky failure")
+	}
+	return nil
+}
+
+func TestRunWithRetries(t *testing.T) {
+	ft := &flakyTask{
+		TaskMeta: TaskMeta{Task: StringPointer("flaky"), Retries: 2, Backoff: StringPointer("10ms")},
+		failures: 2,
+	}
+	exp := &Experiment{Spec: []Task{ft}, Result: &ExperimentResult{}}
+	assert.NoError(t, exp.runWithPolicy(ft))
+	assert.Equal(t, 3, ft.runs)
+	// only the successful attempt changes insights
+	assert.Equal(t, []float64{3}, exp.Result.Insights.NonHistMetricValues[0]["flaky/runs"])
+
+	// retries are exhausted
+	ft = &flakyTask{
+		TaskMeta: TaskMeta{Task: StringPointer("flaky"), Retries: 1, Backoff: StringPointer("10ms")},
+		failures: 2,
+	}
+	exp = &Experiment{Spec: []Task{ft}, Result: &ExperimentResult{}}
+	assert.Error(t, exp.runWithPolicy(ft))
+	assert.Equal(t, 2, ft.runs)
+	assert.Nil(t, exp.Result.Insights)
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	os.Chdir(t.TempDir())
+	rt := &runTask{TaskMeta: TaskMeta{Run: StringPointer("sleep 5"), Timeout: StringPointer("100ms")}}
+	exp := &Experiment{Spec: []Task{rt}}
+	start := time.Now()
+	assert.Error(t, RunExperiment(false, &mockDriver{exp}))
+	assert.Less(t, time.Since(start).Seconds(), 2.0)
+	assert.False(t, exp.NoFailure())
+}
+
+func TestTimedOutAttemptStops(t *testing.T) {
+	os.Chdir(t.TempDir())
+	rt := &runTask{TaskMeta: TaskMeta{
+		Run:     StringPointer("echo started >> attempts; sleep 1; echo finished >> attempts"),
+		Timeout: StringPointer("100ms"),
+		Retries: 1,
+		Backoff: StringPointer("10ms"),
+	}}
+	exp := &Experiment{Spec: []Task{rt}, Result: &ExperimentResult{}}
+	assert.Error(t, exp.runWithPolicy(rt))
+	// each attempt is stopped before the next one starts, and its script does not continue in the background
+	time.Sleep(1500 * time.Millisecond)
+	b, err := ioutil.ReadFile("attempts")
+	assert.NoError(t, err)
+	assert.Equal(t, "started\nstarted\n", string(b))
+}
+
+func TestContinueOnError(t *testing.T) {
+	os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("false"), ContinueOnError: true}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("touch after")}},
+		},
+	}
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	assert.True(t, exp.Completed())
+	assert.True(t, exp.NoFailure())
+	assert.FileExists(t, "after")
+}
+
+func TestValidatePolicy(t *testing.T) {
+	assert.NoError(t, validatePolicy(TaskMeta{Timeout: StringPointer("1m"), Retries: 3, Backoff: StringPointer("2s")}))
+	assert.Error(t, validatePolicy(TaskMeta{Timeout: StringPointer("0s")}))
+	assert.Error(t, validatePolicy(TaskMeta{Timeout: StringPointer("soon")}))
+	assert.Error(t, validatePolicy(TaskMeta{Retries: -1}))
+	assert.Error(t, validatePolicy(TaskMeta{Backoff: StringPointer("later")}))
+}
@@ -0,0 +1,332 @@
+package base
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fortio.org/fortio/stats"
+)
+
+const (
+	// TaskCompleted is the status of a task that completed
+	TaskCompleted TaskStatus = "completed"
+	// TaskFailed is the status of a task that failed
+	TaskFailed TaskStatus = "failed"
+	// TaskFailureIgnored is the status of a task that failed, when the failure is ignored using continueOnError
+	TaskFailureIgnored TaskStatus = "failure ignored"
+	// TaskSkipped is the status of a task whose condition is false
+	TaskSkipped TaskStatus = "skipped"
+	// TaskInterrupted is the status of a task that was stopped by a signal
+	TaskInterrupted TaskStatus = "interrupted"
+
+	// loadProgressInterval is the interval at which the progress of load tests is reported
+	loadProgressInterval = 250 * time.Millisecond
+)
+
+// TaskStatus is the status of a task that has ended
+type TaskStatus string
+
+// LoadProgress is the progress of a load test
+type LoadProgress struct {
+	// Task is the name of the task that generates the load
+	Task string
+	// Requests is the number of requests sent so far
+	Requests int64
+	// TotalRequests is the number of requests to be sent; 0 if the load test runs for a duration
+	TotalRequests int64
+	// Elapsed is the time since the load test started
+	Elapsed time.Duration
+	// Duration of the load test; 0 if the load test sends a number of requests
+	Duration time.Duration
+	// QPS is the rate at which requests are sent, in requests per second
+	QPS float64
+	// MeanLatency is the mean latency of requests so far, in milliseconds
+	MeanLatency float64
+}
+
+// Fraction returns the completed fraction of the load test, between 0 and 1
+func (p LoadProgress) Fraction() float64 {
+	f := 0.0
+	switch {
+	case p.TotalRequests > 0:
+		f = float64(p.Requests) / float64(p.TotalRequests)
+	case p.Duration > 0:
+		f = float64(p.Elapsed) / float64(p.Duration)
+	}
+	if f > 1 {
+		f = 1
+	}
+	return f
+}
+
+// ProgressReporter is notified of the progress of experiments; for example, to display it in a terminal.
+// Its methods may be called concurrently.
+type ProgressReporter interface {
+	// LoopStarted is called when a loop of the experiment starts; maxLoops is 1 for experiments without loops
+	LoopStarted(loop int, maxLoops int)
+	// TaskStarted is called when a task of the experiment starts; index starts at 1
+	TaskStarted(index int, name string)
+	// TaskEnded is called when a task of the experiment ends
+	TaskEnded(index int, name string, status TaskStatus)
+	// LoadProgress is called periodically while a load test runs
+	LoadProgress(p LoadProgress)
+}
+
+// progressReporter is notified of the progress of experiments; nil if progress is not reported
+var progressReporter ProgressReporter
+
+// SetProgressReporter sets the reporter that is notified of the progress of experiments run by this process.
+// A nil reporter disables progress reporting.
+func SetProgressReporter(p ProgressReporter) {
+	progressReporter = p
+}
+
+// reportLoopStarted notifies the progress reporter, if any, that a loop started
+func reportLoopStarted(exp *Experiment) {
+	if progressReporter != nil {
+		progressReporter.LoopStarted(exp.Result.NumLoops, exp.Loop.maxLoops())
+	}
+}
+
+// reportTaskStarted notifies the progress reporter, if any, that a task started
+func reportTaskStarted(index int, name string) {
+	if progressReporter != nil {
+		progressReporter.TaskStarted(index, name)
+	}
+}
+
+// reportTaskEnded notifies the progress reporter, if any, that a task ended
+func reportTaskEnded(index int, name string, status TaskStatus) {
+	if progressReporter != nil {
+		progressReporter.TaskEnded(index, name, status)
+	}
+}
+
+// trackerShard accumulates the requests of one thread of a load test.
+// Counts are updated atomically; the histogram is only recorded if it is needed by live metrics or checkpoints.
+type trackerShard struct {
+	// requests is the number of requests so far; it is updated atomically
+	requests int64
+	// latency is the total latency of requests so far, in nanoseconds; it is updated atomically
+	latency int64
+	// mu protects the histogram; it is locked by the thread, and by snapshots of the load test
+	mu sync.Mutex
+	// hist is the histogram of latencies so far, in seconds; nil if latencies are not recorded in a histogram
+	hist *stats.Histogram
+}
+
+// loadTracker tracks the requests of a load test, periodically reports its progress, and streams live metrics.
+// It is a Fortio access logger, which is notified of each request, and the writer of Fortio logs,
+// from which the status codes of responses that are not OK are read.
+//
+// Load is generated by Fortio, using a fixed pool of threads, each with its own keep-alive connection that is established
+// before the load test starts. The tracker adds little to the cost of each request: requests are accumulated in a shard for each thread,
+// so that threads do not contend, and counts are updated without locks. Latency histograms, which are needed for live metrics
+// and checkpoints, are recorded under the lock of the shard, which is contended only by periodic snapshots.
+type loadTracker struct {
+	// errors is the number of responses so far that were errors; it is updated atomically
+	errors int64
+	// shards accumulate the requests of each thread
+	shards []*trackerShard
+	// progress is the progress of the load test, without its counts
+	progress LoadProgress
+	// reporter is notified of the progress of the load test; nil if progress is not reported
+	reporter ProgressReporter
+	// live configures the streaming of live metrics; nil if live metrics are not streamed
+	live *liveMetricsOpts
+	// checkpoint configures the checkpointing of the load test; nil if the load test is not checkpointed
+	checkpoint *checkpointOpts
+	// isError returns true if a status code is an error; nil if errors are not counted
+	isError func(code int) bool
+	// start is the time when the load test started
+	start time.Time
+	// done stops reporting
+	done chan struct{}
+	// stopped is closed when reporting has stopped
+	stopped chan struct{}
+}
+
+// startLoadTracker returns a load tracker of a load test with the given number of threads, which reports progress, streams live metrics,
+// and checkpoints the load test until it is stopped; nil is returned if progress is not reported, live metrics are not streamed,
+// and the load test is not checkpointed
+func startLoadTracker(task string, totalRequests int64, duration time.Duration, threads int, live *liveMetricsOpts, checkpoint *checkpointOpts) *loadTracker {
+	if progressReporter == nil && live == nil && checkpoint == nil {
+		return nil
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	shards := make([]*trackerShard, threads)
+	for i := range shards {
+		shards[i] = &trackerShard{}
+		if live != nil || checkpoint != nil {
+			shards[i].hist = stats.NewHistogram(0, 0.001)
+		}
+	}
+	lt := &loadTracker{
+		shards: shards,
+		progress: LoadProgress{
+			Task:          task,
+			TotalRequests: totalRequests,
+			Duration:      duration,
+		},
+		reporter:   progressReporter,
+		live:       live,
+		checkpoint: checkpoint,
+		start:      time.Now(),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	if live != nil {
+		lt.isError = live.isError
+	} else if checkpoint != nil {
+		lt.isError = checkpoint.isError
+	}
+	go func() {
+		defer close(lt.stopped)
+		// a nil channel blocks forever, disabling the corresponding case
+		var progressC, liveC, checkpointC <-chan time.Time
+		if lt.reporter != nil {
+			ticker := time.NewTicker(loadProgressInterval)
+			defer ticker.Stop()
+			progressC = ticker.C
+		}
+		if lt.live != nil {
+			ticker := time.NewTicker(lt.live.interval)
+			defer ticker.Stop()
+			liveC = ticker.C
+		}
+		if lt.checkpoint != nil {
+			ticker := time.NewTicker(lt.checkpoint.interval)
+			defer ticker.Stop()
+			checkpointC = ticker.C
+		}
+		for {
+			select {
+			case <-lt.done:
+				return
+			case <-progressC:
+				lt.reporter.LoadProgress(lt.snapshot())
+			case <-liveC:
+				lt.live.stream(lt.liveSnapshot())
+			case <-checkpointC:
+				lt.saveCheckpoint()
+			}
+		}
+	}()
+	return lt
+}
+
+// Report records a request; it implements the Fortio access logger interface
+func (lt *loadTracker) Report(thread int, time int64, latency float64) {
+	s := lt.shards[thread%len(lt.shards)]
+	atomic.AddInt64(&s.requests, 1)
+	atomic.AddInt64(&s.latency, int64(latency*1e9))
+	if s.hist != nil {
+		s.mu.Lock()
+		s.hist.Record(latency)
+		s.mu.Unlock()
+	}
+}
+
+// Info describes the access logger; it implements the Fortio access logger interface
+func (lt *loadTracker) Info() string {
+	return "iter8 progress"
+}
+
+// Write reads Fortio logs, and counts responses whose status codes are errors
+func (lt *loadTracker) Write(p []byte) (int, error) {
+	if lt.isError == nil {
+		return len(p), nil
+	}
+	for _, m := range nonOKCodeRegexp.FindAllSubmatch(p, -1) {
+		if code, err := strconv.Atoi(string(m[1])); err == nil && lt.isError(code) {
+			atomic.AddInt64(&lt.errors, 1)
+		}
+	}
+	return len(p), nil
+}
+
+// totals returns the number and total latency in seconds of requests so far, and optionally, the histogram of their latencies;
+// the histogram is empty if latencies are not recorded
+func (lt *loadTracker) totals(withHist bool) (int64, float64, *stats.Histogram) {
+	requests, latency := int64(0), int64(0)
+	var hist *stats.Histogram
+	if withHist {
+		hist = stats.NewHistogram(0, 0.001)
+	}
+	for _, s := range lt.shards {
+		requests += atomic.LoadInt64(&s.requests)
+		latency += atomic.LoadInt64(&s.latency)
+		if withHist && s.hist != nil {
+			s.mu.Lock()
+			hist.Transfer(s.hist.Clone())
+			s.mu.Unlock()
+		}
+	}
+	return requests, float64(latency) / 1e9, hist
+}
+
+// snapshot returns the progress of the load test
+func (lt *loadTracker) snapshot() LoadProgress {
+	requests, latency, _ := lt.totals(false)
+	return lt.progressOf(requests, latency)
+}
+
+// progressOf returns the progress of the load test, given the number and total latency of requests so far
+func (lt *loadTracker) progressOf(requests int64, latency float64) LoadProgress {
+	p := lt.progress
+	p.Requests = requests
+	p.Elapsed = time.Since(lt.start)
+	if s := p.Elapsed.Seconds(); s > 0 {
+		p.QPS = float64(requests) / s
+	}
+	if requests > 0 {
+		p.MeanLatency = 1000.0 * latency / float64(requests)
+	}
+	return p
+}
+
+// liveSnapshot returns the live metrics of the load test
+func (lt *loadTracker) liveSnapshot() *LiveMetrics {
+	requests, latency, hist := lt.totals(true)
+	errors := atomic.LoadInt64(&lt.errors)
+	p := lt.progressOf(requests, latency)
+	m := &LiveMetrics{
+		Task:        p.Task,
+		Time:        time.Now().UTC().Format(time.RFC3339),
+		Elapsed:     p.Elapsed.Round(time.Second).String(),
+		Requests:    p.Requests,
+		Errors:      errors,
+		QPS:         p.QPS,
+		MeanLatency: p.MeanLatency,
+	}
+	if p.Requests > 0 {
+		m.ErrorRate = float64(errors) / float64(p.Requests)
+		h := hist.Export()
+		m.LatencyP50 = 1000.0 * h.CalcPercentile(50)
+		m.LatencyP99 = 1000.0 * h.CalcPercentile(99)
+	}
+	return m
+}
+
+// saveCheckpoint checkpoints the progress of the load test
+func (lt *loadTracker) saveCheckpoint() {
+	_, _, hist := lt.totals(true)
+	errors := atomic.LoadInt64(&lt.errors)
+	lt.checkpoint.save(errors, hist, time.Since(lt.start))
+}
+
+// stop stops reporting, after reporting the final progress of the load test
+func (lt *loadTracker) stop() {
+	if lt == nil {
+		return
+	}
+	close(lt.done)
+	<-lt.stopped
+	if lt.reporter != nil {
+		lt.reporter.LoadProgress(lt.snapshot())
+	}
+}
@@ -0,0 +1,163 @@
+package base
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingReporter records the progress of experiments
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []string
+	loads  []LoadProgress
+}
+
+func (r *recordingReporter) LoopStarted(loop int, maxLoops int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf("loop %v/%v", loop, maxLoops))
+}
+
+func (r *recordingReporter) TaskStarted(index int, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf("started %v: %v", index, name))
+}
+
+func (r *recordingReporter) TaskEnded(index int, name string, status TaskStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf("%v %v: %v", status, index, name))
+}
+
+func (r *recordingReporter) LoadProgress(p LoadProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loads = append(r.loads, p)
+}
+
+func TestReportProgress(t *testing.T) {
+	os.Chdir(t.TempDir())
+	r := &recordingReporter{}
+	SetProgressReporter(r)
+	defer SetProgressReporter(nil)
+
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo first")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo skipped"), If: StringPointer("false")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1"), ContinueOnError: true}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}},
+		},
+	}
+	err := RunExperiment(false, &mockDriver{exp})
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"loop 1/1",
+		"started 1: run",
+		"completed 1: run",
+		"started 2: run",
+		"skipped 2: run",
+		"started 3: run",
+		"failure ignored 3: run",
+		"started 4: run",
+		"failed 4: run",
+	}, r.events)
+}
+
+func TestLoadTracker(t *testing.T) {
+	// nothing is tracked without a reporter
+	SetProgressReporter(nil)
+	lt := startLoadTracker(CollectHTTPTaskName, 4, 0, 1, nil, nil)
+	assert.Nil(t, lt)
+	lt.stop()
+
+	r := &recordingReporter{}
+	SetProgressReporter(r)
+	defer SetProgressReporter(nil)
+	lt = startLoadTracker(CollectHTTPTaskName, 4, 0, 1, nil, nil)
+	lt.Report(0, time.Now().UnixNano(), 0.01)
+	lt.Report(1, time.Now().UnixNano(), 0.03)
+	lt.stop()
+
+	assert.NotEmpty(t, r.loads)
+	p := r.loads[len(r.loads)-1]
+	assert.Equal(t, CollectHTTPTaskName, p.Task)
+	assert.Equal(t, int64(2), p.Requests)
+	assert.InDelta(t, 20.0, p.MeanLatency, 0.001)
+	assert.InDelta(t, 0.5, p.Fraction(), 0.001)
+}
+
+func TestLoadTrackerThreads(t *testing.T) {
+	r := &recordingReporter{}
+	SetProgressReporter(r)
+	defer SetProgressReporter(nil)
+	// latencies are recorded in histograms for live metrics
+	lt := startLoadTracker(CollectHTTPTaskName, 0, time.Minute, 8, &liveMetricsOpts{interval: time.Hour}, nil)
+	assert.Len(t, lt.shards, 8)
+
+	// requests of all threads are counted
+	var wg sync.WaitGroup
+	for thread := 0; thread < 16; thread++ {
+		wg.Add(1)
+		go func(thread int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				lt.Report(thread, time.Now().UnixNano(), 0.002)
+			}
+		}(thread)
+	}
+	wg.Wait()
+	requests, latency, hist := lt.totals(true)
+	assert.Equal(t, int64(16000), requests)
+	assert.InDelta(t, 32.0, latency, 0.001)
+	assert.Equal(t, int64(16000), hist.Count)
+	lt.stop()
+	assert.Equal(t, int64(16000), r.loads[len(r.loads)-1].Requests)
+
+	// latencies are not recorded in histograms when only progress is reported
+	lt = startLoadTracker(CollectHTTPTaskName, 0, time.Minute, 1, nil, nil)
+	lt.Report(0, time.Now().UnixNano(), 0.002)
+	requests, _, hist = lt.totals(true)
+	lt.stop()
+	assert.Equal(t, int64(1), requests)
+	assert.Equal(t, int64(0), hist.Count)
+}
+
+// benchmarkLoadTracker measures the cost that the load tracker adds to each request sent by concurrent threads
+func benchmarkLoadTracker(b *testing.B, live *liveMetricsOpts) {
+	SetProgressReporter(&recordingReporter{})
+	defer SetProgressReporter(nil)
+	threads := runtime.GOMAXPROCS(0)
+	lt := startLoadTracker(CollectHTTPTaskName, 0, time.Minute, threads, live, nil)
+	defer lt.stop()
+	var thread int64
+	b.RunParallel(func(pb *testing.PB) {
+		th := int(atomic.AddInt64(&thread, 1))
+		for pb.Next() {
+			lt.Report(th, 0, 0.002)
+		}
+	})
+}
+
+func BenchmarkLoadTrackerProgress(b *testing.B) {
+	benchmarkLoadTracker(b, nil)
+}
+
+func BenchmarkLoadTrackerLiveMetrics(b *testing.B) {
+	benchmarkLoadTracker(b, &liveMetricsOpts{interval: time.Hour})
+}
+
+func TestLoadProgressFraction(t *testing.T) {
+	assert.Equal(t, 0.0, LoadProgress{}.Fraction())
+	assert.Equal(t, 0.25, LoadProgress{Requests: 25, TotalRequests: 100}.Fraction())
+	assert.Equal(t, 0.5, LoadProgress{Elapsed: 5 * time.Second, Duration: 10 * time.Second}.Fraction())
+	assert.Equal(t, 1.0, LoadProgress{Elapsed: 15 * time.Second, Duration: 10 * time.Second}.Fraction())
+}
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -14,9 +15,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/jsonpath"
 )
 
 const (
@@ -25,11 +24,20 @@ const (
 
 	// defaultTimeout is default timeout for readiness command
 	defaultTimeout = "10s"
+	// defaultReadinessInterval is the default time between progress reports of the readiness task
+	defaultReadinessInterval = "5s"
+	// readinessPollInterval is the time between checks of objects and metrics that are not ready
+	readinessPollInterval = time.Second
+
+	// readyMetricPrefix is the backend of metrics recorded by the readiness task
+	readyMetricPrefix = "ready"
+	// readyWaitTimeMetricName is the name of the metric with the time spent waiting until objects and metrics are ready
+	readyWaitTimeMetricName = "wait-time"
 )
 
-// ReadinessInputs identifies the K8s object to test for existence and
-// the (optional) condition that should be tested (succeeds if true).
-type readinessInputs struct {
+// readinessResource identifies a K8s object to test for existence, and the (optional) condition
+// or JSONPath result that should be tested.
+type readinessResource struct {
 	// Group of the object. Optional. If unspecified it will be defaulted to ""
 	Group string `json:"group,omitempty" yaml:"group,omitempty"`
 	// Version of the object. Optional. If unspecified it will be defaulted to ""
@@ -42,35 +50,220 @@ type readinessInputs struct {
 	Name string `json:"name" yaml:"name"`
 	// Condition is label of condition to check for value of "True"
 	Condition *string `json:"condition" yaml:"condition"`
-	// Timeout is maximum time spent trying to find object and check condition
+	// JSONPath is a Kubernetes JSONPath expression evaluated on the object; for example, {.status.phase}. Optional.
+	// The object is ready if the result equals Value, or if Value is unspecified, if the result is not empty.
+	JSONPath *string `json:"jsonPath,omitempty" yaml:"jsonPath,omitempty"`
+	// Value is the expected result of JSONPath. Optional.
+	Value *string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Outputs are published once the object is ready. Optional.
+	// Keys are the names of outputs; values are JSONPath expressions evaluated on the object; for example, {.status.podIP}.
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+}
+
+// ReadinessInputs identifies the K8s objects to test for existence and
+// the (optional) conditions that should be tested (succeeds if true).
+type readinessInputs struct {
+	// readinessResource is an object whose readiness is checked.
+	// Optional, if resources, metrics, or Prometheus queries are specified.
+	readinessResource
+	// Resources are further objects whose readiness is checked, along with the object. Optional.
+	// They may be of different types; the task waits until all of them are ready, within the timeout.
+	Resources []readinessResource `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// Timeout is maximum time spent trying to find objects and check conditions, and waiting for metrics
 	Timeout *string `json:"timeout" yaml:"timeout"`
+	// Interval is the time between progress reports, which log the objects and metrics that are not yet ready. Optional. Default is 5s
+	Interval *string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the objects. Optional.
+	// If unspecified, the objects are looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the objects. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+	// Metrics are custom metrics that should have values for each version. Optional.
+	Metrics *metricsReadinessInputs `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	// Prometheus queries that should return samples. Optional.
+	Prometheus *prometheusReadinessInputs `json:"prometheus,omitempty" yaml:"prometheus,omitempty"`
+}
+
+// hasObject returns true if the inputs identify a Kubernetes object.
+// The readiness task may instead wait only for resources, or for metrics.
+func (in *readinessInputs) hasObject() bool {
+	return in.Resource != "" || in.Name != ""
+}
+
+// resources returns the objects whose readiness is checked
+func (in *readinessInputs) resources() []*readinessResource {
+	rs := []*readinessResource{}
+	if in.hasObject() {
+		rs = append(rs, &in.readinessResource)
+	}
+	for i := range in.Resources {
+		rs = append(rs, &in.Resources[i])
+	}
+	return rs
 }
 
-// ReadinessTask checks existence and readiness of specified resources
+// ReadinessTask checks existence and readiness of specified resources,
+// and availability of metrics
 type readinessTask struct {
 	TaskMeta
 	With readinessInputs `json:"with" yaml:"with"`
 }
 
+// driver returns the KubeDriver for the cluster containing the objects
+func (t *readinessTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
 // initializeDefaults sets default values for the readiness task
 func (t *readinessTask) initializeDefaults() {
 	if t.With.Timeout == nil {
 		t.With.Timeout = StringPointer(defaultTimeout)
 	}
+	if t.With.Interval == nil {
+		t.With.Interval = StringPointer(defaultReadinessInterval)
+	}
 
-	kd.initKube()
+	rs := t.With.resources()
+	if len(rs) == 0 {
+		return
+	}
+	t.driver().initKube()
 	// set Namespace (from context) if not already set
-	if t.With.Namespace == nil {
-		t.With.Namespace = StringPointer(kd.Namespace())
+	for _, r := range rs {
+		if r.Namespace == nil {
+			r.Namespace = StringPointer(t.driver().Namespace())
+		}
 	}
 }
 
 // validateInputs validates task inputs
-// at present all validation at initialization
 func (t *readinessTask) validateInputs() error {
+	if (t.With.hasObject() || (len(t.With.Resources) == 0 && t.With.Metrics == nil && t.With.Prometheus == nil)) && (t.With.Resource == "" || t.With.Name == "") {
+		return errors.New("ready task requires a resource and a name")
+	}
+	for i, r := range t.With.Resources {
+		if r.Resource == "" || r.Name == "" {
+			return fmt.Errorf("ready task requires a resource and a name for resource %v", i+1)
+		}
+	}
+	if err := validateMetricsReadiness(t.With.Metrics, t.With.Prometheus); err != nil {
+		return err
+	}
+	if t.With.Timeout != nil {
+		if _, err := time.ParseDuration(*t.With.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %v", *t.With.Timeout)
+		}
+	}
+	if t.With.Interval != nil {
+		if d, err := time.ParseDuration(*t.With.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid interval %v", *t.With.Interval)
+		}
+	}
+	if len(t.With.Outputs) > 0 && !t.With.hasObject() {
+		return errors.New("ready task requires a resource and a name along with outputs")
+	}
+	for _, r := range t.With.resources() {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// validate validates the JSONPath expressions of the resource
+func (r *readinessResource) validate() error {
+	if r.JSONPath != nil {
+		if _, err := parseJSONPath(*r.JSONPath); err != nil {
+			return fmt.Errorf("invalid jsonPath %v: %v", *r.JSONPath, err)
+		}
+	} else if r.Value != nil {
+		return errors.New("ready task requires a jsonPath along with a value")
+	}
+	for name, expr := range r.Outputs {
+		if _, err := parseJSONPath(expr); err != nil {
+			return fmt.Errorf("invalid jsonPath %v for output %v: %v", expr, name, err)
+		}
+	}
+	return nil
+}
+
+// parseJSONPath parses the Kubernetes JSONPath expression; braces around the expression are optional
+func parseJSONPath(expr string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	jp := jsonpath.New("ready")
+	if err := jp.Parse(expr); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
+
+// String describes the resource; for example, deployments.apps default/httpbin
+func (r *readinessResource) String() string {
+	gr := r.Resource
+	if r.Group != "" {
+		gr += "." + r.Group
+	}
+	ns := ""
+	if r.Namespace != nil {
+		ns = *r.Namespace
+	}
+	return fmt.Sprintf("%v %v/%v", gr, ns, r.Name)
+}
+
+// readinessTarget is an object, metrics, or queries whose readiness is checked
+type readinessTarget struct {
+	// name describes the target
+	name string
+	// check returns an error if the target is not ready
+	check func() error
+	// err is the reason why the target was not ready when it was last checked
+	err error
+}
+
+// targets returns the objects, metrics, and queries whose readiness is checked. Objects that are ready are recorded in objs.
+func (t *readinessTask) targets(exp *Experiment, objs map[*readinessResource]*unstructured.Unstructured) []*readinessTarget {
+	targets := []*readinessTarget{}
+	for _, r := range t.With.resources() {
+		r := r
+		targets = append(targets, &readinessTarget{
+			name: r.String(),
+			check: func() error {
+				obj, err := checkObjectExistsAndConditionTrue(t.driver(), r)
+				objs[r] = obj
+				return err
+			},
+		})
+	}
+	if t.With.Metrics != nil {
+		targets = append(targets, &readinessTarget{
+			name:  "custom metrics",
+			check: func() error { return checkMetricsAvailable(t.With.Metrics, exp) },
+		})
+	}
+	if t.With.Prometheus != nil {
+		targets = append(targets, &readinessTarget{
+			name:  "Prometheus samples",
+			check: func() error { return checkPrometheusSamples(t.With.Prometheus) },
+		})
+	}
+	return targets
+}
+
+// describeTargets describes the targets, along with the reasons why they are not ready
+func describeTargets(targets []*readinessTarget) string {
+	ds := []string{}
+	for _, tg := range targets {
+		if tg.err != nil {
+			ds = append(ds, fmt.Sprintf("%v (%v)", tg.name, tg.err))
+		} else {
+			ds = append(ds, tg.name)
+		}
+	}
+	return strings.Join(ds, ", ")
+}
+
 // run executes the task
 func (t *readinessTask) run(exp *Experiment) error {
 	// validation
@@ -82,73 +275,146 @@ func (t *readinessTask) run(exp *Experiment) error {
 	// initialization
 	t.initializeDefaults()
 
-	// parse timeout
+	// parse timeout and interval
 	timeout, err := time.ParseDuration(*t.With.Timeout)
 	if err != nil {
 		e := errors.New("invalid format for timeout")
 		log.Logger.WithStackTrace(err.Error()).Error(e)
 		return e
 	}
+	interval, _ := time.ParseDuration(*t.With.Interval)
 
 	// get rest config
-	restConfig, err := kd.EnvSettings.RESTClientGetter().ToRESTConfig()
-	if err != nil {
-		e := errors.New("unable to get Kubernetes REST config")
-		log.Logger.WithStackTrace(err.Error()).Error(e)
+	if len(t.With.resources()) > 0 {
+		if _, err = t.driver().EnvSettings.RESTClientGetter().ToRESTConfig(); err != nil {
+			e := errors.New("unable to get Kubernetes REST config")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+	}
+
+	// do the work: check objects, metrics, and queries that are not ready,
+	// reporting progress every interval, until all of them are ready, or the deadline passes
+	objs := map[*readinessResource]*unstructured.Unstructured{}
+	targets := t.targets(exp, objs)
+	start := time.Now()
+	deadline := start.Add(timeout)
+	nextReport := start.Add(interval)
+	pending := targets
+	for {
+		unready := []*readinessTarget{}
+		for _, tg := range pending {
+			if tg.err = tg.check(); tg.err != nil {
+				unready = append(unready, tg)
+				continue
+			}
+			log.Logger.Debugf("ready: %v", tg.name)
+		}
+		pending = unready
+		now := time.Now()
+		if len(pending) == 0 || !now.Before(deadline) {
+			break
+		}
+		if !now.Before(nextReport) {
+			log.Logger.Infof("waiting for %v of %v: %v", len(pending), len(targets), describeTargets(pending))
+			nextReport = now.Add(interval)
+		}
+		wait := readinessPollInterval
+		if d := time.Until(deadline); d < wait {
+			wait = d
+		}
+		time.Sleep(wait)
+	}
+	waited := time.Since(start)
+	if exp.Result != nil {
+		if err = exp.Result.recordGauge(readyMetricPrefix+"/"+readyWaitTimeMetricName, MetricMeta{
+			Description: "time spent waiting until objects and metrics are ready",
+			Units:       StringPointer("sec"),
+			Type:        GaugeMetricType,
+		}, waited.Seconds()); err != nil {
+			return err
+		}
+	}
+	if len(pending) > 0 {
+		e := fmt.Errorf("not ready after %v: %v", waited.Round(time.Second), describeTargets(pending))
+		log.Logger.Error(e)
 		return e
 	}
+	log.Logger.Infof("ready: %v", describeTargets(targets))
 
-	// do the work: check for object and condition
-	// repeat until time out
-	interval := 1 * time.Second
-	err = retry.OnError(
-		wait.Backoff{
-			Steps:    int(timeout / interval),
-			Cap:      timeout,
-			Duration: interval,
-			Factor:   1.0,
-			Jitter:   0.1,
-		},
-		func(err error) bool {
-			log.Logger.Error(err)
-			return true
-		}, // retry on all failures
-		func() error {
-			return checkObjectExistsAndConditionTrue(t, restConfig)
-		},
-	)
-	return err
+	// publish outputs from the ready objects
+	for _, r := range t.With.resources() {
+		for name, expr := range r.Outputs {
+			jp, _ := parseJSONPath(expr)
+			buf := new(bytes.Buffer)
+			if err := jp.Execute(buf, objs[r].Object); err != nil {
+				e := fmt.Errorf("unable to get output %v from %v", name, r)
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return e
+			}
+			exp.setOutput(name, buf.String())
+		}
+	}
+	return nil
 }
 
 // checkObjectExistsAndConditionTrue determines if the object exists
-// if so, it further checks if the requested condition is "True"
-func checkObjectExistsAndConditionTrue(t *readinessTask, restCfg *rest.Config) error {
-	log.Logger.Trace("looking for resource (", t.With.Group, "/", t.With.Version, ") ", t.With.Resource, ": ", t.With.Name, " in namespace ", *t.With.Namespace)
+// if so, it further checks if the requested condition is "True", and returns the object
+func checkObjectExistsAndConditionTrue(d *KubeDriver, r *readinessResource) (*unstructured.Unstructured, error) {
+	log.Logger.Trace("looking for resource (", r.Group, "/", r.Version, ") ", r.Resource, ": ", r.Name, " in namespace ", *r.Namespace)
 
-	obj, err := kd.dynamicClient.Resource(gvr(&t.With)).Namespace(*t.With.Namespace).Get(context.Background(), t.With.Name, metav1.GetOptions{})
+	obj, err := d.dynamicClient.Resource(gvr(r)).Namespace(*r.Namespace).Get(context.Background(), r.Name, metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// if no condition to check was specified, we can return now
-	if t.With.Condition == nil {
-		return nil
+	// if a condition to check was specified, find the condition and check that it is "True"
+	if r.Condition != nil {
+		log.Logger.Trace("looking for condition: ", *r.Condition)
+
+		cs, err := getConditionStatus(obj, *r.Condition)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(*cs, string(corev1.ConditionTrue)) {
+			return nil, fmt.Errorf("condition %v is %v", *r.Condition, *cs)
+		}
 	}
 
-	// otherwise, find the condition and check that it is "True"
-	log.Logger.Trace("looking for condition: ", *t.With.Condition)
+	// if a JSONPath was specified, check its result
+	if r.JSONPath != nil {
+		if err := checkJSONPath(obj, *r.JSONPath, r.Value); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
 
-	cs, err := getConditionStatus(obj, *t.With.Condition)
+// checkJSONPath checks that the result of the JSONPath expression evaluated on the object equals the value,
+// or if no value is specified, that the result is not empty
+func checkJSONPath(obj *unstructured.Unstructured, expr string, value *string) error {
+	jp, err := parseJSONPath(expr)
 	if err != nil {
 		return err
 	}
-	if strings.EqualFold(*cs, string(corev1.ConditionTrue)) {
+	buf := new(bytes.Buffer)
+	if err := jp.Execute(buf, obj.Object); err != nil {
+		return fmt.Errorf("jsonPath %v: %v", expr, err)
+	}
+	result := buf.String()
+	if value == nil {
+		if result == "" {
+			return fmt.Errorf("jsonPath %v is empty", expr)
+		}
 		return nil
 	}
-	return errors.New("condition status not True")
+	if result != *value {
+		return fmt.Errorf("jsonPath %v is %q, not %q", expr, result, *value)
+	}
+	return nil
 }
 
-func gvr(objRef *readinessInputs) schema.GroupVersionResource {
+func gvr(objRef *readinessResource) schema.GroupVersionResource {
 	return schema.GroupVersionResource{
 		Group:    objRef.Group,
 		Version:  objRef.Version,
@@ -0,0 +1,137 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	ierrors "github.com/iter8-tools/iter8/base/errors"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// metricsReadinessInputs identifies the custom metrics that should have values for each version.
+// Providers, common values, and version values are the same as those of the custommetrics task.
+type metricsReadinessInputs struct {
+	customMetricsInputs
+	// Names of the metrics that should have values; for example, request-count.
+	// Optional. If unspecified, all metrics of the providers should have values.
+	Names []string `json:"names,omitempty" yaml:"names,omitempty"`
+}
+
+// prometheusReadinessInputs identifies the PromQL queries that should return samples.
+// Typically, there is one query for each version.
+type prometheusReadinessInputs struct {
+	// URL of Prometheus; for example, http://prometheus.istio-system:9090
+	URL string `json:"url" yaml:"url"`
+	// Queries are PromQL queries that should return samples
+	Queries []string `json:"queries" yaml:"queries"`
+	// Headers are HTTP headers sent with queries; for example, Authorization
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// checkMetricsAvailable returns an error if a metric of a custom metrics provider has no value for a version
+func checkMetricsAvailable(in *metricsReadinessInputs, exp *Experiment) error {
+	client := in.Query.client()
+	specs, errs := versionMetricsSpecs(client, in.customMetricsInputs, exp)
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	queries := metricQueries(specs, func(metric Metric) bool {
+		return len(in.Names) == 0 || contains(in.Names, metric.Name)
+	})
+	for _, q := range queries {
+		if q.metric.Params == nil {
+			q.metric.Params = &[]Params{}
+		}
+	}
+	runMetricQueries(client, queries, in.concurrency())
+	for _, q := range queries {
+		if _, isErr := q.value.(error); !q.ok || q.value == nil || isErr {
+			return fmt.Errorf("metric %v/%v has no value for version %v", q.spec.Provider, q.metric.Name, q.version)
+		}
+		log.Logger.Debugf("metric %v/%v has value %v for version %v", q.spec.Provider, q.metric.Name, q.value, q.version)
+	}
+	return nil
+}
+
+// contains returns true if the slice contains the string
+func contains(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// promQueryResponse is the response of the Prometheus query API
+type promQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// checkPrometheusSamples returns an error if a PromQL query does not return samples
+func checkPrometheusSamples(in *prometheusReadinessInputs) error {
+	for i, query := range in.Queries {
+		u := strings.TrimSuffix(in.URL, "/") + "/api/v1/query?" + url.Values{"query": []string{query}}.Encode()
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		for k, v := range in.Headers {
+			req.Header.Add(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return ierrors.Wrap(ierrors.ErrMetricsBackendUnavailable, err, "unable to query prometheus at %v: %v", in.URL, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		pr := promQueryResponse{}
+		if err := json.Unmarshal(body, &pr); err != nil {
+			return fmt.Errorf("unable to parse response of query %v: %v", i, err)
+		}
+		if pr.Status != "success" {
+			return fmt.Errorf("query %v failed: %v", i, pr.Error)
+		}
+		// vectors and matrices are lists of series; scalars and strings are single samples
+		samples := []interface{}{}
+		if err := json.Unmarshal(pr.Data.Result, &samples); err != nil {
+			return fmt.Errorf("unable to parse result of query %v: %v", i, err)
+		}
+		if len(samples) == 0 {
+			return fmt.Errorf("query %v returned no samples", i)
+		}
+		log.Logger.Debugf("query %v returned samples", i)
+	}
+	return nil
+}
+
+// validateMetricsReadiness validates the metrics and Prometheus inputs of the readiness task
+func validateMetricsReadiness(m *metricsReadinessInputs, p *prometheusReadinessInputs) error {
+	if m != nil && len(m.ProviderURLs) == 0 {
+		return errors.New("ready task requires providerURLs for metrics")
+	}
+	if m != nil {
+		if err := m.customMetricsInputs.validate(); err != nil {
+			return err
+		}
+	}
+	if p != nil && (p.URL == "" || len(p.Queries) == 0) {
+		return errors.New("ready task requires a url and queries for prometheus")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package base
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPrometheusServer returns a fake Prometheus server whose queries return samples
+// after the given number of queries
+func newPrometheusServer(t *testing.T, emptyQueries int32) *httptest.Server {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/query":
+			if atomic.AddInt32(&n, 1) <= emptyQueries {
+				fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1645602108.839,"43"]}]}}`)
+		case "/metrics.yaml":
+			fmt.Fprintf(w, `url: %v/api/v1/query
+provider: test
+method: GET
+metrics:
+- name: request-count
+  type: counter
+  description: number of requests
+  params:
+  - name: query
+    value: sum(requests_total{version="{{"{{"}}.version{{"}}"}}"})
+  jqExpression: .data.result[0].value[1] | tonumber
+`, "http://"+r.Host)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReadinessPrometheus(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := newPrometheusServer(t, 2)
+	rTask := &readinessTask{
+		TaskMeta: TaskMeta{Task: StringPointer(ReadinessTaskName)},
+		With: readinessInputs{
+			Prometheus: &prometheusReadinessInputs{
+				URL:     srv.URL,
+				Queries: []string{`sum(requests_total{version="v1"})`, `sum(requests_total{version="v2"})`},
+			},
+			Timeout: StringPointer("10s"),
+		},
+	}
+	assert.NoError(t, rTask.validateInputs())
+	assert.NoError(t, rTask.run(&Experiment{Spec: []Task{rTask}, Result: &ExperimentResult{}}))
+
+	// queries that never return samples time out
+	srv = newPrometheusServer(t, 1000)
+	rTask.With.Prometheus.URL = srv.URL
+	rTask.With.Timeout = StringPointer("2s")
+	assert.Error(t, rTask.run(&Experiment{Spec: []Task{rTask}, Result: &ExperimentResult{}}))
+
+	// prometheus requires queries
+	rTask.With.Prometheus.Queries = nil
+	assert.Error(t, rTask.validateInputs())
+}
+
+func TestReadinessMetrics(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := newPrometheusServer(t, 1)
+	rTask := &readinessTask{
+		TaskMeta: TaskMeta{Task: StringPointer(ReadinessTaskName)},
+		With: readinessInputs{
+			Metrics: &metricsReadinessInputs{
+				customMetricsInputs: customMetricsInputs{
+					ProviderURLs: []string{srv.URL + "/metrics.yaml"},
+					VersionInfo:  []map[string]interface{}{{"version": "v1"}, {"version": "v2"}},
+				},
+				Names: []string{"request-count"},
+			},
+			Timeout: StringPointer("10s"),
+		},
+	}
+	exp := &Experiment{Spec: []Task{rTask}, Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.NoError(t, rTask.run(exp))
+
+	// readiness task without an object or metrics is invalid
+	rTask.With.Metrics = nil
+	assert.Error(t, rTask.validateInputs())
+}
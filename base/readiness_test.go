@@ -74,6 +74,160 @@ func TestInvalidTimeout(t *testing.T) {
 	runTaskTest(t, rTask, false, ns, pod)
 }
 
+// TestTargetCluster tests that the object is looked up in the cluster identified by the kubeconfig context
+func TestTargetCluster(t *testing.T) {
+	os.Chdir(t.TempDir())
+	ns, nm := "default", "test-pod"
+	pod := newPod(ns, nm).withCondition("Ready", "True").build()
+	targetDrivers = map[string]*KubeDriver{"|other": NewFakeKubeDriver(cli.New())}
+	t.Cleanup(func() { targetDrivers = map[string]*KubeDriver{} })
+	rs := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	_, err := targetDrivers["|other"].dynamicClient.Resource(rs).Namespace(ns).Create(context.Background(), pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// object is found in the target cluster
+	rTask := newReadinessTask(nm).withVersion("v1").withResource("pods").withNamespace(ns).withCondition("Ready").withContext("other").build()
+	assert.NoError(t, rTask.run(&Experiment{Spec: []Task{rTask}, Result: &ExperimentResult{}}))
+
+	// object is not present in the cluster in which the experiment runs
+	rTask = newReadinessTask(nm).withVersion("v1").withResource("pods").withNamespace(ns).withTimeout("1s").build()
+	runTaskTest(t, rTask, false, ns, newPod(ns, "other-pod").build())
+}
+
+// TestWithJSONPath tests that the task succeeds when the result of the JSONPath equals the value
+func TestWithJSONPath(t *testing.T) {
+	os.Chdir(t.TempDir())
+	ns, nm := "default", "test-pod"
+	pod := newPod(ns, nm).withPhase(corev1.PodRunning).build()
+	rTask := newReadinessTask(nm).withVersion("v1").withResource("pods").withNamespace(ns).withJSONPath(".status.phase", "Running").build()
+	runTaskTest(t, rTask, true, ns, pod)
+
+	// braces are optional, and any non-empty result is ready if no value is specified
+	rTask = newReadinessTask(nm).withVersion("v1").withResource("pods").withNamespace(ns).withJSONPath("{.status.phase}", "").build()
+	rTask.With.Value = nil
+	runTaskTest(t, rTask, true, ns, pod)
+}
+
+// TestWithJSONPathMismatch tests that the task fails when the result of the JSONPath does not equal the value
+func TestWithJSONPathMismatch(t *testing.T) {
+	os.Chdir(t.TempDir())
+	ns, nm := "default", "test-pod"
+	pod := newPod(ns, nm).withPhase(corev1.PodPending).build()
+	rTask := newReadinessTask(nm).withVersion("v1").withResource("pods").withNamespace(ns).withJSONPath(".status.phase", "Running").withTimeout("1s").build()
+	runTaskTest(t, rTask, false, ns, pod)
+}
+
+// TestInvalidJSONPath tests that the task fails when the JSONPath cannot be parsed
+func TestInvalidJSONPath(t *testing.T) {
+	os.Chdir(t.TempDir())
+	ns, nm := "default", "test-pod"
+	pod := newPod(ns, nm).build()
+	rTask := newReadinessTask(nm).withVersion("v1").withResource("pods").withNamespace(ns).withJSONPath("{.status[", "").build()
+	assert.Error(t, rTask.validateInputs())
+	runTaskTest(t, rTask, false, ns, pod)
+}
+
+// TestCustomResource tests that the task checks conditions of arbitrary resources
+func TestCustomResource(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	isvc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "serving.kserve.io/v1beta1",
+		"kind":       "InferenceService",
+		"metadata":   map[string]interface{}{"name": "sklearn-iris", "namespace": "default"},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		},
+	}}
+	rs := schema.GroupVersionResource{Group: "serving.kserve.io", Version: "v1beta1", Resource: "inferenceservices"}
+	_, err := kd.dynamicClient.Resource(rs).Namespace("default").Create(context.Background(), isvc, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	rTask := newReadinessTask("sklearn-iris").withGroup("serving.kserve.io").withVersion("v1beta1").withResource("inferenceservices").withCondition("Ready").build()
+	assert.NoError(t, rTask.run(&Experiment{Spec: []Task{rTask}, Result: &ExperimentResult{}}))
+}
+
+// TestWithOutputs tests that the task publishes outputs from the ready object
+func TestWithOutputs(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	ns, nm := "default", "test-pod"
+	pod := newPod(ns, nm).withPhase(corev1.PodRunning).build()
+	unstructured.SetNestedField(pod.Object, "10.0.0.7", "status", "podIP")
+	rs := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	_, err := kd.dynamicClient.Resource(rs).Namespace(ns).Create(context.Background(), pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	rTask := newReadinessTask(nm).withVersion("v1").withResource("pods").withNamespace(ns).build()
+	rTask.With.Outputs = map[string]string{"ip": "{.status.podIP}", "phase": ".status.phase"}
+	exp := &Experiment{Spec: []Task{rTask}, Result: &ExperimentResult{}}
+	assert.NoError(t, rTask.run(exp))
+	assert.Equal(t, map[string]string{"ip": "10.0.0.7", "phase": "Running"}, exp.Result.Outputs)
+
+	rTask.With.Outputs["ip"] = "{.status["
+	assert.Error(t, rTask.validateInputs())
+}
+
+// TestMultipleResources tests that the task waits for all of its resources, reporting the ones that are not ready,
+// and records the time spent waiting
+func TestMultipleResources(t *testing.T) {
+	os.Chdir(t.TempDir())
+	ns := "default"
+	*kd = *NewFakeKubeDriver(cli.New())
+	rs := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	_, err := kd.dynamicClient.Resource(rs).Namespace(ns).Create(context.Background(), newPod(ns, "test-pod").withCondition("Ready", "True").build(), metav1.CreateOptions{})
+	assert.NoError(t, err)
+	isvc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "serving.kserve.io/v1beta1",
+		"kind":       "InferenceService",
+		"metadata":   map[string]interface{}{"name": "sklearn-iris", "namespace": ns},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		},
+	}}
+	_, err = kd.dynamicClient.Resource(schema.GroupVersionResource{Group: "serving.kserve.io", Version: "v1beta1", Resource: "inferenceservices"}).Namespace(ns).Create(context.Background(), isvc, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	rTask := newReadinessTask("test-pod").withVersion("v1").withResource("pods").withCondition("Ready").withTimeout("5s").build()
+	rTask.With.Interval = StringPointer("1s")
+	rTask.With.Resources = []readinessResource{{
+		Group:     "serving.kserve.io",
+		Version:   "v1beta1",
+		Resource:  "inferenceservices",
+		Name:      "sklearn-iris",
+		Condition: StringPointer("Ready"),
+		Outputs:   map[string]string{"model": "{.metadata.name}"},
+	}}
+	exp := &Experiment{Spec: []Task{rTask}, Result: &ExperimentResult{}}
+	assert.NoError(t, rTask.run(exp))
+	assert.Equal(t, map[string]string{"model": "sklearn-iris"}, exp.Result.Outputs)
+
+	// the wait time is recorded once insights are initialized
+	assert.Nil(t, exp.Result.Insights)
+	assert.NoError(t, exp.Result.initInsightsWithNumVersions(2))
+	for i := 0; i < 2; i++ {
+		assert.Len(t, exp.Result.Insights.NonHistMetricValues[i]["ready/wait-time"], 1)
+	}
+
+	// only resources that are not ready are reported
+	rTask.With.Timeout = StringPointer("2s")
+	rTask.With.Resources = append(rTask.With.Resources, readinessResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "deployments",
+		Name:     "httpbin",
+	})
+	err = rTask.run(exp)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "deployments.apps default/httpbin")
+	assert.NotContains(t, err.Error(), "sklearn-iris")
+	assert.Len(t, exp.Result.Insights.NonHistMetricValues[0]["ready/wait-time"], 2)
+
+	// every resource requires a name
+	rTask.With.Resources = append(rTask.With.Resources, readinessResource{Resource: "services"})
+	assert.Error(t, rTask.validateInputs())
+}
+
 // UTILITY METHODS for all tests
 
 // runTaskTest creates fake cluster with pod and runs rTask
@@ -118,6 +272,11 @@ func (p *podBuilder) build() *unstructured.Unstructured {
 	return &unstructured.Unstructured{Object: o}
 }
 
+func (p *podBuilder) withPhase(phase corev1.PodPhase) *podBuilder {
+	p.Status.Phase = phase
+	return p
+}
+
 func (p *podBuilder) withCondition(typ string, value string) *podBuilder {
 	c := corev1.PodCondition{Type: (corev1.PodConditionType(typ))}
 	switch strings.ToLower(value) {
@@ -141,17 +300,19 @@ func newReadinessTask(name string) *readinessTaskBuilder {
 			Task: StringPointer(ReadinessTaskName),
 		},
 		With: readinessInputs{
-			Name: name,
+			readinessResource: readinessResource{
+				Name: name,
+			},
 		},
 	}
 
 	return (*readinessTaskBuilder)(rTask)
 }
 
-// func (t *readinessTaskBuilder) withGroup(group string) *readinessTaskBuilder {
-// 	t.With.Group = group
-// 	return t
-// }
+func (t *readinessTaskBuilder) withGroup(group string) *readinessTaskBuilder {
+	t.With.Group = group
+	return t
+}
 func (t *readinessTaskBuilder) withVersion(version string) *readinessTaskBuilder {
 	t.With.Version = version
 	return t
@@ -172,11 +333,22 @@ func (t *readinessTaskBuilder) withTimeout(timeout string) *readinessTaskBuilder
 	return t
 }
 
+func (t *readinessTaskBuilder) withJSONPath(jsonPath string, value string) *readinessTaskBuilder {
+	t.With.JSONPath = &jsonPath
+	t.With.Value = &value
+	return t
+}
+
 func (t *readinessTaskBuilder) withCondition(condition string) *readinessTaskBuilder {
 	t.With.Condition = &condition
 	return t
 }
 
+func (t *readinessTaskBuilder) withContext(context string) *readinessTaskBuilder {
+	t.With.Context = context
+	return t
+}
+
 func (t *readinessTaskBuilder) build() *readinessTask {
 	return (*readinessTask)(t)
 }
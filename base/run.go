@@ -1,11 +1,20 @@
 package base
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 
 	log "github.com/iter8-tools/iter8/base/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const (
@@ -18,10 +27,57 @@ var (
 	tempDirEnv string = fmt.Sprintf("TEMP_DIR=%v", os.TempDir())
 )
 
+// runInputs are the inputs of a run task
+type runInputs struct {
+	// Language of the script; bash or starlark. Optional. If unspecified it will be defaulted to bash.
+	// Starlark scripts run in an embedded interpreter, and do not require a shell.
+	Language string `json:"language,omitempty" yaml:"language,omitempty"`
+	// Shell that runs the script; bash, sh, powershell, pwsh, or cmd. Optional.
+	// If unspecified it will be defaulted to bash, or powershell on Windows. Not used by starlark scripts.
+	Shell string `json:"shell,omitempty" yaml:"shell,omitempty"`
+	// Env are environment variables of the script. Optional.
+	Env []envVar `json:"env,omitempty" yaml:"env,omitempty"`
+	// Metrics enables the script to contribute metrics, by printing lines with JSON documents to stdout. Optional.
+	// For example, {"name": "throughput", "type": "Gauge", "value": 230.5, "version": 1}
+	Metrics bool `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+}
+
+// envVar is an environment variable of the script of a run task
+type envVar struct {
+	// Name of the environment variable
+	Name string `json:"name" yaml:"name"`
+	// Value of the environment variable. Specify either Value or ValueFrom but not both
+	Value *string `json:"value,omitempty" yaml:"value,omitempty"`
+	// ValueFrom is the source of the value of the environment variable
+	ValueFrom *envVarSource `json:"valueFrom,omitempty" yaml:"valueFrom,omitempty"`
+}
+
+// envVarSource is the source of the value of an environment variable; specify exactly one of its fields
+type envVarSource struct {
+	// SecretKeyRef is a key of a Kubernetes secret
+	SecretKeyRef *keyRef `json:"secretKeyRef,omitempty" yaml:"secretKeyRef,omitempty"`
+	// ConfigMapKeyRef is a key of a Kubernetes config map
+	ConfigMapKeyRef *keyRef `json:"configMapKeyRef,omitempty" yaml:"configMapKeyRef,omitempty"`
+	// Env is the name of an environment variable of the Iter8 process
+	Env *string `json:"env,omitempty" yaml:"env,omitempty"`
+}
+
+// keyRef identifies a key of a Kubernetes secret or config map
+type keyRef struct {
+	// Name of the secret or config map
+	Name string `json:"name" yaml:"name"`
+	// Key whose value is used
+	Key string `json:"key" yaml:"key"`
+	// Namespace of the secret or config map. Optional. If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
 // runTask enables running a shell script
 type runTask struct {
 	// TaskMeta has fields common to all tasks
 	TaskMeta
+	// With are the inputs of the task. Optional.
+	With *runInputs `json:"with,omitempty" yaml:"with,omitempty"`
 }
 
 // initializeDefaults sets default values for task inputs
@@ -29,19 +85,129 @@ func (t *runTask) initializeDefaults() {}
 
 // validateInputs for this task
 func (t *runTask) validateInputs() error {
+	if t.With == nil {
+		return nil
+	}
+	if t.With.Language != "" && t.With.Language != bashLanguage && t.With.Language != starlarkLanguage {
+		return fmt.Errorf("unsupported language %v; must be %v or %v", t.With.Language, bashLanguage, starlarkLanguage)
+	}
+	if err := validateShell(t.With.Shell); err != nil {
+		return err
+	}
+	if t.With.Shell != "" && t.With.Language == starlarkLanguage {
+		return errors.New("shell cannot be specified for starlark scripts")
+	}
+	for _, ev := range t.With.Env {
+		if ev.Name == "" {
+			return errors.New("environment variable requires a name")
+		}
+		if (ev.Value == nil) == (ev.ValueFrom == nil) {
+			return fmt.Errorf("environment variable %v requires either a value or valueFrom", ev.Name)
+		}
+		if ev.ValueFrom != nil {
+			n := 0
+			for _, ref := range []*keyRef{ev.ValueFrom.SecretKeyRef, ev.ValueFrom.ConfigMapKeyRef} {
+				if ref != nil {
+					n++
+					if ref.Name == "" || ref.Key == "" {
+						return fmt.Errorf("environment variable %v requires a name and key of its source", ev.Name)
+					}
+				}
+			}
+			if ev.ValueFrom.Env != nil {
+				n++
+			}
+			if n != 1 {
+				return fmt.Errorf("environment variable %v requires exactly one of secretKeyRef, configMapKeyRef, or env", ev.Name)
+			}
+		}
+	}
 	return nil
 }
 
 // getCommand gets the executable command
 func (t *runTask) getCommand() *exec.Cmd {
 	cmdStr := *t.TaskMeta.Run
+	shell := ""
+	if t.With != nil {
+		shell = t.With.Shell
+	}
 	// create command to be executed
-	cmd := exec.Command("/bin/bash", "-c", cmdStr)
+	cmd := shellCommand(shell, cmdStr)
 	// append the environment variable for temp dir
 	cmd.Env = append(os.Environ(), tempDirEnv)
 	return cmd
 }
 
+// env returns the environment variables of the script as name=value pairs
+func (t *runTask) env() ([]string, error) {
+	var env []string
+	if t.With == nil {
+		return env, nil
+	}
+	for _, ev := range t.With.Env {
+		var val string
+		switch {
+		case ev.Value != nil:
+			val = *ev.Value
+		case ev.ValueFrom.Env != nil:
+			v, ok := os.LookupEnv(*ev.ValueFrom.Env)
+			if !ok {
+				return nil, fmt.Errorf("environment variable %v is not set", *ev.ValueFrom.Env)
+			}
+			val = v
+		case ev.ValueFrom.SecretKeyRef != nil:
+			v, err := keyValue(ev.ValueFrom.SecretKeyRef, "secrets")
+			if err != nil {
+				return nil, err
+			}
+			val = v
+		default:
+			v, err := keyValue(ev.ValueFrom.ConfigMapKeyRef, "configmaps")
+			if err != nil {
+				return nil, err
+			}
+			val = v
+		}
+		env = append(env, ev.Name+"="+val)
+	}
+	return env, nil
+}
+
+// keyValue returns the value of the key of the Kubernetes secret or config map
+func keyValue(ref *keyRef, resource string) (string, error) {
+	if err := kd.initKube(); err != nil {
+		return "", err
+	}
+	ns := kd.Namespace()
+	if ref.Namespace != nil {
+		ns = *ref.Namespace
+	}
+	obj, err := kd.dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: resource}).Namespace(ns).Get(context.Background(), ref.Name, metav1.GetOptions{})
+	if err != nil {
+		e := fmt.Errorf("unable to get %v %v/%v", resource, ns, ref.Name)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	val, ok, _ := unstructured.NestedString(obj.Object, "data", ref.Key)
+	if !ok {
+		e := fmt.Errorf("key %v not found in %v %v/%v", ref.Key, resource, ns, ref.Name)
+		log.Logger.Error(e)
+		return "", e
+	}
+	if resource == "secrets" {
+		b, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			e := fmt.Errorf("unable to decode key %v of secret %v/%v", ref.Key, ns, ref.Name)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return "", e
+		}
+		val = string(b)
+		log.AddSecret(val)
+	}
+	return val, nil
+}
+
 // run the command
 func (t *runTask) run(exp *Experiment) error {
 	err := t.validateInputs()
@@ -51,13 +217,90 @@ func (t *runTask) run(exp *Experiment) error {
 
 	t.initializeDefaults()
 
+	if t.With != nil && t.With.Language == starlarkLanguage {
+		env, err := t.env()
+		if err != nil {
+			return err
+		}
+		return t.runStarlark(exp, env)
+	}
+
+	// the script publishes outputs by writing name=value lines to this file
+	of, err := ioutil.TempFile("", "iter8-output")
+	if err != nil {
+		return err
+	}
+	of.Close()
+	defer os.Remove(of.Name())
+
+	env, err := t.env()
+	if err != nil {
+		return err
+	}
 	cmd := t.getCommand()
-	out, err := cmd.CombinedOutput()
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Env = append(cmd.Env, outputEnv+"="+of.Name())
+	if t.With != nil && t.With.Metrics {
+		return t.runWithMetrics(cmd, exp, of.Name())
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = runCommand(exp.context(), cmd)
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("combined execution failed")
-		log.Logger.WithStackTrace(string(out)).Error("combined output from command")
+		log.Logger.WithStackTrace(out.String()).Error("combined output from command")
 		return err
 	}
-	log.Logger.WithStackTrace(string(out)).Trace("combined output from command")
-	return nil
+	log.Logger.WithStackTrace(out.String()).Trace("combined output from command")
+	return exp.readOutputs(of.Name())
+}
+
+// runCommand runs the command in its own process group, and waits for it to exit;
+// the process group is killed when the context is done, so that commands started by the script also stop
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Logger.Warn("stopping command")
+			killProcessGroup(cmd)
+		case <-exited:
+		}
+	}()
+	err := cmd.Wait()
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return fmt.Errorf("command stopped: %v", ctxErr)
+	}
+	return err
+}
+
+// runWithMetrics runs the command, and records the metrics that it prints to stdout
+func (t *runTask) runWithMetrics(cmd *exec.Cmd, exp *Experiment, outputs string) error {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := runCommand(exp.context(), cmd)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("execution failed")
+		log.Logger.WithStackTrace(stdout.String() + stderr.String()).Error("output from command")
+		return err
+	}
+	log.Logger.WithStackTrace(stdout.String() + stderr.String()).Trace("output from command")
+
+	metrics, err := parseRunMetrics(stdout.Bytes())
+	if err != nil {
+		e := errors.New("unable to parse metrics printed by run task")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	if err := exp.updateRunMetrics(runMetricPrefix, metrics); err != nil {
+		return err
+	}
+	return exp.readOutputs(outputs)
 }
@@ -0,0 +1,130 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// runMetricPrefix is the backend of metrics printed by run tasks
+	runMetricPrefix = "run"
+)
+
+// runMetric is a metric printed by the script of a run task, as a single line JSON document; for example,
+// {"name": "throughput", "type": "Gauge", "value": 230.5, "version": 1}
+type runMetric struct {
+	// Name of the metric; it is recorded under the run backend, for example, run/throughput
+	Name string `json:"name"`
+	// Type of the metric; Counter, Gauge, or Sample. Optional. If unspecified it will be defaulted to Gauge
+	Type MetricType `json:"type,omitempty"`
+	// Value of the metric; a number, or for Sample metrics, a list of numbers
+	Value interface{} `json:"value"`
+	// Version is the index of the app version that the metric is observed for. Optional. Default is 0
+	Version int `json:"version,omitempty"`
+	// Description of the metric. Optional.
+	Description string `json:"description,omitempty"`
+	// Units of the metric. Optional.
+	Units *string `json:"units,omitempty"`
+}
+
+// metricMeta returns the meta data and value of the metric
+func (m *runMetric) metricMeta() (MetricMeta, interface{}, error) {
+	mm := MetricMeta{
+		Description: m.Description,
+		Units:       m.Units,
+		Type:        GaugeMetricType,
+	}
+	if mm.Description == "" {
+		mm.Description = fmt.Sprintf("%v printed by run task", m.Name)
+	}
+	for _, t := range []MetricType{CounterMetricType, GaugeMetricType, SampleMetricType} {
+		if strings.EqualFold(string(m.Type), string(t)) {
+			mm.Type = t
+		}
+	}
+	if m.Type != "" && !strings.EqualFold(string(m.Type), string(mm.Type)) {
+		return mm, nil, fmt.Errorf("metric %v has unsupported type %v", m.Name, m.Type)
+	}
+
+	switch v := m.Value.(type) {
+	case float64:
+		if mm.Type != SampleMetricType {
+			return mm, v, nil
+		}
+		return mm, []float64{v}, nil
+	case []interface{}:
+		if mm.Type != SampleMetricType {
+			return mm, nil, fmt.Errorf("metric %v of type %v requires a number", m.Name, mm.Type)
+		}
+		vals := make([]float64, len(v))
+		for i, e := range v {
+			f, ok := e.(float64)
+			if !ok {
+				return mm, nil, fmt.Errorf("metric %v has a value that is not a number: %v", m.Name, e)
+			}
+			vals[i] = f
+		}
+		return mm, vals, nil
+	}
+	return mm, nil, fmt.Errorf("metric %v has a value that is not a number: %v", m.Name, m.Value)
+}
+
+// parseRunMetrics returns the metrics in the output of a script; these are lines that begin with {
+func parseRunMetrics(out []byte) ([]runMetric, error) {
+	var metrics []runMetric
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		m := runMetric{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			return nil, fmt.Errorf("invalid metric %v: %v", line, err)
+		}
+		if m.Name == "" || strings.Contains(m.Name, "/") {
+			return nil, fmt.Errorf("invalid metric name in %v", line)
+		}
+		if m.Version < 0 {
+			return nil, fmt.Errorf("invalid version in %v", line)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, scanner.Err()
+}
+
+// updateRunMetrics records the metrics observed by a task in insights, under the given backend.
+// If insights are not yet initialized, the number of versions is determined from the metrics.
+func (exp *Experiment) updateRunMetrics(backend string, metrics []runMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	n := 0
+	for _, m := range metrics {
+		if m.Version+1 > n {
+			n = m.Version + 1
+		}
+	}
+	if exp.Result.Insights != nil && exp.Result.Insights.NumVersions >= n {
+		n = exp.Result.Insights.NumVersions
+	}
+	if err := exp.Result.initInsightsWithNumVersions(n); err != nil {
+		return err
+	}
+	for _, m := range metrics {
+		mm, val, err := m.metricMeta()
+		if err != nil {
+			log.Logger.Error(err)
+			return err
+		}
+		if err := exp.Result.Insights.updateMetric(backend+"/"+m.Name, mm, m.Version, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
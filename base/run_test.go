@@ -1,10 +1,22 @@
 package base
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
+	log "github.com/iter8-tools/iter8/base/log"
 	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/cli"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestRunRun(t *testing.T) {
@@ -24,3 +36,174 @@ func TestRunRun(t *testing.T) {
 	err := rt.run(exp)
 	assert.NoError(t, err)
 }
+
+func TestRunEnv(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "creds", "namespace": "default"},
+		"data":       map[string]interface{}{"token": base64.StdEncoding.EncodeToString([]byte("s3cret"))},
+	}}
+	_, err := kd.dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "secrets"}).Namespace("default").Create(context.Background(), secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config", "namespace": "test"},
+		"data":       map[string]interface{}{"url": "http://httpbin.test"},
+	}}
+	_, err = kd.dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace("test").Create(context.Background(), cm, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	os.Setenv("ITER8_TEST_LOCAL", "local")
+	defer os.Unsetenv("ITER8_TEST_LOCAL")
+
+	rt := &runTask{
+		TaskMeta: TaskMeta{
+			Run: StringPointer(`test "$TOKEN $URL $LOCAL $PLAIN" = "s3cret http://httpbin.test local plain"`),
+		},
+		With: &runInputs{
+			Env: []envVar{
+				{Name: "TOKEN", ValueFrom: &envVarSource{SecretKeyRef: &keyRef{Name: "creds", Key: "token"}}},
+				{Name: "URL", ValueFrom: &envVarSource{ConfigMapKeyRef: &keyRef{Name: "config", Key: "url", Namespace: StringPointer("test")}}},
+				{Name: "LOCAL", ValueFrom: &envVarSource{Env: StringPointer("ITER8_TEST_LOCAL")}},
+				{Name: "PLAIN", Value: StringPointer("plain")},
+			},
+		},
+	}
+	assert.NoError(t, rt.validateInputs())
+	assert.NoError(t, rt.run(&Experiment{Spec: []Task{rt}, Result: &ExperimentResult{}}))
+	// values of secrets are redacted from logs
+	assert.Equal(t, "token "+log.Redacted, log.Redact("token s3cret"))
+
+	// missing keys are errors
+	rt.With.Env[0].ValueFrom.SecretKeyRef.Key = "missing"
+	assert.Error(t, rt.run(&Experiment{Spec: []Task{rt}, Result: &ExperimentResult{}}))
+}
+
+func TestRunEnvValidateInputs(t *testing.T) {
+	rt := &runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello")}, With: &runInputs{}}
+	rt.With.Env = []envVar{{Name: "A"}}
+	assert.Error(t, rt.validateInputs())
+	rt.With.Env = []envVar{{Name: "A", Value: StringPointer("a"), ValueFrom: &envVarSource{Env: StringPointer("B")}}}
+	assert.Error(t, rt.validateInputs())
+	rt.With.Env = []envVar{{Name: "A", ValueFrom: &envVarSource{}}}
+	assert.Error(t, rt.validateInputs())
+	rt.With.Env = []envVar{{Name: "A", ValueFrom: &envVarSource{SecretKeyRef: &keyRef{Name: "creds"}}}}
+	assert.Error(t, rt.validateInputs())
+}
+
+func TestRunMetrics(t *testing.T) {
+	os.Chdir(t.TempDir())
+	script := `echo starting
+echo '{"name": "throughput", "value": 230.5}'
+echo '{"name": "throughput", "value": 180, "version": 1}'
+echo '{"name": "errors", "type": "counter", "value": 2, "version": 1}'
+echo '{"name": "latency", "type": "Sample", "value": [10, 20, 30]}'
+echo not a metric >&2`
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer(script)}, With: &runInputs{Metrics: true}},
+			&assessTask{
+				TaskMeta: TaskMeta{Task: StringPointer(AssessTaskName)},
+				With: assessInputs{SLOs: &SLOLimits{
+					Lower: []SLO{{Metric: "run/throughput", Limit: 200}},
+				}},
+			},
+		},
+	}
+	assert.NoError(t, RunExperiment(false, &mockDriver{exp}))
+	in := exp.Result.Insights
+	assert.Equal(t, 2, in.NumVersions)
+	assert.Equal(t, []float64{230.5}, in.NonHistMetricValues[0]["run/throughput"])
+	assert.Equal(t, []float64{180}, in.NonHistMetricValues[1]["run/throughput"])
+	assert.Equal(t, CounterMetricType, in.MetricsInfo["run/errors"].Type)
+	assert.Equal(t, []float64{10, 20, 30}, in.NonHistMetricValues[0]["run/latency"])
+	// only the first version satisfies SLOs
+	assert.True(t, exp.SLOsBy(0))
+	assert.False(t, exp.SLOsBy(1))
+}
+
+func TestParseRunMetrics(t *testing.T) {
+	_, err := parseRunMetrics([]byte(`{"name": "throughput", "value": `))
+	assert.Error(t, err)
+	_, err = parseRunMetrics([]byte(`{"name": "run/throughput", "value": 1}`))
+	assert.Error(t, err)
+
+	metrics, err := parseRunMetrics([]byte("hello\n{\"name\": \"throughput\", \"value\": 1}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(metrics))
+
+	// types and values must match
+	for _, m := range []runMetric{
+		{Name: "a", Type: "Histogram", Value: 1.0},
+		{Name: "a", Type: "Gauge", Value: []interface{}{1.0}},
+		{Name: "a", Type: "Sample", Value: []interface{}{"one"}},
+		{Name: "a", Value: "one"},
+	} {
+		_, _, err := m.metricMeta()
+		assert.Error(t, err)
+	}
+}
+
+func TestRunStarlark(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "iter8", r.Header.Get("X-Test"))
+		fmt.Fprint(w, `{"ready": true}`)
+	}))
+	defer srv.Close()
+
+	script := `
+resp = http_get(env("URL"), headers = {"X-Test": "iter8"})
+if resp.status != 200:
+    fail("unexpected status %d" % resp.status)
+output("body", resp.body)
+output("greeting", outputs["greeting"] + " " + env("NAME", "world"))
+output("missing", env("ITER8_TEST_MISSING"))
+metric("throughput", 230.5)
+metric("throughput", 180, version = 1)
+metric("latency", [10, 20, 30], type = "sample")
+output("throughput", metric_value("run/throughput", 1))
+`
+	rt := &runTask{
+		TaskMeta: TaskMeta{Run: StringPointer(script)},
+		With: &runInputs{
+			Language: starlarkLanguage,
+			Env:      []envVar{{Name: "URL", Value: StringPointer(srv.URL)}},
+		},
+	}
+	assert.NoError(t, rt.validateInputs())
+	exp := &Experiment{Spec: []Task{rt}, Result: &ExperimentResult{Outputs: map[string]string{"greeting": "hello"}}}
+	assert.NoError(t, rt.run(exp))
+	assert.Equal(t, map[string]string{
+		"body":       `{"ready": true}`,
+		"greeting":   "hello world",
+		"missing":    "None",
+		"throughput": "None",
+	}, exp.Result.Outputs)
+	in := exp.Result.Insights
+	assert.Equal(t, 2, in.NumVersions)
+	assert.Equal(t, []float64{230.5}, in.NonHistMetricValues[0]["run/throughput"])
+	assert.Equal(t, []float64{10, 20, 30}, in.NonHistMetricValues[0]["run/latency"])
+
+	// metrics are recorded when the script completes, and are available to later scripts
+	rt.TaskMeta.Run = StringPointer(`output("throughput", metric_value("run/throughput", 1))`)
+	assert.NoError(t, rt.run(exp))
+	assert.Equal(t, "180", exp.Result.Outputs["throughput"])
+
+	// script failures and invalid metrics are errors
+	for _, script := range []string{
+		`fail("oops")`,
+		`metric("run/throughput", 1)`,
+		`metric("throughput", "fast")`,
+		`metric("throughput", [1], type = "gauge")`,
+		`outputs["x"] = "y"`,
+	} {
+		rt.TaskMeta.Run = StringPointer(script)
+		assert.Error(t, rt.run(&Experiment{Spec: []Task{rt}, Result: &ExperimentResult{}}), script)
+	}
+
+	rt.With.Language = "python"
+	assert.Error(t, rt.validateInputs())
+}
@@ -0,0 +1,168 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// SeldonTaskName is the name of the task which shifts traffic between the predictors of a SeldonDeployment
+	SeldonTaskName = "seldon"
+)
+
+// seldonDeploymentGVR identifies SeldonDeployments
+var seldonDeploymentGVR = schema.GroupVersionResource{
+	Group:    "machinelearning.seldon.io",
+	Version:  "v1",
+	Resource: "seldondeployments",
+}
+
+// seldonPredictor is a predictor of a SeldonDeployment, which serves a version of the model
+type seldonPredictor struct {
+	// Name of the predictor
+	Name string `json:"name" yaml:"name"`
+	// Traffic is the percentage of traffic routed to the predictor. Optional.
+	// If unspecified for all predictors, the weights of versions recommended by the latest assessment are used,
+	// in the order of predictors; for example, the baseline and the candidate.
+	Traffic *int32 `json:"traffic,omitempty" yaml:"traffic,omitempty"`
+}
+
+// seldonInputs identifies the SeldonDeployment and the traffic of its predictors
+type seldonInputs struct {
+	// Namespace of the SeldonDeployment. Optional. If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// SeldonDeployment is the name of the SeldonDeployment
+	SeldonDeployment string `json:"seldonDeployment" yaml:"seldonDeployment"`
+	// Predictors are the predictors of the SeldonDeployment whose traffic is set; other predictors receive no traffic
+	Predictors []seldonPredictor `json:"predictors" yaml:"predictors"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the SeldonDeployment. Optional.
+	// If unspecified, the SeldonDeployment is looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the SeldonDeployment. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// seldonTask shifts traffic between versions of a model by updating the traffic of the predictors of a SeldonDeployment.
+// Along with the weights recommended by the assess task, or the if condition SLOs(),
+// this enables progressive rollouts of models within an experiment.
+type seldonTask struct {
+	TaskMeta
+	With seldonInputs `json:"with" yaml:"with"`
+}
+
+// driver returns the KubeDriver for the cluster containing the SeldonDeployment
+func (t *seldonTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the seldon task
+func (t *seldonTask) initializeDefaults() {
+	t.driver().initKube()
+	// set Namespace (from context) if not already set
+	if t.With.Namespace == nil {
+		t.With.Namespace = StringPointer(t.driver().Namespace())
+	}
+}
+
+// validateInputs validates task inputs
+func (t *seldonTask) validateInputs() error {
+	if t.With.SeldonDeployment == "" {
+		return errors.New("seldon task requires a seldonDeployment")
+	}
+	if len(t.With.Predictors) == 0 {
+		return errors.New("seldon task requires predictors")
+	}
+	weights := []int32{}
+	for _, p := range t.With.Predictors {
+		if p.Name == "" {
+			return errors.New("seldon task requires a name for each predictor")
+		}
+		if p.Traffic != nil {
+			weights = append(weights, *p.Traffic)
+		}
+	}
+	if len(weights) > 0 && len(weights) < len(t.With.Predictors) {
+		return errors.New("seldon task requires the traffic of all predictors, or of none")
+	}
+	return validateWeights(weights)
+}
+
+// traffic returns the traffic of each predictor, from the inputs or from the recommended weights of versions
+func (t *seldonTask) traffic(exp *Experiment) ([]int32, error) {
+	if t.With.Predictors[0].Traffic == nil {
+		return recommendedWeights(exp, len(t.With.Predictors))
+	}
+	traffic := []int32{}
+	for _, p := range t.With.Predictors {
+		traffic = append(traffic, *p.Traffic)
+	}
+	return traffic, nil
+}
+
+// run executes the task
+func (t *seldonTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+
+	traffic, err := t.traffic(exp)
+	if err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+
+	// update the traffic of predictors
+	err = updateTrafficObject(t.driver(), seldonDeploymentGVR, *t.With.Namespace, t.With.SeldonDeployment, func(obj *unstructured.Unstructured) error {
+		return t.updatePredictors(obj, traffic)
+	})
+	if err != nil {
+		return err
+	}
+	parts := []string{}
+	for i, p := range t.With.Predictors {
+		parts = append(parts, fmt.Sprintf("%v=%v%%", p.Name, traffic[i]))
+	}
+	log.Logger.Infof("updated seldondeployment %v/%v: %v", *t.With.Namespace, t.With.SeldonDeployment, strings.Join(parts, ", "))
+	return nil
+}
+
+// updatePredictors sets the traffic of the predictors of the SeldonDeployment
+func (t *seldonTask) updatePredictors(obj *unstructured.Unstructured, traffic []int32) error {
+	predictors, found, err := unstructured.NestedSlice(obj.Object, "spec", "predictors")
+	if err != nil {
+		return err
+	}
+	if !found || len(predictors) == 0 {
+		return errors.New("seldondeployment has no predictors")
+	}
+
+	byName := map[string]map[string]interface{}{}
+	for _, p := range predictors {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			return errors.New("invalid predictor of seldondeployment")
+		}
+		name, _, _ := unstructured.NestedString(pm, "name")
+		byName[name] = pm
+		pm["traffic"] = int64(0)
+	}
+	for i, p := range t.With.Predictors {
+		pm, ok := byName[p.Name]
+		if !ok {
+			return fmt.Errorf("seldondeployment has no predictor %v", p.Name)
+		}
+		pm["traffic"] = int64(traffic[i])
+	}
+	return unstructured.SetNestedSlice(obj.Object, predictors, "spec", "predictors")
+}
@@ -0,0 +1,73 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeldonShiftTraffic(t *testing.T) {
+	os.Chdir(t.TempDir())
+	createTrafficObject(t, seldonDeploymentGVR, map[string]interface{}{
+		"apiVersion": "machinelearning.seldon.io/v1",
+		"kind":       "SeldonDeployment",
+		"metadata":   map[string]interface{}{"name": "iris", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"predictors": []interface{}{
+				map[string]interface{}{"name": "baseline", "traffic": int64(90), "replicas": int64(1)},
+				map[string]interface{}{"name": "candidate", "traffic": int64(10), "replicas": int64(1)},
+				map[string]interface{}{"name": "shadow", "shadow": true},
+			},
+		},
+	})
+	traffic := func() []interface{} {
+		predictors := getTrafficObject(t, seldonDeploymentGVR, "iris")["spec"].(map[string]interface{})["predictors"].([]interface{})
+		ts := []interface{}{}
+		for _, p := range predictors {
+			ts = append(ts, p.(map[string]interface{})["traffic"])
+		}
+		return ts
+	}
+
+	st := &seldonTask{
+		TaskMeta: TaskMeta{Task: StringPointer(SeldonTaskName)},
+		With: seldonInputs{
+			SeldonDeployment: "iris",
+			Predictors: []seldonPredictor{
+				{Name: "baseline", Traffic: int32Pointer(50)},
+				{Name: "candidate", Traffic: int32Pointer(50)},
+			},
+		},
+	}
+	exp := &Experiment{Spec: []Task{st}, Result: &ExperimentResult{}}
+	assert.NoError(t, st.run(exp))
+	// predictors that are not listed receive no traffic
+	assert.Equal(t, []interface{}{int64(50), int64(50), int64(0)}, traffic())
+
+	// without traffic, the weights recommended by the assess task are used
+	st.With.Predictors = []seldonPredictor{{Name: "baseline"}, {Name: "candidate"}}
+	assert.Error(t, st.run(exp))
+	exp.initResults(1)
+	exp.Result.initInsightsWithNumVersions(2)
+	exp.Result.Insights.Weights = []int32{0, 100}
+	assert.NoError(t, st.run(exp))
+	assert.Equal(t, []interface{}{int64(0), int64(100), int64(0)}, traffic())
+
+	// predictors must exist
+	st.With.Predictors = []seldonPredictor{{Name: "baseline"}, {Name: "missing"}}
+	assert.Error(t, st.run(exp))
+}
+
+func TestSeldonInvalidInputs(t *testing.T) {
+	for _, in := range []seldonInputs{
+		{Predictors: []seldonPredictor{{Name: "baseline"}}},
+		{SeldonDeployment: "iris"},
+		{SeldonDeployment: "iris", Predictors: []seldonPredictor{{Traffic: int32Pointer(100)}}},
+		{SeldonDeployment: "iris", Predictors: []seldonPredictor{{Name: "a", Traffic: int32Pointer(100)}, {Name: "b"}}},
+		{SeldonDeployment: "iris", Predictors: []seldonPredictor{{Name: "a", Traffic: int32Pointer(60)}, {Name: "b", Traffic: int32Pointer(60)}}},
+	} {
+		st := &seldonTask{With: in}
+		assert.Error(t, st.validateInputs())
+	}
+}
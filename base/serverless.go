@@ -0,0 +1,302 @@
+package base
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/stats"
+	"github.com/iter8-tools/iter8/base/aws"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// defaultServerlessService is the default AWS service that authorizes requests to serverless functions
+	defaultServerlessService = "lambda"
+	// awsIAMAuthType is the auth type of functions whose requests are signed with AWS Signature Version 4
+	awsIAMAuthType = "AWS_IAM"
+	// noneAuthType is the auth type of functions whose requests are not signed
+	noneAuthType = "NONE"
+
+	// builtInHTTPColdStartCountId is the number of invocations of the version that were cold starts
+	builtInHTTPColdStartCountId = "cold-start-count"
+	// builtInHTTPColdStartErrorCountId is the number of cold starts of the version whose responses were errors
+	builtInHTTPColdStartErrorCountId = "cold-start-error-count"
+	// builtInHTTPColdStartLatencyMeanId is the mean latency of cold starts of the version
+	builtInHTTPColdStartLatencyMeanId = "cold-start-latency-mean"
+	// builtInHTTPColdStartLatencyMaxId is the maximum latency of cold starts of the version
+	builtInHTTPColdStartLatencyMaxId = "cold-start-latency-max"
+)
+
+// serverlessInputs configure load tests of serverless functions, such as AWS Lambda function URLs and Amazon API Gateway APIs.
+// Each request is signed with AWS Signature Version 4, and invocations that were cold starts are separated from warm invocations.
+type serverlessInputs struct {
+	// AuthType of the function; AWS_IAM if requests are signed, or NONE. Optional. Default is AWS_IAM.
+	AuthType string `json:"authType,omitempty" yaml:"authType,omitempty"`
+	// Service is the AWS service that authorizes requests; lambda for function URLs, or execute-api for API Gateway. Optional. Default is lambda.
+	Service string `json:"service,omitempty" yaml:"service,omitempty"`
+	// Region of the function. Optional. Default is the region in the AWS_REGION environment variable, or us-east-1.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	// ColdStartHeader is an HTTP response header set by the function when an invocation was a cold start;
+	// any value other than false marks a cold start. Optional.
+	// The first invocation of each connection is always a cold start, since the function starts an execution environment
+	// for each concurrent invocation when the load test begins.
+	ColdStartHeader string `json:"coldStartHeader,omitempty" yaml:"coldStartHeader,omitempty"`
+}
+
+// validate the serverless inputs
+func (s *serverlessInputs) validate() error {
+	if s.AuthType != "" && s.AuthType != awsIAMAuthType && s.AuthType != noneAuthType {
+		return fmt.Errorf("invalid auth type %v; must be %v or %v", s.AuthType, awsIAMAuthType, noneAuthType)
+	}
+	return nil
+}
+
+// initializeDefaults sets default values for the serverless inputs
+func (s *serverlessInputs) initializeDefaults() {
+	if s.AuthType == "" {
+		s.AuthType = awsIAMAuthType
+	}
+	if s.Service == "" {
+		s.Service = defaultServerlessService
+	}
+	if s.Region == "" {
+		s.Region = aws.Region()
+	}
+}
+
+// coldStart returns true if the response marks the invocation as a cold start
+func (s *serverlessInputs) coldStart(resp *http.Response) bool {
+	if s.ColdStartHeader == "" || resp == nil {
+		return false
+	}
+	v := strings.TrimSpace(resp.Header.Get(s.ColdStartHeader))
+	return v != "" && !strings.EqualFold(v, "false")
+}
+
+// serverlessSigner signs requests with credentials that are shared by workers, and refreshed when they expire
+type serverlessSigner struct {
+	sync.Mutex
+	in     *serverlessInputs
+	client *http.Client
+	creds  *aws.Credentials
+}
+
+// credentials returns the credentials used to sign requests, which are refreshed if they have expired
+func (s *serverlessSigner) credentials() (*aws.Credentials, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.creds == nil || s.creds.Expired() {
+		creds, err := aws.GetCredentials(s.client, s.in.Region)
+		if err != nil {
+			return nil, err
+		}
+		s.creds = creds
+	}
+	return s.creds, nil
+}
+
+// sign signs the request with AWS Signature Version 4
+func (s *serverlessSigner) sign(req *http.Request, payload []byte) error {
+	creds, err := s.credentials()
+	if err != nil {
+		return err
+	}
+	aws.SignV4(req, payload, creds, s.in.Region, s.in.Service, time.Now())
+	return nil
+}
+
+// serverlessWorker invokes the function, and accumulates the metrics of warm invocations and cold starts without synchronization
+type serverlessWorker struct {
+	// warm and cold are the histograms of latencies of warm invocations and cold starts, in seconds
+	warm, cold *stats.Histogram
+	// warmErrs and coldErrs are the numbers of warm invocations and cold starts whose responses were errors
+	warmErrs, coldErrs float64
+	// invoked is true after the first invocation of the worker
+	invoked bool
+}
+
+// newServerlessWorker returns a worker with empty metrics
+func newServerlessWorker() *serverlessWorker {
+	return &serverlessWorker{
+		warm: stats.NewHistogram(0, 0.001),
+		cold: stats.NewHistogram(0, 0.001),
+	}
+}
+
+// send invokes the function at the URL of the task, and records the metrics of the response
+func (w *serverlessWorker) send(t *collectHTTPTask, client *http.Client, signer *serverlessSigner, payload []byte) {
+	method := http.MethodGet
+	if payload != nil {
+		method = http.MethodPost
+	}
+	status := 0
+	cold := !w.invoked
+	w.invoked = true
+	start := time.Now()
+	req, err := http.NewRequest(method, t.With.URL, bytes.NewReader(payload))
+	if err == nil {
+		for k, val := range t.With.Headers {
+			req.Header.Set(k, val)
+		}
+		if t.With.ContentType != nil {
+			req.Header.Set("Content-Type", *t.With.ContentType)
+		}
+		if signer != nil {
+			err = signer.sign(req, payload)
+		}
+	}
+	if err == nil {
+		var resp *http.Response
+		if resp, err = client.Do(req); err == nil {
+			_, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil {
+				status = resp.StatusCode
+			}
+			cold = cold || t.With.Serverless.coldStart(resp)
+		}
+	}
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Debugf("invocation of %v failed", t.With.URL)
+	}
+	latency := time.Since(start).Seconds()
+	errored := status == 0 || t.errorCode(status)
+	if cold {
+		w.cold.Record(latency)
+		if errored {
+			w.coldErrs++
+		}
+		return
+	}
+	w.warm.Record(latency)
+	if errored {
+		w.warmErrs++
+	}
+}
+
+// runServerless invokes the function at the URL, or at the URL of each version one after another, and updates the built-in
+// metrics of each version from its warm invocations, along with the number, errors, and latency of its cold starts
+func (t *collectHTTPTask) runServerless(exp *Experiment) error {
+	s := t.With.Serverless
+	s.initializeDefaults()
+	payload, err := t.payload()
+	if err != nil {
+		e := errors.New("unable to read payload")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	versions := t.With.Versions
+	if len(versions) == 0 {
+		versions = []httpVersion{{URL: t.With.URL}}
+	}
+	err = exp.Result.initInsightsWithNumVersions(len(versions))
+	if err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+
+	client := newMirrorClient(*t.With.Connections)
+	defer client.CloseIdleConnections()
+	var signer *serverlessSigner
+	if s.AuthType == awsIAMAuthType {
+		signer = &serverlessSigner{in: s, client: client}
+		if _, err := signer.credentials(); err != nil {
+			e := errors.New("unable to get AWS credentials")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+	}
+
+	for i, v := range versions {
+		if exp.stopped() {
+			break
+		}
+		log.Logger.Infof("invoking version %v: %v", i, v.URL)
+		vt := t.forVersion(v)
+		workers := vt.invokeServerless(exp, client, signer, payload)
+
+		warm, cold := stats.NewHistogram(0, 0.001), stats.NewHistogram(0, 0.001)
+		warmErrs, coldErrs := float64(0), float64(0)
+		for _, w := range workers {
+			warm.Transfer(w.warm)
+			cold.Transfer(w.cold)
+			warmErrs += w.warmErrs
+			coldErrs += w.coldErrs
+		}
+		vt.updateMetrics(in, i, warmErrs, warm.Export().CalcPercentiles(t.With.Percentiles))
+		vt.updateColdStartMetrics(in, i, coldErrs, cold.Export())
+		log.Logger.Infof("version %v had %v cold starts in %v invocations", i, cold.Count, cold.Count+warm.Count)
+	}
+	return nil
+}
+
+// invokeServerless invokes the function at the URL of the task at the given rate, by a fixed pool of workers, one for each connection,
+// until the number of requests are sent, the duration ends, or the experiment is interrupted; the workers are returned with their metrics
+func (t *collectHTTPTask) invokeServerless(exp *Experiment, client *http.Client, signer *serverlessSigner, payload []byte) []*serverlessWorker {
+	var deadline time.Time
+	if t.With.Duration != nil {
+		d, _ := time.ParseDuration(*t.With.Duration)
+		deadline = time.Now().Add(d)
+	}
+	workers := make([]*serverlessWorker, *t.With.Connections)
+	work := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range workers {
+		w := newServerlessWorker()
+		workers[i] = w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range work {
+				w.send(t, client, signer, payload)
+			}
+		}()
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / float64(*t.With.QPS)))
+	for n := int64(0); t.With.NumRequests == nil || n < *t.With.NumRequests; n++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		if exp.stopped() {
+			log.Logger.Warn("stopped invoking function")
+			break
+		}
+		work <- struct{}{}
+		<-ticker.C
+	}
+	ticker.Stop()
+	close(work)
+	wg.Wait()
+	return workers
+}
+
+// updateColdStartMetrics updates the metrics of cold starts of the version from the number of errors and the latency histogram
+func (t *collectHTTPTask) updateColdStartMetrics(in *Insights, i int, numErrors float64, hd *stats.HistogramData) {
+	in.updateMetric(httpMetricPrefix+"/"+builtInHTTPColdStartCountId, MetricMeta{
+		Description: "number of invocations that were cold starts",
+		Type:        CounterMetricType,
+	}, i, float64(hd.Count))
+	in.updateMetric(httpMetricPrefix+"/"+builtInHTTPColdStartErrorCountId, MetricMeta{
+		Description: "number of cold starts whose responses were errors",
+		Type:        CounterMetricType,
+	}, i, numErrors)
+	if hd.Count == 0 {
+		return
+	}
+	in.updateMetric(httpMetricPrefix+"/"+builtInHTTPColdStartLatencyMeanId, MetricMeta{
+		Description: "mean of observed latency values of cold starts",
+		Type:        GaugeMetricType,
+		Units:       StringPointer("msec"),
+	}, i, 1000.0*hd.Avg)
+	in.updateMetric(httpMetricPrefix+"/"+builtInHTTPColdStartLatencyMaxId, MetricMeta{
+		Description: "maximum of observed latency values of cold starts",
+		Type:        GaugeMetricType,
+		Units:       StringPointer("msec"),
+	}, i, 1000.0*hd.Max)
+}
@@ -0,0 +1,46 @@
+package base
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+const (
+	// bashShell runs scripts with bash
+	bashShell = "bash"
+	// shShell runs scripts with the POSIX shell
+	shShell = "sh"
+	// powershellShell runs scripts with Windows PowerShell
+	powershellShell = "powershell"
+	// pwshShell runs scripts with PowerShell 7 or later
+	pwshShell = "pwsh"
+	// cmdShell runs scripts with the Windows command interpreter
+	cmdShell = "cmd"
+)
+
+// validateShell checks that the shell is supported; an empty shell refers to the default shell of the platform
+func validateShell(shell string) error {
+	switch shell {
+	case "", bashShell, shShell, powershellShell, pwshShell, cmdShell:
+		return nil
+	default:
+		return fmt.Errorf("unsupported shell %v; must be %v, %v, %v, %v, or %v", shell, bashShell, shShell, powershellShell, pwshShell, cmdShell)
+	}
+}
+
+// shellCommand returns the command that runs the script with the shell.
+// If the shell is empty, the default shell of the platform is used; bash, or powershell on Windows.
+// Shells are found in PATH.
+func shellCommand(shell string, script string) *exec.Cmd {
+	if shell == "" {
+		shell = defaultShell
+	}
+	switch shell {
+	case powershellShell, pwshShell:
+		return exec.Command(shell, "-NoProfile", "-NonInteractive", "-Command", script)
+	case cmdShell:
+		return exec.Command(shell, "/C", script)
+	default:
+		return exec.Command(shell, "-c", script)
+	}
+}
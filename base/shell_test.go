@@ -0,0 +1,59 @@
+package base
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellCommand(t *testing.T) {
+	cmd := shellCommand(bashShell, "echo hello")
+	assert.Equal(t, []string{"bash", "-c", "echo hello"}, cmd.Args)
+	cmd = shellCommand(shShell, "echo hello")
+	assert.Equal(t, []string{"sh", "-c", "echo hello"}, cmd.Args)
+	cmd = shellCommand(pwshShell, "Write-Output hello")
+	assert.Equal(t, []string{"pwsh", "-NoProfile", "-NonInteractive", "-Command", "Write-Output hello"}, cmd.Args)
+	cmd = shellCommand(cmdShell, "echo hello")
+	assert.Equal(t, []string{"cmd", "/C", "echo hello"}, cmd.Args)
+
+	// default shell of the platform
+	cmd = shellCommand("", "echo hello")
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, powershellShell, cmd.Args[0])
+	} else {
+		assert.Equal(t, bashShell, cmd.Args[0])
+	}
+
+	assert.NoError(t, validateShell(""))
+	assert.NoError(t, validateShell(powershellShell))
+	assert.Error(t, validateShell("zsh"))
+}
+
+func TestRunShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	os.Chdir(t.TempDir())
+	rt := &runTask{
+		TaskMeta: TaskMeta{
+			Run: StringPointer(`echo "greeting=hello" >> "$` + outputEnv + `"`),
+		},
+		With: &runInputs{Shell: shShell},
+	}
+	exp := &Experiment{
+		Spec:   []Task{rt},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	assert.NoError(t, rt.run(exp))
+	assert.Equal(t, "hello", exp.Result.Outputs["greeting"])
+
+	// shells are not used by starlark scripts
+	rt.With = &runInputs{Language: starlarkLanguage, Shell: shShell}
+	assert.Error(t, rt.run(exp))
+
+	rt.With = &runInputs{Shell: "zsh"}
+	assert.Error(t, rt.run(exp))
+}
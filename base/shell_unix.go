@@ -0,0 +1,7 @@
+//go:build !windows
+// +build !windows
+
+package base
+
+// defaultShell is the shell that runs scripts of run and background tasks, unless another shell is specified
+const defaultShell = bashShell
@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package base
+
+// defaultShell is the shell that runs scripts of run and background tasks, unless another shell is specified;
+// PowerShell is available on all supported versions of Windows, unlike bash
+const defaultShell = powershellShell
@@ -0,0 +1,432 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"github.com/montanaflynn/stats"
+)
+
+const (
+	// NormalDistribution generates latencies from a normal distribution with the given mean and standard deviation
+	NormalDistribution = "normal"
+	// UniformDistribution generates latencies uniformly between the given min and max
+	UniformDistribution = "uniform"
+	// ExponentialDistribution generates latencies from an exponential distribution with the given mean
+	ExponentialDistribution = "exponential"
+
+	// numSimulatedHistBuckets is the number of buckets in simulated latency histograms
+	numSimulatedHistBuckets = 10
+)
+
+// LatencyDistribution describes the distribution of synthetic latencies in msec
+type LatencyDistribution struct {
+	// Distribution is one of normal, uniform, or exponential. Default value is normal.
+	Distribution string `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+	// Mean latency; used by normal and exponential distributions
+	Mean float64 `json:"mean,omitempty" yaml:"mean,omitempty"`
+	// StdDev is the standard deviation of latencies; used by the normal distribution
+	StdDev float64 `json:"stdDev,omitempty" yaml:"stdDev,omitempty"`
+	// Min latency; used by the uniform distribution
+	Min float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	// Max latency; used by the uniform distribution
+	Max float64 `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// SimulatedLoad describes the synthetic results of a load test
+type SimulatedLoad struct {
+	// NumRequests is the number of simulated requests. If unspecified, it is derived from the task inputs.
+	NumRequests *int64 `json:"numRequests,omitempty" yaml:"numRequests,omitempty"`
+	// Latency is the distribution of latencies
+	Latency LatencyDistribution `json:"latency,omitempty" yaml:"latency,omitempty"`
+	// ErrorRate is the probability that a request results in an error
+	ErrorRate float64 `json:"errorRate,omitempty" yaml:"errorRate,omitempty"`
+}
+
+// Simulation describes the synthetic metrics used in place of real metrics collection
+type Simulation struct {
+	// Seed for the random number generator; simulations with the same seed produce the same metrics
+	Seed int64 `json:"seed,omitempty" yaml:"seed,omitempty"`
+	// HTTP is the simulated load for http tasks
+	HTTP *SimulatedLoad `json:"http,omitempty" yaml:"http,omitempty"`
+	// GRPC is the simulated load for grpc tasks
+	GRPC *SimulatedLoad `json:"grpc,omitempty" yaml:"grpc,omitempty"`
+	// CustomMetrics are the values of custom metrics for each version, keyed by metric name (example, istio/error-rate)
+	CustomMetrics map[string][]float64 `json:"customMetrics,omitempty" yaml:"customMetrics,omitempty"`
+}
+
+// DefaultSimulatedLoad is the simulated load used when a simulation does not specify one
+func DefaultSimulatedLoad() *SimulatedLoad {
+	return &SimulatedLoad{
+		Latency: LatencyDistribution{
+			Distribution: NormalDistribution,
+			Mean:         50,
+			StdDev:       10,
+		},
+	}
+}
+
+// validate the latency distribution
+func (d *LatencyDistribution) validate() error {
+	switch d.Distribution {
+	case NormalDistribution, "":
+		if d.StdDev < 0 {
+			return errors.New("standard deviation of latencies cannot be negative")
+		}
+	case UniformDistribution:
+		if d.Min < 0 || d.Max < d.Min {
+			return errors.New("uniform latencies require 0 <= min <= max")
+		}
+	case ExponentialDistribution:
+		if d.Mean <= 0 {
+			return errors.New("exponential latencies require a positive mean")
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %v; must be one of %v, %v, or %v", d.Distribution, NormalDistribution, UniformDistribution, ExponentialDistribution)
+	}
+	return nil
+}
+
+// sample returns a synthetic latency; latencies are never negative
+func (d *LatencyDistribution) sample(rng *rand.Rand) float64 {
+	var v float64
+	switch d.Distribution {
+	case UniformDistribution:
+		v = d.Min + rng.Float64()*(d.Max-d.Min)
+	case ExponentialDistribution:
+		v = rng.ExpFloat64() * d.Mean
+	default:
+		v = d.Mean + rng.NormFloat64()*d.StdDev
+	}
+	return math.Max(v, 0)
+}
+
+// validate the simulated load
+func (l *SimulatedLoad) validate() error {
+	if l.NumRequests != nil && *l.NumRequests <= 0 {
+		return errors.New("number of simulated requests must be positive")
+	}
+	if l.ErrorRate < 0 || l.ErrorRate > 1 {
+		return errors.New("error rate must be between 0 and 1")
+	}
+	return l.Latency.validate()
+}
+
+// validate the simulation
+func (s *Simulation) validate() error {
+	for name, l := range map[string]*SimulatedLoad{CollectHTTPTaskName: s.HTTP, CollectGRPCTaskName: s.GRPC} {
+		if l == nil {
+			continue
+		}
+		if err := l.validate(); err != nil {
+			return fmt.Errorf("invalid simulated %v load: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// simulatedTask runs in place of a task that interacts with apps or external services during simulation
+type simulatedTask struct {
+	// TaskMeta has fields common to all tasks; it is copied from the simulated task
+	TaskMeta
+	// task is the task being simulated
+	task Task
+	// sim describes the synthetic metrics
+	sim *Simulation
+	// rng generates synthetic metrics
+	rng *rand.Rand
+}
+
+// initializeDefaults sets default values for the simulated task
+func (t *simulatedTask) initializeDefaults() {
+	t.task.initializeDefaults()
+}
+
+// validateInputs of the simulated task
+func (t *simulatedTask) validateInputs() error {
+	return t.task.validateInputs()
+}
+
+// run generates synthetic metrics in place of the simulated task
+func (t *simulatedTask) run(exp *Experiment) error {
+	if err := t.validateInputs(); err != nil {
+		return err
+	}
+	t.initializeDefaults()
+
+	switch tsk := t.task.(type) {
+	case *collectHTTPTask:
+		l := t.sim.HTTP
+		if l == nil {
+			l = DefaultSimulatedLoad()
+		}
+		return t.simulateHTTP(exp, tsk, l)
+	case *collectGRPCTask:
+		l := t.sim.GRPC
+		if l == nil {
+			l = DefaultSimulatedLoad()
+		}
+		return t.simulateGRPC(exp, tsk, l)
+	case *customMetricsTask:
+		return t.simulateCustomMetrics(exp, tsk)
+	default:
+		log.Logger.Infof("skipping %v task in simulation", *getName(t.task))
+		return nil
+	}
+}
+
+// numRequests returns the number of simulated requests
+func numRequests(l *SimulatedLoad, taskRequests *int64, duration *string, qps float32) int64 {
+	if l.NumRequests != nil {
+		return *l.NumRequests
+	}
+	if taskRequests != nil {
+		return *taskRequests
+	}
+	if duration != nil {
+		if d, err := time.ParseDuration(*duration); err == nil && qps > 0 {
+			if n := int64(d.Seconds() * float64(qps)); n > 0 {
+				return n
+			}
+		}
+	}
+	return defaultHTTPNumRequests
+}
+
+// requests returns synthetic latencies and the number of errors for the given number of requests
+func (t *simulatedTask) requests(l *SimulatedLoad, n int64) ([]float64, float64) {
+	latencies := make([]float64, n)
+	errs := float64(0)
+	for i := range latencies {
+		latencies[i] = l.Latency.sample(t.rng)
+		if t.rng.Float64() < l.ErrorRate {
+			errs++
+		}
+	}
+	return latencies, errs
+}
+
+// simulatedHist returns a histogram of latencies with equal width buckets
+func simulatedHist(latencies []float64) []HistBucket {
+	min, _ := stats.Min(latencies)
+	max, _ := stats.Max(latencies)
+	if max == min {
+		return []HistBucket{{Lower: min, Upper: max, Count: uint64(len(latencies))}}
+	}
+	width := (max - min) / numSimulatedHistBuckets
+	buckets := make([]HistBucket, numSimulatedHistBuckets)
+	for i := range buckets {
+		buckets[i].Lower = min + float64(i)*width
+		buckets[i].Upper = min + float64(i+1)*width
+	}
+	for _, v := range latencies {
+		i := int((v - min) / width)
+		if i >= numSimulatedHistBuckets {
+			i = numSimulatedHistBuckets - 1
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// simulateHTTP populates the metrics of the http task, for each of its versions, using synthetic requests
+func (t *simulatedTask) simulateHTTP(exp *Experiment, tsk *collectHTTPTask, l *SimulatedLoad) error {
+	n := numRequests(l, tsk.With.NumRequests, tsk.With.Duration, *tsk.With.QPS)
+
+	numVersions := len(tsk.With.Versions)
+	if numVersions == 0 {
+		numVersions = 1
+	}
+	err := exp.Result.initInsightsWithNumVersions(numVersions)
+	if err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+
+	for i := 0; i < numVersions; i++ {
+		latencies, errs := t.requests(l, n)
+
+		in.updateMetric(httpMetricPrefix+"/"+builtInHTTPRequestCountId, MetricMeta{
+			Description: "number of requests sent",
+			Type:        CounterMetricType,
+		}, i, float64(n))
+		in.updateMetric(httpMetricPrefix+"/"+builtInHTTPErrorCountId, MetricMeta{
+			Description: "number of responses that were errors",
+			Type:        CounterMetricType,
+		}, i, errs)
+		in.updateMetric(httpMetricPrefix+"/"+builtInHTTPErrorRateId, MetricMeta{
+			Description: "fraction of responses that were errors",
+			Type:        GaugeMetricType,
+		}, i, errs/float64(n))
+
+		mean, _ := stats.Mean(latencies)
+		stdDev, _ := stats.StandardDeviation(latencies)
+		min, _ := stats.Min(latencies)
+		max, _ := stats.Max(latencies)
+		for _, s := range []struct {
+			id          string
+			description string
+			value       float64
+		}{
+			{builtInHTTPLatencyMeanId, "mean of observed latency values", mean},
+			{builtInHTTPLatencyStdDevId, "standard deviation of observed latency values", stdDev},
+			{builtInHTTPLatencyMinId, "minimum of observed latency values", min},
+			{builtInHTTPLatencyMaxId, "maximum of observed latency values", max},
+		} {
+			in.updateMetric(httpMetricPrefix+"/"+s.id, MetricMeta{
+				Description: s.description,
+				Type:        GaugeMetricType,
+				Units:       StringPointer("msec"),
+			}, i, s.value)
+		}
+
+		for _, p := range tsk.With.Percentiles {
+			val, err := stats.Percentile(latencies, p)
+			if err != nil {
+				log.Logger.WithStackTrace(err.Error()).Errorf("unable to compute %v-th percentile of simulated latencies", p)
+				return err
+			}
+			in.updateMetric(fmt.Sprintf("%v/%v%v", httpMetricPrefix, builtInHTTPLatencyPercentilePrefix, p), MetricMeta{
+				Description: fmt.Sprintf("%v-th percentile of observed latency values", p),
+				Type:        GaugeMetricType,
+				Units:       StringPointer("msec"),
+			}, i, val)
+		}
+
+		in.updateMetric(httpMetricPrefix+"/"+builtInHTTPLatencyHistId, MetricMeta{
+			Description: "Latency Histogram",
+			Type:        HistogramMetricType,
+			Units:       StringPointer("msec"),
+		}, i, simulatedHist(latencies))
+	}
+	return nil
+}
+
+// simulateGRPC populates the metrics of the grpc task, for each of its versions, using synthetic requests
+func (t *simulatedTask) simulateGRPC(exp *Experiment, tsk *collectGRPCTask, l *SimulatedLoad) error {
+	var taskRequests *int64
+	if tsk.With.N > 0 {
+		taskRequests = int64Pointer(int64(tsk.With.N))
+	}
+	n := numRequests(l, taskRequests, nil, 0)
+
+	numVersions := len(tsk.With.Versions)
+	if numVersions == 0 {
+		numVersions = 1
+	}
+	err := exp.Result.initInsightsWithNumVersions(numVersions)
+	if err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+
+	for i := 0; i < numVersions; i++ {
+		latencies, errs := t.requests(l, n)
+
+		in.updateMetric(gRPCMetricPrefix+"/"+gRPCRequestCountMetricName, MetricMeta{
+			Description: "number of gRPC requests sent",
+			Type:        CounterMetricType,
+		}, i, float64(n))
+		in.updateMetric(gRPCMetricPrefix+"/"+gRPCErrorCountMetricName, MetricMeta{
+			Description: "number of responses that were errors",
+			Type:        CounterMetricType,
+		}, i, errs)
+		in.updateMetric(gRPCMetricPrefix+"/"+gRPCErrorRateMetricName, MetricMeta{
+			Description: "fraction of responses that were errors",
+			Type:        GaugeMetricType,
+		}, i, errs/float64(n))
+		in.updateMetric(gRPCMetricPrefix+"/"+gRPCLatencySampleMetricName, MetricMeta{
+			Description: "gRPC Latency Sample",
+			Type:        SampleMetricType,
+			Units:       StringPointer("msec"),
+		}, i, latencies)
+	}
+	return nil
+}
+
+// simulateCustomMetrics populates custom metrics using the values in the simulation
+func (t *simulatedTask) simulateCustomMetrics(exp *Experiment, tsk *customMetricsTask) error {
+	err := exp.Result.initInsightsWithNumVersions(len(tsk.With.VersionInfo))
+	if err != nil {
+		return err
+	}
+	for m, values := range t.sim.CustomMetrics {
+		for i, v := range values {
+			if i >= len(tsk.With.VersionInfo) {
+				log.Logger.Warnf("ignoring simulated values of %v for versions beyond %v", m, len(tsk.With.VersionInfo))
+				break
+			}
+			err = exp.Result.Insights.updateMetric(m, MetricMeta{
+				Description: "simulated custom metric",
+				Type:        GaugeMetricType,
+			}, i, v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// simulateSpec replaces tasks that interact with apps or external services with simulated tasks
+func simulateSpec(spec ExperimentSpec, sim *Simulation) ExperimentSpec {
+	rng := rand.New(rand.NewSource(sim.Seed))
+	simulated := ExperimentSpec{}
+	for _, t := range spec {
+		switch t.(type) {
+		case *assessTask:
+			simulated = append(simulated, t)
+		default:
+			simulated = append(simulated, &simulatedTask{
+				TaskMeta: TaskMeta{
+					Task: getName(t),
+					If:   getIf(t),
+				},
+				task: t,
+				sim:  sim,
+				rng:  rng,
+			})
+		}
+	}
+	return simulated
+}
+
+// SimulateExperiment runs an experiment using synthetic metrics.
+// Load generation and metrics collection tasks are replaced by tasks that generate metrics using the simulation;
+// tasks that interact with apps or external services, such as readiness checks, notifications, and run commands, are skipped.
+// Assessments and conditions on tasks are evaluated as usual.
+func SimulateExperiment(sim *Simulation, driver Driver) error {
+	if err := sim.validate(); err != nil {
+		e := errors.New("invalid simulation")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	exp, err := BuildExperiment(driver)
+	if err != nil {
+		return err
+	}
+	exp.initResults(driver.GetRevision())
+	spec := exp.Spec
+	exp.Spec = simulateSpec(spec, sim)
+	err = exp.run(&simulationDriver{Driver: driver, spec: spec}, 0)
+	exp.Spec = spec
+	return err
+}
+
+// simulationDriver writes experiments with their original spec, so that simulated tasks are not persisted
+type simulationDriver struct {
+	Driver
+	// spec is the original experiment spec
+	spec ExperimentSpec
+}
+
+// Write the experiment with its original spec
+func (d *simulationDriver) Write(exp *Experiment) error {
+	e := *exp
+	e.Spec = d.spec
+	return d.Driver.Write(&e)
+}
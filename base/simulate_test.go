@@ -0,0 +1,121 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+// readTestExperiment reads an experiment from testdata
+func readTestExperiment(t *testing.T, name string) *Experiment {
+	b, err := ioutil.ReadFile(CompletePath("../testdata", name))
+	assert.NoError(t, err)
+	e := &Experiment{}
+	err = yaml.Unmarshal(b, e)
+	assert.NoError(t, err)
+	return e
+}
+
+func TestSimulateExperiment(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// no mock endpoint; simulation must not send requests
+	md := &mockDriver{readTestExperiment(t, "experiment.yaml")}
+	err := SimulateExperiment(&Simulation{
+		HTTP: &SimulatedLoad{
+			NumRequests: int64Pointer(50),
+			Latency: LatencyDistribution{
+				Distribution: UniformDistribution,
+				Min:          10,
+				Max:          20,
+			},
+		},
+	}, md)
+	assert.NoError(t, err)
+
+	exp := md.Experiment
+	assert.True(t, exp.Completed())
+	assert.True(t, exp.NoFailure())
+	assert.True(t, exp.SLOs())
+	// original spec is preserved
+	_, ok := exp.Spec[0].(*collectHTTPTask)
+	assert.True(t, ok)
+
+	in := exp.Result.Insights
+	assert.Equal(t, 50.0, *in.ScalarMetricValue(0, "http/request-count"))
+	assert.Equal(t, 0.0, *in.ScalarMetricValue(0, "http/error-rate"))
+	mean := *in.ScalarMetricValue(0, "http/latency-mean")
+	assert.True(t, mean >= 10 && mean <= 20)
+	assert.NotNil(t, in.ScalarMetricValue(0, "http/latency-p95"))
+	assert.Len(t, in.HistMetricValues[0]["http/latency"], numSimulatedHistBuckets)
+}
+
+func TestSimulateExperimentSLOViolations(t *testing.T) {
+	os.Chdir(t.TempDir())
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- task: grpc
+  with:
+    host: hello.default:50051
+    call: helloworld.Greeter.SayHello
+    total: 200
+- task: assess
+  with:
+    SLOs:
+      upper:
+      - metric: grpc/error-rate
+        limit: 0
+      - metric: grpc/latency/mean
+        limit: 100
+`), e)
+	assert.NoError(t, err)
+	md := &mockDriver{e}
+	err = SimulateExperiment(&Simulation{
+		Seed: 3,
+		GRPC: &SimulatedLoad{
+			ErrorRate: 0.5,
+			Latency: LatencyDistribution{
+				Distribution: ExponentialDistribution,
+				Mean:         300,
+			},
+		},
+	}, md)
+	assert.NoError(t, err)
+
+	exp := md.Experiment
+	assert.True(t, exp.Completed())
+	assert.False(t, exp.SLOs())
+	assert.Equal(t, 200.0, *exp.Result.Insights.ScalarMetricValue(0, "grpc/request-count"))
+	assert.True(t, *exp.Result.Insights.ScalarMetricValue(0, "grpc/error-rate") > 0)
+}
+
+func TestSimulateCustomMetrics(t *testing.T) {
+	os.Chdir(t.TempDir())
+	md := &mockDriver{readTestExperiment(t, "experiment_db.yaml")}
+	err := SimulateExperiment(&Simulation{
+		CustomMetrics: map[string][]float64{
+			"kfserving/request-count": {0, 5},
+		},
+	}, md)
+	assert.NoError(t, err)
+
+	exp := md.Experiment
+	assert.True(t, exp.Completed())
+	assert.True(t, exp.SLOs())
+	assert.Equal(t, 0.0, *exp.Result.Insights.ScalarMetricValue(0, "kfserving/request-count"))
+}
+
+func TestSimulateInvalidSimulation(t *testing.T) {
+	md := &mockDriver{readTestExperiment(t, "experiment.yaml")}
+	for _, sim := range []*Simulation{
+		{HTTP: &SimulatedLoad{ErrorRate: 2}},
+		{HTTP: &SimulatedLoad{Latency: LatencyDistribution{Distribution: "pareto"}}},
+		{GRPC: &SimulatedLoad{Latency: LatencyDistribution{Distribution: UniformDistribution, Min: 5, Max: 1}}},
+	} {
+		err := SimulateExperiment(sim, md)
+		assert.Error(t, err)
+	}
+}
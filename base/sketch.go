@@ -0,0 +1,315 @@
+package base
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// defaultSketchAccuracy is the default relative accuracy of percentiles of sample metrics aggregated in sketches
+const defaultSketchAccuracy = 0.01
+
+// MetricsRecording configures how an experiment records metrics
+type MetricsRecording struct {
+	// Streaming aggregates the observations of sample metrics, such as gRPC latencies, incrementally in sketches, instead of retaining all of them.
+	// Memory and result size no longer grow with the number of observations, which suits long running (soak) experiments.
+	// The count, mean, standard deviation, min and max of samples are exact, and their percentiles are within the accuracy of the sketch.
+	// Optional; by default, all observations are retained.
+	Streaming bool `json:"streaming,omitempty" yaml:"streaming,omitempty"`
+	// Accuracy is the relative accuracy of percentiles of sample metrics aggregated in sketches. Optional. Default value is 0.01 (1%).
+	Accuracy *float64 `json:"accuracy,omitempty" yaml:"accuracy,omitempty"`
+}
+
+// validate the metrics recording configuration
+func (s *MetricsRecording) validate() error {
+	if s.Accuracy != nil && (*s.Accuracy <= 0 || *s.Accuracy >= 1) {
+		return fmt.Errorf("invalid metrics accuracy %v", *s.Accuracy)
+	}
+	return nil
+}
+
+// sketchAccuracy returns the relative accuracy of sketches of sample metrics; zero if observations are retained
+func (s *MetricsRecording) sketchAccuracy() float64 {
+	if s == nil || !s.Streaming {
+		return 0
+	}
+	if s.Accuracy != nil {
+		return *s.Accuracy
+	}
+	return defaultSketchAccuracy
+}
+
+// SampleSketch aggregates the observations of a sample metric. Observations are counted in buckets whose bounds grow geometrically,
+// so that any percentile is estimated within the relative accuracy of the sketch, using a number of buckets that is logarithmic
+// in the range of observations (DDSketch).
+type SampleSketch struct {
+	// Accuracy is the relative accuracy of percentiles estimated from the sketch
+	Accuracy float64 `json:"accuracy" yaml:"accuracy"`
+	// Count is the number of observations
+	Count int64 `json:"count" yaml:"count"`
+	// Sum of observations
+	Sum float64 `json:"sum" yaml:"sum"`
+	// SumSquares is the sum of squares of observations
+	SumSquares float64 `json:"sumSquares" yaml:"sumSquares"`
+	// Min is the smallest observation
+	Min float64 `json:"min" yaml:"min"`
+	// Max is the largest observation
+	Max float64 `json:"max" yaml:"max"`
+	// Zeros is the number of observations that are zero
+	Zeros int64 `json:"zeros,omitempty" yaml:"zeros,omitempty"`
+	// Positive are the counts of positive observations, by bucket index; bucket k contains observations in (gamma^(k-1), gamma^k]
+	Positive map[int]int64 `json:"positive,omitempty" yaml:"positive,omitempty"`
+	// Negative are the counts of negative observations, by the bucket index of their absolute value
+	Negative map[int]int64 `json:"negative,omitempty" yaml:"negative,omitempty"`
+}
+
+// newSampleSketch returns an empty sketch with the given relative accuracy
+func newSampleSketch(accuracy float64) *SampleSketch {
+	return &SampleSketch{
+		Accuracy: accuracy,
+		Positive: map[int]int64{},
+		Negative: map[int]int64{},
+	}
+}
+
+// gamma is the ratio of the upper and lower bounds of buckets
+func (s *SampleSketch) gamma() float64 {
+	return (1 + s.Accuracy) / (1 - s.Accuracy)
+}
+
+// index returns the index of the bucket of the positive value
+func (s *SampleSketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / math.Log(s.gamma())))
+}
+
+// value returns the estimate of positive values in the bucket with the given index;
+// it is within the relative accuracy of the sketch from all values in the bucket
+func (s *SampleSketch) value(k int) float64 {
+	g := s.gamma()
+	return 2 * math.Pow(g, float64(k)) / (g + 1)
+}
+
+// add observations to the sketch
+func (s *SampleSketch) add(vals ...float64) {
+	for _, v := range vals {
+		if s.Count == 0 || v < s.Min {
+			s.Min = v
+		}
+		if s.Count == 0 || v > s.Max {
+			s.Max = v
+		}
+		s.Count++
+		s.Sum += v
+		s.SumSquares += v * v
+		switch {
+		case v > 0:
+			if s.Positive == nil {
+				s.Positive = map[int]int64{}
+			}
+			s.Positive[s.index(v)]++
+		case v < 0:
+			if s.Negative == nil {
+				s.Negative = map[int]int64{}
+			}
+			s.Negative[s.index(-v)]++
+		default:
+			s.Zeros++
+		}
+	}
+}
+
+// merge adds the observations of another sketch with the same accuracy to the sketch
+func (s *SampleSketch) merge(o *SampleSketch) {
+	if o == nil || o.Count == 0 {
+		return
+	}
+	if s.Count == 0 || o.Min < s.Min {
+		s.Min = o.Min
+	}
+	if s.Count == 0 || o.Max > s.Max {
+		s.Max = o.Max
+	}
+	s.Count += o.Count
+	s.Sum += o.Sum
+	s.SumSquares += o.SumSquares
+	s.Zeros += o.Zeros
+	if s.Positive == nil {
+		s.Positive = map[int]int64{}
+	}
+	for k, c := range o.Positive {
+		s.Positive[k] += c
+	}
+	if s.Negative == nil {
+		s.Negative = map[int]int64{}
+	}
+	for k, c := range o.Negative {
+		s.Negative[k] += c
+	}
+}
+
+// delta returns a sketch of the observations added to the sketch since it was copied into the earlier sketch;
+// the min and max of the result are those of the sketch
+func (s *SampleSketch) delta(earlier *SampleSketch) *SampleSketch {
+	d := newSampleSketch(s.Accuracy)
+	d.merge(s)
+	if earlier == nil {
+		return d
+	}
+	d.Count -= earlier.Count
+	d.Sum -= earlier.Sum
+	d.SumSquares -= earlier.SumSquares
+	d.Zeros -= earlier.Zeros
+	for k, c := range earlier.Positive {
+		if d.Positive[k] -= c; d.Positive[k] <= 0 {
+			delete(d.Positive, k)
+		}
+	}
+	for k, c := range earlier.Negative {
+		if d.Negative[k] -= c; d.Negative[k] <= 0 {
+			delete(d.Negative, k)
+		}
+	}
+	return d
+}
+
+// mean returns the mean of observations
+func (s *SampleSketch) mean() float64 {
+	return s.Sum / float64(s.Count)
+}
+
+// stdDev returns the (population) standard deviation of observations
+func (s *SampleSketch) stdDev() float64 {
+	m := s.mean()
+	return math.Sqrt(math.Max(0, s.SumSquares/float64(s.Count)-m*m))
+}
+
+// sketchBucket is a bucket of the sketch in increasing order of values
+type sketchBucket struct {
+	// lower and upper bounds of values in the bucket
+	lower, upper float64
+	// estimate of values in the bucket
+	estimate float64
+	// count of values in the bucket
+	count int64
+}
+
+// buckets returns the non-empty buckets of the sketch in increasing order of values
+func (s *SampleSketch) buckets() []sketchBucket {
+	g := s.gamma()
+	bs := []sketchBucket{}
+	neg := sortedBucketIndices(s.Negative)
+	for j := len(neg) - 1; j >= 0; j-- {
+		k := neg[j]
+		bs = append(bs, sketchBucket{
+			lower:    -math.Pow(g, float64(k)),
+			upper:    -math.Pow(g, float64(k-1)),
+			estimate: -s.value(k),
+			count:    s.Negative[k],
+		})
+	}
+	if s.Zeros > 0 {
+		bs = append(bs, sketchBucket{count: s.Zeros})
+	}
+	for _, k := range sortedBucketIndices(s.Positive) {
+		bs = append(bs, sketchBucket{
+			lower:    math.Pow(g, float64(k-1)),
+			upper:    math.Pow(g, float64(k)),
+			estimate: s.value(k),
+			count:    s.Positive[k],
+		})
+	}
+	// the first and last buckets start and end at the extreme observations
+	if len(bs) > 0 {
+		bs[0].lower = s.Min
+		bs[len(bs)-1].upper = s.Max
+	}
+	return bs
+}
+
+// sortedBucketIndices returns the indices of non-empty buckets in increasing order
+func sortedBucketIndices(counts map[int]int64) []int {
+	keys := []int{}
+	for k, c := range counts {
+		if c > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// percentile returns the estimate of the given percentile of observations, using the nearest rank
+func (s *SampleSketch) percentile(percent float64) (float64, error) {
+	if s.Count == 0 {
+		return 0, fmt.Errorf("sketch has no observations")
+	}
+	if percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid percent %v", percent)
+	}
+	rank := int64(math.Ceil(percent / 100 * float64(s.Count)))
+	seen := int64(0)
+	for _, b := range s.buckets() {
+		if seen += b.count; seen >= rank {
+			return math.Min(math.Max(b.estimate, s.Min), s.Max), nil
+		}
+	}
+	return s.Max, nil
+}
+
+// aggregate returns the aggregation (a) of the observations in the sketch of the given base metric for the given version (i)
+func (s *SampleSketch) aggregate(i int, baseMetric string, a string) *float64 {
+	if s.Count == 0 {
+		log.Logger.Infof("metric %v for version %v has no sample", baseMetric, i)
+		return nil
+	}
+	switch AggregationType(a) {
+	case MeanAggregator:
+		return float64Pointer(s.mean())
+	case StdDevAggregator:
+		return float64Pointer(s.stdDev())
+	case MinAggregator:
+		return float64Pointer(s.Min)
+	case MaxAggregator:
+		return float64Pointer(s.Max)
+	}
+
+	// at this point, 'a' must be a percentile aggregator
+	b := strings.TrimPrefix(a, PercentileAggregatorPrefix)
+	if b == a {
+		log.Logger.Errorf("invalid aggregation %v", a)
+		return nil
+	}
+	if match, _ := regexp.MatchString(decimalRegex, b); !match {
+		log.Logger.Errorf("unable to extract percent from agggregation func %v", a)
+		return nil
+	}
+	percent, err := strconv.ParseFloat(b, 64)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Errorf("error extracting percent from aggregation func %v", a)
+		return nil
+	}
+	agg, err := s.percentile(percent)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Errorf("aggregation error version %v, metric %v, and aggregation func %v", i, baseMetric, a)
+		return nil
+	}
+	return float64Pointer(agg)
+}
+
+// Histogram returns the histogram of observations in the sketch
+func (s *SampleSketch) Histogram() []HistBucket {
+	hist := []HistBucket{}
+	for _, b := range s.buckets() {
+		hist = append(hist, HistBucket{
+			Lower: b.lower,
+			Upper: b.upper,
+			Count: uint64(b.count),
+		})
+	}
+	return hist
+}
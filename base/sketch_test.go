@@ -0,0 +1,127 @@
+package base
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSampleSketch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = math.Exp(r.NormFloat64())
+	}
+	s := newSampleSketch(defaultSketchAccuracy)
+	s.add(vals...)
+
+	sorted := append([]float64{}, vals...)
+	sort.Float64s(sorted)
+	for _, p := range []float64{1, 25, 50, 75, 90, 95, 99, 99.9, 100} {
+		exact := sorted[int(math.Ceil(p/100*float64(len(sorted))))-1]
+		approx, err := s.percentile(p)
+		assert.NoError(t, err)
+		assert.InEpsilon(t, exact, approx, defaultSketchAccuracy, "percentile %v", p)
+	}
+	assert.Equal(t, sorted[0], s.Min)
+	assert.Equal(t, sorted[len(sorted)-1], s.Max)
+	// the sketch is much smaller than the sample
+	assert.Less(t, len(s.Positive), 1000)
+
+	_, err := s.percentile(0)
+	assert.Error(t, err)
+	_, err = newSampleSketch(defaultSketchAccuracy).percentile(50)
+	assert.Error(t, err)
+}
+
+func TestSampleSketchSigns(t *testing.T) {
+	s := newSampleSketch(defaultSketchAccuracy)
+	s.add(-10, -1, 0, 0, 1, 10)
+	assert.Equal(t, 0.0, s.mean())
+	assert.InDelta(t, math.Sqrt(101.0/3), s.stdDev(), 1e-9)
+	p, _ := s.percentile(10)
+	assert.Equal(t, -10.0, p)
+	p, _ = s.percentile(50)
+	assert.Equal(t, 0.0, p)
+	p, _ = s.percentile(100)
+	assert.Equal(t, 10.0, p)
+
+	h := s.Histogram()
+	assert.Len(t, h, 5)
+	assert.Equal(t, -10.0, h[0].Lower)
+	assert.Equal(t, uint64(2), h[2].Count)
+	assert.Equal(t, 10.0, h[4].Upper)
+	for k := 1; k < len(h); k++ {
+		assert.LessOrEqual(t, h[k-1].Upper, h[k].Lower)
+	}
+}
+
+func TestSampleSketchMergeAndDelta(t *testing.T) {
+	a, b := newSampleSketch(defaultSketchAccuracy), newSampleSketch(defaultSketchAccuracy)
+	a.add(1, 2, 3)
+	b.add(1, 2, 3, 40, 50)
+	d := b.delta(a)
+	assert.Equal(t, int64(2), d.Count)
+	assert.Equal(t, 90.0, d.Sum)
+	assert.Len(t, d.Positive, 2)
+
+	a.merge(d)
+	assert.Equal(t, int64(5), a.Count)
+	assert.Equal(t, 50.0, a.Max)
+	assert.Equal(t, b.Positive, a.Positive)
+}
+
+func TestSampleSketchYAML(t *testing.T) {
+	s := newSampleSketch(defaultSketchAccuracy)
+	s.add(-2, 0, 0.5, 3)
+	b, err := yaml.Marshal(s)
+	assert.NoError(t, err)
+	c := &SampleSketch{}
+	assert.NoError(t, yaml.Unmarshal(b, c))
+	assert.Equal(t, s, c)
+}
+
+func TestStreamSampleMetrics(t *testing.T) {
+	exp := &Experiment{
+		Metrics: &MetricsRecording{Streaming: true},
+		Result:  &ExperimentResult{},
+	}
+	exp.Result.sketchAccuracy = exp.Metrics.sketchAccuracy()
+	assert.NoError(t, exp.Result.initInsightsWithNumVersions(1))
+	in := exp.Result.Insights
+	mm := MetricMeta{Description: "latency", Type: SampleMetricType}
+	m := gRPCMetricPrefix + "/" + gRPCLatencySampleMetricName
+	assert.NoError(t, in.updateMetric(m, mm, 0, []float64{10, 20, 30, 40}))
+	assert.NoError(t, in.updateMetric(m, mm, 0, []float64{50}))
+
+	// observations are aggregated in a sketch, instead of being retained
+	assert.Empty(t, in.NonHistMetricValues[0])
+	assert.Equal(t, int64(5), in.SampleSketch(0, m).Count)
+	assert.Equal(t, 30.0, *in.ScalarMetricValue(0, m+"/mean"))
+	assert.Equal(t, 10.0, *in.ScalarMetricValue(0, m+"/min"))
+	assert.Equal(t, 50.0, *in.ScalarMetricValue(0, m+"/max"))
+	assert.InEpsilon(t, 40.0, *in.ScalarMetricValue(0, m+"/p80"), defaultSketchAccuracy)
+	assert.Nil(t, in.ScalarMetricValue(0, m+"/median"))
+
+	// insights with sketches remain consistent when reused
+	assert.NoError(t, in.initMetrics())
+
+	// a parallel branch adds its observations to the sketch
+	original, err := copyInsights(in)
+	assert.NoError(t, err)
+	branch, err := copyInsights(in)
+	assert.NoError(t, err)
+	branch.updateMetricValueVector(m, 0, []float64{60})
+	merged, err := mergeInsights(in, original, branch)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), merged.SampleSketch(0, m).Count)
+	assert.Equal(t, 60.0, merged.SampleSketch(0, m).Max)
+
+	// invalid accuracy
+	assert.Error(t, (&MetricsRecording{Accuracy: float64Pointer(1)}).validate())
+	assert.Equal(t, 0.0, (&MetricsRecording{Accuracy: float64Pointer(0.1)}).sketchAccuracy())
+}
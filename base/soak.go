@@ -0,0 +1,417 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ierrors "github.com/iter8-tools/iter8/base/errors"
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// SoakTaskName is the name of the task which samples the resource usage of versions during a soak test
+	SoakTaskName = "soak"
+	// soakMetricPrefix is the prefix of the metrics of the soak task
+	soakMetricPrefix = "soak"
+	// defaultSoakInterval is the default interval between samples of resource usage
+	defaultSoakInterval = "1m"
+	// slopeSuffix is the suffix of the names of metrics with the trend of a resource
+	slopeSuffix = "-slope"
+
+	// memoryResource is the memory usage of a version, in bytes
+	memoryResource = "memory"
+	// cpuResource is the CPU usage of a version, in cores
+	cpuResource = "cpu"
+)
+
+var (
+	// podMetricsGVR identifies the metrics of pods served by the metrics server
+	podMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+	// resourceNameRegex matches names of resources that can be used in names of metrics
+	resourceNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+	// resourceUnits are the units of well known resources
+	resourceUnits = map[string]string{
+		memoryResource: "bytes",
+		cpuResource:    "cores",
+	}
+)
+
+// soakVersion identifies the resource usage of a version of the app
+type soakVersion struct {
+	// Selector is the set of labels of the pods of the version, whose memory and CPU usage are sampled from the metrics server. Optional.
+	Selector map[string]string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	// Queries are PromQL queries of the resource usage of the version, by name of the resource; for example, memory, cpu, or fds.
+	// The values of the series returned by each query are summed. Queries take precedence over the metrics server. Optional.
+	Queries map[string]string `json:"queries,omitempty" yaml:"queries,omitempty"`
+}
+
+// soakPrometheus identifies the Prometheus server queried for resource usage
+type soakPrometheus struct {
+	// URL of Prometheus; for example, http://prometheus.istio-system:9090
+	URL string `json:"url" yaml:"url"`
+	// Headers are HTTP headers sent with queries; for example, Authorization
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// soakInputs are the inputs of the soak task
+type soakInputs struct {
+	// Duration of the soak test; for example, 6h.
+	// The soak task is usually run in a parallel branch with tasks that generate load for the same duration.
+	Duration string `json:"duration" yaml:"duration"`
+	// Interval between samples of resource usage; for example, 5m. Optional. Default is 1m.
+	Interval *string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// Namespace of the pods of versions. Optional. If unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Versions are the versions of the app whose resource usage is sampled; the first is the baseline.
+	Versions []soakVersion `json:"versions" yaml:"versions"`
+	// Prometheus is the Prometheus server queried by the queries of versions. Optional.
+	Prometheus *soakPrometheus `json:"prometheus,omitempty" yaml:"prometheus,omitempty"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the pods. Optional.
+	// If unspecified, the pods are looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the pods. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// soakTask periodically samples the memory, CPU, file descriptors, or other resources of versions during a soak test,
+// and records the samples and their trend, as the slope of a least squares fit per hour, as metrics of each version;
+// for example, SLOs on soak/memory-slope detect memory leaks.
+type soakTask struct {
+	TaskMeta
+	With soakInputs `json:"with" yaml:"with"`
+}
+
+// soakSample is a sample of a resource of a version
+type soakSample struct {
+	// at is the time of the sample
+	at time.Time
+	// value of the resource
+	value float64
+}
+
+// driver returns the KubeDriver for the cluster containing the pods
+func (t *soakTask) driver() *KubeDriver {
+	return targetDriver(t.With.KubeConfig, t.With.Context)
+}
+
+// initializeDefaults sets default values for the soak task
+func (t *soakTask) initializeDefaults() {
+	if t.With.Interval == nil {
+		t.With.Interval = StringPointer(defaultSoakInterval)
+	}
+	if t.usesMetricsServer() {
+		t.driver().initKube()
+		// set Namespace (from context) if not already set
+		if t.With.Namespace == nil {
+			t.With.Namespace = StringPointer(t.driver().Namespace())
+		}
+	}
+}
+
+// usesMetricsServer returns true if the resource usage of a version is sampled from the metrics server
+func (t *soakTask) usesMetricsServer() bool {
+	for _, v := range t.With.Versions {
+		if len(v.Selector) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInputs validates task inputs
+func (t *soakTask) validateInputs() error {
+	d, err := time.ParseDuration(t.With.Duration)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("soak task requires a valid duration: %v", t.With.Duration)
+	}
+	if t.With.Interval != nil {
+		if i, err := time.ParseDuration(*t.With.Interval); err != nil || i <= 0 || i > d {
+			return fmt.Errorf("invalid interval %v; must be positive and at most the duration", *t.With.Interval)
+		}
+	}
+	if len(t.With.Versions) == 0 {
+		return errors.New("soak task requires versions")
+	}
+	for i, v := range t.With.Versions {
+		if len(v.Selector) == 0 && len(v.Queries) == 0 {
+			return fmt.Errorf("soak task requires a selector or queries for version %v", i)
+		}
+		for r := range v.Queries {
+			if !resourceNameRegex.MatchString(r) {
+				return fmt.Errorf("invalid resource name %q of version %v", r, i)
+			}
+		}
+		if len(v.Queries) > 0 && (t.With.Prometheus == nil || t.With.Prometheus.URL == "") {
+			return errors.New("soak task requires a prometheus url for queries")
+		}
+	}
+	return nil
+}
+
+// sampleMetricsServer returns the memory and CPU usage of the pods of the version, from the metrics server
+func (t *soakTask) sampleMetricsServer(v soakVersion) (map[string]float64, error) {
+	list, err := t.driver().dynamicClient.Resource(podMetricsGVR).Namespace(*t.With.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(v.Selector).String(),
+	})
+	if err != nil {
+		return nil, ierrors.Wrap(ierrors.ErrMetricsBackendUnavailable, err, "unable to get pod metrics: %v", err)
+	}
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("no pod metrics match labels %v", labels.Set(v.Selector))
+	}
+	usage := map[string]float64{memoryResource: 0, cpuResource: 0}
+	for _, pm := range list.Items {
+		containers, _, err := unstructured.NestedSlice(pm.Object, "containers")
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			cm, ok := c.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid container metrics of pod %v", pm.GetName())
+			}
+			for _, r := range []string{memoryResource, cpuResource} {
+				s, _, _ := unstructured.NestedString(cm, "usage", r)
+				if s == "" {
+					continue
+				}
+				q, err := resource.ParseQuantity(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %v usage %v of pod %v: %v", r, s, pm.GetName(), err)
+				}
+				usage[r] += q.AsApproximateFloat64()
+			}
+		}
+	}
+	return usage, nil
+}
+
+// queryPrometheus returns the sum of the values of the series returned by the PromQL query
+func (t *soakTask) queryPrometheus(query string) (float64, error) {
+	p := t.With.Prometheus
+	u := strings.TrimSuffix(p.URL, "/") + "/api/v1/query?" + url.Values{"query": []string{query}}.Encode()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range p.Headers {
+		req.Header.Add(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, ierrors.Wrap(ierrors.ErrMetricsBackendUnavailable, err, "unable to query prometheus at %v: %v", p.URL, err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return 0, err
+	}
+	pr := promQueryResponse{}
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return 0, fmt.Errorf("unable to parse response of query %v: %v", query, err)
+	}
+	if pr.Status != "success" {
+		return 0, fmt.Errorf("query %v failed: %v", query, pr.Error)
+	}
+	// a vector is a list of series with a sample each; a scalar is a single sample
+	samples := [][2]interface{}{}
+	switch pr.Data.ResultType {
+	case "vector":
+		series := []struct {
+			Value [2]interface{} `json:"value"`
+		}{}
+		if err := json.Unmarshal(pr.Data.Result, &series); err != nil {
+			return 0, fmt.Errorf("unable to parse result of query %v: %v", query, err)
+		}
+		for _, s := range series {
+			samples = append(samples, s.Value)
+		}
+	case "scalar":
+		sample := [2]interface{}{}
+		if err := json.Unmarshal(pr.Data.Result, &sample); err != nil {
+			return 0, fmt.Errorf("unable to parse result of query %v: %v", query, err)
+		}
+		samples = append(samples, sample)
+	default:
+		return 0, fmt.Errorf("query %v returned a %v; must return a vector or scalar", query, pr.Data.ResultType)
+	}
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("query %v returned no samples", query)
+	}
+	sum := float64(0)
+	for _, s := range samples {
+		str, _ := s[1].(string)
+		val, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return 0, fmt.Errorf("query %v returned invalid value %v", query, s[1])
+		}
+		sum += val
+	}
+	return sum, nil
+}
+
+// sample returns the resource usage of the version; queries take precedence over the metrics server
+func (t *soakTask) sample(v soakVersion) (map[string]float64, error) {
+	usage := map[string]float64{}
+	if len(v.Selector) > 0 {
+		u, err := t.sampleMetricsServer(v)
+		if err != nil {
+			return nil, err
+		}
+		usage = u
+	}
+	for r, q := range v.Queries {
+		val, err := t.queryPrometheus(q)
+		if err != nil {
+			return nil, err
+		}
+		usage[r] = val
+	}
+	return usage, nil
+}
+
+// slope returns the slope per hour of the least squares fit of the samples; false if there are fewer than two distinct times
+func slope(samples []soakSample) (float64, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	// the fit is computed about the means of times and values, which avoids the loss of precision of large values
+	n := float64(len(samples))
+	var mx, my float64
+	for _, s := range samples {
+		mx += s.at.Sub(samples[0].at).Hours() / n
+		my += s.value / n
+	}
+	var sxx, sxy float64
+	for _, s := range samples {
+		dx := s.at.Sub(samples[0].at).Hours() - mx
+		sxx += dx * dx
+		sxy += dx * (s.value - my)
+	}
+	if sxx == 0 {
+		return 0, false
+	}
+	return sxy / sxx, true
+}
+
+// run executes the task
+func (t *soakTask) run(exp *Experiment) error {
+	// validation
+	err := t.validateInputs()
+	if err != nil {
+		return err
+	}
+
+	// initialization
+	t.initializeDefaults()
+	err = exp.Result.initInsightsWithNumVersions(len(t.With.Versions))
+	if err != nil {
+		return err
+	}
+
+	duration, _ := time.ParseDuration(t.With.Duration)
+	interval, _ := time.ParseDuration(*t.With.Interval)
+	log.Logger.Infof("sampling resource usage of %v versions every %v for %v", len(t.With.Versions), interval, duration)
+
+	// resource usage is sampled at the start and end of the soak test, and at each interval in between
+	samples := make([]map[string][]soakSample, len(t.With.Versions))
+	for i := range samples {
+		samples[i] = map[string][]soakSample{}
+	}
+	end := time.Now().Add(duration)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		for i, v := range t.With.Versions {
+			usage, err := t.sample(v)
+			if err != nil {
+				log.Logger.WithStackTrace(err.Error()).Warnf("unable to sample resource usage of version %v", i)
+				continue
+			}
+			for r, val := range usage {
+				samples[i][r] = append(samples[i][r], soakSample{at: now, value: val})
+			}
+		}
+		if !now.Before(end) {
+			break
+		}
+		timer := time.NewTimer(time.Until(end))
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+		case <-exp.interrupt:
+		case <-exp.context().Done():
+		}
+		timer.Stop()
+		if exp.stopped() {
+			log.Logger.Warn("stopped sampling resource usage")
+			break
+		}
+	}
+
+	// record the samples and their slope
+	in := exp.Result.Insights
+	numSamples := 0
+	for i := range t.With.Versions {
+		resources := []string{}
+		for r := range samples[i] {
+			resources = append(resources, r)
+		}
+		sort.Strings(resources)
+		for _, r := range resources {
+			values := []float64{}
+			for _, s := range samples[i][r] {
+				values = append(values, s.value)
+			}
+			numSamples += len(values)
+			mm := MetricMeta{
+				Description: fmt.Sprintf("samples of %v usage", r),
+				Type:        SampleMetricType,
+			}
+			slopeMM := MetricMeta{
+				Description: fmt.Sprintf("trend of %v usage per hour", r),
+				Type:        GaugeMetricType,
+			}
+			if units, ok := resourceUnits[r]; ok {
+				mm.Units = StringPointer(units)
+				slopeMM.Units = StringPointer(units + "/hour")
+			}
+			if err := in.updateMetric(soakMetricPrefix+"/"+r, mm, i, values); err != nil {
+				return err
+			}
+			if s, ok := slope(samples[i][r]); ok {
+				if err := in.updateMetric(soakMetricPrefix+"/"+r+slopeSuffix, slopeMM, i, s); err != nil {
+					return err
+				}
+				log.Logger.Infof("%v usage of version %v changes by %v per hour", r, i, s)
+			} else {
+				log.Logger.Warnf("too few samples of %v usage of version %v to compute its trend", r, i)
+			}
+		}
+	}
+	if numSamples == 0 {
+		e := errors.New("soak task was unable to sample resource usage")
+		log.Logger.Error(e)
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package base
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestSlope(t *testing.T) {
+	start := time.Now()
+	s, ok := slope([]soakSample{
+		{at: start, value: 10},
+		{at: start.Add(30 * time.Minute), value: 15},
+		{at: start.Add(time.Hour), value: 20},
+	})
+	assert.True(t, ok)
+	assert.InDelta(t, 10.0, s, 1e-9)
+
+	// the trend is undefined with fewer than two distinct times
+	_, ok = slope([]soakSample{{at: start, value: 10}})
+	assert.False(t, ok)
+	_, ok = slope([]soakSample{{at: start, value: 10}, {at: start, value: 20}})
+	assert.False(t, ok)
+}
+
+func TestSoakPrometheus(t *testing.T) {
+	os.Chdir(t.TempDir())
+	// the memory of the candidate grows with each query; its file descriptors do not
+	var queries int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("query") {
+		case "memory":
+			n := atomic.AddInt32(&queries, 1)
+			fmt.Fprintf(w, `{"status": "success", "data": {"resultType": "vector", "result": [
+				{"metric": {"pod": "a"}, "value": [1, "%v"]}, {"metric": {"pod": "b"}, "value": [1, "1000"]}]}}`, 1000+100*n)
+		case "fds":
+			fmt.Fprint(w, `{"status": "success", "data": {"resultType": "scalar", "result": [1, "64"]}}`)
+		default:
+			fmt.Fprint(w, `{"status": "error", "error": "unknown query"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	st := &soakTask{
+		TaskMeta: TaskMeta{Task: StringPointer(SoakTaskName)},
+		With: soakInputs{
+			Duration:   "600ms",
+			Interval:   StringPointer("200ms"),
+			Prometheus: &soakPrometheus{URL: srv.URL},
+			Versions:   []soakVersion{{Queries: map[string]string{"memory": "memory", "fds": "fds"}}},
+		},
+	}
+	exp := &Experiment{Spec: []Task{st}, Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.NoError(t, st.run(exp))
+
+	in := exp.Result.Insights
+	n := int(atomic.LoadInt32(&queries))
+	assert.GreaterOrEqual(t, n, 3)
+	assert.Len(t, in.NonHistMetricValues[0]["soak/memory"], n)
+	assert.Equal(t, 2000.0+100*float64(n), in.NonHistMetricValues[0]["soak/memory"][n-1])
+	assert.Greater(t, *in.ScalarMetricValue(0, "soak/memory-slope"), 0.0)
+	assert.Equal(t, 0.0, *in.ScalarMetricValue(0, "soak/fds-slope"))
+	assert.Equal(t, "bytes/hour", *in.MetricsInfo["soak/memory-slope"].Units)
+	assert.Nil(t, in.MetricsInfo["soak/fds-slope"].Units)
+
+	// failed queries are not samples
+	st.With.Versions[0].Queries = map[string]string{"memory": "missing"}
+	exp = &Experiment{Spec: []Task{st}, Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.Error(t, st.run(exp))
+}
+
+func TestSoakMetricsServer(t *testing.T) {
+	os.Chdir(t.TempDir())
+	*kd = *NewFakeKubeDriver(cli.New())
+	for _, pod := range []string{"httpbin-v2-a", "httpbin-v2-b"} {
+		createObject(t, podMetricsGVR, map[string]interface{}{
+			"apiVersion": "metrics.k8s.io/v1beta1",
+			"kind":       "PodMetrics",
+			"metadata": map[string]interface{}{
+				"name":      pod,
+				"namespace": "default",
+				"labels":    map[string]interface{}{"app": "httpbin", "version": "v2"},
+			},
+			"containers": []interface{}{
+				map[string]interface{}{"name": "httpbin", "usage": map[string]interface{}{"cpu": "250m", "memory": "64Mi"}},
+				map[string]interface{}{"name": "proxy", "usage": map[string]interface{}{"cpu": "50m", "memory": "32Mi"}},
+			},
+		})
+	}
+
+	st := &soakTask{
+		TaskMeta: TaskMeta{Task: StringPointer(SoakTaskName)},
+		With: soakInputs{
+			Duration: "100ms",
+			Interval: StringPointer("50ms"),
+			Versions: []soakVersion{{Selector: map[string]string{"app": "httpbin", "version": "v2"}}},
+		},
+	}
+	exp := &Experiment{Spec: []Task{st}, Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.NoError(t, st.run(exp))
+
+	in := exp.Result.Insights
+	assert.Equal(t, float64(2*96*1024*1024), in.NonHistMetricValues[0]["soak/memory"][0])
+	assert.InDelta(t, 0.6, in.NonHistMetricValues[0]["soak/cpu"][0], 1e-9)
+	assert.Equal(t, 0.0, *in.ScalarMetricValue(0, "soak/memory-slope"))
+	assert.Equal(t, "cores/hour", *in.MetricsInfo["soak/cpu-slope"].Units)
+
+	// pods without metrics are not sampled
+	st.With.Versions[0].Selector = map[string]string{"app": "missing"}
+	exp = &Experiment{Spec: []Task{st}, Result: &ExperimentResult{}}
+	exp.initResults(1)
+	assert.Error(t, st.run(exp))
+}
+
+func TestSoakInvalidInputs(t *testing.T) {
+	for _, in := range []soakInputs{
+		{Versions: []soakVersion{{Selector: map[string]string{"app": "httpbin"}}}},
+		{Duration: "1h", Interval: StringPointer("2h"), Versions: []soakVersion{{Selector: map[string]string{"app": "httpbin"}}}},
+		{Duration: "1h"},
+		{Duration: "1h", Versions: []soakVersion{{}}},
+		{Duration: "1h", Versions: []soakVersion{{Queries: map[string]string{"memory": "up"}}}},
+		{Duration: "1h", Prometheus: &soakPrometheus{URL: "http://prometheus"}, Versions: []soakVersion{{Queries: map[string]string{"Memory/RSS": "up"}}}},
+	} {
+		st := &soakTask{With: in}
+		assert.Error(t, st.validateInputs())
+	}
+}
@@ -0,0 +1,217 @@
+package base
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+const (
+	// bashLanguage is the default language of run task scripts
+	bashLanguage = "bash"
+	// starlarkLanguage is the language of run task scripts that are run by the embedded Starlark interpreter
+	starlarkLanguage = "starlark"
+	// starlarkHTTPTimeout is the timeout of http requests made by Starlark scripts
+	starlarkHTTPTimeout = 30 * time.Second
+)
+
+func init() {
+	// Starlark scripts are written like small Python programs
+	resolve.AllowFloat = true
+	resolve.AllowSet = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowRecursion = true
+	resolve.AllowGlobalReassign = true
+}
+
+// runStarlark runs the script of the task in the embedded Starlark interpreter.
+// The script does not require a shell, and has access to the following iter8 API.
+//
+//	env(name, default=None)                      value of an environment variable of the task or Iter8 process
+//	outputs                                      dict of outputs published by earlier tasks
+//	output(name, value)                          publishes an output for later tasks
+//	metric(name, value, type="Gauge", version=0) records a metric under the run backend when the script completes
+//	metric_value(name, version=0)                value of a metric in insights; None if unavailable
+//	http_get(url, headers={})                    struct with the status and body of the response
+func (t *runTask) runStarlark(exp *Experiment, env []string) error {
+	vars := map[string]string{}
+	for _, kv := range env {
+		s := strings.SplitN(kv, "=", 2)
+		vars[s[0]] = s[1]
+	}
+	var metrics []runMetric
+
+	outputs := starlark.NewDict(len(exp.Result.Outputs))
+	for k, v := range exp.Result.Outputs {
+		if err := outputs.SetKey(starlark.String(k), starlark.String(v)); err != nil {
+			return err
+		}
+	}
+	outputs.Freeze()
+
+	predeclared := starlark.StringDict{
+		"outputs": outputs,
+		"env": starlark.NewBuiltin("env", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			var def starlark.Value = starlark.None
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "default?", &def); err != nil {
+				return nil, err
+			}
+			if v, ok := vars[name]; ok {
+				return starlark.String(v), nil
+			}
+			if v, ok := os.LookupEnv(name); ok {
+				return starlark.String(v), nil
+			}
+			return def, nil
+		}),
+		"output": starlark.NewBuiltin("output", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			var value starlark.Value
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
+				return nil, err
+			}
+			if s, ok := starlark.AsString(value); ok {
+				exp.setOutput(name, s)
+			} else {
+				exp.setOutput(name, value.String())
+			}
+			return starlark.None, nil
+		}),
+		"metric": starlark.NewBuiltin("metric", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			m := runMetric{}
+			var value starlark.Value
+			var mt string
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &m.Name, "value", &value, "type?", &mt, "version?", &m.Version); err != nil {
+				return nil, err
+			}
+			if m.Name == "" || strings.Contains(m.Name, "/") {
+				return nil, fmt.Errorf("%v: invalid metric name %v", fn.Name(), m.Name)
+			}
+			if m.Version < 0 {
+				return nil, fmt.Errorf("%v: invalid version %v", fn.Name(), m.Version)
+			}
+			m.Type = MetricType(mt)
+			val, err := fromStarlarkNumbers(value)
+			if err != nil {
+				return nil, fmt.Errorf("%v: metric %v: %v", fn.Name(), m.Name, err)
+			}
+			m.Value = val
+			if _, _, err := m.metricMeta(); err != nil {
+				return nil, fmt.Errorf("%v: %v", fn.Name(), err)
+			}
+			metrics = append(metrics, m)
+			return starlark.None, nil
+		}),
+		"metric_value": starlark.NewBuiltin("metric_value", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			var version int
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "version?", &version); err != nil {
+				return nil, err
+			}
+			in := exp.Result.Insights
+			if in == nil || version < 0 || version >= in.NumVersions {
+				return starlark.None, nil
+			}
+			v := in.ScalarMetricValue(version, name)
+			if v == nil {
+				return starlark.None, nil
+			}
+			return starlark.Float(*v), nil
+		}),
+		"http_get": starlark.NewBuiltin("http_get", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var url string
+			headers := &starlark.Dict{}
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "url", &url, "headers?", &headers); err != nil {
+				return nil, err
+			}
+			req, err := http.NewRequestWithContext(exp.context(), http.MethodGet, url, nil)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", fn.Name(), err)
+			}
+			for _, item := range headers.Items() {
+				k, ok1 := starlark.AsString(item[0])
+				v, ok2 := starlark.AsString(item[1])
+				if !ok1 || !ok2 {
+					return nil, fmt.Errorf("%v: headers must be strings", fn.Name())
+				}
+				req.Header.Set(k, v)
+			}
+			client := &http.Client{Timeout: starlarkHTTPTimeout}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", fn.Name(), err)
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", fn.Name(), err)
+			}
+			return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+				"status": starlark.MakeInt(resp.StatusCode),
+				"body":   starlark.String(body),
+			}), nil
+		}),
+	}
+
+	thread := &starlark.Thread{
+		Name: "run",
+		Print: func(thread *starlark.Thread, msg string) {
+			log.Logger.Info(msg)
+		},
+	}
+	// scripts cannot be cancelled while evaluating, so builtins fail once the experiment is interrupted, or the attempt times out
+	for name, v := range predeclared {
+		if b, ok := v.(*starlark.Builtin); ok {
+			predeclared[name] = stoppable(exp, b)
+		}
+	}
+	if _, err := starlark.ExecFile(thread, "run.star", *t.TaskMeta.Run, predeclared); err != nil {
+		e := fmt.Errorf("starlark script failed: %v", err)
+		if ee, ok := err.(*starlark.EvalError); ok {
+			log.Logger.WithStackTrace(ee.Backtrace()).Error(e)
+		} else {
+			log.Logger.Error(e)
+		}
+		return e
+	}
+	return exp.updateRunMetrics(runMetricPrefix, metrics)
+}
+
+// fromStarlarkNumbers converts a Starlark number, or list of numbers, into the value of a run metric
+func fromStarlarkNumbers(v starlark.Value) (interface{}, error) {
+	if f, ok := starlark.AsFloat(v); ok {
+		return f, nil
+	}
+	if l, ok := v.(*starlark.List); ok {
+		vals := make([]interface{}, l.Len())
+		for i := 0; i < l.Len(); i++ {
+			f, ok := starlark.AsFloat(l.Index(i))
+			if !ok {
+				return nil, fmt.Errorf("value %v is not a number", l.Index(i))
+			}
+			vals[i] = f
+		}
+		return vals, nil
+	}
+	return nil, fmt.Errorf("value %v is not a number", v)
+}
+
+// stoppable returns a builtin that fails if the experiment is stopped, and otherwise calls b
+func stoppable(exp *Experiment, b *starlark.Builtin) *starlark.Builtin {
+	return starlark.NewBuiltin(b.Name(), func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if exp.stopped() {
+			return nil, fmt.Errorf("%v: script stopped", fn.Name())
+		}
+		return b.CallInternal(thread, args, kwargs)
+	})
+}
@@ -1,20 +1,50 @@
 package base
 
 import (
+	"io/ioutil"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/storage"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+
+	log "github.com/iter8-tools/iter8/base/log"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 )
 
 // initKubeFake initialize the Kube clientset with a fake
 func initKubeFake(kd *KubeDriver, objects ...runtime.Object) {
-	kd.dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	// resources listed by tasks must be registered with their list kinds
+	kd.dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		servicesGVR:         "ServiceList",
+		deploymentsGVR:      "DeploymentList",
+		ingressesGVR:        "IngressList",
+		knativeRevisionsGVR: "RevisionList",
+		podMetricsGVR:       "PodMetricsList",
+	})
+}
+
+// initHelmFake initializes the Helm configuration of the default namespace with a fake that stores releases in memory
+func initHelmFake(kd *KubeDriver) {
+	kd.helmConfigs = map[string]*action.Configuration{
+		"default": {
+			Releases:     storage.Init(helmdriver.NewMemory()),
+			KubeClient:   &kubefake.PrintingKubeClient{Out: ioutil.Discard},
+			Capabilities: chartutil.DefaultCapabilities,
+			Log:          log.Logger.Debugf,
+		},
+	}
 }
 
 // initFake initializes fake Kubernetes and Helm clients
 func initFake(kd *KubeDriver, objects ...runtime.Object) error {
 	initKubeFake(kd, objects...)
+	initHelmFake(kd)
 	return nil
 }
 
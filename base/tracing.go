@@ -0,0 +1,268 @@
+package base
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// OTLPEndpointEnv is the environment variable that sets the base URL of the OTLP/HTTP collector.
+	// Traces are sent to <base URL>/v1/traces.
+	OTLPEndpointEnv = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	// OTLPTracesEndpointEnv is the environment variable that sets the full URL to which traces are sent.
+	// It takes precedence over OTLPEndpointEnv.
+	OTLPTracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	// OTLPHeadersEnv is the environment variable that sets additional headers sent with traces.
+	// Example: api-key=secret,tenant=team-a
+	OTLPHeadersEnv = "OTEL_EXPORTER_OTLP_HEADERS"
+	// OTelServiceNameEnv is the environment variable that sets the service name of the traces
+	OTelServiceNameEnv = "OTEL_SERVICE_NAME"
+
+	// defaultOTelServiceName is the service name used when OTelServiceNameEnv is not set
+	defaultOTelServiceName = "iter8"
+	// otlpExportTimeout is the timeout used when exporting traces
+	otlpExportTimeout = 10 * time.Second
+)
+
+// tracer records spans for a single experiment run and exports them using OTLP/HTTP with JSON encoding.
+// A nil tracer records nothing, so that tracing can be disabled without additional checks.
+type tracer struct {
+	// endpoint is the URL to which traces are sent
+	endpoint string
+	// headers are additional headers sent with traces
+	headers map[string]string
+	// serviceName is the service name of the traces
+	serviceName string
+	// traceID is the ID of the trace to which all spans belong
+	traceID string
+	// spans recorded so far
+	spans []*span
+}
+
+// span is a single timed operation within an experiment run
+type span struct {
+	// spanID is the ID of this span
+	spanID string
+	// parentSpanID is the ID of the parent span; empty for the root span
+	parentSpanID string
+	// name of this span
+	name string
+	// start time of this span
+	start time.Time
+	// end time of this span
+	end time.Time
+	// attributes of this span
+	attributes map[string]interface{}
+	// err is the error (if any) with which this span ended
+	err error
+}
+
+// randomHexID returns a random ID of n bytes encoded in hex
+func randomHexID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newTracer returns a tracer if an OTLP endpoint is configured, and nil otherwise
+func newTracer() *tracer {
+	endpoint := os.Getenv(OTLPTracesEndpointEnv)
+	if endpoint == "" {
+		if root := os.Getenv(OTLPEndpointEnv); root != "" {
+			endpoint = strings.TrimSuffix(root, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	headers := map[string]string{}
+	for _, kv := range strings.Split(os.Getenv(OTLPHeadersEnv), ",") {
+		if pair := strings.SplitN(kv, "=", 2); len(pair) == 2 {
+			headers[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+		}
+	}
+
+	serviceName := os.Getenv(OTelServiceNameEnv)
+	if serviceName == "" {
+		serviceName = defaultOTelServiceName
+	}
+
+	return &tracer{
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		traceID:     randomHexID(16),
+	}
+}
+
+// startSpan starts a new span with the given parent; parent is nil for the root span
+func (t *tracer) startSpan(name string, parent *span) *span {
+	if t == nil {
+		return nil
+	}
+	s := &span{
+		spanID:     randomHexID(8),
+		name:       name,
+		start:      time.Now(),
+		attributes: map[string]interface{}{},
+	}
+	if parent != nil {
+		s.parentSpanID = parent.spanID
+	}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+// setAttribute sets an attribute of this span
+func (s *span) setAttribute(key string, val interface{}) {
+	if s == nil {
+		return
+	}
+	s.attributes[key] = val
+}
+
+// endSpan ends this span; err is recorded as the span status
+func (s *span) endSpan(err error) {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+}
+
+// otlpValue converts an attribute value into an OTLP AnyValue
+func otlpValue(val interface{}) map[string]interface{} {
+	switch v := val.(type) {
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+// otlpAttributes converts attributes into OTLP KeyValues
+func otlpAttributes(attrs map[string]interface{}) []map[string]interface{} {
+	kvs := []map[string]interface{}{}
+	for k, v := range attrs {
+		kvs = append(kvs, map[string]interface{}{
+			"key":   k,
+			"value": otlpValue(v),
+		})
+	}
+	return kvs
+}
+
+// otlpTime converts a time into OTLP nanoseconds since epoch
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// payload returns the OTLP/HTTP JSON request body for the recorded spans
+func (t *tracer) payload() map[string]interface{} {
+	spans := []map[string]interface{}{}
+	for _, s := range t.spans {
+		end := s.end
+		if end.IsZero() {
+			end = time.Now()
+		}
+		// status code 1 is OK and 2 is ERROR
+		status := map[string]interface{}{"code": 1}
+		if s.err != nil {
+			status = map[string]interface{}{"code": 2, "message": s.err.Error()}
+		}
+		otlpSpan := map[string]interface{}{
+			"traceId":           t.traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              1,
+			"startTimeUnixNano": otlpTime(s.start),
+			"endTimeUnixNano":   otlpTime(end),
+			"attributes":        otlpAttributes(s.attributes),
+			"status":            status,
+		}
+		if s.parentSpanID != "" {
+			otlpSpan["parentSpanId"] = s.parentSpanID
+		}
+		spans = append(spans, otlpSpan)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": otlpAttributes(map[string]interface{}{
+						"service.name":    t.serviceName,
+						"service.version": Version,
+					}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{
+							"name":    "iter8",
+							"version": Version,
+						},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// export sends the recorded spans to the OTLP endpoint
+func (t *tracer) export() error {
+	if t == nil || len(t.spans) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(t.payload())
+	if err != nil {
+		e := errors.New("unable to marshal traces")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(b))
+	if err != nil {
+		e := errors.New("unable to create OTLP request")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: otlpExportTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		e := errors.New("unable to export traces")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e := fmt.Errorf("OTLP endpoint returned status code %v", resp.StatusCode)
+		log.Logger.Error(e)
+		return e
+	}
+	log.Logger.Debugf("exported %v spans to %v", len(t.spans), t.endpoint)
+	return nil
+}
@@ -0,0 +1,84 @@
+package base
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTracer(t *testing.T) {
+	os.Unsetenv(OTLPEndpointEnv)
+	os.Unsetenv(OTLPTracesEndpointEnv)
+	assert.Nil(t, newTracer())
+
+	// nil tracer and spans record nothing
+	var tr *tracer
+	s := tr.startSpan("experiment", nil)
+	s.setAttribute("a", 1)
+	s.endSpan(nil)
+	assert.NoError(t, tr.export())
+
+	os.Setenv(OTLPEndpointEnv, "http://collector:4318/")
+	defer os.Unsetenv(OTLPEndpointEnv)
+	os.Setenv(OTLPHeadersEnv, "api-key=secret, tenant=a")
+	defer os.Unsetenv(OTLPHeadersEnv)
+	tr = newTracer()
+	assert.NotNil(t, tr)
+	assert.Equal(t, "http://collector:4318/v1/traces", tr.endpoint)
+	assert.Equal(t, map[string]string{"api-key": "secret", "tenant": "a"}, tr.headers)
+	assert.Equal(t, defaultOTelServiceName, tr.serviceName)
+	assert.Equal(t, 32, len(tr.traceID))
+
+	os.Setenv(OTLPTracesEndpointEnv, "http://collector:4318/custom")
+	defer os.Unsetenv(OTLPTracesEndpointEnv)
+	tr = newTracer()
+	assert.Equal(t, "http://collector:4318/custom", tr.endpoint)
+}
+
+func TestRunExperimentWithTraces(t *testing.T) {
+	os.Chdir(t.TempDir())
+
+	var payload map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(b, &payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	os.Setenv(OTLPEndpointEnv, srv.URL)
+	defer os.Unsetenv(OTLPEndpointEnv)
+
+	exp := &Experiment{
+		Spec: []Task{
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello")}},
+			&runTask{TaskMeta: TaskMeta{Run: StringPointer("exit 1")}},
+		},
+	}
+	err := RunExperiment(false, &mockDriver{exp})
+	assert.Error(t, err)
+
+	// one root span and one span per attempted task
+	assert.NotNil(t, payload)
+	rs := payload["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	ss := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})
+	spans := ss["spans"].([]interface{})
+	assert.Equal(t, 3, len(spans))
+	root := spans[0].(map[string]interface{})
+	assert.Equal(t, "experiment", root["name"])
+	assert.Nil(t, root["parentSpanId"])
+	assert.Equal(t, float64(2), root["status"].(map[string]interface{})["code"])
+	task := spans[1].(map[string]interface{})
+	assert.Equal(t, "task run", task["name"])
+	assert.Equal(t, root["spanId"], task["parentSpanId"])
+	assert.Equal(t, root["traceId"], task["traceId"])
+	assert.Equal(t, float64(1), task["status"].(map[string]interface{})["code"])
+	failed := spans[2].(map[string]interface{})
+	assert.Equal(t, float64(2), failed["status"].(map[string]interface{})["code"])
+}
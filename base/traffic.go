@@ -0,0 +1,94 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+)
+
+// trafficBackend is a version of the app, identified by its Kubernetes service, to which traffic is routed
+type trafficBackend struct {
+	// Service of the version; for example, httpbin-v2
+	Service string `json:"service" yaml:"service"`
+	// Port of the service. Optional.
+	Port *uint32 `json:"port,omitempty" yaml:"port,omitempty"`
+	// Weight is the percentage of traffic routed to this version. Ignored for mirrors.
+	Weight int32 `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
+
+// String describes the backend
+func (b trafficBackend) String() string {
+	return b.Service
+}
+
+// validateBackends checks that each backend has a service, and that their weights add up to 100
+func validateBackends(backends []trafficBackend) error {
+	weights := []int32{}
+	for _, b := range backends {
+		if b.Service == "" {
+			return errors.New("each backend requires a service")
+		}
+		weights = append(weights, b.Weight)
+	}
+	return validateWeights(weights)
+}
+
+// validateWeights checks that the weights are percentages that add up to 100
+func validateWeights(weights []int32) error {
+	total := int32(0)
+	for _, w := range weights {
+		if w < 0 || w > 100 {
+			return fmt.Errorf("weight %v must be between 0 and 100", w)
+		}
+		total += w
+	}
+	if len(weights) > 0 && total != 100 {
+		return fmt.Errorf("weights add up to %v, not 100", total)
+	}
+	return nil
+}
+
+// describeBackends describes the traffic split among the backends
+func describeBackends(backends []trafficBackend) string {
+	parts := []string{}
+	for _, b := range backends {
+		parts = append(parts, fmt.Sprintf("%v=%v%%", b, b.Weight))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// updateTrafficObject gets the object used for routing traffic, modifies it, and updates it,
+// retrying if the object was modified concurrently
+func updateTrafficObject(kd *KubeDriver, gvr schema.GroupVersionResource, namespace string, name string, modify func(obj *unstructured.Unstructured) error) error {
+	if kd.dynamicClient == nil {
+		e := errors.New("unable to get Kubernetes dynamic client")
+		log.Logger.Error(e)
+		return e
+	}
+	client := kd.dynamicClient.Resource(gvr).Namespace(namespace)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := modify(obj); err != nil {
+			return err
+		}
+		_, err = client.Update(context.Background(), obj, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		e := fmt.Errorf("unable to update %v %v/%v", strings.TrimSuffix(gvr.Resource, "s"), namespace, name)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/cli"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// createTrafficObject creates the object in the default namespace of a fake cluster
+func createTrafficObject(t *testing.T, gvr schema.GroupVersionResource, obj map[string]interface{}) {
+	*kd = *NewFakeKubeDriver(cli.New())
+	_, err := kd.dynamicClient.Resource(gvr).Namespace("default").Create(context.Background(), &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+}
+
+// getTrafficObject gets the object from the default namespace of the fake cluster
+func getTrafficObject(t *testing.T, gvr schema.GroupVersionResource, name string) map[string]interface{} {
+	obj, err := kd.dynamicClient.Resource(gvr).Namespace("default").Get(context.Background(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return obj.Object
+}
+
+func TestGatewayShiftTraffic(t *testing.T) {
+	os.Chdir(t.TempDir())
+	createTrafficObject(t, httpRouteGVR, map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1beta1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": "httpbin", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{map[string]interface{}{"name": "httpbin-v1", "port": int64(80)}},
+					"filters": []interface{}{
+						map[string]interface{}{"type": "RequestHeaderModifier", "requestHeaderModifier": map[string]interface{}{"remove": []interface{}{"x-debug"}}},
+						map[string]interface{}{"type": "RequestMirror", "requestMirror": map[string]interface{}{"backendRef": map[string]interface{}{"name": "httpbin-v0"}}},
+					},
+				},
+			},
+		},
+	})
+
+	port := uint32(80)
+	gt := &gatewayTask{
+		TaskMeta: TaskMeta{Task: StringPointer(GatewayTaskName)},
+		With: gatewayInputs{
+			HTTPRoute: "httpbin",
+			Backends: []trafficBackend{
+				{Service: "httpbin-v1", Port: &port, Weight: 90},
+				{Service: "httpbin-v2", Port: &port, Weight: 10},
+			},
+			Mirror: &trafficBackend{Service: "httpbin-v3", Port: &port},
+		},
+	}
+	assert.NoError(t, gt.run(&Experiment{Spec: []Task{gt}, Result: &ExperimentResult{}}))
+
+	rules, _, _ := unstructured.NestedSlice(getTrafficObject(t, httpRouteGVR, "httpbin"), "spec", "rules")
+	rule := rules[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "httpbin-v1", "port": int64(80), "weight": int64(90)},
+		map[string]interface{}{"name": "httpbin-v2", "port": int64(80), "weight": int64(10)},
+	}, rule["backendRefs"])
+	// the earlier mirror is replaced, and other filters are preserved
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "RequestHeaderModifier", "requestHeaderModifier": map[string]interface{}{"remove": []interface{}{"x-debug"}}},
+		map[string]interface{}{"type": "RequestMirror", "requestMirror": map[string]interface{}{"backendRef": map[string]interface{}{"name": "httpbin-v3", "port": int64(80)}}},
+	}, rule["filters"])
+
+	// missing rules are errors
+	gt.With.Rule = 1
+	assert.Error(t, gt.run(&Experiment{Spec: []Task{gt}, Result: &ExperimentResult{}}))
+}
+
+func TestLinkerdShiftTraffic(t *testing.T) {
+	os.Chdir(t.TempDir())
+	createTrafficObject(t, trafficSplitGVR, map[string]interface{}{
+		"apiVersion": "split.smi-spec.io/v1alpha2",
+		"kind":       "TrafficSplit",
+		"metadata":   map[string]interface{}{"name": "httpbin-split", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"service":  "httpbin",
+			"backends": []interface{}{map[string]interface{}{"service": "httpbin-v1", "weight": int64(100)}},
+		},
+	})
+
+	lt := &linkerdTask{
+		TaskMeta: TaskMeta{Task: StringPointer(LinkerdTaskName)},
+		With: linkerdInputs{
+			TrafficSplit: "httpbin-split",
+			Backends: []trafficBackend{
+				{Service: "httpbin-v1", Weight: 50},
+				{Service: "httpbin-v2", Weight: 50},
+			},
+		},
+	}
+	assert.NoError(t, lt.run(&Experiment{Spec: []Task{lt}, Result: &ExperimentResult{}}))
+
+	spec := getTrafficObject(t, trafficSplitGVR, "httpbin-split")["spec"].(map[string]interface{})
+	assert.Equal(t, "httpbin", spec["service"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"service": "httpbin-v1", "weight": int64(50)},
+		map[string]interface{}{"service": "httpbin-v2", "weight": int64(50)},
+	}, spec["backends"])
+
+	// missing traffic splits are errors
+	lt.With.TrafficSplit = "missing"
+	assert.Error(t, lt.run(&Experiment{Spec: []Task{lt}, Result: &ExperimentResult{}}))
+}
+
+func TestValidateBackends(t *testing.T) {
+	assert.NoError(t, validateBackends([]trafficBackend{{Service: "a", Weight: 30}, {Service: "b", Weight: 70}}))
+	assert.Error(t, validateBackends([]trafficBackend{{Service: "a", Weight: 30}, {Service: "b", Weight: 30}}))
+	assert.Error(t, validateBackends([]trafficBackend{{Service: "a", Weight: 100}, {Weight: 0}}))
+	assert.Error(t, validateBackends([]trafficBackend{{Service: "a", Weight: 120}, {Service: "b", Weight: -20}}))
+
+	lt := &linkerdTask{With: linkerdInputs{TrafficSplit: "split"}}
+	assert.Error(t, lt.validateInputs())
+	gt := &gatewayTask{With: gatewayInputs{HTTPRoute: "route", Rule: -1, Mirror: &trafficBackend{Service: "a"}}}
+	assert.Error(t, gt.validateInputs())
+}
@@ -11,13 +11,18 @@ var MajorMinor = "v0.11"
 
 // Version is the semantic version of Iter8 (with the `v` prefix)
 // Version is intended to be set using LDFLAGS at build time
-var Version = "v0.11.0"
+var Version = "v0.11.1"
 
 // int64Pointer takes an int64 as input, creates a new variable with the input value, and returns a pointer to the variable
 func int64Pointer(i int64) *int64 {
 	return &i
 }
 
+// int32Pointer takes an int32 as input, creates a new variable with the input value, and returns a pointer to the variable
+func int32Pointer(i int32) *int32 {
+	return &i
+}
+
 // intPointer takes an int as input, creates a new variable with the input value, and returns a pointer to the variable
 func intPointer(i int) *int {
 	return &i
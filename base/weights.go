@@ -0,0 +1,199 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	log "github.com/iter8-tools/iter8/base/log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// WeightsAnnotation is the annotation with the recommended traffic weights of versions, as a JSON list of percentages;
+	// for example, [80,20]. Progressive rollout controllers may shift traffic accordingly.
+	WeightsAnnotation = "iter8.tools/weights"
+	// defaultWeightStep is the default increase in the recommended weight of the best version
+	defaultWeightStep = int32(100)
+)
+
+// Rewards are metrics that are optimized. Versions that satisfy SLOs are compared by their rewards, in order;
+// metrics to maximize are compared before metrics to minimize.
+type Rewards struct {
+	// Max are metrics whose larger values are better
+	Max []string `json:"max,omitempty" yaml:"max,omitempty"`
+	// Min are metrics whose smaller values are better
+	Min []string `json:"min,omitempty" yaml:"min,omitempty"`
+}
+
+// weightsInputs configure the recommended traffic weights of versions
+type weightsInputs struct {
+	// Step is the increase in the recommended weight of the best version, each time that versions are assessed,
+	// for progressive rollouts; the remaining traffic is recommended for the baseline (version 0).
+	// Optional. Default is 100; the best version is recommended all traffic.
+	Step *int32 `json:"step,omitempty" yaml:"step,omitempty"`
+	// Annotate is the Kubernetes object annotated with the recommended weights. Optional.
+	Annotate *weightsTarget `json:"annotate,omitempty" yaml:"annotate,omitempty"`
+}
+
+// weightsTarget identifies the Kubernetes object annotated with the recommended weights;
+// for example, an Argo Rollout or the VirtualService of an Istio operator
+type weightsTarget struct {
+	// Group of the object. Optional. If unspecified it will be defaulted to ""
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+	// Version of the object. Optional. If unspecified it will be defaulted to ""
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Resource type of the object. Required.
+	Resource string `json:"resource" yaml:"resource"`
+	// Namespace of the object. Optional. If left unspecified, this will be defaulted to the namespace of the experiment
+	Namespace *string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Name of the object. Required.
+	Name string `json:"name" yaml:"name"`
+	// KubeConfig is the path to the kubeconfig file of the cluster containing the object. Optional.
+	// If unspecified, the object is looked up in the cluster in which the experiment runs
+	KubeConfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster containing the object. Optional.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// validateWeightsInputs validates the inputs of weight recommendations
+func validateWeightsInputs(w *weightsInputs) error {
+	if w == nil {
+		return nil
+	}
+	if w.Step != nil && (*w.Step <= 0 || *w.Step > 100) {
+		return fmt.Errorf("weight step %v must be between 1 and 100", *w.Step)
+	}
+	if w.Annotate != nil && (w.Annotate.Resource == "" || w.Annotate.Name == "") {
+		return errors.New("weights annotation requires a resource and a name")
+	}
+	return nil
+}
+
+// bestVersion returns the version that satisfies SLOs and has the best rewards, or -1 if no version satisfies SLOs.
+// Ties, including when there are no rewards, are broken in favor of later versions, as in Winner().
+func bestVersion(exp *Experiment, rewards *Rewards) int {
+	best := -1
+	for _, j := range exp.getSLOsSatisfiedBy() {
+		if best < 0 || compareRewards(exp, rewards, j, best) >= 0 {
+			best = j
+		}
+	}
+	return best
+}
+
+// compareRewards returns a positive number if version i has better rewards than version j,
+// a negative number if it has worse rewards, and zero if they are equal. Missing rewards are worse than any value.
+func compareRewards(exp *Experiment, rewards *Rewards, i int, j int) int {
+	if rewards == nil {
+		return 0
+	}
+	for _, m := range rewards.Max {
+		if c := compareValues(exp.Metric(m, i), exp.Metric(m, j)); c != 0 {
+			return c
+		}
+	}
+	for _, m := range rewards.Min {
+		if c := compareValues(exp.Metric(m, j), exp.Metric(m, i)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues returns a positive number if a is larger than b, a negative number if it is smaller, and zero otherwise;
+// NaN is smaller than any value
+func compareValues(a float64, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// recommendWeights returns the recommended traffic weights of versions. The weight of the best version is increased
+// by step from its previous recommendation, and the remaining traffic is recommended for the baseline (version 0).
+// If no candidate version is best, all traffic is recommended for the baseline.
+func recommendWeights(numVersions int, best int, step int32, previous []int32) []int32 {
+	weights := make([]int32, numVersions)
+	if numVersions == 0 {
+		return weights
+	}
+	if best <= 0 {
+		weights[0] = 100
+		return weights
+	}
+	w := step
+	if len(previous) == numVersions {
+		w += previous[best]
+	}
+	if w > 100 {
+		w = 100
+	}
+	weights[best] = w
+	weights[0] = 100 - w
+	return weights
+}
+
+// recordWeights recommends traffic weights of versions, records them in insights, and annotates the target object with them
+func (t *assessTask) recordWeights(exp *Experiment) error {
+	step := defaultWeightStep
+	if t.With.Weights.Step != nil {
+		step = *t.With.Weights.Step
+	}
+	in := exp.Result.Insights
+	best := bestVersion(exp, t.With.Rewards)
+	in.Weights = recommendWeights(in.NumVersions, best, step, in.Weights)
+	log.Logger.Infof("recommended weights: %v", in.Weights)
+
+	if t.With.Weights.Annotate == nil {
+		return nil
+	}
+	return annotateWeights(t.With.Weights.Annotate, in.Weights)
+}
+
+// recommendedWeights returns the traffic weights of versions recommended by the latest assessment;
+// the assess task must recommend weights for the given number of versions before this is called
+func recommendedWeights(exp *Experiment, numVersions int) ([]int32, error) {
+	if exp.Result == nil || exp.Result.Insights == nil || len(exp.Result.Insights.Weights) == 0 {
+		return nil, errors.New("no recommended weights; an earlier assess task must recommend weights")
+	}
+	weights := exp.Result.Insights.Weights
+	if len(weights) != numVersions {
+		return nil, fmt.Errorf("weights are recommended for %v versions, not %v", len(weights), numVersions)
+	}
+	return weights, nil
+}
+
+// annotateWeights annotates the target object with the recommended weights
+func annotateWeights(target *weightsTarget, weights []int32) error {
+	kd := targetDriver(target.KubeConfig, target.Context)
+	kd.initKube()
+	ns := kd.Namespace()
+	if target.Namespace != nil {
+		ns = *target.Namespace
+	}
+	b, _ := json.Marshal(weights)
+	gvr := schema.GroupVersionResource{Group: target.Group, Version: target.Version, Resource: target.Resource}
+	return updateTrafficObject(kd, gvr, ns, target.Name, func(obj *unstructured.Unstructured) error {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[WeightsAnnotation] = string(b)
+		obj.SetAnnotations(annotations)
+		return nil
+	})
+}
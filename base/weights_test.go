@@ -0,0 +1,123 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newWeightsExperiment returns an experiment with three versions, and values of the metrics a/latency and a/conversions
+func newWeightsExperiment(task *assessTask) *Experiment {
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	exp.Result.initInsightsWithNumVersions(3)
+	exp.Result.Insights.MetricsInfo = map[string]MetricMeta{
+		"a/latency":     {Type: GaugeMetricType},
+		"a/conversions": {Type: GaugeMetricType},
+	}
+	for j, v := range [][]float64{{100, 10}, {150, 30}, {300, 20}} {
+		exp.Result.Insights.NonHistMetricValues[j]["a/latency"] = []float64{v[0]}
+		exp.Result.Insights.NonHistMetricValues[j]["a/conversions"] = []float64{v[1]}
+	}
+	return exp
+}
+
+func TestRecommendWeights(t *testing.T) {
+	os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			SLOs: &SLOLimits{
+				Upper: []SLO{{Metric: "a/latency", Limit: 200}},
+			},
+			Rewards: &Rewards{Max: []string{"a/conversions"}},
+			Weights: &weightsInputs{Step: int32Pointer(40)},
+		},
+	}
+	exp := newWeightsExperiment(task)
+
+	// the version with the most conversions among those that satisfy SLOs is recommended more traffic in each assessment
+	assert.NoError(t, task.run(exp))
+	assert.Equal(t, []int32{60, 40, 0}, exp.Result.Insights.Weights)
+	assert.Equal(t, task.With.Rewards, exp.Result.Insights.Rewards)
+	assert.NoError(t, task.run(exp))
+	assert.Equal(t, []int32{20, 80, 0}, exp.Result.Insights.Weights)
+	assert.NoError(t, task.run(exp))
+	assert.Equal(t, []int32{0, 100, 0}, exp.Result.Insights.Weights)
+
+	// all traffic is recommended for the baseline if the candidates do not satisfy SLOs
+	task.With.SLOs.Upper[0].Limit = 120
+	assert.NoError(t, task.run(exp))
+	assert.Equal(t, []int32{100, 0, 0}, exp.Result.Insights.Weights)
+
+	// without rewards, the latest version that satisfies SLOs is best
+	task.With.SLOs = nil
+	exp.Result.Insights.SLOs = nil
+	task.With.Rewards = nil
+	task.With.Weights.Step = nil
+	assert.NoError(t, task.run(exp))
+	assert.Equal(t, []int32{0, 0, 100}, exp.Result.Insights.Weights)
+
+	// steps are percentages
+	task.With.Weights.Step = int32Pointer(120)
+	assert.Error(t, task.run(exp))
+}
+
+func TestCompareRewards(t *testing.T) {
+	exp := newWeightsExperiment(&assessTask{})
+	// larger values are better for rewards to maximize, and smaller values for rewards to minimize
+	assert.Equal(t, 1, compareRewards(exp, &Rewards{Max: []string{"a/conversions"}}, 1, 0))
+	assert.Equal(t, -1, compareRewards(exp, &Rewards{Min: []string{"a/latency"}}, 1, 0))
+	// missing rewards are worse than any value
+	assert.Equal(t, -1, compareRewards(exp, &Rewards{Max: []string{"a/missing", "a/conversions"}}, 0, 1))
+	assert.Equal(t, 1, compareRewards(exp, &Rewards{Min: []string{"a/missing"}, Max: []string{"a/conversions"}}, 1, 0))
+	assert.Equal(t, 0, compareRewards(exp, nil, 0, 1))
+
+	assert.Equal(t, 1, bestVersion(exp, &Rewards{Max: []string{"a/conversions"}}))
+	assert.Equal(t, 0, bestVersion(exp, &Rewards{Min: []string{"a/latency"}}))
+	assert.Equal(t, 2, bestVersion(exp, nil))
+}
+
+func TestAnnotateWeights(t *testing.T) {
+	os.Chdir(t.TempDir())
+	newVirtualService(t)
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			Rewards: &Rewards{Min: []string{"a/latency"}},
+			Weights: &weightsInputs{
+				Annotate: &weightsTarget{
+					Group:    virtualServiceGVR.Group,
+					Version:  virtualServiceGVR.Version,
+					Resource: virtualServiceGVR.Resource,
+					Name:     "httpbin",
+				},
+			},
+		},
+	}
+	exp := newWeightsExperiment(task)
+	exp.Result.Insights.NonHistMetricValues[0]["a/latency"] = []float64{200}
+	assert.NoError(t, task.run(exp))
+
+	obj, err := kd.dynamicClient.Resource(virtualServiceGVR).Namespace("default").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "[0,100,0]", obj.GetAnnotations()[WeightsAnnotation])
+
+	// the annotated object must exist
+	task.With.Weights.Annotate.Name = "missing"
+	assert.Error(t, task.run(exp))
+
+	// the annotated object requires a name
+	task.With.Weights.Annotate.Name = ""
+	assert.Error(t, task.validateInputs())
+}
@@ -4,23 +4,100 @@
 {{- end }}
 spec:
   {{- range .Values.tasks }}
-  {{- if eq "assess" . }}
-  {{- include "task.assess" $.Values.assess -}}
-  {{- else if eq "custommetrics" . }}
-  {{- include "task.custommetrics" $.Values.custommetrics -}}
-  {{- else if eq "grpc" . }}
-  {{- include "task.grpc" $.Values.grpc -}}
-  {{- else if eq "http" . }}
-  {{- include "task.http" $.Values.http -}}
-  {{- else if eq "ready" . }}
-  {{- include "task.ready" $ -}}
+  {{- if kindIs "slice" . }}
+  {{- include "task.parallel" (dict "tasks" . "root" $) -}}
   {{- else }}
-  {{- fail "task name must be one of assess, custommetrics, grpc, http, or ready" -}}
+  {{- include "task" (dict "name" . "root" $) -}}
   {{- end }}
   {{- end }}
+{{- with .Values.hooks }}
+hooks:
+  {{- range $hook := list "onStart" "onSuccess" "onFailure" }}
+  {{- with index $.Values.hooks $hook }}
+  {{ $hook }}:
+    {{- range . }}
+    {{- if kindIs "slice" . }}
+    {{- include "task.parallel" (dict "tasks" . "root" $) | trim | nindent 4 }}
+    {{- else }}
+    {{- include "task" (dict "name" . "root" $) | trim | nindent 4 }}
+    {{- end }}
+    {{- end }}
+  {{- end }}
+  {{- end }}
+{{- end }}
+{{- with .Values.background }}
+background:
+{{ toYaml . | indent 2 }}
+{{- end }}
+{{- with .Values.loop }}
+loop:
+  {{- if .interval }}
+  interval: {{ .interval | quote }}
+  {{- end }}
+  {{- if .maxLoops }}
+  maxLoops: {{ .maxLoops }}
+  {{- end }}
+  {{- if .stopOnSLOViolation }}
+  stopOnSLOViolation: {{ .stopOnSLOViolation }}
+  {{- end }}
+{{- end }}
+{{- with .Values.metrics }}
+metrics:
+{{ toYaml . | indent 2 }}
+{{- end }}
 result:
   startTime:         {{ now | toJson }}
   numCompletedTasks: 0
   failure:           false
   iter8Version:      {{ .Values.majorMinor }}
-{{- end }}
\ No newline at end of file
+{{- end }}
+{{- define "task" -}}
+{{- $root := .root }}
+{{- with .name }}
+{{- if eq "annotate" . }}
+{{- include "task.annotate" $root.Values.annotate -}}
+{{- else if eq "assess" . }}
+{{- include "task.assess" $root.Values.assess -}}
+{{- else if eq "compare" . }}
+{{- include "task.compare" $root.Values.compare -}}
+{{- else if eq "custommetrics" . }}
+{{- include "task.custommetrics" $root.Values.custommetrics -}}
+{{- else if eq "email" . }}
+{{- include "task.email" $root.Values.email -}}
+{{- else if eq "discover" . }}
+{{- include "task.discover" $root.Values.discover -}}
+{{- else if eq "grpc" . }}
+{{- $grpc := $root.Values.grpc }}
+{{- /* the gRPC host defaults to the first discovered endpoint */}}
+{{- if and $root.Values.discover $root.Values.discover.endpoints (not (default dict $grpc).host) }}
+{{- $grpc = merge (dict "host" (include "discover.target" (dict "discover" $root.Values.discover "suffix" "Host"))) (default dict $grpc) }}
+{{- end }}
+{{- include "task.grpc" $grpc -}}
+{{- else if eq "helm" . }}
+{{- include "task.helm" $root.Values.helm -}}
+{{- else if eq "http" . }}
+{{- $http := $root.Values.http }}
+{{- /* the HTTP URL defaults to the first discovered endpoint */}}
+{{- if and $root.Values.discover $root.Values.discover.endpoints (not (default dict $http).url) }}
+{{- $http = merge (dict "url" (include "discover.target" (dict "discover" $root.Values.discover "suffix" "URL"))) (default dict $http) }}
+{{- end }}
+{{- include "task.http" $http -}}
+{{- else if eq "ingest" . }}
+{{- include "task.ingest" $root.Values.ingest -}}
+{{- else if eq "label" . }}
+{{- include "task.label" $root -}}
+{{- else if or (eq "promote" .) (eq "rollback" .) }}
+{{- include "task.manifests" (dict "task" . "values" (index $root.Values .)) -}}
+{{- else if or (eq "gateway" .) (eq "istio" .) (eq "knative" .) (eq "kserve" .) (eq "linkerd" .) (eq "seldon" .) }}
+{{- include "task.traffic" (dict "task" . "values" (index $root.Values .)) -}}
+{{- else if eq "ready" . }}
+{{- include "task.ready" $root -}}
+{{- else if eq "soak" . }}
+{{- include "task.soak" $root.Values.soak -}}
+{{- else if and $root.Values.plugins (hasKey $root.Values.plugins .) }}
+{{- include "task.plugin" (dict "task" . "values" (index $root.Values.plugins .)) -}}
+{{- else }}
+{{- fail "task name must be one of annotate, assess, compare, custommetrics, discover, email, gateway, grpc, helm, http, ingest, istio, knative, kserve, label, linkerd, promote, ready, rollback, seldon, or soak, or a plugin task in plugins" -}}
+{{- end }}
+{{- end }}
+{{- end }}
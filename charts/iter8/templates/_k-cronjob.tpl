@@ -3,6 +3,8 @@ apiVersion: batch/v1
 kind: CronJob
 metadata:
   name: {{ .Release.Name }}-{{ .Release.Revision }}-cronjob
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
   annotations:
     iter8.tools/group: {{ .Release.Name }}
     iter8.tools/revision: {{ .Release.Revision | quote }}
@@ -14,20 +16,35 @@ spec:
       template:
         metadata:
           labels:
-            iter8.tools/group: {{ .Release.Name }}
+            {{- include "k.labels" . | nindent 12 }}
           annotations:
             sidecar.istio.io/inject: "false"
         spec:
-          serviceAccountName: {{ .Release.Name }}-iter8-sa
+          serviceAccountName: {{ include "k.serviceaccount.name" . }}
+          {{- with include "k.pod.options" . }}{{ . | trim | nindent 10 }}{{ end }}
           containers:
           - name: iter8
             image: {{ .Values.iter8Image }}
             imagePullPolicy: Always
+            {{- with include "k.container.resources" . }}{{ . | trim | nindent 12 }}{{ end }}
+            {{- with include "k.container.env" . }}{{ . | trim | nindent 12 }}{{ end }}
+            {{- if .Values.kubeconfigSecret }}
+            volumeMounts:
+            - name: kubeconfig
+              mountPath: /etc/iter8/kubeconfig
+              readOnly: true
+            {{- end }}
             command:
             - "/bin/sh"
             - "-c"
             - |
-              iter8 k run --namespace {{ .Release.Namespace }} --group {{ .Release.Name }} -l {{ .Values.logLevel }} --reuseResult
+              iter8 k run --namespace {{ .Release.Namespace }} --group {{ .Release.Name }} --revision {{ .Release.Revision }} -l {{ .Values.logLevel }} --output {{ .Values.logOutput }}{{ include "k.results.flags" . }} --reuseResult
+          {{- if .Values.kubeconfigSecret }}
+          volumes:
+          - name: kubeconfig
+            secret:
+              secretName: {{ .Values.kubeconfigSecret }}
+          {{- end }}
           restartPolicy: Never
       backoffLimit: 0
 {{- end }}
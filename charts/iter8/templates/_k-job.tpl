@@ -3,6 +3,8 @@ apiVersion: batch/v1
 kind: Job
 metadata:
   name: {{ .Release.Name }}-{{ .Release.Revision }}-job
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
   annotations:
     iter8.tools/group: {{ .Release.Name }}
     iter8.tools/revision: {{ .Release.Revision | quote }}
@@ -10,20 +12,97 @@ spec:
   template:
     metadata:
       labels:
-        iter8.tools/group: {{ .Release.Name }}
+        {{- include "k.labels" . | nindent 8 }}
       annotations:
         sidecar.istio.io/inject: "false"
     spec:
-      serviceAccountName: {{ .Release.Name }}-iter8-sa
+      serviceAccountName: {{ include "k.serviceaccount.name" . }}
+      {{- with include "k.pod.options" . }}{{ . | trim | nindent 6 }}{{ end }}
       containers:
       - name: iter8
         image: {{ .Values.iter8Image }}
         imagePullPolicy: Always
+        {{- with include "k.container.resources" . }}{{ . | trim | nindent 8 }}{{ end }}
+        {{- with include "k.container.env" . }}{{ . | trim | nindent 8 }}{{ end }}
+        {{- if .Values.kubeconfigSecret }}
+        volumeMounts:
+        - name: kubeconfig
+          mountPath: /etc/iter8/kubeconfig
+          readOnly: true
+        {{- end }}
         command:
         - "/bin/sh"
         - "-c"
         - |
-          iter8 k run --namespace {{ .Release.Namespace }} --group {{ .Release.Name }} -l {{ .Values.logLevel }}
+          iter8 k run --namespace {{ .Release.Namespace }} --group {{ .Release.Name }} --revision {{ .Release.Revision }} -l {{ .Values.logLevel }} --output {{ .Values.logOutput }}{{ include "k.results.flags" . }}
+      {{- if .Values.kubeconfigSecret }}
+      volumes:
+      - name: kubeconfig
+        secret:
+          secretName: {{ .Values.kubeconfigSecret }}
+      {{- end }}
       restartPolicy: Never
   backoffLimit: 0
 {{- end }}
+
+{{- define "k.results.flags" -}}
+{{- if and .Values.storage (ne "secret" .Values.storage) }} --storage {{ .Values.storage }}{{ end }}
+{{- if .Values.results }}
+{{- if .Values.results.kubeconfig }} --resultKubeconfig {{ .Values.results.kubeconfig }}{{ end }}
+{{- if .Values.results.context }} --resultContext {{ .Values.results.context }}{{ end }}
+{{- if .Values.results.namespace }} --resultNamespace {{ .Values.results.namespace }}{{ end }}
+{{- end }}
+{{- end }}
+
+
+{{- define "k.serviceaccount.name" -}}
+{{- if and .Values.job .Values.job.serviceAccount }}{{ .Values.job.serviceAccount }}{{ else }}{{ .Release.Name }}-iter8-sa{{ end }}
+{{- end }}
+
+{{- define "k.pod.options" -}}
+{{- with .Values.job }}
+{{- with .nodeSelector }}
+nodeSelector:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .tolerations }}
+tolerations:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .imagePullSecrets }}
+imagePullSecrets:
+{{- range . }}
+- name: {{ . }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "k.container.env" -}}
+{{- $email := and .Values.email .Values.email.passwordSecret }}
+{{- $annotate := and .Values.annotate .Values.annotate.apiKeySecret }}
+{{- if or $email $annotate }}
+env:
+{{- if $email }}
+- name: ITER8_SMTP_PASSWORD
+  valueFrom:
+    secretKeyRef:
+      name: {{ .Values.email.passwordSecret }}
+      key: password
+{{- end }}
+{{- if $annotate }}
+- name: ITER8_ANNOTATE_API_KEY
+  valueFrom:
+    secretKeyRef:
+      name: {{ .Values.annotate.apiKeySecret }}
+      key: apiKey
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "k.container.resources" -}}
+{{- if and .Values.job .Values.job.resources }}
+resources:
+  {{- toYaml .Values.job.resources | nindent 2 }}
+{{- end }}
+{{- end }}
@@ -0,0 +1,9 @@
+{{- define "k.labels" -}}
+app.kubernetes.io/managed-by: iter8
+iter8.tools/group: {{ .Release.Name }}
+iter8.tools/revision: {{ .Release.Revision | quote }}
+iter8.tools/chart: {{ printf "%s-%s" .Chart.Name .Chart.Version | replace "+" "_" | trunc 63 | quote }}
+{{- with .Values.owner }}
+iter8.tools/owner: {{ . | quote }}
+{{- end }}
+{{- end }}
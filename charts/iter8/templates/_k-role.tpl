@@ -3,13 +3,46 @@ apiVersion: rbac.authorization.k8s.io/v1
 kind: Role
 metadata:
   name: {{ .Release.Name }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
   annotations:
     iter8.tools/group: {{ .Release.Name }}
 rules:
+{{- $storage := default "secret" .Values.storage }}
+{{- if eq "cr" $storage }}
+- apiGroups: ["iter8.tools"]
+  resourceNames: [{{ .Release.Name | quote }}]
+  resources: ["experiments"]
+  verbs: ["get", "update"]
+{{- else }}
 - apiGroups: [""]
   resourceNames: [{{ .Release.Name | quote }}]
-  resources: ["secrets"]
+  resources: [{{ printf "%ss" $storage | quote }}]
   verbs: ["get", "update"]
+{{- /* large experiments are split across objects named <release>-part-<i> */}}
+- apiGroups: [""]
+  resourceNames:
+  {{- range $i := untilStep 1 8 1 }}
+  - {{ printf "%s-part-%d" $.Release.Name $i | quote }}
+  {{- end }}
+  resources: [{{ printf "%ss" $storage | quote }}]
+  verbs: ["get", "update", "delete"]
+- apiGroups: [""]
+  resources: [{{ printf "%ss" $storage | quote }}]
+  verbs: ["create"]
+{{- end }}
+{{- /* runs of the experiment group hold a lease, so that they do not run concurrently */}}
+- apiGroups: ["coordination.k8s.io"]
+  resourceNames: [{{ printf "%s-lock" .Release.Name | quote }}]
+  resources: ["leases"]
+  verbs: ["get", "update", "delete"]
+- apiGroups: ["coordination.k8s.io"]
+  resources: ["leases"]
+  verbs: ["create"]
+{{- /* milestones of the experiment are recorded as events of the object that stores it */}}
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create"]
 {{- if .Values.ready }}
 ---
 {{- $namespace := coalesce .Values.ready.namespace .Release.Namespace }}
@@ -19,6 +52,8 @@ kind: Role
 metadata:
   name: {{ .Release.Name }}-ready
   namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
   annotations:
     iter8.tools/group: {{ .Release.Name }}
 rules:
@@ -34,6 +69,207 @@ rules:
   resources: ["deployments"]
   verbs: ["get"]
 {{- end }}
+{{- if .Values.ready.ksvc }}
+- apiGroups: ["serving.knative.dev"]
+  resourceNames: [{{ .Values.ready.ksvc | quote }}]
+  resources: ["services"]
+  verbs: ["get"]
+{{- end }}
+{{- with .Values.ready.revisions }}
+- apiGroups: ["serving.knative.dev"]
+  resourceNames:
+  {{- range . }}
+  - {{ . | quote }}
+  {{- end }}
+  resources: ["revisions"]
+  verbs: ["get"]
+{{- end }}
+{{- range .Values.ready.resources }}
+- apiGroups: [{{ default "" .group | quote }}]
+  resourceNames: [{{ .name | quote }}]
+  resources: [{{ .resource | quote }}]
+  verbs: ["get"]
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Values.label }}
+---
+{{- $namespace := coalesce .Values.label.namespace .Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ .Release.Name }}-label
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+rules:
+{{- /* the label task labels and annotates the tested workloads */}}
+{{- if .Values.label.service }}
+- apiGroups: [""]
+  resourceNames: [{{ .Values.label.service | quote }}]
+  resources: ["services"]
+  verbs: ["get", "update"]
+{{- end }}
+{{- if .Values.label.deploy }}
+- apiGroups: ["apps"]
+  resourceNames: [{{ .Values.label.deploy | quote }}]
+  resources: ["deployments"]
+  verbs: ["get", "update"]
+{{- end }}
+{{- range .Values.label.resources }}
+- apiGroups: [{{ default "" .group | quote }}]
+  resourceNames: [{{ .name | quote }}]
+  resources: [{{ .resource | quote }}]
+  verbs: ["get", "update"]
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Values.discover }}
+---
+{{- $namespace := coalesce .Values.discover.namespace .Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ .Release.Name }}-discover
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+rules:
+{{- /* endpoints are discovered by listing objects by their labels */}}
+- apiGroups: [""]
+  resources: ["services"]
+  verbs: ["list"]
+- apiGroups: ["apps"]
+  resources: ["deployments"]
+  verbs: ["list"]
+- apiGroups: ["networking.k8s.io"]
+  resources: ["ingresses"]
+  verbs: ["list"]
+{{- /* URLs of Knative Revisions are found in the traffic status of their Knative Service */}}
+{{- $revisions := false }}
+{{- range .Values.discover.endpoints }}
+{{- if eq "Revision" (default "" .kind) }}
+{{- $revisions = true }}
+{{- end }}
+{{- end }}
+{{- if $revisions }}
+- apiGroups: ["serving.knative.dev"]
+  resources: ["revisions"]
+  verbs: ["list"]
+- apiGroups: ["serving.knative.dev"]
+  resources: ["services"]
+  verbs: ["get"]
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Values.soak }}
+---
+{{- $namespace := coalesce .Values.soak.namespace .Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ .Release.Name }}-soak
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+rules:
+{{- /* the memory and CPU usage of pods are sampled from the metrics server */}}
+- apiGroups: ["metrics.k8s.io"]
+  resources: ["pods"]
+  verbs: ["list"]
+{{- end }}
+{{- end }}
+{{- /* traffic shifting tasks get and update their Kubernetes objects */}}
+{{- range $task, $object := include "traffic.objects" . | fromYaml }}
+{{- with index $.Values $task }}
+---
+{{- $namespace := coalesce .namespace $.Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ $.Release.Name }}-{{ $task }}
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" $ | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ $.Release.Name }}
+rules:
+- apiGroups: [{{ $object.group | quote }}]
+  resourceNames: [{{ get . $object.value | quote }}]
+  resources: [{{ $object.resource | quote }}]
+  verbs: ["get", "update"]
+{{- end }}
+{{- end }}
+{{- end }}
+{{- /* promote and rollback tasks apply or delete the objects of their manifests and patch objects */}}
+{{- range $task, $_ := include "manifests.tasks" . | fromYaml }}
+{{- with index $.Values $task }}
+---
+{{- $namespace := coalesce .namespace $.Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ $.Release.Name }}-{{ $task }}
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" $ | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ $.Release.Name }}
+rules:
+{{- include "manifests.rules" . }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Values.helm }}
+---
+{{- $namespace := coalesce .Values.helm.namespace .Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ .Release.Name }}-helm
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+rules:
+{{- /* Helm stores releases in secrets; access to the resources of the release must be granted separately */}}
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: ["get", "list", "create", "update", "delete"]
+{{- end }}
+{{- end }}
+{{- /* the assess task annotates an object with recommended weights */}}
+{{- with (and .Values.assess .Values.assess.weights .Values.assess.weights.annotate) }}
+---
+{{- $namespace := coalesce .namespace $.Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ $.Release.Name }}-weights
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" $ | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ $.Release.Name }}
+rules:
+- apiGroups: [{{ default "" .group | quote }}]
+  resourceNames: [{{ .name | quote }}]
+  resources: [{{ .resource | quote }}]
+  verbs: ["get", "update"]
 {{- end }}
 {{- end }}
 {{- end }}
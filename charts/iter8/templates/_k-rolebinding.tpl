@@ -3,11 +3,13 @@ apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
 metadata:
   name: {{ .Release.Name }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
   annotations:
     iter8.tools/group: {{ .Release.Name }}
 subjects:
 - kind: ServiceAccount
-  name: {{ .Release.Name }}-iter8-sa
+  name: {{ include "k.serviceaccount.name" . }}
   namespace: {{ .Release.Namespace }}
 roleRef:
   kind: Role
@@ -22,11 +24,13 @@ kind: RoleBinding
 metadata:
   name: {{ .Release.Name }}-ready
   namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
   annotations:
     iter8.tools/group: {{ .Release.Name }}
 subjects:
 - kind: ServiceAccount
-  name: {{ .Release.Name }}-iter8-sa
+  name: {{ include "k.serviceaccount.name" . }}
   namespace: {{ .Release.Namespace }}
 roleRef:
   kind: Role
@@ -34,4 +38,76 @@ roleRef:
   apiGroup: rbac.authorization.k8s.io
 {{- end }}
 {{- end }}
+{{- if .Values.label }}
+---
+{{- $namespace := coalesce .Values.label.namespace .Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: {{ .Release.Name }}-label
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+subjects:
+- kind: ServiceAccount
+  name: {{ include "k.serviceaccount.name" . }}
+  namespace: {{ .Release.Namespace }}
+roleRef:
+  kind: Role
+  name: {{ .Release.Name }}-label
+  apiGroup: rbac.authorization.k8s.io
+{{- end }}
+{{- end }}
+{{- /* tasks that access objects in other namespaces have their own roles */}}
+{{- range $task := list "discover" "gateway" "helm" "istio" "knative" "kserve" "linkerd" "promote" "rollback" "seldon" "soak" }}
+{{- with index $.Values $task }}
+---
+{{- $namespace := coalesce .namespace $.Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: {{ $.Release.Name }}-{{ $task }}
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" $ | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ $.Release.Name }}
+subjects:
+- kind: ServiceAccount
+  name: {{ include "k.serviceaccount.name" $ }}
+  namespace: {{ $.Release.Namespace }}
+roleRef:
+  kind: Role
+  name: {{ $.Release.Name }}-{{ $task }}
+  apiGroup: rbac.authorization.k8s.io
+{{- end }}
+{{- end }}
+{{- end }}
+{{- with (and .Values.assess .Values.assess.weights .Values.assess.weights.annotate) }}
+---
+{{- $namespace := coalesce .namespace $.Release.Namespace }}
+{{- if $namespace }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: {{ $.Release.Name }}-weights
+  namespace: {{ $namespace }}
+  labels:
+    {{- include "k.labels" $ | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ $.Release.Name }}
+subjects:
+- kind: ServiceAccount
+  name: {{ include "k.serviceaccount.name" $ }}
+  namespace: {{ $.Release.Namespace }}
+roleRef:
+  kind: Role
+  name: {{ $.Release.Name }}-weights
+  apiGroup: rbac.authorization.k8s.io
+{{- end }}
+{{- end }}
 {{- end }}
@@ -1,11 +1,44 @@
 {{- define "k.secret" -}}
+{{- $storage := default "secret" .Values.storage }}
+{{- /* experiments run by the controller are Experiment custom resources */}}
+{{- if eq "controller" .Values.runner }}
+{{- $storage = "cr" }}
+{{- end }}
+{{- if eq "secret" $storage }}
 apiVersion: v1
 kind: Secret
 metadata:
   name: {{ .Release.Name }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
   annotations:
     iter8.tools/group: {{ .Release.Name }}
 stringData:
   experiment.yaml: |
 {{ include "experiment" . | indent 4 }}
+{{- else if eq "configmap" $storage }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+data:
+  experiment.yaml: |
+{{ include "experiment" . | indent 4 }}
+{{- else if eq "cr" $storage }}
+apiVersion: iter8.tools/v1alpha1
+kind: Experiment
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+{{ include "experiment" . }}
+{{- else }}
+{{- fail "storage must be one of secret, configmap, or cr" }}
+{{- end }}
 {{- end }}
\ No newline at end of file
@@ -0,0 +1,18 @@
+{{- define "k.service" -}}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Release.Name }}-ingest
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+spec:
+  selector:
+    app.kubernetes.io/managed-by: iter8
+    iter8.tools/group: {{ .Release.Name }}
+  ports:
+  - name: http
+    port: {{ default 8090 .Values.ingest.port }}
+    targetPort: {{ default 8090 .Values.ingest.port }}
+{{- end }}
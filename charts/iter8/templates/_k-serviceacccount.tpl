@@ -3,4 +3,8 @@ apiVersion: v1
 kind: ServiceAccount
 metadata:
   name: {{ .Release.Name }}-iter8-sa
+  labels:
+    {{- include "k.labels" . | nindent 4 }}
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
 {{- end }}
@@ -0,0 +1,20 @@
+{{- define "task.annotate" -}}
+{{- /* Validate values */ -}}
+{{- if not . }}
+{{- fail "annotate values object is nil" }}
+{{- end }}
+{{- if not .provider }}
+  {{- fail "please specify the provider of events; datadog or grafana" }}
+{{- end }}
+{{- $vals := mustDeepCopy . }}
+{{- /* API key is read from the environment of the Kubernetes job */ -}}
+{{- if $vals.apiKeySecret }}
+{{- $_ := unset $vals "apiKeySecret" }}
+{{- $_ := set $vals "apiKeyEnv" "ITER8_ANNOTATE_API_KEY" }}
+{{- end }}
+# task: post the experiment milestone as a Datadog event or Grafana annotation
+# this task runs even if an earlier task has failed
+- task: annotate
+  with:
+{{ toYaml $vals | indent 4 }}
+{{- end }}
@@ -21,5 +21,16 @@
 {{- end }}
 {{- end }}
 {{- end }}
+{{- if .onMissingMetric }}
+    onMissingMetric: {{ .onMissingMetric }}
+{{- end }}
+{{- with .rewards }}
+    rewards:
+      {{- toYaml . | trim | nindent 6 }}
+{{- end }}
+{{- with .weights }}
+    weights:
+      {{- toYaml . | trim | nindent 6 }}
+{{- end }}
 {{- end }}
 {{- end }}
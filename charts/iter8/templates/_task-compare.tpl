@@ -0,0 +1,14 @@
+{{- define "task.compare" -}}
+{{- /* Validate values */ -}}
+{{- if not . }}
+{{- fail "compare values object is nil" }}
+{{- end }}
+{{- if lt (len .urls) 2 }}
+  {{- fail "please specify the urls of a baseline and at least one other version" }}
+{{- end }}
+# task: send identical requests to all versions and compare their responses with those of the baseline
+# collect Iter8's built-in mismatch metrics
+- task: compare
+  with:
+{{ toYaml . | indent 4 }}
+{{- end }}
@@ -0,0 +1,27 @@
+{{- define "task.discover" -}}
+{{- /* Validate values */ -}}
+{{- if not . }}
+{{- fail "discover values object is nil" }}
+{{- end }}
+{{- if not .endpoints }}
+  {{- fail "please specify the endpoints to discover" }}
+{{- end }}
+{{- range .endpoints }}
+{{- if not .name }}
+  {{- fail "please specify the name of each endpoint" }}
+{{- end }}
+{{- if not .selector }}
+  {{- fail (printf "please specify the selector of endpoint %s" .name) }}
+{{- end }}
+{{- end }}
+# task: discover endpoints of versions from the labels of Kubernetes objects
+# the outputs <name>URL and <name>Host are published for later tasks
+- task: discover
+  with:
+{{ toYaml . | indent 4 }}
+{{- end }}
+{{- /* discover.target returns the output of the first discovered endpoint with the suffix; for example, URL or Host */ -}}
+{{- define "discover.target" -}}
+{{- $endpoint := first .discover.endpoints -}}
+{{- printf "{{ .Outputs.%s%s }}" $endpoint.name .suffix -}}
+{{- end }}
@@ -0,0 +1,20 @@
+{{- define "task.email" -}}
+{{- /* Validate values */ -}}
+{{- if not . }}
+{{- fail "email values object is nil" }}
+{{- end }}
+{{- if not .host }}
+  {{- fail "please specify the SMTP host" }}
+{{- end }}
+{{- $vals := mustDeepCopy . }}
+{{- /* Password is read from the environment of the Kubernetes job */ -}}
+{{- if $vals.passwordSecret }}
+{{- $_ := unset $vals "passwordSecret" }}
+{{- $_ := set $vals "passwordEnv" "ITER8_SMTP_PASSWORD" }}
+{{- end }}
+# task: email the experiment report
+# this task runs even if an earlier task has failed
+- task: email
+  with:
+{{ toYaml $vals | indent 4 }}
+{{- end }}
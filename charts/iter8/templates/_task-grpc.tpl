@@ -3,7 +3,7 @@
 {{- if not . }}
 {{- fail "grpc values object is nil" }}
 {{- end }}
-{{- if not .host }}
+{{- if not (or .host .versions) }}
 {{- fail "please set a value for the host parameter" }}
 {{- end }}
 {{- if not .call }}
@@ -0,0 +1,21 @@
+{{- define "task.helm" -}}
+{{- /* Validate values */ -}}
+{{- if not . }}
+{{- fail "helm values object is nil" }}
+{{- end }}
+{{- if not .release }}
+{{- fail "please specify the release of the helm task" }}
+{{- end }}
+{{- $vals := mustDeepCopy . }}
+{{- $_ := unset $vals "if" }}
+{{- /* releases are upgraded if SLOs are satisfied, and rolled back otherwise */ -}}
+{{- $if := "SLOs()" }}
+{{- if eq "rollback" (default "upgrade" .action) }}
+{{- $if = "!SLOs()" }}
+{{- end }}
+# task: {{ default "upgrade" .action }} the Helm release {{ .release }}
+- task: helm
+  if: {{ default $if .if | quote }}
+  with:
+{{ toYaml $vals | indent 4 }}
+{{- end }}
@@ -3,7 +3,7 @@
 {{- if not . }}
 {{- fail "http values object is nil" }}
 {{- end }}
-{{- if not .url }}
+{{- if not (or .url .versions) }}
   {{- fail "please specify the url parameter" }}
 {{- end }}
 {{- /* Perform the various setup steps before the main task */ -}}
@@ -0,0 +1,20 @@
+{{- define "task.ingest" -}}
+{{- /* Validate values */ -}}
+{{- if not . }}
+{{- fail "ingest values object is nil" }}
+{{- end }}
+{{- if not .duration }}
+  {{- fail "please specify the duration parameter" }}
+{{- end }}
+{{- if not .versions }}
+  {{- fail "please specify the names of versions" }}
+{{- end }}
+{{- if not .metrics }}
+  {{- fail "please specify at least one metric" }}
+{{- end }}
+# task: accept business events pushed by the app for the duration
+# aggregate them into metrics of each version
+- task: ingest
+  with:
+{{ toYaml . | indent 4 }}
+{{- end }}
@@ -0,0 +1,50 @@
+{{- define "task.label" }}
+{{- if not .Values.label }}
+{{- fail "label values object is nil" }}
+{{- end }}
+{{- if not (or .Values.label.deploy .Values.label.service .Values.label.resources) }}
+{{- fail "please specify the deploy, service, or resources to label" }}
+{{- end }}
+{{- $namespace := coalesce .Values.label.namespace .Release.Namespace }}
+# task: label the tested workloads with the experiment group, revision, and outcome
+# this task runs even if an earlier task has failed
+- task: label
+  with:
+    {{- /* local experiments belong to the default group */}}
+    group: {{ default "default" .Release.Name | quote }}
+    objects:
+{{- if .Values.label.deploy }}
+    - name: {{ .Values.label.deploy | quote }}
+      group: apps
+      version: v1
+      resource: deployments
+{{- if $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
+{{- end }}
+{{- if .Values.label.service }}
+    - name: {{ .Values.label.service | quote }}
+      version: v1
+      resource: services
+{{- if $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
+{{- end }}
+{{- range .Values.label.resources }}
+    - name: {{ .name | quote }}
+{{- if .group }}
+      group: {{ .group }}
+{{- end }}
+      version: {{ .version }}
+      resource: {{ .resource }}
+{{- if $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
+{{- end }}
+{{- if .Values.label.kubeconfig }}
+    kubeconfig: {{ .Values.label.kubeconfig }}
+{{- end }}
+{{- if .Values.label.context }}
+    context: {{ .Values.label.context }}
+{{- end }}
+{{- end }}
@@ -0,0 +1,58 @@
+{{- /* manifests.tasks are the tasks that apply or delete manifests and patch objects, and their default if conditions */ -}}
+{{- define "manifests.tasks" -}}
+promote: SLOs()
+rollback: "!SLOs()"
+{{- end }}
+
+{{- define "task.manifests" -}}
+{{- /* Validate values */ -}}
+{{- if not .values }}
+{{- fail (printf "%s values object is nil" .task) }}
+{{- end }}
+{{- if not (or .values.manifest .values.patches) }}
+{{- fail (printf "please specify the manifest or patches of the %s task" .task) }}
+{{- end }}
+{{- $vals := mustDeepCopy .values }}
+{{- $_ := unset $vals "if" }}
+{{- $if := default (get (include "manifests.tasks" . | fromYaml) .task) .values.if }}
+# task: {{ .task }} by applying or deleting manifests and patching objects
+- task: manifests
+  if: {{ $if | quote }}
+  with:
+{{ toYaml $vals | indent 4 }}
+{{- end }}
+
+{{- /* manifests.rules are the RBAC rules that enable the manifests task to apply or delete objects and patch objects */ -}}
+{{- define "manifests.rules" -}}
+{{- range regexSplit "(?m)^---\\s*$" (default "" .manifest) -1 }}
+{{- $obj := fromYaml . }}
+{{- if $obj.kind }}
+{{- $group := "" }}
+{{- if contains "/" $obj.apiVersion }}
+{{- $group = $obj.apiVersion | splitList "/" | first }}
+{{- end }}
+{{- /* resource types are derived from kinds, as in the manifests task */}}
+{{- $resource := lower $obj.kind }}
+{{- if hasSuffix "s" $resource }}
+{{- $resource = printf "%ses" $resource }}
+{{- else if hasSuffix "y" $resource }}
+{{- $resource = printf "%sies" (trimSuffix "y" $resource) }}
+{{- else }}
+{{- $resource = printf "%ss" $resource }}
+{{- end }}
+- apiGroups: [{{ $group | quote }}]
+  resourceNames: [{{ $obj.metadata.name | quote }}]
+  resources: [{{ $resource | quote }}]
+  verbs: ["get", "update", "delete"]
+- apiGroups: [{{ $group | quote }}]
+  resources: [{{ $resource | quote }}]
+  verbs: ["create"]
+{{- end }}
+{{- end }}
+{{- range .patches }}
+- apiGroups: [{{ default "" .group | quote }}]
+  resourceNames: [{{ .name | quote }}]
+  resources: [{{ .resource | quote }}]
+  verbs: ["get", "patch"]
+{{- end }}
+{{- end }}
@@ -0,0 +1,18 @@
+{{- define "task.parallel" -}}
+{{- $root := .root }}
+# task: run branches of tasks in parallel;
+# the tasks of a branch run in sequence
+- task: parallel
+  with:
+    branches:
+{{- range .tasks }}
+    -
+{{- if kindIs "slice" . }}
+{{- range . }}
+{{- include "task" (dict "name" . "root" $root) | trim | nindent 6 }}
+{{- end }}
+{{- else }}
+{{- include "task" (dict "name" . "root" $root) | trim | nindent 6 }}
+{{- end }}
+{{- end }}
+{{- end }}
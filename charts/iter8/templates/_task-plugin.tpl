@@ -0,0 +1,8 @@
+{{- define "task.plugin" }}
+# task: run the {{ .task }} task, which is implemented by the plugin iter8-task-{{ .task }}
+- task: {{ .task }}
+{{- with .values }}
+  with:
+{{ toYaml . | indent 4 }}
+{{- end }}
+{{- end }}
@@ -1,32 +1,106 @@
 {{- define "task.ready" }}
 {{- if .Values.ready }}
 {{- $namespace := coalesce .Values.ready.namespace .Release.Namespace }}
-{{- if .Values.ready.service }}
-# task: determine if Kubernetes Service exists
+{{- if or .Values.ready.service .Values.ready.deploy .Values.ready.ksvc .Values.ready.revisions .Values.ready.resources }}
+# task: determine if Kubernetes objects exist and are ready
 - task: ready
   with:
-    name: {{ .Values.ready.service | quote }}
-    version: v1
-    resource: services
+    resources:
+{{- if .Values.ready.service }}
+    - name: {{ .Values.ready.service | quote }}
+      version: v1
+      resource: services
+{{- if $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
+{{- end }}
+{{- if .Values.ready.deploy }}
+    - name: {{ .Values.ready.deploy | quote }}
+      group: apps
+      version: v1
+      resource: deployments
+      condition: Available
+{{- if $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
+{{- end }}
+{{- if .Values.ready.ksvc }}
+    - name: {{ .Values.ready.ksvc | quote }}
+      group: serving.knative.dev
+      version: v1
+      resource: services
+      condition: Ready
+{{- if $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
+{{- end }}
+{{- range .Values.ready.revisions }}
+    - name: {{ . | quote }}
+      group: serving.knative.dev
+      version: v1
+      resource: revisions
+      condition: Ready
+{{- if $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
+{{- end }}
+{{- range .Values.ready.resources }}
+    - name: {{ .name | quote }}
+{{- if .group }}
+      group: {{ .group }}
+{{- end }}
+      version: {{ .version }}
+      resource: {{ .resource }}
+{{- if .condition }}
+      condition: {{ .condition }}
+{{- end }}
+{{- if .jsonPath }}
+      jsonPath: {{ .jsonPath | quote }}
+{{- end }}
+{{- if .value }}
+      value: {{ .value | quote }}
+{{- end }}
+{{- with .outputs }}
+      outputs:
+        {{- toYaml . | trim | nindent 8 }}
+{{- end }}
 {{- if $namespace }}
-    namespace: {{ $namespace }}
+      namespace: {{ $namespace }}
+{{- end }}
 {{- end }}
 {{- if .Values.ready.timeout }}
     timeout: {{ .Values.ready.timeout }}
 {{- end }}
+{{- if .Values.ready.interval }}
+    interval: {{ .Values.ready.interval }}
 {{- end }}
-{{- if .Values.ready.deploy }}
-# task: determine if Kubernetes Deployment exists and is Available
+{{- if .Values.ready.kubeconfig }}
+    kubeconfig: {{ .Values.ready.kubeconfig }}
+{{- end }}
+{{- if .Values.ready.context }}
+    context: {{ .Values.ready.context }}
+{{- end }}
+{{- end }}
+{{- if .Values.ready.metrics }}
+# task: determine if custom metrics are available for each version
 - task: ready
   with:
-    name: {{ .Values.ready.deploy | quote }}
-    group: apps
-    version: v1
-    resource: deployments
-    condition: Available
-{{- if $namespace }}
-    namespace: {{ $namespace }}
+    metrics:
+{{ .Values.custommetrics | toYaml | indent 6 }}
+{{- if kindIs "slice" .Values.ready.metrics }}
+      names:
+{{ .Values.ready.metrics | toYaml | indent 6 }}
+{{- end }}
+{{- if .Values.ready.timeout }}
+    timeout: {{ .Values.ready.timeout }}
+{{- end }}
 {{- end }}
+{{- if .Values.ready.prometheus }}
+# task: determine if Prometheus queries return samples
+- task: ready
+  with:
+    prometheus:
+{{ .Values.ready.prometheus | toYaml | indent 6 }}
 {{- if .Values.ready.timeout }}
     timeout: {{ .Values.ready.timeout }}
 {{- end }}
@@ -0,0 +1,17 @@
+{{- define "task.soak" -}}
+{{- /* Validate values */ -}}
+{{- if not . }}
+{{- fail "soak values object is nil" }}
+{{- end }}
+{{- if not .duration }}
+  {{- fail "please specify the duration parameter" }}
+{{- end }}
+{{- if not .versions }}
+  {{- fail "please specify the versions whose resource usage is sampled" }}
+{{- end }}
+# task: sample the memory, CPU, or other resource usage of versions for the duration
+# record the samples and their trend per hour as metrics of each version
+- task: soak
+  with:
+{{ toYaml . | indent 4 }}
+{{- end }}
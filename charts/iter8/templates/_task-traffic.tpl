@@ -0,0 +1,47 @@
+{{- /* traffic.objects describes the Kubernetes object updated by each traffic shifting task; value is the task value that names the object */ -}}
+{{- define "traffic.objects" -}}
+istio:
+  value: virtualService
+  group: networking.istio.io
+  resource: virtualservices
+gateway:
+  value: httpRoute
+  group: gateway.networking.k8s.io
+  resource: httproutes
+kserve:
+  value: inferenceService
+  group: serving.kserve.io
+  resource: inferenceservices
+knative:
+  value: service
+  group: serving.knative.dev
+  resource: services
+linkerd:
+  value: trafficSplit
+  group: split.smi-spec.io
+  resource: trafficsplits
+seldon:
+  value: seldonDeployment
+  group: machinelearning.seldon.io
+  resource: seldondeployments
+{{- end }}
+
+{{- define "task.traffic" -}}
+{{- $object := (get (include "traffic.objects" . | fromYaml) .task).value }}
+{{- /* Validate values */ -}}
+{{- if not .values }}
+{{- fail (printf "%s values object is nil" .task) }}
+{{- end }}
+{{- if not (get .values $object) }}
+{{- fail (printf "please specify the %s of the %s task" $object .task) }}
+{{- end }}
+{{- $vals := mustDeepCopy .values }}
+{{- $_ := unset $vals "if" }}
+# task: shift traffic using the {{ .task }} {{ $object }} {{ get .values $object }}
+- task: {{ .task }}
+{{- if .values.if }}
+  if: {{ .values.if | quote }}
+{{- end }}
+  with:
+{{ toYaml $vals | indent 4 }}
+{{- end }}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
@@ -14,7 +13,7 @@ import (
 
 // assertDesc is the description of assert cmd
 const assertDesc = `
-Assert if the result of an experiment satisfies the specified conditions. If all conditions are satisfied, the command exits with code 0. Else, the command exits with code 1. 
+Assert if the result of an experiment satisfies the specified conditions. If all conditions are satisfied, the command exits with code 0. Else, the exit code indicates why the conditions are not satisfied: 2 if SLOs are not satisfied, 3 if a task failed, 4 if the experiment did not complete before the timeout, and 5 if the infrastructure is unavailable; other failures exit with code 1.
 
 Assertions are especially useful for automation inside CI/CD/GitOps pipelines.
 
@@ -23,9 +22,23 @@ Supported conditions are 'completed', 'nofailure', 'slos', which indicate that t
 	$ iter8 assert -c completed -c nofailure -c slos
 	# same as iter8 assert -c completed,nofailure,slos
 
+Conditions may also be expressions that use 'loops' (the number of experiment loops), 'slosby=<version>' (the app version satisfies the SLOs), and 'winner=<version>' (the app version is the latest version that satisfies the SLOs), combined using comparison and boolean operators:
+
+	$ iter8 assert -c 'slosby=1 && loops >= 3'
+	$ iter8 assert -c 'completed && winner=1'
+	$ iter8 assert -c 'completed && (slos || !nofailure)'
+
 You can optionally specify a timeout, which is the maximum amount of time to wait for the conditions to be satisfied:
 
 	$ iter8 assert -c completed,nofailures,slos -t 5s
+
+While waiting, the progress of the experiment is logged, including the number of completed tasks, the current loop, and the app versions that satisfy SLOs so far. Timeouts can also be specified for individual conditions; assert fails as soon as any condition is not satisfied within its timeout:
+
+	$ iter8 assert -c completed,slos --timeout 10m --conditionTimeout slos=2m
+
+Use the JSON output format to list the conditions that are not satisfied, and the SLOs violated by each app version along with the amount by which the limits are exceeded:
+
+	$ iter8 assert -c completed,nofailure,slos -o json
 `
 
 // newAssertCmd creates the assert command
@@ -37,12 +50,12 @@ func newAssertCmd(kd *driver.KubeDriver) *cobra.Command {
 		Short: "Assert if experiment result satisfies conditions",
 		Long:  assertDesc,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			allGood, err := actor.LocalRun()
+			allGood, err := actor.LocalRun(outStream)
 			if err != nil {
 				return err
 			}
 			if !allGood {
-				e := errors.New("assert conditions failed")
+				e := actor.Failure()
 				log.Logger.Error(e)
 				return e
 			}
@@ -52,13 +65,16 @@ func newAssertCmd(kd *driver.KubeDriver) *cobra.Command {
 	}
 	addConditionFlag(cmd, &actor.Conditions)
 	addTimeoutFlag(cmd, &actor.Timeout)
+	addConditionTimeoutFlag(cmd, &actor.ConditionTimeouts)
+	addAssertOutputFormatFlag(cmd, &actor.OutputFormat)
 	addRunDirFlag(cmd, &actor.RunDir)
+	addObjectURLFlag(cmd, &actor.ObjectURL)
 	return cmd
 }
 
 // addConditionFlag adds the condition flag to command
 func addConditionFlag(cmd *cobra.Command, conditionPtr *[]string) {
-	cmd.Flags().StringSliceVarP(conditionPtr, "condition", "c", nil, fmt.Sprintf("%v | %v | %v; can specify multiple or separate conditions with commas;", ia.Completed, ia.NoFailure, ia.SLOs))
+	cmd.Flags().StringSliceVarP(conditionPtr, "condition", "c", nil, fmt.Sprintf("%v | %v | %v | %v=<version> | %v=<version> | %v>=<n>, or an expression combining them; can specify multiple or separate conditions with commas;", ia.Completed, ia.NoFailure, ia.SLOs, ia.SLOsBy, ia.Winner, ia.Loops))
 	cmd.MarkFlagRequired("condition")
 }
 
@@ -67,6 +83,16 @@ func addTimeoutFlag(cmd *cobra.Command, timeoutPtr *time.Duration) {
 	cmd.Flags().DurationVar(timeoutPtr, "timeout", 0, "timeout duration (e.g., 5s)")
 }
 
+// addConditionTimeoutFlag adds the per-condition timeout flag to command
+func addConditionTimeoutFlag(cmd *cobra.Command, conditionTimeoutsPtr *map[string]string) {
+	cmd.Flags().StringToStringVar(conditionTimeoutsPtr, "conditionTimeout", nil, "timeout duration for a specific condition (e.g., slos=2m); overrides --timeout for that condition; can specify multiple")
+}
+
+// addAssertOutputFormatFlag adds the output format flag to the assert command
+func addAssertOutputFormatFlag(cmd *cobra.Command, outputFormatPtr *string) {
+	cmd.Flags().StringVarP(outputFormatPtr, "outputFormat", "o", ia.TextOutputFormatKey, fmt.Sprintf("%v | %v", ia.TextOutputFormatKey, ia.JSONOutputFormatKey))
+}
+
 // initialize with assert
 func init() {
 	rootCmd.AddCommand(newAssertCmd(kd))
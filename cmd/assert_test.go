@@ -6,6 +6,7 @@ import (
 
 	"github.com/iter8-tools/iter8/base"
 	id "github.com/iter8-tools/iter8/driver"
+	"github.com/spf13/pflag"
 )
 
 func TestAssert(t *testing.T) {
@@ -24,3 +25,25 @@ func TestAssert(t *testing.T) {
 	*kd = *id.NewFakeKubeDriver(settings)
 	runTestActionCmd(t, tests)
 }
+
+func TestAssertJSON(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "assertinputsfail/experiment.yaml"))
+	tests := []cmdTestCase{
+		// assert, SLOs, JSON output
+		{
+			name:      "assert SLOs JSON",
+			cmd:       "assert -c completed -c nofailure -c slos -o json",
+			golden:    base.CompletePath("../testdata", "output/assert-slos-json.txt"),
+			wantError: true,
+		},
+	}
+
+	// conditions accumulate across executions of the assert command; reset them
+	c, _, _ := rootCmd.Find([]string{"assert"})
+	c.Flags().Lookup("condition").Value.(pflag.SliceValue).Replace(nil)
+
+	// fake kube cluster
+	*kd = *id.NewFakeKubeDriver(settings)
+	runTestActionCmd(t, tests)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/spf13/cobra"
+)
+
+// bundleDesc is the description of the bundle command
+const bundleDesc = `
+Create and load experiment bundles. A bundle is a single archive with the Iter8 experiment charts and default values.
+Bundles enable experiments to be launched without network access; for example, in air-gapped clusters.
+
+Create a bundle in a connected environment. Values specified when creating the bundle become its default values.
+
+	$ iter8 bundle create --file iter8-bundle.tgz \
+		--set "tasks={http}" \
+		--set runner=job
+
+Launch experiments using the bundle in the air-gapped environment. Values specified at launch take precedence over the default values of the bundle.
+
+	$ iter8 k launch --bundle iter8-bundle.tgz \
+		--set http.url=http://httpbin.default/get
+
+Alternatively, load the bundle into the charts parent directory, and launch experiments using the local charts.
+
+	$ iter8 bundle load -f iter8-bundle.tgz
+	$ iter8 launch --noDownload -f bundle-values.yaml \
+		--set http.url=http://httpbin.default/get
+`
+
+// newBundleCmd creates the bundle command
+func newBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Create and load experiment bundles for offline use",
+		Long:  bundleDesc,
+	}
+	cmd.AddCommand(newBundleCreateCmd())
+	cmd.AddCommand(newBundleLoadCmd())
+	return cmd
+}
+
+// newBundleCreateCmd creates the bundle create command
+func newBundleCreateCmd() *cobra.Command {
+	actor := ia.NewBundleOpts()
+
+	cmd := &cobra.Command{
+		Use:          "create",
+		Short:        "Create an experiment bundle with the experiment charts and default values",
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.CreateRun()
+		},
+	}
+	// -f is the shorthand of the values flag
+	cmd.Flags().StringVar(&actor.File, "file", ia.DefaultBundleFile, "path of the bundle")
+	addChartsParentDirFlag(cmd, &actor.ChartsParentDir)
+	addRemoteFolderURLFlag(cmd, &actor.RemoteFolderURL)
+	addRegistryConfigFlag(cmd, &actor.RegistryConfig)
+	addVerifyFlags(cmd, &actor.Verify, &actor.Keyring)
+	addNoDownloadFlag(cmd, &actor.NoDownload)
+	addCacheFlags(cmd, &actor.NoCache, &actor.Refresh)
+	addValueFlags(cmd.Flags(), &actor.Options)
+	return cmd
+}
+
+// newBundleLoadCmd creates the bundle load command
+func newBundleLoadCmd() *cobra.Command {
+	actor := ia.NewBundleOpts()
+
+	cmd := &cobra.Command{
+		Use:          "load",
+		Short:        "Load an experiment bundle into the charts parent directory",
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			_, err := actor.LoadRun()
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&actor.File, "file", "f", ia.DefaultBundleFile, "path of the bundle")
+	addChartsParentDirFlag(cmd, &actor.ChartsParentDir)
+	return cmd
+}
+
+// addBundleFlag adds the bundle flag to the launch command
+func addBundleFlag(cmd *cobra.Command, bundlePtr *string) {
+	cmd.Flags().StringVar(bundlePtr, "bundle", "", "path of an experiment bundle; the bundle is loaded into the charts parent dir and its default values are used, instead of downloading charts")
+}
+
+// initialize with the bundle command
+func init() {
+	rootCmd.AddCommand(newBundleCmd())
+}
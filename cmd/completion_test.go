@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestCompletion(t *testing.T) {
+	tests := []cmdTestCase{
+		// completion for each supported shell
+		{
+			name: "bash completion",
+			cmd:  "completion bash",
+		},
+		{
+			name: "zsh completion",
+			cmd:  "completion zsh",
+		},
+		{
+			name: "fish completion",
+			cmd:  "completion fish",
+		},
+	}
+
+	runTestActionCmd(t, tests)
+}
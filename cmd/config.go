@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// configEnvVar is the environment variable that overrides the location of the config file
+	configEnvVar = "ITER8_CONFIG"
+	// envPrefix is the prefix of environment variables that provide flag defaults
+	envPrefix = "ITER8_"
+)
+
+// configAliases are alternative names of flags in the config file and environment variables
+var configAliases = map[string]string{
+	"repoURL":     "remoteFolderURL",
+	"trustedKeys": "keyring",
+}
+
+// configPath returns the location of the config file
+func configPath() string {
+	if p, ok := os.LookupEnv(configEnvVar); ok {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".iter8", "config.yaml")
+}
+
+// readConfig reads flag defaults from the config file; a missing config file is not an error
+func readConfig(path string) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if path == "" {
+		return config, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		e := fmt.Errorf("unable to read config file %v", path)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	if err = yaml.Unmarshal(b, &config); err != nil {
+		e := fmt.Errorf("unable to parse config file %v", path)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	for alias, name := range configAliases {
+		if v, ok := config[alias]; ok {
+			if _, ok := config[name]; !ok {
+				config[name] = v
+			}
+			delete(config, alias)
+		}
+	}
+	return config, nil
+}
+
+// envVarName returns the environment variable that provides the default for a flag;
+// for example, ITER8_CHARTS_PARENT_DIR for chartsParentDir
+func envVarName(flag string) string {
+	var b strings.Builder
+	b.WriteString(envPrefix)
+	runes := []rune(flag)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteRune('_')
+		}
+		if r == '-' {
+			r = '_'
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// lookupEnv returns the value of the environment variable for a flag, or for one of its aliases
+func lookupEnv(flag string) (string, bool) {
+	if v, ok := os.LookupEnv(envVarName(flag)); ok {
+		return v, true
+	}
+	for alias, name := range configAliases {
+		if name == flag {
+			if v, ok := os.LookupEnv(envVarName(alias)); ok {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
+// setFlag sets a flag using a value from the config file; lists set the flag once per item
+func setFlag(f *pflag.Flag, v interface{}) error {
+	if items, ok := v.([]interface{}); ok {
+		for _, item := range items {
+			if err := f.Value.Set(fmt.Sprint(item)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f.Value.Set(fmt.Sprint(v))
+}
+
+// applyConfig sets the flags of the command that are not specified on the command line,
+// using ITER8_* environment variables, and the config file.
+// Flags specified on the command line take precedence over environment variables,
+// which take precedence over the config file.
+func applyConfig(cmd *cobra.Command) error {
+	config, err := readConfig(configPath())
+	if err != nil {
+		return err
+	}
+	var errs []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			return
+		}
+		if v, ok := lookupEnv(f.Name); ok {
+			if err := f.Value.Set(v); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid value %v for %v: %v", v, envVarName(f.Name), err))
+			}
+			return
+		}
+		if v, ok := config[f.Name]; ok {
+			if err := setFlag(f, v); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid value %v for %v in config file: %v", v, f.Name, err))
+			}
+		}
+	})
+	if len(errs) > 0 {
+		e := errors.New(strings.Join(errs, "; "))
+		log.Logger.Error(e)
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvVarName(t *testing.T) {
+	for flag, env := range map[string]string{
+		"loglevel":        "ITER8_LOGLEVEL",
+		"namespace":       "ITER8_NAMESPACE",
+		"chartsParentDir": "ITER8_CHARTS_PARENT_DIR",
+		"remoteFolderURL": "ITER8_REMOTE_FOLDER_URL",
+		"repoURL":         "ITER8_REPO_URL",
+		"kube-context":    "ITER8_KUBE_CONTEXT",
+	} {
+		assert.Equal(t, env, envVarName(flag))
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	t.Cleanup(resetEnv())
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	err := ioutil.WriteFile(config, []byte(`
+repoURL: github.com/me/charts.git//charts
+chartsParentDir: /from/config
+chartName: mychart
+verify: true
+trustedKeys: /keys/pubring.gpg
+set:
+- tasks={http}
+- http.url=https://example.com
+`), 0664)
+	assert.NoError(t, err)
+	os.Setenv(configEnvVar, config)
+	os.Setenv("ITER8_CHARTS_PARENT_DIR", "/from/env")
+
+	cmd := newLaunchCmd(kd)
+	// flags on the command line take precedence
+	assert.NoError(t, cmd.ParseFlags([]string{"-c", "iter8"}))
+	assert.NoError(t, applyConfig(cmd))
+
+	get := func(name string) string {
+		return cmd.Flags().Lookup(name).Value.String()
+	}
+	assert.Equal(t, "iter8", get("chartName"))
+	assert.Equal(t, "/from/env", get("chartsParentDir"))
+	assert.Equal(t, "github.com/me/charts.git//charts", get("remoteFolderURL"))
+	assert.Equal(t, "[tasks={http},http.url=https://example.com]", get("set"))
+	assert.Equal(t, "true", get("verify"))
+	assert.Equal(t, "/keys/pubring.gpg", get("keyring"))
+	// flags not in the config or environment keep their defaults
+	assert.Equal(t, ".", get("runDir"))
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	t.Cleanup(resetEnv())
+	dir := t.TempDir()
+
+	// missing config file is not an error
+	os.Setenv(configEnvVar, filepath.Join(dir, "missing.yaml"))
+	assert.NoError(t, applyConfig(newLaunchCmd(kd)))
+
+	// invalid config file
+	config := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(config, []byte("dry: [true"), 0664))
+	os.Setenv(configEnvVar, config)
+	assert.Error(t, applyConfig(newLaunchCmd(kd)))
+
+	// invalid value
+	assert.NoError(t, ioutil.WriteFile(config, []byte("dry: maybe"), 0664))
+	assert.Error(t, applyConfig(newLaunchCmd(kd)))
+	os.Setenv(configEnvVar, "")
+	os.Setenv("ITER8_DRY", "maybe")
+	assert.Error(t, applyConfig(newLaunchCmd(kd)))
+}
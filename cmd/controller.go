@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/spf13/cobra"
+)
+
+// controllerDesc is the description of the controller cmd
+const controllerDesc = `
+Run the Iter8 controller. The controller watches Iter8 Experiment custom resources in its namespace, and runs their tasks in-process. Experiments are run when they are created, and are run again when their spec changes. The result of the experiment is recorded in the custom resource, and is summarized in its status.
+
+	$ iter8 controller --namespace iter8
+
+Declare experiments by launching them with the controller runner, or by applying Experiment custom resources directly.
+
+	$ iter8 k launch --set "tasks={http}" --set http.url=https://httpbin.org/get --set runner=controller --namespace iter8
+	$ kubectl get experiments --namespace iter8
+	$ iter8 k assert -c completed,nofailure --storage cr --namespace iter8
+
+The Iter8 Experiment custom resource definition must be installed in the cluster. It is available in the charts/crds folder of the Iter8 repo, along with manifests that deploy the controller.
+`
+
+// newControllerCmd creates the controller command
+func newControllerCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewControllerOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "controller",
+		Short:        "Run experiments declared using Iter8 Experiment custom resources",
+		Long:         controllerDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun()
+		},
+	}
+	settings.AddFlags(cmd.Flags())
+	// hiding these Helm flags for now
+	cmd.Flags().MarkHidden("debug")
+	cmd.Flags().MarkHidden("registry-config")
+	cmd.Flags().MarkHidden("repository-config")
+	cmd.Flags().MarkHidden("repository-cache")
+	actor.EnvSettings = settings
+	return cmd
+}
+
+// initialize with controller cmd
+func init() {
+	rootCmd.AddCommand(newControllerCmd(kd))
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/spf13/cobra"
+)
+
+// createChartDesc is the description of the create chart command
+const createChartDesc = `
+Create the skeleton of an experiment chart, with Chart.yaml, default values, a values schema, task templates, and a README. The chart is created under the charts folder of the charts parent directory.
+
+	$ iter8 create chart my-experiment
+
+The chart can be used as is; extend it with your own tasks and values.
+
+	$ iter8 gen -c my-experiment --spec -
+`
+
+// newCreateCmd creates the create command
+func newCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create Iter8 resources, such as experiment charts",
+	}
+	cmd.AddCommand(newCreateChartCmd())
+	return cmd
+}
+
+// newCreateChartCmd creates the create chart command
+func newCreateChartCmd() *cobra.Command {
+	actor := ia.NewCreateChartOpts()
+
+	cmd := &cobra.Command{
+		Use:          "chart <name>",
+		Short:        "Create the skeleton of an experiment chart",
+		Long:         createChartDesc,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			actor.Name = args[0]
+			return actor.Run()
+		},
+	}
+	addChartsParentDirFlag(cmd, &actor.ChartsParentDir)
+	return cmd
+}
+
+// initialize with the create command
+func init() {
+	rootCmd.AddCommand(newCreateCmd())
+}
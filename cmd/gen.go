@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	ia "github.com/iter8-tools/iter8/action"
 
 	"github.com/spf13/cobra"
@@ -12,6 +14,23 @@ Generate an experiment.yaml file by combining the Iter8 experiment chart with va
 
     $ iter8 gen --set "tasks={http}" --set http.url=https://httpbin.org/get
 
+Write the experiment spec to the standard output, or to a chosen path, using --spec (-o). Note that --output is the format of log output of all commands.
+
+    $ iter8 gen --set "tasks={http}" --set http.url=https://httpbin.org/get -o -
+    $ iter8 gen --set "tasks={http}" --set http.url=https://httpbin.org/get --spec specs/experiment.yaml
+
+Optionally, also write the Kubernetes manifests that run the experiment in a cluster, so that generated specs can be inspected and committed to GitOps repos:
+
+    $ iter8 gen --set "tasks={http}" --set http.url=https://httpbin.org/get --set runner=job --manifests manifests.yaml -g hello --namespace test
+
+Combine layered values files, and apply named profiles defined under the profiles key of values files. Profiles are applied in order on top of the values files; values set on the command line take precedence over profiles.
+
+    $ iter8 gen -f base.yaml -f prod-overrides.yaml --profile soak
+
+For short experiments, a full experiment chart may be overkill. Render a plain experiment.yaml template with values instead; values are available in the template as .Values, along with sprig functions and toYaml.
+
+    $ iter8 gen --template experiment.tpl -f values.yaml
+
 This command is intended for development and testing of experiment charts. For production usage, the launch command is recommended.
 `
 
@@ -25,15 +44,26 @@ func newGenCmd() *cobra.Command {
 		Long:         genDesc,
 		SilenceUsage: true,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return actor.LocalRun()
+			return actor.LocalRun(outStream)
 		},
 	}
 	addChartsParentDirFlag(cmd, &actor.ChartsParentDir)
 	addChartNameFlag(cmd, &actor.ChartName)
 	addValueFlags(cmd.Flags(), &actor.Options)
+	addProfileFlag(cmd.Flags(), &actor.Profiles)
+	addGenOutputFlags(cmd, actor)
 	return cmd
 }
 
+// addGenOutputFlags adds flags that control the outputs of gen
+func addGenOutputFlags(cmd *cobra.Command, actor *ia.GenOpts) {
+	cmd.Flags().StringVarP(&actor.Output, "spec", "o", "", fmt.Sprintf("path of the experiment spec; use %v for stdout; defaults to experiment.yaml", ia.StdoutOutput))
+	cmd.Flags().StringVar(&actor.ManifestsOutput, "manifests", "", fmt.Sprintf("path of the rendered Kubernetes manifests for the experiment; use %v for stdout", ia.StdoutOutput))
+	cmd.Flags().StringVar(&actor.Template, "template", "", "path of a plain experiment spec template, which is rendered with values instead of the experiment chart")
+	addExperimentGroupFlag(cmd, &actor.Group)
+	cmd.Flags().StringVar(&actor.Namespace, "namespace", actor.Namespace, "namespace used when rendering Kubernetes manifests")
+}
+
 // addChartsParentDirFlag to the command
 func addChartsParentDirFlag(cmd *cobra.Command, chartsParentDirPtr *string) {
 	cmd.Flags().StringVar(chartsParentDirPtr, "chartsParentDir", ".", "directory under which the charts folder is located")
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGen(t *testing.T) {
@@ -23,7 +24,25 @@ func TestGen(t *testing.T) {
 			cmd:    fmt.Sprintf("gen -c iter8 --chartsParentDir %v --set tasks={http,assess} --set http.duration=2s -f %v", base.CompletePath("../", ""), base.CompletePath("../testdata", "config.yaml")),
 			golden: base.CompletePath("../testdata", "output/gen-values-file.txt"),
 		},
+		// gen, with output paths and manifests
+		{
+			name:   "gen with output paths and manifests",
+			cmd:    fmt.Sprintf("gen -c iter8 --chartsParentDir %v --set tasks={http} --set http.url=https://httpbin.org --spec spec.yaml --manifests manifests.yaml -g hello --namespace test", base.CompletePath("../", "")),
+			golden: base.CompletePath("../testdata", "output/gen-manifests.txt"),
+		},
 	}
 
 	runTestActionCmd(t, tests)
 }
+
+func TestGenSpecFlag(t *testing.T) {
+	t.Cleanup(resetEnv())
+	// the path of the experiment spec is set using --spec or -o, while --output is the format of log output
+	f := newGenCmd().Flags().ShorthandLookup("o")
+	assert.NotNil(t, f)
+	assert.Equal(t, "spec", f.Name)
+
+	_, _, err := executeActionCommandC(storageFixture(), "gen --output spec.yaml")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "use --spec")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+
+	"github.com/spf13/cobra"
+)
+
+// gitHubDesc is the description of the github cmd
+const gitHubDesc = `
+Publish the result of an experiment to GitHub. This command sets a commit status, posts the experiment report as a pull request comment, or both. The GitHub token is read from the GITHUB_TOKEN environment variable.
+
+The commit status is 'success' if the experiment completed without failures and satisfied SLOs, 'pending' if the experiment has not completed, and 'failure' otherwise. Use it as a required status check to gate merges on experiment results.
+
+	$ iter8 github --repo owner/name --sha $GITHUB_SHA --pr 42
+
+Use --apiURL for GitHub Enterprise.
+
+	$ iter8 github --repo owner/name --sha $GITHUB_SHA --apiURL https://github.example.com/api/v3
+`
+
+// newGitHubCmd creates the github command
+func newGitHubCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewGitHubOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "github",
+		Short:        "Publish experiment result to GitHub",
+		Long:         gitHubDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.LocalRun()
+		},
+	}
+	addGitHubFlags(cmd, actor)
+	addRunDirFlag(cmd, &actor.RunDir)
+	addObjectURLFlag(cmd, &actor.ObjectURL)
+	return cmd
+}
+
+// addGitHubFlags adds the flags used to publish experiment results to GitHub
+func addGitHubFlags(cmd *cobra.Command, actor *ia.GitHubOpts) {
+	cmd.Flags().StringVar(&actor.Repo, "repo", "", "GitHub repository in the owner/name format")
+	cmd.MarkFlagRequired("repo")
+	cmd.Flags().StringVar(&actor.SHA, "sha", "", "commit on which the status is set")
+	cmd.Flags().IntVar(&actor.PR, "pr", 0, "pull request on which the experiment report is posted as a comment")
+	cmd.Flags().StringVar(&actor.Context, "context", ia.DefaultGitHubContext, "label that identifies the commit status")
+	cmd.Flags().StringVar(&actor.TargetURL, "targetURL", "", "link included in the commit status")
+	cmd.Flags().StringVar(&actor.APIURL, "apiURL", ia.DefaultGitHubAPIURL, "base URL of the GitHub API")
+}
+
+// initialize with the github cmd
+func init() {
+	rootCmd.AddCommand(newGitHubCmd(kd))
+}
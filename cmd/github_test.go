@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/base"
+	id "github.com/iter8-tools/iter8/driver"
+)
+
+func TestGitHub(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "assertinputs/experiment.yaml"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	os.Setenv(ia.GitHubTokenEnv, "my-token")
+	defer os.Unsetenv(ia.GitHubTokenEnv)
+
+	tests := []cmdTestCase{
+		// github
+		{
+			name:   "github",
+			cmd:    fmt.Sprintf("github --repo owner/repo --sha abc123 --pr 7 --apiURL %v", srv.URL),
+			golden: base.CompletePath("../testdata", "output/github.txt"),
+		},
+	}
+
+	// fake kube cluster
+	*kd = *id.NewFakeKubeDriver(settings)
+	runTestActionCmd(t, tests)
+}
@@ -12,12 +12,31 @@ Download the Iter8 experiment chart from a URL.
 
 	$ iter8 hub
 
+Use the list option to list the experiment charts available at the URL, along with their versions and descriptions.
+
+	$ iter8 hub --list
+
+Experiment charts can also be pulled from OCI registries. Use helm registry login to authenticate with the registry.
+
+	$ helm registry login ghcr.io
+	$ iter8 hub --remoteFolderURL oci://ghcr.io/org/charts/iter8:0.10.0
+
+Use the verify option to ensure that the chart pulled from an OCI registry is signed by a trusted key. The chart must be pushed along with its Helm provenance file (helm package --sign). Trusted public keys are read from the keyring, which can also be set using the keyring (or trustedKeys) field of the Iter8 config file.
+
+	$ iter8 hub --remoteFolderURL oci://ghcr.io/org/charts/iter8:0.10.0 \
+	  --verify --keyring ~/.gnupg/pubring.gpg
+
+Charts downloaded from remote folders are cached under ~/.iter8/cache, keyed by the URL, including its ref, so that repeated downloads, such as launches in CI, reuse them. The digest of cached charts is verified whenever they are used; corrupted charts are downloaded again. Use the refresh option to download the charts again, for example, when the URL refers to a branch, or the noCache option to disable the cache.
+
+	$ iter8 hub --refresh
+
 This command is intended for development and testing of experiment charts. For production usage, the iter8 launch command is recommended.
 `
 
 // newHubCmd creates the hub command
 func newHubCmd() *cobra.Command {
 	actor := ia.NewHubOpts()
+	list := false
 
 	cmd := &cobra.Command{
 		Use:          "hub",
@@ -25,16 +44,44 @@ func newHubCmd() *cobra.Command {
 		Long:         hubDesc,
 		SilenceUsage: true,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if list {
+				return actor.ListRun()
+			}
 			return actor.LocalRun()
 		},
 	}
+	cmd.Flags().BoolVar(&list, "list", false, "list the experiment charts and versions available at the remote folder, instead of downloading them")
+	cmd.Flags().Lookup("list").NoOptDefVal = "true"
 	addRemoteFolderURLFlag(cmd, &actor.RemoteFolderURL)
+	addRegistryConfigFlag(cmd, &actor.RegistryConfig)
+	addVerifyFlags(cmd, &actor.Verify, &actor.Keyring)
+	addCacheFlags(cmd, &actor.NoCache, &actor.Refresh)
 	return cmd
 }
 
 // add the remoteFolderURL flag to the command
 func addRemoteFolderURLFlag(cmd *cobra.Command, remoteFolderURLPtr *string) {
-	cmd.Flags().StringVar(remoteFolderURLPtr, "remoteFolderURL", ia.DefaultRemoteFolderURL(), "URL of the remote folder containing the Iter8 experiment chart. Accepts any URL supported by https://github.com/hashicorp/go-getter")
+	cmd.Flags().StringVar(remoteFolderURLPtr, "remoteFolderURL", ia.DefaultRemoteFolderURL(), "URL of the remote folder containing the Iter8 experiment chart. Accepts any URL supported by https://github.com/hashicorp/go-getter, or an OCI chart reference like oci://ghcr.io/org/charts/iter8:0.10.0")
+}
+
+// add the registryConfig flag to the command
+func addRegistryConfigFlag(cmd *cobra.Command, registryConfigPtr *string) {
+	cmd.Flags().StringVar(registryConfigPtr, "registryConfig", "", "path to the credentials file of OCI registries; default is the file used by helm registry login")
+}
+
+// add the verify and keyring flags to the command
+func addVerifyFlags(cmd *cobra.Command, verifyPtr *bool, keyringPtr *string) {
+	cmd.Flags().BoolVar(verifyPtr, "verify", false, "verify the provenance of the chart before using it; requires an OCI chart reference")
+	cmd.Flags().Lookup("verify").NoOptDefVal = "true"
+	cmd.Flags().StringVar(keyringPtr, "keyring", ia.DefaultKeyring(), "keyring with the public keys trusted to sign charts")
+}
+
+// add the noCache and refresh flags to the command
+func addCacheFlags(cmd *cobra.Command, noCachePtr *bool, refreshPtr *bool) {
+	cmd.Flags().BoolVar(noCachePtr, "noCache", false, "download charts every time, without using or updating the cache of downloaded charts")
+	cmd.Flags().Lookup("noCache").NoOptDefVal = "true"
+	cmd.Flags().BoolVar(refreshPtr, "refresh", false, "download charts again, replacing the cached charts of the remote folder")
+	cmd.Flags().Lookup("refresh").NoOptDefVal = "true"
 }
 
 // initialize with the hub command
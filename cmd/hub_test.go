@@ -10,10 +10,10 @@ import (
 func TestHub(t *testing.T) {
 	os.Chdir(t.TempDir())
 	tests := []cmdTestCase{
-		// basic hub
+		// basic hub; charts are downloaded without the cache, which is in the home directory
 		{
 			name:   "basic hub",
-			cmd:    "hub --remoteFolderURL github.com/iter8-tools/iter8.git//charts",
+			cmd:    "hub --remoteFolderURL github.com/iter8-tools/iter8.git//charts --noCache",
 			golden: base.CompletePath("../testdata", "output/hub.txt"),
 		},
 	}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/iter8-tools/iter8/driver"
 	"github.com/spf13/cobra"
 )
@@ -9,7 +11,24 @@ import (
 var kCmd = &cobra.Command{
 	Use:   "k",
 	Short: "Work with Kubernetes experiments",
-	Long:  "Work with Kubernetes experiments",
+	Long: `Work with Kubernetes experiments.
+
+By default, results of a Kubernetes experiment are stored in the cluster in which it is launched.
+Use the --resultKubeconfig and --resultContext flags to store and read results in a separate control cluster.
+
+	$ iter8 k assert -c completed --resultContext control
+
+Experiments are stored in secrets by default. Use the --storage flag to store them in config maps, or in Iter8 Experiment custom resources, instead.
+The same kind of storage must be used with all commands for an experiment.
+
+	$ iter8 k launch --storage configmap ...
+	$ iter8 k assert -c completed --storage configmap
+
+In clusters shared by multiple teams, use the --owner flag to launch experiments with an owner label, and to restrict commands such as list and delete to experiments and resources of the owner.
+
+	$ iter8 k launch --owner team-a ...
+	$ iter8 k list -A --owner team-a
+`,
 }
 
 // addExperimentGroupFlag adds the experiment group flag
@@ -17,8 +36,28 @@ func addExperimentGroupFlag(cmd *cobra.Command, groupP *string) {
 	cmd.Flags().StringVarP(groupP, "group", "g", driver.DefaultExperimentGroup, "name of the experiment group")
 }
 
+// addResultClusterFlags adds flags that identify the control cluster in which experiment results are stored
+func addResultClusterFlags(cmd *cobra.Command, rc *driver.ResultCluster) {
+	cmd.PersistentFlags().StringVar(&rc.KubeConfig, "resultKubeconfig", "", "path to the kubeconfig file of the cluster in which experiment results are stored")
+	cmd.PersistentFlags().StringVar(&rc.KubeContext, "resultContext", "", "kubeconfig context of the cluster in which experiment results are stored")
+	cmd.PersistentFlags().StringVar(&rc.Namespace, "resultNamespace", "", "namespace in which experiment results are stored; defaults to the experiment namespace")
+}
+
+// addOwnerFlag adds the flag that sets the owner of experiments
+func addOwnerFlag(cmd *cobra.Command, ownerP *string) {
+	cmd.PersistentFlags().StringVar(ownerP, "owner", "", "owner of experiments, such as a team or tenant; experiments are labeled with their owner")
+}
+
+// addStorageFlag adds the flag that selects the kind of Kubernetes object in which experiments are stored
+func addStorageFlag(cmd *cobra.Command, storageP *string) {
+	cmd.PersistentFlags().StringVar(storageP, "storage", driver.SecretStorage, fmt.Sprintf("kind of Kubernetes object in which experiments are stored; one of %v", driver.StorageKinds))
+}
+
 func init() {
 	settings.AddFlags(kCmd.PersistentFlags())
+	addResultClusterFlags(kCmd, &kd.Results)
+	addStorageFlag(kCmd, &kd.Storage)
+	addOwnerFlag(kCmd, &kd.Owner)
 	// hiding these Helm flags for now
 	kCmd.PersistentFlags().MarkHidden("debug")
 	kCmd.PersistentFlags().MarkHidden("registry-config")
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/spf13/cobra"
+)
+
+// kAbortDesc is the description of the k abort cmd
+const kAbortDesc = `
+Abort a running Kubernetes experiment. The experiment stops after its current task and its result is marked as aborted. Partial results, including any metrics collected so far, are preserved and can be reported as usual.
+
+	$ iter8 k abort
+	$ iter8 k report
+`
+
+// newKAbortCmd creates the Kubernetes abort command
+func newKAbortCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewAbortOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "abort",
+		Short:        "Abort a running Kubernetes experiment",
+		Long:         kAbortDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun()
+		},
+	}
+	addExperimentGroupFlag(cmd, &actor.Group)
+	actor.EnvSettings = settings
+	return cmd
+}
+
+// initialize with the k abort cmd
+func init() {
+	kCmd.AddCommand(newKAbortCmd(kd))
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"errors"
-
 	ia "github.com/iter8-tools/iter8/action"
 	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
@@ -11,7 +9,7 @@ import (
 
 // kAssertDesc is the description of the k assert cmd
 const kAssertDesc = `
-Assert if the result of a Kubernetes experiment satisfies the specified conditions. If all conditions are satisfied, the command exits with code 0. Else, the command exits with code 1. 
+Assert if the result of a Kubernetes experiment satisfies the specified conditions. If all conditions are satisfied, the command exits with code 0. Else, the exit code indicates why the conditions are not satisfied: 2 if SLOs are not satisfied, 3 if a task failed, 4 if the experiment did not complete before the timeout, and 5 if the infrastructure is unavailable; other failures exit with code 1.
 
 Assertions are especially useful for automation inside CI/CD/GitOps pipelines.
 
@@ -20,9 +18,22 @@ Supported conditions are 'completed', 'nofailure', 'slos', which indicate that t
 	$ iter8 k assert -c completed -c nofailure -c slos
 	# same as iter8 k assert -c completed,nofailure,slos
 
+Conditions may also be expressions that use 'loops' (the number of experiment loops), 'slosby=<version>' (the app version satisfies the SLOs), and 'winner=<version>' (the app version is the latest version that satisfies the SLOs), combined using comparison and boolean operators:
+
+	$ iter8 k assert -c 'slosby=1 && loops >= 3'
+	$ iter8 k assert -c 'completed && winner=1'
+
 You can optionally specify a timeout, which is the maximum amount of time to wait for the conditions to be satisfied:
 
 	$ iter8 k assert -c completed,nofailures,slos -t 5s
+
+While waiting, the progress of the experiment is logged, including the number of completed tasks, the current loop, and the app versions that satisfy SLOs so far. Timeouts can also be specified for individual conditions; assert fails as soon as any condition is not satisfied within its timeout:
+
+	$ iter8 k assert -c completed,slos --timeout 10m --conditionTimeout slos=2m
+
+Use the JSON output format to list the conditions that are not satisfied, and the SLOs violated by each app version along with the amount by which the limits are exceeded:
+
+	$ iter8 k assert -c completed,nofailure,slos -o json
 `
 
 // newAssertCmd creates the Kubernetes assert command
@@ -35,12 +46,12 @@ func newKAssertCmd(kd *driver.KubeDriver) *cobra.Command {
 		Long:         kAssertDesc,
 		SilenceUsage: true,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			allGood, err := actor.KubeRun()
+			allGood, err := actor.KubeRun(outStream)
 			if err != nil {
 				return err
 			}
 			if !allGood {
-				e := errors.New("assert conditions failed")
+				e := actor.Failure()
 				log.Logger.Error(e)
 				return e
 			}
@@ -54,6 +65,8 @@ func newKAssertCmd(kd *driver.KubeDriver) *cobra.Command {
 	// options shared with assert
 	addConditionFlag(cmd, &actor.Conditions)
 	addTimeoutFlag(cmd, &actor.Timeout)
+	addConditionTimeoutFlag(cmd, &actor.ConditionTimeouts)
+	addAssertOutputFormatFlag(cmd, &actor.OutputFormat)
 	return cmd
 }
 
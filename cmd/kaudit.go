@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/spf13/cobra"
+)
+
+// kAuditDesc is the description of the k audit cmd
+const kAuditDesc = `
+Display the audit log of Kubernetes experiments. Launches, upgrades, aborts, and deletions of experiment groups are recorded in an append-only log in the iter8-audit config map of the namespace, along with the user who performed them, the resulting revision, and the values used.
+
+	$ iter8 k audit
+
+Display the audit log of all experiment groups in the namespace.
+
+	$ iter8 k audit --all
+
+Include the values used to launch and upgrade experiments.
+
+	$ iter8 k audit -o json
+`
+
+// newKAuditCmd creates the Kubernetes audit command
+func newKAuditCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewAuditOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "audit",
+		Short:        "Display the audit log of Kubernetes experiments",
+		Long:         kAuditDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun(outStream)
+		},
+	}
+	addExperimentGroupFlag(cmd, &actor.Group)
+	cmd.Flags().BoolVar(&actor.All, "all", false, "display the audit log of all experiment groups in the namespace")
+	cmd.Flags().StringVarP(&actor.OutputFormat, "outputFormat", "o", ia.TextOutputFormatKey, fmt.Sprintf("%v | %v", ia.TextOutputFormatKey, ia.JSONOutputFormatKey))
+	actor.EnvSettings = settings
+	return cmd
+}
+
+// initialize with the k audit cmd
+func init() {
+	kCmd.AddCommand(newKAuditCmd(kd))
+}
@@ -11,9 +11,17 @@ import (
 
 // kDeleteDesc is the description of the delete cmd
 const kDeleteDesc = `
-Delete an experiment (group) in Kubernetes.
+Delete an experiment (group) in Kubernetes. Experiment resources that remain after deletion, such as secrets, jobs, pods, and RBAC objects created at launch, are also removed.
 
 	$ iter8 k delete
+
+Delete all experiment groups in the namespace.
+
+	$ iter8 k delete --all
+
+Use the dry option to list the experiment groups and resources to be deleted, without deleting them.
+
+	$ iter8 k delete --all --dry
 `
 
 // newKDeleteCmd deletes an experiment group in Kubernetes.
@@ -31,9 +39,22 @@ func newKDeleteCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 	}
 	addExperimentGroupFlag(cmd, &actor.Group)
 	actor.EnvSettings = settings
+	addDeleteAllFlag(cmd, &actor.All)
+	addDryRunForDeleteFlag(cmd, &actor.DryRun)
 	return cmd
 }
 
+// addDeleteAllFlag adds the all flag to the k delete command
+func addDeleteAllFlag(cmd *cobra.Command, allPtr *bool) {
+	cmd.Flags().BoolVar(allPtr, "all", false, "delete all experiment groups in the namespace")
+}
+
+// addDryRunForDeleteFlag adds dry run flag to the k delete command
+func addDryRunForDeleteFlag(cmd *cobra.Command, dryRunPtr *bool) {
+	cmd.Flags().BoolVar(dryRunPtr, "dry", false, "list experiment groups and resources to be deleted without deleting them")
+	cmd.Flags().Lookup("dry").NoOptDefVal = "true"
+}
+
 // intialize with the k delete cmd
 func init() {
 	kCmd.AddCommand(newKDeleteCmd(kd, os.Stdout))
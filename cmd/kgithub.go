@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+
+	"github.com/spf13/cobra"
+)
+
+// kGitHubDesc is the description of the k github cmd
+const kGitHubDesc = `
+Publish the result of a Kubernetes experiment to GitHub. This command sets a commit status, posts the experiment report as a pull request comment, or both. The GitHub token is read from the GITHUB_TOKEN environment variable.
+
+	$ iter8 k github --repo owner/name --sha $GITHUB_SHA --pr 42
+`
+
+// newKGitHubCmd creates the Kubernetes github command
+func newKGitHubCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewGitHubOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "github",
+		Short:        "Publish Kubernetes experiment result to GitHub",
+		Long:         kGitHubDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun()
+		},
+	}
+	// options specific to k github
+	addExperimentGroupFlag(cmd, &actor.Group)
+	actor.EnvSettings = settings
+
+	// options shared with github
+	addGitHubFlags(cmd, actor)
+	return cmd
+}
+
+// initialize with the k github cmd
+func init() {
+	kCmd.AddCommand(newKGitHubCmd(kd))
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"io"
 	"os"
 
@@ -28,11 +29,19 @@ You can use various launch flags to control the following:
 	1. Whether Iter8 should download the Iter8 experiment chart from a remote URL or reuse local chart.
 	2. The remote URL (example, a GitHub URL) from which the Iter8 experiment chart is downloaded.
 	3. The local (parent) directory under which the Iter8 experiment chart is nested.
+
+Launching an experiment group that already exists upgrades it to a new revision. Use the upgrade option to review the changes to the experiment before the upgrade; the upgrade option fails if the group does not exist. Combine it with the dry option to only review the changes, and with the force option to replace the resources of the experiment instead of updating them.
+
+	$ iter8 k launch --upgrade \
+	  --set http.url=https://httpbin.org/get \
+	  --set http.numRequests=200 \
+	  --set runner=job
 `
 
 // newKLaunchCmd creates the Kubernetes launch command
 func newKLaunchCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 	actor := ia.NewLaunchOpts(kd)
+	upgrade := false
 
 	cmd := &cobra.Command{
 		Use:          "launch",
@@ -40,20 +49,32 @@ func newKLaunchCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 		Long:         kLaunchDesc,
 		SilenceUsage: true,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if actor.Force && !upgrade {
+				return errors.New("the force option requires the upgrade option")
+			}
+			if upgrade {
+				return actor.KubeUpgradeRun(out)
+			}
 			return actor.KubeRun()
 		},
 	}
 	// flags specific to k launch
 	addExperimentGroupFlag(cmd, &actor.Group)
 	addDryRunForKFlag(cmd, &actor.DryRun)
+	addUpgradeFlags(cmd, &upgrade, &actor.Force)
 	actor.EnvSettings = settings
 
 	// flags shared with launch
 	addChartsParentDirFlag(cmd, &actor.ChartsParentDir)
 	addRemoteFolderURLFlag(cmd, &actor.RemoteFolderURL)
+	addRegistryConfigFlag(cmd, &actor.RegistryConfig)
+	addVerifyFlags(cmd, &actor.Verify, &actor.Keyring)
 	addChartNameFlag(cmd, &actor.ChartName)
 	addValueFlags(cmd.Flags(), &actor.Options)
+	addProfileFlag(cmd.Flags(), &actor.Profiles)
 	addNoDownloadFlag(cmd, &actor.NoDownload)
+	addCacheFlags(cmd, &actor.NoCache, &actor.Refresh)
+	addBundleFlag(cmd, &actor.Bundle)
 
 	return cmd
 }
@@ -64,6 +85,14 @@ func addDryRunForKFlag(cmd *cobra.Command, dryRunPtr *bool) {
 	cmd.Flags().Lookup("dry").NoOptDefVal = "true"
 }
 
+// addUpgradeFlags adds the upgrade and force flags to the k launch command
+func addUpgradeFlags(cmd *cobra.Command, upgradePtr *bool, forcePtr *bool) {
+	cmd.Flags().BoolVar(upgradePtr, "upgrade", false, "upgrade an existing experiment group to a new revision, after showing the changes to the experiment")
+	cmd.Flags().Lookup("upgrade").NoOptDefVal = "true"
+	cmd.Flags().BoolVar(forcePtr, "force", false, "with upgrade, replace the resources of the experiment instead of updating them")
+	cmd.Flags().Lookup("force").NoOptDefVal = "true"
+}
+
 // initialize with the k launch cmd
 func init() {
 	kCmd.AddCommand(newKLaunchCmd(kd, os.Stdout))
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+
+	"github.com/spf13/cobra"
+)
+
+// kListDesc is the description of the k list cmd
+const kListDesc = `
+List Kubernetes experiments along with their chart, latest revision, age, and state.
+
+	$ iter8 k list
+
+List experiments in all namespaces.
+
+	$ iter8 k list -A
+`
+
+// newKListCmd creates the Kubernetes list command
+func newKListCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewListOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "list",
+		Aliases:      []string{"ls"},
+		Short:        "List Kubernetes experiments",
+		Long:         kListDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun()
+		},
+	}
+	actor.EnvSettings = settings
+	addAllNamespacesFlag(cmd, &actor.AllNamespaces)
+	return cmd
+}
+
+// addAllNamespacesFlag adds the all namespaces flag to command
+func addAllNamespacesFlag(cmd *cobra.Command, allNamespacesPtr *bool) {
+	cmd.Flags().BoolVarP(allNamespacesPtr, "allNamespaces", "A", false, "list experiments in all namespaces")
+}
+
+// initialize with the k list cmd
+func init() {
+	kCmd.AddCommand(newKListCmd(kd))
+}
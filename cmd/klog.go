@@ -9,9 +9,18 @@ import (
 
 // kLogDesc is the description of the k log cmd
 const kLogDesc = `
-Fetch logs for a Kubernetes experiment.
+Fetch logs for a Kubernetes experiment. Logs are fetched from the experiment pods using the experiment group, so there is no need to look up pod names or label selectors.
 
 	$ iter8 k log
+	# same as iter8 k logs
+
+Fetch logs for a specific experiment group and revision:
+
+	$ iter8 k log -g hello --revision 2
+
+Stream logs until the experiment pods terminate:
+
+	$ iter8 k log -f
 `
 
 // newKLogCmd creates the Kubernetes log commmand
@@ -20,10 +29,14 @@ func newKLogCmd(kd *driver.KubeDriver) *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:          "log",
+		Aliases:      []string{"logs"},
 		Short:        "Fetch logs for a Kubernetes experiment",
 		Long:         kLogDesc,
 		SilenceUsage: true,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if actor.Follow {
+				return actor.KubeStream(outStream)
+			}
 			if lg, err := actor.KubeRun(); err != nil {
 				return err
 			} else {
@@ -34,9 +47,21 @@ func newKLogCmd(kd *driver.KubeDriver) *cobra.Command {
 	}
 	addExperimentGroupFlag(cmd, &actor.Group)
 	actor.EnvSettings = settings
+	addRevisionFlag(cmd, &actor.Revision)
+	addFollowFlag(cmd, &actor.Follow)
 	return cmd
 }
 
+// addRevisionFlag adds the revision flag to command
+func addRevisionFlag(cmd *cobra.Command, revisionPtr *int) {
+	cmd.Flags().IntVar(revisionPtr, "revision", 0, "experiment revision; defaults to all revisions")
+}
+
+// addFollowFlag adds the follow flag to command
+func addFollowFlag(cmd *cobra.Command, followPtr *bool) {
+	cmd.Flags().BoolVarP(followPtr, "follow", "f", false, "stream logs until the experiment pods terminate")
+}
+
 // initialize with k log cmd
 func init() {
 	kCmd.AddCommand(newKLogCmd(kd))
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+
+	"github.com/spf13/cobra"
+)
+
+// kPreflightDesc is the description of the k preflight cmd
+const kPreflightDesc = `
+Check that the current user has the permissions needed to launch, inspect, and delete a Kubernetes experiment, and that the service account of the experiment has the permissions needed to run it. Permissions are checked using Kubernetes access reviews; each missing permission is reported.
+
+	$ iter8 k preflight
+
+Check the permissions needed by experiments with other runners, or experiments whose pods use an existing service account.
+
+	$ iter8 k preflight --runner cronjob
+	$ iter8 k preflight --serviceAccount iter8
+
+The service account of an experiment is created when it is launched; check its permissions after launching the experiment.
+`
+
+// newKPreflightCmd creates the Kubernetes preflight command
+func newKPreflightCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewPreflightOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "preflight",
+		Short:        "Check permissions needed by a Kubernetes experiment",
+		Long:         kPreflightDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun(outStream)
+		},
+	}
+	addExperimentGroupFlag(cmd, &actor.Group)
+	actor.EnvSettings = settings
+	cmd.Flags().StringVar(&actor.Runner, "runner", driver.JobRunner, fmt.Sprintf("runner of the experiment; one of %v", driver.Runners))
+	cmd.Flags().StringVar(&actor.ServiceAccount, "serviceAccount", "", "service account used by the experiment pods; defaults to the service account created for the experiment")
+	cmd.Flags().StringVarP(&actor.OutputFormat, "outputFormat", "o", ia.TextOutputFormatKey, fmt.Sprintf("%v | %v", ia.TextOutputFormatKey, ia.JSONOutputFormatKey))
+	return cmd
+}
+
+// initialize with the k preflight cmd
+func init() {
+	kCmd.AddCommand(newKPreflightCmd(kd))
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+	"github.com/spf13/cobra"
+)
+
+// kPruneDesc is the description of the k prune cmd
+const kPruneDesc = `
+Prune old revisions of an experiment group in Kubernetes. The release records of old revisions, and their jobs and pods, are deleted. The latest revision is never pruned.
+
+Keep the 5 latest revisions.
+
+	$ iter8 k prune --keep 5
+
+Prune revisions deployed more than 30 days ago, except for the 5 latest revisions, in all experiment groups in the namespace.
+
+	$ iter8 k prune --keep 5 --olderThan 30d --all
+
+Use the dry option to list the revisions and resources to be pruned, without deleting them.
+
+	$ iter8 k prune --keep 5 --dry
+
+Experiment groups can also be pruned automatically whenever they are launched or upgraded, by setting the retention values of the chart.
+
+	$ iter8 k launch --set retention.keep=5 --set retention.olderThan=30d ...
+`
+
+// newKPruneCmd creates the Kubernetes prune command
+func newKPruneCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewPruneOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "prune",
+		Short:        "Prune old revisions of an experiment group in Kubernetes",
+		Long:         kPruneDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun()
+		},
+	}
+	addExperimentGroupFlag(cmd, &actor.Group)
+	actor.EnvSettings = settings
+	addPruneFlags(cmd, &actor.Keep, &actor.OlderThan)
+	cmd.Flags().BoolVar(&actor.All, "all", false, "prune all experiment groups in the namespace")
+	cmd.Flags().BoolVar(&actor.DryRun, "dry", false, "list revisions and resources to be pruned without deleting them")
+	cmd.Flags().Lookup("dry").NoOptDefVal = "true"
+	return cmd
+}
+
+// addPruneFlags adds the retention policy flags to the k prune command
+func addPruneFlags(cmd *cobra.Command, keepPtr *int, olderThanPtr *string) {
+	cmd.Flags().IntVar(keepPtr, "keep", 0, "number of latest revisions that are kept")
+	cmd.Flags().StringVar(olderThanPtr, "olderThan", "", "prune only revisions deployed longer ago than this age; for example, 30d or 12h")
+}
+
+// initialize with the k prune cmd
+func init() {
+	kCmd.AddCommand(newKPruneCmd(kd))
+}
@@ -9,13 +9,59 @@ import (
 
 // kReportDesc is the description of the k report cmd
 const kReportDesc = `
-Generate a text or HTML report of a Kubernetes experiment.
+Generate a text, HTML, or SARIF report of a Kubernetes experiment.
 
 	$ iter8 k report # same as iter8 k report -o text
 
 or
 
 	$ iter8 k report -o html > report.html # view with browser
+
+or
+
+	$ iter8 k report -o sarif > report.sarif # SLO violations and task failures for code-scanning tools
+
+or
+
+	$ iter8 k report -o prometheus # metrics and SLO verdicts in Prometheus text exposition format
+
+or
+
+	$ iter8 k report -o csv > metrics.csv # metric observations of each version, one per row; for example, for pandas
+
+or
+
+	$ iter8 k report -o parquet > metrics.parquet # the same observations in Apache Parquet format
+
+Experiment metrics and SLO verdicts can also be pushed to a Prometheus Pushgateway.
+
+	$ iter8 k report --pushgateway http://pushgateway:9091
+
+Labels may be added to every sample; for example, to distinguish the experiments of different apps.
+
+	$ iter8 k report --pushgateway http://pushgateway:9091 --labels app=httpbin,env=staging
+
+Generate a Grafana dashboard with a panel for each metric and SLO limits as thresholds. Panels query the metrics pushed to the Pushgateway, so that successive runs appear as time series. The dashboard can be imported into Grafana, or created and updated using the Grafana HTTP API.
+
+	$ iter8 k report -o grafana > dashboard.json
+
+or
+
+	$ iter8 k report --pushgateway http://pushgateway:9091 --grafana http://grafana:3000 --grafanaToken $TOKEN
+
+You can also render the report using your own Go template.
+
+	$ iter8 k report --template mytemplate.tpl
+
+Compare this experiment with other experiment groups side-by-side.
+
+	$ iter8 k report --compare release-1,release-2
+
+Only the latest revision of each experiment group is kept in the cluster, so earlier revisions of a group cannot be compared. To compare the runs of an experiment over time, record them using the historyDB option, and query them using iter8 history.
+
+Use the follow option to watch the experiment and re-render the report whenever its result changes; for example, during long looping experiments. The report is updated until interrupted.
+
+	$ iter8 k report --follow
 `
 
 // newKReportCmd creates the Kubernetes report command
@@ -33,13 +79,25 @@ func newKReportCmd(kd *driver.KubeDriver) *cobra.Command {
 	}
 	// options specific to k report
 	addExperimentGroupFlag(cmd, &actor.Group)
+	addReportFollowFlag(cmd, &actor.Follow)
 	actor.EnvSettings = settings
 
 	// options shared with report
 	addOutputFormatFlag(cmd, &actor.OutputFormat)
+	addTemplateFlag(cmd, &actor.TemplateFile)
+	addCompareFlag(cmd, &actor.Compare)
+	addPushgatewayFlags(cmd, &actor.Pushgateway, &actor.PushJob)
+	addPrometheusLabelsFlag(cmd, &actor.Labels)
+	addGrafanaFlags(cmd, &actor.Grafana, &actor.GrafanaToken, &actor.GrafanaDatasource)
 	return cmd
 }
 
+// addReportFollowFlag adds the follow flag to the k report command
+func addReportFollowFlag(cmd *cobra.Command, followPtr *bool) {
+	cmd.Flags().BoolVar(followPtr, "follow", false, "re-render the report whenever the experiment result changes, until interrupted")
+	cmd.Flags().Lookup("follow").NoOptDefVal = "true"
+}
+
 // initialize with the k report cmd
 func init() {
 	kCmd.AddCommand(newKReportCmd(kd))
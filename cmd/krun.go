@@ -11,9 +11,19 @@ import (
 
 // kRunDesc is the description of the k run command
 const kRunDesc = `
-Run a Kubernetes experiment. This command reads an experiment specified in a secret and writes the result back to the secret.
+Run a Kubernetes experiment. This command reads an experiment specified in a secret (or the object selected using the storage flag) and writes the result back to it.
 
-	$ iter8 k run --namespace {{ .Experiment.Namespace }} --group {{ .Experiment.group }}
+	$ iter8 k run --namespace {{ .Experiment.Namespace }} --group {{ .Experiment.group }} --revision {{ .Experiment.Revision }}
+
+Use the resume option to continue a failed or aborted experiment from its first incomplete task, for example, when re-running the experiment job.
+
+	$ iter8 k run --namespace {{ .Experiment.Namespace }} --group {{ .Experiment.group }} --resume
+
+A run that receives SIGTERM or SIGINT, for example, when its pod is preempted, stops load generation, writes the metrics collected so far, and marks the experiment as interrupted. Use the resume option to run the interrupted task again; an interrupted http load test continues from its checkpoint, sending only its remaining requests or running for its remaining duration.
+
+Runs of an experiment group do not execute concurrently; while a run holds the lease named <group>-lock in the namespace, other runs of the group fail.
+
+Use the historyDB option, or the ITER8_HISTORY_DB environment variable, to record the experiment run in a Postgres or SQLite database.
 
 This command is intended for use within the Iter8 Docker image that is used to execute Kubernetes experiments.
 `
@@ -34,11 +44,19 @@ func newKRunCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 	}
 	addExperimentGroupFlag(cmd, &actor.Group)
 	addReuseResult(cmd, &actor.ReuseResult)
+	addRunRevisionFlag(cmd, &actor.Revision)
+	addResumeFlag(cmd, &actor.Resume)
+	addHistoryFlags(cmd, &actor.HistoryDB, &actor.HistoryName)
 	actor.EnvSettings = settings
 	cmd.MarkFlagRequired("namespace")
 	return cmd
 }
 
+// addRunRevisionFlag adds the revision flag to the k run command
+func addRunRevisionFlag(cmd *cobra.Command, revisionPtr *int) {
+	cmd.Flags().IntVar(revisionPtr, "revision", 0, "revision of the experiment being run")
+}
+
 // initialize with k run cmd
 func init() {
 	kCmd.AddCommand(newKRunCmd(kd, os.Stdout))
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+
+	"github.com/spf13/cobra"
+)
+
+// kServeDesc is the description of the k serve cmd
+const kServeDesc = `
+Serve the verdicts of Kubernetes experiments over HTTP.
+
+	$ iter8 k serve --port 8080
+
+The verdict is available at the /verdict endpoint as a JSON object. Select the experiment group using the group query parameter; the default is the group specified using the -g flag.
+
+	$ curl http://localhost:8080/verdict?group=my-experiment
+
+Use this endpoint with the Argo Rollouts web metric provider to drive promotion steps using Iter8 assessments. For example, use the success condition: result.pass == true.
+
+A Flagger-compatible webhook is available at the /flagger endpoint. It responds with status 200 if the experiment completed without failures and all app versions satisfy SLOs, and with status 412 otherwise. Use it as a Flagger webhook to gate canary analysis on Iter8 assessments. The conditions and experiment group can be set using the conditions and group keys in the webhook metadata.
+
+The server listens on localhost by default. Use the --address flag to listen on other interfaces; for example, --address 0.0.0.0 listens on all of them.
+`
+
+// newKServeCmd creates the Kubernetes serve command
+func newKServeCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewServeOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "serve",
+		Short:        "Serve Kubernetes experiment verdicts over HTTP",
+		Long:         kServeDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun()
+		},
+	}
+	// options specific to k serve
+	addExperimentGroupFlag(cmd, &actor.Group)
+	actor.EnvSettings = settings
+
+	// options shared with serve
+	addAddressFlag(cmd, &actor.Address)
+	addPortFlag(cmd, &actor.Port)
+	return cmd
+}
+
+// initialize with the k serve cmd
+func init() {
+	kCmd.AddCommand(newKServeCmd(kd))
+}
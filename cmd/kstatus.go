@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+
+	"github.com/spf13/cobra"
+)
+
+// kStatusDesc is the description of the k status cmd
+const kStatusDesc = `
+Summarize the status of a Kubernetes experiment, including its revision, number of loops, completed tasks, failure, and SLOs.
+
+	$ iter8 k status
+
+Refresh the status until the experiment completes or fails.
+
+	$ iter8 k status --watch
+`
+
+// newKStatusCmd creates the Kubernetes status command
+func newKStatusCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewStatusOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "status",
+		Short:        "Summarize the status of a Kubernetes experiment",
+		Long:         kStatusDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.KubeRun()
+		},
+	}
+	addExperimentGroupFlag(cmd, &actor.Group)
+	actor.EnvSettings = settings
+	addWatchFlag(cmd, &actor.Watch)
+	return cmd
+}
+
+// addWatchFlag adds the watch flag to command
+func addWatchFlag(cmd *cobra.Command, watchPtr *bool) {
+	cmd.Flags().BoolVarP(watchPtr, "watch", "w", false, "refresh the status until the experiment completes or fails")
+}
+
+// initialize with the k status cmd
+func init() {
+	kCmd.AddCommand(newKStatusCmd(kd))
+}
@@ -19,32 +19,72 @@ Use the dry option to simulate an experiment. This creates the experiment.yaml f
 	--set http.url=https://httpbin.org/get \
 	--dry
 
+Use the interactive option to be prompted for the experiment chart, tasks, URLs, and SLOs.
+
+	$ iter8 launch -i
+
+Use the job values to customize the pods that run the experiment with the job and cronjob runners; for example, resource requests and limits, node selectors, tolerations, service account, and image pull secrets.
+
+	$ iter8 launch --set "tasks={http}" \
+		--set http.url=https://httpbin.org/get \
+		--set runner=job \
+		--set job.resources.requests.cpu=100m \
+		--set job.resources.requests.memory=128Mi
+
+Use values files and profiles to maintain reusable experiment configurations; for example, in git. Profiles are defined under the profiles key of values files, and are applied in order on top of the values files.
+
+	$ iter8 launch -f base.yaml -f prod-overrides.yaml --profile soak
+
+When logs are written to an interactive terminal, the progress of the experiment is displayed. In CI, or with the noProgress option, plain logs are used.
+
+	$ iter8 launch --set "tasks={http}" \
+		--set http.url=https://httpbin.org/get \
+		--noProgress
+
 You can use various launch flags to control the following:
 	1. Whether Iter8 should download the Iter8 experiment chart from a remote URL or reuse local chart.
 	2. The remote URL (example, a GitHub URL) from which the Iter8 experiment chart is downloaded.
 	3. The local (parent) directory under which the Iter8 experiment chart is nested.
+	4. Whether the downloaded Iter8 experiment chart should be cached, or downloaded again.
 `
 
 // newLaunchCmd creates the launch command
 func newLaunchCmd(kd *driver.KubeDriver) *cobra.Command {
 	actor := ia.NewLaunchOpts(kd)
+	interactive := false
+	noProgress := false
 
 	cmd := &cobra.Command{
 		Use:          "launch",
 		Short:        "Launch an experiment",
 		Long:         launchDesc,
 		SilenceUsage: true,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(c *cobra.Command, _ []string) error {
+			if interactive {
+				if err := actor.Prompt(c.InOrStdin(), c.OutOrStdout()); err != nil {
+					return err
+				}
+			}
+			defer startProgress(noProgress)()
 			return actor.LocalRun()
 		},
 	}
 	addDryRunFlag(cmd, &actor.DryRun)
 	addChartsParentDirFlag(cmd, &actor.ChartsParentDir)
 	addRemoteFolderURLFlag(cmd, &actor.RemoteFolderURL)
+	addRegistryConfigFlag(cmd, &actor.RegistryConfig)
+	addVerifyFlags(cmd, &actor.Verify, &actor.Keyring)
 	addChartNameFlag(cmd, &actor.ChartName)
 	addValueFlags(cmd.Flags(), &actor.Options)
+	addProfileFlag(cmd.Flags(), &actor.Profiles)
 	addRunDirFlag(cmd, &actor.RunDir)
+	addObjectURLFlag(cmd, &actor.ObjectURL)
+	addHistoryFlags(cmd, &actor.HistoryDB, &actor.HistoryName)
 	addNoDownloadFlag(cmd, &actor.NoDownload)
+	addCacheFlags(cmd, &actor.NoCache, &actor.Refresh)
+	addBundleFlag(cmd, &actor.Bundle)
+	addInteractiveFlag(cmd, &interactive)
+	addNoProgressFlag(cmd, &noProgress)
 
 	return cmd
 }
@@ -61,6 +101,12 @@ func addNoDownloadFlag(cmd *cobra.Command, noDownloadPtr *bool) {
 	cmd.Flags().Lookup("noDownload").NoOptDefVal = "true"
 }
 
+// addInteractiveFlag adds interactive flag to the launch command
+func addInteractiveFlag(cmd *cobra.Command, interactivePtr *bool) {
+	cmd.Flags().BoolVarP(interactivePtr, "interactive", "i", false, "prompt for the experiment chart, tasks, URLs, and SLOs")
+	cmd.Flags().Lookup("interactive").NoOptDefVal = "true"
+}
+
 // initialize with the launch cmd
 func init() {
 	rootCmd.AddCommand(newLaunchCmd(kd))
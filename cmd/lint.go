@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+
+	"github.com/spf13/cobra"
+)
+
+// lintDesc is the description for the lint command
+const lintDesc = `
+Validate an experiment chart and values without running the experiment. The chart is rendered along with its Kubernetes manifests, the experiment spec is parsed, and the inputs of every task are validated. All problems are reported at once.
+
+    $ iter8 lint --set "tasks={http}" --set http.url=https://httpbin.org/get
+
+Use this command to catch invalid values before launching an experiment, especially in a Kubernetes cluster where problems would otherwise surface only when the experiment runs.
+`
+
+// newLintCmd creates the lint command
+func newLintCmd() *cobra.Command {
+	actor := ia.NewLintOpts()
+
+	cmd := &cobra.Command{
+		Use:          "lint",
+		Short:        "Validate an experiment chart and values",
+		Long:         lintDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.LocalRun(outStream)
+		},
+	}
+	addChartsParentDirFlag(cmd, &actor.ChartsParentDir)
+	addChartNameFlag(cmd, &actor.ChartName)
+	addValueFlags(cmd.Flags(), &actor.Options)
+	return cmd
+}
+
+// initialize with lint command
+func init() {
+	rootCmd.AddCommand(newLintCmd())
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+)
+
+func TestLint(t *testing.T) {
+	os.Chdir(t.TempDir())
+	tests := []cmdTestCase{
+		// lint, with CLI values
+		{
+			name:   "lint with CLI values",
+			cmd:    fmt.Sprintf("lint -c iter8 --chartsParentDir %v --set tasks={http,assess} --set http.url=https://httpbin.org --set assess.SLOs.upper.http/error-rate=0", base.CompletePath("../", "")),
+			golden: base.CompletePath("../testdata", "output/lint.txt"),
+		},
+	}
+
+	runTestActionCmd(t, tests)
+}
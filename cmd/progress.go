@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/spf13/cobra"
+)
+
+// addNoProgressFlag adds the noProgress flag to the command
+func addNoProgressFlag(cmd *cobra.Command, noProgressPtr *bool) {
+	cmd.Flags().BoolVar(noProgressPtr, "noProgress", false, "do not display progress in the terminal; use plain logs")
+	cmd.Flags().Lookup("noProgress").NoOptDefVal = "true"
+}
+
+// startProgress displays the progress of local experiments when logs are written as text to an interactive terminal.
+// In CI, with JSON or quiet logs, or with the noProgress option, plain logs are used.
+// The returned function stops the display.
+func startProgress(noProgress bool) func() {
+	if noProgress || quiet || logOutput != log.TextFormat || !ia.IsTerminal(os.Stderr) {
+		return func() {}
+	}
+	d := ia.NewProgressDisplay(os.Stderr)
+	out := log.Logger.Out
+	log.Logger.SetOutput(d)
+	base.SetProgressReporter(d)
+	d.Start()
+	return func() {
+		base.SetProgressReporter(nil)
+		d.Stop()
+		log.Logger.SetOutput(out)
+	}
+}
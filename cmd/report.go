@@ -9,13 +9,59 @@ import (
 
 // reportDesc is the description of the report cmd
 const reportDesc = `
-Generate a text or HTML report of an experiment.
+Generate a text, HTML, or SARIF report of an experiment.
 
 	$ iter8 report # same as iter8 report -o text
 
 or
 
 	$ iter8 report -o html > report.html # view with browser
+
+or
+
+	$ iter8 report -o sarif > report.sarif # SLO violations and task failures for code-scanning tools
+
+or
+
+	$ iter8 report -o prometheus # metrics and SLO verdicts in Prometheus text exposition format
+
+or
+
+	$ iter8 report -o csv > metrics.csv # metric observations of each version, one per row; for example, for pandas
+
+or
+
+	$ iter8 report -o parquet > metrics.parquet # the same observations in Apache Parquet format
+
+Experiment metrics and SLO verdicts can also be pushed to a Prometheus Pushgateway.
+
+	$ iter8 report --pushgateway http://pushgateway:9091
+
+Labels may be added to every sample; for example, to distinguish the experiments of different apps.
+
+	$ iter8 report --pushgateway http://pushgateway:9091 --labels app=httpbin,env=staging
+
+Generate a Grafana dashboard with a panel for each metric and SLO limits as thresholds. Panels query the metrics pushed to the Pushgateway, so that successive runs appear as time series. The dashboard can be imported into Grafana, or created and updated using the Grafana HTTP API.
+
+	$ iter8 report -o grafana > dashboard.json
+
+or
+
+	$ iter8 report --pushgateway http://pushgateway:9091 --grafana http://grafana:3000 --grafanaToken $TOKEN
+
+You can also render the report using your own Go template. Sprig functions and the following helpers are available within the template: versions, metric, sloSatisfied, and formatFloat.
+
+	$ iter8 report --template mytemplate.tpl
+
+Compare this experiment with other experiments side-by-side by specifying the directories containing their experiment.yaml files.
+
+	$ iter8 report --compare ../release-1,../release-2
+
+Experiments are compared as they are now; earlier runs of an experiment cannot be compared. To compare the runs of an experiment over time, record them using the historyDB option, and query them using iter8 history.
+
+Experiments in object storage are referenced by their URLs.
+
+	$ iter8 report --objectURL s3://my-bucket/release-3 --compare s3://my-bucket/release-2
 `
 
 // newReportCmd creates the report command
@@ -32,13 +78,47 @@ func newReportCmd(kd *driver.KubeDriver) *cobra.Command {
 		},
 	}
 	addOutputFormatFlag(cmd, &actor.OutputFormat)
+	addTemplateFlag(cmd, &actor.TemplateFile)
+	addCompareFlag(cmd, &actor.Compare)
+	addPushgatewayFlags(cmd, &actor.Pushgateway, &actor.PushJob)
+	addPrometheusLabelsFlag(cmd, &actor.Labels)
+	addGrafanaFlags(cmd, &actor.Grafana, &actor.GrafanaToken, &actor.GrafanaDatasource)
 	addRunDirFlag(cmd, &actor.RunDir)
+	addObjectURLFlag(cmd, &actor.ObjectURL)
 	return cmd
 }
 
 // addOutputFormatFlag adds output format flag to the report command
 func addOutputFormatFlag(cmd *cobra.Command, outputFormat *string) {
-	cmd.Flags().StringVarP(outputFormat, "outputFormat", "o", "text", "text | html")
+	cmd.Flags().StringVarP(outputFormat, "outputFormat", "o", "text", "text | html | sarif | prometheus | csv | parquet | grafana")
+}
+
+// addTemplateFlag adds the template flag to the report command
+func addTemplateFlag(cmd *cobra.Command, templateFile *string) {
+	cmd.Flags().StringVar(templateFile, "template", "", "path to a Go template used to render the report; overrides outputFormat")
+}
+
+// addCompareFlag adds the compare flag to the report command
+func addCompareFlag(cmd *cobra.Command, compare *[]string) {
+	cmd.Flags().StringSliceVar(compare, "compare", nil, "other experiments to compare with this experiment, as they are now; revisions cannot be compared; can specify multiple or separate values with commas")
+}
+
+// addPushgatewayFlags adds the Pushgateway flags to the report command
+func addPushgatewayFlags(cmd *cobra.Command, pushgateway *string, pushJob *string) {
+	cmd.Flags().StringVar(pushgateway, "pushgateway", "", "URL of a Prometheus Pushgateway to which experiment metrics and SLO verdicts are pushed")
+	cmd.Flags().StringVar(pushJob, "pushJob", ia.DefaultPushJob, "Pushgateway job under which experiment metrics are pushed")
+}
+
+// addPrometheusLabelsFlag adds the flag for labels of Prometheus samples to the report command
+func addPrometheusLabelsFlag(cmd *cobra.Command, labels *map[string]string) {
+	cmd.Flags().StringToStringVar(labels, "labels", nil, "labels added to every sample of Prometheus reports and pushed metrics; for example, app=httpbin,env=staging")
+}
+
+// addGrafanaFlags adds the Grafana flags to the report command
+func addGrafanaFlags(cmd *cobra.Command, grafana *string, token *string, datasource *string) {
+	cmd.Flags().StringVar(grafana, "grafana", "", "URL of a Grafana instance in which the experiment dashboard is created or updated")
+	cmd.Flags().StringVar(token, "grafanaToken", "", "Grafana API key or service account token used to push the dashboard")
+	cmd.Flags().StringVar(datasource, "grafanaDatasource", "", "uid of the Prometheus data source queried by dashboard panels; uses the default data source if unspecified")
 }
 
 // initialize with the report cmd
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 
 	id "github.com/iter8-tools/iter8/driver"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/iter8-tools/iter8/base"
 )
@@ -25,3 +29,81 @@ func TestReport(t *testing.T) {
 	*kd = *id.NewFakeKubeDriver(settings)
 	runTestActionCmd(t, tests)
 }
+
+func TestReportTemplate(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "assertinputs/experiment.yaml"))
+	tests := []cmdTestCase{
+		// report with user-supplied template
+		{
+			name:   "report template",
+			cmd:    fmt.Sprintf("report --template %v", base.CompletePath("../testdata", "report.tpl")),
+			golden: base.CompletePath("../testdata", "output/report-template.txt"),
+		},
+		// report with missing template
+		{
+			name:      "report missing template",
+			cmd:       "report --template missing.tpl",
+			wantError: true,
+		},
+	}
+
+	// fake kube cluster
+	*kd = *id.NewFakeKubeDriver(settings)
+	runTestActionCmd(t, tests)
+}
+
+func TestReportCompare(t *testing.T) {
+	os.Chdir(base.CompletePath("../testdata", ""))
+	tests := []cmdTestCase{
+		// report comparing experiments
+		{
+			name:   "report compare",
+			cmd:    "report --template \"\" --runDir assertinputs --compare assertinputs/noinsights",
+			golden: base.CompletePath("../testdata", "output/report-compare.txt"),
+		},
+		// html comparisons are not supported
+		{
+			name:      "report compare html",
+			cmd:       "report -o html --runDir assertinputs --compare assertinputs/noinsights",
+			wantError: true,
+		},
+	}
+
+	// fake kube cluster
+	*kd = *id.NewFakeKubeDriver(settings)
+	runTestActionCmd(t, tests)
+}
+
+func TestReportCompareLogs(t *testing.T) {
+	os.Chdir(base.CompletePath("../testdata", ""))
+	t.Cleanup(resetEnv())
+
+	// comparisons are embedded in JSON log entries
+	_, out, err := executeActionCommandC(storageFixture(), "report -o text --template \"\" --runDir assertinputs --compare assertinputs/noinsights --output json")
+	assert.NoError(t, err)
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &entry), out)
+	assert.Equal(t, "comparison of experiments", entry["msg"])
+	assert.Contains(t, entry["indented-trace"], "assertinputs/noinsights")
+
+	// and are suppressed in quiet mode
+	_, out, err = executeActionCommandC(storageFixture(), "report -o text --template \"\" --runDir assertinputs --compare assertinputs/noinsights -q")
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestReportPrometheusLabels(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "assertinputs/experiment.yaml"))
+	t.Cleanup(resetEnv())
+
+	// flags keep their values across executions; clear the comparisons of earlier tests
+	c, _, err := rootCmd.Find([]string{"report"})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Flags().Lookup("compare").Value.(pflag.SliceValue).Replace(nil))
+
+	_, out, err := executeActionCommandC(storageFixture(), "report -o prometheus --template \"\" --runDir . --labels app=httpbin,env=staging")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `iter8_experiment_completed{app="httpbin",env="staging"} 1`)
+}
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 	"os"
 
+	ia "github.com/iter8-tools/iter8/action"
 	"github.com/iter8-tools/iter8/driver"
 
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -17,6 +20,10 @@ import (
 var (
 	// default log level for Iter8 CLI
 	logLevel = "info"
+	// format of log output; text or json
+	logOutput = log.TextFormat
+	// quiet suppresses all log output except errors
+	quiet = false
 	// Default Helm and Kubernetes settings
 	settings = cli.New()
 	// Kuberdriver used by Helm and Kubernetes clients
@@ -31,30 +38,86 @@ var rootCmd = &cobra.Command{
 	Short: "Kubernetes release optimizer",
 	Long: `
 Kubernetes release optimizer.
+
+Iter8 commands use the following exit codes, so that CI/CD pipelines can act on the class of failure:
+	0	success
+	1	error in inputs or environment; for example, invalid flags, or an experiment that cannot be read
+	2	the experiment completed but its SLOs are not satisfied
+	3	a task in the experiment failed
+	4	the experiment did not complete before the timeout
+	5	the infrastructure is unavailable; for example, an unreachable cluster or metrics backend
+
+Defaults for flags may be specified in the ~/.iter8/config.yaml file, or the file named by the ITER8_CONFIG environment variable. Keys are flag names:
+	remoteFolderURL: github.com/iter8-tools/iter8.git?ref=v0.11.1//charts
+	chartsParentDir: /home/me/iter8
+	namespace: experiments
+	loglevel: debug
+
+Defaults may also be specified using ITER8_* environment variables; for example, ITER8_CHARTS_PARENT_DIR, ITER8_NAMESPACE, and ITER8_LOGLEVEL. The config file may use repoURL as an alias for remoteFolderURL (ITER8_REPO_URL in the environment), and trustedKeys as an alias for keyring (ITER8_TRUSTED_KEYS). Flags specified on the command line take precedence over environment variables, which take precedence over the config file.
+
+Log levels may be set for each subsystem of Iter8 (base, driver, action, and cmd), along with the log level of the others. For example, the following logs experiment tasks at the trace level, and storage of experiments at the warning level:
+	$ iter8 run --loglevel info,base=trace,driver=warning
+
+Authorization headers, tokens, and other secrets, such as the credentials of metrics providers, are redacted in logs.
 `,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		ll, err := logrus.ParseLevel(logLevel)
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		switch logOutput {
+		case log.TextFormat:
+		case log.JSONFormat:
+			log.Logger.SetFormat(log.JSONFormat)
+		default:
+			e := fmt.Errorf("unsupported output %v; must be %v or %v", logOutput, log.TextFormat, log.JSONFormat)
+			if cmd.Flags().Lookup("spec") != nil {
+				e = fmt.Errorf("%v; use --spec for the path of the experiment spec", e)
+			}
+			log.Logger.Error(e)
+			return e
+		}
+		ll, levels, err := log.ParseLevels(logLevel)
 		if err != nil {
 			log.Logger.Error(err)
 			return err
 		}
-		log.Logger.Level = ll
+		if quiet {
+			ll, levels = logrus.ErrorLevel, nil
+		}
+		log.Logger.SetLevels(ll, levels)
 		return nil
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Errors are printed, and the exit code reflects the class of failure.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	if err := rootCmd.Execute(); err != nil {
+		code := ia.ExitCode(err)
+		hint := ierrors.Hint(err)
+		if logOutput == log.JSONFormat {
+			entry := log.Logger.WithField("exitCode", code)
+			if hint != "" {
+				entry = entry.WithField("hint", hint)
+			}
+			entry.Error(err)
+		} else {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			if hint != "" {
+				fmt.Fprintln(os.Stderr, "Hint:", hint)
+			}
+		}
+		os.Exit(code)
+	}
 }
 
 // initialize Iter8 CLI root command
 func init() {
-	// disable completion command for now
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
-	rootCmd.PersistentFlags().StringVarP(&logLevel, "loglevel", "l", "info", "trace, debug, info, warning, error, fatal, panic")
-	rootCmd.SilenceErrors = true // will get printed in Execute() (by cobra.CheckErr())
+	rootCmd.PersistentFlags().StringVarP(&logLevel, "loglevel", "l", "info", "trace, debug, info, warning, error, fatal, panic; optionally followed by levels of subsystems (base, driver, action, cmd), for example, info,base=trace")
+	rootCmd.PersistentFlags().StringVar(&logOutput, "output", log.TextFormat, fmt.Sprintf("format of log output; %v or %v", log.TextFormat, log.JSONFormat))
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log errors; overrides loglevel")
+	rootCmd.SilenceErrors = true // will get printed in Execute()
 }
 
 // addValueFlags adds flags that enable supplying values to the given command
@@ -66,3 +129,8 @@ func addValueFlags(f *pflag.FlagSet, v *values.Options) {
 	f.StringArrayVar(&v.StringValues, "set-string", []string{}, "set STRING values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
 	f.StringArrayVar(&v.FileValues, "set-file", []string{}, "set values from respective files specified via the command line (can specify multiple or separate values with commas: key1=path1,key2=path2)")
 }
+
+// addProfileFlag adds the profile flag, which selects profiles defined in values files, to the given command
+func addProfileFlag(f *pflag.FlagSet, profilesPtr *[]string) {
+	f.StringSliceVar(profilesPtr, "profile", []string{}, "apply a profile defined under the profiles key of values files, on top of the values files (can specify multiple)")
+}
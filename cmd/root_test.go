@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	id "github.com/iter8-tools/iter8/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONOutput(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "experiment.yaml"))
+	t.Cleanup(resetEnv())
+
+	_, out, err := executeActionCommandC(storageFixture(), "simulate --output json")
+	assert.NoError(t, err)
+
+	// every log entry is a JSON object
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		entry := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		assert.Contains(t, entry, "level")
+		assert.Contains(t, entry, "msg")
+		assert.Contains(t, entry, "time")
+	}
+	assert.Contains(t, out, `"msg":"task 1: http : completed"`)
+	// stack traces are embedded as raw strings
+	assert.Contains(t, out, `"stack-trace":"false condition: not SLOs()"`)
+}
+
+func TestQuiet(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "experiment.yaml"))
+	tests := []cmdTestCase{
+		// informational logs are suppressed
+		{
+			name:   "quiet",
+			cmd:    "simulate -q",
+			golden: base.CompletePath("../testdata", "output/quiet.txt"),
+		},
+		// errors are still logged
+		{
+			name:      "quiet with error",
+			cmd:       "simulate -q -s missing.yaml",
+			golden:    base.CompletePath("../testdata", "output/quiet-error.txt"),
+			wantError: true,
+		},
+		// unsupported output
+		{
+			name:      "unsupported output",
+			cmd:       "simulate --output yaml",
+			wantError: true,
+		},
+	}
+
+	runTestActionCmd(t, tests)
+}
+
+func TestSubsystemLogLevels(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "experiment.yaml"))
+	t.Cleanup(resetEnv())
+
+	// tasks are logged by the base subsystem
+	_, out, err := executeActionCommandC(storageFixture(), "simulate -s '' --loglevel error,base=info")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "task 1: http : completed")
+
+	_, out, err = executeActionCommandC(storageFixture(), "simulate -s '' --loglevel info,base=error")
+	assert.NoError(t, err)
+	assert.NotContains(t, out, "task 1: http : completed")
+
+	_, _, err = executeActionCommandC(storageFixture(), "simulate -s '' --loglevel info,kube=trace")
+	assert.Error(t, err)
+}
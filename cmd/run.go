@@ -15,12 +15,33 @@ Run an experiment specified in experiment.yaml. This command will modify the exp
 
 	$ iter8 run
 
+If a previous run failed or was aborted, use the resume option to continue from the first incomplete task instead of restarting the experiment. The stored result, including metrics collected by completed tasks, is reused.
+
+	$ iter8 run --resume
+
+If the OTEL_EXPORTER_OTLP_ENDPOINT (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) environment variable is set, the experiment run and its tasks are exported as OpenTelemetry spans using OTLP/HTTP.
+
+	$ OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4318 iter8 run
+
+Use the objectURL option to write the experiment result to Amazon S3, Google Cloud Storage, or Azure Blob storage instead of experiment.yaml. The experiment is read from the run directory until its result is first written. Credentials are obtained from the environment; for example, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or IAM roles for service accounts in Amazon EKS; GKE workload identity, or GOOGLE_OAUTH_ACCESS_TOKEN; and AZURE_STORAGE_CONNECTION_STRING, or AZURE_STORAGE_ACCOUNT with AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN.
+
+	$ iter8 run --objectURL s3://my-bucket/experiments/my-experiment
+
+Use the historyDB option to record the experiment run in a Postgres or SQLite database. Past runs can be queried using the iter8 history command.
+
+	$ iter8 run --historyDB sqlite://history.db
+
+When logs are written to an interactive terminal, the progress of the experiment is displayed, including the running task, the progress of load tests with their current rate and latency, and the loop of looping experiments. In CI (when the CI environment variable is set), or with the noProgress option, plain logs are used.
+
+	$ iter8 run --noProgress
+
 This command is intended for development and testing of experiment charts and tasks. For production usage, the iter8 launch command is recommended.
 `
 
 // newRunCmd creates the run command
 func newRunCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 	actor := ia.NewRunOpts(kd)
+	noProgress := false
 
 	cmd := &cobra.Command{
 		Use:          "run",
@@ -28,11 +49,16 @@ func newRunCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 		Long:         runDesc,
 		SilenceUsage: true,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			defer startProgress(noProgress)()
 			return actor.LocalRun()
 		},
 	}
 	addRunDirFlag(cmd, &actor.RunDir)
+	addObjectURLFlag(cmd, &actor.ObjectURL)
+	addHistoryFlags(cmd, &actor.HistoryDB, &actor.HistoryName)
 	addReuseResult(cmd, &actor.ReuseResult)
+	addResumeFlag(cmd, &actor.Resume)
+	addNoProgressFlag(cmd, &noProgress)
 	return cmd
 }
 
@@ -41,12 +67,22 @@ func addRunDirFlag(cmd *cobra.Command, runDirPtr *string) {
 	cmd.Flags().StringVar(runDirPtr, "runDir", ".", "directory where experiment is run; contains experiment.yaml")
 }
 
+// addObjectURLFlag adds the object storage URL flag to the command
+func addObjectURLFlag(cmd *cobra.Command, objectURLPtr *string) {
+	cmd.Flags().StringVar(objectURLPtr, "objectURL", "", "URL of the experiment in object storage; for example, s3://bucket/folder, gs://bucket/folder, or azblob://container/folder")
+}
+
 // addReuseResult allows the experiment to reuse the experiment result for
 // looping experiments
 func addReuseResult(cmd *cobra.Command, reuseResultPtr *bool) {
 	cmd.Flags().BoolVar(reuseResultPtr, "reuseResult", false, "reuse experiment result; useful for experiments with multiple loops")
 }
 
+// addResumeFlag adds the resume flag to the command
+func addResumeFlag(cmd *cobra.Command, resumePtr *bool) {
+	cmd.Flags().BoolVar(resumePtr, "resume", false, "continue the experiment from the first incomplete task using the stored result")
+}
+
 // initialize with run cmd
 func init() {
 	rootCmd.AddCommand(newRunCmd(kd, os.Stdout))
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/driver"
+
+	"github.com/spf13/cobra"
+)
+
+// serveDesc is the description of the serve cmd
+const serveDesc = `
+Serve the verdict of an experiment over HTTP.
+
+	$ iter8 serve --port 8080
+
+The verdict is available at the /verdict endpoint as a JSON object. Its 'pass' field is true if the experiment completed without failures and all app versions satisfy SLOs. The verdict also includes metric values for each app version.
+
+	$ curl http://localhost:8080/verdict
+
+Use this endpoint with the Argo Rollouts web metric provider to drive promotion steps using Iter8 assessments. For example, use the success condition: result.pass == true.
+
+A Flagger-compatible webhook is available at the /flagger endpoint. It responds with status 200 if the experiment completed without failures and all app versions satisfy SLOs, and with status 412 otherwise. Use it as a Flagger webhook to gate canary analysis on Iter8 assessments. The conditions and experiment group can be set using the conditions and group keys in the webhook metadata.
+
+The server listens on localhost by default. Use the --address flag to listen on other interfaces; for example, --address 0.0.0.0 listens on all of them.
+
+Use the --api flag to enable the experiments API, which lets platforms run experiments without the CLI. Experiments are submitted as YAML or JSON, run one at a time in the order submitted, and the most recent ones are kept in memory. Requests to the experiments API must present the token set using the --api-token flag, or the ITER8_API_TOKEN environment variable, as a bearer token. Submitted experiments may only use the tasks listed using the --api-tasks flag; by default, these are the assess, compare, grpc, and http tasks. Allow tasks that run scripts or executables on the server, such as run tasks, background tasks, and plugins, only if the clients of the experiments API are trusted.
+
+	$ export ITER8_API_TOKEN=$(openssl rand -hex 16)
+	$ iter8 serve --api
+	$ curl -X POST -H "Authorization: Bearer $ITER8_API_TOKEN" --data-binary @experiment.yaml http://localhost:8080/experiments
+
+	GET    /experiments                lists submitted experiments
+	POST   /experiments                submits an experiment, and responds with its id
+	GET    /experiments/<id>           status, verdict, and result of the experiment
+	GET    /experiments/<id>/insights  insights of the experiment
+	GET    /experiments/<id>/logs      logs of the experiment; use ?follow=true to stream them until the experiment ends
+	DELETE /experiments/<id>           stops the experiment after its current task
+`
+
+// newServeCmd creates the serve command
+func newServeCmd(kd *driver.KubeDriver) *cobra.Command {
+	actor := ia.NewServeOpts(kd)
+
+	cmd := &cobra.Command{
+		Use:          "serve",
+		Short:        "Serve experiment verdict over HTTP",
+		Long:         serveDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.LocalRun()
+		},
+	}
+	addAddressFlag(cmd, &actor.Address)
+	addPortFlag(cmd, &actor.Port)
+	cmd.Flags().BoolVar(&actor.API, "api", false, "enable the experiments API")
+	cmd.Flags().StringVar(&actor.APIToken, "api-token", "", "bearer token required by the experiments API")
+	cmd.Flags().StringSliceVar(&actor.APITasks, "api-tasks", ia.DefaultAPITasks, "tasks that experiments submitted to the experiments API may use")
+	addRunDirFlag(cmd, &actor.RunDir)
+	addObjectURLFlag(cmd, &actor.ObjectURL)
+	return cmd
+}
+
+// addAddressFlag adds the address flag to the command
+func addAddressFlag(cmd *cobra.Command, addressPtr *string) {
+	cmd.Flags().StringVar(addressPtr, "address", ia.DefaultServeAddress, "host or IP address on which the server listens; use 0.0.0.0 for all interfaces")
+}
+
+// addPortFlag adds the port flag to the command
+func addPortFlag(cmd *cobra.Command, portPtr *int) {
+	cmd.Flags().IntVar(portPtr, "port", ia.DefaultServePort, "port on which the server listens")
+}
+
+// initialize with the serve cmd
+func init() {
+	rootCmd.AddCommand(newServeCmd(kd))
+}
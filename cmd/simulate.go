@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/spf13/cobra"
+)
+
+// simulateDesc is the description of the simulate command
+const simulateDesc = `
+Simulate an experiment specified in experiment.yaml using synthetic metrics. Load generation and metrics collection tasks do not send requests to apps or query metrics databases; instead, metrics are generated from the distributions in a simulation file. Tasks that interact with apps or external services, such as readiness checks, notifications, and run commands, are skipped. SLOs and task conditions are evaluated as usual, and the result is stored in experiment.yaml, so that it can be used with the report and assert commands.
+
+	$ iter8 simulate -s simulation.yaml
+	$ iter8 report
+
+A simulation file describes the latencies (in msec) and error rates of requests for http and grpc tasks, and the values of custom metrics for each version. Latencies may follow a normal (mean, stdDev), uniform (min, max), or exponential (mean) distribution.
+
+	seed: 7
+	http:
+	  numRequests: 200
+	  errorRate: 0.02
+	  latency:
+	    distribution: normal
+	    mean: 40
+	    stdDev: 8
+	customMetrics:
+	  istio/error-rate: [0.01, 0.03]
+
+If no simulation file is specified, latencies follow a normal distribution with mean 50 and standard deviation 10, and there are no errors.
+
+This command is intended for development and testing of experiment charts, SLOs, task conditions, and reports.
+`
+
+// newSimulateCmd creates the simulate command
+func newSimulateCmd() *cobra.Command {
+	actor := ia.NewSimulateOpts()
+
+	cmd := &cobra.Command{
+		Use:          "simulate",
+		Short:        "Simulate an experiment using synthetic metrics",
+		Long:         simulateDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return actor.LocalRun()
+		},
+	}
+	addRunDirFlag(cmd, &actor.RunDir)
+	addSimulationFileFlag(cmd, &actor.SimulationFile)
+	return cmd
+}
+
+// addSimulationFileFlag adds the simulation file flag to the command
+func addSimulationFileFlag(cmd *cobra.Command, simulationFilePtr *string) {
+	cmd.Flags().StringVarP(simulationFilePtr, "simulation", "s", "", "YAML file describing the synthetic metrics")
+}
+
+// initialize with simulate cmd
+func init() {
+	rootCmd.AddCommand(newSimulateCmd())
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	id "github.com/iter8-tools/iter8/driver"
+)
+
+func TestSimulate(t *testing.T) {
+	os.Chdir(t.TempDir())
+	id.CopyFileToPwd(t, base.CompletePath("../testdata", "experiment.yaml"))
+	tests := []cmdTestCase{
+		// simulate with a simulation file
+		{
+			name:   "simulate",
+			cmd:    fmt.Sprintf("simulate -s %v", base.CompletePath("../testdata", "simulation.yaml")),
+			golden: base.CompletePath("../testdata", "output/simulate.txt"),
+		},
+		// simulate with a missing simulation file
+		{
+			name:      "simulate with missing simulation file",
+			cmd:       "simulate -s missing.yaml",
+			wantError: true,
+		},
+	}
+
+	runTestActionCmd(t, tests)
+}
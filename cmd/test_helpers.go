@@ -95,6 +95,11 @@ func executeActionCommandStdinC(store *storage.Storage, in *os.File, cmd string)
 	log.Logger.Out = buf
 	outStream = buf
 
+	// ignore the config file of the user running the tests
+	if _, ok := os.LookupEnv(configEnvVar); !ok {
+		os.Setenv(configEnvVar, "")
+	}
+
 	oldStdin := os.Stdin
 	if in != nil {
 		rootCmd.SetIn(in)
@@ -135,6 +140,9 @@ func resetEnv() func() {
 			os.Setenv(kv[0], kv[1])
 		}
 		logLevel = "info"
+		logOutput = log.TextFormat
+		quiet = false
+		log.Logger.SetFormat(log.TextFormat)
 		log.Logger.Out = os.Stderr
 	}
 }
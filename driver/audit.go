@@ -0,0 +1,163 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/iter8/base/log"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// AuditConfigMapName is the name of the config map in which the audit log of experiment lifecycle actions
+	// in a namespace is recorded
+	AuditConfigMapName = "iter8-audit"
+	// auditLogKey is the key of the config map data with the audit log; entries are JSON lines
+	auditLogKey = "audit.jsonl"
+
+	// LaunchAuditAction is recorded when an experiment group is launched
+	LaunchAuditAction = "launch"
+	// UpgradeAuditAction is recorded when an experiment group is upgraded to a new revision
+	UpgradeAuditAction = "upgrade"
+	// AbortAuditAction is recorded when an experiment is aborted
+	AbortAuditAction = "abort"
+	// DeleteAuditAction is recorded when an experiment group is deleted
+	DeleteAuditAction = "delete"
+)
+
+// AuditEntry records an experiment lifecycle action in the audit log
+type AuditEntry struct {
+	// Time of the action
+	Time time.Time `json:"time"`
+	// Action is launch, upgrade, abort, or delete
+	Action string `json:"action"`
+	// Group is the experiment group
+	Group string `json:"group"`
+	// Revision of the experiment group after the action
+	Revision int `json:"revision,omitempty"`
+	// User is the operating system user who performed the action
+	User string `json:"user,omitempty"`
+	// KubeUser is the kubeconfig user with which the action was performed
+	KubeUser string `json:"kubeUser,omitempty"`
+	// Owner of the experiment group
+	Owner string `json:"owner,omitempty"`
+	// Values are the chart values used to launch or upgrade the experiment group
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// kubeUser returns the name of the kubeconfig user of the current context, if any
+func (driver *KubeDriver) kubeUser() string {
+	if driver.EnvSettings == nil {
+		return ""
+	}
+	cfg, err := driver.EnvSettings.RESTClientGetter().ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return ""
+	}
+	name := cfg.CurrentContext
+	if driver.KubeContext != "" {
+		name = driver.KubeContext
+	}
+	if c, ok := cfg.Contexts[name]; ok {
+		return c.AuthInfo
+	}
+	return ""
+}
+
+// audit appends an entry for the given action on the given revision of the experiment group to the audit log of the namespace.
+// Failures are logged, and do not fail the action.
+func (driver *KubeDriver) audit(action string, group string, revision int, vals map[string]interface{}) {
+	entry := AuditEntry{
+		Time:     time.Now().UTC(),
+		Action:   action,
+		Group:    group,
+		Revision: revision,
+		KubeUser: driver.kubeUser(),
+		Owner:    driver.Owner,
+		Values:   vals,
+	}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	if err := driver.appendAuditEntry(entry); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warnf("unable to record %v of experiment group %v in audit log", action, group)
+	}
+}
+
+// appendAuditEntry appends the entry to the audit log, creating the audit config map if needed.
+// Entries are only ever appended; concurrent appends are retried on conflict.
+func (driver *KubeDriver) appendAuditEntry(entry AuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line := string(b) + "\n"
+	cms := driver.Clientset.CoreV1().ConfigMaps(driver.Namespace())
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := cms.Get(context.Background(), AuditConfigMapName, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			_, err = cms.Create(context.Background(), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   AuditConfigMapName,
+					Labels: map[string]string{managedByKey: managedByValue},
+				},
+				Data: map[string]string{auditLogKey: line},
+			}, metav1.CreateOptions{})
+			if kerrors.IsAlreadyExists(err) {
+				// another writer created the config map; retry the append
+				return kerrors.NewConflict(corev1.Resource("configmaps"), AuditConfigMapName, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[auditLogKey] += line
+		_, err = cms.Update(context.Background(), cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// ReadAuditLog reads the entries of the audit log of the namespace, oldest first.
+// Only entries of the experiment group of the driver are returned, unless all is true.
+// If the driver has an owner, only entries of the owner are returned.
+func (driver *KubeDriver) ReadAuditLog(all bool) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	cm, err := driver.Clientset.CoreV1().ConfigMaps(driver.Namespace()).Get(context.Background(), AuditConfigMapName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return entries, nil
+	}
+	if err != nil {
+		e := fmt.Errorf("unable to read audit log in namespace %v", driver.Namespace())
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	for _, line := range strings.Split(cm.Data[auditLogKey], "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry := AuditEntry{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			log.Logger.WithStackTrace(err.Error()).Warn("skipping invalid audit log entry")
+			continue
+		}
+		if !all && entry.Group != driver.Group {
+			continue
+		}
+		if driver.Owner != "" && entry.Owner != driver.Owner {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,80 @@
+package driver
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAuditLog(t *testing.T) {
+	os.Chdir(t.TempDir())
+	kd := NewFakeKubeDriver(cli.New())
+	err := kd.Init()
+	assert.NoError(t, err)
+
+	// no audit log yet
+	entries, err := kd.ReadAuditLog(false)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// dry runs are not recorded
+	opts := values.Options{
+		Values: []string{"tasks={http}", "http.url=https://httpbin.org/get", "runner=job"},
+	}
+	dry := *kd
+	err = dry.Launch(base.CompletePath("../", "charts/iter8"), opts, kd.Group, true)
+	assert.NoError(t, err)
+	entries, err = kd.ReadAuditLog(false)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// launch, upgrade, and delete
+	err = kd.Launch(base.CompletePath("../", "charts/iter8"), opts, kd.Group, false)
+	assert.NoError(t, err)
+	err = kd.Upgrade(base.CompletePath("../", "charts/iter8"), opts, kd.Group, false, false)
+	assert.NoError(t, err)
+	err = kd.Delete()
+	assert.NoError(t, err)
+
+	// actions on another group
+	other := *kd
+	other.Group = "other"
+	other.audit(AbortAuditAction, other.Group, 3, nil)
+
+	entries, err = kd.ReadAuditLog(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, LaunchAuditAction, entries[0].Action)
+	assert.Equal(t, 1, entries[0].Revision)
+	assert.Equal(t, "https://httpbin.org/get", entries[0].Values["http"].(map[string]interface{})["url"])
+	assert.Equal(t, UpgradeAuditAction, entries[1].Action)
+	assert.Equal(t, 2, entries[1].Revision)
+	assert.Equal(t, DeleteAuditAction, entries[2].Action)
+	assert.Equal(t, 2, entries[2].Revision)
+	assert.Nil(t, entries[2].Values)
+	for _, e := range entries {
+		assert.Equal(t, kd.Group, e.Group)
+		assert.False(t, e.Time.IsZero())
+	}
+
+	entries, err = kd.ReadAuditLog(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "other", entries[3].Group)
+
+	// entries of other owners are not returned
+	kd.Owner = "team-a"
+	entries, err = kd.ReadAuditLog(true)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// the audit log is not removed with the experiment group
+	_, err = kd.Clientset.CoreV1().ConfigMaps(kd.Namespace()).Get(context.Background(), AuditConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+}
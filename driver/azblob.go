@@ -0,0 +1,171 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// azureStorageVersion is the version of the Azure Storage REST API
+	azureStorageVersion = "2020-10-02"
+)
+
+// azureBlobClient gets and puts blobs in an Azure Blob storage container
+type azureBlobClient struct {
+	account    string
+	container  string
+	endpoint   string
+	httpClient *http.Client
+	// key is the decoded shared key of the storage account, if any
+	key []byte
+	// sas is the shared access signature, if any
+	sas string
+}
+
+// parseConnectionString parses an Azure Storage connection string into its settings
+func parseConnectionString(s string) map[string]string {
+	settings := map[string]string{}
+	for _, kv := range strings.Split(s, ";") {
+		if i := strings.Index(kv, "="); i > 0 {
+			settings[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+		}
+	}
+	return settings
+}
+
+// newAzureBlobClient creates and returns a new client for the container.
+// The storage account and its credentials are obtained from the AZURE_STORAGE_CONNECTION_STRING environment variable,
+// or from the AZURE_STORAGE_ACCOUNT, and AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN environment variables.
+func newAzureBlobClient(container string, httpClient *http.Client) (*azureBlobClient, error) {
+	a := &azureBlobClient{
+		container:  container,
+		httpClient: httpClient,
+		account:    os.Getenv("AZURE_STORAGE_ACCOUNT"),
+		sas:        os.Getenv("AZURE_STORAGE_SAS_TOKEN"),
+	}
+	key := os.Getenv("AZURE_STORAGE_KEY")
+	if cs := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); cs != "" {
+		settings := parseConnectionString(cs)
+		a.account = settings["AccountName"]
+		a.endpoint = strings.TrimSuffix(settings["BlobEndpoint"], "/")
+		key = settings["AccountKey"]
+		a.sas = settings["SharedAccessSignature"]
+	}
+	if a.account == "" {
+		return nil, errors.New("no Azure storage account; set AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING")
+	}
+	if a.endpoint == "" {
+		a.endpoint = fmt.Sprintf("https://%v.blob.core.windows.net", a.account)
+	}
+	a.sas = strings.TrimPrefix(a.sas, "?")
+	if key != "" {
+		k, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure storage account key: %v", err)
+		}
+		a.key = k
+	}
+	if a.key == nil && a.sas == "" {
+		return nil, errors.New("no Azure storage credentials; set AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN")
+	}
+	return a, nil
+}
+
+// blobURL returns the URL of the blob
+func (a *azureBlobClient) blobURL(key string) string {
+	u := fmt.Sprintf("%v/%v/%v", a.endpoint, a.container, (&url.URL{Path: key}).EscapedPath())
+	if a.key == nil {
+		u += "?" + a.sas
+	}
+	return u
+}
+
+// signSharedKey signs the request using the shared key of the storage account
+func (a *azureBlobClient) signSharedKey(req *http.Request) {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	// canonicalized headers include all x-ms-* headers sorted by name
+	names := []string{}
+	for k := range req.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-ms-") {
+			names = append(names, lk)
+		}
+	}
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, k := range names {
+		headers.WriteString(k + ":" + strings.TrimSpace(req.Header.Get(k)) + "\n")
+	}
+
+	// canonicalized resource includes the account, the path, and query parameters sorted by name
+	var resource strings.Builder
+	resource.WriteString("/" + a.account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	keys := []string{}
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		vs := query[k]
+		sort.Strings(vs)
+		resource.WriteString("\n" + strings.ToLower(k) + ":" + strings.Join(vs, ","))
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date; x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		headers.String() + resource.String(),
+	}, "\n")
+	signature := base64.StdEncoding.EncodeToString(hmacSHA256(a.key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %v:%v", a.account, signature))
+}
+
+// do authorizes and sends the request
+func (a *azureBlobClient) do(method string, key string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, a.blobURL(key), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/yaml")
+		req.Header.Set("x-ms-blob-type", "BlockBlob")
+	}
+	if a.key != nil {
+		a.signSharedKey(req)
+	}
+	return doObjectRequest(a.httpClient, req)
+}
+
+func (a *azureBlobClient) get(key string) ([]byte, error) {
+	return a.do(http.MethodGet, key, nil)
+}
+
+func (a *azureBlobClient) put(key string, b []byte) error {
+	_, err := a.do(http.MethodPut, key, b)
+	return err
+}
@@ -1,7 +1,10 @@
 package driver
 
 import (
+	"errors"
+
 	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
 	"sigs.k8s.io/yaml"
 )
@@ -18,8 +21,33 @@ func ExperimentFromBytes(b []byte) (*base.Experiment, error) {
 	e := base.Experiment{}
 	err := yaml.Unmarshal(b, &e)
 	if err != nil {
-		log.Logger.WithStackTrace(err.Error()).Error("unable to unmarshal experiment")
-		return nil, err
+		e := ierrors.Wrap(ierrors.ErrInvalidExperiment, err, "unable to unmarshal experiment")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
 	}
 	return &e, err
 }
+
+// errStaleExperiment is returned when an experiment is not written because the stored experiment is newer
+var errStaleExperiment = errors.New("experiment was updated by another writer")
+
+// isStale returns true if the stored experiment has progressed beyond the experiment being written,
+// for example, because it was updated by a later revision, run, or loop of the experiment.
+// Writing a stale experiment would lose the updates made by the other writer.
+func isStale(e *base.Experiment, stored *base.Experiment) bool {
+	if stored == nil || stored.Result == nil {
+		return false
+	}
+	if e.Result == nil {
+		return true
+	}
+	switch {
+	case stored.Result.Revision != e.Result.Revision:
+		return stored.Result.Revision > e.Result.Revision
+	case !stored.Result.StartTime.Equal(e.Result.StartTime):
+		return stored.Result.StartTime.After(e.Result.StartTime)
+	case stored.Result.NumLoops != e.Result.NumLoops:
+		return stored.Result.NumLoops > e.Result.NumLoops
+	}
+	return stored.Result.NumCompletedTasks > e.Result.NumCompletedTasks
+}
@@ -0,0 +1,238 @@
+package driver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// compressThreshold is the size of experiments in bytes above which they are compressed in secrets and config maps.
+	// Smaller experiments are stored as plain YAML, which is easier to inspect.
+	compressThreshold = 256 * 1024
+	// sizeBudget is the maximum size in bytes of the experiment data stored in a single Kubernetes object,
+	// leaving room for metadata within the 1MiB limit on objects.
+	// Sample metrics are downsampled when experiments exceed this budget.
+	sizeBudget = 768 * 1024
+	// maxParts is the maximum number of objects across which an experiment is split
+	maxParts = 8
+	// minSamples is the number of observations of sample metrics for each version below which they are not downsampled
+	minSamples = 100
+	// compressedPrefix is the prefix of experiments compressed using gzip and encoded using base64
+	compressedPrefix = "H4sI"
+	// partsHeader begins the header of experiments that are split across objects
+	partsHeader = "#iter8.tools/parts "
+	// readPartsAttempts is the number of attempts to read consistent parts of a split experiment
+	readPartsAttempts = 3
+)
+
+// errInconsistentParts indicates that the parts of a split experiment are from different writes,
+// which happens when the experiment is read while it is being written
+var errInconsistentParts = errors.New("parts of experiment are inconsistent")
+
+// compressExperiment compresses the experiment data using gzip and encodes it using base64
+func compressExperiment(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// decompressExperiment decodes and decompresses experiment data that is compressed;
+// plain experiment data is returned as is
+func decompressExperiment(b []byte) ([]byte, error) {
+	if !bytes.HasPrefix(b, []byte(compressedPrefix)) {
+		return b, nil
+	}
+	z, err := base64.StdEncoding.DecodeString(string(b))
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(z))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// downsampleSamples halves the number of observations of sample metrics for each version.
+// It returns false if there are no sample metrics with enough observations to downsample.
+func downsampleSamples(e *base.Experiment) bool {
+	if e.Result == nil || e.Result.Insights == nil {
+		return false
+	}
+	in := e.Result.Insights
+	downsampled := false
+	for m, mm := range in.MetricsInfo {
+		if mm.Type != base.SampleMetricType {
+			continue
+		}
+		for i := range in.NonHistMetricValues {
+			vals := in.NonHistMetricValues[i][m]
+			if len(vals) < 2*minSamples {
+				continue
+			}
+			kept := make([]float64, 0, (len(vals)+1)/2)
+			for j := 0; j < len(vals); j += 2 {
+				kept = append(kept, vals[j])
+			}
+			in.NonHistMetricValues[i][m] = kept
+			downsampled = true
+		}
+	}
+	return downsampled
+}
+
+// encodeExperiment returns the data of the experiment to be stored.
+// If compress is true, experiments larger than compressThreshold are compressed.
+// Sample metrics are downsampled while the data exceeds sizeBudget; a copy of the experiment is downsampled,
+// so that the experiment being run retains all its observations.
+func encodeExperiment(e *base.Experiment, compress bool) []byte {
+	copied := false
+	for {
+		y, _ := yaml.Marshal(e)
+		b := y
+		if compress && len(b) > compressThreshold {
+			b = compressExperiment(b)
+		}
+		if len(b) <= sizeBudget {
+			return b
+		}
+		if !copied {
+			log.Logger.Warnf("experiment size %v bytes exceeds the budget of %v bytes; downsampling sample metrics", len(b), sizeBudget)
+			c, err := ExperimentFromBytes(y)
+			if err != nil {
+				return b
+			}
+			e = c
+			copied = true
+		}
+		if !downsampleSamples(e) {
+			log.Logger.Warnf("experiment size %v bytes exceeds the budget of %v bytes after downsampling", len(b), sizeBudget)
+			return b
+		}
+	}
+}
+
+// partName is the name of the object storing the given part of a split experiment
+func partName(name string, i int) string {
+	return fmt.Sprintf("%v-part-%v", name, i)
+}
+
+// writeParts splits the experiment data into parts that fit within sizeBudget,
+// and writes all but the first part into separate objects.
+// The returned data is to be stored in the experiment record; if the experiment is split,
+// it is the first part preceded by a header with the number of parts and the checksum of the experiment data.
+// The header is part of the data rather than an annotation, so that it is dropped
+// when the experiment is rewritten, for example, by a Helm upgrade.
+func (driver *KubeDriver) writeParts(store experimentStore, name string, b []byte) ([]byte, error) {
+	if len(b) <= sizeBudget {
+		return b, nil
+	}
+	parts := [][]byte{}
+	for rest := b; len(rest) > 0; {
+		n := sizeBudget
+		if n > len(rest) {
+			n = len(rest)
+		}
+		parts = append(parts, rest[:n])
+		rest = rest[n:]
+	}
+	if len(parts) > maxParts {
+		return nil, fmt.Errorf("experiment is too large to store; %v parts needed, at most %v allowed", len(parts), maxParts)
+	}
+	log.Logger.Debugf("splitting experiment into %v parts", len(parts))
+	for i := 1; i < len(parts); i++ {
+		pr := &experimentRecord{
+			Name:        partName(name, i),
+			Labels:      driver.groupLabels(),
+			Annotations: map[string]string{groupKey: driver.Group},
+			Data:        parts[i],
+		}
+		err := store.update(context.Background(), pr)
+		if kerrors.IsNotFound(err) {
+			err = store.create(context.Background(), pr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	header := fmt.Sprintf("%v%v %v\n", partsHeader, len(parts), sha256Hex(b))
+	return append([]byte(header), parts[0]...), nil
+}
+
+// packExperiment returns the experiment data to be stored in the experiment record.
+// If packed is true, the experiment is split across objects when needed.
+func (driver *KubeDriver) packExperiment(store experimentStore, name string, b []byte, packed bool) ([]byte, error) {
+	if !packed {
+		return b, nil
+	}
+	return driver.writeParts(store, name, b)
+}
+
+// numParts returns the number of parts of the experiment data in the record;
+// experiments that are not split have a single part
+func numParts(r *experimentRecord) int {
+	if !bytes.HasPrefix(r.Data, []byte(partsHeader)) {
+		return 1
+	}
+	var n int
+	if _, err := fmt.Sscanf(string(r.Data[len(partsHeader):]), "%d", &n); err != nil || n < 1 || n > maxParts {
+		return 1
+	}
+	return n
+}
+
+// deleteStaleParts deletes the objects storing parts of an earlier write of the experiment
+// that are beyond the given number of parts of the latest write
+func deleteStaleParts(store experimentStore, name string, parts int, earlierParts int) {
+	for i := parts; i < earlierParts; i++ {
+		err := store.delete(context.Background(), partName(name, i), metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			log.Logger.WithStackTrace(err.Error()).Warnf("unable to delete stale part %v of experiment in %v %v", i, store.kind(), name)
+		}
+	}
+}
+
+// readParts returns the experiment data in the record, joined with the parts stored in separate objects
+// if the experiment is split, and decompressed if it is compressed.
+// If the parts are inconsistent, errInconsistentParts is returned.
+func readParts(store experimentStore, r *experimentRecord) ([]byte, error) {
+	b := r.Data
+	if bytes.HasPrefix(b, []byte(partsHeader)) {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid header of split experiment in %v %v", store.kind(), r.Name)
+		}
+		var n int
+		var checksum string
+		if _, err := fmt.Sscanf(string(b[len(partsHeader):i]), "%d %s", &n, &checksum); err != nil || n < 1 || n > maxParts {
+			return nil, fmt.Errorf("invalid header of split experiment in %v %v", store.kind(), r.Name)
+		}
+		parts := [][]byte{b[i+1:]}
+		for j := 1; j < n; j++ {
+			pr, err := store.get(context.Background(), partName(r.Name, j))
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, pr.Data)
+		}
+		b = bytes.Join(parts, nil)
+		if sha256Hex(b) != checksum {
+			return nil, errInconsistentParts
+		}
+	}
+	return decompressExperiment(b)
+}
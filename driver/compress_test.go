@@ -0,0 +1,125 @@
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// largeExperiment returns the experiment in assertinputs along with a metric of the given type
+// with the given number of random observations for each version
+func largeExperiment(t *testing.T, metricType base.MetricType, n int) *base.Experiment {
+	byteArray, err := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", ExperimentPath))
+	assert.NoError(t, err)
+	exp, err := ExperimentFromBytes(byteArray)
+	assert.NoError(t, err)
+	in := exp.Result.Insights
+	in.MetricsInfo["test/large"] = base.MetricMeta{Description: "large metric", Type: metricType}
+	r := rand.New(rand.NewSource(0))
+	for i := range in.NonHistMetricValues {
+		vals := make([]float64, n)
+		for j := range vals {
+			vals[j] = r.Float64()
+		}
+		in.NonHistMetricValues[i]["test/large"] = vals
+	}
+	return exp
+}
+
+func TestCompressExperiment(t *testing.T) {
+	b := []byte("spec: []\n")
+	c := compressExperiment(b)
+	assert.Equal(t, compressedPrefix, string(c[:len(compressedPrefix)]))
+	d, err := decompressExperiment(c)
+	assert.NoError(t, err)
+	assert.Equal(t, b, d)
+
+	// plain experiments are returned as is
+	d, err = decompressExperiment(b)
+	assert.NoError(t, err)
+	assert.Equal(t, b, d)
+}
+
+func TestDownsampleSamples(t *testing.T) {
+	exp := largeExperiment(t, base.SampleMetricType, 1001)
+	assert.True(t, downsampleSamples(exp))
+	assert.Equal(t, 501, len(exp.Result.Insights.NonHistMetricValues[0]["test/large"]))
+
+	// sample metrics with few observations are not downsampled
+	exp = largeExperiment(t, base.SampleMetricType, minSamples)
+	assert.False(t, downsampleSamples(exp))
+
+	// other metrics are not downsampled
+	exp = largeExperiment(t, base.GaugeMetricType, 1001)
+	assert.False(t, downsampleSamples(exp))
+
+	// experiments over budget are downsampled until they fit
+	exp = largeExperiment(t, base.SampleMetricType, 200000)
+	b := encodeExperiment(exp, true)
+	assert.LessOrEqual(t, len(b), sizeBudget)
+	d, err := decompressExperiment(b)
+	assert.NoError(t, err)
+	stored, err := ExperimentFromBytes(d)
+	assert.NoError(t, err)
+	assert.Less(t, len(stored.Result.Insights.NonHistMetricValues[0]["test/large"]), 200000)
+	// the experiment being run is not downsampled
+	assert.Equal(t, 200000, len(exp.Result.Insights.NonHistMetricValues[0]["test/large"]))
+}
+
+func TestKubeDriverSplitExperiment(t *testing.T) {
+	os.Chdir(t.TempDir())
+	kd := NewFakeKubeDriver(cli.New())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", ExperimentPath))
+	_, err := kd.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// gauge metrics are not downsampled, so the experiment is split
+	exp := largeExperiment(t, base.GaugeMetricType, 200000)
+	assert.NoError(t, kd.Write(exp))
+
+	r, err := kd.resultStore().get(context.TODO(), "default")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(r.Data), sizeBudget+len(partsHeader)+100)
+	_, err = kd.resultStore().get(context.TODO(), partName("default", 1))
+	assert.NoError(t, err)
+
+	exp2, err := kd.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, exp.Result.Insights.NonHistMetricValues, exp2.Result.Insights.NonHistMetricValues)
+
+	// parts from different writes are detected
+	pr, err := kd.resultStore().get(context.TODO(), partName("default", 1))
+	assert.NoError(t, err)
+	pr.Data[0]++
+	assert.NoError(t, kd.resultStore().update(context.TODO(), pr))
+	_, err = readParts(kd.resultStore(), r)
+	assert.Equal(t, errInconsistentParts, err)
+
+	// parts that are no longer needed are deleted when the experiment shrinks
+	exp = largeExperiment(t, base.GaugeMetricType, 1000)
+	assert.NoError(t, kd.Write(exp))
+	_, err = kd.resultStore().get(context.TODO(), partName("default", 1))
+	assert.True(t, kerrors.IsNotFound(err))
+	exp2, err = kd.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, exp.Result.Insights.NonHistMetricValues, exp2.Result.Insights.NonHistMetricValues)
+
+	// experiments that are too large to store are rejected
+	exp = largeExperiment(t, base.GaugeMetricType, 2000000)
+	assert.Error(t, kd.Write(exp))
+}
@@ -0,0 +1,268 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// PhaseRunning indicates that the experiment is running
+	PhaseRunning = "Running"
+	// PhaseCompleted indicates that the experiment completed without failures
+	PhaseCompleted = "Completed"
+	// PhaseFailed indicates that the experiment failed
+	PhaseFailed = "Failed"
+	// resyncInterval is the duration between retries when watching experiments fails
+	resyncInterval = 5 * time.Second
+)
+
+// ExperimentStatus is the status of an Iter8 Experiment custom resource
+type ExperimentStatus struct {
+	// ObservedSpec is the hash of the experiment spec the status refers to
+	ObservedSpec string `json:"observedSpec"`
+	// Phase of the experiment; Running, Completed, or Failed
+	Phase string `json:"phase"`
+	// NumCompletedTasks is the number of completed tasks
+	NumCompletedTasks int `json:"numCompletedTasks"`
+	// Completed is true if the experiment has completed
+	Completed bool `json:"completed"`
+	// Failure is true if a task in the experiment has failed
+	Failure bool `json:"failure"`
+	// SLOs is true if all app versions satisfy SLOs
+	SLOs bool `json:"slos"`
+	// NumVersions is the number of app versions in the experiment
+	NumVersions int `json:"numVersions,omitempty"`
+	// SLOsSatisfied indicate if upper and lower SLO limits are satisfied by each version
+	SLOsSatisfied *base.SLOResults `json:"SLOsSatisfied,omitempty"`
+	// Message describes why the experiment failed
+	Message string `json:"message,omitempty"`
+	// LastUpdateTime is the time when the status was last updated
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+}
+
+// Controller runs experiments declared using Iter8 Experiment custom resources in the namespace of its driver.
+// Experiments are run in-process when they are created, and are run again when their spec changes.
+type Controller struct {
+	// KubeDriver enables access to the Kubernetes cluster
+	*KubeDriver
+	// run runs the experiment using the given driver
+	run func(d *KubeDriver) error
+
+	// mu protects running and pending
+	mu sync.Mutex
+	// running is the set of experiments being reconciled
+	running map[string]bool
+	// pending is the set of experiments that changed while being reconciled
+	pending map[string]bool
+	// wg tracks reconciliations in progress
+	wg sync.WaitGroup
+}
+
+// NewController creates and returns a new Controller
+func NewController(kd *KubeDriver) *Controller {
+	return &Controller{
+		KubeDriver: kd,
+		run: func(d *KubeDriver) error {
+			return base.RunExperiment(false, d)
+		},
+		running: map[string]bool{},
+		pending: map[string]bool{},
+	}
+}
+
+// experiments returns the client of Iter8 Experiment custom resources in the namespace of the controller
+func (c *Controller) experiments() dynamic.ResourceInterface {
+	return c.dynamicClient.Resource(experimentGVR).Namespace(c.Namespace())
+}
+
+// Run watches Iter8 Experiment custom resources and reconciles them until the context is done
+func (c *Controller) Run(ctx context.Context) error {
+	if err := c.InitKube(); err != nil {
+		return err
+	}
+	log.Logger.Infof("watching experiments in namespace %v", c.Namespace())
+	for {
+		if err := c.watch(ctx); err != nil {
+			log.Logger.WithStackTrace(err.Error()).Warn("unable to watch experiments; retrying")
+		}
+		select {
+		case <-ctx.Done():
+			c.wg.Wait()
+			return nil
+		case <-time.After(resyncInterval):
+		}
+	}
+}
+
+// watch lists experiments, reconciles them, and then reconciles experiments as they change
+func (c *Controller) watch(ctx context.Context) error {
+	list, err := c.experiments().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, obj := range list.Items {
+		c.Enqueue(ctx, obj.GetName())
+	}
+	w, err := c.experiments().Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for ev := range w.ResultChan() {
+		if ev.Type != watch.Added && ev.Type != watch.Modified {
+			continue
+		}
+		if obj, ok := ev.Object.(*unstructured.Unstructured); ok {
+			c.Enqueue(ctx, obj.GetName())
+		}
+	}
+	return nil
+}
+
+// Enqueue reconciles the experiment in the background.
+// If the experiment is already being reconciled, it is reconciled again afterwards.
+func (c *Controller) Enqueue(ctx context.Context, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running[name] {
+		c.pending[name] = true
+		return
+	}
+	c.running[name] = true
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			if err := c.Reconcile(ctx, name); err != nil {
+				log.Logger.WithStackTrace(err.Error()).Errorf("unable to reconcile experiment %v", name)
+			}
+			c.mu.Lock()
+			if !c.pending[name] || ctx.Err() != nil {
+				delete(c.running, name)
+				delete(c.pending, name)
+				c.mu.Unlock()
+				return
+			}
+			delete(c.pending, name)
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for reconciliations in progress to finish
+func (c *Controller) Wait() {
+	c.wg.Wait()
+}
+
+// specHash returns the hash of the spec of the experiment custom resource
+func specHash(obj *unstructured.Unstructured) string {
+	b, _ := json.Marshal(obj.Object["spec"])
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// getStatus returns the status of the experiment custom resource
+func getStatus(obj *unstructured.Unstructured) ExperimentStatus {
+	status := ExperimentStatus{}
+	if s, ok := obj.Object["status"].(map[string]interface{}); ok {
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(s, &status)
+	}
+	return status
+}
+
+// Reconcile runs the experiment if it has not been run with its current spec.
+// Experiments that were interrupted while running are run again.
+func (c *Controller) Reconcile(ctx context.Context, name string) error {
+	obj, err := c.experiments().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	hash := specHash(obj)
+	if status := getStatus(obj); status.ObservedSpec == hash && status.Phase != PhaseRunning {
+		log.Logger.Debugf("experiment %v is up to date", name)
+		return nil
+	}
+
+	log.Logger.Infof("running experiment %v", name)
+	if err := c.updateStatus(ctx, name, ExperimentStatus{ObservedSpec: hash, Phase: PhaseRunning}); err != nil {
+		return err
+	}
+	d := *c.KubeDriver
+	d.Group = name
+	d.Storage = CustomResourceStorage
+	d.revision = 0
+	d.resourceVersion = ""
+	runErr := c.run(&d)
+	exp, err := d.Read()
+	if err != nil {
+		return err
+	}
+	status := experimentStatus(exp, hash, runErr)
+	log.Logger.Infof("experiment %v %v", name, status.Phase)
+	return c.updateStatus(ctx, name, status)
+}
+
+// experimentStatus summarizes the experiment in its status
+func experimentStatus(exp *base.Experiment, hash string, runErr error) ExperimentStatus {
+	status := ExperimentStatus{
+		ObservedSpec: hash,
+		Completed:    exp.Completed(),
+		Failure:      !exp.NoFailure(),
+		SLOs:         exp.SLOs(),
+	}
+	if exp.Result != nil {
+		status.NumCompletedTasks = exp.Result.NumCompletedTasks
+		if exp.Result.Insights != nil {
+			status.NumVersions = exp.Result.Insights.NumVersions
+			status.SLOsSatisfied = exp.Result.Insights.SLOsSatisfied
+		}
+	}
+	status.Phase = PhaseCompleted
+	if runErr != nil || status.Failure || !status.Completed {
+		status.Phase = PhaseFailed
+	}
+	if runErr != nil {
+		status.Message = runErr.Error()
+	}
+	return status
+}
+
+// updateStatus updates the status subresource of the experiment custom resource
+func (c *Controller) updateStatus(ctx context.Context, name string, status ExperimentStatus) error {
+	status.LastUpdateTime = metav1.Now()
+	s, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := c.experiments().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		obj.Object["status"] = s
+		_, err = c.experiments().UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		e := fmt.Errorf("unable to update status of experiment %v", name)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// newExperimentResource creates an Iter8 Experiment custom resource from the experiment in the given file
+func newExperimentResource(t *testing.T, name string, file string) *unstructured.Unstructured {
+	byteArray, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	content := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal(byteArray, &content))
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": content["spec"]}}
+	obj.SetAPIVersion("iter8.tools/v1alpha1")
+	obj.SetKind("Experiment")
+	obj.SetName(name)
+	obj.SetNamespace("default")
+	obj.SetAnnotations(map[string]string{groupKey: name})
+	return obj
+}
+
+func TestController(t *testing.T) {
+	os.Chdir(t.TempDir())
+	kd := NewFakeKubeDriver(cli.New())
+	kd.dynamicClient = newFakeDynamicClient(newExperimentResource(t, "hello", base.CompletePath("../testdata/drivertests", ExperimentPath)))
+	c := NewController(kd)
+
+	// the run completes all tasks, unless it is made to fail
+	runs := 0
+	var runErr error
+	c.run = func(d *KubeDriver) error {
+		runs++
+		exp, err := d.Read()
+		assert.NoError(t, err)
+		exp.Result = &base.ExperimentResult{NumCompletedTasks: len(exp.Spec)}
+		if runErr != nil {
+			exp.Result.NumCompletedTasks = 0
+			exp.Result.Failure = true
+		}
+		assert.NoError(t, d.Write(exp))
+		return runErr
+	}
+	getStatusOf := func(name string) ExperimentStatus {
+		obj, err := c.experiments().Get(context.TODO(), name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return getStatus(obj)
+	}
+
+	// new experiments are run
+	c.Enqueue(context.TODO(), "hello")
+	c.Wait()
+	assert.Equal(t, 1, runs)
+	status := getStatusOf("hello")
+	assert.Equal(t, PhaseCompleted, status.Phase)
+	assert.True(t, status.Completed)
+	assert.Equal(t, 4, status.NumCompletedTasks)
+	assert.NotEmpty(t, status.ObservedSpec)
+
+	// experiments are not run again unless their spec changes
+	assert.NoError(t, c.Reconcile(context.TODO(), "hello"))
+	assert.Equal(t, 1, runs)
+
+	// experiments are run again when their spec changes
+	obj, err := c.experiments().Get(context.TODO(), "hello", metav1.GetOptions{})
+	assert.NoError(t, err)
+	spec := obj.Object["spec"].([]interface{})
+	obj.Object["spec"] = spec[:len(spec)-1]
+	_, err = c.experiments().Update(context.TODO(), obj, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	runErr = errors.New("task failed")
+	assert.NoError(t, c.Reconcile(context.TODO(), "hello"))
+	assert.Equal(t, 2, runs)
+	status = getStatusOf("hello")
+	assert.Equal(t, PhaseFailed, status.Phase)
+	assert.True(t, status.Failure)
+	assert.Equal(t, "task failed", status.Message)
+
+	// deleted experiments are ignored
+	assert.NoError(t, c.Reconcile(context.TODO(), "missing"))
+}
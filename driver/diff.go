@@ -0,0 +1,186 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around changes
+	diffContext = 3
+)
+
+// experimentFields are the fields of the experiment that are compared by Diff; the result is excluded
+var experimentFields = []string{"spec", "hooks", "background", "loop"}
+
+// manifestSeparator separates the documents of a Kubernetes manifest
+var manifestSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// Diff returns the differences between the experiment of the latest revision of the experiment group,
+// and the experiment that would be launched by upgrading the group with the chart and values.
+// Lines of the latest experiment are prefixed with -, and lines of the new experiment are prefixed with +.
+// The result is empty if the experiments are the same.
+func (driver *KubeDriver) Diff(chartDir string, valueOpts values.Options, group string) (string, error) {
+	rel, err := driver.getLastRelease()
+	if err != nil {
+		return "", err
+	}
+	if rel == nil {
+		e := fmt.Errorf("experiment group %v does not exist", group)
+		log.Logger.Error(e)
+		return "", e
+	}
+
+	ch, vals, err := driver.getChartAndVals(chartDir, valueOpts)
+	if err != nil {
+		e := fmt.Errorf("unable to get chart and vals for %v", chartDir)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	driver.withOwner(vals)
+
+	// render the next revision without upgrading the group
+	client := action.NewUpgrade(driver.Configuration)
+	client.Namespace = driver.Namespace()
+	client.DryRun = true
+	next, err := client.Run(group, ch, vals)
+	if err != nil {
+		e := fmt.Errorf("unable to render experiment for group %v", group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+
+	current, err := experimentFromManifest(rel.Manifest)
+	if err != nil {
+		e := fmt.Errorf("unable to read experiment of revision %v of group %v", rel.Version, group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	updated, err := experimentFromManifest(next.Manifest)
+	if err != nil {
+		e := fmt.Errorf("unable to read rendered experiment for group %v", group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return "", e
+	}
+	return diffLines(current, updated), nil
+}
+
+// experimentFromManifest returns the YAML form of the experiment in the Kubernetes manifest of an experiment group,
+// without its result. The experiment is found in the secret, config map, or Experiment custom resource that stores it.
+func experimentFromManifest(manifest string) (string, error) {
+	for _, doc := range manifestSeparator.Split(manifest, -1) {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return "", err
+		}
+		var exp map[string]interface{}
+		switch obj["kind"] {
+		case "Secret", "ConfigMap":
+			data, _ := obj["stringData"].(map[string]interface{})
+			if obj["kind"] == "ConfigMap" {
+				data, _ = obj["data"].(map[string]interface{})
+			}
+			spec, ok := data[ExperimentPath].(string)
+			if !ok {
+				continue
+			}
+			if err := yaml.Unmarshal([]byte(spec), &exp); err != nil {
+				return "", err
+			}
+		case "Experiment":
+			exp = obj
+		default:
+			continue
+		}
+		fields := map[string]interface{}{}
+		for _, f := range experimentFields {
+			if v, ok := exp[f]; ok {
+				fields[f] = v
+			}
+		}
+		b, err := yaml.Marshal(fields)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", errors.New("experiment not found in manifest")
+}
+
+// diffLines returns the line differences between a and b, with a few unchanged lines around each change.
+// The result is empty if a and b are the same.
+func diffLines(a string, b string) string {
+	al := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	bl := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// lines of the diff, each prefixed with -, +, or a space
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			lines = append(lines, "  "+al[i])
+			i++
+			j++
+		case i < len(al) && (j == len(bl) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+al[i])
+			i++
+		default:
+			lines = append(lines, "+ "+bl[j])
+			j++
+		}
+	}
+
+	// show changed lines along with the unchanged lines near them
+	show := make([]bool, len(lines))
+	changed := false
+	for k, l := range lines {
+		if l[0] != ' ' {
+			changed = true
+			for c := k - diffContext; c <= k+diffContext; c++ {
+				if c >= 0 && c < len(lines) {
+					show[c] = true
+				}
+			}
+		}
+	}
+	if !changed {
+		return ""
+	}
+	var sb strings.Builder
+	for k, l := range lines {
+		if !show[k] {
+			// mark each run of unchanged lines that is not shown
+			if k == 0 || show[k-1] {
+				sb.WriteString("  ...\n")
+			}
+			continue
+		}
+		sb.WriteString(l + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+package driver
+
+import (
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+)
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t, "", diffLines("a\nb\n", "a\nb\n"))
+	assert.Equal(t, "  a\n- b\n+ c\n", diffLines("a\nb\n", "a\nc\n"))
+
+	// unchanged lines far from changes are not shown
+	d := diffLines("1\n2\n3\n4\n5\n6\n7\n8\n", "1\n2\n3\n4\n5\n6\n7\nx\n")
+	assert.Equal(t, "  ...\n  5\n  6\n  7\n- 8\n+ x\n", d)
+}
+
+func TestExperimentFromManifest(t *testing.T) {
+	manifest := `---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: default-iter8-sa
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: default
+stringData:
+  experiment.yaml: |
+    spec:
+    - task: ready
+    result:
+      numCompletedTasks: 1
+`
+	exp, err := experimentFromManifest(manifest)
+	assert.NoError(t, err)
+	assert.Contains(t, exp, "task: ready")
+	assert.NotContains(t, exp, "numCompletedTasks")
+
+	_, err = experimentFromManifest("kind: ServiceAccount\n")
+	assert.Error(t, err)
+}
+
+func TestDiffAndUpgrade(t *testing.T) {
+	os.Chdir(t.TempDir())
+	kd := NewFakeKubeDriver(cli.New())
+	assert.NoError(t, kd.Init())
+	chartDir := base.CompletePath("../", "charts/iter8")
+
+	// diff and upgrade require an existing group
+	_, err := kd.Diff(chartDir, values.Options{}, kd.Group)
+	assert.Error(t, err)
+	assert.Error(t, kd.Upgrade(chartDir, values.Options{}, kd.Group, false, false))
+
+	assert.NoError(t, kd.Launch(chartDir, values.Options{
+		Values: []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"},
+	}, kd.Group, false))
+	assert.NoError(t, kd.Init())
+
+	d, err := kd.Diff(chartDir, values.Options{
+		Values: []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=2s"},
+	}, kd.Group)
+	assert.NoError(t, err)
+	assert.Equal(t, "", d)
+
+	newOpts := values.Options{
+		Values: []string{"tasks={http}", "http.url=https://httpbin.org/get", "http.duration=5s"},
+	}
+	d, err = kd.Diff(chartDir, newOpts, kd.Group)
+	assert.NoError(t, err)
+	assert.Contains(t, d, "- ")
+	assert.Contains(t, d, "+ ")
+	assert.Contains(t, d, "5s")
+
+	assert.NoError(t, kd.Upgrade(chartDir, newOpts, kd.Group, false, true))
+	rel, err := kd.Releases.Last(kd.Group)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rel.Version)
+}
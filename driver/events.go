@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// eventSource is the component that reports the events of experiments
+	eventSource = "iter8"
+)
+
+// objectReference refers to the object of the store with the experiment record
+func objectReference(store experimentStore, ns string, r *experimentRecord) corev1.ObjectReference {
+	ref := corev1.ObjectReference{
+		Namespace: ns,
+		Name:      r.Name,
+		UID:       r.UID,
+	}
+	switch store.kind() {
+	case ConfigMapStorage:
+		ref.APIVersion, ref.Kind = "v1", "ConfigMap"
+	case SecretStorage:
+		ref.APIVersion, ref.Kind = "v1", "Secret"
+	default:
+		ref.APIVersion, ref.Kind = experimentGVR.GroupVersion().String(), "Experiment"
+	}
+	return ref
+}
+
+// resultClients returns the clientset and namespace of the object that stores the experiment
+func (driver *KubeDriver) resultClients() (kubernetes.Interface, string) {
+	if !driver.Results.enabled() {
+		return driver.Clientset, driver.Namespace()
+	}
+	ns := driver.Results.Namespace
+	if ns == "" {
+		ns = driver.Namespace()
+	}
+	return driver.resultClientset, ns
+}
+
+// RecordEvent records the milestone of the running experiment as a Kubernetes event of the experiment secret
+// (or other object storing the experiment), so that it is shown by kubectl describe, and forwarded by cluster event routers
+func (driver *KubeDriver) RecordEvent(event base.ExperimentEvent) error {
+	cs, ns := driver.resultClients()
+	store := driver.resultStore()
+	r, err := store.get(context.Background(), driver.getExperimentSecretName())
+	if err != nil {
+		e := fmt.Errorf("unable to get experiment group %v", driver.Group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	eventType := corev1.EventTypeNormal
+	if event.Warning {
+		eventType = corev1.EventTypeWarning
+	}
+	now := metav1.NewTime(time.Now())
+	labels := driver.groupLabels()
+	labels[revisionKey] = fmt.Sprint(driver.revision)
+	ev := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// names of events are unique; they follow the convention of Kubernetes components
+			Name:      fmt.Sprintf("%v.%x", r.Name, now.UnixNano()),
+			Namespace: ns,
+			Labels:    labels,
+		},
+		InvolvedObject: objectReference(store, ns, r),
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: eventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := cs.CoreV1().Events(ns).Create(context.Background(), ev, metav1.CreateOptions{}); err != nil {
+		e := fmt.Errorf("unable to record event of experiment group %v", driver.Group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return nil
+}
@@ -2,10 +2,13 @@ package driver
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
-	"path"
+	"os"
+	"path/filepath"
 
 	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
 	"sigs.k8s.io/yaml"
 )
@@ -14,29 +17,76 @@ import (
 type FileDriver struct {
 	// RunDir is the directory where the experiment.yaml file is to be found
 	RunDir string
+	// observed is the hash of the experiment file when it was last read or written by this driver
+	observed string
 }
 
 // Read the experiment
 func (f *FileDriver) Read() (*base.Experiment, error) {
-	b, err := ioutil.ReadFile(path.Join(f.RunDir, ExperimentPath))
+	b, err := ioutil.ReadFile(filepath.Join(f.RunDir, ExperimentPath))
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("unable to read experiment")
+		if os.IsNotExist(err) {
+			return nil, ierrors.Wrap(ierrors.ErrExperimentNotFound, err, "unable to read experiment").
+				WithHint("check the run directory, which should contain %v; use 'iter8 gen' or 'iter8 launch' to create it", ExperimentPath)
+		}
 		return nil, errors.New("unable to read experiment")
 	}
+	f.observed = sha256Hex(b)
 	return ExperimentFromBytes(b)
 }
 
-// Write the experiment
+// Write the experiment.
+// The experiment file is locked while it is written, and is replaced atomically, so that readers never see partial writes.
+// If the file was changed by another writer since it was last read or written by this driver,
+// the experiment is written only if it is not stale compared to the stored experiment.
 func (f *FileDriver) Write(exp *base.Experiment) error {
-	b, _ := yaml.Marshal(exp)
-	err := ioutil.WriteFile(path.Join(f.RunDir, ExperimentPath), b, 0664)
+	p := filepath.Join(f.RunDir, ExperimentPath)
+	lock, err := lockFile(p)
 	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to lock experiment")
+		return errors.New("unable to write experiment")
+	}
+	defer lock.unlock()
+
+	if f.observed != "" {
+		if cur, err := ioutil.ReadFile(p); err == nil && sha256Hex(cur) != f.observed {
+			if stored, err := ExperimentFromBytes(cur); err == nil && isStale(exp, stored) {
+				log.Logger.WithStackTrace(errStaleExperiment.Error()).Error("unable to write experiment")
+				return fmt.Errorf("unable to write experiment: %w", errStaleExperiment)
+			}
+		}
+	}
+
+	b, _ := yaml.Marshal(exp)
+	if err := writeFileAtomic(p, b, 0664); err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("unable to write experiment")
 		return errors.New("unable to write experiment")
 	}
+	f.observed = sha256Hex(b)
 	return nil
 }
 
+// writeFileAtomic writes the data into a temporary file, and renames it to the file with the given path
+func writeFileAtomic(p string, b []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(p), filepath.Base(p)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
 // GetRevision is undefined for file drivers
 func (f *FileDriver) GetRevision() int {
 	return 0
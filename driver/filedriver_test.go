@@ -1,10 +1,15 @@
 package driver
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,4 +38,91 @@ func TestFileDriverReadError(t *testing.T) {
 	exp, err := fd.Read()
 	assert.Error(t, err)
 	assert.Nil(t, exp)
+	assert.True(t, errors.Is(err, ierrors.ErrExperimentNotFound))
+	assert.Contains(t, ierrors.Hint(err), "run directory")
+
+	err = ioutil.WriteFile(ExperimentPath, []byte("spec: {"), 0664)
+	assert.NoError(t, err)
+	_, err = fd.Read()
+	assert.True(t, errors.Is(err, ierrors.ErrInvalidExperiment))
+}
+
+func TestFileDriverStaleWrite(t *testing.T) {
+	os.Chdir(t.TempDir())
+	CopyFileToPwd(t, base.CompletePath("../testdata/assertinputs", ExperimentPath))
+
+	// two drivers read the same experiment
+	fd1, fd2 := &FileDriver{RunDir: "."}, &FileDriver{RunDir: "."}
+	exp1, err := fd1.Read()
+	assert.NoError(t, err)
+	exp2, err := fd2.Read()
+	assert.NoError(t, err)
+
+	// the first driver makes progress
+	exp1.Result.NumLoops++
+	assert.NoError(t, fd1.Write(exp1))
+
+	// writes from the second driver that would lose this progress fail
+	err = fd2.Write(exp2)
+	assert.True(t, errors.Is(err, errStaleExperiment))
+	exp2.Result.NumLoops += 2
+	assert.NoError(t, fd2.Write(exp2))
+
+	// drivers keep writing their own updates
+	exp2.Result.NumCompletedTasks--
+	assert.NoError(t, fd2.Write(exp2))
+	exp, err := fd1.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, exp2.Result.NumLoops, exp.Result.NumLoops)
+	assert.Equal(t, exp2.Result.NumCompletedTasks, exp.Result.NumCompletedTasks)
+}
+
+func TestFileDriverLock(t *testing.T) {
+	os.Chdir(t.TempDir())
+	CopyFileToPwd(t, base.CompletePath("../testdata/assertinputs", ExperimentPath))
+	exp, err := (&FileDriver{RunDir: "."}).Read()
+	assert.NoError(t, err)
+
+	// concurrent writes do not interleave
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, (&FileDriver{RunDir: "."}).Write(exp))
+		}()
+	}
+	wg.Wait()
+	_, err = (&FileDriver{RunDir: "."}).Read()
+	assert.NoError(t, err)
+	_, err = os.Stat(ExperimentPath + lockSuffix)
+	assert.True(t, os.IsNotExist(err))
+
+	// abandoned locks are broken
+	assert.NoError(t, ioutil.WriteFile(ExperimentPath+lockSuffix, nil, 0644))
+	old := time.Now().Add(-2 * lockTimeout)
+	assert.NoError(t, os.Chtimes(ExperimentPath+lockSuffix, old, old))
+	assert.NoError(t, (&FileDriver{RunDir: "."}).Write(exp))
+	_, err = os.Stat(ExperimentPath + lockSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileLockToken(t *testing.T) {
+	os.Chdir(t.TempDir())
+	l, err := lockFile(ExperimentPath)
+	assert.NoError(t, err)
+
+	// a lock that was broken and acquired by another holder is not released by the earlier holder
+	other := &fileLock{path: l.path, token: newLockToken()}
+	assert.NoError(t, ioutil.WriteFile(l.path, []byte(other.token+"\n"), 0644))
+	l.unlock()
+	b, err := ioutil.ReadFile(l.path)
+	assert.NoError(t, err)
+	assert.Equal(t, other.token+"\n", string(b))
+
+	// the holder releases the lock, leaving no other files behind
+	other.unlock()
+	files, err := ioutil.ReadDir(".")
+	assert.NoError(t, err)
+	assert.Empty(t, files)
 }
@@ -0,0 +1,91 @@
+package driver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// lockTimeout is the duration to wait for a file lock
+	lockTimeout = 10 * time.Second
+	// lockRetryInterval is the duration between attempts to acquire a file lock
+	lockRetryInterval = 10 * time.Millisecond
+	// lockSuffix is appended to the name of a file to get the name of its lock file
+	lockSuffix = ".lock"
+)
+
+// fileLock is an exclusive lock on a file.
+// The lock is held by creating a lock file next to the file, which works across processes and platforms.
+// The lock file contains a token that identifies the holder of the lock.
+type fileLock struct {
+	// path of the lock file
+	path string
+	// token written into the lock file by the holder of the lock
+	token string
+}
+
+// newLockToken returns a token that identifies the holder of a lock
+func newLockToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%v-%v", os.Getpid(), hex.EncodeToString(b))
+}
+
+// lockFile acquires the lock on the file with the given path.
+// A lock that is held longer than lockTimeout is considered abandoned, for example, by a process that crashed,
+// and is broken.
+func lockFile(p string) (*fileLock, error) {
+	l := &fileLock{path: p + lockSuffix, token: newLockToken()}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintln(f, l.token)
+			f.Close()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, err := os.Stat(l.path); err == nil && time.Since(fi.ModTime()) > lockTimeout {
+			// only the abandoned lock is broken, and not a lock acquired after it was checked
+			if stale, err := ioutil.ReadFile(l.path); err == nil {
+				removeLockFile(l.path, strings.TrimSpace(string(stale)))
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %v", l.path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// unlock releases the lock, unless it was broken and acquired by another holder
+func (l *fileLock) unlock() {
+	removeLockFile(l.path, l.token)
+}
+
+// removeLockFile removes the lock file at the given path if it contains the given token.
+// The lock file is first renamed to a unique path, which is atomic, so that a lock file created
+// by another holder in the meantime is never removed; such a lock file is restored instead.
+// It returns true if the lock file is removed.
+func removeLockFile(path string, token string) bool {
+	aside := fmt.Sprintf("%v.%v", path, newLockToken())
+	if err := os.Rename(path, aside); err != nil {
+		return false
+	}
+	defer os.Remove(aside)
+	b, err := ioutil.ReadFile(aside)
+	if err == nil && strings.TrimSpace(string(b)) == token {
+		return true
+	}
+	// the lock file belongs to another holder; restore it, unless yet another lock file was created
+	os.Link(aside, path)
+	return false
+}
@@ -0,0 +1,118 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// gcsEndpoint is the endpoint of Google Cloud Storage
+	gcsEndpoint = "https://storage.googleapis.com"
+	// gceMetadataHost is the host of the metadata server of Google Compute Engine and GKE
+	gceMetadataHost = "metadata.google.internal"
+)
+
+// gcsClient gets and puts objects in a Google Cloud Storage bucket
+type gcsClient struct {
+	bucket     string
+	endpoint   string
+	httpClient *http.Client
+
+	// mu protects token and expiration
+	mu         sync.Mutex
+	token      string
+	expiration time.Time
+}
+
+// newGCSClient creates and returns a new client for the bucket.
+// The STORAGE_EMULATOR_HOST environment variable selects a storage emulator.
+func newGCSClient(bucket string, httpClient *http.Client) *gcsClient {
+	endpoint := gcsEndpoint
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint = strings.TrimSuffix(host, "/")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+	}
+	return &gcsClient{
+		bucket:     bucket,
+		endpoint:   endpoint,
+		httpClient: httpClient,
+	}
+}
+
+// accessToken returns an OAuth2 access token from the GOOGLE_OAUTH_ACCESS_TOKEN environment variable,
+// or from the metadata server, for example, with GKE workload identity.
+// No token is used with storage emulators.
+func (g *gcsClient) accessToken() (string, error) {
+	if t := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); t != "" {
+		return t, nil
+	}
+	if g.endpoint != gcsEndpoint {
+		return "", nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Now().Add(time.Minute).Before(g.expiration) {
+		return g.token, nil
+	}
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = gceMetadataHost
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%v/computeMetadata/v1/instance/service-accounts/default/token", host), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	b, err := doObjectRequest(g.httpClient, req)
+	if err != nil {
+		return "", fmt.Errorf("no Google Cloud credentials; set GOOGLE_OAUTH_ACCESS_TOKEN, or use a service account through the metadata server: %v", err)
+	}
+	tok := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(b, &tok); err != nil || tok.AccessToken == "" {
+		return "", errors.New("unable to parse access token from the metadata server")
+	}
+	g.token = tok.AccessToken
+	g.expiration = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return g.token, nil
+}
+
+// do authorizes and sends the request
+func (g *gcsClient) do(method string, u string, payload []byte) ([]byte, error) {
+	token, err := g.accessToken()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/yaml")
+	}
+	return doObjectRequest(g.httpClient, req)
+}
+
+func (g *gcsClient) get(key string) ([]byte, error) {
+	return g.do(http.MethodGet, fmt.Sprintf("%v/storage/v1/b/%v/o/%v?alt=media", g.endpoint, url.PathEscape(g.bucket), url.PathEscape(key)), nil)
+}
+
+func (g *gcsClient) put(key string, b []byte) error {
+	_, err := g.do(http.MethodPost, fmt.Sprintf("%v/upload/storage/v1/b/%v/o?uploadType=media&name=%v", g.endpoint, url.PathEscape(g.bucket), url.QueryEscape(key)), b)
+	return err
+}
@@ -3,12 +3,14 @@ package driver
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -24,14 +26,15 @@ import (
 	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
 
 	"github.com/iter8-tools/iter8/base"
+	ierrors "github.com/iter8-tools/iter8/base/errors"
 	"github.com/iter8-tools/iter8/base/log"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/yaml"
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -46,6 +49,14 @@ const (
 	retryInterval = 1 * time.Second
 	// ManifestFile is the name of the Kubernetes manifest file
 	ManifestFile = "manifest.yaml"
+	// groupKey is the label and annotation that identifies the experiment group of a Kubernetes resource
+	groupKey = "iter8.tools/group"
+	// abortKey is the experiment secret annotation that asks a running experiment to stop.
+	// Its value is the aborted revision, so that later revisions of the experiment are unaffected.
+	abortKey = "iter8.tools/abort"
+	// liveMetricsKey is the experiment secret annotation with the live metrics of the running load test, in JSON format.
+	// It is removed when the experiment is next written.
+	liveMetricsKey = "iter8.tools/live-metrics"
 )
 
 // KubeDriver embeds Helm and Kube configuration, and
@@ -61,6 +72,38 @@ type KubeDriver struct {
 	Group string
 	// revision is the revision of the experiment
 	revision int
+	// Results identifies the control cluster in which experiment results are stored
+	Results ResultCluster
+	// resultClientset enables interaction with the control cluster
+	resultClientset kubernetes.Interface
+	// Storage is the kind of Kubernetes object in which experiments are stored; secret, configmap, or cr
+	Storage string
+	// Owner of experiments; for example, a team or tenant.
+	// If set, experiments are launched with the owner label, and only experiments and resources of the owner are listed and cleaned up.
+	Owner string
+	// dynamicClient enables interaction with Iter8 Experiment custom resources
+	dynamicClient dynamic.Interface
+	// resultDynamicClient enables interaction with Iter8 Experiment custom resources in the control cluster
+	resultDynamicClient dynamic.Interface
+	// resourceVersion is the resource version of the stored experiment when it was last read or written by this driver;
+	// empty if unknown
+	resourceVersion string
+}
+
+// ResultCluster identifies a control cluster in which experiment results are stored.
+// If no kubeconfig or context is specified, results are stored in the cluster in which the experiment is launched.
+type ResultCluster struct {
+	// KubeConfig is the path to the kubeconfig file of the control cluster
+	KubeConfig string
+	// KubeContext is the kubeconfig context of the control cluster
+	KubeContext string
+	// Namespace in the control cluster; defaults to the namespace of the experiment
+	Namespace string
+}
+
+// enabled returns true if results are stored in a separate control cluster
+func (rc ResultCluster) enabled() bool {
+	return rc.KubeConfig != "" || rc.KubeContext != ""
 }
 
 // NewKubeDriver creates and returns a new KubeDriver
@@ -70,24 +113,68 @@ func NewKubeDriver(s *cli.EnvSettings) *KubeDriver {
 		Group:         DefaultExperimentGroup,
 		Configuration: nil,
 		Clientset:     nil,
+		Storage:       SecretStorage,
 	}
 	return kd
 }
 
 // InitKube initializes the Kubernetes clientset
 func (kd *KubeDriver) InitKube() error {
-	if kd.Clientset == nil {
+	if err := validateStorage(kd.Storage); err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+	if kd.Clientset == nil || kd.dynamicClient == nil {
 		// get REST config
 		restConfig, err := kd.EnvSettings.RESTClientGetter().ToRESTConfig()
 		if err != nil {
-			e := errors.New("unable to get Kubernetes REST config")
+			e := ierrors.Wrap(ierrors.ErrClusterUnavailable, err, "unable to get Kubernetes REST config")
 			log.Logger.WithStackTrace(err.Error()).Error(e)
 			return e
 		}
 		// get clientset
-		kd.Clientset, err = kubernetes.NewForConfig(restConfig)
+		if kd.Clientset == nil {
+			kd.Clientset, err = kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				e := ierrors.Wrap(ierrors.ErrClusterUnavailable, err, "unable to get Kubernetes clientset")
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return e
+			}
+		}
+		// get dynamic client
+		if kd.dynamicClient == nil {
+			kd.dynamicClient, err = dynamic.NewForConfig(restConfig)
+			if err != nil {
+				e := errors.New("unable to get Kubernetes dynamic client")
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return e
+			}
+		}
+	}
+	return kd.initResultKube()
+}
+
+// initResultKube initializes the Kubernetes clientset of the control cluster, if any
+func (kd *KubeDriver) initResultKube() error {
+	if kd.resultClientset == nil && kd.Results.enabled() {
+		s := cli.New()
+		s.KubeConfig = kd.Results.KubeConfig
+		s.KubeContext = kd.Results.KubeContext
+		restConfig, err := s.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			e := ierrors.Wrap(ierrors.ErrClusterUnavailable, err, "unable to get Kubernetes REST config for results cluster")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+		kd.resultClientset, err = kubernetes.NewForConfig(restConfig)
 		if err != nil {
-			e := errors.New("unable to get Kubernetes clientset")
+			e := errors.New("unable to get Kubernetes clientset for results cluster")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+		kd.resultDynamicClient, err = dynamic.NewForConfig(restConfig)
+		if err != nil {
+			e := errors.New("unable to get Kubernetes dynamic client for results cluster")
 			log.Logger.WithStackTrace(err.Error()).Error(e)
 			return e
 		}
@@ -95,6 +182,24 @@ func (kd *KubeDriver) InitKube() error {
 	return nil
 }
 
+// experimentStore returns the store of experiments in the given namespace
+// of the cluster in which the experiment is launched
+func (driver *KubeDriver) experimentStore(ns string) experimentStore {
+	return newExperimentStore(driver.Storage, driver.Clientset, driver.dynamicClient, ns)
+}
+
+// resultStore returns the store used for experiment results
+func (driver *KubeDriver) resultStore() experimentStore {
+	if !driver.Results.enabled() {
+		return driver.experimentStore(driver.Namespace())
+	}
+	ns := driver.Results.Namespace
+	if ns == "" {
+		ns = driver.Namespace()
+	}
+	return newExperimentStore(driver.Storage, driver.resultClientset, driver.resultDynamicClient, ns)
+}
+
 // initHelm initializes the Helm configuration
 func (kd *KubeDriver) initHelm() error {
 	if kd.Configuration == nil {
@@ -102,7 +207,7 @@ func (kd *KubeDriver) initHelm() error {
 		kd.Configuration = new(action.Configuration)
 		helmDriver := os.Getenv("HELM_DRIVER")
 		if err := kd.Configuration.Init(kd.EnvSettings.RESTClientGetter(), kd.EnvSettings.Namespace(), helmDriver, log.Logger.Debugf); err != nil {
-			e := errors.New("unable to get Helm client config")
+			e := ierrors.Wrap(ierrors.ErrClusterUnavailable, err, "unable to get Helm client config")
 			log.Logger.WithStackTrace(err.Error()).Error(e)
 			return e
 		}
@@ -156,13 +261,14 @@ func (driver *KubeDriver) getLastRelease() (*release.Release, error) {
 	return rel, nil
 }
 
-// getExperimentSecretName yields the name of the experiment secret
+// getExperimentSecretName yields the name of the experiment secret (or other object storing the experiment)
 func (driver *KubeDriver) getExperimentSecretName() string {
 	return fmt.Sprintf("%v", driver.Group)
 }
 
-// getSecretWithRetry attempts to get a Kubernetes secret with retries
-func (driver *KubeDriver) getSecretWithRetry(name string) (sec *corev1.Secret, err error) {
+// getExperimentWithRetry attempts to get a stored experiment with retries
+func (driver *KubeDriver) getExperimentWithRetry(name string) (r *experimentRecord, err error) {
+	store := driver.experimentStore(driver.Namespace())
 	err1 := retry.OnError(
 		wait.Backoff{
 			Steps:    int(secretTimeout / retryInterval),
@@ -175,84 +281,263 @@ func (driver *KubeDriver) getSecretWithRetry(name string) (sec *corev1.Secret, e
 			return kerrors.ReasonForError(err2) == metav1.StatusReasonForbidden
 		},
 		func() (err3 error) {
-			secretsClient := driver.Clientset.CoreV1().Secrets(driver.Namespace())
-			sec, err3 = secretsClient.Get(context.Background(), name, metav1.GetOptions{})
+			r, err3 = store.get(context.Background(), name)
 			return err3
 		},
 	)
 	if err1 != nil {
-		err = fmt.Errorf("unable to get secret %v", name)
+		if kerrors.IsNotFound(err1) {
+			err = ierrors.Wrap(ierrors.ErrExperimentNotFound, err1, "unable to get %v %v", store.kind(), name)
+		} else {
+			err = fmt.Errorf("unable to get %v %v", store.kind(), name)
+		}
 		log.Logger.WithStackTrace(err1.Error()).Error(err)
 		return nil, err
 	}
-	return sec, nil
+	return r, nil
 }
 
-// getExperimentSecret gets the Kubernetes experiment secret
-func (driver *KubeDriver) getExperimentSecret() (s *corev1.Secret, err error) {
-	return driver.getSecretWithRetry(driver.getExperimentSecretName())
+// getExperimentRecord gets the stored experiment, and records its resource version.
+// If results are stored in a control cluster, the experiment is read from the control cluster;
+// until the first result is written there, it is read from the cluster in which the experiment is launched.
+func (driver *KubeDriver) getExperimentRecord() (r *experimentRecord, err error) {
+	driver.resourceVersion = ""
+	if driver.Results.enabled() {
+		store := driver.resultStore()
+		r, err = store.get(context.Background(), driver.getExperimentSecretName())
+		if err == nil {
+			driver.resourceVersion = r.ResourceVersion
+			return r, nil
+		}
+		if !kerrors.IsNotFound(err) {
+			e := fmt.Errorf("unable to get %v %v from results cluster", store.kind(), driver.getExperimentSecretName())
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return nil, e
+		}
+		log.Logger.Debugf("experiment %v not found in results cluster", store.kind())
+		return driver.getExperimentWithRetry(driver.getExperimentSecretName())
+	}
+	r, err = driver.getExperimentWithRetry(driver.getExperimentSecretName())
+	if err == nil {
+		driver.resourceVersion = r.ResourceVersion
+	}
+	return r, err
 }
 
-// Read experiment from secret
+// Read experiment from secret (or other object storing the experiment)
 func (driver *KubeDriver) Read() (*base.Experiment, error) {
-	s, err := driver.getExperimentSecret()
-	if err != nil {
-		return nil, err
-	}
+	for attempt := 1; ; attempt++ {
+		r, err := driver.getExperimentRecord()
+		if err != nil {
+			return nil, err
+		}
 
-	b, ok := s.Data[ExperimentPath]
-	if !ok {
-		err = fmt.Errorf("unable to extract experiment; spec secret has no %v field", ExperimentPath)
-		log.Logger.Error(err)
-		return nil, err
-	}
+		if len(r.Data) == 0 {
+			err = fmt.Errorf("unable to extract experiment; spec %v has no %v field", driver.resultStore().kind(), ExperimentPath)
+			log.Logger.Error(err)
+			return nil, err
+		}
 
-	return ExperimentFromBytes(b)
+		b, err := readParts(driver.resultStore(), r)
+		if errors.Is(err, errInconsistentParts) && attempt < readPartsAttempts {
+			log.Logger.Debugf("experiment is being written; retrying read")
+			time.Sleep(retryInterval)
+			continue
+		}
+		if err != nil {
+			e := fmt.Errorf("unable to extract experiment from %v %v", driver.resultStore().kind(), r.Name)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return nil, e
+		}
+		return ExperimentFromBytes(b)
+	}
 }
 
-// formExperimentSecret creates the experiment secret using the experiment
-func (driver *KubeDriver) formExperimentSecret(e *base.Experiment) (*corev1.Secret, error) {
-	byteArray, _ := yaml.Marshal(e)
-	sec := corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: driver.getExperimentSecretName(),
+// updateExperiment updates the stored experiment
+// as opposed to patch, update is an atomic operation.
+// Updates use optimistic concurrency: each update is conditional on the resource version of the stored experiment,
+// and is retried on conflict. If the stored experiment was changed by another writer since it was last read or written
+// by this driver, the update is made only if the experiment is not stale compared to the stored experiment,
+// so that the updates of the other writer are not lost.
+//
+// Experiments stored in secrets and config maps are compressed when they are large. Sample metrics are downsampled
+// if experiments exceed the size budget of an object, and experiments that still exceed it are split across objects.
+// Objects storing parts that are no longer needed are deleted.
+func (driver *KubeDriver) updateExperiment(e *base.Experiment) error {
+	store := driver.resultStore()
+	// custom resources store the experiment as structured fields, which are neither compressed nor split
+	packed := driver.Storage != CustomResourceStorage
+	byteArray := encodeExperiment(e, packed)
+	name := driver.getExperimentSecretName()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		r := &experimentRecord{
+			Name:   name,
+			Labels: driver.groupLabels(),
 			Annotations: map[string]string{
-				"iter8.tools/group": driver.Group,
+				groupKey: driver.Group,
 			},
-		},
-		StringData: map[string]string{ExperimentPath: string(byteArray)},
+		}
+		cur, err := store.get(context.Background(), name)
+		if err != nil {
+			if driver.Results.enabled() && kerrors.IsNotFound(err) {
+				// first result written to the control cluster
+				if r.Data, err = driver.packExperiment(store, name, byteArray, packed); err != nil {
+					return err
+				}
+				if err := store.create(context.Background(), r); err != nil {
+					return err
+				}
+				driver.resourceVersion = r.ResourceVersion
+				return nil
+			}
+			return err
+		}
+		if driver.resourceVersion != "" && cur.ResourceVersion != driver.resourceVersion {
+			log.Logger.Debugf("%v %v was changed by another writer", store.kind(), name)
+			if b, err := readParts(store, cur); err == nil {
+				if stored, err := ExperimentFromBytes(b); err == nil && isStale(e, stored) {
+					return errStaleExperiment
+				}
+			}
+		}
+		if r.Data, err = driver.packExperiment(store, name, byteArray, packed); err != nil {
+			return err
+		}
+		// preserve the labels of the stored experiment, and any abort request made while the experiment is running
+		r.Labels = cur.Labels
+		if v, ok := cur.Annotations[abortKey]; ok {
+			r.Annotations[abortKey] = v
+		}
+		r.ResourceVersion = cur.ResourceVersion
+		if err := store.update(context.Background(), r); err != nil {
+			return err
+		}
+		driver.resourceVersion = r.ResourceVersion
+		// parts of the earlier write are deleted only after the experiment record no longer refers to them
+		if packed {
+			deleteStaleParts(store, name, numParts(r), numParts(cur))
+		}
+		return nil
+	})
+	if err != nil {
+		e := fmt.Errorf("unable to update %v %v", store.kind(), name)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		if errors.Is(err, errStaleExperiment) {
+			return fmt.Errorf("%v: %w", e, errStaleExperiment)
+		}
+		return e
 	}
-	// formed experiment secret ...
-	return &sec, nil
+	return nil
 }
 
-// updateExperimentSecret updates the experiment secret
-// as opposed to patch, update is an atomic operation
-func (driver *KubeDriver) updateExperimentSecret(e *base.Experiment) error {
-	if sec, err := driver.formExperimentSecret(e); err == nil {
-		secretsClient := driver.Clientset.CoreV1().Secrets(driver.Namespace())
-		_, err1 := secretsClient.Update(context.Background(), sec, metav1.UpdateOptions{})
-		// TODO: Evaluate if result secret update requires retries.
-		// Probably not. Conflicts will be avoided if cronjob avoids parallel jobs.
-		if err1 != nil {
-			err2 := fmt.Errorf("unable to update secret %v", sec.Name)
-			log.Logger.WithStackTrace(err1.Error()).Error(err2)
-			return err2
-		}
-	} else {
+// Write writes a Kubernetes experiment
+func (driver *KubeDriver) Write(e *base.Experiment) error {
+	if err := driver.updateExperiment(e); err != nil {
 		return err
 	}
 	return nil
 }
 
-// Write writes a Kubernetes experiment
-func (driver *KubeDriver) Write(e *base.Experiment) error {
-	if err := driver.updateExperimentSecret(e); err != nil {
+// AbortRequested returns true if the experiment has been asked to stop using Abort
+func (driver *KubeDriver) AbortRequested() bool {
+	r, err := driver.resultStore().get(context.Background(), driver.getExperimentSecretName())
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warn("unable to check for abort request")
+		return false
+	}
+	return r.Annotations[abortKey] == fmt.Sprint(driver.revision)
+}
+
+// Abort asks the running experiment to stop after its current task.
+// The request is recorded in the experiment secret (or other object storing the experiment),
+// which the experiment checks before each task.
+// Only the current revision of the experiment is aborted.
+func (driver *KubeDriver) Abort() error {
+	store := driver.resultStore()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		r, err := store.get(context.Background(), driver.getExperimentSecretName())
+		if err != nil {
+			return err
+		}
+		if r.Annotations == nil {
+			r.Annotations = map[string]string{}
+		}
+		r.Annotations[abortKey] = fmt.Sprint(driver.revision)
+		return store.update(context.Background(), r)
+	})
+	if err != nil {
+		e := fmt.Errorf("unable to abort experiment group %v", driver.Group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	driver.audit(AbortAuditAction, driver.Group, driver.revision, nil)
+	log.Logger.Infof("requested abort of experiment group %v", driver.Group)
+	return nil
+}
+
+// WriteLiveMetrics publishes the live metrics of the running load test in the experiment secret
+// (or other object storing the experiment), so that they can be monitored before the experiment completes
+func (driver *KubeDriver) WriteLiveMetrics(m *base.LiveMetrics) error {
+	b, err := json.Marshal(m)
+	if err != nil {
 		return err
 	}
+	store := driver.resultStore()
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		r, err := store.get(context.Background(), driver.getExperimentSecretName())
+		if err != nil {
+			return err
+		}
+		if r.Annotations == nil {
+			r.Annotations = map[string]string{}
+		}
+		r.Annotations[liveMetricsKey] = string(b)
+		prev := r.ResourceVersion
+		if err := store.update(context.Background(), r); err != nil {
+			return err
+		}
+		// the experiment is unchanged, so this update does not make the experiment written by this driver stale
+		if driver.resourceVersion == prev {
+			driver.resourceVersion = r.ResourceVersion
+		}
+		return nil
+	})
+	if err != nil {
+		e := fmt.Errorf("unable to write live metrics of experiment group %v", driver.Group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
 	return nil
 }
 
+// ClearAbort withdraws any request to abort the experiment, so that it can be resumed
+func (driver *KubeDriver) ClearAbort() error {
+	store := driver.resultStore()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		r, err := store.get(context.Background(), driver.getExperimentSecretName())
+		if err != nil {
+			return err
+		}
+		if _, ok := r.Annotations[abortKey]; !ok {
+			return nil
+		}
+		delete(r.Annotations, abortKey)
+		return store.update(context.Background(), r)
+	})
+	if err != nil {
+		e := fmt.Errorf("unable to clear abort request for experiment group %v", driver.Group)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return nil
+}
+
+// SetRevision sets the experiment revision.
+// It is used by experiment runs, which know their revision but are not permitted to look up releases.
+func (driver *KubeDriver) SetRevision(revision int) {
+	driver.revision = revision
+}
+
 // GetRevision gets the experiment revision
 func (driver *KubeDriver) GetRevision() int {
 	return driver.revision
@@ -300,10 +585,12 @@ func writeManifest(rel *release.Release) error {
 // Credit: the logic for this function is sourced from Helm
 // https://github.com/helm/helm/blob/8ab18f7567cedffdfa5ba4d7f6abfb58efc313f8/cmd/helm/upgrade.go#L69
 // Upgrade a Kubernetes experiment to the next release
-func (driver *KubeDriver) upgrade(chartDir string, valueOpts values.Options, group string, dry bool) error {
+// With force, resources of the experiment are replaced instead of being updated.
+func (driver *KubeDriver) upgrade(chartDir string, valueOpts values.Options, group string, dry bool, force bool) error {
 	client := action.NewUpgrade(driver.Configuration)
 	client.Namespace = driver.Namespace()
 	client.DryRun = dry
+	client.Force = force
 
 	ch, vals, err := driver.getChartAndVals(chartDir, valueOpts)
 	if err != nil {
@@ -311,6 +598,7 @@ func (driver *KubeDriver) upgrade(chartDir string, valueOpts values.Options, gro
 		log.Logger.WithStackTrace(err.Error()).Error(e)
 		return e
 	}
+	driver.withOwner(vals)
 
 	// Create context and prepare the handle of SIGTERM
 	ctx := context.Background()
@@ -323,7 +611,7 @@ func (driver *KubeDriver) upgrade(chartDir string, valueOpts values.Options, gro
 	signal.Notify(cSignal, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-cSignal
-		fmt.Printf("experiment for group %s has been cancelled.\n", group)
+		log.Logger.Warnf("experiment for group %s has been cancelled", group)
 		cancel()
 	}()
 
@@ -345,6 +633,8 @@ func (driver *KubeDriver) upgrade(chartDir string, valueOpts values.Options, gro
 		}
 		log.Logger.Info("dry run complete")
 	} else {
+		driver.audit(UpgradeAuditAction, group, rel.Version, vals)
+		driver.applyRetention(vals)
 		log.Logger.Info("experiment launched. Happy Iter8ing!")
 	}
 
@@ -366,6 +656,7 @@ func (driver *KubeDriver) install(chartDir string, valueOpts values.Options, gro
 		log.Logger.WithStackTrace(err.Error()).Error(e)
 		return e
 	}
+	driver.withOwner(vals)
 
 	// Create context and prepare the handle of SIGTERM
 	ctx := context.Background()
@@ -378,7 +669,7 @@ func (driver *KubeDriver) install(chartDir string, valueOpts values.Options, gro
 	signal.Notify(cSignal, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-cSignal
-		fmt.Printf("experiment for group %s has been cancelled.\n", group)
+		log.Logger.Warnf("experiment for group %s has been cancelled", group)
 		cancel()
 	}()
 
@@ -400,6 +691,8 @@ func (driver *KubeDriver) install(chartDir string, valueOpts values.Options, gro
 		}
 		log.Logger.Info("dry run complete")
 	} else {
+		driver.audit(LaunchAuditAction, group, rel.Version, vals)
+		driver.applyRetention(vals)
 		log.Logger.Info("experiment launched. Happy Iter8ing!")
 	}
 
@@ -411,23 +704,272 @@ func (driver *KubeDriver) Launch(chartDir string, valueOpts values.Options, grou
 	if driver.revision <= 0 {
 		return driver.install(chartDir, valueOpts, group, dry)
 	} else {
-		return driver.upgrade(chartDir, valueOpts, group, dry)
+		return driver.upgrade(chartDir, valueOpts, group, dry, false)
+	}
+}
+
+// Upgrade an existing Kubernetes experiment group to a new revision.
+// With force, resources of the experiment are replaced instead of being updated.
+func (driver *KubeDriver) Upgrade(chartDir string, valueOpts values.Options, group string, dry bool, force bool) error {
+	if driver.revision <= 0 {
+		e := fmt.Errorf("experiment group %v does not exist; launch it without upgrade", group)
+		log.Logger.Error(e)
+		return e
 	}
+	return driver.upgrade(chartDir, valueOpts, group, dry, force)
 }
 
 // Delete a Kubernetes experiment group
 func (driver *KubeDriver) Delete() error {
 	client := action.NewUninstall(driver.Configuration)
-	_, err := client.Run(driver.Group)
+	resp, err := client.Run(driver.Group)
 	if err != nil {
 		e := fmt.Errorf("deletion of experiment group %v failed", driver.Group)
 		log.Logger.WithStackTrace(err.Error()).Error(e)
 		return e
 	}
+	revision := 0
+	if resp != nil && resp.Release != nil {
+		revision = resp.Release.Version
+	}
+	driver.audit(DeleteAuditAction, driver.Group, revision, nil)
 	log.Logger.Infof("experiment group %v deleted", driver.Group)
 	return nil
 }
 
+// ListExperiments lists the latest releases of Iter8 experiment groups.
+// Releases in all namespaces are listed if allNamespaces is true;
+// otherwise, only releases in the namespace of the driver are listed.
+func (driver *KubeDriver) ListExperiments(allNamespaces bool) ([]*release.Release, error) {
+	releases := driver.Configuration.Releases
+	if allNamespaces {
+		cfg := new(action.Configuration)
+		if err := cfg.Init(driver.EnvSettings.RESTClientGetter(), "", os.Getenv("HELM_DRIVER"), log.Logger.Debugf); err != nil {
+			e := errors.New("unable to get Helm client config")
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return nil, e
+		}
+		releases = cfg.Releases
+	}
+
+	// Iter8 experiments are the releases whose manifests contain experiment group labels;
+	// if the driver has an owner, only experiments launched with the owner value are listed
+	rels, err := releases.List(func(rel *release.Release) bool {
+		if driver.Owner != "" && rel.Config[ownerValue] != driver.Owner {
+			return false
+		}
+		return strings.Contains(rel.Manifest, groupKey)
+	})
+	if err != nil {
+		e := errors.New("unable to list experiment releases")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+
+	// keep the latest release of each experiment group
+	latest := map[string]*release.Release{}
+	for _, rel := range rels {
+		key := rel.Namespace + "/" + rel.Name
+		if l, ok := latest[key]; !ok || l.Version < rel.Version {
+			latest[key] = rel
+		}
+	}
+	result := []*release.Release{}
+	for _, rel := range latest {
+		result = append(result, rel)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// ReadExperiment reads the experiment of the given group in the given namespace without retries.
+// It is used to summarize experiments other than the one referred to by the driver.
+func (driver *KubeDriver) ReadExperiment(namespace string, group string) (*base.Experiment, error) {
+	store := driver.experimentStore(namespace)
+	r, err := store.get(context.Background(), group)
+	if err != nil {
+		e := fmt.Errorf("unable to get %v %v in namespace %v", store.kind(), group, namespace)
+		log.Logger.WithStackTrace(err.Error()).Debug(e)
+		return nil, e
+	}
+	if len(r.Data) == 0 {
+		e := fmt.Errorf("unable to extract experiment; spec %v has no %v field", store.kind(), ExperimentPath)
+		log.Logger.Debug(e)
+		return nil, e
+	}
+	b, err := readParts(store, r)
+	if err != nil {
+		e := fmt.Errorf("unable to extract experiment from %v %v in namespace %v", store.kind(), group, namespace)
+		log.Logger.WithStackTrace(err.Error()).Debug(e)
+		return nil, e
+	}
+	return ExperimentFromBytes(b)
+}
+
+// belongsToGroup returns true if the object is labeled or annotated with the experiment group,
+// and belongs to the owner of the driver if any
+func (driver *KubeDriver) belongsToGroup(meta metav1.ObjectMeta) bool {
+	return (meta.Labels[groupKey] == driver.Group || meta.Annotations[groupKey] == driver.Group) && driver.ownedBy(meta)
+}
+
+// CleanupExperiment deletes Kubernetes resources of the experiment group that remain in the namespace,
+// such as the experiment secret, jobs, pods, roles, role bindings, and service accounts.
+// These resources are normally removed when the experiment is deleted, but may be left behind
+// by earlier revisions or by interrupted deletions.
+// If dry is true, resources are only listed.
+// The kind and name of each (to be) deleted resource is returned.
+func (driver *KubeDriver) CleanupExperiment(dry bool) ([]string, error) {
+	ctx := context.Background()
+	ns := driver.Namespace()
+	listOpts := metav1.ListOptions{}
+	background := metav1.DeletePropagationBackground
+	deleteOpts := metav1.DeleteOptions{PropagationPolicy: &background}
+
+	deleted := []string{}
+	// remove deletes a single resource and records it
+	remove := func(kind string, name string, del func(context.Context, string, metav1.DeleteOptions) error) error {
+		if !dry {
+			if err := del(ctx, name, deleteOpts); err != nil && !kerrors.IsNotFound(err) {
+				e := fmt.Errorf("unable to delete %v %v", kind, name)
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return e
+			}
+		}
+		deleted = append(deleted, kind+"/"+name)
+		return nil
+	}
+	// listError logs and returns an error when resources cannot be listed
+	listError := func(kind string, err error) error {
+		e := fmt.Errorf("unable to list %v", kind)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+
+	cs := driver.Clientset
+	jobs, err := cs.BatchV1().Jobs(ns).List(ctx, listOpts)
+	if err != nil {
+		return nil, listError("jobs", err)
+	}
+	for _, o := range jobs.Items {
+		if driver.belongsToGroup(o.ObjectMeta) {
+			if err := remove("job", o.Name, cs.BatchV1().Jobs(ns).Delete); err != nil {
+				return nil, err
+			}
+		}
+	}
+	cronjobs, err := cs.BatchV1().CronJobs(ns).List(ctx, listOpts)
+	if err != nil {
+		return nil, listError("cronjobs", err)
+	}
+	for _, o := range cronjobs.Items {
+		if driver.belongsToGroup(o.ObjectMeta) {
+			if err := remove("cronjob", o.Name, cs.BatchV1().CronJobs(ns).Delete); err != nil {
+				return nil, err
+			}
+		}
+	}
+	leases, err := cs.CoordinationV1().Leases(ns).List(ctx, listOpts)
+	if err != nil {
+		return nil, listError("leases", err)
+	}
+	for _, o := range leases.Items {
+		if driver.belongsToGroup(o.ObjectMeta) {
+			if err := remove("lease", o.Name, cs.CoordinationV1().Leases(ns).Delete); err != nil {
+				return nil, err
+			}
+		}
+	}
+	pods, err := cs.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: driver.experimentPodSelector(0),
+	})
+	if err != nil {
+		return nil, listError("pods", err)
+	}
+	for _, o := range pods.Items {
+		// pods of experiment groups with the same name that belong to other owners are left alone
+		if !driver.ownedBy(o.ObjectMeta) {
+			continue
+		}
+		if err := remove("pod", o.Name, cs.CoreV1().Pods(ns).Delete); err != nil {
+			return nil, err
+		}
+	}
+	secrets, err := cs.CoreV1().Secrets(ns).List(ctx, listOpts)
+	if err != nil {
+		return nil, listError("secrets", err)
+	}
+	for _, o := range secrets.Items {
+		if driver.belongsToGroup(o.ObjectMeta) {
+			if err := remove("secret", o.Name, cs.CoreV1().Secrets(ns).Delete); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// experiments stored in objects other than secrets
+	if driver.Storage != "" && driver.Storage != SecretStorage {
+		store := driver.experimentStore(ns)
+		records, err := store.list(ctx)
+		if err != nil {
+			return nil, listError(store.kind()+"s", err)
+		}
+		for _, r := range records {
+			if driver.belongsToGroup(metav1.ObjectMeta{Labels: r.Labels, Annotations: r.Annotations}) {
+				if err := remove(store.kind(), r.Name, store.delete); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	roles, err := cs.RbacV1().Roles(ns).List(ctx, listOpts)
+	if err != nil {
+		return nil, listError("roles", err)
+	}
+	for _, o := range roles.Items {
+		if driver.belongsToGroup(o.ObjectMeta) {
+			if err := remove("role", o.Name, cs.RbacV1().Roles(ns).Delete); err != nil {
+				return nil, err
+			}
+		}
+	}
+	roleBindings, err := cs.RbacV1().RoleBindings(ns).List(ctx, listOpts)
+	if err != nil {
+		return nil, listError("rolebindings", err)
+	}
+	for _, o := range roleBindings.Items {
+		if driver.belongsToGroup(o.ObjectMeta) {
+			if err := remove("rolebinding", o.Name, cs.RbacV1().RoleBindings(ns).Delete); err != nil {
+				return nil, err
+			}
+		}
+	}
+	serviceAccounts, err := cs.CoreV1().ServiceAccounts(ns).List(ctx, listOpts)
+	if err != nil {
+		return nil, listError("serviceaccounts", err)
+	}
+	for _, o := range serviceAccounts.Items {
+		// service accounts created by earlier versions of the chart are identified by name
+		if driver.belongsToGroup(o.ObjectMeta) || (o.Name == driver.ServiceAccountName() && driver.ownedBy(o.ObjectMeta)) {
+			if err := remove("serviceaccount", o.Name, cs.CoreV1().ServiceAccounts(ns).Delete); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, d := range deleted {
+		if dry {
+			log.Logger.Infof("would delete %v", d)
+		} else {
+			log.Logger.Infof("deleted %v", d)
+		}
+	}
+	return deleted, nil
+}
+
 // getChartAndVals gets experiment chart and its values
 // Credit: the logic for this function is sourced from Helm
 // https://github.com/helm/helm/blob/8ab18f7567cedffdfa5ba4d7f6abfb58efc313f8/cmd/helm/install.go#L177
@@ -478,37 +1020,64 @@ func checkIfInstallable(ch *chart.Chart) error {
 	return e
 }
 
+// experimentPodSelector returns the label selector for experiment pods of the given revision;
+// pods of all revisions are selected if revision is zero
+func (driver *KubeDriver) experimentPodSelector(revision int) string {
+	selector := fmt.Sprintf("%v=%v", groupKey, driver.Group)
+	if revision > 0 {
+		selector += fmt.Sprintf(",%v=%v", revisionKey, revision)
+	}
+	return selector
+}
+
 // GetExperimentLogs gets logs for a Kubernetes experiment
 func (driver *KubeDriver) GetExperimentLogs() (string, error) {
+	buf := new(bytes.Buffer)
+	if err := driver.StreamExperimentLogs(buf, 0, false); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// StreamExperimentLogs writes logs for a Kubernetes experiment into the given writer.
+// Logs are restricted to the given revision unless it is zero.
+// If follow is true, logs are streamed until the experiment pods terminate.
+func (driver *KubeDriver) StreamExperimentLogs(out io.Writer, revision int, follow bool) error {
 	podsClient := driver.Clientset.CoreV1().Pods(driver.Namespace())
 	pods, err := podsClient.List(context.TODO(), metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("iter8.tools/group=%v", driver.Group),
+		LabelSelector: driver.experimentPodSelector(revision),
 	})
 	if err != nil {
 		e := errors.New("unable to get experiment pod(s)")
 		log.Logger.Error(e)
-		return "", e
+		return e
+	}
+	if len(pods.Items) == 0 {
+		e := fmt.Errorf("no experiment pods found for group %v", driver.Group)
+		log.Logger.Error(e)
+		return e
 	}
-	lgs := make([]string, len(pods.Items))
 	for i, p := range pods.Items {
-		req := podsClient.GetLogs(p.Name, &corev1.PodLogOptions{})
+		if i > 0 {
+			fmt.Fprint(out, "\n***\n")
+		}
+		req := podsClient.GetLogs(p.Name, &corev1.PodLogOptions{
+			Follow: follow,
+		})
 		podLogs, err := req.Stream(context.TODO())
 		if err != nil {
 			e := errors.New("error in opening log stream")
 			log.Logger.Error(e)
-			return "", e
+			return e
 		}
 
-		defer podLogs.Close()
-		buf := new(bytes.Buffer)
-		_, err = io.Copy(buf, podLogs)
+		_, err = io.Copy(out, podLogs)
+		podLogs.Close()
 		if err != nil {
-			e := errors.New("error in copy information from podLogs to buf")
+			e := errors.New("error in copying logs from experiment pod")
 			log.Logger.Error(e)
-			return "", e
+			return e
 		}
-		str := buf.String()
-		lgs[i] = str
 	}
-	return strings.Join(lgs, "\n***\n"), nil
+	return nil
 }
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -12,6 +13,8 @@ import (
 	"helm.sh/helm/v3/pkg/cli/values"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -42,7 +45,7 @@ func TestKOps(t *testing.T) {
 	// upgrade
 	err = kd.upgrade(base.CompletePath("../", "charts/iter8"), values.Options{
 		Values: []string{"tasks={http}", "http.url=https://httpbin.org/get", "runner=job"},
-	}, kd.Group, false)
+	}, kd.Group, false, false)
 	assert.NoError(t, err)
 
 	rel, err = kd.Releases.Last(kd.Group)
@@ -144,3 +147,184 @@ func TestDryInstall(t *testing.T) {
 	assert.NoError(t, err)
 	assert.FileExists(t, ManifestFile)
 }
+
+func TestCleanupExperiment(t *testing.T) {
+	groupAnnotation := map[string]string{groupKey: "default"}
+	kd := NewFakeKubeDriver(cli.New(),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default", Annotations: groupAnnotation}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "default-1-job", Namespace: "default", Annotations: groupAnnotation}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "default-1-job-8218s", Namespace: "default", Labels: groupAnnotation}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default", Annotations: groupAnnotation}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default", Annotations: groupAnnotation}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default-iter8-sa", Namespace: "default"}},
+	)
+
+	// dry run lists resources without deleting them
+	deleted, err := kd.CleanupExperiment(true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"job/default-1-job", "pod/default-1-job-8218s", "secret/default", "role/default", "rolebinding/default", "serviceaccount/default-iter8-sa"}, deleted)
+	_, err = kd.Clientset.CoreV1().Secrets("default").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	deleted, err = kd.CleanupExperiment(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(deleted))
+	_, err = kd.Clientset.CoreV1().Secrets("default").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.Error(t, err)
+	_, err = kd.Clientset.CoreV1().Secrets("default").Get(context.TODO(), "other", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// nothing remains to be cleaned up
+	deleted, err = kd.CleanupExperiment(false)
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+}
+
+func TestCleanupExperimentOwner(t *testing.T) {
+	kd := NewFakeKubeDriver(cli.New(),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "default-1-job-a", Namespace: "default", Labels: map[string]string{groupKey: "default", ownerKey: "team-a"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "default-1-job-b", Namespace: "default", Labels: map[string]string{groupKey: "default", ownerKey: "team-b"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "default-1-job-c", Namespace: "default", Labels: map[string]string{groupKey: "default"}}},
+	)
+	kd.Owner = "team-a"
+
+	// only pods of the owner are cleaned up
+	deleted, err := kd.CleanupExperiment(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pod/default-1-job-a"}, deleted)
+	pods, err := kd.Clientset.CoreV1().Pods("default").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pods.Items))
+}
+
+func TestResultCluster(t *testing.T) {
+	os.Chdir(t.TempDir())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/drivertests", ExperimentPath))
+	kd := NewFakeKubeDriver(cli.New(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"},
+		Data:       map[string][]byte{ExperimentPath: byteArray},
+	})
+	kd.Results = ResultCluster{KubeContext: "control", Namespace: "results"}
+	rc := newFakeClientset()
+	kd.resultClientset = rc
+	assert.NoError(t, kd.InitKube())
+
+	// spec is read from the launch cluster until results are written to the control cluster
+	exp, err := kd.Read()
+	assert.NoError(t, err)
+	assert.NotNil(t, exp)
+	assert.NoError(t, kd.Write(exp))
+	s, err := rc.CoreV1().Secrets("results").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, s.Data, ExperimentPath)
+
+	// subsequent writes update the result in the control cluster
+	assert.NoError(t, kd.Write(exp))
+	_, err = kd.Read()
+	assert.NoError(t, err)
+
+	// abort requests are recorded in the control cluster
+	kd.revision = 1
+	assert.NoError(t, kd.Abort())
+	assert.True(t, kd.AbortRequested())
+	s, _ = rc.CoreV1().Secrets("results").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.Equal(t, "1", s.Annotations[abortKey])
+	s, _ = kd.Clientset.CoreV1().Secrets("default").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NotContains(t, s.Annotations, abortKey)
+}
+
+func TestKubeDriverStaleWrite(t *testing.T) {
+	os.Chdir(t.TempDir())
+	kd1 := NewFakeKubeDriver(cli.New())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", ExperimentPath))
+	_, err := kd1.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// two drivers read the same experiment
+	kd2 := *kd1
+	exp1, err := kd1.Read()
+	assert.NoError(t, err)
+	exp2, err := kd2.Read()
+	assert.NoError(t, err)
+
+	// the first driver makes progress
+	exp1.Result.NumLoops++
+	assert.NoError(t, kd1.Write(exp1))
+
+	// writes from the second driver that would lose this progress fail
+	err = kd2.Write(exp2)
+	assert.True(t, errors.Is(err, errStaleExperiment))
+
+	// changes by other writers that do not make progress, such as abort requests, are preserved
+	assert.NoError(t, kd2.Abort())
+	exp1.Result.NumCompletedTasks++
+	assert.NoError(t, kd1.Write(exp1))
+	assert.True(t, kd1.AbortRequested())
+	exp, err := kd2.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, exp1.Result.NumCompletedTasks, exp.Result.NumCompletedTasks)
+
+	// stale updates of the stored experiment are rejected with a conflict
+	r, err := kd1.resultStore().get(context.TODO(), "default")
+	assert.NoError(t, err)
+	r.ResourceVersion = "1"
+	assert.True(t, kerrors.IsConflict(kd1.resultStore().update(context.TODO(), r)))
+}
+
+func TestWriteLiveMetrics(t *testing.T) {
+	os.Chdir(t.TempDir())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/drivertests", ExperimentPath))
+	kd := NewFakeKubeDriver(cli.New(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"},
+		Data:       map[string][]byte{ExperimentPath: byteArray},
+	})
+	assert.NoError(t, kd.InitKube())
+	exp, err := kd.Read()
+	assert.NoError(t, err)
+
+	// live metrics are published in an annotation of the experiment secret
+	assert.NoError(t, kd.WriteLiveMetrics(&base.LiveMetrics{Task: base.CollectHTTPTaskName, Requests: 10, Errors: 1, ErrorRate: 0.1}))
+	s, err := kd.Clientset.CoreV1().Secrets("default").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, s.Annotations[liveMetricsKey], `"requests":10`)
+	assert.Contains(t, s.Annotations[liveMetricsKey], `"errorRate":0.1`)
+
+	// the annotation is removed when the experiment is next written
+	assert.NoError(t, kd.Write(exp))
+	s, err = kd.Clientset.CoreV1().Secrets("default").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, s.Annotations, liveMetricsKey)
+}
+
+func TestRecordEvent(t *testing.T) {
+	os.Chdir(t.TempDir())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/drivertests", ExperimentPath))
+	kd := NewFakeKubeDriver(cli.New(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default", UID: "1234"},
+		Data:       map[string][]byte{ExperimentPath: byteArray},
+	})
+	assert.NoError(t, kd.InitKube())
+
+	// milestones are recorded as events of the experiment secret
+	assert.NoError(t, kd.RecordEvent(base.ExperimentEvent{Warning: true, Reason: base.EventReasonSLOViolation, Message: "versions [1] do not satisfy SLOs"}))
+	events, err := kd.Clientset.CoreV1().Events("default").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, events.Items, 1)
+	ev := events.Items[0]
+	assert.Equal(t, corev1.ObjectReference{APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "default", UID: "1234"}, ev.InvolvedObject)
+	assert.Equal(t, corev1.EventTypeWarning, ev.Type)
+	assert.Equal(t, base.EventReasonSLOViolation, ev.Reason)
+	assert.Equal(t, "versions [1] do not satisfy SLOs", ev.Message)
+	assert.Equal(t, "default", ev.Labels[groupKey])
+
+	// the experiment must exist
+	kd.Group = "missing"
+	assert.Error(t, kd.RecordEvent(base.ExperimentEvent{Reason: base.EventReasonStarted}))
+}
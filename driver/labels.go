@@ -0,0 +1,227 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// managedByKey is the label that identifies resources managed by Iter8
+	managedByKey = "app.kubernetes.io/managed-by"
+	// managedByValue is the value of the managedByKey label of resources managed by Iter8
+	managedByValue = "iter8"
+	// revisionKey is the label that identifies the experiment revision of a Kubernetes resource
+	revisionKey = "iter8.tools/revision"
+	// ownerKey is the label that identifies the owner of a Kubernetes resource; for example, a team or tenant
+	ownerKey = "iter8.tools/owner"
+	// chartKey is the label that identifies the chart used to create a Kubernetes resource
+	chartKey = "iter8.tools/chart"
+	// ownerValue is the chart value that sets the owner of an experiment
+	ownerValue = "owner"
+)
+
+// LabeledResource is a Kubernetes resource labeled with the experiment group and owner
+type LabeledResource struct {
+	// Kind of the resource; for example, secret or job
+	Kind string `json:"kind"`
+	// Namespace of the resource
+	Namespace string `json:"namespace"`
+	// Name of the resource
+	Name string `json:"name"`
+	// Group is the experiment group of the resource
+	Group string `json:"group"`
+	// Revision is the experiment revision that created the resource
+	Revision string `json:"revision,omitempty"`
+	// Owner of the resource
+	Owner string `json:"owner,omitempty"`
+	// Chart used to create the resource
+	Chart string `json:"chart,omitempty"`
+}
+
+// ResourceQuery selects Kubernetes resources managed by Iter8 using their labels
+type ResourceQuery struct {
+	// Group selects resources of the experiment group; resources of all groups are selected if empty
+	Group string
+	// Owner selects resources of the owner; resources of all owners are selected if empty
+	Owner string
+	// AllNamespaces selects resources in all namespaces; otherwise, only resources in the namespace of the driver are selected
+	AllNamespaces bool
+}
+
+// Selector returns the label selector of the query
+func (q ResourceQuery) Selector() string {
+	selector := []string{fmt.Sprintf("%v=%v", managedByKey, managedByValue)}
+	if q.Group != "" {
+		selector = append(selector, fmt.Sprintf("%v=%v", groupKey, q.Group))
+	}
+	if q.Owner != "" {
+		selector = append(selector, fmt.Sprintf("%v=%v", ownerKey, q.Owner))
+	}
+	return strings.Join(selector, ",")
+}
+
+// groupLabels returns the labels of resources created by the driver for the experiment group
+func (driver *KubeDriver) groupLabels() map[string]string {
+	labels := map[string]string{
+		managedByKey: managedByValue,
+		groupKey:     driver.Group,
+	}
+	if driver.Owner != "" {
+		labels[ownerKey] = driver.Owner
+	}
+	return labels
+}
+
+// withOwner sets the owner chart value to the owner of the driver, unless the value is set
+func (driver *KubeDriver) withOwner(vals map[string]interface{}) {
+	if driver.Owner == "" {
+		return
+	}
+	if _, ok := vals[ownerValue]; !ok {
+		vals[ownerValue] = driver.Owner
+	}
+}
+
+// ownedBy returns true if the object is labeled with the owner of the driver, or if the driver has no owner
+func (driver *KubeDriver) ownedBy(meta metav1.ObjectMeta) bool {
+	return driver.Owner == "" || meta.Labels[ownerKey] == driver.Owner
+}
+
+// QueryResources returns the Kubernetes resources managed by Iter8 that are selected by the query,
+// sorted by namespace, group, kind, and name.
+// Secrets, config maps, service accounts, roles, role bindings, jobs, and cron jobs are queried.
+func (driver *KubeDriver) QueryResources(q ResourceQuery) ([]LabeledResource, error) {
+	ns := driver.Namespace()
+	if q.AllNamespaces {
+		ns = metav1.NamespaceAll
+	}
+	ctx := context.Background()
+	opts := metav1.ListOptions{LabelSelector: q.Selector()}
+	cs := driver.Clientset
+
+	resources := []LabeledResource{}
+	add := func(kind string, meta metav1.ObjectMeta) {
+		resources = append(resources, LabeledResource{
+			Kind:      kind,
+			Namespace: meta.Namespace,
+			Name:      meta.Name,
+			Group:     meta.Labels[groupKey],
+			Revision:  meta.Labels[revisionKey],
+			Owner:     meta.Labels[ownerKey],
+			Chart:     meta.Labels[chartKey],
+		})
+	}
+	for _, lister := range []struct {
+		kind string
+		list func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error)
+	}{
+		{"secret", func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error) {
+			l, err := cs.CoreV1().Secrets(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, o := range l.Items {
+				metas = append(metas, o.ObjectMeta)
+			}
+			return metas, nil
+		}},
+		{"configmap", func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error) {
+			l, err := cs.CoreV1().ConfigMaps(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, o := range l.Items {
+				metas = append(metas, o.ObjectMeta)
+			}
+			return metas, nil
+		}},
+		{"serviceaccount", func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error) {
+			l, err := cs.CoreV1().ServiceAccounts(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, o := range l.Items {
+				metas = append(metas, o.ObjectMeta)
+			}
+			return metas, nil
+		}},
+		{"role", func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error) {
+			l, err := cs.RbacV1().Roles(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, o := range l.Items {
+				metas = append(metas, o.ObjectMeta)
+			}
+			return metas, nil
+		}},
+		{"rolebinding", func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error) {
+			l, err := cs.RbacV1().RoleBindings(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, o := range l.Items {
+				metas = append(metas, o.ObjectMeta)
+			}
+			return metas, nil
+		}},
+		{"job", func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error) {
+			l, err := cs.BatchV1().Jobs(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, o := range l.Items {
+				metas = append(metas, o.ObjectMeta)
+			}
+			return metas, nil
+		}},
+		{"cronjob", func(cs kubernetes.Interface) ([]metav1.ObjectMeta, error) {
+			l, err := cs.BatchV1().CronJobs(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, o := range l.Items {
+				metas = append(metas, o.ObjectMeta)
+			}
+			return metas, nil
+		}},
+	} {
+		metas, err := lister.list(cs)
+		if err != nil {
+			e := fmt.Errorf("unable to list %vs with labels %v", lister.kind, q.Selector())
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return nil, e
+		}
+		for _, m := range metas {
+			add(lister.kind, m)
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return resources, nil
+}
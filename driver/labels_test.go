@@ -0,0 +1,85 @@
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQueryResources(t *testing.T) {
+	labels := func(group string, owner string) map[string]string {
+		return map[string]string{managedByKey: managedByValue, groupKey: group, ownerKey: owner, revisionKey: "1"}
+	}
+	kd := NewFakeKubeDriver(cli.New(),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", Labels: labels("a", "team-a")}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "a-1-job", Namespace: "default", Labels: labels("a", "team-a")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default", Labels: labels("b", "team-b")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "other", Labels: labels("c", "team-a")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "default"}},
+	)
+
+	resources, err := kd.QueryResources(ResourceQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(resources))
+
+	resources, err = kd.QueryResources(ResourceQuery{Owner: "team-a", AllNamespaces: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []LabeledResource{
+		{Kind: "job", Namespace: "default", Name: "a-1-job", Group: "a", Revision: "1", Owner: "team-a"},
+		{Kind: "secret", Namespace: "default", Name: "a", Group: "a", Revision: "1", Owner: "team-a"},
+		{Kind: "secret", Namespace: "other", Name: "c", Group: "c", Revision: "1", Owner: "team-a"},
+	}, resources)
+
+	resources, err = kd.QueryResources(ResourceQuery{Group: "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resources))
+	assert.Equal(t, "team-b", resources[0].Owner)
+}
+
+func TestCleanupExperimentOfOwner(t *testing.T) {
+	kd := NewFakeKubeDriver(cli.New(),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default", Labels: map[string]string{groupKey: "default", ownerKey: "team-b"}}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default-iter8-sa", Namespace: "default", Labels: map[string]string{ownerKey: "team-b"}}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "default-1-job", Namespace: "default", Labels: map[string]string{groupKey: "default", ownerKey: "team-a"}}},
+	)
+
+	// resources of other owners are not cleaned up
+	kd.Owner = "team-a"
+	deleted, err := kd.CleanupExperiment(true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job/default-1-job"}, deleted)
+
+	kd.Owner = ""
+	deleted, err = kd.CleanupExperiment(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(deleted))
+}
+
+func TestKubeDriverPreservesLabels(t *testing.T) {
+	os.Chdir(t.TempDir())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/assertinputs", ExperimentPath))
+	kd := NewFakeKubeDriver(cli.New(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+			Labels:    map[string]string{managedByKey: managedByValue, groupKey: "default", ownerKey: "team-a"},
+		},
+		Data: map[string][]byte{ExperimentPath: byteArray},
+	})
+
+	exp, err := kd.Read()
+	assert.NoError(t, err)
+	assert.NoError(t, kd.Write(exp))
+	assert.NoError(t, kd.Abort())
+	sec, err := kd.Clientset.CoreV1().Secrets("default").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "team-a", sec.Labels[ownerKey])
+}
@@ -0,0 +1,150 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iter8-tools/iter8/base/log"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// leaseSuffix is the suffix of the name of the lease that guards runs of an experiment group
+	leaseSuffix = "-lock"
+	// leaseDuration is the duration for which a lease is held without being renewed;
+	// the lease of a run that stops without releasing it can be acquired after this duration
+	leaseDuration = 30 * time.Second
+	// leaseRenewInterval is the interval at which a held lease is renewed
+	leaseRenewInterval = 10 * time.Second
+)
+
+// lockName returns the name of the lease that guards runs of the experiment group
+func (driver *KubeDriver) lockName() string {
+	return driver.Group + leaseSuffix
+}
+
+// lockIdentity returns the identity of this run; the host name, which is the pod name in Kubernetes experiments,
+// along with the process ID and the start time of the run
+func lockIdentity() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%v_%v_%v", host, os.Getpid(), time.Now().UnixNano())
+}
+
+// expired returns true if the lease has not been renewed within its duration
+func expired(l *coordinationv1.Lease, now time.Time) bool {
+	if l.Spec.HolderIdentity == nil || *l.Spec.HolderIdentity == "" || l.Spec.RenewTime == nil {
+		return true
+	}
+	d := leaseDuration
+	if l.Spec.LeaseDurationSeconds != nil {
+		d = time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return l.Spec.RenewTime.Add(d).Before(now)
+}
+
+// Lock acquires the lease of the experiment group, so that runs of the group do not execute concurrently
+// and interleave writes to its result. The lease is renewed until the returned function releases it.
+// An error is returned if another run holds the lease.
+func (driver *KubeDriver) Lock() (func(), error) {
+	ctx := context.Background()
+	leases := driver.Clientset.CoordinationV1().Leases(driver.Namespace())
+	identity := lockIdentity()
+	seconds := int32(leaseDuration / time.Second)
+	now := metav1.NewMicroTime(time.Now())
+
+	l, err := leases.Get(ctx, driver.lockName(), metav1.GetOptions{})
+	switch {
+	case kerrors.IsNotFound(err):
+		l, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   driver.lockName(),
+				Labels: driver.groupLabels(),
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+	case err == nil && !expired(l, now.Time):
+		e := fmt.Errorf("experiment group %v is being run by %v", driver.Group, *l.Spec.HolderIdentity)
+		log.Logger.Error(e)
+		return nil, e
+	case err == nil:
+		// the lease of an earlier run has expired; an update fails with a conflict if another run acquires it first
+		if l.Spec.HolderIdentity != nil && *l.Spec.HolderIdentity != "" {
+			log.Logger.Warnf("acquiring expired lock of experiment group %v held by %v", driver.Group, *l.Spec.HolderIdentity)
+		}
+		transitions := int32(1)
+		if l.Spec.LeaseTransitions != nil {
+			transitions = *l.Spec.LeaseTransitions + 1
+		}
+		l.Spec = coordinationv1.LeaseSpec{
+			HolderIdentity:       &identity,
+			LeaseDurationSeconds: &seconds,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+			LeaseTransitions:     &transitions,
+		}
+		l, err = leases.Update(ctx, l, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		e := fmt.Errorf("unable to lock experiment group %v", driver.Group)
+		if kerrors.IsAlreadyExists(err) || kerrors.IsConflict(err) {
+			e = fmt.Errorf("experiment group %v is being run by another run", driver.Group)
+		}
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	log.Logger.Debugf("locked experiment group %v as %v", driver.Group, identity)
+
+	// renew the lease until it is released
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(leaseRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				renewTime := metav1.NewMicroTime(time.Now())
+				l.Spec.RenewTime = &renewTime
+				renewed, err := leases.Update(ctx, l, metav1.UpdateOptions{})
+				if err != nil {
+					log.Logger.WithStackTrace(err.Error()).Warnf("unable to renew lock of experiment group %v", driver.Group)
+				} else {
+					l = renewed
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+			// release the lease, unless another run has acquired it
+			err := leases.Delete(ctx, l.Name, metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{ResourceVersion: &l.ResourceVersion},
+			})
+			if err != nil && !kerrors.IsNotFound(err) {
+				log.Logger.WithStackTrace(err.Error()).Warnf("unable to release lock of experiment group %v", driver.Group)
+				return
+			}
+			log.Logger.Debugf("unlocked experiment group %v", driver.Group)
+		})
+	}, nil
+}
@@ -0,0 +1,47 @@
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLock(t *testing.T) {
+	kd := NewFakeKubeDriver(cli.New())
+	assert.NoError(t, kd.Init())
+
+	unlock, err := kd.Lock()
+	assert.NoError(t, err)
+	l, err := kd.Clientset.CoordinationV1().Leases(kd.Namespace()).Get(context.Background(), kd.lockName(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, kd.Group, l.Labels[groupKey])
+
+	// a concurrent run of the group is rejected
+	_, err = kd.Lock()
+	assert.Error(t, err)
+
+	// the group can be run again once it is unlocked
+	unlock()
+	unlock()
+	unlock, err = kd.Lock()
+	assert.NoError(t, err)
+
+	// the lease of a run that stopped without releasing it can be acquired after it expires
+	l, err = kd.Clientset.CoordinationV1().Leases(kd.Namespace()).Get(context.Background(), kd.lockName(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	renewTime := metav1.NewMicroTime(time.Now().Add(-2 * leaseDuration))
+	l.Spec.RenewTime = &renewTime
+	_, err = kd.Clientset.CoordinationV1().Leases(kd.Namespace()).Update(context.Background(), l, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	unlock2, err := kd.Lock()
+	assert.NoError(t, err)
+	l, err = kd.Clientset.CoordinationV1().Leases(kd.Namespace()).Get(context.Background(), kd.lockName(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), *l.Spec.LeaseTransitions)
+	unlock2()
+	unlock()
+}
@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// S3Scheme is the URL scheme of experiments stored in Amazon S3
+	S3Scheme = "s3"
+	// GCSScheme is the URL scheme of experiments stored in Google Cloud Storage
+	GCSScheme = "gs"
+	// AzureBlobScheme is the URL scheme of experiments stored in Azure Blob storage
+	AzureBlobScheme = "azblob"
+	// objectTimeout is the timeout of requests to object storage
+	objectTimeout = 30 * time.Second
+)
+
+// errObjectNotFound is returned when the object does not exist in object storage
+var errObjectNotFound = errors.New("object not found")
+
+// objectClient gets and puts objects in a bucket (or container) in object storage
+type objectClient interface {
+	// get the object with the given key
+	get(key string) ([]byte, error)
+	// put the object with the given key
+	put(key string, b []byte) error
+}
+
+// ObjectDriver enables reading and writing experiment spec and result in object storage,
+// such as Amazon S3, Google Cloud Storage, and Azure Blob storage.
+// The experiment is stored in the experiment.yaml object under the path of the URL.
+// Credentials are obtained from the environment.
+type ObjectDriver struct {
+	// URL of the experiment folder in object storage;
+	// for example, s3://bucket/folder, gs://bucket/folder, or azblob://container/folder
+	URL string
+	// RunDir is the directory where the experiment.yaml file is to be found,
+	// until the experiment is written to object storage
+	RunDir string
+	// key is the key of the experiment object
+	key string
+	// client of object storage
+	client objectClient
+}
+
+// IsObjectURL returns true if the location is the URL of an experiment in object storage
+func IsObjectURL(location string) bool {
+	for _, scheme := range []string{S3Scheme, GCSScheme, AzureBlobScheme} {
+		if strings.HasPrefix(location, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewObjectDriver creates and returns a new ObjectDriver for the experiment at the given URL
+func NewObjectDriver(objectURL string, runDir string) (*ObjectDriver, error) {
+	u, err := url.Parse(objectURL)
+	if err != nil || u.Host == "" {
+		e := fmt.Errorf("invalid object storage URL %v", objectURL)
+		log.Logger.Error(e)
+		return nil, e
+	}
+	od := &ObjectDriver{
+		URL:    objectURL,
+		RunDir: runDir,
+		key:    strings.TrimPrefix(path.Join(u.Path, ExperimentPath), "/"),
+	}
+	httpClient := &http.Client{Timeout: objectTimeout}
+	switch u.Scheme {
+	case S3Scheme:
+		od.client = newS3Client(u.Host, httpClient)
+	case GCSScheme:
+		od.client = newGCSClient(u.Host, httpClient)
+	case AzureBlobScheme:
+		od.client, err = newAzureBlobClient(u.Host, httpClient)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		e := fmt.Errorf("unsupported object storage URL %v; scheme must be one of %v, %v, or %v", objectURL, S3Scheme, GCSScheme, AzureBlobScheme)
+		log.Logger.Error(e)
+		return nil, e
+	}
+	return od, nil
+}
+
+// Read the experiment.
+// If the experiment is not yet in object storage, it is read from the run dir.
+func (od *ObjectDriver) Read() (*base.Experiment, error) {
+	b, err := od.client.get(od.key)
+	if err == nil {
+		return ExperimentFromBytes(b)
+	}
+	if errors.Is(err, errObjectNotFound) && od.RunDir != "" {
+		log.Logger.Debugf("experiment not found at %v; reading it from %v", od.URL, od.RunDir)
+		return (&FileDriver{RunDir: od.RunDir}).Read()
+	}
+	e := fmt.Errorf("unable to read experiment from %v", od.URL)
+	log.Logger.WithStackTrace(err.Error()).Error(e)
+	return nil, e
+}
+
+// Write the experiment
+func (od *ObjectDriver) Write(exp *base.Experiment) error {
+	b, _ := yaml.Marshal(exp)
+	if err := od.client.put(od.key, b); err != nil {
+		e := fmt.Errorf("unable to write experiment to %v", od.URL)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return e
+	}
+	return nil
+}
+
+// GetRevision is undefined for object drivers
+func (od *ObjectDriver) GetRevision() int {
+	return 0
+}
+
+// doObjectRequest sends the request to object storage and returns the response body;
+// errObjectNotFound is returned if the object does not exist
+func doObjectRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errObjectNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := string(b)
+		if len(msg) > 512 {
+			msg = msg[:512]
+		}
+		return nil, fmt.Errorf("%v %v returned status %v: %v", req.Method, req.URL.Redacted(), resp.StatusCode, msg)
+	}
+	return b, nil
+}
@@ -0,0 +1,197 @@
+package driver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+)
+
+// setEnv sets environment variables until the end of the test
+func setEnv(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		old, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		k := k
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(k, old)
+			} else {
+				os.Unsetenv(k)
+			}
+		})
+	}
+}
+
+// fakeObjectStore is an in-memory object store; objects are keyed by their name without bucket
+type fakeObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	// auth is the Authorization header of the last request
+	auth string
+}
+
+// newFakeObjectServer starts an object storage server that understands S3, GCS, and Azure Blob requests
+func newFakeObjectServer(t *testing.T) (*httptest.Server, *fakeObjectStore) {
+	store := &fakeObjectStore{objects: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.auth = r.Header.Get("Authorization")
+		var name string
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/"):
+			name = r.URL.Query().Get("name")
+		case strings.HasPrefix(r.URL.Path, "/storage/v1/b/"):
+			name = r.URL.Path[strings.Index(r.URL.Path, "/o/")+3:]
+		default:
+			// path style; the first segment is the bucket or container
+			name = strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[1]
+		}
+		switch r.Method {
+		case http.MethodGet:
+			b, ok := store.objects[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(b)
+		case http.MethodPut, http.MethodPost:
+			b, _ := ioutil.ReadAll(r.Body)
+			store.objects[name] = b
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, store
+}
+
+// testObjectDriver reads the experiment from the run dir, writes it, and reads it back from object storage
+func testObjectDriver(t *testing.T, objectURL string, store *fakeObjectStore) {
+	dir := t.TempDir()
+	CopyFileToPwd(t, base.CompletePath("../testdata/drivertests", ExperimentPath))
+	assert.NoError(t, os.Rename(ExperimentPath, filepath.Join(dir, ExperimentPath)))
+
+	od, err := NewObjectDriver(objectURL, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, od.GetRevision())
+
+	// experiment is read from the run dir until it is written
+	exp, err := od.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(exp.Spec))
+	exp.Result = &base.ExperimentResult{NumCompletedTasks: 4}
+	assert.NoError(t, od.Write(exp))
+	assert.Contains(t, store.objects, "folder/experiment.yaml")
+	assert.NotEmpty(t, store.auth)
+
+	// experiment is read from object storage after it is written
+	assert.NoError(t, os.Remove(filepath.Join(dir, ExperimentPath)))
+	exp, err = od.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, exp.Result.NumCompletedTasks)
+}
+
+func TestS3Driver(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv, store := newFakeObjectServer(t)
+	setEnv(t, map[string]string{
+		"AWS_ENDPOINT_URL":      srv.URL,
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	})
+	testObjectDriver(t, "s3://bucket/folder", store)
+	assert.True(t, strings.HasPrefix(store.auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+}
+
+func TestS3DriverWithWebIdentity(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv, store := newFakeObjectServer(t)
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "AssumeRoleWithWebIdentity", r.Form.Get("Action"))
+		assert.Equal(t, "arn:aws:iam::123456789012:role/iter8", r.Form.Get("RoleArn"))
+		assert.Equal(t, "my-token", r.Form.Get("WebIdentityToken"))
+		w.Write([]byte(`<AssumeRoleWithWebIdentityResponse>
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>session</SessionToken>
+      <Expiration>` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("my-token\n"), 0600))
+	setEnv(t, map[string]string{
+		"AWS_ENDPOINT_URL":            srv.URL,
+		"AWS_ENDPOINT_URL_STS":        sts.URL,
+		"AWS_ACCESS_KEY_ID":           "",
+		"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+		"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/iter8",
+	})
+	testObjectDriver(t, "s3://bucket/folder", store)
+	assert.True(t, strings.HasPrefix(store.auth, "AWS4-HMAC-SHA256 Credential=ASIAEXAMPLE/"))
+}
+
+func TestGCSDriver(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv, store := newFakeObjectServer(t)
+	setEnv(t, map[string]string{
+		"STORAGE_EMULATOR_HOST":     strings.TrimPrefix(srv.URL, "http://"),
+		"GOOGLE_OAUTH_ACCESS_TOKEN": "my-token",
+	})
+	testObjectDriver(t, "gs://bucket/folder", store)
+	assert.Equal(t, "Bearer my-token", store.auth)
+}
+
+func TestAzureBlobDriver(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv, store := newFakeObjectServer(t)
+	setEnv(t, map[string]string{
+		"AZURE_STORAGE_CONNECTION_STRING": "DefaultEndpointsProtocol=http;AccountName=account;AccountKey=c2VjcmV0;BlobEndpoint=" + srv.URL + ";",
+	})
+	testObjectDriver(t, "azblob://container/folder", store)
+	assert.True(t, strings.HasPrefix(store.auth, "SharedKey account:"))
+}
+
+func TestInvalidObjectURL(t *testing.T) {
+	assert.True(t, IsObjectURL("s3://bucket/folder"))
+	assert.False(t, IsObjectURL("https://bucket/folder"))
+
+	_, err := NewObjectDriver("s3:///folder", ".")
+	assert.Error(t, err)
+	_, err = NewObjectDriver("ftp://bucket/folder", ".")
+	assert.Error(t, err)
+
+	setEnv(t, map[string]string{
+		"AZURE_STORAGE_CONNECTION_STRING": "",
+		"AZURE_STORAGE_ACCOUNT":           "",
+	})
+	_, err = NewObjectDriver("azblob://container/folder", ".")
+	assert.Error(t, err)
+
+	// errors from object storage are reported
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	setEnv(t, map[string]string{"STORAGE_EMULATOR_HOST": srv.URL})
+	od, err := NewObjectDriver("gs://bucket/folder", ".")
+	assert.NoError(t, err)
+	_, err = od.Read()
+	assert.Error(t, err)
+	assert.Error(t, od.Write(&base.Experiment{}))
+}
@@ -0,0 +1,174 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iter8-tools/iter8/base/log"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// JobRunner runs Kubernetes experiments in a job
+	JobRunner = "job"
+	// CronJobRunner runs Kubernetes experiments periodically in a cron job
+	CronJobRunner = "cronjob"
+	// ControllerRunner runs Kubernetes experiments in the Iter8 controller
+	ControllerRunner = "controller"
+	// NoRunner does not run Kubernetes experiments
+	NoRunner = "none"
+)
+
+// Runners are the supported runners of Kubernetes experiments
+var Runners = []string{JobRunner, CronJobRunner, ControllerRunner, NoRunner}
+
+// AccessCheck is a permission required by a Kubernetes experiment, along with the result of checking it
+type AccessCheck struct {
+	// Subject whose permission is checked; the current identity if empty
+	Subject string `json:"subject,omitempty"`
+	// Verb is the Kubernetes API verb; for example, get or create
+	Verb string `json:"verb"`
+	// Group is the API group of the resource
+	Group string `json:"group,omitempty"`
+	// Resource is the Kubernetes resource; for example, secrets
+	Resource string `json:"resource"`
+	// Namespace of the resource
+	Namespace string `json:"namespace"`
+	// Name of the resource; any resource if empty
+	Name string `json:"name,omitempty"`
+	// Allowed is true if the subject has the permission
+	Allowed bool `json:"allowed"`
+	// Reason explains why the permission is denied, or why it could not be checked
+	Reason string `json:"reason,omitempty"`
+}
+
+// storageResource returns the API group and resource of the objects in which experiments are stored
+func (driver *KubeDriver) storageResource() (string, string) {
+	switch driver.Storage {
+	case ConfigMapStorage:
+		return "", "configmaps"
+	case CustomResourceStorage:
+		return experimentGVR.Group, experimentGVR.Resource
+	default:
+		return "", "secrets"
+	}
+}
+
+// ServiceAccountName returns the name of the service account used by experiment pods,
+// unless the service account is customized using job values
+func (driver *KubeDriver) ServiceAccountName() string {
+	return driver.Group + "-iter8-sa"
+}
+
+// LaunchPermissions returns the permissions needed by the current identity
+// to launch, inspect, and delete the experiment with the given runner
+func (driver *KubeDriver) LaunchPermissions(runner string) []AccessCheck {
+	ns := driver.Namespace()
+	checks := []AccessCheck{}
+	add := func(group string, resource string, verbs ...string) {
+		for _, v := range verbs {
+			checks = append(checks, AccessCheck{Verb: v, Group: group, Resource: resource, Namespace: ns})
+		}
+	}
+	// Helm stores releases in secrets
+	add("", "secrets", "get", "list", "create", "update", "delete")
+	if driver.Storage == ConfigMapStorage || driver.Storage == CustomResourceStorage {
+		group, resource := driver.storageResource()
+		add(group, resource, "get", "list", "create", "update", "delete")
+	}
+	if runner == JobRunner || runner == CronJobRunner {
+		add("", "serviceaccounts", "get", "create", "update", "delete")
+		add("rbac.authorization.k8s.io", "roles", "get", "create", "update", "delete")
+		add("rbac.authorization.k8s.io", "rolebindings", "get", "create", "update", "delete")
+		add("batch", "jobs", "get", "list", "create", "update", "delete")
+		if runner == CronJobRunner {
+			add("batch", "cronjobs", "get", "create", "update", "delete")
+		}
+		add("", "pods", "list")
+		add("", "pods/log", "get")
+		// roles can only grant permissions held by the identity that creates them
+		add("coordination.k8s.io", "leases", "get", "create", "update", "delete")
+		add("", "events", "create")
+	}
+	return checks
+}
+
+// ExperimentPermissions returns the permissions needed by the given service account
+// to run the experiment in a job or cron job; these are the permissions granted by the role of the experiment
+func (driver *KubeDriver) ExperimentPermissions(serviceAccount string) []AccessCheck {
+	ns := driver.Namespace()
+	subject := fmt.Sprintf("system:serviceaccount:%v:%v", ns, serviceAccount)
+	checks := []AccessCheck{}
+	add := func(group string, resource string, name string, verbs ...string) {
+		for _, v := range verbs {
+			checks = append(checks, AccessCheck{Subject: subject, Verb: v, Group: group, Resource: resource, Namespace: ns, Name: name})
+		}
+	}
+	group, resource := driver.storageResource()
+	name := driver.getExperimentSecretName()
+	add(group, resource, name, "get", "update")
+	if driver.Storage != CustomResourceStorage {
+		// large experiments are split across objects, which are created when they are first needed,
+		// and deleted when they are no longer needed
+		for i := 1; i < maxParts; i++ {
+			add(group, resource, partName(name, i), "get", "update", "delete")
+		}
+		add(group, resource, "", "create")
+	}
+	// runs of the experiment group hold a lease, so that they do not run concurrently
+	add("coordination.k8s.io", "leases", driver.lockName(), "get", "update", "delete")
+	add("coordination.k8s.io", "leases", "", "create")
+	// milestones of the experiment are recorded as events
+	add("", "events", "", "create")
+	return checks
+}
+
+// CheckAccess checks the given permissions using self subject access reviews for the current identity,
+// and subject access reviews for other subjects. The result of each check is recorded in it.
+// The number of permissions that are denied or could not be checked is returned.
+func (driver *KubeDriver) CheckAccess(checks []AccessCheck) int {
+	missing := 0
+	for i := range checks {
+		c := &checks[i]
+		attrs := &authv1.ResourceAttributes{
+			Namespace: c.Namespace,
+			Verb:      c.Verb,
+			Group:     c.Group,
+			Resource:  c.Resource,
+			Name:      c.Name,
+		}
+		var status authv1.SubjectAccessReviewStatus
+		if c.Subject == "" {
+			r, err := driver.Clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(context.Background(), &authv1.SelfSubjectAccessReview{
+				Spec: authv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attrs},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				log.Logger.WithStackTrace(err.Error()).Debugf("unable to review access to %v %v", c.Verb, c.Resource)
+				status.Reason = fmt.Sprintf("unable to review access: %v", err)
+			} else {
+				status = r.Status
+			}
+		} else {
+			r, err := driver.Clientset.AuthorizationV1().SubjectAccessReviews().Create(context.Background(), &authv1.SubjectAccessReview{
+				Spec: authv1.SubjectAccessReviewSpec{
+					ResourceAttributes: attrs,
+					User:               c.Subject,
+					Groups:             []string{"system:serviceaccounts", "system:serviceaccounts:" + c.Namespace, "system:authenticated"},
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				log.Logger.WithStackTrace(err.Error()).Debugf("unable to review access of %v to %v %v", c.Subject, c.Verb, c.Resource)
+				status.Reason = fmt.Sprintf("unable to review access: %v", err)
+			} else {
+				status = r.Status
+			}
+		}
+		c.Allowed = status.Allowed
+		c.Reason = status.Reason
+		if !c.Allowed {
+			missing++
+		}
+	}
+	return missing
+}
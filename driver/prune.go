@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/iter8/base/log"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// retentionValue is the chart value that sets the retention policy of an experiment group.
+	// Old revisions are pruned whenever the group is launched or upgraded.
+	retentionValue = "retention"
+)
+
+// RetentionPolicy determines which revisions of an experiment group are pruned.
+// The latest revision is never pruned.
+type RetentionPolicy struct {
+	// Keep is the number of latest revisions that are kept; if zero, revisions are pruned by age only
+	Keep int
+	// OlderThan prunes only revisions that were deployed longer ago than this; if zero, revisions are pruned by number only
+	OlderThan time.Duration
+}
+
+// empty returns true if the policy does not prune any revision
+func (p RetentionPolicy) empty() bool {
+	return p.Keep <= 0 && p.OlderThan <= 0
+}
+
+// ParseAge parses an age such as 30d or 12h; in addition to the units of durations, d (days) is supported
+func ParseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid age %v", s)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %v", s)
+	}
+	return d, nil
+}
+
+// retentionPolicy returns the retention policy set by the chart values, if any
+func retentionPolicy(vals map[string]interface{}) (*RetentionPolicy, error) {
+	r, ok := vals[retentionValue].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	p := &RetentionPolicy{}
+	if keep, ok := r["keep"]; ok {
+		k, err := strconv.Atoi(fmt.Sprint(keep))
+		if err != nil || k < 1 {
+			return nil, fmt.Errorf("invalid retention.keep %v; must be a positive integer", keep)
+		}
+		p.Keep = k
+	}
+	if olderThan, ok := r["olderThan"]; ok {
+		d, err := ParseAge(fmt.Sprint(olderThan))
+		if err != nil {
+			return nil, err
+		}
+		p.OlderThan = d
+	}
+	return p, nil
+}
+
+// applyRetention prunes old revisions of the experiment group according to the retention policy in the chart values.
+// Failures are logged, and do not fail the launch.
+func (driver *KubeDriver) applyRetention(vals map[string]interface{}) {
+	p, err := retentionPolicy(vals)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warn("ignoring invalid retention policy")
+		return
+	}
+	if p == nil {
+		return
+	}
+	if _, err := driver.Prune(*p, false); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Warnf("unable to prune old revisions of experiment group %v", driver.Group)
+	}
+}
+
+// Prune deletes the release records and jobs of old revisions of the experiment group, according to the policy.
+// Pods of pruned jobs are deleted along with them.
+// If dry is true, resources are only listed.
+// The kind and name of each (to be) deleted resource is returned.
+func (driver *KubeDriver) Prune(p RetentionPolicy, dry bool) ([]string, error) {
+	if p.empty() {
+		e := errors.New("retention policy must keep a number of revisions, or prune revisions older than an age")
+		log.Logger.Error(e)
+		return nil, e
+	}
+	hist, err := driver.Configuration.Releases.History(driver.Group)
+	if err != nil || len(hist) == 0 {
+		e := fmt.Errorf("unable to get revisions of experiment group %v", driver.Group)
+		if err != nil {
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+		} else {
+			log.Logger.Error(e)
+		}
+		return nil, e
+	}
+	sort.Slice(hist, func(i, j int) bool {
+		return hist[i].Version > hist[j].Version
+	})
+
+	cutoff := time.Now().Add(-p.OlderThan)
+	kept := map[int]bool{}
+	deleted := []string{}
+	for i, rel := range hist {
+		prune := i > 0
+		if p.Keep > 0 && i < p.Keep {
+			prune = false
+		}
+		if p.OlderThan > 0 && (rel.Info == nil || rel.Info.LastDeployed.Time.After(cutoff)) {
+			prune = false
+		}
+		if !prune {
+			kept[rel.Version] = true
+			continue
+		}
+		if !dry {
+			if _, err := driver.Configuration.Releases.Delete(rel.Name, rel.Version); err != nil {
+				e := fmt.Errorf("unable to delete revision %v of experiment group %v", rel.Version, driver.Group)
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return nil, e
+			}
+		}
+		deleted = append(deleted, fmt.Sprintf("revision/%v", rel.Version))
+	}
+
+	// jobs of revisions that are not kept, including revisions whose release records were pruned earlier
+	ctx := context.Background()
+	ns := driver.Namespace()
+	jobs, err := driver.Clientset.BatchV1().Jobs(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		e := errors.New("unable to list jobs")
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	background := metav1.DeletePropagationBackground
+	for _, o := range jobs.Items {
+		if !driver.belongsToGroup(o.ObjectMeta) {
+			continue
+		}
+		rev, err := strconv.Atoi(o.Labels[revisionKey])
+		if err != nil || kept[rev] || rev > hist[0].Version {
+			continue
+		}
+		if !dry {
+			err := driver.Clientset.BatchV1().Jobs(ns).Delete(ctx, o.Name, metav1.DeleteOptions{PropagationPolicy: &background})
+			if err != nil && !kerrors.IsNotFound(err) {
+				e := fmt.Errorf("unable to delete job %v", o.Name)
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return nil, e
+			}
+		}
+		deleted = append(deleted, "job/"+o.Name)
+	}
+
+	for _, d := range deleted {
+		if dry {
+			log.Logger.Infof("would delete %v", d)
+		} else {
+			log.Logger.Infof("deleted %v", d)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,121 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrune(t *testing.T) {
+	os.Chdir(t.TempDir())
+	kd := NewFakeKubeDriver(cli.New())
+	assert.NoError(t, kd.Init())
+
+	// revisions 1 to 4, each with a job
+	opts := values.Options{
+		Values: []string{"tasks={http}", "http.url=https://httpbin.org/get", "runner=job"},
+	}
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, kd.Launch(base.CompletePath("../", "charts/iter8"), opts, kd.Group, false))
+		_, err := kd.Clientset.BatchV1().Jobs(kd.Namespace()).Create(context.Background(), &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("%v-%v-job", kd.Group, i),
+				Labels: map[string]string{
+					managedByKey: managedByValue,
+					groupKey:     kd.Group,
+					revisionKey:  fmt.Sprint(i),
+				},
+			},
+		}, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	// a job of another group
+	_, err := kd.Clientset.BatchV1().Jobs(kd.Namespace()).Create(context.Background(), &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "other-1-job",
+			Labels: map[string]string{managedByKey: managedByValue, groupKey: "other", revisionKey: "1"},
+		},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// a policy is required
+	_, err = kd.Prune(RetentionPolicy{}, false)
+	assert.Error(t, err)
+
+	// dry run
+	deleted, err := kd.Prune(RetentionPolicy{Keep: 2}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"revision/2", "revision/1", "job/default-1-job", "job/default-2-job"}, deleted)
+	hist, _ := kd.Releases.History(kd.Group)
+	assert.Equal(t, 4, len(hist))
+
+	// recent revisions are not pruned by age
+	deleted, err = kd.Prune(RetentionPolicy{Keep: 2, OlderThan: 24 * time.Hour}, false)
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	deleted, err = kd.Prune(RetentionPolicy{Keep: 2}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(deleted))
+	hist, _ = kd.Releases.History(kd.Group)
+	assert.Equal(t, 2, len(hist))
+	jobs, _ := kd.Clientset.BatchV1().Jobs(kd.Namespace()).List(context.Background(), metav1.ListOptions{})
+	names := []string{}
+	for _, j := range jobs.Items {
+		names = append(names, j.Name)
+	}
+	assert.ElementsMatch(t, []string{"default-3-job", "default-4-job", "other-1-job"}, names)
+
+	// retention policy set at launch
+	opts.Values = append(opts.Values, "retention.keep=1", "retention.olderThan=0d")
+	assert.NoError(t, kd.Launch(base.CompletePath("../", "charts/iter8"), opts, kd.Group, false))
+	hist, _ = kd.Releases.History(kd.Group)
+	assert.Equal(t, 1, len(hist))
+	assert.Equal(t, 5, hist[0].Version)
+	jobs, _ = kd.Clientset.BatchV1().Jobs(kd.Namespace()).List(context.Background(), metav1.ListOptions{})
+	assert.Equal(t, 1, len(jobs.Items))
+
+	// unknown groups cannot be pruned
+	kd.Group = "unknown"
+	_, err = kd.Prune(RetentionPolicy{Keep: 1}, false)
+	assert.Error(t, err)
+}
+
+func TestParseAge(t *testing.T) {
+	for s, d := range map[string]time.Duration{
+		"30d":  30 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"12h":  12 * time.Hour,
+		"90m":  90 * time.Minute,
+	} {
+		got, err := ParseAge(s)
+		assert.NoError(t, err)
+		assert.Equal(t, d, got)
+	}
+	for _, s := range []string{"", "d", "-1d", "30 days", "-5h"} {
+		_, err := ParseAge(s)
+		assert.Error(t, err)
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	p, err := retentionPolicy(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+
+	p, err = retentionPolicy(map[string]interface{}{"retention": map[string]interface{}{"keep": float64(3), "olderThan": "7d"}})
+	assert.NoError(t, err)
+	assert.Equal(t, RetentionPolicy{Keep: 3, OlderThan: 7 * 24 * time.Hour}, *p)
+
+	_, err = retentionPolicy(map[string]interface{}{"retention": map[string]interface{}{"keep": 0}})
+	assert.Error(t, err)
+}
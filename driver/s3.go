@@ -0,0 +1,96 @@
+package driver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/iter8-tools/iter8/base/aws"
+)
+
+// hmacSHA256 returns the HMAC-SHA256 of the data using the key
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sha256Hex returns the hex encoded SHA256 hash of the data
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// s3Client gets and puts objects in an Amazon S3 bucket
+type s3Client struct {
+	bucket     string
+	region     string
+	httpClient *http.Client
+
+	// mu protects creds
+	mu    sync.Mutex
+	creds *aws.Credentials
+}
+
+// newS3Client creates and returns a new client for the bucket
+func newS3Client(bucket string, httpClient *http.Client) *s3Client {
+	return &s3Client{
+		bucket:     bucket,
+		region:     aws.Region(),
+		httpClient: httpClient,
+	}
+}
+
+// credentials returns the AWS credentials; temporary credentials are refreshed before they expire
+func (s *s3Client) credentials() (*aws.Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.creds == nil || s.creds.Expired() {
+		creds, err := aws.GetCredentials(s.httpClient, s.region)
+		if err != nil {
+			return nil, err
+		}
+		s.creds = creds
+	}
+	return s.creds, nil
+}
+
+// objectURL returns the URL of the object;
+// virtual-hosted style is used with AWS, and path style with custom endpoints
+func (s *s3Client) objectURL(key string) string {
+	if endpoint := aws.Endpoint("s3"); endpoint != "" {
+		return fmt.Sprintf("%v/%v/%v", endpoint, s.bucket, aws.URIEncode(key, false))
+	}
+	return fmt.Sprintf("https://%v.s3.%v.amazonaws.com/%v", s.bucket, s.region, aws.URIEncode(key, false))
+}
+
+// do signs and sends the request
+func (s *s3Client) do(method string, key string, payload []byte) ([]byte, error) {
+	creds, err := s.credentials()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, s.objectURL(key), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/yaml")
+	}
+	aws.SignV4(req, payload, creds, s.region, "s3", time.Now())
+	return doObjectRequest(s.httpClient, req)
+}
+
+func (s *s3Client) get(key string) ([]byte, error) {
+	return s.do(http.MethodGet, key, nil)
+}
+
+func (s *s3Client) put(key string, b []byte) error {
+	_, err := s.do(http.MethodPut, key, b)
+	return err
+}
@@ -0,0 +1,359 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SecretStorage stores experiments in Kubernetes secrets
+	SecretStorage = "secret"
+	// ConfigMapStorage stores experiments in Kubernetes config maps
+	ConfigMapStorage = "configmap"
+	// CustomResourceStorage stores experiments in Iter8 Experiment custom resources
+	CustomResourceStorage = "cr"
+)
+
+// StorageKinds are the supported kinds of Kubernetes experiment storage
+var StorageKinds = []string{SecretStorage, ConfigMapStorage, CustomResourceStorage}
+
+// experimentGVR identifies the Iter8 Experiment custom resource
+var experimentGVR = schema.GroupVersionResource{
+	Group:    "iter8.tools",
+	Version:  "v1alpha1",
+	Resource: "experiments",
+}
+
+// experimentRecord is an experiment as stored in a Kubernetes object
+type experimentRecord struct {
+	// Name of the object
+	Name string
+	// Labels of the object
+	Labels map[string]string
+	// Annotations of the object
+	Annotations map[string]string
+	// Data is the experiment in YAML
+	Data []byte
+	// ResourceVersion of the object
+	ResourceVersion string
+	// UID of the object; set when the record is read
+	UID types.UID
+}
+
+// experimentStore reads and writes experiment records in Kubernetes objects of a single kind
+type experimentStore interface {
+	// kind of Kubernetes object used to store experiments
+	kind() string
+	// get the experiment record with the given name
+	get(ctx context.Context, name string) (*experimentRecord, error)
+	// create a new experiment record; the resource version of the record is set to that of the created object
+	create(ctx context.Context, r *experimentRecord) error
+	// update an existing experiment record; the update fails with a conflict
+	// if the resource version of the record is set and is not the latest.
+	// The resource version of the record is set to that of the updated object.
+	update(ctx context.Context, r *experimentRecord) error
+	// list all experiment records
+	list(ctx context.Context) ([]*experimentRecord, error)
+	// delete the experiment record with the given name
+	delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	// watch the object with the given name
+	watch(ctx context.Context, name string) (watch.Interface, error)
+}
+
+// nameSelector selects the object with the given name
+func nameSelector(name string) metav1.ListOptions {
+	return metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String()}
+}
+
+// validateStorage returns an error if the kind of storage is not supported
+func validateStorage(kind string) error {
+	switch kind {
+	case "", SecretStorage, ConfigMapStorage, CustomResourceStorage:
+		return nil
+	default:
+		return fmt.Errorf("unknown storage %v; must be one of %v", kind, StorageKinds)
+	}
+}
+
+// newExperimentStore returns the store of the given kind in the given namespace;
+// experiments are stored in secrets by default
+func newExperimentStore(kind string, cs kubernetes.Interface, dc dynamic.Interface, ns string) experimentStore {
+	switch kind {
+	case ConfigMapStorage:
+		return &configMapStore{cs: cs, ns: ns}
+	case CustomResourceStorage:
+		return &customResourceStore{dc: dc, ns: ns}
+	default:
+		return &secretStore{cs: cs, ns: ns}
+	}
+}
+
+// secretStore stores experiments in secrets
+type secretStore struct {
+	cs kubernetes.Interface
+	ns string
+}
+
+func (s *secretStore) kind() string {
+	return SecretStorage
+}
+
+func (s *secretStore) get(ctx context.Context, name string) (*experimentRecord, error) {
+	sec, err := s.cs.CoreV1().Secrets(s.ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &experimentRecord{
+		Name:            sec.Name,
+		Labels:          sec.Labels,
+		Annotations:     sec.Annotations,
+		Data:            sec.Data[ExperimentPath],
+		ResourceVersion: sec.ResourceVersion,
+		UID:             sec.UID,
+	}, nil
+}
+
+// toSecret forms the secret corresponding to the experiment record
+func (s *secretStore) toSecret(r *experimentRecord) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            r.Name,
+			Labels:          r.Labels,
+			Annotations:     r.Annotations,
+			ResourceVersion: r.ResourceVersion,
+		},
+		StringData: map[string]string{ExperimentPath: string(r.Data)},
+	}
+}
+
+func (s *secretStore) create(ctx context.Context, r *experimentRecord) error {
+	obj, err := s.cs.CoreV1().Secrets(s.ns).Create(ctx, s.toSecret(r), metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	r.ResourceVersion = obj.ResourceVersion
+	return nil
+}
+
+func (s *secretStore) update(ctx context.Context, r *experimentRecord) error {
+	obj, err := s.cs.CoreV1().Secrets(s.ns).Update(ctx, s.toSecret(r), metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	r.ResourceVersion = obj.ResourceVersion
+	return nil
+}
+
+func (s *secretStore) list(ctx context.Context) ([]*experimentRecord, error) {
+	secrets, err := s.cs.CoreV1().Secrets(s.ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	records := []*experimentRecord{}
+	for _, sec := range secrets.Items {
+		records = append(records, &experimentRecord{Name: sec.Name, Labels: sec.Labels, Annotations: sec.Annotations})
+	}
+	return records, nil
+}
+
+func (s *secretStore) delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return s.cs.CoreV1().Secrets(s.ns).Delete(ctx, name, opts)
+}
+
+func (s *secretStore) watch(ctx context.Context, name string) (watch.Interface, error) {
+	return s.cs.CoreV1().Secrets(s.ns).Watch(ctx, nameSelector(name))
+}
+
+// configMapStore stores experiments in config maps
+type configMapStore struct {
+	cs kubernetes.Interface
+	ns string
+}
+
+func (s *configMapStore) kind() string {
+	return ConfigMapStorage
+}
+
+func (s *configMapStore) get(ctx context.Context, name string) (*experimentRecord, error) {
+	cm, err := s.cs.CoreV1().ConfigMaps(s.ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	r := &experimentRecord{
+		Name:            cm.Name,
+		Labels:          cm.Labels,
+		Annotations:     cm.Annotations,
+		ResourceVersion: cm.ResourceVersion,
+		UID:             cm.UID,
+	}
+	if d, ok := cm.Data[ExperimentPath]; ok {
+		r.Data = []byte(d)
+	}
+	return r, nil
+}
+
+// toConfigMap forms the config map corresponding to the experiment record
+func (s *configMapStore) toConfigMap(r *experimentRecord) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            r.Name,
+			Labels:          r.Labels,
+			Annotations:     r.Annotations,
+			ResourceVersion: r.ResourceVersion,
+		},
+		Data: map[string]string{ExperimentPath: string(r.Data)},
+	}
+}
+
+func (s *configMapStore) create(ctx context.Context, r *experimentRecord) error {
+	obj, err := s.cs.CoreV1().ConfigMaps(s.ns).Create(ctx, s.toConfigMap(r), metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	r.ResourceVersion = obj.ResourceVersion
+	return nil
+}
+
+func (s *configMapStore) update(ctx context.Context, r *experimentRecord) error {
+	obj, err := s.cs.CoreV1().ConfigMaps(s.ns).Update(ctx, s.toConfigMap(r), metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	r.ResourceVersion = obj.ResourceVersion
+	return nil
+}
+
+func (s *configMapStore) list(ctx context.Context) ([]*experimentRecord, error) {
+	cms, err := s.cs.CoreV1().ConfigMaps(s.ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	records := []*experimentRecord{}
+	for _, cm := range cms.Items {
+		records = append(records, &experimentRecord{Name: cm.Name, Labels: cm.Labels, Annotations: cm.Annotations})
+	}
+	return records, nil
+}
+
+func (s *configMapStore) delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return s.cs.CoreV1().ConfigMaps(s.ns).Delete(ctx, name, opts)
+}
+
+func (s *configMapStore) watch(ctx context.Context, name string) (watch.Interface, error) {
+	return s.cs.CoreV1().ConfigMaps(s.ns).Watch(ctx, nameSelector(name))
+}
+
+// customResourceStore stores experiments in Iter8 Experiment custom resources.
+// The spec and result of the experiment are the spec and result fields of the custom resource.
+type customResourceStore struct {
+	dc dynamic.Interface
+	ns string
+}
+
+func (s *customResourceStore) kind() string {
+	return "experiment"
+}
+
+func (s *customResourceStore) get(ctx context.Context, name string) (*experimentRecord, error) {
+	obj, err := s.dc.Resource(experimentGVR).Namespace(s.ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]interface{}{}
+	for _, field := range []string{"spec", "hooks", "background", "loop", "result"} {
+		if v, ok := obj.Object[field]; ok {
+			content[field] = v
+		}
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return &experimentRecord{
+		Name:            obj.GetName(),
+		Labels:          obj.GetLabels(),
+		Annotations:     obj.GetAnnotations(),
+		Data:            b,
+		ResourceVersion: obj.GetResourceVersion(),
+		UID:             obj.GetUID(),
+	}, nil
+}
+
+// toUnstructured forms the custom resource corresponding to the experiment record
+func (s *customResourceStore) toUnstructured(r *experimentRecord) (*unstructured.Unstructured, error) {
+	content := map[string]interface{}{}
+	if err := yaml.Unmarshal(r.Data, &content); err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for _, field := range []string{"spec", "hooks", "background", "loop", "result"} {
+		if v, ok := content[field]; ok {
+			obj.Object[field] = v
+		}
+	}
+	obj.SetAPIVersion(experimentGVR.GroupVersion().String())
+	obj.SetKind("Experiment")
+	obj.SetName(r.Name)
+	obj.SetLabels(r.Labels)
+	obj.SetAnnotations(r.Annotations)
+	obj.SetResourceVersion(r.ResourceVersion)
+	return obj, nil
+}
+
+func (s *customResourceStore) create(ctx context.Context, r *experimentRecord) error {
+	obj, err := s.toUnstructured(r)
+	if err != nil {
+		return err
+	}
+	obj, err = s.dc.Resource(experimentGVR).Namespace(s.ns).Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	r.ResourceVersion = obj.GetResourceVersion()
+	return nil
+}
+
+func (s *customResourceStore) update(ctx context.Context, r *experimentRecord) error {
+	obj, err := s.toUnstructured(r)
+	if err != nil {
+		return err
+	}
+	obj, err = s.dc.Resource(experimentGVR).Namespace(s.ns).Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	r.ResourceVersion = obj.GetResourceVersion()
+	return nil
+}
+
+func (s *customResourceStore) list(ctx context.Context) ([]*experimentRecord, error) {
+	objs, err := s.dc.Resource(experimentGVR).Namespace(s.ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	records := []*experimentRecord{}
+	for _, obj := range objs.Items {
+		records = append(records, &experimentRecord{Name: obj.GetName(), Labels: obj.GetLabels(), Annotations: obj.GetAnnotations()})
+	}
+	return records, nil
+}
+
+func (s *customResourceStore) delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return s.dc.Resource(experimentGVR).Namespace(s.ns).Delete(ctx, name, opts)
+}
+
+func (s *customResourceStore) watch(ctx context.Context, name string) (watch.Interface, error) {
+	return s.dc.Resource(experimentGVR).Namespace(s.ns).Watch(ctx, nameSelector(name))
+}
@@ -0,0 +1,96 @@
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// testStorage reads, writes, aborts, and cleans up an experiment stored using the driver
+func testStorage(t *testing.T, kd *KubeDriver, kind string) {
+	assert.NoError(t, kd.InitKube())
+	kd.revision = 1
+
+	exp, err := kd.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(exp.Spec))
+
+	exp.Result = &base.ExperimentResult{NumCompletedTasks: 2}
+	assert.NoError(t, kd.Write(exp))
+	exp, err = kd.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, exp.Result.NumCompletedTasks)
+
+	assert.NoError(t, kd.Abort())
+	assert.True(t, kd.AbortRequested())
+	// writes preserve abort requests
+	assert.NoError(t, kd.Write(exp))
+	assert.True(t, kd.AbortRequested())
+	assert.NoError(t, kd.ClearAbort())
+	assert.False(t, kd.AbortRequested())
+
+	exp, err = kd.ReadExperiment("default", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, exp.Result.NumCompletedTasks)
+
+	deleted, err := kd.CleanupExperiment(false)
+	assert.NoError(t, err)
+	assert.Contains(t, deleted, kind+"/default")
+	_, err = kd.Read()
+	assert.Error(t, err)
+}
+
+func TestConfigMapStorage(t *testing.T) {
+	os.Chdir(t.TempDir())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/drivertests", ExperimentPath))
+	kd := NewFakeKubeDriver(cli.New(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default", Annotations: map[string]string{groupKey: "default"}},
+		Data:       map[string]string{ExperimentPath: string(byteArray)},
+	})
+	kd.Storage = ConfigMapStorage
+	testStorage(t, kd, "configmap")
+}
+
+func TestCustomResourceStorage(t *testing.T) {
+	os.Chdir(t.TempDir())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/drivertests", ExperimentPath))
+	content := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal(byteArray, &content))
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": content["spec"]}}
+	obj.SetAPIVersion("iter8.tools/v1alpha1")
+	obj.SetKind("Experiment")
+	obj.SetName("default")
+	obj.SetNamespace("default")
+	obj.SetAnnotations(map[string]string{groupKey: "default"})
+
+	kd := NewFakeKubeDriver(cli.New())
+	kd.dynamicClient = newFakeDynamicClient(obj)
+	kd.Storage = CustomResourceStorage
+
+	// spec and result are fields of the custom resource
+	exp, err := kd.Read()
+	assert.NoError(t, err)
+	exp.Result = &base.ExperimentResult{NumCompletedTasks: 1}
+	assert.NoError(t, kd.Write(exp))
+	obj, err = kd.dynamicClient.Resource(experimentGVR).Namespace("default").Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, obj.Object, "spec")
+	assert.Contains(t, obj.Object, "result")
+
+	testStorage(t, kd, "experiment")
+}
+
+func TestInvalidStorage(t *testing.T) {
+	kd := NewFakeKubeDriver(cli.New())
+	kd.Storage = "database"
+	assert.Error(t, kd.InitKube())
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/iter8-tools/iter8/base/log"
@@ -17,13 +18,30 @@ import (
 	"helm.sh/helm/v3/pkg/storage"
 	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
 	ktesting "k8s.io/client-go/testing"
 )
 
 // initKubeFake initialize the Kube clientset with a fake
 func initKubeFake(kd *KubeDriver, objects ...runtime.Object) {
+	kd.Clientset = newFakeClientset(objects...)
+	kd.dynamicClient = newFakeDynamicClient()
+}
+
+// newFakeDynamicClient creates a fake Kube dynamic client that knows Iter8 Experiment custom resources
+func newFakeDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		experimentGVR: "ExperimentList",
+	}, objects...)
+}
+
+// newFakeClientset creates a fake Kube clientset that populates secret data from string data
+func newFakeClientset(objects ...runtime.Object) *fake.Clientset {
 	// secretDataReactor sets the secret.Data field based on the values from secret.StringData
 	// Credit: this function is adapted from https://github.com/creydr/go-k8s-utils
 	var secretDataReactor = func(action ktesting.Action) (bool, runtime.Object, error) {
@@ -41,9 +59,35 @@ func initKubeFake(kd *KubeDriver, objects ...runtime.Object) {
 	}
 
 	fc := fake.NewSimpleClientset(objects...)
+
+	// resourceVersionReactor emulates the optimistic concurrency of the Kubernetes API server;
+	// objects are versioned, and updates of objects that are not at the latest version fail with a conflict
+	var resourceVersionReactor = func(action ktesting.Action) (bool, runtime.Object, error) {
+		obj, _ := meta.Accessor(action.(ktesting.CreateAction).GetObject())
+		if action.GetVerb() == "create" {
+			obj.SetResourceVersion("1")
+			return false, nil, nil
+		}
+		cur, err := fc.Tracker().Get(action.GetResource(), action.GetNamespace(), obj.GetName())
+		if err != nil {
+			return false, nil, nil
+		}
+		curObj, _ := meta.Accessor(cur)
+		if obj.GetResourceVersion() != "" && obj.GetResourceVersion() != curObj.GetResourceVersion() {
+			return true, nil, kerrors.NewConflict(action.GetResource().GroupResource(), obj.GetName(), errors.New("the object has been modified"))
+		}
+		v, _ := strconv.Atoi(curObj.GetResourceVersion())
+		obj.SetResourceVersion(strconv.Itoa(v + 1))
+		return false, nil, nil
+	}
+
+	for _, resource := range []string{"secrets", "configmaps"} {
+		fc.PrependReactor("create", resource, resourceVersionReactor)
+		fc.PrependReactor("update", resource, resourceVersionReactor)
+	}
 	fc.PrependReactor("create", "secrets", secretDataReactor)
 	fc.PrependReactor("update", "secrets", secretDataReactor)
-	kd.Clientset = fc
+	return fc
 }
 
 // initHelmFake initializes the Helm config with a fake
@@ -77,6 +121,7 @@ func NewFakeKubeDriver(s *cli.EnvSettings, objects ...runtime.Object) *KubeDrive
 	kd := &KubeDriver{
 		EnvSettings: s,
 		Group:       DefaultExperimentGroup,
+		Storage:     SecretStorage,
 	}
 	initFake(kd, objects...)
 	return kd
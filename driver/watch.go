@@ -0,0 +1,89 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/yaml"
+)
+
+// WatchExperiment calls onChange with the experiment when the watch starts, and whenever the experiment changes,
+// until the context is done. The experiment is watched in the secret (or other object) storing it;
+// changes to the object that do not change the experiment, such as abort requests, are ignored.
+// If the watch ends, for example, because of a timeout of the API server, it is restarted.
+// Errors returned by onChange end the watch, as does the deletion of the experiment.
+func (driver *KubeDriver) WatchExperiment(ctx context.Context, onChange func(*base.Experiment) error) error {
+	name := driver.getExperimentSecretName()
+	var last []byte
+	changed := func() error {
+		exp, err := driver.Read()
+		if err != nil {
+			return err
+		}
+		b, err := yaml.Marshal(exp)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(b, last) {
+			return nil
+		}
+		last = b
+		return onChange(exp)
+	}
+
+	for {
+		if err := changed(); err != nil {
+			return err
+		}
+		w, err := driver.resultStore().watch(ctx, name)
+		if err != nil {
+			log.Logger.WithStackTrace(err.Error()).Warn("unable to watch experiment; retrying")
+		} else {
+			err = watchObject(ctx, w, name, changed)
+			w.Stop()
+			if err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(resyncInterval):
+		}
+	}
+}
+
+// watchObject calls changed whenever the object with the given name is added or modified,
+// until the watch ends or the context is done
+func watchObject(ctx context.Context, w watch.Interface, name string, changed func() error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			obj, err := meta.Accessor(ev.Object)
+			if err != nil || obj.GetName() != name {
+				continue
+			}
+			switch ev.Type {
+			case watch.Added, watch.Modified:
+				if err := changed(); err != nil {
+					return err
+				}
+			case watch.Deleted:
+				e := fmt.Errorf("experiment %v was deleted", name)
+				log.Logger.Error(e)
+				return e
+			}
+		}
+	}
+}
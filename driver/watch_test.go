@@ -0,0 +1,87 @@
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchExperiment(t *testing.T) {
+	os.Chdir(t.TempDir())
+	byteArray, _ := ioutil.ReadFile(base.CompletePath("../testdata/drivertests", ExperimentPath))
+	kd := NewFakeKubeDriver(cli.New(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"},
+		Data:       map[string][]byte{ExperimentPath: byteArray},
+	})
+	assert.NoError(t, kd.InitKube())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exps := make(chan *base.Experiment, 10)
+	done := make(chan error)
+	go func() {
+		done <- kd.WatchExperiment(ctx, func(e *base.Experiment) error {
+			exps <- e
+			return nil
+		})
+	}()
+
+	// the experiment is reported when the watch starts
+	first := receive(t, exps)
+	assert.NotNil(t, first)
+
+	// and when it changes
+	writer := *kd
+	exp, err := writer.Read()
+	assert.NoError(t, err)
+	if exp.Result == nil {
+		exp.Result = &base.ExperimentResult{}
+	}
+	exp.Result.NumCompletedTasks++
+	assert.NoError(t, writer.Write(exp))
+	second := receive(t, exps)
+	assert.NotNil(t, second.Result)
+	assert.Equal(t, exp.Result.NumCompletedTasks, second.Result.NumCompletedTasks)
+
+	// changes that do not change the experiment are ignored
+	assert.NoError(t, writer.Abort())
+	select {
+	case <-exps:
+		assert.Fail(t, "unchanged experiment was reported")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	// the watch ends when the experiment is deleted
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		done <- kd.WatchExperiment(ctx, func(e *base.Experiment) error {
+			exps <- e
+			return nil
+		})
+	}()
+	receive(t, exps)
+	assert.NoError(t, kd.Clientset.CoreV1().Secrets("default").Delete(context.TODO(), "default", metav1.DeleteOptions{}))
+	assert.Error(t, <-done)
+}
+
+// receive returns the next experiment, or fails after a timeout
+func receive(t *testing.T, exps chan *base.Experiment) *base.Experiment {
+	select {
+	case e := <-exps:
+		return e
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "experiment was not reported")
+		return nil
+	}
+}
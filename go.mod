@@ -25,14 +25,18 @@ require (
 	github.com/hashicorp/go-getter v1.6.1
 	github.com/itchyny/gojq v0.12.7
 	github.com/jarcoal/httpmock v1.1.0
+	github.com/lib/pq v1.10.4
 	github.com/mattn/go-shellwords v1.0.12
 	github.com/mcuadros/go-defaults v1.2.0
+	github.com/mitchellh/copystructure v1.2.0
 	github.com/montanaflynn/stats v0.6.6
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.4.0
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.7.1
+	github.com/xeipuuv/gojsonschema v1.2.0
+	go.starlark.net v0.0.0-20200306205701-8dd3e2ee1dd5
 	golang.org/x/net v0.0.0-20220420153159-1850ba15e1be
 	google.golang.org/grpc v1.45.0
 	google.golang.org/protobuf v1.28.0
@@ -40,5 +44,6 @@ require (
 	k8s.io/api v0.23.6
 	k8s.io/apimachinery v0.23.6
 	k8s.io/client-go v0.23.6
+	modernc.org/sqlite v1.14.8
 	sigs.k8s.io/yaml v1.3.0
 )
@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"errors"
+	"math"
+
+	"github.com/iter8-tools/iter8/base"
+)
+
+// Experiment is an experiment built by the SDK.
+// Its result, including insights, is available through the embedded Iter8 experiment after it runs.
+type Experiment struct {
+	*base.Experiment
+}
+
+// Run runs the experiment in memory; its result is reset before it runs
+func (e *Experiment) Run() error {
+	if e == nil || e.Experiment == nil {
+		return errors.New("nil experiment")
+	}
+	return base.RunExperiment(false, &Driver{Experiment: e.Experiment})
+}
+
+// Passed returns true if the experiment completed without failure, and all versions satisfy SLOs
+func (e *Experiment) Passed() bool {
+	return e.Completed() && e.NoFailure() && e.SLOs()
+}
+
+// MetricValue returns the value of the given scalar metric for the given version,
+// and false if the value is unavailable
+func (e *Experiment) MetricValue(name string, version int) (float64, bool) {
+	v := e.Metric(name, version)
+	return v, !math.IsNaN(v)
+}
+
+// Driver stores an experiment in memory. It enables experiments to be run by Go programs and tests.
+type Driver struct {
+	// Experiment is the stored experiment
+	*base.Experiment
+}
+
+// Read the experiment
+func (d *Driver) Read() (*base.Experiment, error) {
+	if d.Experiment == nil {
+		return nil, errors.New("no experiment in driver")
+	}
+	return d.Experiment, nil
+}
+
+// Write the experiment
+func (d *Driver) Write(e *base.Experiment) error {
+	d.Experiment = e
+	return nil
+}
+
+// GetRevision returns the experiment revision; experiments in memory have a single revision
+func (d *Driver) GetRevision() int {
+	return 0
+}
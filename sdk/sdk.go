@@ -0,0 +1,228 @@
+// Package sdk enables Go programs and tests to construct, run, and inspect Iter8 experiments,
+// without YAML, charts, or the CLI.
+//
+// For example, the following experiment generates load for an HTTP service, and validates its SLOs.
+//
+//	exp, err := sdk.NewExperiment().
+//		WithHTTPTask("http://httpbin.default/get", &sdk.HTTPOptions{NumRequests: 100}).
+//		WithSLOs(sdk.Upper("http/latency-mean", 50), sdk.Upper("http/error-rate", 0)).
+//		Run()
+//	if err == nil && exp.Passed() {
+//		// all versions satisfy SLOs
+//	}
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/iter8-tools/iter8/base"
+)
+
+// Builder constructs an experiment; tasks run in the order in which they are added
+type Builder struct {
+	// tasks are the tasks of the experiment in their JSON form
+	tasks []map[string]interface{}
+	// loop is the loop spec of the experiment
+	loop *base.LoopSpec
+	// err is the first error encountered while building
+	err error
+}
+
+// NewExperiment returns a builder for a new experiment
+func NewExperiment() *Builder {
+	return &Builder{}
+}
+
+// HTTPOptions are the options of the http task. Zero values are unspecified, and use the defaults of the task.
+type HTTPOptions struct {
+	// NumRequests is the number of requests to be sent
+	NumRequests int64 `json:"numRequests,omitempty"`
+	// Duration of the load test; for example, 30s. Used only if NumRequests is unspecified
+	Duration string `json:"duration,omitempty"`
+	// QPS is the number of queries per second
+	QPS float32 `json:"qps,omitempty"`
+	// Connections is the number of parallel connections used to send requests
+	Connections int `json:"connections,omitempty"`
+	// PayloadStr is the string data to be sent as the payload of requests
+	PayloadStr string `json:"payloadStr,omitempty"`
+	// ContentType is the type of the payload
+	ContentType string `json:"contentType,omitempty"`
+	// Headers are the HTTP headers of requests
+	Headers map[string]string `json:"headers,omitempty"`
+	// Percentiles are the latency percentiles computed by the task
+	Percentiles []float64 `json:"percentiles,omitempty"`
+}
+
+// GRPCOptions are the options of the grpc task. Zero values are unspecified, and use the defaults of the task.
+type GRPCOptions struct {
+	// Proto is the path of the protocol buffer file describing the service; unnecessary if the service supports reflection
+	Proto string `json:"proto,omitempty"`
+	// Data is the request message
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Total is the number of requests to be sent
+	Total int `json:"total,omitempty"`
+	// Concurrency is the number of workers sending requests
+	Concurrency int `json:"concurrency,omitempty"`
+	// RPS is the number of requests per second
+	RPS int `json:"rps,omitempty"`
+}
+
+// RunOptions are the options of the run task
+type RunOptions struct {
+	// Language of the script; bash or starlark. Default is bash
+	Language string `json:"language,omitempty"`
+	// Metrics enables the script to contribute metrics, by printing lines with JSON documents to stdout;
+	// these are recorded under the run backend, for example, run/throughput
+	Metrics bool `json:"metrics,omitempty"`
+}
+
+// SLO is an upper or lower limit of a metric
+type SLO struct {
+	// Metric is the fully qualified metric name in the backendName/metricName format
+	Metric string
+	// Limit of the metric
+	Limit float64
+	// Lower is true if the limit is a lower limit
+	Lower bool
+}
+
+// Upper returns an SLO with an upper limit for the metric
+func Upper(metric string, limit float64) SLO {
+	return SLO{Metric: metric, Limit: limit}
+}
+
+// Lower returns an SLO with a lower limit for the metric
+func Lower(metric string, limit float64) SLO {
+	return SLO{Metric: metric, Limit: limit, Lower: true}
+}
+
+// WithTask adds a task with the given name and inputs.
+// This enables tasks without builder methods, including plugin tasks, to be used.
+func (b *Builder) WithTask(name string, inputs interface{}) *Builder {
+	t := map[string]interface{}{"task": name}
+	if inputs != nil {
+		t["with"] = inputs
+	}
+	b.tasks = append(b.tasks, t)
+	return b
+}
+
+// WithHTTPTask adds an http task, which generates load for the given URL and collects built-in HTTP metrics.
+// Options are optional.
+func (b *Builder) WithHTTPTask(url string, opts *HTTPOptions) *Builder {
+	with, err := toMap(opts)
+	if err != nil {
+		return b.fail(err)
+	}
+	with["url"] = url
+	return b.WithTask(base.CollectHTTPTaskName, with)
+}
+
+// WithGRPCTask adds a grpc task, which generates load for the given call of the service at host,
+// and collects built-in gRPC metrics. Options are optional.
+func (b *Builder) WithGRPCTask(host string, call string, opts *GRPCOptions) *Builder {
+	with, err := toMap(opts)
+	if err != nil {
+		return b.fail(err)
+	}
+	with["host"] = host
+	with["call"] = call
+	return b.WithTask(base.CollectGRPCTaskName, with)
+}
+
+// WithRunTask adds a run task, which runs the given script. Options are optional.
+func (b *Builder) WithRunTask(script string, opts *RunOptions) *Builder {
+	t := map[string]interface{}{"run": script}
+	if opts != nil {
+		with, err := toMap(opts)
+		if err != nil {
+			return b.fail(err)
+		}
+		t["with"] = with
+	}
+	b.tasks = append(b.tasks, t)
+	return b
+}
+
+// WithSLOs adds an assess task, which validates the given SLOs using metrics collected by earlier tasks
+func (b *Builder) WithSLOs(slos ...SLO) *Builder {
+	if len(slos) == 0 {
+		return b.fail(errors.New("at least one SLO is required"))
+	}
+	limits := base.SLOLimits{}
+	for _, s := range slos {
+		if s.Lower {
+			limits.Lower = append(limits.Lower, base.SLO{Metric: s.Metric, Limit: s.Limit})
+		} else {
+			limits.Upper = append(limits.Upper, base.SLO{Metric: s.Metric, Limit: s.Limit})
+		}
+	}
+	return b.WithTask(base.AssessTaskName, map[string]interface{}{"SLOs": limits})
+}
+
+// WithLoop runs the tasks of the experiment in loops
+func (b *Builder) WithLoop(loop base.LoopSpec) *Builder {
+	b.loop = &loop
+	return b
+}
+
+// fail records the first error encountered while building
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Build returns the experiment, or an error if it is invalid
+func (b *Builder) Build() (*Experiment, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.tasks) == 0 {
+		return nil, errors.New("experiment has no tasks")
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"spec": b.tasks,
+		"loop": b.loop,
+	})
+	if err != nil {
+		return nil, err
+	}
+	exp := &base.Experiment{}
+	if err := json.Unmarshal(data, exp); err != nil {
+		return nil, err
+	}
+	if errs := base.ValidateExperiment(exp); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid experiment: %v", errs)
+	}
+	return &Experiment{Experiment: exp}, nil
+}
+
+// Run builds and runs the experiment. The experiment is returned along with any error,
+// so that its result can be inspected after a task fails.
+func (b *Builder) Run() (*Experiment, error) {
+	exp, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return exp, exp.Run()
+}
+
+// toMap returns the JSON form of the options
+func toMap(opts interface{}) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m, nil
+}
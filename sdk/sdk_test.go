@@ -0,0 +1,66 @@
+package sdk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPExperiment(t *testing.T) {
+	os.Chdir(t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	exp, err := NewExperiment().
+		WithHTTPTask(srv.URL, &HTTPOptions{NumRequests: 10, Headers: map[string]string{"X-Test": "sdk"}}).
+		WithSLOs(Upper("http/error-rate", 0), Upper("http/latency-mean", 1000)).
+		Run()
+	assert.NoError(t, err)
+	assert.True(t, exp.Passed())
+	v, ok := exp.MetricValue("http/request-count", 0)
+	assert.True(t, ok)
+	assert.Equal(t, float64(10), v)
+	_, ok = exp.MetricValue("http/missing", 0)
+	assert.False(t, ok)
+}
+
+func TestRunExperimentLoops(t *testing.T) {
+	os.Chdir(t.TempDir())
+	exp, err := NewExperiment().
+		WithRunTask(`echo '{"name": "throughput", "value": 100}'`, &RunOptions{Metrics: true}).
+		WithSLOs(Lower("run/throughput", 200)).
+		WithLoop(base.LoopSpec{MaxLoops: 2}).
+		Run()
+	assert.NoError(t, err)
+	assert.True(t, exp.Completed())
+	assert.False(t, exp.Passed())
+	assert.Equal(t, 2, exp.NumLoops())
+	assert.Equal(t, -1, exp.Winner())
+	v, ok := exp.MetricValue("run/throughput", 0)
+	assert.True(t, ok)
+	assert.Equal(t, float64(100), v)
+
+	// failed tasks are returned along with the experiment
+	exp, err = NewExperiment().WithRunTask("exit 1", nil).Run()
+	assert.Error(t, err)
+	assert.False(t, exp.NoFailure())
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := NewExperiment().Build()
+	assert.Error(t, err)
+	_, err = NewExperiment().WithSLOs().Build()
+	assert.Error(t, err)
+	_, err = NewExperiment().WithTask("unknown", nil).Build()
+	assert.Error(t, err)
+	_, err = NewExperiment().WithGRPCTask("", "", nil).Build()
+	assert.Error(t, err)
+	_, err = NewExperiment().WithLoop(base.LoopSpec{MaxLoops: -1}).WithRunTask("echo hello", nil).Build()
+	assert.Error(t, err)
+}
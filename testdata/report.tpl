@@ -0,0 +1,8 @@
+Experiment completed: {{ .Completed }}
+{{- range $v := versions }}
+Version {{ $v }}:
+  SLOs satisfied: {{ sloSatisfied $v }}
+  {{- with metric $v "http/latency-mean" }}
+  Mean latency: {{ formatFloat 2 . }} msec
+  {{- end }}
+{{- end }}